@@ -0,0 +1,357 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	gmetrics "github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// filter with block range only, which queries from the universal event log table
+	FilterKindRange = "range"
+	// filter with single contract address
+	FilterKindContract = "contract"
+	// filter with single contract address and event signature (topic0)
+	FilterKindTopic = "topic"
+	// filter with multiple contract addresses
+	FilterKindMultiContracts = "multi"
+)
+
+// StoreBenchConfig store benchmark configuration
+type StoreBenchConfig struct {
+	WorkloadConfig
+
+	Epochs    uint64 // number of epochs to ingest
+	BatchSize int    // number of epochs per batch to ingest
+
+	Queries          int            // number of log queries to run
+	QueryConcurrency int            // number of concurrent log query workers
+	QueryBlockRange  uint64         // block range of each log query
+	FilterMix        map[string]int // log filter kind => weight
+}
+
+func (conf *StoreBenchConfig) validate() error {
+	if conf.Contracts <= 0 || conf.Topics <= 0 {
+		return errors.New("number of contracts and topics must be positive")
+	}
+
+	if conf.BlocksPerEpoch <= 0 || conf.BatchSize <= 0 {
+		return errors.New("blocks per epoch and batch size must be positive")
+	}
+
+	if conf.Queries > 0 && conf.QueryConcurrency <= 0 {
+		return errors.New("query concurrency must be positive")
+	}
+
+	for kind, weight := range conf.FilterMix {
+		switch kind {
+		case FilterKindRange, FilterKindContract, FilterKindTopic, FilterKindMultiContracts:
+		default:
+			return errors.Errorf("unknown filter kind %v", kind)
+		}
+
+		if weight < 0 {
+			return errors.Errorf("negative weight for filter kind %v", kind)
+		}
+	}
+
+	return nil
+}
+
+// StoreBenchmarker generates synthetic event log workload against the target store
+// to benchmark ingest throughput and log query latency.
+type StoreBenchmarker struct {
+	conf *StoreBenchConfig
+	db   *mysql.MysqlStore
+
+	// block number range of ingested synthetic data
+	bnRange citypes.RangeUint64
+	// contracts and topics used to generate the synthetic data
+	wl *workload
+
+	ingestTimer      gmetrics.Timer
+	ingestLogs       int64
+	ingestEpochs     int64
+	ingestDuration   time.Duration
+	queryTimers      map[string]gmetrics.Timer
+	queryResultSizes map[string]gmetrics.Histogram
+	queryErrors      map[string]*int64
+}
+
+func NewStoreBenchmarker(conf *StoreBenchConfig, db *mysql.MysqlStore) (*StoreBenchmarker, error) {
+	if err := conf.validate(); err != nil {
+		return nil, errors.WithMessage(err, "invalid config")
+	}
+
+	b := &StoreBenchmarker{
+		conf:             conf,
+		db:               db,
+		ingestTimer:      gmetrics.NewTimer(),
+		queryTimers:      make(map[string]gmetrics.Timer),
+		queryResultSizes: make(map[string]gmetrics.Histogram),
+		queryErrors:      make(map[string]*int64),
+	}
+
+	for kind := range conf.FilterMix {
+		b.queryTimers[kind] = gmetrics.NewTimer()
+		b.queryResultSizes[kind] = gmetrics.NewHistogram(gmetrics.NewExpDecaySample(1024, 0.015))
+		b.queryErrors[kind] = new(int64)
+	}
+
+	return b, nil
+}
+
+// Run ingests synthetic epoch data into store, and then runs log queries against it.
+func (b *StoreBenchmarker) Run(ctx context.Context) error {
+	if err := b.ingest(ctx); err != nil {
+		return errors.WithMessage(err, "failed to ingest synthetic data")
+	}
+
+	b.query(ctx)
+	b.report()
+
+	return nil
+}
+
+func (b *StoreBenchmarker) ingest(ctx context.Context) error {
+	startEpoch, startBn, err := b.nextEpochAndBlock()
+	if err != nil {
+		return err
+	}
+
+	b.wl = newWorkload(&b.conf.WorkloadConfig, startEpoch, startBn)
+	b.bnRange = citypes.RangeUint64{From: startBn, To: startBn}
+
+	logrus.WithFields(logrus.Fields{
+		"startEpoch": startEpoch, "startBlockNumber": startBn, "epochs": b.conf.Epochs,
+	}).Info("Start to ingest synthetic epoch data")
+
+	start := time.Now()
+	defer func() { b.ingestDuration = time.Since(start) }()
+
+	for ingested := uint64(0); ingested < b.conf.Epochs; {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		batch := make([]*store.EpochData, 0, b.conf.BatchSize)
+		for i := 0; i < b.conf.BatchSize && ingested < b.conf.Epochs; i++ {
+			batch = append(batch, b.wl.next())
+			ingested++
+		}
+
+		batchStart := time.Now()
+		if err := b.db.Pushn(batch); err != nil {
+			return errors.WithMessagef(err, "failed to push epoch data from %v", batch[0].Number)
+		}
+		b.ingestTimer.UpdateSince(batchStart)
+
+		b.ingestEpochs += int64(len(batch))
+		b.ingestLogs += int64(len(batch) * b.conf.numLogsPerEpoch())
+		b.bnRange.To = b.wl.nextBlockNumber - 1
+
+		logrus.WithFields(logrus.Fields{
+			"epoch": batch[len(batch)-1].Number, "ingestedEpochs": b.ingestEpochs,
+		}).Debug("Synthetic epoch data batch ingested")
+	}
+
+	return nil
+}
+
+// nextEpochAndBlock returns the next epoch and block number to continue ingesting.
+func (b *StoreBenchmarker) nextEpochAndBlock() (uint64, uint64, error) {
+	maxEpoch, ok, err := b.db.MaxEpoch()
+	if err != nil {
+		return 0, 0, errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	if !ok {
+		return 0, 0, nil
+	}
+
+	bnRange, ok, err := b.db.BlockRange(maxEpoch)
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "failed to get block range of epoch %v", maxEpoch)
+	}
+
+	if !ok {
+		return 0, 0, errors.Errorf("no block range found for epoch %v", maxEpoch)
+	}
+
+	return maxEpoch + 1, bnRange.To + 1, nil
+}
+
+func (b *StoreBenchmarker) query(ctx context.Context) {
+	if b.conf.Queries <= 0 || b.wl == nil || b.ingestEpochs == 0 {
+		return
+	}
+
+	kinds, weights := b.filterKinds()
+	if len(kinds) == 0 {
+		return
+	}
+
+	logrus.WithField("queries", b.conf.Queries).Info("Start to run log queries")
+
+	var wg sync.WaitGroup
+	queryCh := make(chan int)
+
+	for i := 0; i < b.conf.QueryConcurrency; i++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(b.conf.Seed + int64(worker)))
+			for range queryCh {
+				kind := pickWeighted(r, kinds, weights)
+				b.queryOnce(ctx, r, kind)
+			}
+		}(i)
+	}
+
+dispatch:
+	for i := 0; i < b.conf.Queries; i++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case queryCh <- i:
+		}
+	}
+
+	close(queryCh)
+	wg.Wait()
+}
+
+func (b *StoreBenchmarker) queryOnce(ctx context.Context, r *rand.Rand, kind string) {
+	filter := b.randLogFilter(r, kind)
+
+	ctx, cancel := rpcutil.WithStoreTimeout(ctx, "cfx_getLogs")
+	defer cancel()
+
+	start := time.Now()
+	logs, err := b.db.GetLogs(ctx, filter)
+	if err != nil {
+		atomic.AddInt64(b.queryErrors[kind], 1)
+		logrus.WithError(err).WithField("kind", kind).Debug("Failed to query synthetic event logs")
+		return
+	}
+
+	b.queryTimers[kind].UpdateSince(start)
+	b.queryResultSizes[kind].Update(int64(len(logs)))
+}
+
+// randLogFilter generates random log filter of the specified kind within the ingested block range.
+func (b *StoreBenchmarker) randLogFilter(r *rand.Rand, kind string) store.LogFilter {
+	span := b.bnRange.To - b.bnRange.From + 1
+	blockRange := b.conf.QueryBlockRange
+	if blockRange == 0 || blockRange > span {
+		blockRange = span
+	}
+
+	from := b.bnRange.From + uint64(r.Int63n(int64(span-blockRange+1)))
+	filter := store.LogFilter{BlockFrom: from, BlockTo: from + blockRange - 1}
+
+	contracts := b.wl.contracts
+	switch kind {
+	case FilterKindContract:
+		filter.Contracts = store.NewVariadicValue(contracts[r.Intn(len(contracts))].MustGetBase32Address())
+	case FilterKindTopic:
+		filter.Contracts = store.NewVariadicValue(contracts[r.Intn(len(contracts))].MustGetBase32Address())
+		filter.Topics = []store.VariadicValue{
+			store.NewVariadicValue(b.wl.topics[r.Intn(len(b.wl.topics))].String()),
+		}
+	case FilterKindMultiContracts:
+		var addrs []string
+		for _, i := range r.Perm(len(contracts)) {
+			if len(addrs) >= 3 {
+				break
+			}
+
+			addrs = append(addrs, contracts[i].MustGetBase32Address())
+		}
+		filter.Contracts = store.NewVariadicValue(addrs...)
+	}
+
+	return filter
+}
+
+// filterKinds returns the filter kinds with positive weights in a stable order.
+func (b *StoreBenchmarker) filterKinds() (kinds []string, weights []int) {
+	for kind, weight := range b.conf.FilterMix {
+		if weight > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		weights = append(weights, b.conf.FilterMix[kind])
+	}
+
+	return kinds, weights
+}
+
+func (b *StoreBenchmarker) report() {
+	fmt.Println("// ----------------- ingest ------------------")
+	fmt.Printf("     total epochs: %v\n", b.ingestEpochs)
+	fmt.Printf("       total logs: %v\n", b.ingestLogs)
+	fmt.Printf("  total durations: %.2f(ms)\n", float64(b.ingestDuration)/1e6)
+
+	if secs := b.ingestDuration.Seconds(); secs > 0 {
+		fmt.Printf("       epochs/sec: %.2f\n", float64(b.ingestEpochs)/secs)
+		fmt.Printf("         logs/sec: %.2f\n", float64(b.ingestLogs)/secs)
+	}
+
+	fmt.Printf("  max batch duration: %.2f(ms)\n", float64(b.ingestTimer.Max())/1e6)
+	fmt.Printf(" mean batch duration: %.2f(ms)\n", b.ingestTimer.Mean()/1e6)
+	fmt.Printf("  p99 batch duration: %.2f(ms)\n", b.ingestTimer.Percentile(99)/1e6)
+
+	kinds, _ := b.filterKinds()
+	for _, kind := range kinds {
+		timer := b.queryTimers[kind]
+
+		fmt.Printf("// ------------ query (%v) -------------\n", strings.ToUpper(kind))
+		fmt.Printf("      queries: %v\n", timer.Count())
+		fmt.Printf("       errors: %v\n", atomic.LoadInt64(b.queryErrors[kind]))
+		fmt.Printf("  max latency: %.2f(ms)\n", float64(timer.Max())/1e6)
+		fmt.Printf(" mean latency: %.2f(ms)\n", timer.Mean()/1e6)
+		fmt.Printf("  p99 latency: %.2f(ms)\n", timer.Percentile(99)/1e6)
+		fmt.Printf("  p75 latency: %.2f(ms)\n", timer.Percentile(75)/1e6)
+		fmt.Printf("    mean logs: %.2f\n", b.queryResultSizes[kind].Mean())
+	}
+}
+
+// pickWeighted picks a random item by weight.
+func pickWeighted(r *rand.Rand, items []string, weights []int) string {
+	var total int
+	for _, w := range weights {
+		total += w
+	}
+
+	n := r.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return items[i]
+		}
+
+		n -= w
+	}
+
+	return items[len(items)-1]
+}
@@ -0,0 +1,203 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cmptutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	hexBig0   = types.NewBigInt(0)
+	txStatus0 = hexutil.Uint64(0)
+)
+
+// WorkloadConfig synthetic chain data workload configuration
+type WorkloadConfig struct {
+	NetworkId      uint32 // network ID to generate base32 addresses
+	Seed           int64  // random seed to generate reproducible workload
+	Contracts      int    // number of contracts to emit event logs
+	Topics         int    // number of distinct event signatures (topic0)
+	BlocksPerEpoch int    // number of blocks per epoch
+	TxsPerBlock    int    // number of transactions per block
+	LogsPerTx      int    // number of event logs per transaction
+	LogDataSize    int    // size of event log data in bytes
+}
+
+// workload generates continuous synthetic epoch data with event logs.
+type workload struct {
+	conf *WorkloadConfig
+	rand *rand.Rand
+
+	contracts []types.Address // candidate contracts to emit event logs
+	topics    []types.Hash    // candidate event signatures
+
+	nextEpoch       uint64     // next epoch number to generate
+	nextBlockNumber uint64     // next block number to generate
+	parentHash      types.Hash // hash of the last generated pivot block
+}
+
+func newWorkload(conf *WorkloadConfig, startEpoch, startBlockNumber uint64) *workload {
+	w := &workload{
+		conf:            conf,
+		rand:            rand.New(rand.NewSource(conf.Seed)),
+		nextEpoch:       startEpoch,
+		nextBlockNumber: startBlockNumber,
+	}
+	w.parentHash = w.hash("genesis", startEpoch)
+
+	for i := 0; i < conf.Contracts; i++ {
+		addr := common.BytesToAddress(crypto.Keccak256([]byte(fmt.Sprintf("contract#%v#%v", conf.Seed, i))))
+		cfxAddr, _ := cfxaddress.NewFromCommon(addr, conf.NetworkId)
+		w.contracts = append(w.contracts, cfxAddr)
+	}
+
+	for i := 0; i < conf.Topics; i++ {
+		w.topics = append(w.topics, w.hash("topic", uint64(i)))
+	}
+
+	return w
+}
+
+// hash generates deterministic hash for the specified entity and sequence.
+func (w *workload) hash(entity string, seq uint64) types.Hash {
+	h := crypto.Keccak256Hash([]byte(fmt.Sprintf("%v#%v#%v", entity, w.conf.Seed, seq)))
+	return types.Hash(h.Hex())
+}
+
+// randContract picks a random contract.
+func (w *workload) randContract() types.Address {
+	return w.contracts[w.rand.Intn(len(w.contracts))]
+}
+
+// randTopic picks a random event signature.
+func (w *workload) randTopic() types.Hash {
+	return w.topics[w.rand.Intn(len(w.topics))]
+}
+
+// next generates the next epoch data.
+func (w *workload) next() *store.EpochData {
+	epoch := &store.EpochData{
+		Number:   w.nextEpoch,
+		Receipts: make(map[types.Hash]*types.TransactionReceipt),
+	}
+
+	for i := 0; i < w.conf.BlocksPerEpoch; i++ {
+		block, receipts := w.nextBlock()
+
+		epoch.Blocks = append(epoch.Blocks, block)
+		for _, rcpt := range receipts {
+			epoch.Receipts[rcpt.TransactionHash] = rcpt
+		}
+	}
+
+	w.parentHash = epoch.GetPivotBlock().Hash
+	w.nextEpoch++
+
+	return epoch
+}
+
+func (w *workload) nextBlock() (*types.Block, []*types.TransactionReceipt) {
+	bn := w.nextBlockNumber
+	w.nextBlockNumber++
+
+	blockHash := w.hash("block", bn)
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Hash:                  blockHash,
+			ParentHash:            w.parentHash,
+			Height:                types.NewBigInt(w.nextEpoch),
+			Miner:                 w.contracts[0],
+			DeferredStateRoot:     w.hash("stateRoot", bn),
+			DeferredReceiptsRoot:  w.hash("receiptsRoot", bn),
+			DeferredLogsBloomHash: w.hash("logsBloomHash", bn),
+			TransactionsRoot:      w.hash("txsRoot", bn),
+			EpochNumber:           types.NewBigInt(w.nextEpoch),
+			BlockNumber:           types.NewBigInt(bn),
+			GasLimit:              types.NewBigInt(30_000_000),
+			GasUsed:               types.NewBigInt(0),
+			Timestamp:             types.NewBigInt(bn),
+			Difficulty:            hexBig0,
+			PowQuality:            hexBig0,
+			RefereeHashes:         []types.Hash{},
+			Nonce:                 hexBig0,
+			Size:                  hexBig0,
+			Custom:                []cmptutil.Bytes{},
+		},
+	}
+
+	var receipts []*types.TransactionReceipt
+	var logIndex uint64
+
+	for i := 0; i < w.conf.TxsPerBlock; i++ {
+		txHash := w.hash("tx", bn*uint64(w.conf.TxsPerBlock)+uint64(i))
+		to := w.randContract()
+
+		block.Transactions = append(block.Transactions, types.Transaction{
+			Hash:             txHash,
+			Nonce:            types.NewBigInt(uint64(i)),
+			BlockHash:        &blockHash,
+			TransactionIndex: types.NewUint64(uint64(i)),
+			From:             w.contracts[0],
+			To:               &to,
+			Value:            hexBig0,
+			GasPrice:         types.NewBigInt(1),
+			Gas:              types.NewBigInt(21000),
+			Data:             "0x",
+			StorageLimit:     hexBig0,
+			EpochHeight:      hexBig0,
+			ChainID:          types.NewBigInt(uint64(w.conf.NetworkId)),
+			Status:           &txStatus0,
+			V:                hexBig0,
+			R:                hexBig0,
+			S:                hexBig0,
+		})
+
+		rcpt := &types.TransactionReceipt{
+			TransactionHash: txHash,
+			Index:           hexutil.Uint64(i),
+			BlockHash:       blockHash,
+			EpochNumber:     types.NewUint64(w.nextEpoch),
+			From:            w.contracts[0],
+			To:              &to,
+			GasUsed:         types.NewBigInt(21000),
+			GasFee:          types.NewBigInt(21000),
+			StateRoot:       w.hash("stateRoot", bn),
+			OutcomeStatus:   0,
+		}
+
+		for j := 0; j < w.conf.LogsPerTx; j++ {
+			data := make([]byte, w.conf.LogDataSize)
+			w.rand.Read(data)
+
+			rcpt.Logs = append(rcpt.Logs, types.Log{
+				Address:             w.randContract(),
+				Topics:              []types.Hash{w.randTopic(), w.hash("topic1", w.rand.Uint64())},
+				Data:                data,
+				BlockHash:           &blockHash,
+				EpochNumber:         types.NewBigInt(w.nextEpoch),
+				TransactionHash:     &txHash,
+				TransactionIndex:    types.NewBigInt(uint64(i)),
+				LogIndex:            types.NewBigInt(logIndex),
+				TransactionLogIndex: types.NewBigInt(uint64(j)),
+			})
+
+			logIndex++
+		}
+
+		receipts = append(receipts, rcpt)
+	}
+
+	return block, receipts
+}
+
+// numLogsPerEpoch returns the number of event logs generated per epoch.
+func (conf *WorkloadConfig) numLogsPerEpoch() int {
+	return conf.BlocksPerEpoch * conf.TxsPerBlock * conf.LogsPerTx
+}
@@ -0,0 +1,141 @@
+// Package client provides a typed Go client for the extension RPC methods of evm space gateway,
+// which are not covered by the standard Ethereum clients (eg., web3go).
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	ethtypes "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// Client typed client of gateway extension RPC methods for evm space.
+type Client struct {
+	p interfaces.Provider
+}
+
+// NewClient creates client to request the gateway of the specified RPC URL.
+func NewClient(url string, option ...providers.Option) (*Client, error) {
+	var opt providers.Option
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	p, err := providers.NewProviderWithOption(url, opt)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create RPC provider")
+	}
+
+	return NewClientWithProvider(p), nil
+}
+
+// NewClientWithProvider creates client with the underlying RPC provider, eg., to share the
+// provider with web3go client.
+func NewClientWithProvider(p interfaces.Provider) *Client {
+	return &Client{p: p}
+}
+
+func (c *Client) Close() {
+	c.p.Close()
+}
+
+// Capabilities returns the supported namespaces, methods and subscriptions of the gateway.
+func (c *Client) Capabilities(ctx context.Context) (val *Capabilities, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_capabilities")
+	return
+}
+
+// Quota returns the compute unit quota status of the tenant by API key.
+func (c *Client) Quota(ctx context.Context) (val *QuotaStatus, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_quota")
+	return
+}
+
+// Status returns the gateway's view of data freshness, including the max epoch and reorg version of
+// store, the latest epoch of upstream fullnodes, and their lags to the best node.
+func (c *Client) Status(ctx context.Context) (val *GatewayStatus, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_status")
+	return
+}
+
+// ExplainLogs returns the planned execution of `eth_getLogs` for the log filter without execution.
+func (c *Client) ExplainLogs(ctx context.Context, fq ethtypes.FilterQuery) (val *LogsQueryPlan, err error) {
+	err = c.p.CallContext(ctx, &val, "eth_explainLogs", fq)
+	return
+}
+
+// SubmitLogsJob submits heavy event logs query job to be executed in background.
+func (c *Client) SubmitLogsJob(ctx context.Context, fq ethtypes.FilterQuery) (val rpc.ID, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_submitLogsJob", fq)
+	return
+}
+
+// GetLogsJobStatus returns the status of event logs query job.
+func (c *Client) GetLogsJobStatus(ctx context.Context, id rpc.ID) (val *LogsJobStatus, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_getLogsJobStatus", id)
+	return
+}
+
+// GetLogsJobResult returns the event logs of completed event logs query job.
+func (c *Client) GetLogsJobResult(ctx context.Context, id rpc.ID) (val []ethtypes.Log, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_getLogsJobResult", id)
+	return
+}
+
+// CancelLogsJob cancels the event logs query job if not finished yet.
+func (c *Client) CancelLogsJob(ctx context.Context, id rpc.ID) (val bool, err error) {
+	err = c.p.CallContext(ctx, &val, "confura_cancelLogsJob", id)
+	return
+}
+
+// WaitLogsJob polls the status of event logs query job at the specified interval until finished,
+// and returns the event logs if completed.
+func (c *Client) WaitLogsJob(ctx context.Context, id rpc.ID, interval time.Duration) ([]ethtypes.Log, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.GetLogsJobStatus(ctx, id)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get logs job status")
+		}
+
+		switch status.State {
+		case LogsJobCompleted:
+			return c.GetLogsJobResult(ctx, id)
+		case LogsJobFailed:
+			return nil, errors.Errorf("logs job failed: %v", status.Error)
+		case LogsJobCanceled:
+			return nil, errors.New("logs job canceled")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// TxTrackerStatus returns the status of tracked pending transactions for the sender.
+func (c *Client) TxTrackerStatus(ctx context.Context, sender common.Address) (val *SenderStatus, err error) {
+	err = c.p.CallContext(ctx, &val, "txtracker_status", sender)
+	return
+}
+
+// TxTrackerResubmit resubmits the tracked pending transaction to fullnode.
+func (c *Client) TxTrackerResubmit(ctx context.Context, txHash common.Hash) error {
+	return c.p.CallContext(ctx, nil, "txtracker_resubmit", txHash)
+}
+
+// GasStationPrice returns the percentile-based gas price suggestions.
+func (c *Client) GasStationPrice(ctx context.Context) (val *types.GasStationPrice, err error) {
+	err = c.p.CallContext(ctx, &val, "gasstation_price")
+	return
+}
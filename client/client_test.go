@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/txpool"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	ethtypes "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mock services return the server side types, so as to check the compatibility of JSON encoding.
+
+type confuraService struct {
+	polls int
+}
+
+func (*confuraService) Capabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"space":         "eth",
+		"namespaces":    map[string][]string{"eth": {"eth_explainLogs"}},
+		"subscriptions": []string{"logs"},
+		"unsupported":   []string{},
+	}
+}
+
+func (*confuraService) SubmitLogsJob(fq ethtypes.FilterQuery) rpc.ID {
+	return rpc.ID("0x1")
+}
+
+func (s *confuraService) GetLogsJobStatus(id rpc.ID) *handler.LogsJobStatus {
+	s.polls++
+
+	state := handler.LogsJobRunning
+	if s.polls > 1 {
+		state = handler.LogsJobCompleted
+	}
+
+	return &handler.LogsJobStatus{ID: id, State: state, FromBlock: 1, ToBlock: 10, CreatedAt: time.Now()}
+}
+
+func (*confuraService) GetLogsJobResult(id rpc.ID) []ethtypes.Log {
+	return []ethtypes.Log{{BlockNumber: 5, Topics: []common.Hash{}, Data: []byte{}}}
+}
+
+type ethService struct{}
+
+func (ethService) ExplainLogs(fq ethtypes.FilterQuery) *handler.LogsQueryPlan {
+	estimated := uint64(100)
+
+	return &handler.LogsQueryPlan{
+		Database: []handler.LogsQueryRange{{FromBlock: 1, ToBlock: 10}},
+		Indexes:  []mysql.LogsQueryIndex{{Table: "logs_1", Index: "PRIMARY"}},
+		Cost: &handler.LogsQueryCost{
+			BlockSpan: 10, LogsPerBlock: 10, AddressSelectivity: 1, TopicSelectivity: 1,
+		},
+		EstimatedCost: &estimated,
+		Decision:      handler.LogsQueryDecisionExecute,
+	}
+}
+
+type txtrackerService struct{}
+
+func (txtrackerService) Status(sender common.Address) *txpool.SenderStatus {
+	return &txpool.SenderStatus{
+		Sender:        sender.Hex(),
+		NextNonce:     3,
+		MissingNonces: []hexutil.Uint64{4},
+		Pending:       []*txpool.TxStatus{{Hash: "0x01", Nonce: 5, Status: "pending"}},
+	}
+}
+
+type gasstationService struct{}
+
+func (gasstationService) Price() *types.GasStationPrice {
+	return &types.GasStationPrice{}
+}
+
+func newTestClient(t *testing.T) *Client {
+	server := rpc.NewServer()
+
+	require.NoError(t, server.RegisterName("confura", &confuraService{}))
+	require.NoError(t, server.RegisterName("eth", ethService{}))
+	require.NoError(t, server.RegisterName("txtracker", txtrackerService{}))
+	require.NoError(t, server.RegisterName("gasstation", gasstationService{}))
+
+	t.Cleanup(server.Stop)
+
+	return NewClientWithProvider(rpc.DialInProc(server))
+}
+
+func TestClient(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+
+	caps, err := c.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "eth", caps.Space)
+	assert.True(t, caps.Supports("eth_explainLogs"))
+	assert.False(t, caps.Supports("eth_getLogs"))
+
+	plan, err := c.ExplainLogs(ctx, ethtypes.FilterQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, LogsQueryDecisionExecute, plan.Decision)
+	assert.Equal(t, []LogsQueryRange{{FromBlock: 1, ToBlock: 10}}, plan.Database)
+	assert.Equal(t, "logs_1", plan.Indexes[0].Table)
+	assert.Equal(t, uint64(100), *plan.EstimatedCost)
+
+	sender := common.HexToAddress("0x1")
+	status, err := c.TxTrackerStatus(ctx, sender)
+	require.NoError(t, err)
+	assert.Equal(t, sender.Hex(), status.Sender)
+	assert.Equal(t, hexutil.Uint64(3), status.NextNonce)
+	assert.Equal(t, hexutil.Uint64(5), status.Pending[0].Nonce)
+
+	price, err := c.GasStationPrice(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, price)
+}
+
+func TestClientWaitLogsJob(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id, err := c.SubmitLogsJob(ctx, ethtypes.FilterQuery{})
+	require.NoError(t, err)
+
+	logs, err := c.WaitLogsJob(ctx, id, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, uint64(5), logs[0].BlockNumber)
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	ethtypes "github.com/openweb3/web3go/types"
+)
+
+// Types below mirror the JSON results of gateway extension RPC methods, so that the client
+// could be used without importing server side packages (which requires configurations).
+
+// Capabilities RPC capabilities served by the gateway.
+type Capabilities struct {
+	Space         string              `json:"space"`         // "cfx" for core space or "eth" for evm space
+	Namespaces    map[string][]string `json:"namespaces"`    // namespace => supported methods
+	Subscriptions []string            `json:"subscriptions"` // supported pubsub subscriptions
+	Unsupported   []string            `json:"unsupported"`   // explicitly unsupported methods
+}
+
+// Supports checks if the specified RPC method (eg., `eth_explainLogs`) is supported.
+func (caps *Capabilities) Supports(method string) bool {
+	for _, methods := range caps.Namespaces {
+		for _, m := range methods {
+			if m == method {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+const (
+	// decisions of event logs query plan
+	LogsQueryDecisionExecute = "execute"
+	LogsQueryDecisionQueue   = "queue"
+	LogsQueryDecisionReject  = "reject"
+)
+
+// LogsQueryRange block range of event logs query.
+type LogsQueryRange struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+// LogsQueryIndex table and index chosen to query event logs from database.
+type LogsQueryIndex struct {
+	Table    string `json:"table"`
+	Index    string `json:"index"`
+	Contract string `json:"contract,omitempty"`
+}
+
+// LogsQueryCost estimated cost factors of event logs query.
+type LogsQueryCost struct {
+	BlockSpan          uint64  `json:"blockSpan"`
+	LogsPerBlock       float64 `json:"logsPerBlock"`
+	AddressSelectivity float64 `json:"addressSelectivity"`
+	TopicSelectivity   float64 `json:"topicSelectivity"`
+}
+
+// LogsQueryPlan planned execution of event logs query explained by `eth_explainLogs`.
+type LogsQueryPlan struct {
+	Database      []LogsQueryRange      `json:"database"`
+	Fullnode      *ethtypes.FilterQuery `json:"fullnode,omitempty"`
+	Indexes       []LogsQueryIndex      `json:"indexes"`
+	Cost          *LogsQueryCost        `json:"cost,omitempty"`
+	EstimatedCost *uint64               `json:"estimatedCost,omitempty"`
+	Decision      string                `json:"decision"`
+	Reason        string                `json:"reason,omitempty"`
+}
+
+// LogsJobState state of heavy event logs query job.
+type LogsJobState string
+
+const (
+	LogsJobPending   LogsJobState = "pending"
+	LogsJobRunning   LogsJobState = "running"
+	LogsJobCompleted LogsJobState = "completed"
+	LogsJobFailed    LogsJobState = "failed"
+	LogsJobCanceled  LogsJobState = "canceled"
+)
+
+// Finished checks if the logs job is finished, whether succeeded or not.
+func (state LogsJobState) Finished() bool {
+	return state == LogsJobCompleted || state == LogsJobFailed || state == LogsJobCanceled
+}
+
+// LogsJobStatus status of heavy event logs query job.
+type LogsJobStatus struct {
+	ID           rpc.ID       `json:"id"`
+	State        LogsJobState `json:"state"`
+	FromBlock    uint64       `json:"fromBlock"`
+	ToBlock      uint64       `json:"toBlock"`
+	CurrentBlock uint64       `json:"currentBlock"`
+	Progress     float64      `json:"progress"`
+	NumLogs      int          `json:"numLogs"`
+	Error        string       `json:"error,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	FinishedAt   *time.Time   `json:"finishedAt,omitempty"`
+}
+
+// TxStatus status of transaction tracked by gateway.
+type TxStatus struct {
+	Hash        string         `json:"hash"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Status      string         `json:"status"`
+	SubmittedAt time.Time      `json:"submittedAt"`
+	Resubmits   int            `json:"resubmits"`
+}
+
+// SenderStatus status of tracked pending transactions for some sender.
+type SenderStatus struct {
+	Sender        string           `json:"sender"`
+	NextNonce     hexutil.Uint64   `json:"nextNonce"`
+	MissingNonces []hexutil.Uint64 `json:"missingNonces"`
+	Pending       []*TxStatus      `json:"pending"`
+}
+
+// QuotaPeriod compute unit quota of tenant within a period.
+type QuotaPeriod struct {
+	Limit     uint64    `json:"limit"`
+	Used      uint64    `json:"used"`
+	Remaining uint64    `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// QuotaStatus compute unit quota status of tenant, where nil period means unlimited.
+type QuotaStatus struct {
+	Tenant  string       `json:"tenant"`
+	Daily   *QuotaPeriod `json:"daily,omitempty"`
+	Monthly *QuotaPeriod `json:"monthly,omitempty"`
+}
+
+// StoreStatus sync status of store.
+type StoreStatus struct {
+	MaxEpoch     uint64 `json:"maxEpoch"`
+	ReorgVersion int    `json:"reorgVersion"`
+	Backlog      uint64 `json:"backlog"`
+	Error        string `json:"error,omitempty"`
+}
+
+// NodeStatus latest epoch of upstream fullnode.
+type NodeStatus struct {
+	Name        string `json:"name"`
+	LatestEpoch uint64 `json:"latestEpoch"`
+	Lag         uint64 `json:"lag"`
+	Error       string `json:"error,omitempty"`
+}
+
+// GatewayStatus gateway's view of data freshness, where store is nil if not available.
+type GatewayStatus struct {
+	Space     string        `json:"space"`
+	BestEpoch uint64        `json:"bestEpoch"`
+	Store     *StoreStatus  `json:"store,omitempty"`
+	Nodes     []*NodeStatus `json:"nodes"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
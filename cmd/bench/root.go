@@ -0,0 +1,13 @@
+package bench
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark tools to help sizing hardware for Confura services",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
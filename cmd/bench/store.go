@@ -0,0 +1,107 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Conflux-Chain/confura/bench"
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storeBenchNetwork string // RPC network space ("cfx" or "eth")
+	storeBenchConf    bench.StoreBenchConfig
+
+	storeCmd = &cobra.Command{
+		Use:   "store",
+		Short: "Benchmark store backend with synthetic event log workload",
+		Long: `Generate synthetic epoch data with event logs into the target store backend, and then run
+mixed log queries against it to report ingest throughput and query latency.
+
+Note, synthetic data will be persisted into the store, please use a dedicated database for benchmark.`,
+		Run: benchStore,
+	}
+)
+
+func init() {
+	storeCmd.Flags().StringVarP(
+		&storeBenchNetwork, "network", "n", "cfx", "network space of the target store (`cfx` or `eth`)",
+	)
+
+	// workload
+	storeCmd.Flags().Int64Var(&storeBenchConf.Seed, "seed", 1, "random seed to generate reproducible workload")
+	storeCmd.Flags().Uint32Var(&storeBenchConf.NetworkId, "network-id", 1, "network ID to generate base32 addresses")
+	storeCmd.Flags().IntVar(&storeBenchConf.Contracts, "contracts", 100, "number of contracts to emit event logs")
+	storeCmd.Flags().IntVar(&storeBenchConf.Topics, "topics", 20, "number of distinct event signatures")
+	storeCmd.Flags().IntVar(&storeBenchConf.BlocksPerEpoch, "blocks-per-epoch", 2, "number of blocks per epoch")
+	storeCmd.Flags().IntVar(&storeBenchConf.TxsPerBlock, "txs-per-block", 10, "number of transactions per block")
+	storeCmd.Flags().IntVar(&storeBenchConf.LogsPerTx, "logs-per-tx", 3, "number of event logs per transaction")
+	storeCmd.Flags().IntVar(&storeBenchConf.LogDataSize, "log-data-size", 64, "size of event log data in bytes")
+
+	// ingest
+	storeCmd.Flags().Uint64VarP(&storeBenchConf.Epochs, "epochs", "e", 10000, "number of epochs to ingest")
+	storeCmd.Flags().IntVarP(&storeBenchConf.BatchSize, "batch-size", "b", 10, "number of epochs per batch to ingest")
+
+	// query
+	storeCmd.Flags().IntVarP(&storeBenchConf.Queries, "queries", "q", 1000, "number of log queries to run")
+	storeCmd.Flags().IntVarP(&storeBenchConf.QueryConcurrency, "concurrency", "c", 4, "number of concurrent log query workers")
+	storeCmd.Flags().Uint64Var(&storeBenchConf.QueryBlockRange, "query-block-range", 100, "block range of each log query")
+	storeCmd.Flags().StringToIntVar(
+		&storeBenchConf.FilterMix, "filter-mix",
+		map[string]int{
+			bench.FilterKindRange:          1,
+			bench.FilterKindContract:       4,
+			bench.FilterKindTopic:          4,
+			bench.FilterKindMultiContracts: 1,
+		},
+		"weights of log filter kinds (range, contract, topic or multi) to run queries",
+	)
+
+	Cmd.AddCommand(storeCmd)
+}
+
+func benchStore(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(storeBenchNetwork)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	benchmarker, err := bench.NewStoreBenchmarker(&storeBenchConf, dbs)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to create store benchmarker")
+		return
+	}
+
+	logrus.WithField("config", storeBenchConf).
+		Info("Press the Enter Key to start store benchmark with synthetic data written into the store")
+	fmt.Scanln() // wait for Enter Key
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { // stop benchmark on termination signal
+		termChan := make(chan os.Signal, 1)
+		signal.Notify(termChan, syscall.SIGTERM, syscall.SIGINT)
+
+		<-termChan
+		cancel()
+	}()
+
+	if err := benchmarker.Run(ctx); err != nil {
+		logrus.WithError(err).Info("Failed to run store benchmark")
+	}
+}
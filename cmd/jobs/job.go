@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type jobCmdConfig struct {
+	Network string // network space ("cfx" or "eth") of the database where jobs persisted
+	Name    string // job name
+	Limit   int    // max number of run history to list
+}
+
+var (
+	jobCfg jobCmdConfig
+
+	listJobsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List all scheduled jobs",
+		Run:   listJobs,
+	}
+
+	triggerJobCmd = &cobra.Command{
+		Use:   "trigger",
+		Short: "Trigger scheduled job to run as soon as possible",
+		Run:   triggerJob,
+	}
+
+	pauseJobCmd = &cobra.Command{
+		Use:   "pause",
+		Short: "Pause scheduled job",
+		Run: func(cmd *cobra.Command, args []string) {
+			pauseJob(true)
+		},
+	}
+
+	resumeJobCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume paused job",
+		Run: func(cmd *cobra.Command, args []string) {
+			pauseJob(false)
+		},
+	}
+
+	listJobRunsCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List the latest run history of scheduled job",
+		Run:   listJobRuns,
+	}
+)
+
+func init() {
+	Cmd.AddCommand(listJobsCmd)
+	hookJobCmdFlags(listJobsCmd, false, false)
+
+	Cmd.AddCommand(triggerJobCmd)
+	hookJobCmdFlags(triggerJobCmd, true, false)
+
+	Cmd.AddCommand(pauseJobCmd)
+	hookJobCmdFlags(pauseJobCmd, true, false)
+
+	Cmd.AddCommand(resumeJobCmd)
+	hookJobCmdFlags(resumeJobCmd, true, false)
+
+	Cmd.AddCommand(listJobRunsCmd)
+	hookJobCmdFlags(listJobRunsCmd, true, true)
+}
+
+func listJobs(cmd *cobra.Command, args []string) {
+	withJobStore(func(dbs *mysql.MysqlStore) {
+		jobs, err := dbs.LoadJobs()
+		if err != nil {
+			logrus.WithError(err).Info("Failed to load scheduled jobs")
+			return
+		}
+
+		if len(jobs) == 0 {
+			logrus.Info("No scheduled jobs found")
+			return
+		}
+
+		logrus.WithField("total", len(jobs)).Info("Scheduled jobs loaded:")
+
+		for _, job := range jobs {
+			logrus.WithFields(logrus.Fields{
+				"interval":  job.Interval,
+				"paused":    job.Paused,
+				"nextRunAt": job.NextRunAt,
+				"lastRunAt": job.LastRunAt,
+			}).Info("Job ", job.Name)
+		}
+	})
+}
+
+func triggerJob(cmd *cobra.Command, args []string) {
+	withJobStore(func(dbs *mysql.MysqlStore) {
+		triggered, err := dbs.TriggerJob(jobCfg.Name)
+		if err != nil {
+			logrus.WithError(err).Info("Failed to trigger scheduled job")
+			return
+		}
+
+		if triggered {
+			logrus.WithField("name", jobCfg.Name).Info("Scheduled job triggered")
+		} else {
+			logrus.WithField("name", jobCfg.Name).Info("Scheduled job not found")
+		}
+	})
+}
+
+func pauseJob(paused bool) {
+	withJobStore(func(dbs *mysql.MysqlStore) {
+		updated, err := dbs.PauseJob(jobCfg.Name, paused)
+		if err != nil {
+			logrus.WithError(err).Info("Failed to pause/resume scheduled job")
+			return
+		}
+
+		logger := logrus.WithField("name", jobCfg.Name)
+
+		switch {
+		case !updated:
+			logger.Info("Scheduled job not found")
+		case paused:
+			logger.Info("Scheduled job paused")
+		default:
+			logger.Info("Scheduled job resumed")
+		}
+	})
+}
+
+func listJobRuns(cmd *cobra.Command, args []string) {
+	withJobStore(func(dbs *mysql.MysqlStore) {
+		runs, err := dbs.LoadJobRuns(jobCfg.Name, jobCfg.Limit)
+		if err != nil {
+			logrus.WithError(err).Info("Failed to load job run history")
+			return
+		}
+
+		if len(runs) == 0 {
+			logrus.WithField("name", jobCfg.Name).Info("No job run history found")
+			return
+		}
+
+		logrus.WithField("total", len(runs)).Info("Job run history loaded:")
+
+		for i, run := range runs {
+			logrus.WithFields(logrus.Fields{
+				"instance":   run.Instance,
+				"status":     run.Status,
+				"error":      run.Error,
+				"startedAt":  run.StartedAt,
+				"finishedAt": run.FinishedAt,
+			}).Info("Run #", i)
+		}
+	})
+}
+
+func withJobStore(f func(dbs *mysql.MysqlStore)) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(jobCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	f(dbs)
+}
+
+func hookJobCmdFlags(jobCmd *cobra.Command, hookName, hookLimit bool) {
+	jobCmd.Flags().StringVarP(
+		&jobCfg.Network, "network", "n", "cfx", "network space ('cfx' or 'eth') of database where jobs persisted",
+	)
+
+	if hookName {
+		jobCmd.Flags().StringVar(&jobCfg.Name, "name", "", "job name")
+		jobCmd.MarkFlagRequired("name")
+	}
+
+	if hookLimit {
+		jobCmd.Flags().IntVarP(&jobCfg.Limit, "limit", "l", 20, "max number of run history to list")
+	}
+}
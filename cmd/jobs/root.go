@@ -0,0 +1,15 @@
+package jobs
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "jobs",
+		Short: "Scheduled job utility toolset",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+)
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type compressLogsConfig struct {
+	Network     string   // network space ("cfx" or "eth") of the database to migrate
+	Compression string   // compression algorithm
+	BatchSize   int      // number of rows to compress per batch
+	Tables      []string // event log tables to compress, empty for all
+}
+
+var (
+	compressLogsCfg compressLogsConfig
+
+	compressLogsCmd = &cobra.Command{
+		Use:   "compress-logs",
+		Short: "Compress the extension data of existing event logs in database",
+		Run:   compressLogs,
+	}
+)
+
+func init() {
+	compressLogsCmd.Flags().StringVarP(
+		&compressLogsCfg.Network, "network", "n", "cfx", "network space ('cfx' or 'eth') of database to migrate",
+	)
+
+	compressLogsCmd.Flags().StringVarP(
+		&compressLogsCfg.Compression, "algo", "a", string(store.LogCompressionZstd), "compression algorithm ('snappy' or 'zstd')",
+	)
+
+	compressLogsCmd.Flags().IntVarP(
+		&compressLogsCfg.BatchSize, "batch", "b", 1000, "number of rows to compress per batch",
+	)
+
+	compressLogsCmd.Flags().StringSliceVarP(
+		&compressLogsCfg.Tables, "tables", "t", nil, "event log tables to compress, default all",
+	)
+
+	Cmd.AddCommand(compressLogsCmd)
+}
+
+func compressLogs(cmd *cobra.Command, args []string) {
+	compression := store.LogCompression(compressLogsCfg.Compression)
+	if err := compression.Validate(); err != nil || len(compression) == 0 {
+		logrus.WithField("algo", compressLogsCfg.Compression).Info("Unsupported compression algorithm")
+		return
+	}
+
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(compressLogsCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	tables := compressLogsCfg.Tables
+	if len(tables) == 0 {
+		if tables, err = dbs.LogTables(); err != nil {
+			logrus.WithError(err).Info("Failed to load event log tables")
+			return
+		}
+	}
+
+	logrus.WithField("tables", len(tables)).Info("Start to compress event logs")
+
+	var totalBefore, totalAfter int64
+	for _, table := range tables {
+		stats, err := dbs.CompressLogTable(context.Background(), table, compression, compressLogsCfg.BatchSize)
+		if stats != nil {
+			totalBefore += stats.BytesBefore
+			totalAfter += stats.BytesAfter
+		}
+
+		if err != nil {
+			logrus.WithField("table", table).WithError(err).Info("Failed to compress event logs")
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"rows":        stats.Rows,
+			"bytesBefore": stats.BytesBefore,
+			"bytesAfter":  stats.BytesAfter,
+		}).Info("Event logs compressed for table ", table)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"bytesBefore": totalBefore,
+		"bytesAfter":  totalAfter,
+	}).Info("Event logs compressed for all tables")
+}
@@ -0,0 +1,13 @@
+package migrate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Database migration toolset",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
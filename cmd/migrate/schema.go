@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type schemaConfig struct {
+	Network   string // network space ("cfx" or "eth") of the database to migrate
+	DryRun    bool   // print the migrations to apply or revert without execution
+	ToVersion uint   // target version to migrate up to, 0 for the latest
+	Steps     int    // number of migrations to roll back
+}
+
+var (
+	schemaCfg schemaConfig
+
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "List the schema migrations along with the applied status",
+		Run:   schemaStatus,
+	}
+
+	upCmd = &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending schema migrations in order",
+		Run:   schemaUp,
+	}
+
+	rollbackCmd = &cobra.Command{
+		Use:   "rollback",
+		Short: "Revert the latest applied schema migrations in reverse order",
+		Run:   schemaRollback,
+	}
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{statusCmd, upCmd, rollbackCmd} {
+		cmd.Flags().StringVarP(
+			&schemaCfg.Network, "network", "n", "cfx", "network space ('cfx' or 'eth') of database to migrate",
+		)
+
+		Cmd.AddCommand(cmd)
+	}
+
+	upCmd.Flags().BoolVar(&schemaCfg.DryRun, "dry-run", false, "print the SQL statements without execution")
+	upCmd.Flags().UintVar(&schemaCfg.ToVersion, "to", 0, "target version to migrate up to, default the latest")
+
+	rollbackCmd.Flags().BoolVar(&schemaCfg.DryRun, "dry-run", false, "print the SQL statements without execution")
+	rollbackCmd.Flags().IntVar(&schemaCfg.Steps, "steps", 1, "number of applied migrations to roll back")
+}
+
+// getSchemaStore returns the mysql store of the network space to migrate, or nil if unavailable.
+func getSchemaStore(storeCtx util.StoreContext) *mysql.MysqlStore {
+	dbs, err := storeCtx.GetMysqlStore(schemaCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return nil
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+	}
+
+	return dbs
+}
+
+func schemaStatus(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs := getSchemaStore(storeCtx)
+	if dbs == nil {
+		return
+	}
+
+	statuses, err := dbs.Migrations()
+	if err != nil {
+		logrus.WithError(err).Info("Failed to list schema migrations")
+		return
+	}
+
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Printf("%6v  %-40v  %v\n", s.Version, s.Name, applied)
+	}
+}
+
+func schemaUp(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs := getSchemaStore(storeCtx)
+	if dbs == nil {
+		return
+	}
+
+	applied, err := dbs.MigrateUp(schemaCfg.ToVersion, schemaCfg.DryRun)
+	printMigrations(applied, true)
+
+	if err != nil {
+		logrus.WithError(err).Info("Failed to apply schema migrations")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"migrations": len(applied), "dryRun": schemaCfg.DryRun,
+	}).Info("Schema migrations applied")
+}
+
+func schemaRollback(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs := getSchemaStore(storeCtx)
+	if dbs == nil {
+		return
+	}
+
+	reverted, err := dbs.Rollback(schemaCfg.Steps, schemaCfg.DryRun)
+	printMigrations(reverted, false)
+
+	if err != nil {
+		logrus.WithError(err).Info("Failed to roll back schema migrations")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"migrations": len(reverted), "dryRun": schemaCfg.DryRun,
+	}).Info("Schema migrations rolled back")
+}
+
+func printMigrations(migrations []mysql.Migration, up bool) {
+	for _, m := range migrations {
+		stmts, fn := m.Down, m.DownFn
+		if up {
+			stmts, fn = m.Up, m.UpFn
+		}
+
+		fmt.Printf("-- %v: %v\n", m.Version, m.Name)
+		if fn != nil {
+			fmt.Println("-- (schema change applied by table models or on partitioned tables)")
+		}
+
+		for _, stmt := range stmts {
+			fmt.Printf("%v;\n", stmt)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type nodeCmdConfig struct {
+	Endpoint string // node manager RPC endpoint
+	Group    string // route group
+	URL      string // full node URL
+	Save     bool   // whether to persist the route group changes into db
+}
+
+var (
+	nodeCfg nodeCmdConfig
+
+	addNodeCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Add full node into route group",
+		Run:   addNode,
+	}
+
+	delNodeCmd = &cobra.Command{
+		Use:   "rm",
+		Short: "Remove full node from route group",
+		Run:   delNode,
+	}
+
+	listNodesCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List full nodes of route group, or all route groups if group not specified",
+		Run:   listNodes,
+	}
+)
+
+func init() {
+	Cmd.AddCommand(addNodeCmd)
+	hookNodeCmdFlags(addNodeCmd, true)
+
+	Cmd.AddCommand(delNodeCmd)
+	hookNodeCmdFlags(delNodeCmd, true)
+
+	Cmd.AddCommand(listNodesCmd)
+	hookNodeCmdFlags(listNodesCmd, false)
+}
+
+func addNode(cmd *cobra.Command, args []string) {
+	client, ok := dialNodeManager(true)
+	if !ok {
+		return
+	}
+	defer client.Close()
+
+	err := client.Call(nil, "node_add", node.Group(nodeCfg.Group), nodeCfg.URL, nodeCfg.Save)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to add full node")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group": nodeCfg.Group, "url": nodeCfg.URL,
+	}).Info("Full node added")
+}
+
+func delNode(cmd *cobra.Command, args []string) {
+	client, ok := dialNodeManager(true)
+	if !ok {
+		return
+	}
+	defer client.Close()
+
+	err := client.Call(nil, "node_remove", node.Group(nodeCfg.Group), nodeCfg.URL, nodeCfg.Save)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to remove full node")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group": nodeCfg.Group, "url": nodeCfg.URL,
+	}).Info("Full node removed")
+}
+
+func listNodes(cmd *cobra.Command, args []string) {
+	client, ok := dialNodeManager(false)
+	if !ok {
+		return
+	}
+	defer client.Close()
+
+	var groupNodes map[node.Group][]string
+	if len(nodeCfg.Group) == 0 {
+		if err := client.Call(&groupNodes, "node_listAll"); err != nil {
+			logrus.WithError(err).Info("Failed to list full nodes")
+			return
+		}
+	} else {
+		var urls []string
+		if err := client.Call(&urls, "node_list", node.Group(nodeCfg.Group)); err != nil {
+			logrus.WithError(err).Info("Failed to list full nodes")
+			return
+		}
+
+		groupNodes = map[node.Group][]string{node.Group(nodeCfg.Group): urls}
+	}
+
+	var healthy []string
+	if len(nodeCfg.Group) > 0 {
+		if err := client.Call(&healthy, "node_listHealthy", node.Group(nodeCfg.Group)); err != nil {
+			logrus.WithError(err).Info("Failed to list healthy full nodes")
+			return
+		}
+	}
+
+	groups := make([]string, 0, len(groupNodes))
+	for grp := range groupNodes {
+		groups = append(groups, string(grp))
+	}
+	sort.Strings(groups)
+
+	for _, grp := range groups {
+		fmt.Printf("%v:\n", grp)
+
+		for _, url := range groupNodes[node.Group(grp)] {
+			if healthy != nil && !contains(healthy, url) {
+				fmt.Printf("  %v (unhealthy)\n", url)
+			} else {
+				fmt.Printf("  %v\n", url)
+			}
+		}
+	}
+}
+
+func contains(urls []string, url string) bool {
+	for _, u := range urls {
+		if u == url {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dialNodeManager validates the command config and dials the node manager RPC endpoint.
+func dialNodeManager(validateNode bool) (*rpc.Client, bool) {
+	if err := validateNodeCmdConfig(validateNode); err != nil {
+		logrus.WithField("config", nodeCfg).WithError(err).Info("Invalid command config")
+		return nil, false
+	}
+
+	client, err := rpc.DialHTTP(nodeCfg.Endpoint)
+	if err != nil {
+		logrus.WithError(err).WithField("endpoint", nodeCfg.Endpoint).Info("Failed to dial node manager")
+		return nil, false
+	}
+
+	return client, true
+}
+
+func validateNodeCmdConfig(validateNode bool) error {
+	if len(nodeCfg.Endpoint) == 0 {
+		return errors.New("node manager endpoint must not be empty")
+	}
+
+	if validateNode && len(nodeCfg.Group) == 0 {
+		return errors.New("route group must not be empty")
+	}
+
+	if validateNode && len(nodeCfg.URL) == 0 {
+		return errors.New("full node URL must not be empty")
+	}
+
+	return nil
+}
+
+func hookNodeCmdFlags(nodeCmd *cobra.Command, hookNode bool) {
+	nodeCmd.Flags().StringVarP(
+		&nodeCfg.Endpoint, "endpoint", "e", "http://127.0.0.1:22530",
+		"node manager RPC endpoint, eg., http://127.0.0.1:28530 for evm space",
+	)
+
+	nodeCmd.Flags().StringVarP(
+		&nodeCfg.Group, "group", "g", "", "route group, eg., cfxhttp or ethhttp",
+	)
+
+	if hookNode {
+		nodeCmd.MarkFlagRequired("group")
+
+		nodeCmd.Flags().StringVarP(&nodeCfg.URL, "url", "u", "", "full node URL")
+		nodeCmd.MarkFlagRequired("url")
+
+		nodeCmd.Flags().BoolVarP(
+			&nodeCfg.Save, "save", "s", false, "whether to persist the route group changes into db",
+		)
+	}
+}
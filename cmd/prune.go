@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// prune boot options
+	pruneOpt struct {
+		dbPruneEnabled  bool
+		kvPruneEnabled  bool
+		ethPruneEnabled bool
+		once            bool
+	}
+
+	pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Start prune service, including core space DB/KV prune and evm space DB prune",
+		Run:   startPruneService,
+	}
+)
+
+func init() {
+	// boot flag for core space DB prune
+	pruneCmd.Flags().BoolVar(
+		&pruneOpt.dbPruneEnabled, "db", false, "start core space DB prune",
+	)
+
+	// boot flag for core space KV prune
+	pruneCmd.Flags().BoolVar(
+		&pruneOpt.kvPruneEnabled, "kv", false, "start core space KV prune",
+	)
+
+	// boot flag for evm space DB prune
+	pruneCmd.Flags().BoolVar(
+		&pruneOpt.ethPruneEnabled, "eth", false, "start evm space DB prune",
+	)
+
+	// prune once and exit
+	pruneCmd.Flags().BoolVar(
+		&pruneOpt.once, "once", false, "prune only once and exit rather than periodically",
+	)
+
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func startPruneService(*cobra.Command, []string) {
+	if !pruneOpt.dbPruneEnabled && !pruneOpt.kvPruneEnabled && !pruneOpt.ethPruneEnabled {
+		logrus.Fatal("No prune server specified")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	type pruner struct {
+		name      string
+		prune     func()
+		pruneOnce func(ctx context.Context) error
+	}
+
+	var pruners []pruner
+
+	if pruneOpt.dbPruneEnabled && storeCtx.CfxDB != nil {
+		pruners = append(pruners, pruner{"cfx.db", storeCtx.CfxDB.Prune, storeCtx.CfxDB.PruneOnce})
+	}
+
+	if pruneOpt.kvPruneEnabled && storeCtx.CfxCache != nil {
+		kvPruner := cisync.MustNewKVCachePruner(storeCtx.CfxCache)
+		prune := func() { go kvPruner.Prune(ctx, &wg) }
+		pruners = append(pruners, pruner{"cfx.cache", prune, kvPruner.PruneOnce})
+	}
+
+	if pruneOpt.ethPruneEnabled && storeCtx.EthDB != nil {
+		pruners = append(pruners, pruner{"eth.db", storeCtx.EthDB.Prune, storeCtx.EthDB.PruneOnce})
+	}
+
+	if len(pruners) == 0 {
+		logrus.Fatal("No store configured to prune")
+	}
+
+	for _, p := range pruners {
+		if !pruneOpt.once {
+			logrus.WithField("store", p.name).Info("Start to prune store periodically")
+			p.prune()
+			continue
+		}
+
+		if err := p.pruneOnce(ctx); err != nil {
+			logrus.WithField("store", p.name).WithError(err).Error("Failed to prune store")
+		} else {
+			logrus.WithField("store", p.name).Info("Store pruned")
+		}
+	}
+
+	if pruneOpt.once {
+		cancel()
+		return
+	}
+
+	util.GracefulShutdown(&wg, cancel)
+}
@@ -19,6 +19,7 @@ type keysetCmdConfig struct {
 	LimitKey  string         // rate limit key
 	LimitType rate.LimitType // rate limit type (0 - by key, 1 - by IP)
 	Memo      string         // rate limit memo
+	Schedules []string       // time-of-day schedules (eg., "22:00-06:00=night")
 }
 
 var (
@@ -61,6 +62,7 @@ func init() {
 	hookKeysetCmdLimitKeyFlag(addKeyCmd, false)
 	hookKeysetCmdMemoFlag(addKeyCmd)
 	hookKeysetCmdAllowListFlag(addKeyCmd)
+	hookKeysetCmdSchedulesFlag(addKeyCmd)
 
 	Cmd.AddCommand(delKeyCmd)
 	hookKeysetCmdFlags(delKeyCmd, true, false, true, false)
@@ -116,6 +118,22 @@ func addKey(cmd *cobra.Command, args []string) {
 		acl = *allowList
 	}
 
+	var schedules []rate.Schedule
+	for _, v := range keysetCfg.Schedules {
+		sch, err := rate.ParseSchedule(v)
+		if err != nil {
+			logrus.WithField("schedule", v).WithError(err).Info("Invalid rate limit schedule")
+			return
+		}
+
+		if _, err := dbs.LoadRateLimitStrategy(sch.Strategy); err != nil {
+			logrus.WithField("schedule", v).WithError(err).Info("Failed to load rate limit strategy of schedule")
+			return
+		}
+
+		schedules = append(schedules, sch)
+	}
+
 	limitKey := strings.TrimSpace(keysetCfg.LimitKey)
 	if len(limitKey) == 0 { // generate random limit key if not provided
 		limitKey, err = rate.GenerateRandomLimitKey(keysetCfg.LimitType)
@@ -129,11 +147,12 @@ func addKey(cmd *cobra.Command, args []string) {
 		"allowlist": acl,
 		"limitKey":  limitKey,
 		"limitType": limitTypeMap[keysetCfg.LimitType],
+		"schedules": schedules,
 	}).Info("Press the Enter Key to add new rate limit key")
 	fmt.Scanln() // wait for Enter Key
 
 	err = dbs.RateLimitStore.AddRateLimit(
-		strategy.ID, acl.ID, keysetCfg.LimitType, limitKey, keysetCfg.Memo,
+		strategy.ID, acl.ID, keysetCfg.LimitType, limitKey, keysetCfg.Memo, schedules,
 	)
 	if err != nil {
 		logrus.WithError(err).Info("Failed to add rate limit key")
@@ -241,6 +260,7 @@ func listKeys(cmd *cobra.Command, args []string) {
 			"limitType": limitTypeMap[rate.LimitType(k.LimitType)],
 			"allowList": allowLists[k.AclID],
 			"memo":      k.Memo,
+			"schedules": k.Schedules,
 		}).Info("Key #", i)
 	}
 }
@@ -334,3 +354,10 @@ func hookKeysetCmdAllowListFlag(keysetCmd *cobra.Command) {
 		&keysetCfg.AllowList, "acl", "l", "", "allowlist used",
 	)
 }
+
+func hookKeysetCmdSchedulesFlag(keysetCmd *cobra.Command) {
+	keysetCmd.Flags().StringSliceVar(
+		&keysetCfg.Schedules, "schedule", nil,
+		"time-of-day schedule in UTC to apply another strategy (eg., '22:00-06:00=night')",
+	)
+}
@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type logSpanCmdConfig struct {
+	Network  string        // RPC network space ("cfx" or "eth")
+	LimitKey string        // rate limit key
+	MaxSpan  uint64        // max block span of log filter, 0 means unlimited
+	Memo     string        // memo such as override request ticket
+	Duration time.Duration // valid duration of override, 0 means never expires
+}
+
+var (
+	logSpanCfg logSpanCmdConfig
+
+	// grant also updates the existing override
+	grantLogSpanCmd = &cobra.Command{
+		Use:   "addspan",
+		Short: "Grant max block span override of getLogs for rate limit key",
+		Run:   grantLogSpan,
+	}
+
+	revokeLogSpanCmd = &cobra.Command{
+		Use:   "rmspan",
+		Short: "Revoke max block span override of getLogs for rate limit key",
+		Run:   revokeLogSpan,
+	}
+
+	listLogSpansCmd = &cobra.Command{
+		Use:   "lsspan",
+		Short: "List max block span overrides of getLogs",
+		Run:   listLogSpans,
+	}
+)
+
+func init() {
+	Cmd.AddCommand(grantLogSpanCmd)
+	hookLogSpanCmdFlags(grantLogSpanCmd, true, true)
+
+	Cmd.AddCommand(revokeLogSpanCmd)
+	hookLogSpanCmdFlags(revokeLogSpanCmd, true, false)
+
+	Cmd.AddCommand(listLogSpansCmd)
+	hookLogSpanCmdFlags(listLogSpansCmd, false, false)
+}
+
+func grantLogSpan(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	if len(logSpanCfg.LimitKey) == 0 {
+		logrus.Info("Rate limit key must not be empty")
+		return
+	}
+
+	dbs, err := storeCtx.GetMysqlStore(logSpanCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	// override is only applicable to the registered rate limit key
+	keysets, err := dbs.LoadRateLimitKeyset(&rate.KeysetFilter{KeySet: []string{logSpanCfg.LimitKey}})
+	if err != nil {
+		logrus.WithError(err).Info("Failed to load rate limit key")
+		return
+	}
+
+	if len(keysets) == 0 {
+		logrus.WithField("limitKey", logSpanCfg.LimitKey).Info("Rate limit key not found")
+		return
+	}
+
+	var expiresAt *time.Time
+	if logSpanCfg.Duration > 0 {
+		t := time.Now().Add(logSpanCfg.Duration)
+		expiresAt = &t
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"limitKey":  logSpanCfg.LimitKey,
+		"maxSpan":   logSpanCfg.MaxSpan,
+		"memo":      logSpanCfg.Memo,
+		"expiresAt": expiresAt,
+	}).Info("Press the Enter Key to grant max block span override of getLogs")
+	fmt.Scanln() // wait for Enter Key
+
+	err = dbs.GrantLogSpanOverride(logSpanCfg.LimitKey, logSpanCfg.MaxSpan, logSpanCfg.Memo, expiresAt)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to grant max block span override")
+		return
+	}
+
+	logrus.Info("Max block span override granted")
+}
+
+func revokeLogSpan(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	if len(logSpanCfg.LimitKey) == 0 {
+		logrus.Info("Rate limit key must not be empty")
+		return
+	}
+
+	dbs, err := storeCtx.GetMysqlStore(logSpanCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	logrus.WithField("limitKey", logSpanCfg.LimitKey).
+		Info("Press the Enter Key to revoke the max block span override!")
+	fmt.Scanln() // wait for Enter Key
+
+	removed, err := dbs.RevokeLogSpanOverride(logSpanCfg.LimitKey)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to revoke the max block span override")
+		return
+	}
+
+	if removed {
+		logrus.WithField("limitKey", logSpanCfg.LimitKey).Info("Max block span override revoked")
+	} else {
+		logrus.WithField("limitKey", logSpanCfg.LimitKey).Info("Max block span override not existed")
+	}
+}
+
+func listLogSpans(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(logSpanCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	overrides, err := dbs.LoadLogSpanOverrides()
+	if err != nil {
+		logrus.WithError(err).Info("Failed to load max block span overrides")
+		return
+	}
+
+	if len(overrides) == 0 {
+		logrus.Info("No max block span overrides found")
+		return
+	}
+
+	logrus.WithField("total", len(overrides)).Info("Max block span overrides loaded:")
+
+	for i, o := range overrides {
+		logrus.WithFields(logrus.Fields{
+			"limitKey":  o.LimitKey,
+			"maxSpan":   o.MaxSpan,
+			"memo":      o.Memo,
+			"expiresAt": o.ExpiresAt,
+			"grantedAt": o.UpdatedAt,
+		}).Info("Override #", i)
+	}
+}
+
+func hookLogSpanCmdFlags(logSpanCmd *cobra.Command, hookLimitKey, hookOverride bool) {
+	logSpanCmd.Flags().StringVarP(
+		&logSpanCfg.Network, "network", "n", "cfx", "RPC network space ('cfx' or 'eth')",
+	)
+	logSpanCmd.MarkFlagRequired("network")
+
+	if hookLimitKey {
+		logSpanCmd.Flags().StringVarP(&logSpanCfg.LimitKey, "key", "k", "", "rate limit key")
+		logSpanCmd.MarkFlagRequired("key")
+	}
+
+	if hookOverride {
+		logSpanCmd.Flags().Uint64Var(
+			&logSpanCfg.MaxSpan, "span", 0, "max block span of getLogs (0 means unlimited)",
+		)
+		logSpanCmd.MarkFlagRequired("span")
+
+		logSpanCmd.Flags().StringVarP(
+			&logSpanCfg.Memo, "memo", "m", "", "memo such as override request ticket",
+		)
+
+		logSpanCmd.Flags().DurationVar(
+			&logSpanCfg.Duration, "duration", 0, "valid duration of override (0 means never expires)",
+		)
+	}
+}
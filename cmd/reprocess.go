@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/sync/reprocess"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// reprocess settings
+	reprocessSetting struct {
+		network        string
+		processor      string
+		blockFrom      uint64
+		blockTo        uint64
+		reset          bool
+		listProcessors bool
+	}
+
+	reprocessCmd = &cobra.Command{
+		Use:   "reprocess",
+		Short: "Rebuild derived tables from the stored raw event logs without full chain resync",
+		Run:   startReprocess,
+	}
+)
+
+func init() {
+	reprocessCmd.Flags().StringVarP(
+		&reprocessSetting.network, "network", "n", "cfx", "network space of the db store (`cfx` or `eth`)",
+	)
+
+	reprocessCmd.Flags().StringVarP(
+		&reprocessSetting.processor, "processor", "p", "", "name of the derived table processor",
+	)
+
+	reprocessCmd.Flags().Uint64Var(
+		&reprocessSetting.blockFrom, "start", 0,
+		"the block number from which reprocess will start",
+	)
+	reprocessCmd.Flags().Uint64Var(
+		&reprocessSetting.blockTo, "end", 0,
+		"the block number until which reprocess will end, defaults to the latest block in db store",
+	)
+
+	reprocessCmd.Flags().BoolVar(
+		&reprocessSetting.reset, "reset", false,
+		"reset derived table and reprocess from the start block regardless of checkpoint",
+	)
+
+	reprocessCmd.Flags().BoolVarP(
+		&reprocessSetting.listProcessors, "list", "l", false, "list all available processors",
+	)
+
+	rootCmd.AddCommand(reprocessCmd)
+}
+
+func startReprocess(*cobra.Command, []string) {
+	if reprocessSetting.listProcessors {
+		logrus.WithField("processors", reprocess.Processors()).Info("Available derived table processors")
+		return
+	}
+
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(reprocessSetting.network)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get mysql store by network")
+	}
+
+	if dbs == nil {
+		logrus.Fatal("DB store is unavailable")
+	}
+
+	reprocessor, err := newReprocessor(dbs)
+	if err != nil {
+		logrus.WithError(err).
+			WithField("processors", reprocess.Processors()).
+			Fatal("Failed to create derived table processor")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		reprocessor.Run(ctx)
+	}()
+
+	util.GracefulShutdown(&wg, cancel)
+}
+
+// newReprocessor creates reprocessor with the derived table processor specified by command flags.
+func newReprocessor(db reprocess.Store) (*reprocess.Reprocessor, error) {
+	processor, err := reprocess.NewProcessor(reprocessSetting.processor, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return reprocess.MustNewReprocessor(
+		db, processor,
+		reprocess.WithBlockFrom(reprocessSetting.blockFrom),
+		reprocess.WithBlockTo(reprocessSetting.blockTo),
+		reprocess.WithReset(reprocessSetting.reset),
+	), nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/token"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReprocessStore stores one ERC-20 transfer event log per block, and persists the rebuilt token
+// transfers in memory.
+type mockReprocessStore struct {
+	mu        sync.Mutex
+	maxBn     uint64
+	configs   map[string]interface{}
+	transfers map[uint64][]*mysql.TokenTransfer // block number => transfers
+}
+
+func newMockReprocessStore(maxBn uint64) *mockReprocessStore {
+	return &mockReprocessStore{
+		maxBn:     maxBn,
+		configs:   make(map[string]interface{}),
+		transfers: make(map[uint64][]*mysql.TokenTransfer),
+	}
+}
+
+func (s *mockReprocessStore) MaxEpoch() (uint64, bool, error) {
+	return s.maxBn, s.maxBn > 0, nil
+}
+
+func (s *mockReprocessStore) BlockRange(epoch uint64) (citypes.RangeUint64, bool, error) {
+	return citypes.RangeUint64{From: epoch, To: epoch}, epoch <= s.maxBn, nil
+}
+
+func (s *mockReprocessStore) ScanLogs(ctx context.Context, bnFrom, bnTo uint64) (logs []*store.Log, err error) {
+	topic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	from, to := common.HexToHash("0xa"), common.HexToHash("0xb")
+	txHash := types.Hash(common.HexToHash("0x1").Hex())
+
+	for bn := bnFrom; bn <= bnTo; bn++ {
+		log := &types.Log{
+			Address:          cfxaddress.MustNewFromHex("0x1111111111111111111111111111111111111111", 1030),
+			Topics:           []types.Hash{types.Hash(topic.Hex()), types.Hash(from.Hex()), types.Hash(to.Hex())},
+			Data:             common.LeftPadBytes(big.NewInt(int64(bn)).Bytes(), 32),
+			EpochNumber:      types.NewBigInt(bn),
+			LogIndex:         types.NewBigInt(0),
+			TransactionHash:  &txHash,
+			TransactionIndex: types.NewBigInt(0),
+		}
+
+		logs = append(logs, store.ParseCfxLog(log, 0, bn, nil))
+	}
+
+	return logs, nil
+}
+
+func (s *mockReprocessStore) LoadConfig(confNames ...string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]interface{})
+	for _, name := range confNames {
+		if val, ok := s.configs[name]; ok {
+			result[name] = val
+		}
+	}
+
+	return result, nil
+}
+
+func (s *mockReprocessStore) StoreConfig(confName string, confVal interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configs[confName] = confVal
+	return nil
+}
+
+func (s *mockReprocessStore) ReplaceTokenTransfers(bnFrom, bnTo uint64, transfers []*mysql.TokenTransfer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for bn := bnFrom; bn <= bnTo; bn++ {
+		delete(s.transfers, bn)
+	}
+
+	for _, t := range transfers {
+		s.transfers[t.Bn] = append(s.transfers[t.Bn], t)
+	}
+
+	return nil
+}
+
+func (s *mockReprocessStore) ResetTokenTransfers(bnFrom uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for bn := range s.transfers {
+		if bn >= bnFrom {
+			delete(s.transfers, bn)
+		}
+	}
+
+	return nil
+}
+
+func (s *mockReprocessStore) transferBlocks() (bns []uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for bn := range s.transfers {
+		bns = append(bns, bn)
+	}
+
+	sort.Slice(bns, func(i, j int) bool { return bns[i] < bns[j] })
+	return bns
+}
+
+func TestReprocessCommand(t *testing.T) {
+	oldSetting := reprocessSetting
+	defer func() { reprocessSetting = oldSetting }()
+
+	db := newMockReprocessStore(10)
+	db.transfers[20] = []*mysql.TokenTransfer{{Bn: 20}} // out of block range to reprocess
+
+	reprocessSetting.processor = token.ProcessorName
+	reprocessSetting.blockFrom, reprocessSetting.blockTo = 3, 0
+
+	reprocessor, err := newReprocessor(db)
+	assert.NoError(t, err)
+
+	reprocessor.Run(context.Background())
+
+	// rebuilt till the latest block in store
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7, 8, 9, 10, 20}, db.transferBlocks())
+	assert.Equal(t, "5", db.transfers[5][0].Value)
+	assert.Equal(t, "0x1111111111111111111111111111111111111111", db.transfers[5][0].Contract)
+	assert.Equal(t, "11", db.configs["reprocess.checkpoint."+token.ProcessorName])
+
+	// reset from the start block regardless of checkpoint
+	reprocessSetting.blockFrom, reprocessSetting.reset = 8, true
+
+	reprocessor, err = newReprocessor(db)
+	assert.NoError(t, err)
+
+	reprocessor.Run(context.Background())
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7, 8, 9, 10}, db.transferBlocks())
+
+	// unknown processor
+	reprocessSetting.processor = "unknown"
+	_, err = newReprocessor(db)
+	assert.Error(t, err)
+}
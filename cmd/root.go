@@ -7,10 +7,15 @@ import (
 	"sync"
 
 	"github.com/Conflux-Chain/confura/cmd/acl"
+	"github.com/Conflux-Chain/confura/cmd/bench"
+	"github.com/Conflux-Chain/confura/cmd/jobs"
+	"github.com/Conflux-Chain/confura/cmd/migrate"
+	"github.com/Conflux-Chain/confura/cmd/node"
 	"github.com/Conflux-Chain/confura/cmd/noderoute"
 	"github.com/Conflux-Chain/confura/cmd/ratelimit"
 	"github.com/Conflux-Chain/confura/cmd/test"
 	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/cmd/webhook"
 	"github.com/Conflux-Chain/confura/config"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -60,6 +65,11 @@ func init() {
 	rootCmd.AddCommand(ratelimit.Cmd)
 	rootCmd.AddCommand(noderoute.Cmd)
 	rootCmd.AddCommand(acl.Cmd)
+	rootCmd.AddCommand(bench.Cmd)
+	rootCmd.AddCommand(jobs.Cmd)
+	rootCmd.AddCommand(migrate.Cmd)
+	rootCmd.AddCommand(webhook.Cmd)
+	rootCmd.AddCommand(node.Cmd)
 }
 
 func start(cmd *cobra.Command, args []string) {
@@ -84,11 +94,20 @@ func start(cmd *cobra.Command, args []string) {
 		defer syncCtx.Close()
 
 		startSyncServiceAdaptively(ctx, wg, syncCtx)
+
+		// start sync of extra networks
+		defer startSyncNetworks(ctx, wg)()
 	}
 
 	if rpcServerEnabled { // start RPC
-		startNativeSpaceRpcServer(ctx, wg, storeCtx)
-		startEvmSpaceRpcServer(ctx, wg, storeCtx)
+		// extra networks served on the URL prefixes of RPC endpoints of the same space
+		networks := mustLoadRpcNetworks()
+		defer closeRpcNetworks(networks)
+
+		startNativeSpaceRpcServer(ctx, wg, storeCtx, networks)
+		startEvmSpaceRpcServer(ctx, wg, storeCtx, networks)
+		mustMountedRpcNetworks(networks)
+
 		startNativeSpaceBridgeRpcServer(ctx, wg)
 	}
 
@@ -12,12 +12,25 @@ import (
 	"github.com/Conflux-Chain/confura/cmd/util"
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc"
+	"github.com/Conflux-Chain/confura/rpc/cache"
+	"github.com/Conflux-Chain/confura/rpc/graphql"
+	"github.com/Conflux-Chain/confura/rpc/grpc"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/cold"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/sync/activity"
+	"github.com/Conflux-Chain/confura/sync/contract"
+	"github.com/Conflux-Chain/confura/sync/token"
+	"github.com/Conflux-Chain/confura/sync/trace"
 	"github.com/Conflux-Chain/confura/util/acl"
+	"github.com/Conflux-Chain/confura/util/audit"
+	"github.com/Conflux-Chain/confura/util/gasstation"
 	"github.com/Conflux-Chain/confura/util/rate"
 	"github.com/Conflux-Chain/confura/util/relay"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/Conflux-Chain/confura/util/txpool"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
 )
@@ -31,9 +44,10 @@ var (
 	}
 
 	rpcCmd = &cobra.Command{
-		Use:   "rpc",
-		Short: "Start RPC service, including core space, evm space and CfxBridge RPC servers",
-		Run:   startRpcService,
+		Use:     "rpc",
+		Aliases: []string{"serve"},
+		Short:   "Start RPC service, including core space, evm space and CfxBridge RPC servers",
+		Run:     startRpcService,
 	}
 )
 
@@ -67,47 +81,119 @@ func startRpcService(*cobra.Command, []string) {
 	storeCtx := util.MustInitStoreContext()
 	defer storeCtx.Close()
 
+	// audit logging shared by all RPC servers
+	if logger, ok := audit.MustInitDefaultFromViper(); ok {
+		go logger.Run(ctx, &wg)
+		logrus.Info("RPC audit logging enabled")
+	}
+
+	// read queries of RPC handlers are routed to read replica if configured
+	readCtx := storeCtx.ReadReplica()
+
+	// extra networks served on the URL prefixes of RPC endpoints of the same space
+	networks := mustLoadRpcNetworks()
+	defer closeRpcNetworks(networks)
+
+	// RPC servers by space to dispatch on the same endpoint if configured
+	spaces := make(map[string]*rpcutil.Server)
+
 	if rpcOpt.cfxEnabled { // start core space RPC
-		startNativeSpaceRpcServer(ctx, &wg, storeCtx)
+		spaces[rpcutil.SpaceCfx] = startNativeSpaceRpcServer(ctx, &wg, readCtx, networks)
 	}
 
 	if rpcOpt.ethEnabled { // start evm space RPC
-		startEvmSpaceRpcServer(ctx, &wg, storeCtx)
+		spaces[rpcutil.SpaceEth] = startEvmSpaceRpcServer(ctx, &wg, readCtx, networks)
 	}
 
+	mustMountedRpcNetworks(networks)
+
+	var bridge *rpcutil.Server
 	if rpcOpt.cfxBridgeEnabled { // start core space bridge RPC
-		startNativeSpaceBridgeRpcServer(ctx, &wg)
+		bridge = startNativeSpaceBridgeRpcServer(ctx, &wg)
 	}
 
+	// serve both spaces on the same endpoint if configured
+	startDispatchRpcServer(ctx, &wg, spaces, bridge)
+
 	util.GracefulShutdown(&wg, cancel)
 }
 
-// startNativeSpaceRpcServer starts core space RPC server
-func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
-	var rateReg *rate.Registry
+// startNativeSpaceRpcServer starts core space RPC server, along with the extra networks of core space
+// served on the URL prefixes.
+func startNativeSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, networks []*rpcNetwork,
+) *rpcutil.Server {
+	network := &rpcNetwork{router: node.Factory().CreateRouter(), storeCtx: storeCtx}
+	server := newNativeSpaceRpcServer(ctx, wg, network)
+
+	for _, extra := range networks {
+		if extra.space == "cfx" {
+			extra.rateReg, extra.tenantReg = network.rateReg, network.tenantReg
+			server.Mount(extra.name, extra.prefix, newNativeSpaceRpcServer(ctx, wg, extra))
+			extra.mounted = true
+		}
+	}
 
-	router := node.Factory().CreateRouter()
-	clientProvider := node.NewCfxClientProvider(storeCtx.CfxDB, router)
-	relayer := relay.MustNewTxnRelayerFromViper()
+	// serve HTTP endpoint
+	httpEndpoint := viper.GetString("rpc.endpoint")
+	go server.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
 
-	option := rpc.CfxAPIOption{
-		TxnHandler: handler.MustNewCfxTxnHandler(relayer),
+	// serve Websocket endpoint
+	if wsEndpoint := viper.GetString("rpc.wsEndpoint"); len(wsEndpoint) > 0 {
+		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
 	}
 
-	if vfc, ok := vfclient.MustNewCfxClientFromViper(); ok {
-		option.VirtualFilterClient = vfc
-		logrus.Info("Virtual filter client enabled")
+	// serve debug endpoint
+	if debugEndpoint := viper.GetString("rpc.debugEndpoint"); len(debugEndpoint) > 0 {
+		server := rpc.MustNewDebugServer()
+		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
 	}
 
+	return server
+}
+
+// newNativeSpaceRpcServer creates core space RPC server of the specified network.
+func newNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, network *rpcNetwork) *rpcutil.Server {
+	storeCtx := network.storeCtx
+	clientProvider := node.NewCfxClientProvider(storeCtx.CfxDB, network.router)
+
+	var option rpc.CfxAPIOption
+
+	// relayers and node management service are only available for the default network
+	if !network.extra() {
+		relayer := relay.MustNewTxnRelayerFromViper()
+		option.TxnHandler = handler.MustNewCfxTxnHandler(relayer)
+
+		if vfc, ok := vfclient.MustNewCfxClientFromViper(); ok {
+			option.VirtualFilterClient = vfc
+			logrus.Info("Virtual filter client enabled")
+		}
+	}
+
+	option.TraceHandler = handler.MustNewTraceHandlerFromViper(network.cacheName("cfx"), "rpc.trace")
+	option.LogSpanLimiter = handler.MustNewLogSpanLimiterFromViper()
+	option.TxTracker = mustStartTxTracker(ctx, "cfx", "rpc.txTracker", txpool.NewCfxChain(clientProvider))
+
 	// initialize store handler
 	if storeCtx.CfxDB != nil {
 		option.StoreHandler = handler.NewCfxCommonStoreHandler("db", storeCtx.CfxDB, option.StoreHandler)
 
-		rateKeyLoader := rate.NewKeyLoader(storeCtx.CfxDB.LoadRateLimitKeyInfos)
-		rateReg = rate.NewRegistry(rateKeyLoader, acl.NewCfxValidator)
+		// periodically reload log span overrides from db
+		if option.LogSpanLimiter != nil {
+			go option.LogSpanLimiter.AutoReload(15*time.Second, storeCtx.CfxDB.LoadLogSpanOverrides)
+		}
+
+		// rate limits and tenants are shared by the extra networks
+		if !network.extra() {
+			rateKeyLoader := rate.NewKeyLoader(storeCtx.CfxDB.LoadRateLimitKeyInfos)
+			network.rateReg = rate.NewRegistry(rateKeyLoader, acl.NewCfxValidator)
+
+			// periodically reload rate limit settings from db
+			go network.rateReg.AutoReload(15*time.Second, storeCtx.CfxDB.LoadRateLimitConfigs)
 
-		// periodically reload rate limit settings from db
-		go rateReg.AutoReload(15*time.Second, storeCtx.CfxDB.LoadRateLimitConfigs)
+			// tag requests with tenant by API key and account usages if enabled
+			network.tenantReg = mustStartTenantRegistry(ctx, wg, "rpc", storeCtx.CfxDB)
+		}
 	}
 
 	if storeCtx.CfxCache != nil {
@@ -115,7 +201,10 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 	}
 
 	// initialize gas station handler
-	gasHandler := handler.NewGasStationHandler(storeCtx.CfxDB, storeCtx.CfxCache)
+	gasOracle := mustStartGasPriceOracle(
+		ctx, wg, "cfx", "rpc.gasStation", gasstation.NewCfxSampler(clientProvider), !network.extra(),
+	)
+	gasHandler := handler.NewGasStationHandler(storeCtx.CfxDB, storeCtx.CfxCache, gasOracle)
 
 	if storeCtx.CfxDB != nil {
 		// initialize pruned logs handler
@@ -133,78 +222,253 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 		option.LogApiHandler = handler.NewCfxLogsApiHandler(storeCtx.CfxDB, prunedHandler)
 	}
 
+	// report gateway status via `confura_status` if enabled
+	if status := rpc.MustNewCfxStatusReporterFromViper(storeCtx.CfxDB); status != nil {
+		option.StatusReporter = status
+		go status.Run(ctx)
+	}
+
 	// initialize RPC server
 	exposedModules := viper.GetStringSlice("rpc.exposedModules")
-	server := rpc.MustNewNativeSpaceServer(rateReg, clientProvider, gasHandler, exposedModules, option)
+
+	return rpc.MustNewNativeSpaceServer(
+		network.rateReg, network.tenantReg, clientProvider, gasHandler, exposedModules, option,
+	)
+}
+
+// startEvmSpaceRpcServer starts evm space RPC server, along with the extra networks of evm space
+// served on the URL prefixes.
+func startEvmSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, networks []*rpcNetwork,
+) *rpcutil.Server {
+	network := &rpcNetwork{router: node.EthFactory().CreateRouter(), storeCtx: storeCtx}
+	server := newEvmSpaceRpcServer(ctx, wg, network)
+
+	for _, extra := range networks {
+		if extra.space == "eth" {
+			extra.rateReg, extra.tenantReg = network.rateReg, network.tenantReg
+			server.Mount(extra.name, extra.prefix, newEvmSpaceRpcServer(ctx, wg, extra))
+			extra.mounted = true
+		}
+	}
 
 	// serve HTTP endpoint
-	httpEndpoint := viper.GetString("rpc.endpoint")
+	httpEndpoint := viper.GetString("ethrpc.endpoint")
 	go server.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
 
 	// serve Websocket endpoint
-	if wsEndpoint := viper.GetString("rpc.wsEndpoint"); len(wsEndpoint) > 0 {
+	if wsEndpoint := viper.GetString("ethrpc.wsEndpoint"); len(wsEndpoint) > 0 {
 		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
 	}
 
 	// serve debug endpoint
-	if debugEndpoint := viper.GetString("rpc.debugEndpoint"); len(debugEndpoint) > 0 {
+	if debugEndpoint := viper.GetString("ethrpc.debugEndpoint"); len(debugEndpoint) > 0 {
 		server := rpc.MustNewDebugServer()
 		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
 	}
+
+	return server
 }
 
-// startEvmSpaceRpcServer starts evm space RPC server
-func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
-	var rateReg *rate.Registry
+// newEvmSpaceRpcServer creates evm space RPC server of the specified network.
+func newEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, network *rpcNetwork) *rpcutil.Server {
+	storeCtx := network.storeCtx
+	clientProvider := node.NewEthClientProvider(storeCtx.EthDB, network.router)
+
+	var option rpc.EthAPIOption
+
+	// relayers and node management service are only available for the default network
+	if !network.extra() {
+		relayer := relay.MustNewEthTxnRelayerFromViper()
+		option.TxnHandler = handler.MustNewEthTxnHandler(relayer)
 
-	router := node.EthFactory().CreateRouter()
-	clientProvider := node.NewEthClientProvider(storeCtx.EthDB, router)
-	relayer := relay.MustNewEthTxnRelayerFromViper()
+		if vfc, ok := vfclient.MustNewEthClientFromViper(); ok {
+			option.VirtualFilterClient = vfc
+			logrus.Info("Virtual filter client enabled")
+		}
+	}
+
+	if blockCache, ok := cache.MustNewTieredCacheFromViper(network.cacheName("eth_block"), "ethrpc.blockCache"); ok {
+		option.BlockCache = blockCache
+		logrus.Info("Tiered block cache enabled")
+	}
+
+	if callCache, ok := cache.MustNewCallCacheFromViper("ethrpc.callCache"); ok {
+		option.CallCache = callCache
+		logrus.Info("Call result cache enabled")
+	}
 
-	option := rpc.EthAPIOption{
-		TxnHandler: handler.MustNewEthTxnHandler(relayer),
+	if idempotencyCache, ok := cache.MustNewIdempotencyCacheFromViper("ethrpc.idempotency"); ok {
+		option.IdempotencyCache = idempotencyCache
+		logrus.Info("Idempotency keys of write RPCs enabled")
 	}
 
-	if vfc, ok := vfclient.MustNewEthClientFromViper(); ok {
-		option.VirtualFilterClient = vfc
-		logrus.Info("Virtual filter client enabled")
+	if staleCache, ok := cache.MustNewStaleCacheFromViper("ethrpc.staleCache"); ok {
+		option.StaleCache = staleCache
+		logrus.Info("Stale-while-revalidate cache enabled")
 	}
 
+	option.TraceHandler = handler.MustNewTraceHandlerFromViper(network.cacheName("eth"), "ethrpc.trace")
+	option.LogSpanLimiter = handler.MustNewLogSpanLimiterFromViper()
+	option.LogsBackfill = rpc.MustNewEthLogsBackfillConfigFromViper()
+	option.PubSubFailover = rpc.MustNewEthPubSubFailoverConfigFromViper()
+	option.TxTracker = mustStartTxTracker(ctx, "eth", "ethrpc.txTracker", txpool.NewEthChain(clientProvider))
+	option.NonceTracker = txpool.MustNewNonceTrackerFromViper("ethrpc.nonceTracker")
+	option.RelayCache = txpool.MustNewRelayCacheFromViper("ethrpc.relayCache")
+
+	// answer static or semi-static methods locally by upstreams of the default network if enabled
+	if answerer, ok := rpc.MustNewEthLocalAnswererFromViper(); ok && !network.extra() {
+		option.LocalAnswerer = answerer
+		go answerer.Run(ctx)
+		logrus.Info("Local answering of static methods enabled")
+	}
+
+	// initialize gas station handler
+	gasOracle := mustStartGasPriceOracle(
+		ctx, wg, "eth", "ethrpc.gasStation", gasstation.NewEthSampler(clientProvider), !network.extra(),
+	)
+	option.GasStationHandler = handler.NewGasStationHandler(nil, nil, gasOracle)
+
 	if storeCtx.EthDB != nil {
 		// initialize store handler
 		option.StoreHandler = handler.NewEthStoreHandler(storeCtx.EthDB, nil)
-		// initialize logs api handler
-		option.LogApiHandler = handler.NewEthLogsApiHandler(storeCtx.EthDB)
+		// resolve `latest` block to the head of store if consistent read mode opted in by client
+		option.HeadStore = storeCtx.EthDB
+		// initialize logs api handler, which serves pruned event logs from cold storage if enabled
+		var coldStore *cold.LogStore
+		if !network.extra() {
+			var ok bool
+			if coldStore, ok = cold.MustNewLogStoreFromViper("ethstore.cold", storeCtx.EthDB); ok {
+				logrus.Info("Cold storage tier of event logs enabled")
+			}
+		}
+
+		option.LogApiHandler = handler.NewEthLogsApiHandler(storeCtx.EthDB, coldStore)
+		// initialize heavy logs query job manager
+		option.LogsJobManager = handler.MustNewEthLogsJobManagerFromViper(ctx, option.LogApiHandler)
+
+		// serve token balances and transfers indexed by sync service if enabled
+		if token.MustLoadConfigFromViper().Enabled {
+			option.TokenStore = storeCtx.EthDB
+		}
+
+		// serve transactions by address indexed by sync service if enabled
+		if activity.MustLoadConfigFromViper().Enabled {
+			option.AddressTxStore = storeCtx.EthDB
+		}
+
+		// serve internal transactions indexed by sync service if enabled
+		if trace.MustLoadConfigFromViper().Enabled {
+			option.InternalTxStore = storeCtx.EthDB
+		}
+
+		// serve contract creations and accelerate `eth_getCode` by index of sync service if enabled
+		if contract.MustLoadConfigFromViper().Enabled {
+			option.ContractIndexStore = storeCtx.EthDB
+		}
+
+		// periodically reload log span overrides from db
+		if option.LogSpanLimiter != nil {
+			go option.LogSpanLimiter.AutoReload(15*time.Second, storeCtx.EthDB.LoadLogSpanOverrides)
+		}
 
-		rateKeyLoader := rate.NewKeyLoader(storeCtx.EthDB.LoadRateLimitKeyInfos)
-		rateReg = rate.NewRegistry(rateKeyLoader, acl.NewEthValidator)
+		// standalone endpoints, rate limits and tenants are only available for the default network
+		if !network.extra() {
+			rateKeyLoader := rate.NewKeyLoader(storeCtx.EthDB.LoadRateLimitKeyInfos)
+			network.rateReg = rate.NewRegistry(rateKeyLoader, acl.NewEthValidator)
+
+			// periodically reload rate limit settings from db
+			go network.rateReg.AutoReload(15*time.Second, storeCtx.EthDB.LoadRateLimitConfigs)
+
+			// tag requests with tenant by API key and account usages if enabled
+			network.tenantReg = mustStartTenantRegistry(ctx, wg, "ethrpc", storeCtx.EthDB)
+
+			// serve GraphQL queries over stored chain data if endpoint configured, which are
+			// authenticated, rate limited and accounted the same as evm space RPC requests
+			backend := graphql.NewStoreBackend(storeCtx.EthDB, clientProvider)
+			graphqlMiddleware := rpc.MustNewGraphqlMiddleware(network.rateReg, network.tenantReg)
+			if server, ok := graphql.MustNewServerFromViper("ethrpc.graphql", backend, graphqlMiddleware); ok {
+				go server.MustServeGraceful(ctx, wg)
+			}
+
+			// serve gRPC requests over stored chain data for internal services if endpoint configured
+			if server, ok := grpc.MustNewServerFromViper("ethrpc.grpc", backend); ok {
+				go server.MustServeGraceful(ctx, wg)
+			}
+		}
+	}
 
-		// periodically reload rate limit settings from db
-		go rateReg.AutoReload(15*time.Second, storeCtx.EthDB.LoadRateLimitConfigs)
+	// report gateway status via `confura_status` if enabled
+	if status := rpc.MustNewEthStatusReporterFromViper(storeCtx.EthDB); status != nil {
+		option.StatusReporter = status
+		go status.Run(ctx)
 	}
 
 	// initialize RPC server
 	exposedModules := viper.GetStringSlice("ethrpc.exposedModules")
-	server := rpc.MustNewEvmSpaceServer(rateReg, clientProvider, exposedModules, option)
 
-	// serve HTTP endpoint
-	httpEndpoint := viper.GetString("ethrpc.endpoint")
-	go server.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
+	return rpc.MustNewEvmSpaceServer(network.rateReg, network.tenantReg, clientProvider, exposedModules, option)
+}
 
-	// serve Websocket endpoint
-	if wsEndpoint := viper.GetString("ethrpc.wsEndpoint"); len(wsEndpoint) > 0 {
-		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
+// mustStartTenantRegistry starts tenant registry to account usages if API key enabled, and serves
+// the admin RPC to manage API keys if endpoint configured.
+func mustStartTenantRegistry(
+	ctx context.Context, wg *sync.WaitGroup, key string, db *mysql.MysqlStore,
+) *tenant.Registry {
+	if adminEndpoint := viper.GetString(key + ".adminEndpoint"); len(adminEndpoint) > 0 {
+		server := rpc.MustNewAdminServer(db)
+		go server.MustServeGraceful(ctx, wg, adminEndpoint, rpcutil.ProtocolHttp)
 	}
 
-	// serve debug endpoint
-	if debugEndpoint := viper.GetString("ethrpc.debugEndpoint"); len(debugEndpoint) > 0 {
-		server := rpc.MustNewDebugServer()
-		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
+	registry, ok := tenant.MustNewRegistryFromViper(key+".apiKey", db)
+	if !ok {
+		return nil
 	}
+
+	go registry.Run(ctx, wg)
+
+	logrus.WithField("key", key).Info("API key tenant registry enabled")
+
+	return registry
+}
+
+// mustStartGasPriceOracle starts gas price oracle if enabled, and serves the gas price
+// suggestions over HTTP if endpoint configured and `serve` specified.
+func mustStartGasPriceOracle(
+	ctx context.Context, wg *sync.WaitGroup, space, key string, sampler gasstation.Sampler, serve bool,
+) *gasstation.Oracle {
+	oracle, ok := gasstation.MustNewOracleFromViper(space, key, sampler)
+	if !ok {
+		return nil
+	}
+
+	go oracle.Run(ctx)
+
+	if endpoint := oracle.Config().Endpoint; len(endpoint) > 0 && serve {
+		go oracle.MustServeGraceful(ctx, wg, endpoint)
+	}
+
+	logrus.WithField("space", space).Info("Gas price oracle enabled")
+
+	return oracle
+}
+
+// mustStartTxTracker starts transaction tracker if enabled.
+func mustStartTxTracker(ctx context.Context, space, key string, chain txpool.Chain) *txpool.Tracker {
+	tracker := txpool.MustNewTrackerFromViper(space, key, chain)
+	if tracker == nil {
+		return nil
+	}
+
+	go tracker.Run(ctx)
+
+	logrus.WithField("space", space).Info("Transaction tracker enabled")
+
+	return tracker
 }
 
 // startNativeSpaceBridgeRpcServer starts core space bridge RPC server
-func startNativeSpaceBridgeRpcServer(ctx context.Context, wg *sync.WaitGroup) {
+func startNativeSpaceBridgeRpcServer(ctx context.Context, wg *sync.WaitGroup) *rpcutil.Server {
 	var config rpc.CfxBridgeServerConfig
 
 	viperutil.MustUnmarshalKey("rpc.cfxBridge", &config)
@@ -212,4 +476,121 @@ func startNativeSpaceBridgeRpcServer(ctx context.Context, wg *sync.WaitGroup) {
 
 	server := rpc.MustNewNativeSpaceBridgeServer(&config)
 	go server.MustServeGraceful(ctx, wg, config.Endpoint, rpcutil.ProtocolHttp)
+
+	return server
+}
+
+// startDispatchRpcServer serves RPC of both spaces on the same endpoint if configured, which dispatches
+// requests by URL path or method prefix. Besides, core space methods could be translated into evm space
+// via CfxBridge if core space RPC server not started.
+func startDispatchRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, spaces map[string]*rpcutil.Server, bridge *rpcutil.Server,
+) {
+	var config rpcutil.DispatchConfig
+	viperutil.MustUnmarshalKey("rpc.dispatch", &config)
+
+	if len(config.Endpoint) == 0 && len(config.WsEndpoint) == 0 {
+		return
+	}
+
+	if _, ok := spaces[rpcutil.SpaceCfx]; !ok && config.Translate {
+		if bridge == nil {
+			var bridgeConf rpc.CfxBridgeServerConfig
+			viperutil.MustUnmarshalKey("rpc.cfxBridge", &bridgeConf)
+
+			bridge = rpc.MustNewNativeSpaceBridgeServer(&bridgeConf)
+		}
+
+		spaces[rpcutil.SpaceCfx] = bridge
+		logrus.Info("Core space methods translated into evm space via CfxBridge")
+	}
+
+	server, err := rpcutil.NewDispatchServer(spaces, config.DefaultSpace)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create RPC dispatch server")
+	}
+
+	if len(config.Endpoint) > 0 {
+		go server.MustServeGraceful(ctx, wg, config.Endpoint, rpcutil.ProtocolHttp)
+	}
+
+	if len(config.WsEndpoint) > 0 {
+		go server.MustServeGraceful(ctx, wg, config.WsEndpoint, rpcutil.ProtocolWS)
+	}
+}
+
+// rpcNetwork network served by RPC server, either the default one or an extra network (eg., testnet)
+// mounted on the URL prefix of RPC endpoints of the same space.
+type rpcNetwork struct {
+	name, space, prefix string // empty for the default network
+
+	router   node.Router
+	storeCtx util.StoreContext
+
+	// rate limits and tenants of the default network, which are shared by the extra networks
+	rateReg   *rate.Registry
+	tenantReg *tenant.Registry
+
+	mounted bool // whether the extra network mounted on RPC server
+}
+
+// mustLoadRpcNetworks loads the extra networks served on the URL prefixes of RPC endpoints.
+func mustLoadRpcNetworks() (networks []*rpcNetwork) {
+	for _, conf := range util.MustLoadNetworksFromViper() {
+		networks = append(networks, newRpcNetwork(conf))
+	}
+
+	return networks
+}
+
+// mustMountedRpcNetworks ensures all the extra networks mounted on the RPC server of the same space.
+func mustMountedRpcNetworks(networks []*rpcNetwork) {
+	for _, network := range networks {
+		if !network.mounted {
+			logrus.WithField("network", network.name).Fatal("RPC server of the network space not started")
+		}
+	}
+}
+
+func closeRpcNetworks(networks []*rpcNetwork) {
+	for _, network := range networks {
+		network.close()
+	}
+}
+
+func newRpcNetwork(conf util.NetworkConfig) *rpcNetwork {
+	logrus.WithFields(logrus.Fields{
+		"name": conf.Name, "space": conf.Space, "prefix": conf.Prefix,
+	}).Info("Extra network served on URL prefix")
+
+	return &rpcNetwork{
+		name:     conf.Name,
+		space:    conf.Space,
+		prefix:   conf.Prefix,
+		router:   conf.Router(),
+		storeCtx: conf.MustInitStoreContext(),
+	}
+}
+
+// extra returns whether the network is an extra one served on URL prefix.
+func (n *rpcNetwork) extra() bool {
+	return len(n.name) > 0
+}
+
+// cacheName returns the cache name scoped by the network, so that the shared cache tier (eg., redis)
+// is not polluted by other networks.
+func (n *rpcNetwork) cacheName(name string) string {
+	if n.extra() {
+		return n.name + "_" + name
+	}
+
+	return name
+}
+
+func (n *rpcNetwork) close() {
+	if router, ok := n.router.(*node.ManagedRouter); ok {
+		router.Close()
+	}
+
+	n.storeCtx.Close()
 }
@@ -6,8 +6,17 @@ import (
 
 	"github.com/Conflux-Chain/confura/cmd/util"
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/cold"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/Conflux-Chain/confura/sync/activity"
 	"github.com/Conflux-Chain/confura/sync/catchup"
+	"github.com/Conflux-Chain/confura/sync/contract"
+	"github.com/Conflux-Chain/confura/sync/stream"
+	"github.com/Conflux-Chain/confura/sync/token"
+	"github.com/Conflux-Chain/confura/sync/trace"
+	"github.com/Conflux-Chain/confura/sync/webhook"
+	"github.com/Conflux-Chain/confura/util/scheduler"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +28,7 @@ var (
 		kvSyncEnabled  bool
 		ethSyncEnabled bool
 		catchupEnabled bool
+		networkEnabled bool
 	}
 
 	// catch up settings
@@ -56,6 +66,11 @@ func init() {
 		&syncOpt.catchupEnabled, "catchup", false, "start core space fast catchup server",
 	)
 
+	// boot flag for sync of extra networks
+	syncCmd.Flags().BoolVar(
+		&syncOpt.networkEnabled, "networks", false, "start sync server of extra networks",
+	)
+
 	// load fast catchup settings from command line arguments
 	syncCmd.Flags().Uint64Var(
 		&catchupSetting.epochFrom, "start", 0,
@@ -79,7 +94,7 @@ func init() {
 
 func startSyncService(*cobra.Command, []string) {
 	if !syncOpt.dbSyncEnabled && !syncOpt.kvSyncEnabled &&
-		!syncOpt.ethSyncEnabled && !syncOpt.catchupEnabled {
+		!syncOpt.ethSyncEnabled && !syncOpt.catchupEnabled && !syncOpt.networkEnabled {
 		logrus.Fatal("No Sync server specified")
 	}
 
@@ -92,15 +107,17 @@ func startSyncService(*cobra.Command, []string) {
 	syncCtx := util.MustInitSyncContext(storeCtx)
 	defer syncCtx.Close()
 
+	sched := mustNewJobScheduler(syncCtx)
+
 	var subs []cisync.EpochSubscriber
 
 	if syncOpt.dbSyncEnabled { // start DB sync
-		syncer := startSyncCfxDatabase(ctx, &wg, syncCtx)
+		syncer := startSyncCfxDatabase(ctx, &wg, syncCtx, sched)
 		subs = append(subs, syncer)
 	}
 
 	if syncOpt.kvSyncEnabled { // start KV sync
-		if syncer := startSyncCfxCache(ctx, &wg, syncCtx); syncer != nil {
+		if syncer := startSyncCfxCache(ctx, &wg, syncCtx, sched); syncer != nil {
 			subs = append(subs, syncer)
 		}
 	}
@@ -115,7 +132,19 @@ func startSyncService(*cobra.Command, []string) {
 	}
 
 	if syncOpt.ethSyncEnabled { // start ETH sync
-		startSyncEthDatabase(ctx, &wg, syncCtx)
+		startSyncEthDatabase(ctx, &wg, syncCtx, sched)
+	}
+
+	if syncOpt.dbSyncEnabled && syncOpt.ethSyncEnabled { // coordinate sync of both spaces
+		go syncCtx.Coordinator.Run(ctx, &wg, syncCtx.CfxDB, syncCtx.EthDB)
+	}
+
+	if syncOpt.networkEnabled { // start sync of extra networks
+		defer startSyncNetworks(ctx, &wg)()
+	}
+
+	if sched != nil { // start job scheduler
+		go sched.Run(ctx, &wg)
 	}
 
 	util.GracefulShutdown(&wg, cancel)
@@ -127,15 +156,17 @@ func startSyncServiceAdaptively(ctx context.Context, wg *sync.WaitGroup, syncCtx
 		logrus.Fatal("No data sync configured")
 	}
 
+	sched := mustNewJobScheduler(syncCtx)
+
 	var subs []cisync.EpochSubscriber
 
 	if syncCtx.CfxDB != nil { // start DB sync
-		syncer := startSyncCfxDatabase(ctx, wg, syncCtx)
+		syncer := startSyncCfxDatabase(ctx, wg, syncCtx, sched)
 		subs = append(subs, syncer)
 	}
 
 	if syncCtx.CfxCache != nil { // start KV sync
-		if syncer := startSyncCfxCache(ctx, wg, syncCtx); syncer != nil {
+		if syncer := startSyncCfxCache(ctx, wg, syncCtx, sched); syncer != nil {
 			subs = append(subs, syncer)
 		}
 	}
@@ -146,23 +177,65 @@ func startSyncServiceAdaptively(ctx context.Context, wg *sync.WaitGroup, syncCtx
 	}
 
 	if syncCtx.EthDB != nil { // start ETH sync
-		startSyncEthDatabase(ctx, wg, syncCtx)
+		startSyncEthDatabase(ctx, wg, syncCtx, sched)
+	}
+
+	if syncCtx.CfxDB != nil && syncCtx.EthDB != nil { // coordinate sync of both spaces
+		go syncCtx.Coordinator.Run(ctx, wg, syncCtx.CfxDB, syncCtx.EthDB)
+	}
+
+	if sched != nil { // start job scheduler
+		go sched.Run(ctx, wg)
+	}
+}
+
+// mustNewJobScheduler creates job scheduler with jobs persisted in the core space database if
+// available, otherwise the evm space database, or returns nil if disabled.
+func mustNewJobScheduler(syncCtx util.SyncContext) *scheduler.Scheduler {
+	switch {
+	case syncCtx.CfxDB != nil:
+		return scheduler.MustNewSchedulerFromViper(syncCtx.CfxDB)
+	case syncCtx.EthDB != nil:
+		return scheduler.MustNewSchedulerFromViper(syncCtx.EthDB)
+	default:
+		return nil
 	}
 }
 
-func startSyncCfxDatabase(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) *cisync.DatabaseSyncer {
+func startSyncCfxDatabase(
+	ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext, sched *scheduler.Scheduler,
+) *cisync.DatabaseSyncer {
 	logrus.Info("Start to sync core space blockchain data into database")
 
-	syncer := cisync.MustNewDatabaseSyncer(syncCtx.SyncCfx, syncCtx.CfxDB)
+	// fast catch-up workers share the rate limit against the same fullnode with evm space sync
+	syncer := cisync.MustNewDatabaseSyncer(
+		syncCtx.SyncCfx, syncCtx.CfxDB, syncCtx.Coordinator.ClientOptions()...,
+	)
 	go syncer.Sync(ctx, wg)
 
+	// start core space data integrity audit
+	if auditor := cisync.MustNewCfxAuditor(syncCtx.SyncCfx, syncCtx.CfxDB); auditor.Enabled() {
+		go auditor.Run(ctx, wg, syncer)
+	}
+
+	// start core space gap detection and backfill
+	if backfiller := cisync.MustNewCfxGapBackfiller(syncCtx.SyncCfx, syncCtx.CfxDB); backfiller.Enabled() {
+		go backfiller.Run(ctx, wg, syncer)
+	}
+
 	// start core space db prune
-	go syncCtx.CfxDB.Prune()
+	if sched != nil {
+		sched.Register("cfx.db.prune", mysql.ArchivePruneInterval, syncCtx.CfxDB.PruneOnce)
+	} else {
+		go syncCtx.CfxDB.Prune()
+	}
 
 	return syncer
 }
 
-func startSyncCfxCache(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) *cisync.KVCacheSyncer {
+func startSyncCfxCache(
+	ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext, sched *scheduler.Scheduler,
+) *cisync.KVCacheSyncer {
 	if store.StoreConfig().IsChainBlockDisabled() &&
 		store.StoreConfig().IsChainTxnDisabled() &&
 		store.StoreConfig().IsChainReceiptDisabled() {
@@ -178,19 +251,130 @@ func startSyncCfxCache(ctx context.Context, wg *sync.WaitGroup, syncCtx util.Syn
 
 	// start core space cache prune
 	cpruner := cisync.MustNewKVCachePruner(syncCtx.CfxCache)
-	go cpruner.Prune(ctx, wg)
+	if sched != nil {
+		sched.Register("cfx.cache.prune", cpruner.Interval(), cpruner.PruneOnce)
+	} else {
+		go cpruner.Prune(ctx, wg)
+	}
 
 	return csyncer
 }
 
-func startSyncEthDatabase(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) {
+func startSyncEthDatabase(
+	ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext, sched *scheduler.Scheduler,
+) {
 	logrus.Info("Start to sync evm space blockchain data into database")
 
+	// push matched event logs to webhooks as blocks synced, which must be observed before sync
+	if notifier, ok := webhook.MustNewNotifierFromViper(syncCtx.EthDB); ok {
+		syncCtx.EthDB.AddEpochDataObserver(notifier)
+		go notifier.Run(ctx, wg)
+	}
+
+	// publish synced chain data to message broker, which must be observed before sync as well
+	if streamer, ok := stream.MustNewStreamerFromViper(syncCtx.EthDB); ok {
+		syncCtx.EthDB.AddEpochDataObserver(streamer)
+		go streamer.Run(ctx, wg)
+	}
+
+	// index token transfers and balances along with synced blocks
+	if indexer, ok := token.MustNewIndexerFromViper(syncCtx.EthDB); ok {
+		syncCtx.EthDB.AddEpochDataObserver(indexer)
+		logrus.Info("Token transfer index enabled")
+	}
+
+	// index transactions by address along with synced blocks
+	if indexer, ok := activity.MustNewIndexerFromViper(syncCtx.EthDB); ok {
+		syncCtx.EthDB.AddEpochDataObserver(indexer)
+		logrus.Info("Address activity index enabled")
+	}
+
+	// index contract creations along with synced blocks
+	if indexer, ok := contract.MustNewIndexerFromViper(syncCtx.EthDB); ok {
+		syncCtx.EthDB.AddEpochDataObserver(indexer)
+		logrus.Info("Contract creation index enabled")
+	}
+
+	// index internal transactions traced from fullnode behind synced blocks
+	if indexer, ok := trace.MustNewIndexerFromViper(syncCtx.SyncEth, syncCtx.EthDB); ok {
+		go indexer.Run(ctx, wg)
+	}
+
 	ethSyncer := cisync.MustNewEthSyncer(syncCtx.SyncEth, syncCtx.EthDB)
 	go ethSyncer.Sync(ctx, wg)
 
+	// start evm space data integrity audit
+	if auditor := cisync.MustNewEthAuditor(syncCtx.SyncEth, syncCtx.EthDB); auditor.Enabled() {
+		go auditor.Run(ctx, wg, ethSyncer)
+	}
+
+	// start evm space gap detection and backfill
+	if backfiller := cisync.MustNewEthGapBackfiller(syncCtx.SyncEth, syncCtx.EthDB); backfiller.Enabled() {
+		go backfiller.Run(ctx, wg, ethSyncer)
+	}
+
+	// offload event log partitions to cold storage before pruned
+	if coldStore, ok := cold.MustNewLogStoreFromViper("ethstore.cold", syncCtx.EthDB); ok {
+		syncCtx.EthDB.SetLogArchiver(coldStore)
+		logrus.Info("Cold storage tier of event logs enabled")
+	}
+
 	// start evm space db prune
-	go syncCtx.EthDB.Prune()
+	if sched != nil {
+		sched.Register("eth.db.prune", mysql.ArchivePruneInterval, syncCtx.EthDB.PruneOnce)
+	} else {
+		go syncCtx.EthDB.Prune()
+	}
+}
+
+// startSyncNetworks starts to sync chain data of all the extra networks configured to sync, and returns
+// the function to release their store and sync contexts.
+func startSyncNetworks(ctx context.Context, wg *sync.WaitGroup) func() {
+	var closers []func()
+
+	for _, conf := range util.MustLoadNetworksFromViper() {
+		if !conf.Sync {
+			continue
+		}
+
+		netStoreCtx := conf.MustInitStoreContext()
+		netSyncCtx := conf.MustInitSyncContext(netStoreCtx)
+		closers = append(closers, netSyncCtx.Close, netStoreCtx.Close)
+
+		startSyncNetwork(ctx, wg, conf, netSyncCtx)
+	}
+
+	return func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+}
+
+// startSyncNetwork starts to sync chain data of the extra network into its own database. Note, the
+// auxiliary pipelines (eg., indexers, webhooks and cold storage) are only available for the default
+// network, and db prune is not scheduled by the job scheduler of the default network.
+func startSyncNetwork(ctx context.Context, wg *sync.WaitGroup, conf util.NetworkConfig, syncCtx util.SyncContext) {
+	logrus.WithFields(logrus.Fields{
+		"network": conf.Name, "space": conf.Space,
+	}).Info("Start to sync blockchain data of extra network into database")
+
+	if conf.Space == "eth" {
+		syncer := cisync.MustNewEthSyncer(syncCtx.SyncEth, syncCtx.EthDB)
+		syncer.ScopeMetrics(conf.Name)
+		go syncer.Sync(ctx, wg)
+		go syncCtx.EthDB.Prune()
+
+		return
+	}
+
+	syncer := cisync.MustNewDatabaseSyncer(syncCtx.SyncCfx, syncCtx.CfxDB)
+	syncer.ScopeMetrics(conf.Name)
+	go syncer.Sync(ctx, wg)
+	go syncCtx.CfxDB.Prune()
+
+	// monitor pivot chain switch via pub/sub
+	go cisync.MustSubEpoch(ctx, wg, syncCtx.SubCfx, syncer)
 }
 
 func startCatchupSyncCfxDatabase(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) {
@@ -208,6 +392,7 @@ func startCatchupSyncCfxDatabase(ctx context.Context, wg *sync.WaitGroup, syncCt
 		catchup.WithAdaptive(catchupSetting.adaptive),
 		catchup.WithEpochFrom(catchupSetting.epochFrom),
 		catchup.WithEpochTo(catchupSetting.epochTo),
+		catchup.WithClientOptions(syncCtx.Coordinator.ClientOptions()...),
 	)
 
 	wg.Add(1)
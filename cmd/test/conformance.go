@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Conflux-Chain/confura/test"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// evm space JSON-RPC conformance test configuration
+	conformanceConf test.EthConformanceConfig
+
+	conformanceTestCmd = &cobra.Command{
+		Use:   "conformance",
+		Short: "check if evm space JSON-RPC proxy conforms to Ethereum JSON-RPC spec and produce compatibility report",
+		Run:   startConformanceTest,
+	}
+)
+
+func init() {
+	// confura RPC endpoint
+	conformanceTestCmd.Flags().StringVarP(
+		&conformanceConf.RpcEndpoint,
+		"infura-endpoint", "u", "", "infura rpc endpoint to be tested against",
+	)
+	conformanceTestCmd.MarkFlagRequired("infura-endpoint")
+
+	// execution-apis test vectors
+	conformanceTestCmd.Flags().StringVarP(
+		&conformanceConf.VectorsDir,
+		"vectors", "d", "", "directory of execution-apis test vectors (*.io files)",
+	)
+
+	conformanceTestCmd.Flags().BoolVar(
+		&conformanceConf.StrictVectors,
+		"strict", false, "whether to compare the result of test vectors strictly rather than the shape",
+	)
+
+	// compatibility report
+	conformanceTestCmd.Flags().StringVarP(
+		&conformanceConf.ReportFile,
+		"report", "o", "", "file path to write the JSON compatibility report",
+	)
+
+	conformanceTestCmd.Flags().DurationVar(
+		&conformanceConf.RequestTimeout,
+		"timeout", 10*time.Second, "timeout for each JSON-RPC request",
+	)
+
+	Cmd.AddCommand(conformanceTestCmd)
+}
+
+func startConformanceTest(cmd *cobra.Command, args []string) {
+	if len(conformanceConf.RpcEndpoint) == 0 {
+		logrus.Fatal("Infura rpc endpoint must be configured for conformance test")
+	}
+
+	logrus.WithField("config", conformanceConf).Info("Starting JSON-RPC conformance test...")
+
+	runner := test.NewEthConformanceRunner(&conformanceConf)
+
+	report, err := runner.Run(context.Background())
+	if report != nil {
+		test.LogConformanceReport(report)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to run conformance test")
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/test"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// evm space synthetic event generator options, which override the configurations if specified
+	eventGenOpt struct {
+		fullnodeEndpoint string
+		infuraEndpoint   string
+		privateKey       string
+		rounds           int
+	}
+
+	eventGenCmd = &cobra.Command{
+		Use:   "eventgen",
+		Short: "generate synthetic event logs on evm space devnet and verify from JSON-RPC proxy",
+		Run:   startEventGen,
+	}
+)
+
+func init() {
+	// fullnode endpoint
+	eventGenCmd.Flags().StringVarP(
+		&eventGenOpt.fullnodeEndpoint,
+		"fn-endpoint", "f", "", "devnet fullnode rpc endpoint to send transactions",
+	)
+
+	// confura RPC endpoint
+	eventGenCmd.Flags().StringVarP(
+		&eventGenOpt.infuraEndpoint,
+		"infura-endpoint", "u", "", "infura rpc endpoint to verify the generated event logs",
+	)
+
+	// sender private key
+	eventGenCmd.Flags().StringVarP(
+		&eventGenOpt.privateKey,
+		"private-key", "k", "", "private key of the funded devnet account to send transactions",
+	)
+
+	// number of rounds
+	eventGenCmd.Flags().IntVarP(
+		&eventGenOpt.rounds,
+		"rounds", "r", 0, "number of rounds to generate events, and 0 to use the configured one",
+	)
+
+	Cmd.AddCommand(eventGenCmd)
+}
+
+func startEventGen(cmd *cobra.Command, args []string) {
+	conf := test.MustNewEthEventGenConfigFromViper()
+
+	if len(eventGenOpt.fullnodeEndpoint) > 0 {
+		conf.FullnodeRpcEndpoint = eventGenOpt.fullnodeEndpoint
+	}
+
+	if len(eventGenOpt.infuraEndpoint) > 0 {
+		conf.InfuraRpcEndpoint = eventGenOpt.infuraEndpoint
+	}
+
+	if len(eventGenOpt.privateKey) > 0 {
+		conf.PrivateKey = eventGenOpt.privateKey
+	}
+
+	if eventGenOpt.rounds > 0 {
+		conf.Rounds = eventGenOpt.rounds
+	}
+
+	if len(conf.FullnodeRpcEndpoint) == 0 || len(conf.PrivateKey) == 0 {
+		logrus.Fatal("Fullnode rpc endpoint && private key must be configured for event generator")
+	}
+
+	logrus.Info("Starting synthetic event generator...")
+
+	generator := test.MustNewEthEventGenerator(conf)
+	util.StartAndGracefulShutdown(generator.Run)
+}
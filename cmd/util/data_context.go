@@ -7,6 +7,7 @@ import (
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/store/redis"
+	cisync "github.com/Conflux-Chain/confura/sync"
 	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/openweb3/web3go"
@@ -44,6 +45,20 @@ func MustInitStoreContext() StoreContext {
 	return ctx
 }
 
+// ReadReplica returns the store context of which the db stores route read queries to read replica
+// if configured, which is used by RPC handlers.
+func (ctx StoreContext) ReadReplica() StoreContext {
+	if ctx.CfxDB != nil {
+		ctx.CfxDB = ctx.CfxDB.ReadReplica()
+	}
+
+	if ctx.EthDB != nil {
+		ctx.EthDB = ctx.EthDB.ReadReplica()
+	}
+
+	return ctx
+}
+
 func (ctx *StoreContext) Close() {
 	if ctx.CfxDB != nil {
 		ctx.CfxDB.Close()
@@ -77,18 +92,24 @@ type SyncContext struct {
 	SyncCfx *sdk.Client
 	SubCfx  *sdk.Client
 	SyncEth *web3go.Client
+
+	// coordinates core space and evm space sync in the same process, nil for extra networks
+	Coordinator *cisync.Coordinator
 }
 
 func MustInitSyncContext(storeCtx StoreContext) SyncContext {
-	sc := SyncContext{StoreContext: storeCtx}
+	sc := SyncContext{StoreContext: storeCtx, Coordinator: cisync.MustNewCoordinatorFromViper()}
+
+	// sync clients of both spaces share the rate limit against the same fullnode
+	options := append([]rpc.ClientOption{rpc.WithClientHookMetrics(true)}, sc.Coordinator.ClientOptions()...)
 
 	if storeCtx.CfxDB != nil || storeCtx.CfxCache != nil {
-		sc.SyncCfx = rpc.MustNewCfxClientFromViper(rpc.WithClientHookMetrics(true))
+		sc.SyncCfx = rpc.MustNewCfxClientFromViper(options...)
 		sc.SubCfx = rpc.MustNewCfxWsClientFromViper()
 	}
 
 	if storeCtx.EthDB != nil {
-		sc.SyncEth = rpc.MustNewEthClientFromViper(rpc.WithClientHookMetrics(true))
+		sc.SyncEth = rpc.MustNewEthClientFromViper(options...)
 	}
 
 	return sc
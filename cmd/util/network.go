@@ -0,0 +1,131 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NetworkConfig configurations of an extra network (eg., testnet) served by the same gateway instance
+// besides the default one, which has its own full nodes, db store and sync pipeline, while sharing the
+// process level infrastructures (eg., metrics and rate limiting) with the default network.
+type NetworkConfig struct {
+	Name string
+	// space of the network, `cfx` for core space or `eth` for evm space
+	Space string
+	// URL path prefix to serve the network on the RPC endpoints of the same space, eg., `/testnet`
+	Prefix string
+	// full node HTTP and websocket URLs of the network
+	Nodes   []string
+	WsNodes []string
+	// database on the mysql server of the same space to store chain data of the network, empty to
+	// proxy all RPC requests to full nodes
+	Database string
+	// whether to sync chain data of the network into database
+	Sync bool
+}
+
+// MustLoadNetworksFromViper loads the extra networks served by the same gateway instance, or panics
+// if any network misconfigured.
+func MustLoadNetworksFromViper() []NetworkConfig {
+	var networks []NetworkConfig
+	if err := viper.UnmarshalKey("networks", &networks); err != nil {
+		logrus.WithError(err).Fatal("Failed to load network configurations")
+	}
+
+	if err := validateNetworks(networks); err != nil {
+		logrus.WithError(err).Fatal("Invalid network configurations")
+	}
+
+	return networks
+}
+
+func validateNetworks(networks []NetworkConfig) error {
+	names, prefixes := make(map[string]bool), make(map[string]bool)
+
+	for _, n := range networks {
+		if len(n.Name) == 0 || names[n.Name] {
+			return errors.Errorf("network name %q empty or duplicated", n.Name)
+		}
+
+		if n.Space != "cfx" && n.Space != "eth" {
+			return errors.Errorf("invalid space %q of network %v", n.Space, n.Name)
+		}
+
+		prefix := strings.TrimSuffix(n.Prefix, "/")
+		if !strings.HasPrefix(prefix, "/") || prefixes[prefix] {
+			return errors.Errorf("URL prefix %q of network %v invalid or duplicated", n.Prefix, n.Name)
+		}
+
+		if len(n.Nodes) == 0 {
+			return errors.Errorf("no full node configured for network %v", n.Name)
+		}
+
+		if n.Sync && len(n.Database) == 0 {
+			return errors.Errorf("no database configured to sync network %v", n.Name)
+		}
+
+		if n.Sync && n.Space == "cfx" && len(n.WsNodes) == 0 {
+			return errors.Errorf("no websocket full node configured to sync network %v", n.Name)
+		}
+
+		names[n.Name], prefixes[prefix] = true, true
+	}
+
+	return nil
+}
+
+// Router creates router of the full nodes managed in process for the network.
+func (n *NetworkConfig) Router() *node.ManagedRouter {
+	return node.MustNewManagedRouter(n.Space, n.Nodes, n.WsNodes)
+}
+
+// MustInitStoreContext opens the database of the network on the mysql server of the same space, which
+// is required to be enabled.
+func (n *NetworkConfig) MustInitStoreContext() StoreContext {
+	var ctx StoreContext
+
+	if len(n.Database) == 0 {
+		return ctx
+	}
+
+	config, disabler := mysql.MustNewConfigFromViper(), store.StoreConfig()
+	if n.Space == "eth" {
+		config, disabler = mysql.MustNewEthStoreConfigFromViper(), store.EthStoreConfig()
+	}
+
+	if !config.Enabled {
+		logrus.WithField("network", n.Name).Fatal("Database of the same space not enabled for network")
+	}
+
+	config.Database = n.Database
+	db := config.MustOpenOrCreate(mysql.StoreOption{Disabler: disabler})
+
+	if n.Space == "eth" {
+		ctx.EthDB = db
+	} else {
+		ctx.CfxDB = db
+	}
+
+	return ctx
+}
+
+// MustInitSyncContext creates sdk clients of the network to sync chain data into the store.
+func (n *NetworkConfig) MustInitSyncContext(storeCtx StoreContext) SyncContext {
+	sc := SyncContext{StoreContext: storeCtx}
+
+	if n.Space == "eth" {
+		sc.SyncEth = rpc.MustNewEthClient(n.Nodes[0], rpc.WithClientHookMetrics(true))
+	} else {
+		sc.SyncCfx = rpc.MustNewCfxClient(n.Nodes[0], rpc.WithClientHookMetrics(true))
+		sc.SubCfx = rpc.MustNewCfxClient(n.WsNodes[0])
+	}
+
+	return sc
+}
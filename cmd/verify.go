@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// verify options
+	verifyOpt struct {
+		network    string
+		start, end uint64
+	}
+
+	verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Audit the synced data in store against fullnode over an epoch (or block) range",
+		Run:   verifyStore,
+	}
+)
+
+func init() {
+	verifyCmd.Flags().StringVarP(
+		&verifyOpt.network, "network", "n", "cfx", "network space ('cfx' or 'eth') of database to verify",
+	)
+
+	verifyCmd.Flags().Uint64Var(
+		&verifyOpt.start, "start", 0, "the epoch (or block) number from which to verify",
+	)
+
+	verifyCmd.Flags().Uint64Var(
+		&verifyOpt.end, "end", 0, "the epoch (or block) number until which to verify, default the max synced",
+	)
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func verifyStore(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	dbs, err := storeCtx.GetMysqlStore(verifyOpt.network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	end := verifyOpt.end
+	if end == 0 {
+		maxEpoch, ok, err := dbs.MaxEpoch()
+		if err != nil {
+			logrus.WithError(err).Info("Failed to get max synced epoch")
+			return
+		}
+
+		if !ok {
+			logrus.Info("No data synced in store")
+			return
+		}
+
+		end = maxEpoch
+	}
+
+	auditor := mustNewVerifyAuditor(verifyOpt.network, dbs)
+
+	var mismatched, missing int
+	for epoch := verifyOpt.start; epoch <= end; epoch++ {
+		mismatches, ok, err := auditor.Audit(context.Background(), epoch)
+		if err != nil {
+			logrus.WithField("epoch", epoch).WithError(err).Info("Failed to verify epoch")
+			return
+		}
+
+		switch {
+		case !ok:
+			missing++
+			fmt.Printf("%v: missing in store\n", epoch)
+		case len(mismatches) > 0:
+			mismatched++
+			fmt.Printf("%v: %v mismatched with fullnode\n", epoch, strings.Join(mismatches, ", "))
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"start":      verifyOpt.start,
+		"end":        end,
+		"mismatched": mismatched,
+		"missing":    missing,
+	}).Info("Store verified against fullnode")
+}
+
+// mustNewVerifyAuditor creates the auditor to compare the synced data in store with that of the
+// fullnode of network space.
+func mustNewVerifyAuditor(network string, dbs *mysql.MysqlStore) *cisync.Auditor {
+	if strings.EqualFold(network, "eth") {
+		return cisync.MustNewEthAuditor(rpcutil.MustNewEthClientFromViper(), dbs)
+	}
+
+	return cisync.MustNewCfxAuditor(rpcutil.MustNewCfxClientFromViper(), dbs)
+}
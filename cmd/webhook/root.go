@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "webhook",
+		Short: "EVM space event log webhook utility toolset",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+)
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"errors"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/webhook"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type webhookCmdConfig struct {
+	Name      string // webhook name
+	Url       string // callback URL
+	Contracts string // comma separated contract addresses
+	Topics    string // JSON encoded topics
+	Secret    string // secret to sign payload
+}
+
+var (
+	webhookCfg webhookCmdConfig
+
+	addWebhookCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Register new webhook",
+		Run:   addWebhook,
+	}
+
+	delWebhookCmd = &cobra.Command{
+		Use:   "rm",
+		Short: "Remove existing webhook along with its deliveries",
+		Run:   delWebhook,
+	}
+
+	listWebhooksCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List all registered webhooks",
+		Run:   listWebhooks,
+	}
+)
+
+func init() {
+	Cmd.AddCommand(addWebhookCmd)
+	hookWebhookCmdFlags(addWebhookCmd, true, true)
+
+	Cmd.AddCommand(delWebhookCmd)
+	hookWebhookCmdFlags(delWebhookCmd, true, false)
+
+	Cmd.AddCommand(listWebhooksCmd)
+}
+
+func addWebhook(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	if storeCtx.EthDB == nil {
+		logrus.Info("EVM space MySQL store is unavailable")
+		return
+	}
+
+	if err := validateWebhookCmdConfig(); err != nil {
+		logrus.WithError(err).Info("Invalid command config")
+		return
+	}
+
+	wh := &mysql.Webhook{
+		Name:      webhookCfg.Name,
+		Url:       webhookCfg.Url,
+		Contracts: webhookCfg.Contracts,
+		Topics:    webhookCfg.Topics,
+		Secret:    webhookCfg.Secret,
+	}
+
+	if err := webhook.ValidateWebhook(wh); err != nil {
+		logrus.WithError(err).Info("Invalid webhook")
+		return
+	}
+
+	if err := storeCtx.EthDB.AddWebhook(wh); err != nil {
+		logrus.WithError(err).Info("Failed to add new webhook")
+		return
+	}
+
+	logrus.WithField("id", wh.ID).Info("New webhook added")
+}
+
+func delWebhook(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	if storeCtx.EthDB == nil {
+		logrus.Info("EVM space MySQL store is unavailable")
+		return
+	}
+
+	removed, err := storeCtx.EthDB.DeleteWebhook(webhookCfg.Name)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to delete the webhook")
+		return
+	}
+
+	if removed {
+		logrus.Info("Webhook deleted")
+	} else {
+		logrus.Info("Webhook does not exist")
+	}
+}
+
+func listWebhooks(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	if storeCtx.EthDB == nil {
+		logrus.Info("EVM space MySQL store is unavailable")
+		return
+	}
+
+	webhooks, err := storeCtx.EthDB.LoadWebhooks()
+	if err != nil {
+		logrus.WithError(err).Info("Failed to load webhooks")
+		return
+	}
+
+	if len(webhooks) == 0 {
+		logrus.Info("No webhook found")
+		return
+	}
+
+	logrus.WithField("total", len(webhooks)).Info("Webhooks loaded:")
+
+	for _, wh := range webhooks {
+		logrus.WithFields(logrus.Fields{
+			"id":        wh.ID,
+			"url":       wh.Url,
+			"contracts": wh.Contracts,
+			"topics":    wh.Topics,
+			"signed":    len(wh.Secret) > 0,
+		}).Info("Webhook ", wh.Name)
+	}
+}
+
+func validateWebhookCmdConfig() error {
+	if len(webhookCfg.Name) == 0 {
+		return errors.New("webhook name must not be empty")
+	}
+
+	if len(webhookCfg.Url) == 0 {
+		return errors.New("callback URL must not be empty")
+	}
+
+	return nil
+}
+
+func hookWebhookCmdFlags(webhookCmd *cobra.Command, hookName, hookFilter bool) {
+	if hookName { // webhook name
+		webhookCmd.Flags().StringVarP(&webhookCfg.Name, "name", "n", "", "webhook name")
+		webhookCmd.MarkFlagRequired("name")
+	}
+
+	if hookFilter { // callback URL and event log filter
+		webhookCmd.Flags().StringVarP(&webhookCfg.Url, "url", "u", "", "callback URL")
+		webhookCmd.MarkFlagRequired("url")
+
+		webhookCmd.Flags().StringVarP(
+			&webhookCfg.Contracts, "contracts", "c", "", "comma separated contract addresses, empty for any",
+		)
+		webhookCmd.Flags().StringVarP(
+			&webhookCfg.Topics, "topics", "t", "", `JSON encoded topics, eg., '[["0x..."],[],["0x..."]]'`,
+		)
+		webhookCmd.Flags().StringVarP(
+			&webhookCfg.Secret, "secret", "s", "", "secret to sign the notification payload",
+		)
+	}
+}
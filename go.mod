@@ -6,25 +6,39 @@ require (
 	github.com/Conflux-Chain/go-conflux-sdk v1.5.8
 	github.com/Conflux-Chain/go-conflux-util v0.1.1-0.20230518032210-314b940bbd35
 	github.com/Conflux-Chain/web3pay-service v0.0.0-20230609030113-dc3c4d42820a
+	github.com/aws/aws-sdk-go v1.44.327
 	github.com/btcsuite/btcutil v1.0.3-0.20201208143702-a53e38424cce
 	github.com/buraksezer/consistent v0.9.0
 	github.com/cespare/xxhash v1.1.0
 	github.com/ethereum/go-ethereum v1.10.15
 	github.com/go-redis/redis/v8 v8.8.2
 	github.com/go-sql-driver/mysql v1.6.0
+	github.com/golang/snappy v0.0.4
+	github.com/gorilla/websocket v1.5.0
+	github.com/graph-gophers/graphql-go v1.3.0
 	github.com/hashicorp/golang-lru v0.5.5-0.20210104140557-80c98217689d
+	github.com/klauspost/compress v1.14.1
 	github.com/montanaflynn/stats v0.6.6
+	github.com/nats-io/nats.go v1.11.0
 	github.com/openweb3/go-rpc-provider v0.3.2-0.20230427073643-a9b973086662
 	github.com/openweb3/web3go v0.2.5
 	github.com/pkg/errors v0.9.1
 	github.com/royeo/dingrobot v1.0.1-0.20191230075228-c90a788ca8fd
+	github.com/rs/cors v1.7.0
+	github.com/segmentio/kafka-go v0.2.0
 	github.com/sirupsen/logrus v1.9.0
 	github.com/spf13/cobra v1.5.0
 	github.com/spf13/viper v1.10.0
 	github.com/stretchr/testify v1.7.0
+	github.com/valyala/fasthttp v1.33.0
+	github.com/xitongsys/parquet-go v1.6.2
 	github.com/zealws/golang-ring v0.0.0-20210116075443-7c86fdb43134
 	go.uber.org/multierr v1.6.0
+	golang.org/x/sync v0.0.0-20220722155255-886fb9371eb4
 	golang.org/x/time v0.0.0-20220411224347-583f2d630306
+	google.golang.org/grpc v1.42.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gorm.io/driver/mysql v1.3.6
 	gorm.io/gorm v1.23.8
 )
@@ -0,0 +1,289 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrCircuitOpen = errors.New("full node circuit breaker is open")
+)
+
+// CircuitState represents the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// requests flow to the full node normally
+	CircuitClosed CircuitState = iota
+	// requests are fast failed or rerouted to other full nodes
+	CircuitOpen
+	// limited probe requests are allowed to detect whether the full node recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "halfOpen"
+	}
+
+	return "unknown"
+}
+
+// breakerConfig circuit breaker configurations
+type breakerConfig struct {
+	// whether to enable circuit breaker for full node
+	Enabled bool
+	// error rate threshold (0 ~ 1), exceeding which opens the circuit
+	ErrorRate float64 `default:"0.5"`
+	// min number of requests within the time window to evaluate error rate
+	MinRequests uint64 `default:"20"`
+	// time window to collect request statistics
+	Window time.Duration `default:"10s"`
+	// duration to keep the circuit open before half-opening with probe traffic
+	OpenTimeout time.Duration `default:"30s"`
+	// max number of concurrent probe requests when half-open, which is also
+	// the number of successful probes required to close the circuit.
+	HalfOpenProbes uint64 `default:"5"`
+	// max number of reroutes to other full nodes when the routed one is open
+	MaxReroutes int `default:"2"`
+}
+
+// breakerSlotData time window slot data for circuit breaker statistics
+type breakerSlotData struct {
+	total    uint64
+	failures uint64
+}
+
+// implements `metrics.SlotData` interface
+
+func (d breakerSlotData) Add(v metrics.SlotData) metrics.SlotData {
+	rhs := v.(breakerSlotData)
+	return breakerSlotData{
+		total:    d.total + rhs.total,
+		failures: d.failures + rhs.failures,
+	}
+}
+
+func (d breakerSlotData) Sub(v metrics.SlotData) metrics.SlotData {
+	rhs := v.(breakerSlotData)
+	return breakerSlotData{
+		total:    d.total - rhs.total,
+		failures: d.failures - rhs.failures,
+	}
+}
+
+func (d breakerSlotData) SnapShot() metrics.SlotData {
+	return d
+}
+
+// circuitBreaker trips once the error rate of some full node exceeds the configured threshold
+// within the time window, so that requests could be fast failed or rerouted rather than waiting
+// for a sick full node until timeout.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	conf     *breakerConfig
+	nodeName string
+
+	state    CircuitState
+	openedAt time.Time
+	window   *metrics.TimeWindow
+
+	probes         uint64 // number of in-flight probe requests when half-open
+	probeSuccesses uint64 // number of succeeded probe requests when half-open
+}
+
+func newCircuitBreaker(nodeName string, conf *breakerConfig) *circuitBreaker {
+	cb := &circuitBreaker{conf: conf, nodeName: nodeName}
+	cb.resetWindow()
+
+	return cb
+}
+
+// State returns the current circuit state.
+func (cb *circuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.tryHalfOpen(time.Now())
+	return cb.state
+}
+
+// Open checks if the circuit refuses any more request, which is useful for routing.
+func (cb *circuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.tryHalfOpen(time.Now())
+
+	switch cb.state {
+	case CircuitOpen:
+		return true
+	case CircuitHalfOpen:
+		return cb.probes >= cb.conf.HalfOpenProbes
+	}
+
+	return false
+}
+
+// Allow checks if request is allowed to pass through. If allowed, the request result
+// must be reported by `Mark` afterwards.
+func (cb *circuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.tryHalfOpen(time.Now())
+
+	switch cb.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if cb.probes >= cb.conf.HalfOpenProbes {
+			return false
+		}
+
+		cb.probes++
+	}
+
+	return true
+}
+
+// Mark reports the request result to the circuit breaker.
+func (cb *circuitBreaker) Mark(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitClosed:
+		data := breakerSlotData{total: 1}
+		if failed {
+			data.failures++
+		}
+
+		cb.window.Add(data)
+
+		if cb.tripped() {
+			cb.transit(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if cb.probes > 0 {
+			cb.probes--
+		}
+
+		if failed { // still sick, open the circuit again
+			cb.transit(CircuitOpen)
+			return
+		}
+
+		if cb.probeSuccesses++; cb.probeSuccesses >= cb.conf.HalfOpenProbes {
+			cb.transit(CircuitClosed)
+		}
+	}
+}
+
+// tripped checks if the error rate within the time window exceeds the threshold.
+func (cb *circuitBreaker) tripped() bool {
+	data, ok := cb.window.Data().(breakerSlotData)
+	if !ok || data.total == 0 || data.total < cb.conf.MinRequests {
+		return false
+	}
+
+	return float64(data.failures)/float64(data.total) > cb.conf.ErrorRate
+}
+
+// tryHalfOpen half-opens the circuit if it has been open long enough.
+func (cb *circuitBreaker) tryHalfOpen(now time.Time) {
+	if cb.state == CircuitOpen && now.Sub(cb.openedAt) >= cb.conf.OpenTimeout {
+		cb.transit(CircuitHalfOpen)
+	}
+}
+
+func (cb *circuitBreaker) transit(state CircuitState) {
+	logrus.WithFields(logrus.Fields{
+		"node": cb.nodeName,
+		"from": cb.state,
+		"to":   state,
+	}).Warn("Full node circuit breaker state changed")
+
+	cb.state = state
+	cb.probes, cb.probeSuccesses = 0, 0
+
+	switch state {
+	case CircuitOpen:
+		cb.openedAt = time.Now()
+	case CircuitClosed:
+		cb.resetWindow()
+	}
+
+	metrics.Registry.RPC.FullnodeCircuitState(cb.nodeName).Update(int64(state))
+}
+
+func (cb *circuitBreaker) resetWindow() {
+	numSlots := 10
+	cb.window = metrics.NewTimeWindow(cb.conf.Window/time.Duration(numSlots), numSlots)
+}
+
+// middleware returns the RPC client call middleware to fast fail requests if the circuit is open,
+// and also collect request results for the circuit breaker.
+func (cb *circuitBreaker) middleware(handler providers.CallContextFunc) providers.CallContextFunc {
+	return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		if !cb.Allow() {
+			return ErrCircuitOpen
+		}
+
+		err := handler(ctx, result, method, args...)
+
+		// only non RPC errors (eg., io error or timeout) are regarded as full node failures
+		cb.Mark(!util.IsInterfaceValNil(err) && !utils.IsRPCJSONError(err))
+
+		return err
+	}
+}
+
+// breakerRegistry manages circuit breakers for all full nodes.
+type breakerRegistry struct {
+	conf *breakerConfig
+
+	// node name => circuit breaker
+	breakers util.ConcurrentMap
+}
+
+func newBreakerRegistry(conf *breakerConfig) *breakerRegistry {
+	return &breakerRegistry{conf: conf}
+}
+
+// get gets or creates the circuit breaker for the specified full node, or returns nil
+// if circuit breaker is not enabled.
+func (r *breakerRegistry) get(nodeName string) *circuitBreaker {
+	if r == nil || !r.conf.Enabled {
+		return nil
+	}
+
+	v, _ := r.breakers.LoadOrStoreFn(nodeName, func(k interface{}) interface{} {
+		return newCircuitBreaker(nodeName, r.conf)
+	})
+
+	return v.(*circuitBreaker)
+}
+
+// isOpen checks if the circuit of the specified full node is open.
+func (r *breakerRegistry) isOpen(nodeName string) bool {
+	if cb := r.get(nodeName); cb != nil {
+		return cb.Open()
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCircuitBreaker() *circuitBreaker {
+	return newCircuitBreaker("test", &breakerConfig{
+		Enabled:        true,
+		ErrorRate:      0.5,
+		MinRequests:    4,
+		Window:         10 * time.Second,
+		OpenTimeout:    50 * time.Millisecond,
+		HalfOpenProbes: 2,
+	})
+}
+
+func TestCircuitBreakerTrip(t *testing.T) {
+	cb := newTestCircuitBreaker()
+
+	// not enough requests to evaluate error rate
+	for i := 0; i < 3; i++ {
+		assert.True(t, cb.Allow())
+		cb.Mark(true)
+	}
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	// error rate exceeds threshold
+	assert.True(t, cb.Allow())
+	cb.Mark(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.True(t, cb.Open())
+	assert.False(t, cb.Allow())
+}
+
+func TestCircuitBreakerNotTripBelowErrorRate(t *testing.T) {
+	cb := newTestCircuitBreaker()
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, cb.Allow())
+		cb.Mark(i%3 == 0)
+	}
+
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.False(t, cb.Open())
+}
+
+func TestCircuitBreakerNotTripAtErrorRate(t *testing.T) {
+	cb := newTestCircuitBreaker()
+
+	// error rate equals to the threshold
+	for i := 0; i < 4; i++ {
+		assert.True(t, cb.Allow())
+		cb.Mark(i%2 == 0)
+	}
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	// error rate exceeds the threshold
+	assert.True(t, cb.Allow())
+	cb.Mark(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	cb := newTestCircuitBreaker()
+
+	for i := 0; i < 4; i++ {
+		cb.Allow()
+		cb.Mark(true)
+	}
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	// half-open after open timeout
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+
+	// probe requests limited
+	assert.True(t, cb.Allow())
+	assert.True(t, cb.Allow())
+	assert.False(t, cb.Allow())
+	assert.True(t, cb.Open())
+
+	// probe failure opens the circuit again
+	cb.Mark(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+
+	// enough probe successes close the circuit
+	for i := 0; i < 2; i++ {
+		assert.True(t, cb.Allow())
+		cb.Mark(false)
+	}
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.True(t, cb.Allow())
+}
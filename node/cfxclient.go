@@ -13,8 +13,9 @@ type CfxClientProvider struct {
 	*clientProvider
 }
 
-func newCfxClient(url string) (interface{}, error) {
-	return rpc.NewCfxClient(url, rpc.WithClientHookMetrics(true))
+func newCfxClient(url string, options ...rpc.ClientOption) (interface{}, error) {
+	options = append(options, rpc.WithClientHookMetrics(true))
+	return rpc.NewCfxClient(url, options...)
 }
 
 func NewCfxClientProvider(db *mysql.MysqlStore, router Router) *CfxClientProvider {
@@ -33,10 +34,10 @@ func (p *CfxClientProvider) GetClient(key string, groups ...Group) (sdk.ClientOp
 	return client.(sdk.ClientOperator), nil
 }
 
-// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address.
+// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address,
+// or by API key if route affinity of API key configured.
 func (p *CfxClientProvider) GetClientByIP(ctx context.Context, groups ...Group) (sdk.ClientOperator, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
-	client, err := p.getClient(remoteAddr, cfxNodeGroup(groups...))
+	client, err := p.getClientByContext(ctx, cfxNodeGroup(groups...))
 	if err != nil {
 		return nil, err
 	}
@@ -52,3 +53,12 @@ func cfxNodeGroup(groups ...Group) Group {
 
 	return grp
 }
+
+// unavailableCfxClient placeholder core space client when no full node available, so that RPC
+// requests could still be served by store if possible, otherwise fail with ErrClientUnavailable.
+var unavailableCfxClient, _ = sdk.NewClientWithProvider(unavailableProvider{})
+
+// UnavailableCfxClient returns the placeholder core space client when no full node available.
+func UnavailableCfxClient() sdk.ClientOperator {
+	return unavailableCfxClient
+}
@@ -2,13 +2,17 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/cespare/xxhash"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -16,14 +20,22 @@ import (
 const (
 	RouteKeyCacheSize       = 5000
 	RouteCacheExpirationTTL = 60 * time.Second
+
+	// max number of attempts to route to some other full node
+	maxAlternativeRoutes = 5
 )
 
 var (
-	ErrClientUnavailable = errors.New("no full node available")
+	// ErrClientUnavailable is returned if no full node available to route, eg., none configured
+	// or all unhealthy (empty hash ring).
+	ErrClientUnavailable = rpc.NewCodedError(rpc.ErrCodeNoUpstreamAvailable, errors.New("no upstream available"))
+
+	// ErrNoAlternativeClient is returned if no other full node available to route.
+	ErrNoAlternativeClient = errors.New("no alternative full node available")
 )
 
 // clientFactory factory method to create RPC client for fullnode proxy.
-type clientFactory func(url string) (interface{}, error)
+type clientFactory func(url string, options ...rpc.ClientOption) (interface{}, error)
 
 // clientProvider provides different RPC client based on request IP to achieve load balance
 // or with node group for resource isolation. Generally, it is used by RPC server to delegate
@@ -40,6 +52,12 @@ type clientProvider struct {
 
 	// group => node name => RPC client
 	clients *util.ConcurrentMap
+	// circuit breakers per full node
+	breakers *breakerRegistry
+	// concurrency limiters per full node
+	limiters *concurrencyRegistry
+	// coalescer of identical in-flight read requests
+	coalescer *coalescer
 }
 
 func newClientProvider(db *mysql.MysqlStore, router Router, factory clientFactory) *clientProvider {
@@ -49,6 +67,9 @@ func newClientProvider(db *mysql.MysqlStore, router Router, factory clientFactor
 		factory:       factory,
 		clients:       &util.ConcurrentMap{},
 		routeKeyCache: util.NewExpirableLruCache(RouteKeyCacheSize, RouteCacheExpirationTTL),
+		breakers:      newBreakerRegistry(&cfg.CircuitBreaker),
+		limiters:      newConcurrencyRegistry(&cfg.Concurrency),
+		coalescer:     newCoalescer(&cfg.Coalesce),
 	}
 }
 
@@ -132,6 +153,45 @@ func (p *clientProvider) populateCache(token string) (grp Group, ok bool) {
 
 // getClient gets client based on keyword and node group type.
 func (p *clientProvider) getClient(key string, group Group) (interface{}, error) {
+	return p.getClientWithRouteFn(key, group, p.router.Route)
+}
+
+// getClientByContext gets client by the route key of caller identity, and selects full node by the
+// selection policy of method group or excludes the full nodes lagging behind for "latest"-sensitive
+// calls if configured. Besides, the full nodes already attempted for the RPC call are excluded if
+// tracked in context, eg., to retry on some other full node.
+func (p *clientProvider) getClientByContext(ctx context.Context, group Group) (interface{}, error) {
+	routeFn := p.routeFn(ctx)
+	if excludedNodes := excludedNodesFromContext(ctx); len(excludedNodes) > 0 {
+		routeFn = routeExcluded(routeFn, excludedNodes)
+	}
+
+	return p.getClientWithRouteFn(routeKeyFromContext(ctx), group, routeFn)
+}
+
+// routeFn returns the function to route by the RPC call in context.
+func (p *clientProvider) routeFn(ctx context.Context) func(group Group, key []byte) string {
+	latest := isLatestSensitive(ctx)
+
+	// lagging full nodes are excluded before selection for "latest"-sensitive calls
+	if req, ok := routeMethodFromContext(ctx); ok {
+		return func(group Group, key []byte) string {
+			return routeMethod(p.router, group, key, req.Method, req.Params, latest)
+		}
+	}
+
+	if latest {
+		return func(group Group, key []byte) string {
+			return routeLatest(p.router, group, key)
+		}
+	}
+
+	return p.router.Route
+}
+
+func (p *clientProvider) getClientWithRouteFn(
+	key string, group Group, routeFn func(group Group, key []byte) string,
+) (interface{}, error) {
 	clients := p.getOrRegisterGroup(group)
 
 	logger := logrus.WithFields(logrus.Fields{
@@ -139,12 +199,19 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 		"group": group,
 	})
 
-	url := p.router.Route(group, []byte(key))
-	if len(url) == 0 {
-		logger.WithError(ErrClientUnavailable).Error("Failed to get full node client from provider")
-		return nil, ErrClientUnavailable
+	url, err := p.route(key, group, routeFn)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get full node client from provider")
+		return nil, err
 	}
 
+	return p.loadClient(clients, url, logger)
+}
+
+// loadClient gets or creates client of the routed full node.
+func (p *clientProvider) loadClient(
+	clients *util.ConcurrentMap, url string, logger *logrus.Entry,
+) (interface{}, error) {
 	nodeName := rpc.Url2NodeName(url)
 
 	logger = logger.WithFields(logrus.Fields{
@@ -157,7 +224,26 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 		// TODO improvements required
 		// 1. Necessary retry? (but longer timeout). Better to let user side to decide.
 		// 2. Different metrics for different full nodes.
-		return p.factory(url)
+		var options []rpc.ClientOption
+
+		// hooked ahead (outer) of other middlewares, so that coalesced requests never wait for
+		// in-flight slots of the full node
+		if mw := p.coalescer.middleware(nodeName); mw != nil {
+			options = append(options, rpc.WithClientCallMiddlewares(mw))
+		}
+
+		if cb := p.breakers.get(nodeName); cb != nil {
+			options = append(options, rpc.WithClientCallMiddlewares(cb.middleware))
+		}
+
+		if l := p.limiters.get(nodeName); l != nil {
+			options = append(options,
+				rpc.WithClientCallMiddlewares(l.middleware),
+				rpc.WithClientBatchCallMiddlewares(l.batchMiddleware),
+			)
+		}
+
+		return p.factory(url, options...)
 	})
 
 	if err != nil {
@@ -174,6 +260,137 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 	return client, nil
 }
 
+// getAlternativeClient gets client of some other full node than the excluded ones in the specified
+// node group, which is routed with random keys and skips the full nodes whose circuit is open.
+func (p *clientProvider) getAlternativeClient(group Group, excludedNodes ...string) (interface{}, error) {
+	clients := p.getOrRegisterGroup(group)
+
+	for i := 0; i < maxAlternativeRoutes; i++ {
+		key := fmt.Sprintf("alternative_key_%v", rand.Int())
+
+		url := p.router.Route(group, []byte(key))
+		if len(url) == 0 {
+			return nil, ErrClientUnavailable
+		}
+
+		nodeName := rpc.Url2NodeName(url)
+		if includeNode(excludedNodes, nodeName) || p.breakers.isOpen(nodeName) {
+			continue
+		}
+
+		logger := logrus.WithFields(logrus.Fields{
+			"group":         group,
+			"excludedNodes": excludedNodes,
+		})
+
+		return p.loadClient(clients, url, logger)
+	}
+
+	return nil, ErrNoAlternativeClient
+}
+
+func includeNode(nodes []string, node string) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+// route routes the key to some full node of the specified group by the route function. If the circuit
+// of the routed full node is open, it will try to reroute to other full nodes with a salted key. If the
+// routed full node is saturated with in-flight requests, it will try to reroute to other idle full
+// nodes, otherwise the request will be queued for the routed full node.
+func (p *clientProvider) route(
+	key string, group Group, routeFn func(group Group, key []byte) string,
+) (string, error) {
+	url := routeFn(group, []byte(key))
+	if len(url) == 0 {
+		return "", ErrClientUnavailable
+	}
+
+	nodeName := rpc.Url2NodeName(url)
+	if p.breakers.isOpen(nodeName) {
+		metrics.Registry.RPC.FullnodeCircuitRejects(nodeName).Mark(1)
+
+		rurl, ok := p.reroute(routeFn, key, group, nodeName, "circuit open", p.breakers.conf.MaxReroutes, false)
+		if !ok {
+			return "", ErrCircuitOpen
+		}
+
+		return rurl, nil
+	}
+
+	if !p.limiters.isSaturated(nodeName) {
+		return url, nil
+	}
+
+	rurl, ok := p.reroute(routeFn, key, group, nodeName, "saturated", p.limiters.conf.MaxReroutes, true)
+	if !ok { // queue for the routed full node
+		return url, nil
+	}
+
+	metrics.Registry.RPC.FullnodeSaturatedReroutes(nodeName).Mark(1)
+
+	return rurl, nil
+}
+
+// reroute tries to reroute the key to some other full node whose circuit is not open with salted
+// keys, and skips the saturated full nodes if required.
+func (p *clientProvider) reroute(
+	routeFn func(group Group, key []byte) string,
+	key string,
+	group Group,
+	nodeName, reason string,
+	maxReroutes int,
+	idleRequired bool,
+) (string, bool) {
+	for i := 1; i <= maxReroutes; i++ {
+		rerouteKey := fmt.Sprintf("%v#reroute-%v", key, i)
+
+		rurl := routeFn(group, []byte(rerouteKey))
+		if len(rurl) == 0 {
+			continue
+		}
+
+		rnodeName := rpc.Url2NodeName(rurl)
+		if rnodeName == nodeName || p.breakers.isOpen(rnodeName) {
+			continue
+		}
+
+		if idleRequired && p.limiters.isSaturated(rnodeName) {
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"key":      key,
+			"group":    group,
+			"fromNode": nodeName,
+			"toNode":   rnodeName,
+		}).Debugf("Client provider rerouted key due to %v", reason)
+
+		return rurl, true
+	}
+
+	return "", false
+}
+
+// routeKeyFromContext returns the consistent hashing key of the caller identity according to the
+// configured route affinity, so that all calls of the same client land on the same full node.
+//
+// Note, API key is hashed so that the raw token is never exposed by logs or route stats.
+func routeKeyFromContext(ctx context.Context) string {
+	if cfg.Router.Affinity == RouteAffinityKey {
+		if token, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(token) > 0 {
+			return fmt.Sprintf("key_%016x", xxhash.Sum64String(token))
+		}
+	}
+
+	return remoteAddrFromContext(ctx)
+}
+
 func remoteAddrFromContext(ctx context.Context) string {
 	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
 		return ip
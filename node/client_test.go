@@ -0,0 +1,43 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteKeyFromContext(t *testing.T) {
+	defer func(affinity string) { cfg.Router.Affinity = affinity }(cfg.Router.Affinity)
+
+	ctx := context.WithValue(context.Background(), handlers.CtxKeyRealIP, "127.0.0.1")
+	keyCtx := context.WithValue(ctx, handlers.CtxKeyAccessToken, "key1")
+
+	// route by client IP
+	cfg.Router.Affinity = RouteAffinityIP
+	assert.Equal(t, "127.0.0.1", routeKeyFromContext(keyCtx))
+
+	// route by API key, or client IP if not provided
+	cfg.Router.Affinity = RouteAffinityKey
+	key := routeKeyFromContext(keyCtx)
+	assert.True(t, strings.HasPrefix(key, "key_"))
+	assert.NotContains(t, key, "key1")
+	assert.Equal(t, key, routeKeyFromContext(keyCtx))
+	assert.Equal(t, "127.0.0.1", routeKeyFromContext(ctx))
+}
+
+func TestUnavailableClients(t *testing.T) {
+	_, err := UnavailableCfxClient().GetEpochNumber()
+	assert.True(t, errors.Is(err, ErrClientUnavailable), err)
+
+	// used by filter APIs
+	_, ok := UnavailableCfxClient().(*sdk.Client)
+	assert.True(t, ok)
+
+	_, err = UnavailableEthClient().Eth.BlockNumber()
+	assert.True(t, errors.Is(err, ErrClientUnavailable), err)
+}
@@ -0,0 +1,149 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+var defaultCoalescedMethods = []string{
+	"cfx_epochNumber", "cfx_gasPrice", "cfx_getStatus", "cfx_getBlockByEpochNumber",
+	"eth_blockNumber", "eth_gasPrice", "eth_chainId", "eth_getBlockByNumber",
+}
+
+// coalesceConfig request coalescing configurations for RPC proxy
+type coalesceConfig struct {
+	// whether to coalesce identical in-flight read requests to the same full node
+	Enabled bool
+	// read-only methods to coalesce, which defaults to the head data polled frequently
+	Methods []string
+}
+
+func (c *coalesceConfig) coalesced(method string) bool {
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultCoalescedMethods
+	}
+
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// coalescedCall in-flight upstream call shared by identical requests.
+type coalescedCall struct {
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+}
+
+// coalescer deduplicates concurrent identical read requests (same full node, method and params) into
+// a single upstream call, and fans out the result to all waiters, which is useful when many clients
+// poll the same head data.
+type coalescer struct {
+	conf *coalesceConfig
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall // node name + method + params => in-flight call
+}
+
+func newCoalescer(conf *coalesceConfig) *coalescer {
+	return &coalescer{
+		conf:  conf,
+		calls: make(map[string]*coalescedCall),
+	}
+}
+
+// middleware returns the RPC client call middleware to coalesce requests to the full node, or nil
+// if coalescing disabled.
+func (c *coalescer) middleware(nodeName string) providers.CallContextMiddleware {
+	if c == nil || !c.conf.Enabled {
+		return nil
+	}
+
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if !c.conf.coalesced(method) {
+				return handler(ctx, result, method, args...)
+			}
+
+			params, err := json.Marshal(args)
+			if err != nil {
+				return handler(ctx, result, method, args...)
+			}
+
+			return c.call(ctx, nodeName+"/"+method+string(params), handler, result, method, args...)
+		}
+	}
+}
+
+func (c *coalescer) call(
+	ctx context.Context, key string, handler providers.CallContextFunc,
+	result interface{}, method string, args ...interface{},
+) error {
+	c.mu.Lock()
+	call, ok := c.calls[key]
+	if !ok {
+		call = &coalescedCall{done: make(chan struct{})}
+		c.calls[key] = call
+	}
+	c.mu.Unlock()
+
+	metrics.Registry.RPC.FullnodeCoalesced(method).Mark(ok)
+
+	if !ok { // leader to call upstream
+		c.lead(ctx, key, call, handler, method, args...)
+	} else {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// leader canceled or timed out by its own request context, so call upstream alone
+		if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+			return handler(ctx, result, method, args...)
+		}
+	}
+
+	if call.err != nil || len(call.result) == 0 {
+		return call.err
+	}
+
+	return json.Unmarshal(call.result, result)
+}
+
+// lead calls upstream on behalf of all the waiters, which are always released even if the
+// upstream call panics, so that they would not block forever on the in-flight call.
+func (c *coalescer) lead(
+	ctx context.Context, key string, call *coalescedCall, handler providers.CallContextFunc,
+	method string, args ...interface{},
+) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			call.err = errors.Errorf("coalesced call panicked: %v", r)
+		}
+
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+
+		close(call.done)
+
+		if r != nil { // propagate to the leader's caller
+			panic(r)
+		}
+	}()
+
+	call.err = handler(ctx, &call.result, method, args...)
+}
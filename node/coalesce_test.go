@@ -0,0 +1,91 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescerMiddleware(t *testing.T) {
+	var calls int32
+
+	upstream := func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return json.Unmarshal([]byte(`"0x64"`), result)
+	}
+
+	c := newCoalescer(&coalesceConfig{Enabled: true})
+	handler := c.middleware("node1")(upstream)
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := handler(context.Background(), &results[i], "eth_blockNumber")
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.Equal(t, "0x64", result)
+	}
+
+	// methods not coalesced
+	var result string
+	assert.NoError(t, handler(context.Background(), &result, "eth_call"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// disabled
+	assert.Nil(t, newCoalescer(&coalesceConfig{}).middleware("node1"))
+}
+
+func TestCoalescerLeaderPanic(t *testing.T) {
+	started := make(chan struct{})
+
+	upstream := func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		panic("upstream panicked")
+	}
+
+	c := newCoalescer(&coalesceConfig{Enabled: true})
+	handler := c.middleware("node1")(upstream)
+
+	leaderDone := make(chan interface{})
+	go func() {
+		defer func() { leaderDone <- recover() }()
+
+		var result string
+		handler(context.Background(), &result, "eth_blockNumber")
+	}()
+
+	<-started
+
+	// waiter released with error rather than blocked forever
+	var result string
+	err := handler(context.Background(), &result, "eth_blockNumber")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "upstream panicked")
+	}
+
+	// panic propagated to the leader
+	assert.Equal(t, "upstream panicked", <-leaderDone)
+
+	c.mu.Lock()
+	assert.Empty(t, c.calls)
+	c.mu.Unlock()
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNodeSaturated = errors.New("full node saturated with too many in-flight requests")
+)
+
+// concurrencyConfig per full node concurrency limit configurations
+type concurrencyConfig struct {
+	// max number of in-flight requests per full node, 0 for unlimited
+	MaxInflight int
+	// max duration to wait in queue for idle slot if the full node saturated, 0 to fail fast
+	QueueTimeout time.Duration `default:"100ms"`
+	// max number of reroutes to other full nodes when the routed one is saturated, beyond which
+	// requests will be queued for the routed full node
+	MaxReroutes int `default:"2"`
+}
+
+// concurrencyLimiter bounds the in-flight requests of some full node, so that one hot partition
+// of the hash ring could not overload a single full node.
+type concurrencyLimiter struct {
+	conf     *concurrencyConfig
+	nodeName string
+	slots    chan struct{}
+}
+
+func newConcurrencyLimiter(nodeName string, conf *concurrencyConfig) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		conf:     conf,
+		nodeName: nodeName,
+		slots:    make(chan struct{}, conf.MaxInflight),
+	}
+}
+
+// Saturated checks if no idle slot for more request, which is useful for routing.
+func (l *concurrencyLimiter) Saturated() bool {
+	return len(l.slots) >= cap(l.slots)
+}
+
+// Acquire acquires an idle slot, or waits in queue until timeout if saturated. If acquired,
+// the slot must be released by `Release` afterwards.
+func (l *concurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		l.updateMetrics()
+		return nil
+	default:
+	}
+
+	if l.conf.QueueTimeout <= 0 {
+		metrics.Registry.RPC.FullnodeSaturatedRejects(l.nodeName).Mark(1)
+		return ErrNodeSaturated
+	}
+
+	timer := time.NewTimer(l.conf.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		l.updateMetrics()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		metrics.Registry.RPC.FullnodeSaturatedRejects(l.nodeName).Mark(1)
+		return ErrNodeSaturated
+	}
+}
+
+// Release releases the acquired slot.
+func (l *concurrencyLimiter) Release() {
+	<-l.slots
+	l.updateMetrics()
+}
+
+func (l *concurrencyLimiter) updateMetrics() {
+	metrics.Registry.RPC.FullnodeInflight(l.nodeName).Update(int64(len(l.slots)))
+}
+
+// middleware returns the RPC client call middleware to bound the in-flight requests.
+func (l *concurrencyLimiter) middleware(handler providers.CallContextFunc) providers.CallContextFunc {
+	return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		if err := l.Acquire(ctx); err != nil {
+			return err
+		}
+
+		defer l.Release()
+
+		return handler(ctx, result, method, args...)
+	}
+}
+
+// batchMiddleware returns the RPC client batch call middleware to bound the in-flight requests,
+// which takes the whole batch as one request.
+func (l *concurrencyLimiter) batchMiddleware(handler providers.BatchCallContextFunc) providers.BatchCallContextFunc {
+	return func(ctx context.Context, b []w3rpc.BatchElem) error {
+		if err := l.Acquire(ctx); err != nil {
+			return err
+		}
+
+		defer l.Release()
+
+		return handler(ctx, b)
+	}
+}
+
+// concurrencyRegistry manages concurrency limiters for all full nodes.
+type concurrencyRegistry struct {
+	conf *concurrencyConfig
+
+	// node name => concurrency limiter
+	limiters util.ConcurrentMap
+}
+
+func newConcurrencyRegistry(conf *concurrencyConfig) *concurrencyRegistry {
+	return &concurrencyRegistry{conf: conf}
+}
+
+// get gets or creates the concurrency limiter for the specified full node, or returns nil
+// if unlimited.
+func (r *concurrencyRegistry) get(nodeName string) *concurrencyLimiter {
+	if r == nil || r.conf.MaxInflight <= 0 {
+		return nil
+	}
+
+	v, _ := r.limiters.LoadOrStoreFn(nodeName, func(k interface{}) interface{} {
+		return newConcurrencyLimiter(nodeName, r.conf)
+	})
+
+	return v.(*concurrencyLimiter)
+}
+
+// isSaturated checks if the specified full node is saturated with in-flight requests.
+func (r *concurrencyRegistry) isSaturated(nodeName string) bool {
+	if l := r.get(nodeName); l != nil {
+		return l.Saturated()
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l := newConcurrencyLimiter("test", &concurrencyConfig{MaxInflight: 2, QueueTimeout: 10 * time.Millisecond})
+
+	assert.NoError(t, l.Acquire(context.Background()))
+	assert.False(t, l.Saturated())
+
+	assert.NoError(t, l.Acquire(context.Background()))
+	assert.True(t, l.Saturated())
+
+	// timeout in queue
+	assert.Equal(t, ErrNodeSaturated, l.Acquire(context.Background()))
+
+	// admitted once slot released in queue
+	go func() {
+		time.Sleep(time.Millisecond)
+		l.Release()
+	}()
+
+	l.conf.QueueTimeout = time.Second
+	assert.NoError(t, l.Acquire(context.Background()))
+}
+
+func TestConcurrencyRegistryUnlimited(t *testing.T) {
+	r := newConcurrencyRegistry(&concurrencyConfig{})
+	assert.Nil(t, r.get("test"))
+	assert.False(t, r.isSaturated("test"))
+
+	var nilRegistry *concurrencyRegistry
+	assert.False(t, nilRegistry.isSaturated("test"))
+}
+
+func TestConcurrencyLimiterBatchMiddleware(t *testing.T) {
+	l := newConcurrencyLimiter("test", &concurrencyConfig{MaxInflight: 1})
+
+	var inflight int
+	handler := l.batchMiddleware(func(ctx context.Context, b []w3rpc.BatchElem) error {
+		inflight = len(l.slots)
+		return nil
+	})
+
+	// slot taken by the whole batch, and released afterwards
+	batch := []w3rpc.BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_chainId"}}
+	assert.NoError(t, handler(context.Background(), batch))
+	assert.Equal(t, 1, inflight)
+	assert.False(t, l.Saturated())
+
+	// rejected if saturated
+	assert.NoError(t, l.Acquire(context.Background()))
+	assert.Equal(t, ErrNodeSaturated, handler(context.Background(), batch))
+
+	l.Release()
+	assert.NoError(t, handler(context.Background(), batch))
+}
@@ -5,11 +5,18 @@ import (
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/buraksezer/consistent"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 // Node manager component always uses configuration from viper.
 
+const (
+	// route affinity of caller identity
+	RouteAffinityIP  = "ip"  // route by client IP
+	RouteAffinityKey = "key" // route by API key, or client IP if API key not provided
+)
+
 var cfg config
 var urlCfg map[Group]UrlConfig
 var ethUrlCfg map[Group]UrlConfig
@@ -18,6 +25,14 @@ func init() {
 	viper.MustUnmarshalKey("node", &cfg)
 	logrus.WithField("config", cfg).Debug("Node manager configurations loaded.")
 
+	if _, err := cfg.HashRing.Raw(); err != nil {
+		logrus.WithError(err).Fatal("Invalid hash ring configurations")
+	}
+
+	if cfg.Router.Affinity != RouteAffinityIP && cfg.Router.Affinity != RouteAffinityKey {
+		logrus.WithField("affinity", cfg.Router.Affinity).Fatal("Unsupported route affinity")
+	}
+
 	urlCfg = map[Group]UrlConfig{
 		GroupCfxHttp: {
 			Nodes:    cfg.URLs,
@@ -36,6 +51,9 @@ func init() {
 		GroupCfxFilter: {
 			Nodes: cfg.FilterNodes,
 		},
+		GroupCfxTraces: {
+			Nodes: cfg.TraceNodes,
+		},
 	}
 
 	ethUrlCfg = map[Group]UrlConfig{
@@ -53,6 +71,9 @@ func init() {
 		GroupEthFilter: {
 			Nodes: cfg.EthFilterNodes,
 		},
+		GroupEthTraces: {
+			Nodes: cfg.EthTraceNodes,
+		},
 	}
 }
 
@@ -68,12 +89,10 @@ type config struct {
 	FilterNodes    []string
 	EthFilterNodes []string
 	ArchiveNodes   []string
-	HashRing       struct {
-		PartitionCount    int     `default:"15739"`
-		ReplicationFactor int     `default:"51"`
-		Load              float64 `default:"1.25"`
-	}
-	Monitor struct {
+	TraceNodes     []string
+	EthTraceNodes  []string
+	HashRing       HashRingConfig
+	Monitor        struct {
 		Interval time.Duration `default:"1s"`
 		Unhealth struct {
 			Failures          uint64        `default:"3"`
@@ -86,11 +105,23 @@ type config struct {
 			SuccessCounter uint64        `default:"60"`
 		}
 	}
-	Router struct {
-		RedisURL        string
-		NodeRPCURL      string
-		EthNodeRPCURL   string
-		ChainedFailover struct {
+	CircuitBreaker breakerConfig
+	Concurrency    concurrencyConfig
+	Coalesce       coalesceConfig
+	Hedge          hedgeConfig
+	Verify         verifyConfig
+	Discovery      discoveryConfig
+	LagFilter      lagFilterConfig
+	RouteStats     routeStatsConfig
+	Repartition    repartitionConfig
+	Selection      selectionConfig
+	Router         struct {
+		RedisURL          string
+		NodeRPCURL        string
+		EthNodeRPCURL     string
+		BootstrapInterval time.Duration `default:"3s"`
+		Affinity          string        `default:"ip"` // route affinity of caller identity, `ip` or `key`
+		ChainedFailover   struct {
 			URL      string
 			WSURL    string
 			EthURL   string
@@ -99,13 +130,63 @@ type config struct {
 	}
 }
 
-func (c *config) HashRingRaw() consistent.Config {
+// HashRingConfig consistent hash ring parameters.
+type HashRingConfig struct {
+	PartitionCount    int     `default:"15739" json:"partitionCount"`
+	ReplicationFactor int     `default:"51" json:"replicationFactor"`
+	Load              float64 `default:"1.25" json:"load"`
+	Hasher            string  `default:"xxhash" json:"hasher"` // `xxhash`, `fnv` or `crc64`
+}
+
+// Raw returns the hash ring config for consistent hashing, or error if any parameter invalid.
+func (c HashRingConfig) Raw() (consistent.Config, error) {
+	if c.PartitionCount <= 0 || c.ReplicationFactor <= 0 {
+		return consistent.Config{}, errors.New("partition count and replication factor must be positive")
+	}
+
+	// otherwise, not enough room to distribute partitions
+	if c.Load < 1 {
+		return consistent.Config{}, errors.New("load must not be less than 1")
+	}
+
+	h, ok := ringHashers[c.Hasher]
+	if !ok {
+		return consistent.Config{}, errors.Errorf("unsupported hasher %v", c.Hasher)
+	}
+
 	return consistent.Config{
-		PartitionCount:    c.HashRing.PartitionCount,
-		ReplicationFactor: c.HashRing.ReplicationFactor,
-		Load:              c.HashRing.Load,
-		Hasher:            &hasher{},
+		PartitionCount:    c.PartitionCount,
+		ReplicationFactor: c.ReplicationFactor,
+		Load:              c.Load,
+		Hasher:            h,
+	}, nil
+}
+
+// merge fills the unspecified parameters with the fallback ones.
+func (c HashRingConfig) merge(fallback HashRingConfig) HashRingConfig {
+	if c.PartitionCount == 0 {
+		c.PartitionCount = fallback.PartitionCount
+	}
+
+	if c.ReplicationFactor == 0 {
+		c.ReplicationFactor = fallback.ReplicationFactor
 	}
+
+	if c.Load == 0 {
+		c.Load = fallback.Load
+	}
+
+	if len(c.Hasher) == 0 {
+		c.Hasher = fallback.Hasher
+	}
+
+	return c
+}
+
+// HashRingRaw returns the hash ring config for consistent hashing, which is validated at startup.
+func (c *config) HashRingRaw() consistent.Config {
+	raw, _ := c.HashRing.Raw()
+	return raw
 }
 
 func Config() *config {
@@ -0,0 +1,368 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// discoveryConfig node auto-discovery configurations, by which the full nodes of route group are
+// periodically resolved from DNS or service registry.
+type discoveryConfig struct {
+	// interval to re-resolve the full nodes
+	Interval time.Duration `default:"30s"`
+	// timeout to resolve the full nodes
+	Timeout time.Duration `default:"5s"`
+	// route group => discovery source, eg., `dns+http://fullnode.svc.cluster.local:12537`
+	Groups map[string]string
+}
+
+// discoverer resolves the URLs of full nodes from DNS or service registry.
+type discoverer interface {
+	discover(ctx context.Context) ([]string, error)
+}
+
+// newDiscoverer creates discoverer from the source, which is in any of the following formats:
+//
+//   - `dns+<scheme>://<host>:<port>[/path]`: resolves host into IP addresses
+//   - `dnssrv+<scheme>://<name>[/path]`: resolves SRV records into target hosts and ports
+//   - `consul+<scheme>://<consul host>:<port>/<service>`: resolves passing instances of Consul service
+//   - `etcd://<etcd host>:<port>/<key prefix>`: resolves node URLs stored as values of etcd keys
+//
+// where `<scheme>` is the scheme of full node URLs, eg., `http` or `ws`.
+func newDiscoverer(source string) (discoverer, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid discovery source")
+	}
+
+	kind, scheme := u.Scheme, ""
+	if i := strings.Index(kind, "+"); i >= 0 {
+		kind, scheme = kind[:i], kind[i+1:]
+	}
+
+	if kind != "etcd" && len(scheme) == 0 {
+		return nil, errors.Errorf("node url scheme not specified for %v discovery", kind)
+	}
+
+	switch kind {
+	case "dns":
+		if len(u.Port()) == 0 {
+			return nil, errors.New("port not specified for dns discovery")
+		}
+
+		return &dnsDiscoverer{scheme: scheme, host: u.Hostname(), port: u.Port(), path: u.Path}, nil
+	case "dnssrv":
+		return &dnsSrvDiscoverer{scheme: scheme, name: u.Host, path: u.Path}, nil
+	case "consul":
+		service := strings.Trim(u.Path, "/")
+		if len(service) == 0 {
+			return nil, errors.New("service not specified for consul discovery")
+		}
+
+		return &consulDiscoverer{scheme: scheme, addr: u.Host, service: service}, nil
+	case "etcd":
+		return &etcdDiscoverer{addr: u.Host, prefix: strings.TrimPrefix(u.Path, "/")}, nil
+	default:
+		return nil, errors.Errorf("unsupported discovery source %v", kind)
+	}
+}
+
+// dnsDiscoverer resolves full nodes by the A/AAAA records of host, eg., Kubernetes headless service.
+type dnsDiscoverer struct {
+	scheme, host, port, path string
+}
+
+func (d *dnsDiscoverer) discover(ctx context.Context) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, d.host)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		urls = append(urls, fmt.Sprintf("%v://%v%v", d.scheme, net.JoinHostPort(addr, d.port), d.path))
+	}
+
+	return urls, nil
+}
+
+// dnsSrvDiscoverer resolves full nodes by the SRV records of name.
+type dnsSrvDiscoverer struct {
+	scheme, name, path string
+}
+
+func (d *dnsSrvDiscoverer) discover(ctx context.Context) ([]string, error) {
+	_, srvs, err := net.DefaultResolver.LookupSRV(ctx, "", "", d.name)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		host := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), fmt.Sprint(srv.Port))
+		urls = append(urls, fmt.Sprintf("%v://%v%v", d.scheme, host, d.path))
+	}
+
+	return urls, nil
+}
+
+// consulDiscoverer resolves full nodes by the passing instances of Consul service.
+type consulDiscoverer struct {
+	scheme, addr, service string
+}
+
+func (d *consulDiscoverer) discover(ctx context.Context) ([]string, error) {
+	api := fmt.Sprintf("http://%v/v1/health/service/%v?passing=true", d.addr, url.PathEscape(d.service))
+
+	var entries []struct {
+		Node    struct{ Address string }
+		Service struct {
+			Address string
+			Port    int
+		}
+	}
+
+	if err := discoveryHttpCall(ctx, http.MethodGet, api, nil, &entries); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, e := range entries {
+		addr := e.Service.Address
+		if len(addr) == 0 { // defaults to node address
+			addr = e.Node.Address
+		}
+
+		host := net.JoinHostPort(addr, fmt.Sprint(e.Service.Port))
+		urls = append(urls, fmt.Sprintf("%v://%v", d.scheme, host))
+	}
+
+	return urls, nil
+}
+
+// etcdDiscoverer resolves full nodes by the values of etcd keys with prefix through the etcd v3
+// JSON gateway, where each value is the URL of some full node.
+type etcdDiscoverer struct {
+	addr, prefix string
+}
+
+func (d *etcdDiscoverer) discover(ctx context.Context) ([]string, error) {
+	req := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(d.prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(etcdPrefixEnd(d.prefix)),
+	}
+
+	var resp struct {
+		Kvs []struct {
+			Value []byte // base64 decoded
+		}
+	}
+
+	api := fmt.Sprintf("http://%v/v3/kv/range", d.addr)
+	if err := discoveryHttpCall(ctx, http.MethodPost, api, req, &resp); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if url := strings.TrimSpace(string(kv.Value)); len(url) > 0 {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls, nil
+}
+
+// etcdPrefixEnd returns the range end to query all keys with prefix.
+func etcdPrefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	// all keys
+	return []byte{0}
+}
+
+func discoveryHttpCall(ctx context.Context, method, api string, body, result interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, api, &reqBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected http status %v", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// membership applies the membership changes of discovered full nodes to route group, which is
+// either the node pool of node management service or the local router of standalone process.
+type membership interface {
+	apply(grp Group, added, removed []string) error
+}
+
+// apply implements the membership interface to add or remove discovered full nodes of node pool.
+func (h *apiHandler) apply(grp Group, added, removed []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.pool.add(grp, added...); err != nil {
+		return err
+	}
+
+	h.pool.del(grp, removed...)
+	return nil
+}
+
+// discovery periodically re-resolves the full nodes of route groups, and applies the membership
+// changes to the node pool or local router.
+type discovery struct {
+	conf    discoveryConfig
+	members membership
+	sources map[Group]discoverer
+
+	// route group => node name => url discovered last time
+	discovered map[Group]map[string]string
+}
+
+// mustNewDiscovery creates discovery for the configured route groups, or returns nil if none
+// configured.
+func mustNewDiscovery(conf discoveryConfig, members membership, groups map[Group]UrlConfig) *discovery {
+	d := &discovery{
+		conf:       conf,
+		members:    members,
+		sources:    make(map[Group]discoverer),
+		discovered: make(map[Group]map[string]string),
+	}
+
+	for name, source := range conf.Groups {
+		grp := Group(name)
+		if _, ok := groups[grp]; !ok { // route group of the other space
+			continue
+		}
+
+		discoverer, err := newDiscoverer(source)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"group": grp, "source": source,
+			}).WithError(err).Fatal("Failed to create node discoverer")
+		}
+
+		d.sources[grp] = discoverer
+	}
+
+	if len(d.sources) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+// start resolves the full nodes at once, and then re-resolves periodically in background.
+func (d *discovery) start() {
+	d.refresh()
+	go d.run()
+}
+
+// run re-resolves the full nodes periodically.
+func (d *discovery) run() {
+	ticker := time.NewTicker(d.conf.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.refresh()
+	}
+}
+
+func (d *discovery) refresh() {
+	for grp, discoverer := range d.sources {
+		d.refreshGroup(grp, discoverer)
+	}
+}
+
+func (d *discovery) refreshGroup(grp Group, discoverer discoverer) {
+	logger := logrus.WithField("group", grp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.conf.Timeout)
+	defer cancel()
+
+	urls, err := discoverer.discover(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to discover full nodes")
+		return
+	}
+
+	// keeps the current membership, in case of transient failure of DNS or service registry
+	if len(urls) == 0 {
+		logger.Warn("No full node discovered")
+		return
+	}
+
+	added, removed, latest := diffNodeUrls(d.discovered[grp], urls)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	if err := d.members.apply(grp, added, removed); err != nil {
+		logger.WithError(err).Warn("Failed to apply discovered full nodes")
+		return
+	}
+
+	d.discovered[grp] = latest
+
+	logger.WithFields(logrus.Fields{
+		"added": added, "removed": removed,
+	}).Info("Discovered full nodes changed")
+}
+
+// diffNodeUrls returns the added and removed node URLs, along with the latest node set.
+func diffNodeUrls(prev map[string]string, urls []string) (added, removed []string, latest map[string]string) {
+	latest = make(map[string]string)
+	for _, url := range urls {
+		latest[rpc.Url2NodeName(url)] = url
+	}
+
+	for name, url := range latest {
+		if _, ok := prev[name]; !ok {
+			added = append(added, url)
+		}
+	}
+
+	for name, url := range prev {
+		if _, ok := latest[name]; !ok {
+			removed = append(removed, url)
+		}
+	}
+
+	return added, removed, latest
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiscoverer(t *testing.T) {
+	d, err := newDiscoverer("dns+http://fullnode.svc.cluster.local:12537")
+	assert.NoError(t, err)
+	assert.Equal(t, &dnsDiscoverer{scheme: "http", host: "fullnode.svc.cluster.local", port: "12537"}, d)
+
+	d, err = newDiscoverer("etcd://127.0.0.1:2379/confura/nodes/")
+	assert.NoError(t, err)
+	assert.Equal(t, &etcdDiscoverer{addr: "127.0.0.1:2379", prefix: "confura/nodes/"}, d)
+
+	_, err = newDiscoverer("dns://fullnode.svc.cluster.local:12537") // node url scheme missing
+	assert.Error(t, err)
+
+	_, err = newDiscoverer("zk+http://127.0.0.1:2181/fullnode")
+	assert.Error(t, err)
+}
+
+func TestConsulDiscoverer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/health/service/fullnode", r.URL.Path)
+		w.Write([]byte(`[
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "", "Port": 12537}},
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "10.0.0.2", "Port": 12537}}
+		]`))
+	}))
+	defer srv.Close()
+
+	d, err := newDiscoverer("consul+http://" + strings.TrimPrefix(srv.URL, "http://") + "/fullnode")
+	assert.NoError(t, err)
+
+	urls, err := d.discover(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.0.1:12537", "http://10.0.0.2:12537"}, urls)
+}
+
+func TestDiffNodeUrls(t *testing.T) {
+	added, removed, latest := diffNodeUrls(nil, []string{"http://10.0.0.1:12537"})
+	assert.Equal(t, []string{"http://10.0.0.1:12537"}, added)
+	assert.Empty(t, removed)
+
+	added, removed, _ = diffNodeUrls(latest, []string{"http://10.0.0.2:12537"})
+	assert.Equal(t, []string{"http://10.0.0.2:12537"}, added)
+	assert.Equal(t, []string{"http://10.0.0.1:12537"}, removed)
+}
+
+func TestEtcdPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("confura/nodes0"), etcdPrefixEnd("confura/nodes/"))
+	assert.Equal(t, []byte{0}, etcdPrefixEnd(""))
+}
+
+type mockDiscoverer struct {
+	urls []string
+}
+
+func (d *mockDiscoverer) discover(ctx context.Context) ([]string, error) {
+	return d.urls, nil
+}
+
+func TestDiscoveryLocalRouter(t *testing.T) {
+	router := NewLocalRouter(map[Group][]string{GroupEthHttp: {"http://10.0.0.1:8545"}})
+	mock := &mockDiscoverer{urls: []string{"http://10.0.0.2:8545"}}
+
+	d := &discovery{
+		conf:       discoveryConfig{Timeout: time.Second},
+		members:    router,
+		sources:    map[Group]discoverer{GroupEthHttp: mock},
+		discovered: make(map[Group]map[string]string),
+	}
+
+	nodes := func() (urls []string) {
+		for _, node := range router.groups[GroupEthHttp].nodes {
+			urls = append(urls, node.String())
+		}
+
+		sort.Strings(urls)
+		return urls
+	}
+
+	// statically configured full nodes are kept
+	d.refresh()
+	assert.Equal(t, []string{"http://10.0.0.1:8545", "http://10.0.0.2:8545"}, nodes())
+
+	mock.urls = []string{"http://10.0.0.3:8545"}
+	d.refresh()
+	assert.Equal(t, []string{"http://10.0.0.1:8545", "http://10.0.0.3:8545"}, nodes())
+	assert.Contains(t, nodes(), router.Route(GroupEthHttp, []byte("key")))
+
+	// membership kept if nothing discovered
+	mock.urls = nil
+	d.refresh()
+	assert.Equal(t, []string{"http://10.0.0.1:8545", "http://10.0.0.3:8545"}, nodes())
+
+	// route group not configured statically
+	d.sources[GroupEthWs] = &mockDiscoverer{urls: []string{"ws://10.0.0.4:8546"}}
+	d.refresh()
+	assert.Equal(t, "ws://10.0.0.4:8546", router.Route(GroupEthWs, []byte("key")))
+}
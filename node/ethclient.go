@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/Conflux-Chain/confura/store/mysql"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
 	"github.com/openweb3/web3go"
+	"github.com/sirupsen/logrus"
 )
 
 type Web3goClient struct {
@@ -20,13 +24,29 @@ func (w3c *Web3goClient) NodeName() string {
 	return rpcutil.Url2NodeName(w3c.URL)
 }
 
+// WithDeadline returns a copy of the client whose calls are bounded by the deadline of the context
+// if any, so that the remaining time budget of RPC request could be propagated into fullnode.
+func (w3c *Web3goClient) WithDeadline(ctx context.Context) *Web3goClient {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return w3c
+	}
+
+	return &Web3goClient{
+		Client: web3go.NewClientWithProvider(deadlineProvider{w3c.Provider(), deadline}),
+		URL:    w3c.URL,
+	}
+}
+
 // EthClientProvider provides evm space client by router.
 type EthClientProvider struct {
 	*clientProvider
 }
 
-func newEthClient(url string) (interface{}, error) {
-	client, err := rpcutil.NewEthClient(url, rpcutil.WithClientHookMetrics(true))
+func newEthClient(url string, options ...rpcutil.ClientOption) (interface{}, error) {
+	options = append(options, rpcutil.WithClientHookMetrics(true))
+
+	client, err := rpcutil.NewEthClient(url, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -52,10 +72,39 @@ func (p *EthClientProvider) GetClient(key string, groups ...Group) (*Web3goClien
 	return client.(*Web3goClient), nil
 }
 
-// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address.
+// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address,
+// or by API key if route affinity of API key configured.
 func (p *EthClientProvider) GetClientByIP(ctx context.Context, groups ...Group) (*Web3goClient, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
-	client, err := p.getClient(remoteAddr, ethNodeGroup(groups...))
+	client, err := p.getClientByContext(ctx, ethNodeGroup(groups...))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetClientByURL gets client of the specified full node in specific group (or use normal HTTP group
+// as default), or returns ErrCircuitOpen if the circuit of the full node is open.
+func (p *EthClientProvider) GetClientByURL(url string, groups ...Group) (*Web3goClient, error) {
+	if p.breakers.isOpen(rpcutil.Url2NodeName(url)) {
+		return nil, ErrCircuitOpen
+	}
+
+	grp := ethNodeGroup(groups...)
+	logger := logrus.WithField("group", grp)
+
+	client, err := p.loadClient(p.getOrRegisterGroup(grp), url, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetAlternativeClient gets client of some other full node than the excluded one in specific group
+// (or use normal HTTP group as default), eg., to fail over from the unavailable full node.
+func (p *EthClientProvider) GetAlternativeClient(excludedNode string, groups ...Group) (*Web3goClient, error) {
+	client, err := p.getAlternativeClient(ethNodeGroup(groups...), excludedNode)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +115,11 @@ func (p *EthClientProvider) GetClientByIP(ctx context.Context, groups ...Group)
 func (p *EthClientProvider) GetClientRandom() (*Web3goClient, error) {
 	key := fmt.Sprintf("random_key_%v", rand.Int())
 	client, err := p.getClient(key, GroupEthHttp)
+	if err != nil {
+		return nil, err
+	}
 
-	return client.(*Web3goClient), err
+	return client.(*Web3goClient), nil
 }
 
 func ethNodeGroup(groups ...Group) Group {
@@ -78,3 +130,79 @@ func ethNodeGroup(groups ...Group) Group {
 
 	return grp
 }
+
+// unavailableEthClient placeholder evm space client when no full node available, so that RPC
+// requests could still be served by store if possible, otherwise fail with ErrClientUnavailable.
+var unavailableEthClient = &Web3goClient{
+	Client: web3go.NewClientWithProvider(unavailableProvider{}),
+	URL:    "unavailable",
+}
+
+// UnavailableEthClient returns the placeholder evm space client when no full node available.
+func UnavailableEthClient() *Web3goClient {
+	return unavailableEthClient
+}
+
+// unavailableProvider RPC provider that always fails with ErrClientUnavailable.
+type unavailableProvider struct{}
+
+func (unavailableProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	return ErrClientUnavailable
+}
+
+func (unavailableProvider) BatchCallContext(ctx context.Context, b []w3rpc.BatchElem) error {
+	return ErrClientUnavailable
+}
+
+func (unavailableProvider) Subscribe(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) (*w3rpc.ClientSubscription, error) {
+	return nil, ErrClientUnavailable
+}
+
+func (unavailableProvider) SubscribeWithReconn(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) *w3rpc.ReconnClientSubscription {
+	return nil
+}
+
+func (unavailableProvider) Close() {}
+
+// deadlineProvider RPC provider that bounds calls with the deadline of RPC request, and never closes
+// the shared underlying provider.
+type deadlineProvider struct {
+	inner    interfaces.Provider
+	deadline time.Time
+}
+
+func (p deadlineProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	ctx, cancel := context.WithDeadline(ctx, p.deadline)
+	defer cancel()
+
+	return p.inner.CallContext(ctx, result, method, args...)
+}
+
+func (p deadlineProvider) BatchCallContext(ctx context.Context, b []w3rpc.BatchElem) error {
+	ctx, cancel := context.WithDeadline(ctx, p.deadline)
+	defer cancel()
+
+	return p.inner.BatchCallContext(ctx, b)
+}
+
+func (p deadlineProvider) Subscribe(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) (*w3rpc.ClientSubscription, error) {
+	return p.inner.Subscribe(ctx, namespace, channel, args...)
+}
+
+func (p deadlineProvider) SubscribeWithReconn(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) *w3rpc.ReconnClientSubscription {
+	return p.inner.SubscribeWithReconn(ctx, namespace, channel, args...)
+}
+
+func (deadlineProvider) Close() {}
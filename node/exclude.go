@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+)
+
+const ctxKeyRoutedNodes = handlers.CtxKey("Infura-Routed-Nodes")
+
+// routedNodes full nodes routed for the attempts of the same RPC call.
+type routedNodes struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// WithRoutedNodes tracks the full nodes routed for the RPC call, so that retries of the RPC call
+// will be routed to some other full nodes than the ones already attempted.
+func WithRoutedNodes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyRoutedNodes, &routedNodes{})
+}
+
+// RecordRoutedNode records the full node routed for the RPC call if tracked.
+func RecordRoutedNode(ctx context.Context, nodeName string) {
+	if nodes, ok := ctx.Value(ctxKeyRoutedNodes).(*routedNodes); ok {
+		nodes.mu.Lock()
+		defer nodes.mu.Unlock()
+
+		nodes.names = append(nodes.names, nodeName)
+	}
+}
+
+// excludedNodesFromContext returns the full nodes already attempted for the RPC call if tracked.
+func excludedNodesFromContext(ctx context.Context) []string {
+	nodes, ok := ctx.Value(ctxKeyRoutedNodes).(*routedNodes)
+	if !ok {
+		return nil
+	}
+
+	nodes.mu.Lock()
+	defer nodes.mu.Unlock()
+
+	return append([]string(nil), nodes.names...)
+}
+
+// routeExcluded returns the route function to route to some full node other than the excluded ones
+// with salted keys, which returns empty URL if no other full node available.
+func routeExcluded(
+	routeFn func(group Group, key []byte) string, excludedNodes []string,
+) func(group Group, key []byte) string {
+	return func(group Group, key []byte) string {
+		for i := 0; i <= maxAlternativeRoutes; i++ {
+			saltedKey := key
+			if i > 0 {
+				saltedKey = []byte(fmt.Sprintf("%s#exclude-%v", key, i))
+			}
+
+			url := routeFn(group, saltedKey)
+			if len(url) == 0 || !includeNode(excludedNodes, rpc.Url2NodeName(url)) {
+				return url
+			}
+		}
+
+		return ""
+	}
+}
@@ -0,0 +1,39 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteExcluded(t *testing.T) {
+	// routed to node1 by default, and node2 with salted key
+	routeFn := func(group Group, key []byte) string {
+		if bytes.Contains(key, []byte("#exclude-")) {
+			return "http://node2"
+		}
+
+		return "http://node1"
+	}
+
+	ctx := WithRoutedNodes(context.Background())
+	assert.Empty(t, excludedNodesFromContext(ctx))
+
+	// no full node excluded
+	assert.Equal(t, "http://node1", routeExcluded(routeFn, excludedNodesFromContext(ctx))(GroupEthHttp, []byte("key")))
+
+	// retry routed to other full node
+	RecordRoutedNode(ctx, "node1")
+	assert.Equal(t, []string{"node1"}, excludedNodesFromContext(ctx))
+	assert.Equal(t, "http://node2", routeExcluded(routeFn, excludedNodesFromContext(ctx))(GroupEthHttp, []byte("key")))
+
+	// no other full node available
+	RecordRoutedNode(ctx, "node2")
+	assert.Empty(t, routeExcluded(routeFn, excludedNodesFromContext(ctx))(GroupEthHttp, []byte("key")))
+
+	// not tracked
+	RecordRoutedNode(context.Background(), "node1")
+	assert.Empty(t, excludedNodesFromContext(context.Background()))
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/openweb3/web3go"
+	"github.com/sirupsen/logrus"
+)
+
+var defaultHedgedMethods = []string{"eth_call", "eth_getBalance"}
+
+// hedgeConfig hedged request configurations for evm space RPC proxy
+type hedgeConfig struct {
+	// whether to hedge requests to some other full node
+	Enabled bool
+	// delay without response before re-issuing the request to some other full node
+	Delay time.Duration `default:"100ms"`
+	// read-only methods to hedge, which defaults to `eth_call` and `eth_getBalance`
+	Methods []string
+}
+
+func (c *hedgeConfig) hedged(method string) bool {
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultHedgedMethods
+	}
+
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithHedge returns a copy of the client which re-issues the hedged RPC method to some other full
+// node of the same group if no response within the configured delay, and takes the first answer.
+// Note, the client is returned as it is if hedging disabled or the method not hedged.
+func (p *EthClientProvider) WithHedge(client *Web3goClient, group Group, method string) *Web3goClient {
+	if !cfg.Hedge.Enabled || !cfg.Hedge.hedged(method) {
+		return client
+	}
+
+	hp := &hedgedProvider{
+		conf:    &cfg.Hedge,
+		primary: client.Provider(),
+		alternative: func() (interfaces.Provider, error) {
+			alt, err := p.getAlternativeClient(group, client.NodeName())
+			if err != nil {
+				return nil, err
+			}
+
+			return alt.(*Web3goClient).Provider(), nil
+		},
+	}
+
+	return &Web3goClient{
+		Client: web3go.NewClientWithProvider(hp),
+		URL:    client.URL,
+	}
+}
+
+// hedgedResponse raw response of hedged request.
+type hedgedResponse struct {
+	result      json.RawMessage
+	err         error
+	alternative bool
+}
+
+func (resp *hedgedResponse) decode(result interface{}) error {
+	if resp.err != nil || len(resp.result) == 0 {
+		return resp.err
+	}
+
+	return json.Unmarshal(resp.result, result)
+}
+
+// hedgedProvider RPC provider that hedges the configured read-only methods to some other full node
+// after delay, and cancels the loser once answered. Other calls are delegated to the primary one.
+type hedgedProvider struct {
+	conf        *hedgeConfig
+	primary     interfaces.Provider
+	alternative func() (interfaces.Provider, error) // lazily routed once hedged
+}
+
+func (p *hedgedProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	if !p.conf.hedged(method) {
+		return p.primary.CallContext(ctx, result, method, args...)
+	}
+
+	// cancel the loser once answered
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	respCh := make(chan hedgedResponse, 2)
+	call := func(provider interfaces.Provider, alternative bool) {
+		var raw json.RawMessage
+		err := provider.CallContext(ctx, &raw, method, args...)
+		respCh <- hedgedResponse{raw, err, alternative}
+	}
+
+	go call(p.primary, false)
+	pending := 1
+
+	timer := time.NewTimer(p.conf.Delay)
+	defer timer.Stop()
+
+	var resp hedgedResponse
+
+	select {
+	case resp = <-respCh:
+		pending--
+	case <-timer.C:
+	}
+
+	// answered within delay, or request canceled
+	if pending == 0 && (hedgeAnswered(resp.err) || ctx.Err() != nil) {
+		return resp.decode(result)
+	}
+
+	// hedge after delay, or fall back to the alternative on transport failure
+	alt, err := p.alternative()
+	if err != nil {
+		logrus.WithField("method", method).WithError(err).Debug("Failed to route hedged request")
+
+		if pending > 0 {
+			resp = <-respCh
+		}
+
+		return resp.decode(result)
+	}
+
+	metrics.Registry.RPC.FullnodeHedged(method).Mark(1)
+	go call(alt, true)
+	pending++
+
+	// take the first answer, otherwise the failure of the last one
+	for ; pending > 0; pending-- {
+		if resp = <-respCh; hedgeAnswered(resp.err) {
+			break
+		}
+	}
+
+	metrics.Registry.RPC.FullnodeHedgeWin(method).Mark(resp.alternative)
+
+	return resp.decode(result)
+}
+
+// hedgeAnswered returns whether the full node answered with either result or JSON-RPC error (eg.,
+// execution reverted), which is returned to client immediately, rather than transport or timeout
+// error which could be saved by some other full node.
+func hedgeAnswered(err error) bool {
+	return err == nil || utils.IsRPCJSONError(err)
+}
+
+func (p *hedgedProvider) BatchCallContext(ctx context.Context, b []w3rpc.BatchElem) error {
+	return p.primary.BatchCallContext(ctx, b)
+}
+
+func (p *hedgedProvider) Subscribe(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) (*w3rpc.ClientSubscription, error) {
+	return p.primary.Subscribe(ctx, namespace, channel, args...)
+}
+
+func (p *hedgedProvider) SubscribeWithReconn(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) *w3rpc.ReconnClientSubscription {
+	return p.primary.SubscribeWithReconn(ctx, namespace, channel, args...)
+}
+
+// Close never closes the shared underlying providers.
+func (p *hedgedProvider) Close() {}
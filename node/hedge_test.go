@@ -0,0 +1,121 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// delayedProvider answers with the result or error after delay unless canceled.
+type delayedProvider struct {
+	unavailableProvider
+
+	delay    time.Duration
+	result   string
+	err      error
+	canceled chan struct{}
+}
+
+func (p *delayedProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	select {
+	case <-time.After(p.delay):
+		if p.err != nil {
+			return p.err
+		}
+
+		return json.Unmarshal([]byte(p.result), result)
+	case <-ctx.Done():
+		close(p.canceled)
+		return ctx.Err()
+	}
+}
+
+func newTestHedgedProvider(primary, alternative *delayedProvider) *hedgedProvider {
+	return &hedgedProvider{
+		conf:    &hedgeConfig{Delay: 20 * time.Millisecond},
+		primary: primary,
+		alternative: func() (interfaces.Provider, error) {
+			return alternative, nil
+		},
+	}
+}
+
+func TestHedgedProvider(t *testing.T) {
+	// answered by primary within delay
+	primary := &delayedProvider{result: `"0x1"`, canceled: make(chan struct{})}
+	alternative := &delayedProvider{result: `"0x2"`, canceled: make(chan struct{})}
+
+	var result string
+	err := newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", result)
+
+	// answered by alternative, and primary canceled
+	primary.delay = time.Second
+
+	err = newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", result)
+
+	select {
+	case <-primary.canceled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "primary request not canceled")
+	}
+
+	// not hedged
+	primary.canceled = make(chan struct{})
+	primary.delay = 50 * time.Millisecond
+
+	err = newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_blockNumber")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", result)
+}
+
+type revertedService struct{}
+
+func (revertedService) Call() error {
+	return errors.New("execution reverted")
+}
+
+func TestHedgedProviderErrors(t *testing.T) {
+	// JSON-RPC error responded by full node
+	server := w3rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", revertedService{}))
+	defer server.Stop()
+
+	jsonErr := w3rpc.DialInProc(server).CallContext(context.Background(), nil, "eth_call")
+	assert.Error(t, jsonErr)
+
+	// JSON-RPC error of primary returned immediately after hedged, without waiting for alternative
+	primary := &delayedProvider{delay: 50 * time.Millisecond, err: jsonErr, canceled: make(chan struct{})}
+	alternative := &delayedProvider{delay: time.Second, result: `"0x2"`, canceled: make(chan struct{})}
+
+	var result string
+	start := time.Now()
+	err := newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_call")
+	assert.Equal(t, jsonErr, err)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+
+	// JSON-RPC error of primary within delay not hedged
+	primary.delay = 0
+
+	err = newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_call")
+	assert.Equal(t, jsonErr, err)
+
+	// fall back to alternative on transport error of primary
+	primary.err = errors.New("connection refused")
+	alternative.delay = 0
+
+	err = newTestHedgedProvider(primary, alternative).CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", result)
+}
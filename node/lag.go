@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+)
+
+const ctxKeyLatestSensitive = handlers.CtxKey("Infura-Latest-Sensitive")
+
+// lagFilterConfig epoch-lag-aware routing configurations, by which the full nodes lagging behind the
+// cluster are excluded from routing for "latest"-sensitive RPC calls.
+type lagFilterConfig struct {
+	// whether to exclude lagging full nodes for "latest"-sensitive RPC calls
+	Enabled bool
+	// max number of epochs (or blocks) behind the middle epoch of cluster
+	MaxLag uint64 `default:"5"`
+}
+
+// LatestRouter is implemented by Router which routes "latest"-sensitive RPC requests to the full
+// nodes not lagging behind the cluster.
+type LatestRouter interface {
+	// RouteLatest returns the full node URL not lagging behind for specified group and key.
+	RouteLatest(group Group, key []byte) string
+}
+
+// WithLatestSensitive marks the RPC call as "latest"-sensitive, so that the full nodes lagging
+// behind the cluster will be excluded from routing if configured.
+func WithLatestSensitive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyLatestSensitive, true)
+}
+
+func isLatestSensitive(ctx context.Context) bool {
+	latest, _ := ctx.Value(ctxKeyLatestSensitive).(bool)
+	return latest
+}
+
+// routeLatest routes "latest"-sensitive requests by router if supported, otherwise in normal way.
+func routeLatest(router Router, group Group, key []byte) string {
+	if lr, ok := router.(LatestRouter); ok {
+		return lr.RouteLatest(group, key)
+	}
+
+	return router.Route(group, key)
+}
+
+// laggingLocked returns whether the full node lags behind the middle epoch of cluster more than
+// configured, which requires the lock held.
+func (m *Manager) laggingLocked(nodeName string) bool {
+	epoch, ok := m.nodeName2Epochs[nodeName]
+	if !ok {
+		return false
+	}
+
+	mid := m.HealthyEpoch()
+	return mid > epoch && mid-epoch > cfg.LagFilter.MaxLag
+}
+
+// distributeLatest distributes a full node not lagging behind by specified key, which is the closest
+// one on the hash ring if the distributed full node lags behind. If all full nodes lag behind, the
+// distributed one is returned as it is.
+func (m *Manager) distributeLatest(key []byte) Node {
+	node := m.Distribute(key)
+	if node == nil || !cfg.LagFilter.Enabled {
+		return node
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.laggingLocked(node.Name()) {
+		return node
+	}
+
+	metrics.Registry.Nodes.LagExcluded(m.group.Space(), m.group.String(), node.Name()).Mark(1)
+
+	closest, err := m.hashRing.GetClosestN(key, len(m.hashRing.GetMembers()))
+	if err != nil {
+		return node
+	}
+
+	for _, member := range closest {
+		if n := member.(Node); !m.laggingLocked(n.Name()) {
+			return n
+		}
+	}
+
+	return node
+}
+
+// RouteLatest implements the LatestRouter interface.
+func (m *Manager) RouteLatest(key []byte) string {
+	return m.route(key, m.distributeLatest(key))
+}
@@ -1,14 +1,20 @@
 package node
 
 import (
+	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/buraksezer/consistent"
 	"github.com/cespare/xxhash"
+	"github.com/sirupsen/logrus"
 )
 
+// number of sampled keys to estimate key movement due to rebalance
+const rebalanceSampleKeys = 1000
+
 // nodeFactory factory method to create node instance
 type nodeFactory func(group Group, name, url string, hm HealthMonitor) (Node, error)
 
@@ -22,27 +28,50 @@ type Manager struct {
 	hashRing *consistent.Consistent // consistent hashing algorithm
 	resolver RepartitionResolver    // support repartition for hash ring
 	mu       sync.RWMutex
+	down     sync.Map // names of unhealthy full nodes removed from hash ring
 
 	nodeName2Epochs map[string]uint64 // node name => epoch
 	midEpoch        uint64            // middle epoch of managed full nodes.
+
+	stats     *routeStats   // distribution of routed keys, nil if disabled
+	closeOnce sync.Once     // to close the manager only once
+	closed    chan struct{} // closed once the manager closed
 }
 
 func NewManager(group Group) *Manager {
+	if cfg.Repartition.Enabled {
+		return NewManagerWithRepartition(group, newDynamicRepartitionResolverFromConfig(group, &cfg.Repartition))
+	}
+
 	return NewManagerWithRepartition(group, &noopRepartitionResolver{})
 }
 
 func NewManagerWithRepartition(group Group, resolver RepartitionResolver) *Manager {
-	return &Manager{
+	m := &Manager{
 		group:           group,
 		nodes:           make(map[string]Node),
 		resolver:        resolver,
 		nodeName2Epochs: make(map[string]uint64),
 		hashRing:        consistent.New(nil, cfg.HashRingRaw()),
+		closed:          make(chan struct{}),
+	}
+
+	if cfg.RouteStats.Enabled {
+		m.stats = newRouteStats(group)
+		go m.reportRouteStats(cfg.RouteStats)
+	}
+
+	if r, ok := resolver.(*DynamicRepartitionResolver); ok {
+		go r.run(m.closed, m.memberNames)
 	}
+
+	return m
 }
 
 // Close closes the manager to reclaim resources
 func (m *Manager) Close() {
+	m.closeOnce.Do(func() { close(m.closed) })
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -75,10 +104,19 @@ func (m *Manager) Remove(nodeNames ...string) {
 			delete(m.nodes, nn)
 			delete(m.nodeName2Epochs, nn)
 			m.hashRing.Remove(nn)
+			m.down.Delete(nn)
+			m.evictRemapped(nn)
 		}
 	}
 }
 
+// evictRemapped drops the keys remapped to the full node by dynamic repartition if configured.
+func (m *Manager) evictRemapped(nodeName string) {
+	if r, ok := m.resolver.(*DynamicRepartitionResolver); ok {
+		r.evict(nodeName)
+	}
+}
+
 // Get gets monitored fullnode from url
 func (m *Manager) Get(nodeName string) (Node, bool) {
 	m.mu.RLock()
@@ -102,6 +140,18 @@ func (m *Manager) List() []Node {
 	return nodes
 }
 
+// memberNames returns the names of healthy fullnodes in hash ring.
+func (m *Manager) memberNames() []string {
+	members := m.ring().GetMembers()
+
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, member.String())
+	}
+
+	return names
+}
+
 // String implements stringer interface
 func (m *Manager) String() string {
 	m.mu.RLock()
@@ -123,9 +173,14 @@ func (m *Manager) Distribute(key []byte) Node {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Use repartition resolver to distribute if configured.
+	// Use repartition resolver to distribute if configured, unless the resolved node removed or
+	// unhealthy (left the hash ring).
 	if name, ok := m.resolver.Get(k); ok {
-		return m.nodes[name]
+		if node, ok := m.nodes[name]; ok {
+			if _, down := m.down.Load(name); !down {
+				return node
+			}
+		}
 	}
 
 	member := m.hashRing.LocateKey(key)
@@ -141,14 +196,100 @@ func (m *Manager) Distribute(key []byte) Node {
 
 // Route implements the Router interface.
 func (m *Manager) Route(key []byte) string {
-	if n := m.Distribute(key); n != nil {
+	return m.route(key, m.Distribute(key))
+}
+
+// route returns the URL of the distributed full node, and updates the route metrics.
+func (m *Manager) route(key []byte, n Node) string {
+	if n != nil {
 		// metrics overall route QPS
 		metrics.Registry.Nodes.Routes(m.group.Space(), m.group.String(), "overall").Mark(1)
 		// metrics per node route QPS
 		metrics.Registry.Nodes.Routes(m.group.Space(), m.group.String(), n.Name()).Mark(1)
 
+		if m.stats != nil {
+			m.stats.mark(key, m.ring().FindPartitionID(key), n.Name())
+		}
+
 		return n.Url()
 	}
 
+	// no node available, eg., none configured or all unhealthy
+	metrics.Registry.Nodes.Routes(m.group.Space(), m.group.String(), "unavailable").Mark(1)
+
 	return ""
 }
+
+// Available returns whether any healthy node available to route.
+func (m *Manager) Available() bool {
+	return len(m.ring().GetMembers()) > 0
+}
+
+// ring returns the current hash ring, which could be rebuilt due to rebalance.
+func (m *Manager) ring() *consistent.Consistent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.hashRing
+}
+
+// Rebalance rebuilds the hash ring with the new parameters live, and returns the ratio of sampled
+// keys moved to other full nodes.
+//
+// Note, keys already resolved by the repartition resolver (if configured) are still routed to the
+// same full node, so as to minimize key movement.
+func (m *Manager) Rebalance(conf HashRingConfig) (float64, error) {
+	raw, err := conf.Raw()
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ring := consistent.New(m.hashRing.GetMembers(), raw)
+
+	var moved int
+	for i := 0; i < rebalanceSampleKeys; i++ {
+		key := []byte(fmt.Sprintf("rebalance_sample_key_%v", i))
+
+		prev, curr := m.hashRing.LocateKey(key), ring.LocateKey(key)
+		if prev != nil && curr != nil && prev.String() != curr.String() {
+			moved++
+		}
+	}
+
+	m.hashRing = ring
+
+	ratio := float64(moved) / rebalanceSampleKeys
+	logrus.WithFields(logrus.Fields{
+		"group": m.group, "config": conf, "movedRatio": ratio,
+	}).Info("Hash ring rebalanced")
+
+	return ratio, nil
+}
+
+// RouteStats returns the distribution of routed keys with top `k` keys and partitions,
+// or false if route stats disabled.
+func (m *Manager) RouteStats(k int) (*RouteStats, bool) {
+	if m.stats == nil {
+		return nil, false
+	}
+
+	return m.stats.snapshot(k, len(m.List())), true
+}
+
+// reportRouteStats reports route stats metrics periodically until manager closed.
+func (m *Manager) reportRouteStats(conf routeStatsConfig) {
+	ticker := time.NewTicker(conf.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.closed:
+			return
+		case <-ticker.C:
+			m.stats.report(len(m.List()), conf.HotKeyShare)
+		}
+	}
+}
@@ -51,16 +51,34 @@ func (m *Manager) ReportUnhealthy(nodeName string, remind bool, reason error) {
 	}
 
 	// remove unhealthy node from hash ring
+	m.mu.RLock()
 	m.hashRing.Remove(nodeName)
+	m.down.Store(nodeName, struct{}{})
+	m.mu.RUnlock()
 
-	// FIXME update repartition cache if configured
+	if !m.Available() {
+		logrus.WithField("group", m.group).Error("No healthy node available in group")
+	}
+
+	// keys remapped to the unhealthy node are routed by hash ring again
+	m.evictRemapped(nodeName)
 }
 
 // ReportHealthy reports healthy status of managed node to manager.
 func (m *Manager) ReportHealthy(nodeName string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// node might be removed during health monitoring
+	node, ok := m.nodes[nodeName]
+	if !ok {
+		return
+	}
+
 	// alert
 	logrus.WithField("node", nodeName).Warn("Node became healthy now")
 
 	// add recovered node into hash ring again
-	m.hashRing.Add(m.nodes[nodeName])
+	m.hashRing.Add(node)
+	m.down.Delete(nodeName)
 }
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNode struct {
+	*baseNode
+}
+
+func newMockNode(name string) *mockNode {
+	n := &mockNode{baseNode: newBaseNode(name, "http://"+name, func() {})}
+	n.atomicStatus.Store(NewStatus(GroupCfxHttp, name))
+
+	return n
+}
+
+func (n *mockNode) LatestEpochNumber() (uint64, error) {
+	return 0, nil
+}
+
+func TestManagerEmptyHashRing(t *testing.T) {
+	m := NewManager(GroupCfxHttp)
+	defer m.Close()
+
+	// none configured
+	assert.False(t, m.Available())
+	assert.Nil(t, m.Distribute([]byte("key")))
+	assert.Empty(t, m.Route([]byte("key")))
+
+	m.Add(newMockNode("node1"))
+	assert.True(t, m.Available())
+	assert.Equal(t, "http://node1", m.Route([]byte("key")))
+
+	// all unhealthy
+	m.ReportUnhealthy("node1", false, nil)
+	assert.False(t, m.Available())
+	assert.Empty(t, m.Route([]byte("key")))
+
+	// recovered after removed
+	m.Remove("node1")
+	m.ReportHealthy("node1")
+	assert.False(t, m.Available())
+	assert.Empty(t, m.Route([]byte("key")))
+}
+
+func TestLocalRouterBootstrapping(t *testing.T) {
+	r := NewLocalRouter(map[Group][]string{GroupCfxHttp: nil})
+	assert.True(t, r.bootstrapping())
+	assert.Empty(t, r.Route(GroupCfxHttp, []byte("key")))
+
+	r.update(map[Group][]string{GroupCfxHttp: {"http://node1"}})
+	assert.False(t, r.bootstrapping())
+	assert.Equal(t, "http://node1", r.Route(GroupCfxHttp, []byte("key")))
+}
+
+func TestManagerRebalance(t *testing.T) {
+	m := NewManager(GroupCfxHttp)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"), newMockNode("node3"))
+
+	// invalid parameters
+	_, err := m.Rebalance(HashRingConfig{Hasher: "md5"}.merge(cfg.HashRing))
+	assert.Error(t, err)
+
+	// unchanged parameters
+	moved, err := m.Rebalance(cfg.HashRing)
+	assert.NoError(t, err)
+	assert.Zero(t, moved)
+
+	// changed hasher
+	moved, err = m.Rebalance(HashRingConfig{Hasher: "fnv"}.merge(cfg.HashRing))
+	assert.NoError(t, err)
+	assert.Greater(t, moved, 0.0)
+	assert.NotEmpty(t, m.Route([]byte("key")))
+}
+
+func TestManagerRouteLatest(t *testing.T) {
+	defer func(conf lagFilterConfig) { cfg.LagFilter = conf }(cfg.LagFilter)
+	cfg.LagFilter = lagFilterConfig{Enabled: true, MaxLag: 5}
+
+	m := NewManager(GroupCfxHttp)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"), newMockNode("node3"))
+
+	key := []byte("key")
+	routed := m.Route(key)
+
+	for _, n := range m.List() {
+		epoch := uint64(100)
+		if n.Url() == routed { // lags behind
+			epoch = 90
+		}
+
+		m.ReportEpoch(n.Name(), epoch)
+	}
+
+	// lagging node excluded for "latest"-sensitive requests
+	latest := m.RouteLatest(key)
+	assert.NotEmpty(t, latest)
+	assert.NotEqual(t, routed, latest)
+
+	// still allowed for historical queries
+	assert.Equal(t, routed, m.Route(key))
+}
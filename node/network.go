@@ -0,0 +1,84 @@
+package node
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ManagedRouter routes RPC requests to the full nodes managed in process with health monitoring,
+// which is used by the extra networks (eg., testnet) served by the same gateway instance besides
+// the default one managed by the node management service.
+type ManagedRouter struct {
+	pool *nodePool
+}
+
+// MustNewManagedRouter creates router to manage the full nodes of the specified space (`cfx` or `eth`)
+// in process, or panics if any full node failed to add.
+func MustNewManagedRouter(space string, urls, wsUrls []string) *ManagedRouter {
+	var nf nodeFactory
+	var groupConf map[Group]UrlConfig
+
+	if space == "eth" {
+		nf = func(group Group, name, url string, hm HealthMonitor) (Node, error) {
+			return NewEthNode(group, name, url, hm)
+		}
+		groupConf = map[Group]UrlConfig{GroupEthHttp: {Nodes: urls}, GroupEthWs: {Nodes: wsUrls}}
+	} else {
+		nf = func(group Group, name, url string, hm HealthMonitor) (Node, error) {
+			return NewCfxNode(group, name, url, hm)
+		}
+		groupConf = map[Group]UrlConfig{GroupCfxHttp: {Nodes: urls}, GroupCfxWs: {Nodes: wsUrls}}
+	}
+
+	pool := newNodePool(nf)
+
+	for grp, cfg := range groupConf {
+		if err := pool.add(grp, cfg.Nodes...); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"group":  grp,
+				"config": cfg,
+			}).WithError(err).Fatal("Failed to add group nodes to the managed router")
+		}
+	}
+
+	return &ManagedRouter{pool: pool}
+}
+
+// Route implements the Router interface.
+func (r *ManagedRouter) Route(group Group, key []byte) string {
+	if m, ok := r.pool.manager(group); ok {
+		return m.Route(key)
+	}
+
+	return ""
+}
+
+// RouteLatest implements the LatestRouter interface.
+func (r *ManagedRouter) RouteLatest(group Group, key []byte) string {
+	if m, ok := r.pool.manager(group); ok {
+		return m.RouteLatest(key)
+	}
+
+	return ""
+}
+
+// RouteMethod implements the MethodRouter interface.
+func (r *ManagedRouter) RouteMethod(
+	group Group, key []byte, method string, params json.RawMessage, latest bool,
+) string {
+	if m, ok := r.pool.manager(group); ok {
+		return m.RouteMethod(key, method, params, latest)
+	}
+
+	return ""
+}
+
+// Close closes the node managers to reclaim resources.
+func (r *ManagedRouter) Close() {
+	for _, grp := range r.pool.groups() {
+		if m, ok := r.pool.manager(grp); ok {
+			m.Close()
+		}
+	}
+}
@@ -3,6 +3,7 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/confura/util/metrics"
@@ -63,6 +64,12 @@ func (s *Status) Update(n Node, monitor HealthMonitor) {
 	s.updateHealth(monitor)
 }
 
+// MeanLatency returns the mean heartbeat latency of the full node, which is cached upon heartbeat
+// rather than taking histogram snapshot for each call, since it's used to select node per request.
+func (s *Status) MeanLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.metric.meanLatency))
+}
+
 // MarshalJSON marshals as JSON.
 func (s *Status) MarshalJSON() ([]byte, error) {
 	type Status struct {
@@ -190,6 +197,8 @@ func (s *Status) Close() {
 type statusMetrics struct {
 	latency      string // ping latency via cfx_epochNumber/eth_blockNumber
 	availability string // node availability percent
+
+	meanLatency int64 // cached mean of latency histogram in nanoseconds, accessed atomically
 }
 
 func newStatusMetrics(latency, availability string) *statusMetrics {
@@ -201,7 +210,11 @@ func newStatusMetrics(latency, availability string) *statusMetrics {
 
 func (sm *statusMetrics) update(start time.Time, err error) {
 	if err == nil {
-		metrics.GetOrRegisterHistogram(sm.latency).Update(time.Since(start).Nanoseconds())
+		histogram := metrics.GetOrRegisterHistogram(sm.latency)
+		histogram.Update(time.Since(start).Nanoseconds())
+
+		// refresh the cached mean latency periodically along with heartbeat
+		atomic.StoreInt64(&sm.meanLatency, int64(histogram.Snapshot().Mean()))
 	}
 
 	metrics.GetOrRegisterTimeWindowPercentageDefault(sm.availability).Mark(err == nil)
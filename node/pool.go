@@ -106,6 +106,25 @@ func (p *nodePool) get(grp Group, excluded ...string) (urls []string) {
 	return urls
 }
 
+// healthy gets url of healthy nodes by group
+func (p *nodePool) healthy(grp Group) (urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, ok := p.managers[grp]
+	if !ok {
+		return nil
+	}
+
+	for _, n := range m.List() {
+		if status := n.Status(); !status.unhealthy {
+			urls = append(urls, n.Url())
+		}
+	}
+
+	return urls
+}
+
 // status returns status for (all or some specific) nodes by group
 func (p *nodePool) status(grp Group, included ...string) (res []Status) {
 	p.mu.Lock()
@@ -143,6 +162,28 @@ func (p *nodePool) groups() (res []Group) {
 	return res
 }
 
+// rebalance rebuilds the hash ring of all groups with the new parameters, and returns the ratio
+// of sampled keys moved by group.
+func (p *nodePool) rebalance(conf HashRingConfig) (map[Group]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := make(map[Group]float64)
+	for grp, m := range p.managers {
+		moved, err := m.Rebalance(conf)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to rebalance group %v", grp)
+		}
+
+		res[grp] = moved
+	}
+
+	// applies to the groups created later
+	cfg.HashRing = conf
+
+	return res, nil
+}
+
 // manager returns the node manager for specific group
 func (p *nodePool) manager(group Group) (*Manager, bool) {
 	p.mu.Lock()
@@ -90,6 +90,17 @@ func (r *SimpleRepartitionResolver) Put(key uint64, value string) {
 	}
 }
 
+// Delete deletes the repartitioned key if any.
+func (r *SimpleRepartitionResolver) Delete(key uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if value, ok := r.key2Items.Load(key); ok {
+		r.items.Remove(value.(*list.Element))
+		r.key2Items.Delete(key)
+	}
+}
+
 // gc removes the expired items.
 func (r *SimpleRepartitionResolver) gc() {
 	now := time.Now()
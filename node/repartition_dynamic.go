@@ -0,0 +1,306 @@
+package node
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// repartitionConfig dynamic repartition configurations to smooth out hot spots of hash ring
+type repartitionConfig struct {
+	// whether to remap hot keys from overloaded full nodes to underutilized ones
+	Enabled bool
+	// interval to collect the load of full nodes and remap hot keys
+	Interval time.Duration `default:"1m"`
+	// full node with load above the average by this ratio is regarded as overloaded
+	OverloadRatio float64 `default:"1.5"`
+	// max number of keys to remap per full node each round
+	MaxMoves int `default:"10"`
+	// expiration duration of remapped keys since last routed
+	TTL time.Duration `default:"1h"`
+	// redis to persist remapped keys, empty to keep in memory
+	RedisURL string
+}
+
+// number of shards to collect the load of route keys, so as to reduce lock contention on hot path
+const keyLoadShards = 32
+
+// keyLoad load of some route key within the recent interval.
+type keyLoad struct {
+	node string
+	hits int
+}
+
+// keyLoadShard shard of route keys load
+type keyLoadShard struct {
+	mu   sync.Mutex
+	keys map[uint64]*keyLoad // key => load within the recent interval
+}
+
+// remapInfo key remapped by dynamic repartition
+type remapInfo struct {
+	node     string    // target full node
+	lastSeen time.Time // last interval the key routed
+}
+
+// repartitionDeleter is implemented by repartition resolver which supports to delete remapped key.
+type repartitionDeleter interface {
+	Delete(key uint64)
+}
+
+// DynamicRepartitionResolver implements RepartitionResolver, which monitors the load (route hits)
+// of full nodes and remaps the hottest keys from overloaded full nodes to underutilized ones
+// periodically, so as to smooth out the hot spots of consistent hashing. Remapped keys are persisted
+// by the underlying resolver, while the others are still routed by the hash ring.
+type DynamicRepartitionResolver struct {
+	conf     *repartitionConfig
+	group    Group
+	resolver RepartitionResolver // to persist remapped keys
+
+	shards [keyLoadShards]keyLoadShard
+
+	mu       sync.Mutex
+	remapped map[uint64]*remapInfo // keys remapped by this resolver
+}
+
+func NewDynamicRepartitionResolver(
+	group Group, conf *repartitionConfig, resolver RepartitionResolver,
+) *DynamicRepartitionResolver {
+	r := &DynamicRepartitionResolver{
+		conf:     conf,
+		group:    group,
+		resolver: resolver,
+		remapped: make(map[uint64]*remapInfo),
+	}
+
+	for i := range r.shards {
+		r.shards[i].keys = make(map[uint64]*keyLoad)
+	}
+
+	return r
+}
+
+// newDynamicRepartitionResolverFromConfig creates dynamic repartition resolver for the node group,
+// which persists remapped keys in redis if configured, otherwise in memory.
+func newDynamicRepartitionResolverFromConfig(group Group, conf *repartitionConfig) *DynamicRepartitionResolver {
+	var resolver RepartitionResolver = NewSimpleRepartitionResolver(conf.TTL)
+
+	if len(conf.RedisURL) > 0 {
+		opt, err := redis.ParseURL(conf.RedisURL)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to parse redis URL for repartition")
+		}
+
+		resolver = NewRedisRepartitionResolver(redis.NewClient(opt), conf.TTL, string(group))
+	}
+
+	return NewDynamicRepartitionResolver(group, conf, resolver)
+}
+
+// Get implements RepartitionResolver to get the remapped full node if any.
+func (r *DynamicRepartitionResolver) Get(key uint64) (string, bool) {
+	node, ok := r.resolver.Get(key)
+	if ok {
+		r.mark(key, node)
+	}
+
+	return node, ok
+}
+
+// Put implements RepartitionResolver to collect the load of key routed by hash ring, which is not
+// persisted unless remapped.
+func (r *DynamicRepartitionResolver) Put(key uint64, value string) {
+	r.mark(key, value)
+}
+
+func (r *DynamicRepartitionResolver) mark(key uint64, node string) {
+	shard := &r.shards[key%keyLoadShards]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if kl, ok := shard.keys[key]; ok {
+		kl.node = node
+		kl.hits++
+	} else {
+		shard.keys[key] = &keyLoad{node: node, hits: 1}
+	}
+}
+
+// collect returns the load of route keys within the recent interval, and resets for the next interval.
+func (r *DynamicRepartitionResolver) collect() map[uint64]*keyLoad {
+	keys := make(map[uint64]*keyLoad)
+
+	for i := range r.shards {
+		shard := &r.shards[i]
+
+		shard.mu.Lock()
+		for k, v := range shard.keys {
+			keys[k] = v
+		}
+		shard.keys = make(map[uint64]*keyLoad)
+		shard.mu.Unlock()
+	}
+
+	return keys
+}
+
+// evict drops the keys remapped to the full node, eg., once the full node became unhealthy or removed,
+// so that those keys will be routed by the hash ring again. Returns the number of keys dropped.
+func (r *DynamicRepartitionResolver) evict(node string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleter, deletable := r.resolver.(repartitionDeleter)
+
+	var evicted int
+	for k, info := range r.remapped {
+		if info.node != node {
+			continue
+		}
+
+		if deletable {
+			deleter.Delete(k)
+		}
+
+		delete(r.remapped, k)
+		evicted++
+	}
+
+	if evicted > 0 {
+		logrus.WithFields(logrus.Fields{
+			"group": r.group, "node": node, "evicted": evicted,
+		}).Info("Remapped keys evicted by dynamic repartition")
+	}
+
+	return evicted
+}
+
+// run remaps hot keys periodically among the full nodes until closed.
+func (r *DynamicRepartitionResolver) run(closed <-chan struct{}, nodes func() []string) {
+	ticker := time.NewTicker(r.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			r.rebalance(nodes())
+		}
+	}
+}
+
+// rebalance remaps the hottest keys of overloaded full nodes to the least loaded ones among the
+// specified healthy full nodes based on the load within the recent interval, and returns the number
+// of keys remapped.
+func (r *DynamicRepartitionResolver) rebalance(nodes []string) int {
+	keys := r.collect()
+	r.expire(keys)
+
+	if len(nodes) < 2 {
+		return 0
+	}
+
+	loads := make(map[string]int, len(nodes))
+	node2Keys := make(map[string][]uint64, len(nodes))
+
+	for _, node := range nodes {
+		loads[node] = 0
+	}
+
+	var total int
+	for k, kl := range keys {
+		if _, ok := loads[kl.node]; !ok { // node removed or unhealthy
+			continue
+		}
+
+		loads[kl.node] += kl.hits
+		node2Keys[kl.node] = append(node2Keys[kl.node], k)
+		total += kl.hits
+	}
+
+	threshold := float64(total) / float64(len(nodes)) * r.conf.OverloadRatio
+
+	// overloaded full nodes in descending order of load
+	sort.Slice(nodes, func(i, j int) bool { return loads[nodes[i]] > loads[nodes[j]] })
+
+	var moved int
+	for _, node := range nodes {
+		if float64(loads[node]) <= threshold {
+			break
+		}
+
+		// hottest keys at first
+		nkeys := node2Keys[node]
+		sort.Slice(nkeys, func(i, j int) bool { return keys[nkeys[i]].hits > keys[nkeys[j]].hits })
+
+		for i := 0; i < len(nkeys) && i < r.conf.MaxMoves && float64(loads[node]) > threshold; i++ {
+			target := leastLoadedNode(loads)
+			hits := keys[nkeys[i]].hits
+
+			// remap only if the hot spot smoothed out, eg., not a single dominant key
+			if target == node || loads[target]+hits >= loads[node] {
+				continue
+			}
+
+			r.remap(nkeys[i], target)
+			loads[node], loads[target] = loads[node]-hits, loads[target]+hits
+			moved++
+
+			logrus.WithFields(logrus.Fields{
+				"group": r.group,
+				"key":   nkeys[i],
+				"hits":  hits,
+				"from":  node,
+				"to":    target,
+			}).Debug("Hot key remapped by dynamic repartition")
+		}
+	}
+
+	if moved > 0 {
+		metrics.Registry.Nodes.RepartitionMoves(r.group.Space(), r.group.String()).Mark(int64(moved))
+		logrus.WithFields(logrus.Fields{"group": r.group, "moved": moved}).Info("Hot keys remapped by dynamic repartition")
+	}
+
+	return moved
+}
+
+func (r *DynamicRepartitionResolver) remap(key uint64, node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resolver.Put(key, node)
+	r.remapped[key] = &remapInfo{node: node, lastSeen: time.Now()}
+}
+
+// expire forgets the remapped keys not routed within the TTL, which are expired by the underlying
+// resolver as well.
+func (r *DynamicRepartitionResolver) expire(keys map[uint64]*keyLoad) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, info := range r.remapped {
+		if _, ok := keys[k]; ok {
+			info.lastSeen = now
+		} else if now.Sub(info.lastSeen) > r.conf.TTL {
+			delete(r.remapped, k)
+		}
+	}
+}
+
+func leastLoadedNode(loads map[string]int) (node string) {
+	min := -1
+	for n, load := range loads {
+		if min < 0 || load < min || (load == min && n < node) {
+			node, min = n, load
+		}
+	}
+
+	return node
+}
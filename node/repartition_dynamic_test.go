@@ -0,0 +1,99 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicRepartitionResolver(t *testing.T) {
+	conf := repartitionConfig{OverloadRatio: 1.2, MaxMoves: 10}
+	r := NewDynamicRepartitionResolver(Group("test"), &conf, NewSimpleRepartitionResolver(time.Minute))
+
+	// node1 overloaded by 2 hot keys, while node3 idle
+	for i := 0; i < 60; i++ {
+		r.Put(1, "node1")
+	}
+
+	for i := 0; i < 40; i++ {
+		r.Put(2, "node1")
+	}
+
+	for i := 0; i < 30; i++ {
+		r.Put(3, "node2")
+	}
+
+	moved := r.rebalance([]string{"node1", "node2", "node3"})
+	assert.Equal(t, 1, moved)
+
+	// hot key remapped to the idle node
+	node, ok := r.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "node3", node)
+
+	_, ok = r.Get(2)
+	assert.False(t, ok)
+
+	// single dominant key never remapped
+	for i := 0; i < 100; i++ {
+		r.Put(4, "node2")
+	}
+
+	assert.Equal(t, 0, r.rebalance([]string{"node1", "node2", "node3"}))
+}
+
+func TestDynamicRepartitionEvictUnhealthyNode(t *testing.T) {
+	conf := repartitionConfig{Interval: time.Hour, OverloadRatio: 1.2, MaxMoves: 10, TTL: time.Minute}
+	r := NewDynamicRepartitionResolver(GroupCfxHttp, &conf, NewSimpleRepartitionResolver(time.Minute))
+
+	m := NewManagerWithRepartition(GroupCfxHttp, r)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"), newMockNode("node3"))
+
+	// unhealthy node never selected as remap target
+	m.ReportUnhealthy("node3", false, nil)
+	assert.ElementsMatch(t, []string{"node1", "node2"}, m.memberNames())
+
+	for i := 0; i < 60; i++ {
+		r.Put(1, "node1")
+	}
+
+	for i := 0; i < 40; i++ {
+		r.Put(2, "node1")
+	}
+
+	for i := 0; i < 10; i++ {
+		r.Put(3, "node3")
+	}
+
+	assert.Equal(t, 1, r.rebalance(m.memberNames()))
+
+	node, ok := r.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "node2", node)
+
+	// remapped keys dropped once target node unhealthy
+	m.ReportUnhealthy("node2", false, nil)
+
+	_, ok = r.Get(1)
+	assert.False(t, ok)
+	assert.Empty(t, r.remapped)
+}
+
+func TestManagerDistributeSkipsUnhealthyRemappedNode(t *testing.T) {
+	resolver := NewSimpleRepartitionResolver(time.Minute)
+
+	m := NewManagerWithRepartition(GroupCfxHttp, resolver)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"))
+
+	node := m.Distribute([]byte("key"))
+	assert.NotNil(t, node)
+
+	// routed to the other healthy node
+	m.ReportUnhealthy(node.Name(), false, nil)
+	assert.NotEqual(t, node.Name(), m.Distribute([]byte("key")).Name())
+}
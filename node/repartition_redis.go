@@ -51,6 +51,14 @@ func (r *RedisRepartitionResolver) Put(key uint64, value string) {
 	}
 }
 
+// Delete deletes the repartitioned key if any.
+func (r *RedisRepartitionResolver) Delete(key uint64) {
+	redisKey := redisRepartitionKey(key, r.keyPrefix)
+	if err := r.client.Del(r.ctx, redisKey).Err(); err != nil {
+		r.logger.WithError(err).WithField("key", redisKey).Error("Failed to delete key from redis")
+	}
+}
+
 func redisRepartitionKey(key uint64, prefixs ...string) string {
 	prefixs = append(prefixs, "key")
 	prefixStr := strings.Join(prefixs, ":")
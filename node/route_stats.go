@@ -0,0 +1,121 @@
+package node
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// sliding time window to collect route stats
+	routeStatsSlotInterval = 10 * time.Second
+	routeStatsNumSlots     = 6
+)
+
+// route stats configurations
+type routeStatsConfig struct {
+	// whether to collect the distribution of routed keys
+	Enabled bool
+	// interval to report route stats metrics
+	ReportInterval time.Duration `default:"10s"`
+	// route key with traffic share above this ratio is regarded as hot key
+	HotKeyShare float64 `default:"0.2"`
+}
+
+// RouteStats distribution of routed keys across the hash ring of some node group within
+// the recent sliding time window.
+type RouteStats struct {
+	Group         Group             `json:"group"`
+	TotalHits     int               `json:"totalHits"`
+	Skew          float64           `json:"skew"`          // max node hits / average node hits
+	TopKeyShare   float64           `json:"topKeyShare"`   // top key hits / total hits
+	TopKeys       []metrics.Visitor `json:"topKeys"`       // hottest route keys
+	TopPartitions []metrics.Visitor `json:"topPartitions"` // hottest hash ring partitions
+	Nodes         []metrics.Visitor `json:"nodes"`         // route hits per node
+}
+
+// routeStats collects the distribution of routed keys across the hash ring, so that operators
+// could detect hot keys (eg., some popular client) which funnel disproportionate load to
+// some node.
+type routeStats struct {
+	group      Group
+	keys       metrics.TrafficCollector // route key => hits
+	partitions metrics.TrafficCollector // hash ring partition => hits
+	nodes      metrics.TrafficCollector // node name => hits
+}
+
+func newRouteStats(group Group) *routeStats {
+	return &routeStats{
+		group:      group,
+		keys:       metrics.NewTrafficCollector(routeStatsSlotInterval, routeStatsNumSlots),
+		partitions: metrics.NewTrafficCollector(routeStatsSlotInterval, routeStatsNumSlots),
+		nodes:      metrics.NewTrafficCollector(routeStatsSlotInterval, routeStatsNumSlots),
+	}
+}
+
+func (s *routeStats) mark(key []byte, partition int, node string) {
+	s.keys.MarkHit(string(key))
+	s.partitions.MarkHit(strconv.Itoa(partition))
+	s.nodes.MarkHit(node)
+}
+
+// snapshot returns the route stats with top `k` keys and partitions.
+func (s *routeStats) snapshot(k, numNodes int) *RouteStats {
+	stats := &RouteStats{
+		Group:         s.group,
+		TopKeys:       s.keys.TopkVisitors(k),
+		TopPartitions: s.partitions.TopkVisitors(k),
+		Nodes:         s.nodes.TopkVisitors(numNodes),
+	}
+
+	var maxHits int
+	for _, v := range stats.Nodes {
+		stats.TotalHits += v.Hits
+		if v.Hits > maxHits {
+			maxHits = v.Hits
+		}
+	}
+
+	if stats.TotalHits == 0 {
+		return stats
+	}
+
+	if numNodes > 0 {
+		stats.Skew = float64(maxHits) * float64(numNodes) / float64(stats.TotalHits)
+	}
+
+	if len(stats.TopKeys) > 0 {
+		stats.TopKeyShare = float64(stats.TopKeys[0].Hits) / float64(stats.TotalHits)
+	}
+
+	return stats
+}
+
+// report updates the route stats metrics, and warns about hot key if any.
+func (s *routeStats) report(numNodes int, hotKeyShare float64) {
+	stats := s.snapshot(1, numNodes)
+
+	space, group := s.group.Space(), s.group.String()
+	metrics.Registry.Nodes.RouteSkew(space, group).Update(stats.Skew)
+	metrics.Registry.Nodes.RouteTopKeyShare(space, group).Update(stats.TopKeyShare)
+
+	if len(stats.TopPartitions) > 0 {
+		qps := float64(stats.TopPartitions[0].Hits) / (routeStatsSlotInterval * routeStatsNumSlots).Seconds()
+		metrics.Registry.Nodes.RouteTopPartitionQps(space, group).Update(qps)
+	}
+
+	if hotKeyShare > 0 && stats.TopKeyShare > hotKeyShare {
+		metrics.Registry.Nodes.RouteHotKeys(space, group).Mark(1)
+
+		logrus.WithFields(logrus.Fields{
+			"group":    s.group,
+			"key":      stats.TopKeys[0].Source,
+			"hits":     stats.TopKeys[0].Hits,
+			"share":    stats.TopKeyShare,
+			"skew":     stats.Skew,
+			"numNodes": numNodes,
+		}).Warn("Hot route key detected with disproportionate load")
+	}
+}
@@ -0,0 +1,31 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteStatsSnapshot(t *testing.T) {
+	stats := newRouteStats(GroupCfxHttp)
+
+	// empty stats
+	snapshot := stats.snapshot(1, 2)
+	assert.Zero(t, snapshot.TotalHits)
+	assert.Zero(t, snapshot.Skew)
+
+	for i := 0; i < 6; i++ {
+		stats.mark([]byte("hot"), 1, "node1")
+	}
+
+	stats.mark([]byte("cold1"), 2, "node2")
+	stats.mark([]byte("cold2"), 3, "node2")
+
+	snapshot = stats.snapshot(1, 2)
+	assert.Equal(t, 8, snapshot.TotalHits)
+	assert.Equal(t, 1.5, snapshot.Skew)
+	assert.Equal(t, 0.75, snapshot.TopKeyShare)
+	assert.Equal(t, "hot", snapshot.TopKeys[0].Source)
+	assert.Equal(t, "1", snapshot.TopPartitions[0].Source)
+	assert.Len(t, snapshot.Nodes, 2)
+}
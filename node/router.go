@@ -2,6 +2,9 @@ package node
 
 import (
 	"context"
+	"encoding/json"
+	"hash/crc64"
+	"hash/fnv"
 	"strings"
 	"sync"
 	"time"
@@ -28,12 +31,14 @@ const (
 	GroupCfxFilter   Group = "cfxfilter"
 	GroupCfxLogs     Group = "cfxlog"
 	GroupCfxArchives Group = "cfxarchives"
+	GroupCfxTraces   Group = "cfxtraces"
 
 	// evm space fullnode groups
 	GroupEthHttp   Group = "ethhttp"
 	GroupEthWs     Group = "ethws"
 	GroupEthFilter Group = "ethfilter"
 	GroupEthLogs   Group = "ethlogs"
+	GroupEthTraces Group = "ethtraces"
 )
 
 // Space parses space from group name
@@ -81,10 +86,11 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 
 		routers = append(routers, NewNodeRpcRouter(client))
 
-		// Also add local router in case node rpc temporary unavailable
+		// Also add local router in case node rpc temporary unavailable, which will retry node
+		// discovery aggressively in bootstrap mode if node manager not ready yet.
 		localRouter, err := NewLocalRouterFromNodeRPC(client)
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to new local router with node rpc")
+			logrus.WithError(err).Warn("Failed to discover nodes from node rpc, local router in bootstrap mode")
 		}
 		routers = append(routers, localRouter)
 	}
@@ -96,7 +102,15 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 			group2Urls[k] = v.Nodes
 		}
 
-		routers = append(routers, NewLocalRouter(group2Urls))
+		localRouter := NewLocalRouter(group2Urls)
+
+		// track the full nodes resolved from DNS or service registry in process, since no node
+		// management service available to discover full nodes
+		if d := mustNewDiscovery(cfg.Discovery, localRouter, groupConf); d != nil {
+			d.start()
+		}
+
+		routers = append(routers, localRouter)
 	}
 
 	return NewChainedRouter(groupConf, routers...)
@@ -122,7 +136,35 @@ func (r *chainedRouter) Route(group Group, key []byte) string {
 		}
 	}
 
-	// Failover if configured
+	return r.failover(group, key)
+}
+
+// RouteLatest implements the LatestRouter interface.
+func (r *chainedRouter) RouteLatest(group Group, key []byte) string {
+	for _, r := range r.routers {
+		if val := routeLatest(r, group, key); len(val) > 0 {
+			return val
+		}
+	}
+
+	return r.failover(group, key)
+}
+
+// RouteMethod implements the MethodRouter interface.
+func (r *chainedRouter) RouteMethod(
+	group Group, key []byte, method string, params json.RawMessage, latest bool,
+) string {
+	for _, r := range r.routers {
+		if val := routeMethod(r, group, key, method, params, latest); len(val) > 0 {
+			return val
+		}
+	}
+
+	return r.failover(group, key)
+}
+
+// failover returns the chained default full node if configured.
+func (r *chainedRouter) failover(group Group, key []byte) string {
 	config, ok := r.groupConf[group]
 	if !ok {
 		return ""
@@ -186,16 +228,68 @@ func (r *NodeRpcRouter) Route(group Group, key []byte) string {
 	return result
 }
 
+// RouteLatest implements the LatestRouter interface.
+func (r *NodeRpcRouter) RouteLatest(group Group, key []byte) string {
+	var result string
+	if err := r.client.Call(&result, "node_routeLatest", group, hexutil.Bytes(key)); err != nil {
+		logrus.WithError(err).Error("Failed to route latest key from node RPC")
+		return ""
+	}
+
+	return result
+}
+
+// RouteMethod implements the MethodRouter interface.
+func (r *NodeRpcRouter) RouteMethod(
+	group Group, key []byte, method string, params json.RawMessage, latest bool,
+) string {
+	args := []interface{}{group, hexutil.Bytes(key), method, hexutil.Bytes(params)}
+	if latest { // optional argument, which is compatible with node server of elder versions
+		args = append(args, latest)
+	}
+
+	var result string
+	if err := r.client.Call(&result, "node_routeMethod", args...); err != nil {
+		logrus.WithError(err).Error("Failed to route method from node RPC")
+		return ""
+	}
+
+	return result
+}
+
 type localNode string
 
 func (n localNode) String() string { return string(n) }
 
+// supported hashers for hash ring
+var ringHashers = map[string]consistent.Hasher{
+	"xxhash": hasher{},
+	"fnv":    fnvHasher{},
+	"crc64":  crc64Hasher{},
+}
+
 type hasher struct{}
 
 func (h hasher) Sum64(data []byte) uint64 {
 	return xxhash.Sum64(data)
 }
 
+type fnvHasher struct{}
+
+func (h fnvHasher) Sum64(data []byte) uint64 {
+	fh := fnv.New64a()
+	fh.Write(data)
+	return fh.Sum64()
+}
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+type crc64Hasher struct{}
+
+func (h crc64Hasher) Sum64(data []byte) uint64 {
+	return crc64.Checksum(data, crc64Table)
+}
+
 type localNodeGroup struct {
 	nodes    map[string]localNode // name -> node URL
 	hashRing *consistent.Consistent
@@ -253,32 +347,51 @@ func (r *LocalRouter) Route(group Group, key []byte) string {
 	return ""
 }
 
+// NewLocalRouterFromNodeRPC creates local router with nodes discovered from node manager RPC.
+// Note, the router is still available even if failed to discover nodes at first, and will keep
+// polling in bootstrap mode until any node discovered.
 func NewLocalRouterFromNodeRPC(client *rpc.Client) (*LocalRouter, error) {
 	router := &LocalRouter{
 		groups: make(map[Group]*localNodeGroup),
 	}
 
-	if err := router.pollOnce(client); err != nil {
-		return router, err
-	}
+	err := router.pollOnce(client)
 
 	go router.poll(client)
 
-	return router, nil
+	return router, err
 }
 
 func (r *LocalRouter) poll(client *rpc.Client) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-
 	// could update nodes periodically all the time
-	for range ticker.C {
+	for {
+		interval := time.Minute
+		if r.bootstrapping() { // retry node discovery aggressively
+			interval = cfg.Router.BootstrapInterval
+		}
+
+		time.Sleep(interval)
+
 		if err := r.pollOnce(client); err != nil {
 			logrus.WithError(err).Error("Failed to poll route groups from node manager RPC")
 		}
 	}
 }
 
+// bootstrapping returns true if no node discovered yet for any group.
+func (r *LocalRouter) bootstrapping() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.groups {
+		if len(item.nodes) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (r *LocalRouter) pollOnce(client *rpc.Client) error {
 	groupNodes := make(map[Group][]string)
 
@@ -290,6 +403,36 @@ func (r *LocalRouter) pollOnce(client *rpc.Client) error {
 	return nil
 }
 
+// apply implements the membership interface to add or remove discovered full nodes.
+func (r *LocalRouter) apply(grp Group, added, removed []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.groups[grp]
+	if !ok {
+		r.groups[grp] = newLocalNodeGroup(added)
+		return nil
+	}
+
+	for _, v := range added {
+		nodeName := rpcutil.Url2NodeName(v)
+		if _, ok := item.nodes[nodeName]; !ok {
+			item.nodes[nodeName] = localNode(v)
+			item.hashRing.Add(localNode(v))
+		}
+	}
+
+	for _, v := range removed {
+		nodeName := rpcutil.Url2NodeName(v)
+		if node, ok := item.nodes[nodeName]; ok {
+			delete(item.nodes, nodeName)
+			item.hashRing.Remove(node.String())
+		}
+	}
+
+	return nil
+}
+
 func (r *LocalRouter) update(groupNodes map[Group][]string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
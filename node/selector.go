@@ -0,0 +1,278 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/cespare/xxhash"
+	"github.com/sirupsen/logrus"
+)
+
+const ctxKeyRouteMethod = handlers.CtxKey("Infura-Route-Method")
+
+// built-in node selector types
+const (
+	SelectorHash      = "hash"      // consistent by route key (rendezvous hashing)
+	SelectorLatency   = "latency"   // lower heartbeat latency preferred
+	SelectorFreshness = "freshness" // higher epoch preferred
+	SelectorRandom    = "random"    // weighted random
+)
+
+// SelectRequest RPC request to select full node for.
+type SelectRequest struct {
+	Key    []byte          // route key of caller identity
+	Method string          // RPC method
+	Params json.RawMessage // RPC params
+	Latest bool            // whether "latest"-sensitive, so that lagging full nodes are excluded
+}
+
+// NodeSelector scores the full node for some RPC request, the higher the better, by which the full
+// node with the highest score is selected.
+type NodeSelector interface {
+	Score(node Node, req *SelectRequest) float64
+}
+
+// MethodRouter is implemented by Router which routes RPC requests by the selection policy of method
+// group if configured.
+type MethodRouter interface {
+	// RouteMethod returns the full node URL selected for specified group, key and RPC request, which
+	// excludes the full nodes lagging behind if the request is "latest"-sensitive.
+	RouteMethod(group Group, key []byte, method string, params json.RawMessage, latest bool) string
+}
+
+// selectorConfig node selector with weight in a selection policy
+type selectorConfig struct {
+	// selector type, eg., `hash`, `latency`, `freshness` or `random`
+	Type string
+	// weight of score when composed with other selectors, which defaults to 1
+	Weight float64
+}
+
+// selectionPolicy composes node selectors for a group of methods
+type selectionPolicy struct {
+	// RPC methods, which are case insensitive and could end with `*` as wildcard, eg., `eth_call`
+	Methods []string
+	// selectors to compose, in which the scores are weighted summed
+	Selectors []selectorConfig
+}
+
+// selectionConfig pluggable node selection configurations, by which methods not matched with any
+// policy are routed by the hash ring as usual.
+type selectionConfig struct {
+	// selection policies per method group, in which the first matched one applies
+	Policies []selectionPolicy
+	// weights of full nodes (by node name) for weighted random selector, which defaults to 1
+	Weights map[string]float64
+}
+
+// policy returns the selection policy matched with the method if any.
+func (c *selectionConfig) policy(method string) (*selectionPolicy, bool) {
+	for i := range c.Policies {
+		if handlers.MatchMethod(c.Policies[i].Methods, method) {
+			return &c.Policies[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// WithRouteMethod sets the RPC request in context, so that the full node will be selected by the
+// selection policy of method group if configured.
+func WithRouteMethod(ctx context.Context, method string, params json.RawMessage) context.Context {
+	if _, ok := cfg.Selection.policy(method); !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, ctxKeyRouteMethod, &SelectRequest{Method: method, Params: params})
+}
+
+func routeMethodFromContext(ctx context.Context) (*SelectRequest, bool) {
+	req, ok := ctx.Value(ctxKeyRouteMethod).(*SelectRequest)
+	return req, ok
+}
+
+// routeMethod routes requests by router with selection policy if supported, otherwise in normal way.
+func routeMethod(
+	router Router, group Group, key []byte, method string, params json.RawMessage, latest bool,
+) string {
+	if mr, ok := router.(MethodRouter); ok {
+		return mr.RouteMethod(group, key, method, params, latest)
+	}
+
+	if latest {
+		return routeLatest(router, group, key)
+	}
+
+	return router.Route(group, key)
+}
+
+// hashSelector scores full node by rendezvous hashing of route key, so that calls of the same client
+// land on the same full node.
+type hashSelector struct{}
+
+func (hashSelector) Score(node Node, req *SelectRequest) float64 {
+	key := append(append([]byte{}, req.Key...), node.Name()...)
+	return float64(xxhash.Sum64(key)) / math.MaxUint64
+}
+
+// latencySelector scores full node by the mean heartbeat latency.
+type latencySelector struct{}
+
+func (latencySelector) Score(node Node, req *SelectRequest) float64 {
+	status := node.Status()
+	return 1 / (1 + status.MeanLatency().Seconds()*1000)
+}
+
+// freshnessSelector scores full node by the number of epochs behind the highest one of cluster.
+type freshnessSelector struct {
+	m *Manager
+}
+
+// Score implements NodeSelector, which requires the lock of manager held.
+func (s freshnessSelector) Score(node Node, req *SelectRequest) float64 {
+	epoch, ok := s.m.nodeName2Epochs[node.Name()]
+	if !ok {
+		return 0
+	}
+
+	var max uint64
+	for _, e := range s.m.nodeName2Epochs {
+		if e > max {
+			max = e
+		}
+	}
+
+	return 1 / float64(1+max-epoch)
+}
+
+// randomSelector scores full node randomly by the configured weight.
+type randomSelector struct {
+	weights map[string]float64
+}
+
+func (s randomSelector) Score(node Node, req *SelectRequest) float64 {
+	weight, ok := s.weights[node.Name()]
+	if !ok {
+		weight = 1
+	}
+
+	return rand.Float64() * weight
+}
+
+// weightedSelector node selector with weight.
+type weightedSelector struct {
+	NodeSelector
+	weight float64
+}
+
+// compositeSelector composes node selectors, in which the scores are weighted summed.
+type compositeSelector []weightedSelector
+
+func newCompositeSelector(m *Manager, policy *selectionPolicy, weights map[string]float64) compositeSelector {
+	var cs compositeSelector
+
+	for _, sc := range policy.Selectors {
+		var selector NodeSelector
+
+		switch strings.ToLower(sc.Type) {
+		case SelectorHash:
+			selector = hashSelector{}
+		case SelectorLatency:
+			selector = latencySelector{}
+		case SelectorFreshness:
+			selector = freshnessSelector{m}
+		case SelectorRandom:
+			selector = randomSelector{weights}
+		default:
+			logrus.WithField("type", sc.Type).Warn("Unknown node selector type ignored")
+			continue
+		}
+
+		weight := sc.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		cs = append(cs, weightedSelector{selector, weight})
+	}
+
+	return cs
+}
+
+func (cs compositeSelector) Score(node Node, req *SelectRequest) float64 {
+	var score float64
+	for _, s := range cs {
+		score += s.weight * s.Score(node, req)
+	}
+
+	return score
+}
+
+// Select selects the full node with the highest score among healthy ones by the selection policy
+// of method group, or distributes by the hash ring if no policy configured for the method. For
+// "latest"-sensitive requests, the full nodes lagging behind are excluded before scoring if configured.
+func (m *Manager) Select(req *SelectRequest) Node {
+	policy, ok := cfg.Selection.policy(req.Method)
+	if !ok {
+		if req.Latest {
+			return m.distributeLatest(req.Key)
+		}
+
+		return m.Distribute(req.Key)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	selector := newCompositeSelector(m, policy, cfg.Selection.Weights)
+
+	var selected Node
+	var maxScore float64
+
+	for _, node := range m.candidatesLocked(req.Latest) {
+		if score := selector.Score(node, req); selected == nil || score > maxScore {
+			selected, maxScore = node, score
+		}
+	}
+
+	return selected
+}
+
+// candidatesLocked returns the full nodes to select from, which excludes the lagging ones for
+// "latest"-sensitive requests if configured, unless all full nodes lag behind. It requires the
+// lock held.
+func (m *Manager) candidatesLocked(latest bool) []Node {
+	var all, candidates, lagging []Node
+
+	for _, member := range m.hashRing.GetMembers() {
+		node := member.(Node)
+		all = append(all, node)
+
+		if latest && cfg.LagFilter.Enabled && m.laggingLocked(node.Name()) {
+			lagging = append(lagging, node)
+		} else {
+			candidates = append(candidates, node)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return all
+	}
+
+	for _, node := range lagging {
+		metrics.Registry.Nodes.LagExcluded(m.group.Space(), m.group.String(), node.Name()).Mark(1)
+	}
+
+	return candidates
+}
+
+// RouteMethod implements the MethodRouter interface.
+func (m *Manager) RouteMethod(key []byte, method string, params json.RawMessage, latest bool) string {
+	req := SelectRequest{Key: key, Method: method, Params: params, Latest: latest}
+	return m.route(key, m.Select(&req))
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerRouteMethod(t *testing.T) {
+	defer func(conf selectionConfig) { cfg.Selection = conf }(cfg.Selection)
+	cfg.Selection = selectionConfig{
+		Policies: []selectionPolicy{
+			{Methods: []string{"eth_call"}, Selectors: []selectorConfig{{Type: SelectorFreshness}}},
+			{Methods: []string{"eth_get*"}, Selectors: []selectorConfig{{Type: SelectorHash}}},
+		},
+	}
+
+	m := NewManager(GroupEthHttp)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"), newMockNode("node3"))
+	m.ReportEpoch("node1", 90)
+	m.ReportEpoch("node2", 100)
+	m.ReportEpoch("node3", 95)
+
+	// freshest node selected
+	assert.Equal(t, "http://node2", m.RouteMethod([]byte("key1"), "eth_call", nil, false))
+	assert.Equal(t, "http://node2", m.RouteMethod([]byte("key2"), "eth_call", nil, false))
+
+	// consistent by route key
+	routed := m.RouteMethod([]byte("key1"), "eth_getBalance", nil, false)
+	assert.NotEmpty(t, routed)
+	assert.Equal(t, routed, m.RouteMethod([]byte("key1"), "eth_getCode", nil, false))
+
+	// routed by hash ring if no policy matched
+	assert.Equal(t, m.Route([]byte("key1")), m.RouteMethod([]byte("key1"), "eth_blockNumber", nil, false))
+}
+
+func TestManagerRouteMethodLatest(t *testing.T) {
+	defer func(conf selectionConfig, lag lagFilterConfig) {
+		cfg.Selection, cfg.LagFilter = conf, lag
+	}(cfg.Selection, cfg.LagFilter)
+
+	cfg.LagFilter = lagFilterConfig{Enabled: true, MaxLag: 5}
+	cfg.Selection = selectionConfig{
+		Policies: []selectionPolicy{
+			{Methods: []string{"eth_call"}, Selectors: []selectorConfig{{Type: SelectorHash}}},
+		},
+	}
+
+	m := NewManager(GroupEthHttp)
+	defer m.Close()
+
+	m.Add(newMockNode("node1"), newMockNode("node2"), newMockNode("node3"))
+
+	key := []byte("key")
+	routed := m.RouteMethod(key, "eth_call", nil, false)
+
+	for _, n := range m.List() {
+		epoch := uint64(100)
+		if n.Url() == routed { // lags behind
+			epoch = 90
+		}
+
+		m.ReportEpoch(n.Name(), epoch)
+	}
+
+	// lagging node excluded before selection for "latest"-sensitive requests
+	latest := m.RouteMethod(key, "eth_call", nil, true)
+	assert.NotEmpty(t, latest)
+	assert.NotEqual(t, routed, latest)
+
+	// still allowed for historical queries
+	assert.Equal(t, routed, m.RouteMethod(key, "eth_call", nil, false))
+
+	// methods without policy fall back to lag-aware hash ring routing
+	assert.Equal(t, m.RouteLatest(key), m.RouteMethod(key, "eth_getBalance", nil, true))
+}
+
+func TestLatencySelectorCached(t *testing.T) {
+	status := NewStatus(GroupEthHttp, "node1")
+	defer status.Close()
+
+	assert.Zero(t, status.MeanLatency())
+
+	start := time.Now().Add(-10 * time.Millisecond)
+	status.metric.update(start, nil)
+
+	latency := status.MeanLatency()
+	assert.GreaterOrEqual(t, latency, 10*time.Millisecond)
+
+	// cached until next heartbeat
+	assert.Equal(t, latency, status.MeanLatency())
+}
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"encoding/json"
 	"sync"
 
 	"github.com/Conflux-Chain/confura/store/mysql"
@@ -12,6 +13,8 @@ import (
 
 var (
 	errDbNotAvailableForPersistence = errors.New("db not available for persistence")
+	errRouteGroupNotFound           = errors.New("route group not found")
+	errRouteStatsDisabled           = errors.New("route stats disabled")
 )
 
 // MustNewServer creates node management RPC server
@@ -41,8 +44,15 @@ func MustNewServer(db *mysql.MysqlStore, nf nodeFactory, grpConf map[Group]UrlCo
 		}
 	}
 
+	h := &apiHandler{dbs: db, pool: npool}
+
+	// track the full nodes resolved from DNS or service registry
+	if d := mustNewDiscovery(cfg.Discovery, h, grpConf); d != nil {
+		d.start()
+	}
+
 	return rpc.MustNewServer("node", map[string]interface{}{
-		"node": &api{h: &apiHandler{dbs: db, pool: npool}},
+		"node": &api{h: h},
 	})
 }
 
@@ -74,6 +84,11 @@ func (api *api) List(group Group) []string {
 	return api.h.pool.get(group)
 }
 
+// ListHealthy returns the URL list of all healthy nodes.
+func (api *api) ListHealthy(group Group) []string {
+	return api.h.pool.healthy(group)
+}
+
 func (api *api) Status(group Group, urls ...string) (res []Status) {
 	return api.h.pool.status(group, urls...)
 }
@@ -99,6 +114,59 @@ func (api *api) Route(group Group, key hexutil.Bytes) string {
 	return ""
 }
 
+// RouteLatest implements the LatestRouter interface. It routes the specified key to any node not
+// lagging behind the cluster for "latest"-sensitive requests, and return the node URL.
+func (api *api) RouteLatest(group Group, key hexutil.Bytes) string {
+	if m, ok := api.h.pool.manager(group); ok {
+		return m.RouteLatest(key)
+	}
+
+	return ""
+}
+
+// RouteMethod implements the MethodRouter interface. It routes the specified key to the node selected
+// by the selection policy of method group if configured, and return the node URL. The full nodes
+// lagging behind are excluded before selection if the optional `latest` is true.
+func (api *api) RouteMethod(
+	group Group, key hexutil.Bytes, method string, params hexutil.Bytes, latest *bool,
+) string {
+	if m, ok := api.h.pool.manager(group); ok {
+		return m.RouteMethod(key, method, json.RawMessage(params), latest != nil && *latest)
+	}
+
+	return ""
+}
+
+// RouteStats returns the distribution of routed keys across the nodes of the specified group,
+// along with the top `k` (10 by default) hottest keys and hash ring partitions.
+func (api *api) RouteStats(group Group, k *int) (*RouteStats, error) {
+	m, ok := api.h.pool.manager(group)
+	if !ok {
+		return nil, errRouteGroupNotFound
+	}
+
+	topk := 10
+	if k != nil && *k > 0 {
+		topk = *k
+	}
+
+	stats, ok := m.RouteStats(topk)
+	if !ok {
+		return nil, errRouteStatsDisabled
+	}
+
+	return stats, nil
+}
+
+// Rebalance applies the new hash ring parameters to all groups live, where the unspecified ones keep
+// unchanged, and returns the ratio of sampled keys moved to other nodes by group.
+func (api *api) Rebalance(conf HashRingConfig) (map[Group]float64, error) {
+	api.h.mu.Lock()
+	defer api.h.mu.Unlock()
+
+	return api.h.pool.rebalance(conf.merge(cfg.HashRing))
+}
+
 // apiHandler rpc handler for node api
 type apiHandler struct {
 	mu sync.Mutex
@@ -0,0 +1,300 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	defaultVerifiedMethods = []string{"eth_call", "eth_getBalance"}
+
+	// ErrUpstreamDiverged is returned if results of verified request diverge among full nodes
+	// without quorum reached.
+	ErrUpstreamDiverged = rpc.NewCodedError(
+		rpc.ErrCodeUpstreamDiverged, errors.New("upstream results diverged without quorum"),
+	)
+)
+
+// verifyConfig N-of-M verification configurations for evm space RPC proxy
+type verifyConfig struct {
+	// whether to verify requests against multiple full nodes
+	Enabled bool
+	// number of full nodes (M) to query, including the routed one
+	Nodes int `default:"3"`
+	// min number of full nodes (N) agreed on the same result to serve
+	Quorum int `default:"2"`
+	// read-only methods to verify, which defaults to `eth_call` and `eth_getBalance`
+	Methods []string
+}
+
+func (c *verifyConfig) verified(method string) bool {
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultVerifiedMethods
+	}
+
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithVerify returns a copy of the client which queries the verified RPC method on multiple full
+// nodes of the same group, and serves the majority answer agreed by quorum while logging divergences.
+// Note, the client is returned as it is along with false if verification disabled or the method not
+// verified.
+func (p *EthClientProvider) WithVerify(client *Web3goClient, group Group, method string) (*Web3goClient, bool) {
+	if !cfg.Verify.Enabled || !cfg.Verify.verified(method) {
+		return client, false
+	}
+
+	vp := &verifiedProvider{
+		conf:    &cfg.Verify,
+		primary: verifiedNode{client.NodeName(), client.Provider()},
+		alternative: func(excludedNodes ...string) (verifiedNode, error) {
+			alt, err := p.getAlternativeClient(group, excludedNodes...)
+			if err != nil {
+				return verifiedNode{}, err
+			}
+
+			w3c := alt.(*Web3goClient)
+			return verifiedNode{w3c.NodeName(), w3c.Provider()}, nil
+		},
+	}
+
+	return &Web3goClient{
+		Client: web3go.NewClientWithProvider(vp),
+		URL:    client.URL,
+	}, true
+}
+
+// verifiedNode full node to query for verified request.
+type verifiedNode struct {
+	name     string
+	provider interfaces.Provider
+}
+
+// verifiedResponse raw response of verified request from some full node.
+type verifiedResponse struct {
+	node   string
+	result json.RawMessage
+	err    error
+}
+
+// verifiedProvider RPC provider that queries the configured read-only methods on multiple full nodes,
+// and serves the majority answer agreed by quorum. Other calls are delegated to the primary one.
+type verifiedProvider struct {
+	conf        *verifyConfig
+	primary     verifiedNode
+	alternative func(excludedNodes ...string) (verifiedNode, error) // lazily routed once verified
+}
+
+// nodes returns the primary full node along with the alternative ones to query, which could be less
+// than configured if not enough full nodes available.
+func (p *verifiedProvider) nodes() []verifiedNode {
+	nodes := []verifiedNode{p.primary}
+	excluded := []string{p.primary.name}
+
+	for len(nodes) < p.conf.Nodes {
+		alt, err := p.alternative(excluded...)
+		if err != nil {
+			break
+		}
+
+		nodes = append(nodes, alt)
+		excluded = append(excluded, alt.name)
+	}
+
+	return nodes
+}
+
+func (p *verifiedProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	if !p.conf.verified(method) {
+		return p.primary.provider.CallContext(ctx, result, method, args...)
+	}
+
+	args, err := p.pinBlock(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	nodes := p.nodes()
+
+	// serve with less full nodes agreed if not enough full nodes available
+	quorum := p.conf.Quorum
+	if quorum > len(nodes) {
+		quorum = len(nodes)
+	}
+	metrics.Registry.RPC.FullnodeVerifyQuorumCapped(method).Mark(quorum < p.conf.Quorum)
+
+	respCh := make(chan verifiedResponse, len(nodes))
+	for _, n := range nodes {
+		go func(n verifiedNode) {
+			var raw json.RawMessage
+			err := n.provider.CallContext(ctx, &raw, method, args...)
+			respCh <- verifiedResponse{n.name, raw, err}
+		}(n)
+	}
+
+	resps := make([]verifiedResponse, 0, len(nodes))
+	for range nodes {
+		resps = append(resps, <-respCh)
+	}
+
+	resp, err := p.majority(method, quorum, resps)
+	if err != nil || len(resp) == 0 {
+		return err
+	}
+
+	return json.Unmarshal(resp, result)
+}
+
+// pinBlock resolves the `latest` or `pending` block param of verified request to the latest block
+// number of primary full node, so that full nodes at different heights answer at the same block.
+func (p *verifiedProvider) pinBlock(ctx context.Context, args []interface{}) ([]interface{}, error) {
+	for i, arg := range args {
+		var bnh *types.BlockNumberOrHash
+		switch v := arg.(type) {
+		case *types.BlockNumberOrHash:
+			bnh = v
+		case types.BlockNumberOrHash:
+			bnh = &v
+		default:
+			continue
+		}
+
+		if bnh != nil {
+			bn, ok := bnh.Number()
+			if !ok || (bn != types.LatestBlockNumber && bn != types.PendingBlockNumber) {
+				return args, nil
+			}
+		}
+
+		var latest hexutil.Uint64
+		if err := p.primary.provider.CallContext(ctx, &latest, "eth_blockNumber"); err != nil {
+			return nil, errors.WithMessage(err, "failed to get latest block number to verify")
+		}
+
+		pinned := types.BlockNumberOrHashWithNumber(types.BlockNumber(latest))
+
+		result := make([]interface{}, len(args))
+		copy(result, args)
+		result[i] = &pinned
+
+		return result, nil
+	}
+
+	return args, nil
+}
+
+// majority returns the result agreed by quorum of full nodes, and logs the diverged ones if any.
+func (p *verifiedProvider) majority(
+	method string, quorum int, resps []verifiedResponse,
+) (json.RawMessage, error) {
+	var firstErr error
+
+	keys := make([]string, len(resps)) // canonical result per full node
+	counts := make(map[string]int)     // canonical result => number of full nodes agreed
+	var majorityKey string
+	var majority json.RawMessage
+
+	for i, resp := range resps {
+		var key string
+		if resp.err == nil {
+			if key, resp.err = canonicalResult(resp.result); resp.err != nil {
+				resps[i].err = errors.WithMessage(resp.err, "invalid JSON result")
+			}
+		}
+
+		if resps[i].err != nil {
+			if firstErr == nil {
+				firstErr = resps[i].err
+			}
+
+			continue
+		}
+
+		keys[i] = key
+		if counts[key]++; counts[key] > counts[majorityKey] {
+			majorityKey, majority = key, resp.result
+		}
+	}
+
+	diverged := len(counts) > 1 || (len(counts) > 0 && firstErr != nil)
+	metrics.Registry.RPC.FullnodeVerifyDiverged(method).Mark(diverged)
+
+	if diverged {
+		fields := logrus.Fields{"method": method, "majority": string(majority)}
+		for i, resp := range resps {
+			switch {
+			case resp.err != nil:
+				fields[resp.node] = resp.err.Error()
+			case keys[i] != majorityKey:
+				fields[resp.node] = string(resp.result)
+				metrics.Registry.RPC.FullnodeVerifyMismatch(resp.node).Mark(1)
+			}
+		}
+
+		logrus.WithFields(fields).Info("Verified request diverged among full nodes")
+	}
+
+	if len(counts) == 0 { // all failed
+		return nil, firstErr
+	}
+
+	if counts[majorityKey] < quorum {
+		return nil, ErrUpstreamDiverged
+	}
+
+	return majority, nil
+}
+
+// canonicalResult returns the canonical form of JSON result regardless of field order and whitespaces.
+func canonicalResult(result json.RawMessage) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(result, &v); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (p *verifiedProvider) BatchCallContext(ctx context.Context, b []w3rpc.BatchElem) error {
+	return p.primary.provider.BatchCallContext(ctx, b)
+}
+
+func (p *verifiedProvider) Subscribe(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) (*w3rpc.ClientSubscription, error) {
+	return p.primary.provider.Subscribe(ctx, namespace, channel, args...)
+}
+
+func (p *verifiedProvider) SubscribeWithReconn(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) *w3rpc.ReconnClientSubscription {
+	return p.primary.provider.SubscribeWithReconn(ctx, namespace, channel, args...)
+}
+
+// Close never closes the shared underlying providers.
+func (p *verifiedProvider) Close() {}
@@ -0,0 +1,119 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestVerifiedProvider(results ...string) *verifiedProvider {
+	nodes := make([]verifiedNode, 0, len(results))
+	for i, r := range results {
+		nodes = append(nodes, verifiedNode{
+			name:     fmt.Sprintf("node%v", i),
+			provider: &delayedProvider{result: r},
+		})
+	}
+
+	return &verifiedProvider{
+		conf:    &verifyConfig{Nodes: len(nodes), Quorum: 2},
+		primary: nodes[0],
+		alternative: func(excludedNodes ...string) (verifiedNode, error) {
+			if len(excludedNodes) >= len(nodes) {
+				return verifiedNode{}, ErrNoAlternativeClient
+			}
+
+			return nodes[len(excludedNodes)], nil
+		},
+	}
+}
+
+func TestVerifiedProvider(t *testing.T) {
+	var result string
+
+	// majority answer served
+	err := newTestVerifiedProvider(`"0x1"`, `"0x2"`, `"0x2"`).CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", result)
+
+	// diverged without quorum
+	err = newTestVerifiedProvider(`"0x1"`, `"0x2"`, `"0x3"`).CallContext(context.Background(), &result, "eth_call")
+	assert.Equal(t, ErrUpstreamDiverged, err)
+
+	// not verified
+	err = newTestVerifiedProvider(`"0x1"`, `"0x2"`, `"0x2"`).CallContext(context.Background(), &result, "eth_chainId")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", result)
+}
+
+// blockParamProvider records the block param of verified request, and answers the same result.
+type blockParamProvider struct {
+	unavailableProvider
+
+	mu     sync.Mutex
+	blocks []string
+	latest string
+	result string
+}
+
+func (p *blockParamProvider) CallContext(
+	ctx context.Context, result interface{}, method string, args ...interface{},
+) error {
+	if method == "eth_blockNumber" {
+		return json.Unmarshal([]byte(p.latest), result)
+	}
+
+	data, _ := json.Marshal(args[len(args)-1])
+
+	p.mu.Lock()
+	p.blocks = append(p.blocks, string(data))
+	p.mu.Unlock()
+
+	return json.Unmarshal([]byte(p.result), result)
+}
+
+func TestVerifiedProviderPinBlock(t *testing.T) {
+	primary := &blockParamProvider{latest: `"0x64"`, result: `"0x1"`}
+	alternative := &blockParamProvider{latest: `"0x65"`, result: `"0x1"`}
+
+	vp := &verifiedProvider{
+		conf:    &verifyConfig{Nodes: 2, Quorum: 2},
+		primary: verifiedNode{"node0", primary},
+		alternative: func(excludedNodes ...string) (verifiedNode, error) {
+			return verifiedNode{"node1", alternative}, nil
+		},
+	}
+
+	latest := types.BlockNumberOrHashWithNumber(types.LatestBlockNumber)
+	pending := types.BlockNumberOrHashWithNumber(types.PendingBlockNumber)
+	number := types.BlockNumberOrHashWithNumber(10)
+
+	var result string
+	for _, bnh := range []*types.BlockNumberOrHash{nil, &latest, &pending, &number} {
+		assert.NoError(t, vp.CallContext(context.Background(), &result, "eth_getBalance", "0x0", bnh))
+	}
+
+	// resolved to the latest block of primary full node
+	expected := []string{`"0x64"`, `"0x64"`, `"0x64"`, `"0xa"`}
+	assert.Equal(t, expected, primary.blocks)
+	assert.Equal(t, expected, alternative.blocks)
+}
+
+func TestVerifiedProviderQuorumCapped(t *testing.T) {
+	vp := newTestVerifiedProvider(`"0x1"`, `"0x1"`)
+	vp.conf = &verifyConfig{Nodes: 3, Quorum: 3}
+
+	// only 2 full nodes available
+	var result string
+	err := vp.CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", result)
+
+	err = newTestVerifiedProvider(`"0x1"`).CallContext(context.Background(), &result, "eth_call")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/pkg/errors"
+)
+
+var errUpstreamUsageNotEnabled = errors.New("upstream usage accounting not enabled")
+
+// ApiKeyInfo API key issued to tenant.
+type ApiKeyInfo struct {
+	Key       string     `json:"key"`
+	Tenant    string     `json:"tenant"`
+	Memo      string     `json:"memo,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+func newApiKeyInfo(apiKey *mysql.ApiKey) *ApiKeyInfo {
+	return &ApiKeyInfo{
+		Key:       apiKey.Key,
+		Tenant:    apiKey.Tenant,
+		Memo:      apiKey.Memo,
+		CreatedAt: apiKey.CreatedAt,
+		RevokedAt: apiKey.RevokedAt,
+	}
+}
+
+// UsageReport accounted requests of tenant per method per day.
+type UsageReport struct {
+	Tenant   string `json:"tenant"`
+	Date     string `json:"date"`
+	Method   string `json:"method"`
+	Requests uint64 `json:"requests"`
+	Errors   uint64 `json:"errors"`
+}
+
+// adminAPI provides RPC methods to manage API keys of tenants and report usages, which is only
+// served on the admin endpoint for internal use.
+type adminAPI struct {
+	store *mysql.MysqlStore
+}
+
+// CreateApiKey issues a new API key for the tenant.
+func (api *adminAPI) CreateApiKey(ctx context.Context, tenantName string, memo *string) (*ApiKeyInfo, error) {
+	if len(tenantName) == 0 {
+		return nil, errors.New("tenant required")
+	}
+
+	var memoStr string
+	if memo != nil {
+		memoStr = *memo
+	}
+
+	apiKey, err := api.store.AddApiKey(tenantName, memoStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newApiKeyInfo(apiKey), nil
+}
+
+// RevokeApiKey revokes the API key, and returns false if not found or already revoked.
+func (api *adminAPI) RevokeApiKey(ctx context.Context, key string) (bool, error) {
+	return api.store.RevokeApiKey(key)
+}
+
+// ListApiKeys returns API keys of the tenant, or all tenants if not specified.
+func (api *adminAPI) ListApiKeys(ctx context.Context, tenantName *string) ([]*ApiKeyInfo, error) {
+	var name string
+	if tenantName != nil {
+		name = *tenantName
+	}
+
+	apiKeys, err := api.store.ListApiKeys(name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ApiKeyInfo, 0, len(apiKeys))
+	for _, v := range apiKeys {
+		result = append(result, newApiKeyInfo(v))
+	}
+
+	return result, nil
+}
+
+// UsageReport returns the daily usages per method of the tenant (or all tenants if not specified)
+// within the date range (inclusive, eg., "2006-01-02" in UTC).
+func (api *adminAPI) UsageReport(
+	ctx context.Context, fromDate, toDate string, tenantName *string,
+) ([]*UsageReport, error) {
+	for _, date := range []string{fromDate, toDate} {
+		if _, err := time.Parse(tenant.DateLayout, date); err != nil {
+			return nil, errors.Errorf("invalid date %v, expected format %v", date, tenant.DateLayout)
+		}
+	}
+
+	var name string
+	if tenantName != nil {
+		name = *tenantName
+	}
+
+	usages, err := api.store.LoadUsages(name, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*UsageReport, 0, len(usages))
+	for _, v := range usages {
+		result = append(result, &UsageReport{
+			Tenant:   v.Tenant,
+			Date:     v.Date,
+			Method:   v.Method,
+			Requests: v.Requests,
+			Errors:   v.Errors,
+		})
+	}
+
+	return result, nil
+}
+
+// UpstreamUsage returns the RPC usages (requests, errors and byte volumes) per upstream fullnode since
+// process started or last reset, and resets the accounted usages if `reset` is true.
+func (api *adminAPI) UpstreamUsage(ctx context.Context, reset *bool) ([]rpcutil.UpstreamUsage, error) {
+	if !rpcutil.UpstreamUsageEnabled() {
+		return nil, errUpstreamUsageNotEnabled
+	}
+
+	return rpcutil.UpstreamUsages(reset != nil && *reset), nil
+}
@@ -4,8 +4,10 @@ import (
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/cfxbridge"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util/metrics/service"
 	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/txpool"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/openweb3/web3go"
 	"github.com/pkg/errors"
@@ -58,6 +60,13 @@ func filterExposedApis(allApis []API, exposedModules []string) (map[string]inter
 func nativeSpaceApis(
 	clientProvider *node.CfxClientProvider, gashandler *handler.GasStationHandler, option ...CfxAPIOption,
 ) []API {
+	var traceHandler *handler.TraceHandler
+	var txTracker *txpool.Tracker
+	if len(option) > 0 {
+		traceHandler = option[0].TraceHandler
+		txTracker = option[0].TxTracker
+	}
+
 	return []API{
 		{
 			Namespace: "cfx",
@@ -77,7 +86,7 @@ func nativeSpaceApis(
 		}, {
 			Namespace: "trace",
 			Version:   "1.0",
-			Service:   &traceAPI{},
+			Service:   &traceAPI{handler: traceHandler},
 			Public:    false,
 		}, {
 			Namespace: service.Namespace,
@@ -94,38 +103,58 @@ func nativeSpaceApis(
 			Version:   "1.0",
 			Service:   &cfxDebugAPI{},
 			Public:    false,
+		}, {
+			Namespace: "txtracker",
+			Version:   "1.0",
+			Service:   &cfxTxTrackerAPI{tracker: txTracker},
+			Public:    false,
 		},
 	}
 }
 
 // evmSpaceApis returns the collection of built-in RPC APIs for EVM space.
-func evmSpaceApis(clientProvider *node.EthClientProvider, option ...EthAPIOption) ([]API, error) {
+func evmSpaceApis(ethAPI *ethAPI) ([]API, error) {
 	return []API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   mustNewEthAPI(clientProvider, option...),
+			Service:   ethAPI,
 			Public:    true,
 		}, {
 			Namespace: "web3",
 			Version:   "1.0",
-			Service:   &web3API{},
+			Service:   &web3API{local: ethAPI.LocalAnswerer},
 			Public:    true,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
-			Service:   &netAPI{},
+			Service:   &netAPI{local: ethAPI.LocalAnswerer},
 			Public:    true,
 		}, {
 			Namespace: "trace",
 			Version:   "1.0",
-			Service:   &ethTraceAPI{},
+			Service:   &ethTraceAPI{handler: ethAPI.TraceHandler},
+			Public:    false,
+		}, {
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   &ethDebugAPI{handler: ethAPI.TraceHandler},
+			Public:    false,
+		}, {
+			Namespace: "gasstation",
+			Version:   "1.0",
+			Service:   newGasStationAPI(ethAPI.GasStationHandler),
 			Public:    false,
 		}, {
 			Namespace: "parity",
 			Version:   "1.0",
 			Service:   &parityAPI{},
 			Public:    false,
+		}, {
+			Namespace: "txtracker",
+			Version:   "1.0",
+			Service:   &ethTxTrackerAPI{tracker: ethAPI.TxTracker},
+			Public:    false,
 		},
 	}, nil
 }
@@ -170,7 +199,19 @@ func nativeSpaceBridgeApis(ethNodeURL, cfxNodeURL string) ([]API, error) {
 	}, nil
 }
 
-// debugApis returns the collection of non-standard RPC methods for run time diagnostics and debug.
+// adminApis returns the collection of RPC methods to manage API keys of tenants and report usages,
+// which are only served on the admin endpoint.
+func adminApis(store *mysql.MysqlStore) []API {
+	return []API{
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   &adminAPI{store: store},
+			Public:    false,
+		},
+	}
+}
+
 func debugApis() []API {
 	return []API{
 		{
@@ -8,6 +8,8 @@ import (
 	"github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
 )
 
 var EthDefault = NewEth()
@@ -19,6 +21,8 @@ type EthCache struct {
 	chainIdCache       *expiryCache
 	priceCache         *expiryCache
 	blockNumberCache   *nodeExpiryCaches
+	finalizedCache     *nodeExpiryCaches
+	safeCache          *nodeExpiryCaches
 }
 
 func NewEth() *EthCache {
@@ -28,6 +32,8 @@ func NewEth() *EthCache {
 		chainIdCache:       newExpiryCache(time.Hour * 24 * 365 * 100),
 		priceCache:         newExpiryCache(3 * time.Second),
 		blockNumberCache:   newNodeExpiryCaches(time.Second),
+		finalizedCache:     newNodeExpiryCaches(time.Second),
+		safeCache:          newNodeExpiryCaches(time.Second),
 	}
 }
 
@@ -92,3 +98,39 @@ func (cache *EthCache) GetBlockNumber(client *node.Web3goClient) (*hexutil.Big,
 
 	return (*hexutil.Big)(val.(*big.Int)), nil
 }
+
+// GetFinalizedBlockNumber returns the latest finalized block number of the fullnode.
+func (cache *EthCache) GetFinalizedBlockNumber(client *node.Web3goClient) (uint64, error) {
+	return cache.getTaggedBlockNumber(client, cache.finalizedCache, types.FinalizedBlockNumber)
+}
+
+// GetSafeBlockNumber returns the latest safe (confirmed) block number of the fullnode.
+func (cache *EthCache) GetSafeBlockNumber(client *node.Web3goClient) (uint64, error) {
+	return cache.getTaggedBlockNumber(client, cache.safeCache, types.SafeBlockNumber)
+}
+
+func (cache *EthCache) getTaggedBlockNumber(
+	client *node.Web3goClient, tagCache *nodeExpiryCaches, tag types.BlockNumber,
+) (uint64, error) {
+	nodeName := rpc.Url2NodeName(client.URL)
+
+	val, err := tagCache.getOrUpdate(nodeName, func() (interface{}, error) {
+		block, err := client.Eth.BlockByNumber(tag, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == nil || block.Number == nil {
+			name, _ := tag.MarshalText()
+			return nil, errors.Errorf("%s block not found", name)
+		}
+
+		return block.Number.Uint64(), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return val.(uint64), nil
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/openweb3/web3go/types"
+)
+
+// CallCacheConfig `eth_call` result cache configurations.
+type CallCacheConfig struct {
+	// whether to cache `eth_call` results at finalized block heights
+	Enabled bool
+	// duration to keep the cached result
+	TTL time.Duration `default:"10m"`
+	// max number of cached results
+	MaxEntries int `default:"10000"`
+	// results larger than this size in bytes are never cached
+	MaxResultSize int `default:"65536"`
+}
+
+type callEntry struct {
+	result   hexutil.Bytes
+	cachedAt time.Time
+}
+
+// CallCache memoizes `eth_call` results at finalized block heights keyed by call parameters, which
+// benefits dapps that repeat identical view calls. Note, results at `latest` or `pending` block are
+// never cached since they could change at any time.
+type CallCache struct {
+	conf CallCacheConfig
+
+	mu      sync.Mutex
+	entries *simplelru.LRU // key => *callEntry
+}
+
+// MustNewCallCacheFromViper creates `eth_call` result cache from viper settings of the specified
+// key, or returns false if not enabled.
+func MustNewCallCacheFromViper(key string) (*CallCache, bool) {
+	var conf CallCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	return NewCallCache(conf), true
+}
+
+func NewCallCache(conf CallCacheConfig) *CallCache {
+	entries, _ := simplelru.NewLRU(conf.MaxEntries, nil)
+	return &CallCache{conf: conf, entries: entries}
+}
+
+// CallCacheKey returns the cache key of `eth_call` at the specified block number, which is composed
+// of block number and digest of all the call parameters (eg., `to` and `data`).
+func CallCacheKey(blockNum uint64, request types.CallRequest) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%v:%x", blockNum, sha256.Sum256(data)), nil
+}
+
+// Get returns the cached result if not expired.
+func (c *CallCache) Get(key string) (hexutil.Bytes, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry := val.(*callEntry)
+	if time.Since(entry.cachedAt) > c.conf.TTL {
+		c.entries.Remove(key)
+		return nil, false
+	}
+
+	return entry.result, true
+}
+
+// Set caches the result unless oversized.
+func (c *CallCache) Set(key string, result hexutil.Bytes) {
+	if len(result) > c.conf.MaxResultSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Add(key, &callEntry{result: result, cachedAt: time.Now()})
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallCache(t *testing.T) {
+	c := NewCallCache(CallCacheConfig{TTL: 20 * time.Millisecond, MaxEntries: 10, MaxResultSize: 4})
+
+	to := common.HexToAddress("0x1")
+	req := types.CallRequest{To: &to, Data: []byte{0x1, 0x2}}
+
+	key, err := CallCacheKey(100, req)
+	assert.NoError(t, err)
+
+	// different block or call parameters
+	key2, _ := CallCacheKey(101, req)
+	assert.NotEqual(t, key, key2)
+
+	req.Data = []byte{0x1, 0x3}
+	key3, _ := CallCacheKey(100, req)
+	assert.NotEqual(t, key, key3)
+
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+
+	c.Set(key, hexutil.Bytes{0x1})
+	c.Set(key2, hexutil.Bytes{0x1, 0x2, 0x3, 0x4, 0x5}) // oversized
+
+	result, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, hexutil.Bytes{0x1}, result)
+
+	_, ok = c.Get(key2)
+	assert.False(t, ok)
+
+	// expired
+	time.Sleep(30 * time.Millisecond)
+
+	_, ok = c.Get(key)
+	assert.False(t, ok)
+}
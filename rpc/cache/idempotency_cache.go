@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/pkg/errors"
+)
+
+// ErrIdempotencyKeyReused returned if the idempotency key is reused with a different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key already used with a different request")
+
+// errIdempotentAttemptAborted returned to waiting retries if the first attempt panicked.
+var errIdempotentAttemptAborted = errors.New("first attempt of the idempotency key aborted")
+
+// IdempotencyCacheConfig idempotency key configurations for write RPCs (eg., `eth_sendRawTransaction`).
+type IdempotencyCacheConfig struct {
+	// whether to honor the idempotency key header of write RPCs
+	Enabled bool
+	// duration to keep the result of the first attempt
+	TTL time.Duration `default:"10m"`
+	// max number of cached idempotency keys
+	MaxKeys int `default:"100000"`
+}
+
+type idempotentEntry struct {
+	digest   [32]byte      // digest of request to detect key reuse
+	done     chan struct{} // closed once the first attempt completed
+	result   interface{}
+	err      error
+	cachedAt time.Time
+}
+
+// IdempotencyCache caches the result of the first attempt of write RPCs by client specified idempotency
+// key, so that client retries (eg., due to timeout) are responded with the same result rather than
+// broadcasted again. Note, concurrent retries wait for the first attempt in flight.
+type IdempotencyCache struct {
+	conf IdempotencyCacheConfig
+
+	mu      sync.Mutex
+	entries *simplelru.LRU // key => *idempotentEntry
+}
+
+// MustNewIdempotencyCacheFromViper creates idempotency cache from viper settings of the specified
+// key, or returns false if not enabled.
+func MustNewIdempotencyCacheFromViper(key string) (*IdempotencyCache, bool) {
+	var conf IdempotencyCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	return NewIdempotencyCache(conf), true
+}
+
+func NewIdempotencyCache(conf IdempotencyCacheConfig) *IdempotencyCache {
+	entries, _ := simplelru.NewLRU(conf.MaxKeys, nil)
+	return &IdempotencyCache{conf: conf, entries: entries}
+}
+
+// Do executes the write RPC for the first attempt of the idempotency key, and returns the result of
+// the first attempt for retries with the same request. Note, transient errors (eg., io error or
+// timeout) are not cached so that retries could be executed again, and retries waiting for the first
+// attempt in flight return once the context is done.
+func (c *IdempotencyCache) Do(
+	ctx context.Context, key string, request []byte, fn func() (interface{}, error),
+) (result interface{}, cached bool, err error) {
+	digest := sha256.Sum256(request)
+
+	c.mu.Lock()
+
+	if val, ok := c.entries.Get(key); ok {
+		entry := val.(*idempotentEntry)
+		if !entry.cachedAt.IsZero() && time.Since(entry.cachedAt) > c.conf.TTL {
+			c.entries.Remove(key)
+		} else {
+			c.mu.Unlock()
+
+			if entry.digest != digest {
+				return nil, false, ErrIdempotencyKeyReused
+			}
+
+			select {
+			case <-entry.done:
+				return entry.result, true, entry.err
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+	}
+
+	entry := &idempotentEntry{digest: digest, done: make(chan struct{})}
+	c.entries.Add(key, entry)
+
+	c.mu.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			// panicked, waiting retries get the error and later retries could be executed again
+			entry.err = errIdempotentAttemptAborted
+			c.remove(key, entry)
+		}
+
+		close(entry.done)
+	}()
+
+	entry.result, entry.err = fn()
+	completed = true
+
+	if entry.err != nil && !utils.IsRPCJSONError(entry.err) {
+		// transient error, waiting retries will still get the error of this attempt
+		c.remove(key, entry)
+	} else {
+		c.mu.Lock()
+		entry.cachedAt = time.Now()
+		c.mu.Unlock()
+	}
+
+	return entry.result, false, entry.err
+}
+
+// remove removes the entry of idempotency key unless replaced by another attempt.
+func (c *IdempotencyCache) remove(key string, entry *idempotentEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if val, ok := c.entries.Peek(key); ok && val == entry {
+		c.entries.Remove(key)
+	}
+}
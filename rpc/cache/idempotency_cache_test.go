@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	c := NewIdempotencyCache(IdempotencyCacheConfig{TTL: time.Minute, MaxKeys: 10})
+
+	var calls int
+	send := func(result interface{}, err error) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			calls++
+			return result, err
+		}
+	}
+
+	result, cached, err := c.Do(context.Background(), "k1", []byte("tx1"), send("0x1", nil))
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "0x1", result)
+
+	// retry responded with the result of first attempt
+	result, cached, err = c.Do(context.Background(), "k1", []byte("tx1"), send("0x2", nil))
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "0x1", result)
+	assert.Equal(t, 1, calls)
+
+	// key reused with a different request
+	_, _, err = c.Do(context.Background(), "k1", []byte("tx2"), send("0x2", nil))
+	assert.Equal(t, ErrIdempotencyKeyReused, err)
+
+	// RPC error cached
+	rpcErr := &rpc.JsonError{Code: -32000, Message: "nonce too low"}
+	c.Do(context.Background(), "k2", []byte("tx2"), send(nil, rpcErr))
+	_, cached, err = c.Do(context.Background(), "k2", []byte("tx2"), send("0x2", nil))
+	assert.True(t, cached)
+	assert.Equal(t, rpcErr, err)
+
+	// transient error not cached
+	c.Do(context.Background(), "k3", []byte("tx3"), send(nil, errors.New("i/o timeout")))
+	result, cached, err = c.Do(context.Background(), "k3", []byte("tx3"), send("0x3", nil))
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "0x3", result)
+}
+
+func TestIdempotencyCacheConcurrentRetries(t *testing.T) {
+	c := NewIdempotencyCache(IdempotencyCacheConfig{TTL: time.Minute, MaxKeys: 10})
+
+	var mu sync.Mutex
+	var calls int
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+
+			close(started)
+			<-release
+			return "0x1", nil
+		})
+	}()
+
+	<-started
+
+	// retry waits for the first attempt in flight
+	done := make(chan interface{})
+	go func() {
+		result, _, _ := c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) { return "0x2", nil })
+		done <- result
+	}()
+
+	close(release)
+	assert.Equal(t, "0x1", <-done)
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyCacheRetryContextDone(t *testing.T) {
+	c := NewIdempotencyCache(IdempotencyCacheConfig{TTL: time.Minute, MaxKeys: 10})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	go c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) {
+		close(started)
+		<-release
+		return "0x1", nil
+	})
+
+	<-started
+
+	// retry gives up waiting for the first attempt in flight once context done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, cached, err := c.Do(ctx, "k", []byte("tx"), func() (interface{}, error) { return "0x2", nil })
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, cached)
+}
+
+func TestIdempotencyCachePanic(t *testing.T) {
+	c := NewIdempotencyCache(IdempotencyCacheConfig{TTL: time.Minute, MaxKeys: 10})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+
+		c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+
+	<-started
+
+	// waiting retry released with error once the first attempt panicked
+	done := make(chan error)
+	go func() {
+		_, _, err := c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) { return "0x2", nil })
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, errIdempotentAttemptAborted, <-done)
+
+	// later retry executed again
+	result, cached, err := c.Do(context.Background(), "k", []byte("tx"), func() (interface{}, error) { return "0x3", nil })
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "0x3", result)
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/sirupsen/logrus"
+)
+
+// StaleCacheConfig stale-while-revalidate cache configurations.
+//
+// Note, method names are case insensitive since viper lower cases map keys.
+type StaleCacheConfig struct {
+	// whether to enable stale-while-revalidate cache
+	Enabled bool
+	// duration within which the cached result is regarded as fresh without refreshing
+	MaxAge time.Duration `default:"100ms"`
+	// method => staleness budget, within which the cached result is served while refreshing in
+	// background, and beyond which the result is fetched synchronously
+	Budgets map[string]time.Duration
+	// max number of cached results
+	MaxEntries int `default:"1000"`
+}
+
+// staleEntry cached result along with the time fetched.
+type staleEntry struct {
+	value      json.RawMessage
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// StaleCache caches RPC results in stale-while-revalidate manner, which serves the cached result
+// up to the staleness budget old while refreshing in background, so as to reduce the upstream load
+// of polling-heavy clients (eg., `eth_getBlockByNumber("latest")` every a few hundred milliseconds).
+type StaleCache struct {
+	conf StaleCacheConfig
+
+	mu      sync.Mutex
+	entries *simplelru.LRU // key => *staleEntry
+}
+
+// MustNewStaleCacheFromViper creates stale-while-revalidate cache from viper settings of the
+// specified key, or returns false if not enabled.
+func MustNewStaleCacheFromViper(key string) (*StaleCache, bool) {
+	var conf StaleCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled || len(conf.Budgets) == 0 {
+		return nil, false
+	}
+
+	return NewStaleCache(conf), true
+}
+
+func NewStaleCache(conf StaleCacheConfig) *StaleCache {
+	budgets := make(map[string]time.Duration, len(conf.Budgets))
+	for method, budget := range conf.Budgets {
+		budgets[strings.ToLower(method)] = budget
+	}
+
+	conf.Budgets = budgets
+
+	entries, _ := simplelru.NewLRU(conf.MaxEntries, nil)
+
+	return &StaleCache{conf: conf, entries: entries}
+}
+
+// Budget returns the staleness budget of the method, or false if not cached.
+func (c *StaleCache) Budget(method string) (time.Duration, bool) {
+	budget, ok := c.conf.Budgets[strings.ToLower(method)]
+	return budget, ok
+}
+
+// GetOrUpdate returns the cached result of the method with key if within the staleness budget, and
+// triggers a background refresh if stale. Otherwise, it fetches the result synchronously.
+func (c *StaleCache) GetOrUpdate(
+	method, key string, fetch func() (json.RawMessage, error),
+) (json.RawMessage, error) {
+	budget, ok := c.Budget(method)
+	if !ok {
+		return fetch()
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	var entry *staleEntry
+	if val, ok := c.entries.Get(key); ok {
+		entry = val.(*staleEntry)
+	}
+
+	if entry == nil || now.Sub(entry.fetchedAt) > budget { // missed or too stale
+		c.mu.Unlock()
+		metrics.Registry.RPC.CacheStaleHit(method).Mark(false)
+
+		value, err := fetch()
+		if err == nil {
+			c.set(key, value, now)
+		}
+
+		return value, err
+	}
+
+	value := entry.value
+	refresh := !entry.refreshing && now.Sub(entry.fetchedAt) > c.conf.MaxAge
+	if refresh {
+		entry.refreshing = true
+	}
+	c.mu.Unlock()
+
+	metrics.Registry.RPC.CacheStaleHit(method).Mark(true)
+
+	if refresh {
+		go c.refresh(key, entry, fetch)
+	}
+
+	return value, nil
+}
+
+func (c *StaleCache) refresh(key string, entry *staleEntry, fetch func() (json.RawMessage, error)) {
+	start := time.Now()
+
+	value, err := fetch()
+	if err != nil {
+		logrus.WithField("key", key).WithError(err).Debug("Failed to refresh stale cache entry")
+
+		c.mu.Lock()
+		entry.refreshing = false
+		c.mu.Unlock()
+
+		return
+	}
+
+	c.set(key, value, start)
+}
+
+func (c *StaleCache) set(key string, value json.RawMessage, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// do not overwrite the newer one fetched concurrently
+	if val, ok := c.entries.Peek(key); ok && val.(*staleEntry).fetchedAt.After(fetchedAt) {
+		return
+	}
+
+	c.entries.Add(key, &staleEntry{value: value, fetchedAt: fetchedAt})
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleCache(t *testing.T) {
+	cache := NewStaleCache(StaleCacheConfig{
+		MaxAge:     10 * time.Millisecond,
+		Budgets:    map[string]time.Duration{"eth_blockNumber": 100 * time.Millisecond},
+		MaxEntries: 10,
+	})
+
+	var fetches int64
+	fetch := func() (json.RawMessage, error) {
+		n := atomic.AddInt64(&fetches, 1)
+		return json.Marshal(n)
+	}
+
+	// fetched synchronously if missed
+	val, err := cache.GetOrUpdate("eth_blockNumber", "k", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(val))
+
+	// served from cache while fresh
+	val, _ = cache.GetOrUpdate("eth_blockNumber", "k", fetch)
+	assert.Equal(t, "1", string(val))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fetches))
+
+	// served stale while refreshing in background
+	time.Sleep(20 * time.Millisecond)
+	val, _ = cache.GetOrUpdate("eth_blockNumber", "k", fetch)
+	assert.Equal(t, "1", string(val))
+
+	assert.Eventually(t, func() bool {
+		val, _ := cache.GetOrUpdate("eth_blockNumber", "k", fetch)
+		return string(val) == "2"
+	}, time.Second, time.Millisecond)
+
+	// fetched synchronously once staleness budget exceeded
+	time.Sleep(150 * time.Millisecond)
+	before := atomic.LoadInt64(&fetches)
+	val, _ = cache.GetOrUpdate("eth_blockNumber", "k", fetch)
+	assert.Equal(t, fmt.Sprint(before+1), string(val))
+
+	// errors are not cached
+	_, err = cache.GetOrUpdate("eth_blockNumber", "k2", func() (json.RawMessage, error) {
+		return nil, errors.New("upstream unavailable")
+	})
+	assert.Error(t, err)
+
+	// method not configured
+	_, ok := cache.Budget("eth_gasPrice")
+	assert.False(t, ok)
+}
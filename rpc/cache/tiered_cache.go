@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tierHot  = "hot"
+	tierCold = "cold"
+)
+
+// TieredCacheConfig hot/cold tiered cache configurations
+type TieredCacheConfig struct {
+	// whether to enable tiered cache
+	Enabled bool
+	// max total bytes of entries in the in-process (hot) tier
+	HotCapacity int `default:"67108864"`
+	// max number of entries in the in-process (hot) tier
+	HotEntries int `default:"10000"`
+	// entries no larger than this size are put into the hot tier directly
+	SmallEntrySize int `default:"4096"`
+	// entries larger than this size are never promoted into the hot tier
+	MaxHotEntrySize int `default:"262144"`
+	// number of cold tier hits before the entry is promoted into the hot tier
+	PromoteHits int `default:"3"`
+	// redis url of the cold tier, if empty only the hot tier is used
+	RedisUrl string
+	// expiration duration of entries in the cold tier
+	ColdTTL time.Duration `default:"1h"`
+}
+
+// TieredCache two-tier cache with a small in-process LRU (hot) tier and a larger Redis (cold)
+// tier. Small entries go into the hot tier directly, while large entries (eg., huge block bodies)
+// stay in the cold tier and are promoted only if frequently accessed and not oversized, so that
+// they won't evict thousands of small hot entries from the in-process tier. Entries evicted from
+// the hot tier are demoted and still available in the cold tier until expired.
+type TieredCache struct {
+	name string
+	conf TieredCacheConfig
+
+	mu       sync.Mutex
+	hot      *simplelru.LRU // key => encoded value
+	hotBytes int
+	coldHits *simplelru.LRU // key => number of cold tier hits
+
+	cold *goredis.Client
+}
+
+// MustNewTieredCacheFromViper creates tiered cache from viper settings of the specified key.
+func MustNewTieredCacheFromViper(name, key string) (*TieredCache, bool) {
+	var conf TieredCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	var rdb *goredis.Client
+	if len(conf.RedisUrl) > 0 {
+		rdb = redis.MustNewRedisClient(conf.RedisUrl)
+	}
+
+	return NewTieredCache(name, conf, rdb), true
+}
+
+// NewTieredCache creates tiered cache, the cold tier is disabled if redis client is nil.
+func NewTieredCache(name string, conf TieredCacheConfig, rdb *goredis.Client) *TieredCache {
+	c := &TieredCache{name: name, conf: conf, cold: rdb}
+
+	c.hot, _ = simplelru.NewLRU(conf.HotEntries, c.onHotEvicted)
+	c.coldHits, _ = simplelru.NewLRU(conf.HotEntries, nil)
+
+	return c
+}
+
+// Get looks up the cached value of the key and decodes into `v` if found.
+func (c *TieredCache) Get(key string, v interface{}) bool {
+	data, ok := c.get(key)
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		logrus.WithField("key", key).WithError(err).Warn("Failed to decode tiered cache value")
+		return false
+	}
+
+	return true
+}
+
+// Set encodes the value and caches it into the proper tier(s) by size.
+func (c *TieredCache) Set(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logrus.WithField("key", key).WithError(err).Warn("Failed to encode tiered cache value")
+		return
+	}
+
+	c.set(key, data)
+}
+
+func (c *TieredCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	val, ok := c.hot.Get(key)
+	c.mu.Unlock()
+
+	metrics.Registry.RPC.CacheTierHit(c.name, tierHot).Mark(ok)
+	if ok {
+		return val.([]byte), true
+	}
+
+	if c.cold == nil {
+		return nil, false
+	}
+
+	data, err := c.cold.Get(context.Background(), c.coldKey(key)).Bytes()
+	if err != nil && err != goredis.Nil {
+		logrus.WithField("key", key).WithError(err).Info("Failed to get value from cold tier cache")
+	}
+
+	ok = err == nil
+	metrics.Registry.RPC.CacheTierHit(c.name, tierCold).Mark(ok)
+
+	if ok && c.countColdHit(key) {
+		c.promote(key, data)
+	}
+
+	return data, ok
+}
+
+func (c *TieredCache) set(key string, data []byte) {
+	if c.cold != nil {
+		err := c.cold.Set(context.Background(), c.coldKey(key), data, c.conf.ColdTTL).Err()
+		if err != nil {
+			logrus.WithField("key", key).WithError(err).Info("Failed to set value into cold tier cache")
+		}
+	}
+
+	// without cold tier, cache the entry in process as long as it's not oversized
+	maxSize := c.conf.SmallEntrySize
+	if c.cold == nil {
+		maxSize = c.conf.MaxHotEntrySize
+	}
+
+	if len(data) <= maxSize {
+		c.addHot(key, data)
+		return
+	}
+
+	// otherwise, remove the stale entry if any
+	c.mu.Lock()
+	c.hot.Remove(key)
+	c.mu.Unlock()
+}
+
+// countColdHit increases the cold tier hits of the key, and returns true if the entry
+// should be promoted into the hot tier.
+func (c *TieredCache) countColdHit(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hits := 1
+	if val, ok := c.coldHits.Get(key); ok {
+		hits += val.(int)
+	}
+
+	if hits < c.conf.PromoteHits {
+		c.coldHits.Add(key, hits)
+		return false
+	}
+
+	c.coldHits.Remove(key)
+	return true
+}
+
+func (c *TieredCache) promote(key string, data []byte) {
+	if len(data) > c.conf.MaxHotEntrySize { // oversized
+		return
+	}
+
+	c.addHot(key, data)
+	metrics.Registry.RPC.CachePromotions(c.name).Mark(1)
+}
+
+func (c *TieredCache) addHot(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.hot.Peek(key); ok { // replace the old entry
+		c.hotBytes -= len(old.([]byte))
+	}
+
+	c.hot.Add(key, data)
+	c.hotBytes += len(data)
+
+	// demote the least recently used entries once the hot tier capacity exceeded
+	for c.hotBytes > c.conf.HotCapacity && c.hot.Len() > 0 {
+		c.hot.RemoveOldest()
+	}
+
+	metrics.Registry.RPC.CacheHotBytes(c.name).Update(int64(c.hotBytes))
+}
+
+// onHotEvicted is called with lock held once entry removed or evicted from the hot tier.
+func (c *TieredCache) onHotEvicted(key, value interface{}) {
+	c.hotBytes -= len(value.([]byte))
+	metrics.Registry.RPC.CacheDemotions(c.name).Mark(1)
+}
+
+func (c *TieredCache) coldKey(key string) string {
+	return "confura:cache:" + c.name + ":" + key
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredCacheHotOnly(t *testing.T) {
+	cache := NewTieredCache("test", TieredCacheConfig{
+		HotCapacity:     100,
+		HotEntries:      10,
+		SmallEntrySize:  10,
+		MaxHotEntrySize: 60,
+		PromoteHits:     3,
+	}, nil)
+
+	var val string
+	assert.False(t, cache.Get("k1", &val))
+
+	cache.Set("k1", "v1")
+	assert.True(t, cache.Get("k1", &val))
+	assert.Equal(t, "v1", val)
+
+	// oversized entry is not cached
+	cache.Set("k2", strings.Repeat("x", 100))
+	assert.False(t, cache.Get("k2", &val))
+
+	// least recently used entries are evicted once capacity exceeded
+	cache.Set("k3", strings.Repeat("y", 50))
+	cache.Set("k4", strings.Repeat("z", 50))
+	assert.False(t, cache.Get("k1", &val))
+	assert.False(t, cache.Get("k3", &val))
+	assert.True(t, cache.Get("k4", &val))
+	assert.Equal(t, 52, cache.hotBytes)
+}
+
+func TestTieredCacheCountColdHit(t *testing.T) {
+	cache := NewTieredCache("test", TieredCacheConfig{HotEntries: 10, PromoteHits: 3}, nil)
+
+	assert.False(t, cache.countColdHit("k"))
+	assert.False(t, cache.countColdHit("k"))
+	assert.True(t, cache.countColdHit("k"))
+
+	// hits reset after promotion
+	assert.False(t, cache.countColdHit("k"))
+}
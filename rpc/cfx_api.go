@@ -8,6 +8,9 @@ import (
 	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/middlewares"
+	"github.com/Conflux-Chain/confura/util/txpool"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -29,6 +32,10 @@ type CfxAPIOption struct {
 	LogApiHandler       *handler.CfxLogsApiHandler
 	TxnHandler          *handler.CfxTxnHandler
 	VirtualFilterClient *vfclient.CfxClient
+	TraceHandler        *handler.TraceHandler
+	LogSpanLimiter      *handler.LogSpanLimiter
+	TxTracker           *txpool.Tracker
+	StatusReporter      *StatusReporter
 }
 
 // cfxAPI provides main proxy API for core space.
@@ -50,20 +57,23 @@ func newCfxAPI(provider *node.CfxClientProvider, option ...CfxAPIOption) *cfxAPI
 	}
 }
 
-func toEpochSlice(epoch *types.Epoch) []*types.Epoch {
+// toEpochSlice resolves the epoch tag if any, so that the tag is mapped consistently across store
+// queries and fullnode calls, and wraps into slice for optional argument.
+func toEpochSlice(cfx sdk.ClientOperator, epoch *types.Epoch) []*types.Epoch {
 	if epoch == nil {
 		return emptyEpochs
 	}
 
-	return []*types.Epoch{epoch}
+	return []*types.Epoch{handler.ResolveCfxEpochTag(cfx, epoch)}
 }
 
-func toEpochOrBlockHashSlice(epoch *types.EpochOrBlockHash) []*types.EpochOrBlockHash {
+// toEpochOrBlockHashSlice resolves the epoch tag if any, and wraps into slice for optional argument.
+func toEpochOrBlockHashSlice(cfx sdk.ClientOperator, epoch *types.EpochOrBlockHash) []*types.EpochOrBlockHash {
 	if epoch == nil {
 		return emptyEpochOrBlockHashes
 	}
 
-	return []*types.EpochOrBlockHash{epoch}
+	return []*types.EpochOrBlockHash{handler.ResolveCfxEpochOrBlockHash(cfx, epoch)}
 }
 
 func (api *cfxAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
@@ -80,67 +90,67 @@ func (api *cfxAPI) EpochNumber(ctx context.Context, epoch *types.Epoch) (*hexuti
 func (api *cfxAPI) GetBalance(ctx context.Context, address types.Address, epoch *types.EpochOrBlockHash) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update2(epoch, "cfx_getBalance", cfx)
-	return cfx.GetBalance(address, toEpochOrBlockHashSlice(epoch)...)
+	return cfx.GetBalance(address, toEpochOrBlockHashSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetAdmin(ctx context.Context, contract types.Address, epoch *types.Epoch) (*types.Address, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getAdmin", cfx)
-	return cfx.GetAdmin(contract, toEpochSlice(epoch)...)
+	return cfx.GetAdmin(contract, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetSponsorInfo(ctx context.Context, contract types.Address, epoch *types.Epoch) (types.SponsorInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getSponsorInfo", cfx)
-	return cfx.GetSponsorInfo(contract, toEpochSlice(epoch)...)
+	return cfx.GetSponsorInfo(contract, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetStakingBalance(ctx context.Context, address types.Address, epoch *types.Epoch) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getStakingBalance", cfx)
-	return cfx.GetStakingBalance(address, toEpochSlice(epoch)...)
+	return cfx.GetStakingBalance(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetDepositList(ctx context.Context, address types.Address, epoch *types.Epoch) ([]types.DepositInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getDepositList", cfx)
-	return cfx.GetDepositList(address, toEpochSlice(epoch)...)
+	return cfx.GetDepositList(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetVoteList(ctx context.Context, address types.Address, epoch *types.Epoch) ([]types.VoteStakeInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getVoteList", cfx)
-	return cfx.GetVoteList(address, toEpochSlice(epoch)...)
+	return cfx.GetVoteList(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetCollateralInfo(ctx context.Context, epoch *types.Epoch) (info types.StorageCollateralInfo, err error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getCollateralInfo", cfx)
-	return cfx.GetCollateralInfo(epoch)
+	return cfx.GetCollateralInfo(handler.ResolveCfxEpochTag(cfx, epoch))
 }
 
 func (api *cfxAPI) GetCollateralForStorage(ctx context.Context, address types.Address, epoch *types.Epoch) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getCollateralForStorage", cfx)
-	return cfx.GetCollateralForStorage(address, toEpochSlice(epoch)...)
+	return cfx.GetCollateralForStorage(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetCode(ctx context.Context, contract types.Address, epoch *types.EpochOrBlockHash) (hexutil.Bytes, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update2(epoch, "cfx_getCode", cfx)
-	return cfx.GetCode(contract, toEpochOrBlockHashSlice(epoch)...)
+	return cfx.GetCode(contract, toEpochOrBlockHashSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetStorageAt(ctx context.Context, address types.Address, position *hexutil.Big, epoch *types.EpochOrBlockHash) (hexutil.Bytes, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update2(epoch, "cfx_getStorageAt", cfx)
-	return cfx.GetStorageAt(address, position, toEpochOrBlockHashSlice(epoch)...)
+	return cfx.GetStorageAt(address, position, toEpochOrBlockHashSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetStorageRoot(ctx context.Context, address types.Address, epoch *types.Epoch) (*types.StorageRoot, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getStorageRoot", cfx)
-	return cfx.GetStorageRoot(address, toEpochSlice(epoch)...)
+	return cfx.GetStorageRoot(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetBlockByHash(ctx context.Context, blockHash types.Hash, includeTxs bool) (interface{}, error) {
@@ -152,7 +162,7 @@ func (api *cfxAPI) GetBlockByHash(ctx context.Context, blockHash types.Hash, inc
 		block, err := api.StoreHandler.GetBlockByHash(ctx, blockHash, includeTxs)
 
 		logger.WithError(err).Debug("Delegated `cfx_getBlockByHash` to store handler")
-		api.collectHitStats("cfx_getBlockByHash", err == nil)
+		api.collectHitStats(ctx, "cfx_getBlockByHash", err == nil)
 
 		if err == nil {
 			return block, nil
@@ -187,10 +197,13 @@ func (api *cfxAPI) GetBlockByEpochNumber(ctx context.Context, epoch types.Epoch,
 	api.inputEpochMetric.Update(&epoch, "cfx_getBlockByEpochNumber", cfx)
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
+		// resolve epoch tag, so that store and fullnode are queried consistently
+		epoch = *handler.ResolveCfxEpochTag(cfx, &epoch)
+
 		block, err := api.StoreHandler.GetBlockByEpochNumber(ctx, &epoch, includeTxs)
 
 		logger.WithError(err).Debug("Delegated `cfx_getBlockByEpochNumber` to store handler")
-		api.collectHitStats("cfx_getBlockByEpochNumber", err == nil)
+		api.collectHitStats(ctx, "cfx_getBlockByEpochNumber", err == nil)
 
 		if err == nil {
 			return block, nil
@@ -216,7 +229,7 @@ func (api *cfxAPI) GetBlockByBlockNumber(
 		block, err := api.StoreHandler.GetBlockByBlockNumber(ctx, blockNumer, includeTxs)
 
 		logger.WithError(err).Debug("Delegated `cfx_getBlockByBlockNumber` to store handler")
-		api.collectHitStats("cfx_getBlockByBlockNumber", err == nil)
+		api.collectHitStats(ctx, "cfx_getBlockByBlockNumber", err == nil)
 
 		if err == nil {
 			return block, nil
@@ -242,24 +255,79 @@ func (api *cfxAPI) GetBestBlockHash(ctx context.Context) (types.Hash, error) {
 func (api *cfxAPI) GetNextNonce(ctx context.Context, address types.Address, epoch *types.EpochOrBlockHash) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update2(epoch, "cfx_getNextNonce", cfx)
-	return cfx.GetNextNonce(address, toEpochOrBlockHashSlice(epoch)...)
+	return cfx.GetNextNonce(address, toEpochOrBlockHashSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (types.Hash, error) {
 	cfx := GetCfxClientFromContext(ctx)
 
+	var txHash types.Hash
+	var err error
+
 	if api.TxnHandler != nil {
 		cgroup := GetClientGroupFromContext(ctx)
-		return api.TxnHandler.SendRawTxn(cfx, cgroup, signedTx)
+		txHash, err = api.TxnHandler.SendRawTxn(cfx, cgroup, signedTx)
+	} else {
+		txHash, err = cfx.SendRawTransaction(signedTx)
+	}
+
+	// track pending transaction to detect the stuck or nonce gapped ones
+	if err == nil {
+		api.TxTracker.Track(txHash.String(), signedTx)
+	}
+
+	return txHash, err
+}
+
+// Accounts returns a list of addresses owned by client, which is not supported by gateway unless
+// signing methods allowed.
+func (api *cfxAPI) Accounts(ctx context.Context) (result []types.Address, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("cfx_accounts")
+	}
+
+	err = GetCfxClientFromContext(ctx).CallRPC(&result, "cfx_accounts")
+	return result, err
+}
+
+// Sign signs data with the account, which is not supported by gateway unless signing methods allowed.
+func (api *cfxAPI) Sign(
+	ctx context.Context, address types.Address, data hexutil.Bytes,
+) (result hexutil.Bytes, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("cfx_sign")
+	}
+
+	err = GetCfxClientFromContext(ctx).CallRPC(&result, "cfx_sign", address, data)
+	return result, err
+}
+
+// SignTransaction signs transaction with the account, which is not supported by gateway unless
+// signing methods allowed.
+func (api *cfxAPI) SignTransaction(ctx context.Context, args interface{}) (result hexutil.Bytes, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("cfx_signTransaction")
 	}
 
-	return cfx.SendRawTransaction(signedTx)
+	err = GetCfxClientFromContext(ctx).CallRPC(&result, "cfx_signTransaction", args)
+	return result, err
+}
+
+// SendTransaction signs and sends transaction with the account, which is not supported by gateway
+// unless signing methods allowed.
+func (api *cfxAPI) SendTransaction(ctx context.Context, args interface{}) (result types.Hash, err error) {
+	if !middlewares.SigningAllowed() {
+		return "", errMethodUnsupportedByGateway("cfx_sendTransaction")
+	}
+
+	err = GetCfxClientFromContext(ctx).CallRPC(&result, "cfx_sendTransaction", args)
+	return result, err
 }
 
 func (api *cfxAPI) Call(ctx context.Context, request types.CallRequest, epoch *types.EpochOrBlockHash) (hexutil.Bytes, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update2(epoch, "cfx_call", cfx)
-	return cfx.Call(request, epoch)
+	return cfx.Call(request, handler.ResolveCfxEpochOrBlockHash(cfx, epoch))
 }
 
 func (api *cfxAPI) GetLogs(ctx context.Context, fq types.LogFilter) ([]types.Log, error) {
@@ -289,16 +357,57 @@ func (api *cfxAPI) getLogs(
 		return emptyLogs, err
 	}
 
+	// cap the block span by request tier before splitting log filter
+	span := LogFilterSpan(flag, &fq)
+	if err := api.LogSpanLimiter.Validate(ctx, span); err != nil {
+		return emptyLogs, rpcutil.ResponseError(err)
+	}
+
 	if api.LogApiHandler != nil {
 		logs, hitStore, err := api.LogApiHandler.GetLogs(ctx, cfx, &fq, rpcMethod)
-		api.collectHitStats(rpcMethod, hitStore)
-		return uniformCfxLogs(logs), err
+		api.collectHitStats(ctx, rpcMethod, hitStore)
+		err = hintLogsTooLarge(err, logFilterStart(flag, &fq), span)
+		return uniformCfxLogs(logs), rpcutil.ResponseError(err)
 	}
 
 	// fail over to fullnode if no handler configured
 	return cfx.GetLogs(fq)
 }
 
+// ExplainLogs returns the planned execution of `cfx_getLogs` for the log filter without execution,
+// so as to understand why some query is slow or rejected.
+func (api *cfxAPI) ExplainLogs(ctx context.Context, fq types.LogFilter) (*handler.LogsQueryPlan, error) {
+	cfx := GetCfxClientFromContext(ctx)
+
+	flag, ok := ParseLogFilterType(&fq)
+	if !ok {
+		return nil, ErrInvalidLogFilter
+	}
+
+	if err := NormalizeLogFilter(cfx, flag, &fq); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateLogFilter(flag, &fq); err != nil {
+		return handler.NewLogsQueryPlan().Reject(err), nil
+	}
+
+	if err := api.LogSpanLimiter.Validate(ctx, LogFilterSpan(flag, &fq)); err != nil {
+		return handler.NewLogsQueryPlan().Reject(err), nil
+	}
+
+	if api.LogApiHandler != nil {
+		plan, err := api.LogApiHandler.ExplainLogs(ctx, cfx, &fq)
+		return plan, rpcutil.ResponseError(err)
+	}
+
+	// delegated to fullnode if no handler configured
+	plan := handler.NewLogsQueryPlan()
+	plan.Fullnode = fq
+
+	return plan, nil
+}
+
 func (api *cfxAPI) GetTransactionByHash(ctx context.Context, txHash types.Hash) (*types.Transaction, error) {
 	logger := logrus.WithFields(logrus.Fields{"txHash": txHash})
 
@@ -306,7 +415,7 @@ func (api *cfxAPI) GetTransactionByHash(ctx context.Context, txHash types.Hash)
 		txn, err := api.StoreHandler.GetTransactionByHash(ctx, txHash)
 
 		logger.WithError(err).Debug("Delegated `cfx_getTransactionByHash` to store handler")
-		api.collectHitStats("cfx_getTransactionByHash", err == nil)
+		api.collectHitStats(ctx, "cfx_getTransactionByHash", err == nil)
 
 		if err == nil {
 			return txn, nil
@@ -322,7 +431,7 @@ func (api *cfxAPI) GetTransactionByHash(ctx context.Context, txHash types.Hash)
 func (api *cfxAPI) EstimateGasAndCollateral(ctx context.Context, request types.CallRequest, epoch *types.Epoch) (types.Estimate, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_estimateGasAndCollateral", cfx)
-	return cfx.EstimateGasAndCollateral(request, toEpochSlice(epoch)...)
+	return cfx.EstimateGasAndCollateral(request, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) CheckBalanceAgainstTransaction(
@@ -330,7 +439,7 @@ func (api *cfxAPI) CheckBalanceAgainstTransaction(
 ) (types.CheckBalanceAgainstTransactionResponse, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_checkBalanceAgainstTransaction", cfx)
-	return cfx.CheckBalanceAgainstTransaction(account, contract, gas, price, storage, toEpochSlice(epoch)...)
+	return cfx.CheckBalanceAgainstTransaction(account, contract, gas, price, storage, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetBlocksByEpoch(ctx context.Context, epoch types.Epoch) ([]types.Hash, error) {
@@ -340,10 +449,13 @@ func (api *cfxAPI) GetBlocksByEpoch(ctx context.Context, epoch types.Epoch) ([]t
 	api.inputEpochMetric.Update(&epoch, "cfx_getBlocksByEpoch", cfx)
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
+		// resolve epoch tag, so that store and fullnode are queried consistently
+		epoch = *handler.ResolveCfxEpochTag(cfx, &epoch)
+
 		blocks, err := api.StoreHandler.GetBlocksByEpoch(ctx, &epoch)
 
 		logger.WithError(err).Debug("Delegated `cfx_getBlocksByEpoch` to store handler")
-		api.collectHitStats("cfx_getBlocksByEpoch", err == nil)
+		api.collectHitStats(ctx, "cfx_getBlocksByEpoch", err == nil)
 
 		if err == nil {
 			return blocks, nil
@@ -358,7 +470,7 @@ func (api *cfxAPI) GetBlocksByEpoch(ctx context.Context, epoch types.Epoch) ([]t
 func (api *cfxAPI) GetSkippedBlocksByEpoch(ctx context.Context, epoch types.Epoch) ([]types.Hash, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(&epoch, "cfx_getSkippedBlocksByEpoch", cfx)
-	return cfx.GetSkippedBlocksByEpoch(&epoch)
+	return cfx.GetSkippedBlocksByEpoch(handler.ResolveCfxEpochTag(cfx, &epoch))
 }
 
 func (api *cfxAPI) GetTransactionReceipt(ctx context.Context, txHash types.Hash) (*types.TransactionReceipt, error) {
@@ -368,7 +480,7 @@ func (api *cfxAPI) GetTransactionReceipt(ctx context.Context, txHash types.Hash)
 		rcpt, err := api.StoreHandler.GetTransactionReceipt(ctx, txHash)
 
 		logger.WithError(err).Debug("Delegated `cfx_getTransactionReceipt` to store handler")
-		api.collectHitStats("cfx_getTransactionReceipt", err == nil)
+		api.collectHitStats(ctx, "cfx_getTransactionReceipt", err == nil)
 
 		if err == nil {
 			return rcpt, nil
@@ -388,19 +500,19 @@ func (api *cfxAPI) GetTransactionReceipt(ctx context.Context, txHash types.Hash)
 func (api *cfxAPI) GetAccount(ctx context.Context, address types.Address, epoch *types.Epoch) (types.AccountInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getAccount", cfx)
-	return cfx.GetAccountInfo(address, toEpochSlice(epoch)...)
+	return cfx.GetAccountInfo(address, toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetInterestRate(ctx context.Context, epoch *types.Epoch) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getInterestRate", cfx)
-	return cfx.GetInterestRate(epoch)
+	return cfx.GetInterestRate(handler.ResolveCfxEpochTag(cfx, epoch))
 }
 
 func (api *cfxAPI) GetAccumulateInterestRate(ctx context.Context, epoch *types.Epoch) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getAccumulateInterestRate", cfx)
-	return cfx.GetAccumulateInterestRate(toEpochSlice(epoch)...)
+	return cfx.GetAccumulateInterestRate(toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetConfirmationRiskByHash(ctx context.Context, blockHash types.Hash) (*hexutil.Big, error) {
@@ -415,7 +527,7 @@ func (api *cfxAPI) GetStatus(ctx context.Context) (types.Status, error) {
 func (api *cfxAPI) GetBlockRewardInfo(ctx context.Context, epoch types.Epoch) ([]types.RewardInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(&epoch, "cfx_getBlockRewardInfo", cfx)
-	return cfx.GetBlockRewardInfo(epoch)
+	return cfx.GetBlockRewardInfo(*handler.ResolveCfxEpochTag(cfx, &epoch))
 }
 
 func (api *cfxAPI) ClientVersion(ctx context.Context) (string, error) {
@@ -426,7 +538,7 @@ func (api *cfxAPI) ClientVersion(ctx context.Context) (string, error) {
 func (api *cfxAPI) GetSupplyInfo(ctx context.Context, epoch *types.Epoch) (types.TokenSupplyInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getSupplyInfo", cfx)
-	return cfx.GetSupplyInfo(toEpochSlice(epoch)...)
+	return cfx.GetSupplyInfo(toEpochSlice(cfx, epoch)...)
 }
 
 func (api *cfxAPI) GetAccountPendingInfo(ctx context.Context, address types.Address) (*types.AccountPendingInfo, error) {
@@ -450,15 +562,15 @@ func (api *cfxAPI) GetOpenedMethodGroups(ctx context.Context) (openedGroups []st
 func (api *cfxAPI) GetPoSRewardByEpoch(ctx context.Context, epoch types.Epoch) (reward *postypes.EpochReward, err error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(&epoch, "cfx_getPoSRewardByEpoch", cfx)
-	return cfx.GetPoSRewardByEpoch(epoch)
+	return cfx.GetPoSRewardByEpoch(*handler.ResolveCfxEpochTag(cfx, &epoch))
 }
 
 func (api *cfxAPI) GetParamsFromVote(ctx context.Context, epoch *types.Epoch) (postypes.VoteParamsInfo, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_getParamsFromVote", cfx)
-	return GetCfxClientFromContext(ctx).GetParamsFromVote(epoch)
+	return cfx.GetParamsFromVote(handler.ResolveCfxEpochTag(cfx, epoch))
 }
 
-func (h *cfxAPI) collectHitStats(method string, hit bool) {
-	metrics.Registry.RPC.StoreHit(method, "store").Mark(hit)
+func (h *cfxAPI) collectHitStats(ctx context.Context, method string, hit bool) {
+	collectStoreHitStats(ctx, method, hit)
 }
@@ -33,7 +33,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 
 	rpcSub := psCtx.notifier.CreateSubscription()
 
-	headersCh := make(chan *types.BlockHeader, pubsubChannelBufferSize)
+	headersCh := make(chan *types.BlockHeader, pubsubBufferCfg.Size)
 	dClient := getOrNewDelegateClient(psCtx.cfx)
 
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
@@ -56,6 +56,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 			select {
 			case blockHeader := <-headersCh:
 				logger.WithField("blockHeader", blockHeader).Debug("Received new block header from pubsub delegate")
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
 				psCtx.notifier.Notify(rpcSub.ID, blockHeader)
 
 			case err = <-dSub.err: // delegate subscription error
@@ -100,7 +101,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 
 	rpcSub := psCtx.notifier.CreateSubscription()
 
-	epochsCh := make(chan *types.WebsocketEpochResponse, pubsubChannelBufferSize)
+	epochsCh := make(chan *types.WebsocketEpochResponse, pubsubBufferCfg.Size)
 	dClient := getOrNewDelegateClient(psCtx.cfx)
 
 	dSub, err := dClient.delegateSubscribeEpochs(rpcSub.ID, epochsCh, *subEpoch)
@@ -123,6 +124,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 			select {
 			case epoch := <-epochsCh:
 				logger.WithField("epoch", epoch).Debugf("Received new epoch from pubsub delegate (%v)", subEpoch)
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
 				psCtx.notifier.Notify(rpcSub.ID, epoch)
 
 			case err = <-dSub.err: // delegate subscription error
@@ -161,7 +163,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 
 	rpcSub := psCtx.notifier.CreateSubscription()
 
-	logsCh := make(chan *types.SubscriptionLog, pubsubChannelBufferSize)
+	logsCh := make(chan *types.SubscriptionLog, pubsubBufferCfg.Size)
 	dClient := getOrNewDelegateClient(psCtx.cfx)
 
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
@@ -184,6 +186,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 			select {
 			case log := <-logsCh:
 				logger.WithField("log", log).Debug("Received new log from pubsub delegate")
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
 				psCtx.notifier.Notify(rpcSub.ID, log)
 
 			case err = <-dSub.err: // delegate subscription error
@@ -134,6 +134,11 @@ func ConvertBlockHeader(block *ethTypes.Block, ethNetworkId uint32) *types.Block
 		extraData = []byte{}
 	}
 
+	var nonce uint64
+	if block.Nonce != nil { // nonce might be omitted by some fullnode
+		nonce = block.Nonce.Uint64()
+	}
+
 	return &types.BlockHeader{
 		Hash:                  types.Hash(block.Hash.Hex()),
 		ParentHash:            types.Hash(block.ParentHash.Hex()),
@@ -153,7 +158,7 @@ func ConvertBlockHeader(block *ethTypes.Block, ethNetworkId uint32) *types.Block
 		PowQuality:            HexBig0,
 		RefereeHashes:         referees,
 		Adaptive:              false,
-		Nonce:                 types.NewBigInt(block.Nonce.Uint64()),
+		Nonce:                 types.NewBigInt(nonce),
 		Size:                  types.NewBigInt(block.Size),
 		Custom:                []cmptutil.Bytes{extraData},
 	}
@@ -205,6 +210,11 @@ func ConvertLog(log *ethTypes.Log, ethNetworkId uint32) *types.Log {
 		topics[i] = types.Hash(log.Topics[i].Hex())
 	}
 
+	var txnLogIndex *hexutil.Big
+	if log.TransactionLogIndex != nil {
+		txnLogIndex = types.NewBigInt(uint64(*log.TransactionLogIndex))
+	}
+
 	return &types.Log{
 		Address:             ConvertAddress(log.Address, ethNetworkId),
 		Topics:              topics,
@@ -212,9 +222,9 @@ func ConvertLog(log *ethTypes.Log, ethNetworkId uint32) *types.Log {
 		BlockHash:           ConvertHashNullable(&log.BlockHash),
 		EpochNumber:         types.NewBigInt(log.BlockNumber),
 		TransactionHash:     ConvertHashNullable(&log.TxHash),
-		TransactionIndex:    types.NewBigInt(uint64(log.TxIndex)),              // tx index in block
-		LogIndex:            types.NewBigInt(uint64(log.Index)),                // log index in block
-		TransactionLogIndex: types.NewBigInt(uint64(*log.TransactionLogIndex)), // log index in tx
+		TransactionIndex:    types.NewBigInt(uint64(log.TxIndex)), // tx index in block
+		LogIndex:            types.NewBigInt(uint64(log.Index)),   // log index in block
+		TransactionLogIndex: txnLogIndex,                          // log index in tx
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
 	"github.com/ethereum/go-ethereum/common"
@@ -14,7 +15,8 @@ import (
 	"github.com/pkg/errors"
 )
 
-// EthBlockNumber accepts number and epoch tag latest_state, other values are invalid, e.g. latest_confirmed.
+// EthBlockNumber accepts number and epoch tags mapped onto evm space block tags (e.g. latest_confirmed
+// as safe), other values are invalid, e.g. latest_checkpoint.
 type EthBlockNumber struct {
 	value ethTypes.BlockNumber
 }
@@ -52,22 +54,24 @@ func (ebn *EthBlockNumber) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Supports hex, latest_state and earliest
+	// Supports hex number
 	if num, ok := epoch.ToInt(); ok {
 		ebn.value = ethTypes.BlockNumber(num.Int64())
-	} else if types.EpochLatestState.Equals(&epoch) {
-		ebn.value = ethTypes.LatestBlockNumber
-	} else if types.EpochEarliest.Equals(&epoch) {
-		ebn.value = ethTypes.EarliestBlockNumber
-	} else {
-		// Other values are all invalid
-		return ErrEpochUnsupported
+		return nil
 	}
 
-	return nil
+	// Supports epoch tags available in evm space
+	if bn, ok := ethBlockTag(&epoch); ok {
+		ebn.value = bn
+		return nil
+	}
+
+	// Other values are all invalid
+	return ErrEpochUnsupported
 }
 
-// EthBlockNumberOrHash accepts hex number, hash and epoch tag latest_state, other values are invalid, e.g. latest_confirmed.
+// EthBlockNumberOrHash accepts hex number, hash and epoch tags mapped onto evm space block tags, other values
+// are invalid, e.g. latest_checkpoint.
 type EthBlockNumberOrHash struct {
 	number ethTypes.BlockNumber
 	hash   *common.Hash
@@ -97,12 +101,13 @@ func (ebnh *EthBlockNumberOrHash) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
-	// Supports particular tags (latest_state and earliest) and hash
+	// Supports epoch tags available in evm space and hash
+	if bn, ok := ethBlockTag(&epoch); ok {
+		ebnh.number = bn
+		return nil
+	}
+
 	switch {
-	case types.EpochEarliest.Equals(&epoch):
-		ebnh.number = ethTypes.EarliestBlockNumber
-	case types.EpochLatestState.Equals(&epoch):
-		ebnh.number = ethTypes.LatestBlockNumber
 	case len(epoch.String()) == 66:
 		blockHash := common.HexToHash(epoch.String())
 		ebnh.hash = &blockHash
@@ -340,3 +345,13 @@ func resolveToHashes(val interface{}) ([]common.Hash, error) {
 
 	return nil, errors.Errorf("failed to convert %v to hash or hashes", val)
 }
+
+// ethBlockTag maps the epoch tag onto evm space block tag by Conflux confirmation rules.
+func ethBlockTag(epoch *types.Epoch) (ethTypes.BlockNumber, bool) {
+	tag, ok := rpcutil.CfxEpochTag(epoch)
+	if !ok {
+		return 0, false
+	}
+
+	return tag.EthBlockNumber()
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+var errActivityIndexNotEnabled = errors.New("address activity index not enabled")
+
+// AddressTxStore store to query the indexed transactions by address.
+type AddressTxStore interface {
+	GetAddressTxs(filter mysql.AddressTxFilter) ([]*mysql.AddressTx, error)
+}
+
+// AddressTxQuery optional conditions to query transactions by address.
+type AddressTxQuery struct {
+	Direction string         `json:"direction"` // `in`, `out` or empty for both
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+	Cursor    hexutil.Uint64 `json:"cursor"` // `nextCursor` of the last page
+	Limit     hexutil.Uint64 `json:"limit"`
+}
+
+type AddressTx struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"` // created contract for contract creation
+	Value            *hexutil.Big   `json:"value"`
+	Status           hexutil.Uint64 `json:"status"`
+}
+
+// AddressTxPage a page of address transactions in reverse chronological order, where `nextCursor`
+// is nil if no more.
+type AddressTxPage struct {
+	Transactions []*AddressTx    `json:"transactions"`
+	NextCursor   *hexutil.Uint64 `json:"nextCursor"`
+}
+
+// GetTransactionsByAddress returns the transactions sent from or to the address in pages.
+func (api *confuraAPI) GetTransactionsByAddress(
+	ctx context.Context, address common.Address, query *AddressTxQuery,
+) (*AddressTxPage, error) {
+	if api.AddressTxStore == nil {
+		return nil, errActivityIndexNotEnabled
+	}
+
+	if query == nil {
+		query = &AddressTxQuery{}
+	}
+
+	if err := validateIndexQuery(query.Direction, query.FromBlock, query.ToBlock); err != nil {
+		return nil, err
+	}
+
+	filter := mysql.AddressTxFilter{
+		Address:   normalizeIndexAddress(address),
+		Direction: query.Direction,
+		FromBlock: uint64(query.FromBlock),
+		ToBlock:   uint64(query.ToBlock),
+		Cursor:    uint64(query.Cursor),
+		Limit:     normalizeIndexQueryLimit(query.Limit, mysql.MaxAddressTxQueryLimit),
+	}
+
+	txs, err := api.AddressTxStore.GetAddressTxs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AddressTxPage{Transactions: make([]*AddressTx, 0, len(txs))}
+	for _, tx := range txs {
+		page.Transactions = append(page.Transactions, &AddressTx{
+			BlockNumber:      hexutil.Uint64(tx.Bn),
+			TransactionHash:  common.HexToHash(tx.TxHash),
+			TransactionIndex: hexutil.Uint64(tx.TxIndex),
+			From:             common.HexToAddress(tx.From),
+			To:               common.HexToAddress(tx.To),
+			Value:            parseIndexDecimal(tx.Value),
+			Status:           hexutil.Uint64(tx.Status),
+		})
+	}
+
+	if len(txs) == filter.Limit {
+		cursor := hexutil.Uint64(txs[len(txs)-1].ID)
+		page.NextCursor = &cursor
+	}
+
+	return page, nil
+}
@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"unicode"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/rpc/middlewares"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	// namespace for gateway extension RPC methods
+	confuraNamespace = "confura"
+)
+
+var (
+	errQuotaNotEnabled = errors.New("compute unit quota not enabled")
+	errApiKeyRequired  = errors.New("API key required")
+
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	subscriptionType = reflect.TypeOf((*rpc.Subscription)(nil))
+)
+
+// Capabilities describes the RPC capabilities served by the gateway, so that clients (eg.,
+// wallet SDKs) could detect the supported features and degrade gracefully.
+type Capabilities struct {
+	Space         string              `json:"space"`         // "cfx" for core space or "eth" for evm space
+	Namespaces    map[string][]string `json:"namespaces"`    // namespace => supported methods
+	Subscriptions []string            `json:"subscriptions"` // supported pubsub subscriptions
+	Unsupported   []string            `json:"unsupported"`   // explicitly unsupported methods
+}
+
+// confuraAPIOption optional components of gateway extension RPC methods, which are nil if not enabled.
+type confuraAPIOption struct {
+	StatusReporter     *StatusReporter
+	TokenStore         TokenStore
+	AddressTxStore     AddressTxStore
+	InternalTxStore    InternalTxStore
+	ContractIndexStore ContractIndexStore
+
+	// evm space API to submit logs jobs, nil for core space
+	Eth *ethAPI
+}
+
+// confuraAPI provides gateway extension RPC methods.
+type confuraAPI struct {
+	confuraAPIOption
+	capabilities *Capabilities
+}
+
+func newConfuraAPI(space string, exposedApis map[string]interface{}, option confuraAPIOption) *confuraAPI {
+	return &confuraAPI{
+		confuraAPIOption: option,
+		capabilities:     discoverCapabilities(space, exposedApis),
+	}
+}
+
+// Capabilities returns the supported namespaces, methods and subscriptions of the gateway, excluding
+// the methods not exposed on the endpoint or to the tenant by method filter if configured.
+func (api *confuraAPI) Capabilities(ctx context.Context) (*Capabilities, error) {
+	filter, ok := ctx.Value(handlers.CtxKeyMethodFilter).(*handlers.MethodFilter)
+	if !ok {
+		return api.capabilities, nil
+	}
+
+	tenantName, _ := tenant.FromContext(ctx)
+	return api.capabilities.filter(filter, tenantName), nil
+}
+
+// Quota returns the compute unit quota status of the tenant by API key.
+func (api *confuraAPI) Quota(ctx context.Context) (*tenant.QuotaStatus, error) {
+	registry, ok := ctx.Value(handlers.CtxKeyTenantRegistry).(*tenant.Registry)
+	if !ok {
+		return nil, errQuotaNotEnabled
+	}
+
+	tenantName, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, errApiKeyRequired
+	}
+
+	status, ok := registry.Quota(tenantName)
+	if !ok {
+		return nil, errQuotaNotEnabled
+	}
+
+	return status, nil
+}
+
+// Status returns the gateway's view of data freshness, including the max epoch and reorg version of
+// store, the latest epoch of upstream fullnodes, and their lags to the best node.
+func (api *confuraAPI) Status(ctx context.Context) (*GatewayStatus, error) {
+	if api.StatusReporter == nil {
+		return nil, errStatusNotEnabled
+	}
+
+	return api.StatusReporter.Status(), nil
+}
+
+// discoverCapabilities collects all the exposed RPC methods of the services by reflection,
+// in the same way as RPC server registers the callbacks.
+func discoverCapabilities(space string, exposedApis map[string]interface{}) *Capabilities {
+	unsupported := make(map[string]bool)
+	for _, method := range gatewayUnsupportedMethods {
+		unsupported[method] = !middlewares.SigningAllowed()
+	}
+
+	caps := &Capabilities{
+		Space:         space,
+		Namespaces:    make(map[string][]string),
+		Subscriptions: []string{},
+		Unsupported:   []string{},
+	}
+
+	// the gateway extension methods themselves
+	caps.Namespaces[confuraNamespace] = []string{
+		confuraNamespace + "_capabilities", confuraNamespace + "_quota", confuraNamespace + "_status",
+		confuraNamespace + "_getTokenBalances", confuraNamespace + "_getTokenTransfers",
+		confuraNamespace + "_getTransactionsByAddress", confuraNamespace + "_getInternalTransactions",
+		confuraNamespace + "_getContractCreation",
+	}
+
+	if space == "eth" {
+		caps.Namespaces[confuraNamespace] = append(caps.Namespaces[confuraNamespace],
+			confuraNamespace+"_submitLogsJob", confuraNamespace+"_getLogsJobStatus",
+			confuraNamespace+"_getLogsJobResult", confuraNamespace+"_cancelLogsJob",
+		)
+	}
+
+	for namespace, service := range exposedApis {
+		svcType := reflect.TypeOf(service)
+
+		methods := []string{}
+		for i := 0; i < svcType.NumMethod(); i++ {
+			method := svcType.Method(i)
+			if len(method.PkgPath) > 0 || !isSuitableCallback(method.Type) {
+				continue
+			}
+
+			name := formatRpcMethodName(method.Name)
+			if isSubscription(method.Type) {
+				caps.Subscriptions = append(caps.Subscriptions, name)
+				continue
+			}
+
+			fullName := namespace + "_" + name
+			if unsupported[fullName] {
+				caps.Unsupported = append(caps.Unsupported, fullName)
+				continue
+			}
+
+			methods = append(methods, fullName)
+		}
+
+		if len(methods) > 0 {
+			sort.Strings(methods)
+			caps.Namespaces[namespace] = methods
+		}
+	}
+
+	sort.Strings(caps.Subscriptions)
+	sort.Strings(caps.Unsupported)
+
+	return caps
+}
+
+// filter returns a copy of capabilities with the methods allowed by method filter for the tenant
+// only, and subscriptions are excluded if the subscribe method not allowed.
+func (caps *Capabilities) filter(filter *handlers.MethodFilter, tenantName string) *Capabilities {
+	filtered := &Capabilities{
+		Space:         caps.Space,
+		Namespaces:    make(map[string][]string),
+		Subscriptions: []string{},
+		Unsupported:   caps.Unsupported,
+	}
+
+	for namespace, methods := range caps.Namespaces {
+		allowed := []string{}
+		for _, method := range methods {
+			if filter.Allowed(tenantName, method) {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) > 0 {
+			filtered.Namespaces[namespace] = allowed
+		}
+	}
+
+	if filter.Allowed(tenantName, caps.Space+"_subscribe") {
+		filtered.Subscriptions = caps.Subscriptions
+	}
+
+	return filtered
+}
+
+// isSuitableCallback checks if method could be registered as RPC callback, which returns
+// at most 2 values with the error as the last one.
+func isSuitableCallback(fntype reflect.Type) bool {
+	switch fntype.NumOut() {
+	case 0, 1:
+		return true
+	case 2:
+		return fntype.Out(1) == errorType
+	default:
+		return false
+	}
+}
+
+// isSubscription checks if method is a pubsub subscription, which returns RPC subscription.
+func isSubscription(fntype reflect.Type) bool {
+	return fntype.NumOut() == 2 && fntype.Out(0) == subscriptionType
+}
+
+// formatRpcMethodName converts method name to RPC method name by lower casing the first rune.
+func formatRpcMethodName(name string) string {
+	ret := []rune(name)
+	if len(ret) > 0 {
+		ret[0] = unicode.ToLower(ret[0])
+	}
+
+	return string(ret)
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesFilter(t *testing.T) {
+	caps := &Capabilities{
+		Space: "eth",
+		Namespaces: map[string][]string{
+			"eth":     {"eth_call", "eth_getBalance", "eth_subscribe"},
+			"debug":   {"debug_traceTransaction"},
+			"web3":    {"web3_clientVersion"},
+			"confura": {"confura_capabilities", "confura_status"},
+		},
+		Subscriptions: []string{"logs", "newHeads"},
+		Unsupported:   []string{"eth_sendTransaction"},
+	}
+	api := &confuraAPI{capabilities: caps}
+
+	// not filtered if method filter not configured
+	result, err := api.Capabilities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, caps, result)
+
+	filter := handlers.NewMethodFilter(handlers.MethodFilterConfig{
+		MethodList: handlers.MethodList{Deny: []string{"debug_*", "eth_subscribe", "confura_status"}},
+		Tenants: map[string]handlers.MethodList{
+			"vip": {Allow: []string{"eth_*"}},
+		},
+	})
+	ctx := context.WithValue(context.Background(), handlers.CtxKeyMethodFilter, filter)
+
+	result, err = api.Capabilities(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"eth":     {"eth_call", "eth_getBalance"},
+		"web3":    {"web3_clientVersion"},
+		"confura": {"confura_capabilities"},
+	}, result.Namespaces)
+	assert.Empty(t, result.Subscriptions)
+	assert.Equal(t, caps.Unsupported, result.Unsupported)
+
+	// method list overridden by tenant
+	result, err = api.Capabilities(tenant.NewContext(ctx, "VIP"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"eth": {"eth_call", "eth_getBalance", "eth_subscribe"},
+	}, result.Namespaces)
+	assert.Equal(t, caps.Subscriptions, result.Subscriptions)
+}
+
+func TestLogsJobOwner(t *testing.T) {
+	// API key required
+	_, err := logsJobOwner(context.Background())
+	assert.Equal(t, errApiKeyRequired, err)
+
+	// owned by API key
+	ctx := context.WithValue(context.Background(), handlers.CtxKeyAccessToken, "key1")
+	owner, err := logsJobOwner(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "key:key1", owner)
+
+	// tenant preferred to API key
+	owner, err = logsJobOwner(tenant.NewContext(ctx, "alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant:alice", owner)
+
+	// not supported for core space
+	_, err = (&confuraAPI{}).GetLogsJobStatus(ctx, "0x1")
+	assert.Equal(t, errLogsJobUnsupported, err)
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+var errContractIndexNotEnabled = errors.New("contract creation index not enabled")
+
+// ContractIndexStore store to query the indexed contract creations and externally owned accounts.
+type ContractIndexStore interface {
+	GetContractCreation(address string) (*mysql.ContractCreation, bool, error)
+	GetExternallyOwnedAccount(address string) (uint64, bool, error)
+}
+
+type ContractCreation struct {
+	Address          common.Address `json:"address"`
+	Creator          common.Address `json:"creator"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+}
+
+// GetContractCreation returns the creation transaction, block and creator of the contract, or nil
+// if not found. Note, contracts created by internal calls (eg., factory contracts) are not indexed.
+func (api *confuraAPI) GetContractCreation(ctx context.Context, address common.Address) (*ContractCreation, error) {
+	if api.ContractIndexStore == nil {
+		return nil, errContractIndexNotEnabled
+	}
+
+	creation, ok, err := api.ContractIndexStore.GetContractCreation(normalizeIndexAddress(address))
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &ContractCreation{
+		Address:          common.HexToAddress(creation.Address),
+		Creator:          common.HexToAddress(creation.Creator),
+		TransactionHash:  common.HexToHash(creation.TxHash),
+		TransactionIndex: hexutil.Uint64(creation.TxIndex),
+		BlockNumber:      hexutil.Uint64(creation.Bn),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+var errLogsJobUnsupported = errors.New("logs job not supported")
+
+// logsJobOwner returns the tenant or API key of the request, so that logs jobs are only
+// accessible to the client who submitted them.
+func logsJobOwner(ctx context.Context) (string, error) {
+	if name, ok := tenant.FromContext(ctx); ok {
+		return "tenant:" + name, nil
+	}
+
+	if key, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(key) > 0 {
+		return "key:" + key, nil
+	}
+
+	return "", errApiKeyRequired
+}
+
+func (api *confuraAPI) logsJobManager() (*handler.EthLogsJobManager, error) {
+	if api.Eth == nil || api.Eth.LogsJobManager == nil {
+		return nil, errLogsJobUnsupported
+	}
+
+	return api.Eth.LogsJobManager, nil
+}
+
+// SubmitLogsJob submits a heavy event logs query job to be executed in background, and returns
+// the job ID to poll the job status and result later by the same API key.
+func (api *confuraAPI) SubmitLogsJob(ctx context.Context, fq types.FilterQuery) (rpc.ID, error) {
+	manager, err := api.logsJobManager()
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := logsJobOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	w3c := GetEthClientFromContext(ctx)
+
+	flag, ok := ParseEthLogFilterType(&fq)
+	if !ok {
+		return "", ErrInvalidEthLogFilter
+	}
+
+	params, err := api.Eth.getChainParams(w3c.Client)
+	if err != nil {
+		return "", err
+	}
+
+	if err := NormalizeEthLogFilter(w3c, flag, &fq, params.hardforkBlockNumber); err != nil {
+		return "", err
+	}
+
+	if err := ValidateEthLogFilter(flag, &fq); err != nil {
+		return "", err
+	}
+
+	return manager.Submit(w3c.Eth, fq, owner)
+}
+
+// GetLogsJobStatus returns the status of the specified event logs query job.
+func (api *confuraAPI) GetLogsJobStatus(ctx context.Context, id rpc.ID) (*handler.LogsJobStatus, error) {
+	manager, err := api.logsJobManager()
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := logsJobOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.Status(id, owner)
+}
+
+// GetLogsJobResult returns the event logs of the specified completed event logs query job.
+func (api *confuraAPI) GetLogsJobResult(ctx context.Context, id rpc.ID) ([]types.Log, error) {
+	manager, err := api.logsJobManager()
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := logsJobOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := manager.Result(id, owner)
+	return uniformEthLogs(logs), err
+}
+
+// CancelLogsJob cancels the specified event logs query job if not finished yet.
+func (api *confuraAPI) CancelLogsJob(ctx context.Context, id rpc.ID) (bool, error) {
+	manager, err := api.logsJobManager()
+	if err != nil {
+		return false, err
+	}
+
+	owner, err := logsJobOwner(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return manager.Cancel(id, owner), nil
+}
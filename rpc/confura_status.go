@@ -0,0 +1,303 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// weight of the latest sample to smooth the sync rate
+const syncRateSmoothing = 0.3
+
+var errStatusNotEnabled = errors.New("gateway status not enabled")
+
+// StatusConfig gateway status (`confura_status`) configurations.
+type StatusConfig struct {
+	// whether to report gateway status
+	Enabled bool
+	// interval to poll the latest epoch of upstream fullnodes and store
+	PollInterval time.Duration `default:"5s"`
+}
+
+// HeadStore provides the head of store.
+type HeadStore interface {
+	MaxEpoch() (uint64, bool, error)
+}
+
+// StatusStore provides the sync status of store.
+type StatusStore interface {
+	HeadStore
+	GetReorgVersion() (int, error)
+}
+
+// StoreStatus sync status of store.
+type StoreStatus struct {
+	MaxEpoch     uint64 `json:"maxEpoch"`     // max epoch (or block) synced into store
+	ReorgVersion int    `json:"reorgVersion"` // increased once chain reorg occurred
+	Backlog      uint64 `json:"backlog"`      // number of epochs (or blocks) behind the best node
+	// epochs (or blocks) synced per second, smoothed over the recent polls
+	SyncRate float64 `json:"syncRate"`
+	// estimated seconds to catch up with the backlog, nil if not predictable yet
+	CatchUpEta *float64 `json:"catchUpEta,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// NodeStatus latest epoch of upstream fullnode.
+type NodeStatus struct {
+	Name        string `json:"name"`
+	LatestEpoch uint64 `json:"latestEpoch"`
+	Lag         uint64 `json:"lag"` // number of epochs (or blocks) behind the best node
+	Error       string `json:"error,omitempty"`
+}
+
+// GatewayStatus gateway's view of data freshness, so that clients and monitors could reason about
+// how stale the served data could be.
+type GatewayStatus struct {
+	Space     string        `json:"space"`
+	BestEpoch uint64        `json:"bestEpoch"`       // latest epoch (or block) of the best node
+	Store     *StoreStatus  `json:"store,omitempty"` // nil if store not available
+	Nodes     []*NodeStatus `json:"nodes"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// epochFunc returns the latest epoch (or block) number of upstream fullnode.
+type epochFunc func() (uint64, error)
+
+// StatusReporter polls the latest epoch of upstream fullnodes and store periodically to report
+// gateway status.
+type StatusReporter struct {
+	conf   StatusConfig
+	space  string
+	store  StatusStore          // could be nil if store not available
+	nodes  map[string]epochFunc // node name => latest epoch func
+	status atomic.Value         // *GatewayStatus
+
+	// last polled store head to estimate sync rate
+	lastMaxEpoch uint64
+	lastPolledAt time.Time
+	syncRate     float64
+}
+
+// MustNewCfxStatusReporterFromViper creates core space status reporter from viper settings, or
+// returns nil if not enabled.
+func MustNewCfxStatusReporterFromViper(store StatusStore) *StatusReporter {
+	var conf StatusConfig
+	viper.MustUnmarshalKey("rpc.status", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	nodes := make(map[string]epochFunc)
+	for _, url := range node.CfxUrlConfig()[node.GroupCfxHttp].Nodes {
+		url := url
+
+		nodes[statusNodeName(nodes, url)] = func() (uint64, error) {
+			cfx, err := rpcutil.CfxClientPool.GetCfxClient(url)
+			if err != nil {
+				return 0, err
+			}
+
+			epoch, err := cfx.GetEpochNumber(types.EpochLatestMined)
+			if err != nil {
+				return 0, err
+			}
+
+			return epoch.ToInt().Uint64(), nil
+		}
+	}
+
+	return newStatusReporter(conf, "cfx", store, nodes)
+}
+
+// MustNewEthStatusReporterFromViper creates evm space status reporter from viper settings, or
+// returns nil if not enabled.
+func MustNewEthStatusReporterFromViper(store StatusStore) *StatusReporter {
+	var conf StatusConfig
+	viper.MustUnmarshalKey("ethrpc.status", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	nodes := make(map[string]epochFunc)
+	for _, url := range node.EthUrlConfig()[node.GroupEthHttp].Nodes {
+		url := url
+
+		nodes[statusNodeName(nodes, url)] = func() (uint64, error) {
+			eth, err := rpcutil.EthClientPool.GetEthClient(url)
+			if err != nil {
+				return 0, err
+			}
+
+			bn, err := eth.Eth.BlockNumber()
+			if err != nil {
+				return 0, err
+			}
+
+			return bn.Uint64(), nil
+		}
+	}
+
+	return newStatusReporter(conf, "eth", store, nodes)
+}
+
+// statusNodeName names the fullnode by alias or host of URL, so that credentials (e.g. API key in
+// URL path) are never exposed in the public status. Fullnodes of the same host are suffixed with
+// sequence number to be distinguished.
+func statusNodeName(nodes map[string]epochFunc, url string) string {
+	name := rpcutil.NodeAlias(url)
+	if _, ok := nodes[name]; !ok {
+		return name
+	}
+
+	for i := 2; ; i++ {
+		if seqName := fmt.Sprintf("%v#%v", name, i); nodes[seqName] == nil {
+			return seqName
+		}
+	}
+}
+
+func newStatusReporter(conf StatusConfig, space string, store StatusStore, nodes map[string]epochFunc) *StatusReporter {
+	if util.IsInterfaceValNil(store) {
+		store = nil
+	}
+
+	r := &StatusReporter{conf: conf, space: space, store: store, nodes: nodes}
+	r.refresh()
+
+	return r
+}
+
+// Run polls the gateway status periodically until context done.
+func (r *StatusReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh()
+		}
+	}
+}
+
+// Status returns the last polled gateway status.
+func (r *StatusReporter) Status() *GatewayStatus {
+	return r.status.Load().(*GatewayStatus)
+}
+
+func (r *StatusReporter) refresh() {
+	status := GatewayStatus{
+		Space:     r.space,
+		Nodes:     make([]*NodeStatus, 0, len(r.nodes)),
+		UpdatedAt: time.Now(),
+	}
+
+	for name, latestEpoch := range r.nodes {
+		ns := NodeStatus{Name: name}
+
+		if epoch, err := latestEpoch(); err != nil {
+			logrus.WithField("node", name).WithError(err).Debug("Failed to poll latest epoch of fullnode")
+			ns.Error = err.Error()
+		} else {
+			ns.LatestEpoch = epoch
+		}
+
+		if ns.LatestEpoch > status.BestEpoch {
+			status.BestEpoch = ns.LatestEpoch
+		}
+
+		status.Nodes = append(status.Nodes, &ns)
+	}
+
+	sort.Slice(status.Nodes, func(i, j int) bool {
+		return status.Nodes[i].Name < status.Nodes[j].Name
+	})
+
+	// unavailable nodes are regarded as lagging behind all the way
+	for _, ns := range status.Nodes {
+		ns.Lag = status.BestEpoch - ns.LatestEpoch
+	}
+
+	if r.store != nil {
+		status.Store = r.storeStatus(status.BestEpoch, status.UpdatedAt)
+	}
+
+	r.status.Store(&status)
+}
+
+func (r *StatusReporter) storeStatus(bestEpoch uint64, now time.Time) *StoreStatus {
+	var status StoreStatus
+
+	maxEpoch, ok, err := r.store.MaxEpoch()
+	if err != nil {
+		status.Error = errors.WithMessage(err, "failed to get max epoch").Error()
+		return &status
+	}
+
+	reorgVersion, err := r.store.GetReorgVersion()
+	if err != nil {
+		status.Error = errors.WithMessage(err, "failed to get reorg version").Error()
+		return &status
+	}
+
+	if ok {
+		status.MaxEpoch = maxEpoch
+	}
+
+	status.ReorgVersion = reorgVersion
+
+	if bestEpoch > status.MaxEpoch {
+		status.Backlog = bestEpoch - status.MaxEpoch
+	}
+
+	r.updateSyncRate(status.MaxEpoch, now)
+	status.SyncRate = r.syncRate
+
+	if status.Backlog == 0 {
+		eta := float64(0)
+		status.CatchUpEta = &eta
+	} else if r.syncRate > 0 {
+		eta := float64(status.Backlog) / r.syncRate
+		status.CatchUpEta = &eta
+	}
+
+	return &status
+}
+
+// updateSyncRate estimates the sync rate by the store head advanced since last poll, which is smoothed
+// by exponential moving average.
+func (r *StatusReporter) updateSyncRate(maxEpoch uint64, now time.Time) {
+	defer func() {
+		r.lastMaxEpoch, r.lastPolledAt = maxEpoch, now
+	}()
+
+	elapsed := now.Sub(r.lastPolledAt).Seconds()
+	if r.lastPolledAt.IsZero() || elapsed <= 0 {
+		return
+	}
+
+	var rate float64
+	if maxEpoch > r.lastMaxEpoch { // store head reverted due to reorg regarded as no progress
+		rate = float64(maxEpoch-r.lastMaxEpoch) / elapsed
+	}
+
+	if r.syncRate == 0 {
+		r.syncRate = rate
+	} else {
+		r.syncRate = syncRateSmoothing*rate + (1-syncRateSmoothing)*r.syncRate
+	}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStatusStore struct {
+	maxEpoch     uint64
+	reorgVersion int
+}
+
+func (s *mockStatusStore) MaxEpoch() (uint64, bool, error) { return s.maxEpoch, true, nil }
+func (s *mockStatusStore) GetReorgVersion() (int, error)   { return s.reorgVersion, nil }
+
+func TestStatusReporter(t *testing.T) {
+	nodes := map[string]epochFunc{
+		"node1": func() (uint64, error) { return 100, nil },
+		"node2": func() (uint64, error) { return 95, nil },
+		"node3": func() (uint64, error) { return 0, errors.New("timeout") },
+	}
+
+	store := &mockStatusStore{maxEpoch: 90, reorgVersion: 2}
+	status := newStatusReporter(StatusConfig{}, "eth", store, nodes).Status()
+
+	assert.Equal(t, uint64(100), status.BestEpoch)
+	assert.Equal(t, &StoreStatus{MaxEpoch: 90, ReorgVersion: 2, Backlog: 10}, status.Store)
+
+	assert.Equal(t, 3, len(status.Nodes))
+	assert.Equal(t, &NodeStatus{Name: "node1", LatestEpoch: 100}, status.Nodes[0])
+	assert.Equal(t, &NodeStatus{Name: "node2", LatestEpoch: 95, Lag: 5}, status.Nodes[1])
+	assert.Equal(t, &NodeStatus{Name: "node3", Lag: 100, Error: "timeout"}, status.Nodes[2])
+
+	// store not available
+	var nilStore *mockStatusStore
+	status = newStatusReporter(StatusConfig{}, "eth", nilStore, nodes).Status()
+	assert.Nil(t, status.Store)
+}
+
+func TestStatusReporterSyncRate(t *testing.T) {
+	nodes := map[string]epochFunc{
+		"node1": func() (uint64, error) { return 1000, nil },
+	}
+
+	store := &mockStatusStore{maxEpoch: 100}
+	reporter := newStatusReporter(StatusConfig{}, "eth", store, nodes)
+	assert.Nil(t, reporter.Status().Store.CatchUpEta)
+
+	// 100 epochs synced within 10 seconds
+	reporter.lastPolledAt = reporter.lastPolledAt.Add(-10 * time.Second)
+	store.maxEpoch = 200
+	reporter.refresh()
+
+	status := reporter.Status().Store
+	assert.InDelta(t, 10, status.SyncRate, 0.1)
+	assert.NotNil(t, status.CatchUpEta)
+	assert.InDelta(t, 80, *status.CatchUpEta, 1)
+
+	// caught up
+	store.maxEpoch = 1000
+	reporter.refresh()
+	assert.Equal(t, float64(0), *reporter.Status().Store.CatchUpEta)
+}
+
+func TestStatusNodeName(t *testing.T) {
+	nodes := make(map[string]epochFunc)
+	noop := func() (uint64, error) { return 0, nil }
+
+	for _, url := range []string{
+		"https://rpc.example.com/v1/secret-key1",
+		"https://rpc.example.com/v1/secret-key2",
+		"http://127.0.0.1:12537",
+	} {
+		nodes[statusNodeName(nodes, url)] = noop
+	}
+
+	assert.Len(t, nodes, 3)
+	assert.Contains(t, nodes, "rpc.example.com")
+	assert.Contains(t, nodes, "rpc.example.com#2")
+	assert.Contains(t, nodes, "127.0.0.1:12537")
+}
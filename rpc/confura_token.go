@@ -0,0 +1,237 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// default page size of indexed data query
+const defaultIndexQueryLimit = 100
+
+var errTokenIndexNotEnabled = errors.New("token index not enabled")
+
+// TokenStore store to query the indexed token balances and transfers.
+type TokenStore interface {
+	GetTokenBalances(owner, contract string, cursor uint64, limit int) ([]*mysql.TokenBalance, error)
+	TokenBalanceSince() (uint64, bool, error)
+	GetTokenTransfers(filter mysql.TokenTransferFilter) ([]*mysql.TokenTransfer, error)
+}
+
+// TokenBalanceQuery optional conditions to query token balances.
+type TokenBalanceQuery struct {
+	Contract *common.Address `json:"contract"`
+	Cursor   hexutil.Uint64  `json:"cursor"` // `nextCursor` of the last page
+	Limit    hexutil.Uint64  `json:"limit"`
+}
+
+// TokenTransferQuery optional conditions to query token transfers.
+type TokenTransferQuery struct {
+	Contract  *common.Address `json:"contract"`
+	Direction string          `json:"direction"` // `in`, `out` or empty for both
+	FromBlock hexutil.Uint64  `json:"fromBlock"`
+	ToBlock   hexutil.Uint64  `json:"toBlock"`
+	Cursor    hexutil.Uint64  `json:"cursor"` // `nextCursor` of the last page
+	Limit     hexutil.Uint64  `json:"limit"`
+}
+
+type TokenBalance struct {
+	Contract common.Address `json:"contract"`
+	Standard string         `json:"standard"`
+	TokenId  *hexutil.Big   `json:"tokenId,omitempty"`
+	Balance  *hexutil.Big   `json:"balance"`
+	// whether the balance is clamped to zero, since the token transfers in are missing
+	Partial bool `json:"partial,omitempty"`
+}
+
+type TokenTransfer struct {
+	BlockNumber     hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash common.Hash    `json:"transactionHash"`
+	LogIndex        hexutil.Uint64 `json:"logIndex"`
+	Contract        common.Address `json:"contract"`
+	Standard        string         `json:"standard"`
+	From            common.Address `json:"from"`
+	To              common.Address `json:"to"`
+	TokenId         *hexutil.Big   `json:"tokenId,omitempty"`
+	Value           *hexutil.Big   `json:"value"`
+}
+
+// TokenBalancePage a page of token balances, where `nextCursor` is nil if no more. Note, balances are
+// accumulated from the token transfers since block `since`, which are inaccurate if not from genesis.
+type TokenBalancePage struct {
+	Balances   []*TokenBalance `json:"balances"`
+	NextCursor *hexutil.Uint64 `json:"nextCursor"`
+	Since      *hexutil.Uint64 `json:"since,omitempty"` // nil if accumulated from genesis
+}
+
+// TokenTransferPage a page of token transfers in reverse chronological order, where `nextCursor` is
+// nil if no more.
+type TokenTransferPage struct {
+	Transfers  []*TokenTransfer `json:"transfers"`
+	NextCursor *hexutil.Uint64  `json:"nextCursor"`
+}
+
+// GetTokenBalances returns the ERC-20/721/1155 token balances of the owner address in pages.
+func (api *confuraAPI) GetTokenBalances(
+	ctx context.Context, owner common.Address, query *TokenBalanceQuery,
+) (*TokenBalancePage, error) {
+	if api.TokenStore == nil {
+		return nil, errTokenIndexNotEnabled
+	}
+
+	if query == nil {
+		query = &TokenBalanceQuery{}
+	}
+
+	var contract string
+	if query.Contract != nil {
+		contract = normalizeIndexAddress(*query.Contract)
+	}
+
+	limit := normalizeIndexQueryLimit(query.Limit, mysql.MaxTokenQueryLimit)
+
+	balances, err := api.TokenStore.GetTokenBalances(
+		normalizeIndexAddress(owner), contract, uint64(query.Cursor), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	since, ok, err := api.TokenStore.TokenBalanceSince()
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TokenBalancePage{Balances: make([]*TokenBalance, 0, len(balances))}
+	if ok && since > 0 {
+		page.Since = (*hexutil.Uint64)(&since)
+	}
+
+	for _, b := range balances {
+		balance, clamped, err := b.ClampedBalance()
+		if err != nil {
+			return nil, err
+		}
+
+		page.Balances = append(page.Balances, &TokenBalance{
+			Contract: common.HexToAddress(b.Contract),
+			Standard: b.Standard,
+			TokenId:  parseIndexDecimal(b.TokenId),
+			Balance:  (*hexutil.Big)(balance),
+			Partial:  clamped,
+		})
+	}
+
+	if len(balances) == limit {
+		cursor := hexutil.Uint64(balances[len(balances)-1].ID)
+		page.NextCursor = &cursor
+	}
+
+	return page, nil
+}
+
+// GetTokenTransfers returns the ERC-20/721/1155 token transfers from or to the address in pages.
+func (api *confuraAPI) GetTokenTransfers(
+	ctx context.Context, address common.Address, query *TokenTransferQuery,
+) (*TokenTransferPage, error) {
+	if api.TokenStore == nil {
+		return nil, errTokenIndexNotEnabled
+	}
+
+	if query == nil {
+		query = &TokenTransferQuery{}
+	}
+
+	if err := validateIndexQuery(query.Direction, query.FromBlock, query.ToBlock); err != nil {
+		return nil, err
+	}
+
+	filter := mysql.TokenTransferFilter{
+		Address:   normalizeIndexAddress(address),
+		Direction: query.Direction,
+		FromBlock: uint64(query.FromBlock),
+		ToBlock:   uint64(query.ToBlock),
+		Cursor:    uint64(query.Cursor),
+		Limit:     normalizeIndexQueryLimit(query.Limit, mysql.MaxTokenQueryLimit),
+	}
+
+	if query.Contract != nil {
+		filter.Contract = normalizeIndexAddress(*query.Contract)
+	}
+
+	transfers, err := api.TokenStore.GetTokenTransfers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TokenTransferPage{Transfers: make([]*TokenTransfer, 0, len(transfers))}
+	for _, t := range transfers {
+		page.Transfers = append(page.Transfers, &TokenTransfer{
+			BlockNumber:     hexutil.Uint64(t.Bn),
+			TransactionHash: common.HexToHash(t.TxHash),
+			LogIndex:        hexutil.Uint64(t.LogIndex),
+			Contract:        common.HexToAddress(t.Contract),
+			Standard:        t.Standard,
+			From:            common.HexToAddress(t.From),
+			To:              common.HexToAddress(t.To),
+			TokenId:         parseIndexDecimal(t.TokenId),
+			Value:           parseIndexDecimal(t.Value),
+		})
+	}
+
+	if len(transfers) == filter.Limit {
+		cursor := hexutil.Uint64(transfers[len(transfers)-1].ID)
+		page.NextCursor = &cursor
+	}
+
+	return page, nil
+}
+
+func normalizeIndexAddress(addr common.Address) string {
+	return strings.ToLower(addr.Hex())
+}
+
+// validateIndexQuery validates the direction and block range of indexed data query.
+func validateIndexQuery(direction string, fromBlock, toBlock hexutil.Uint64) error {
+	switch direction {
+	case "", "in", "out":
+	default:
+		return errors.Errorf("invalid direction %v, expected `in` or `out`", direction)
+	}
+
+	if toBlock > 0 && fromBlock > toBlock {
+		return errors.New("invalid block range, fromBlock is greater than toBlock")
+	}
+
+	return nil
+}
+
+// normalizeIndexQueryLimit returns the default page size if limit not specified, otherwise the
+// limit capped at max page size.
+func normalizeIndexQueryLimit(limit hexutil.Uint64, maxLimit int) int {
+	if limit == 0 {
+		return defaultIndexQueryLimit
+	}
+
+	if limit > hexutil.Uint64(maxLimit) {
+		return maxLimit
+	}
+
+	return int(limit)
+}
+
+// parseIndexDecimal parses the persisted decimal string, or returns nil if empty (eg., token id of
+// ERC-20 transfer).
+func parseIndexDecimal(val string) *hexutil.Big {
+	v, ok := new(big.Int).SetString(val, 10)
+	if !ok {
+		return nil
+	}
+
+	return (*hexutil.Big)(v)
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTokenStore struct {
+	balances []*mysql.TokenBalance
+	since    uint64
+}
+
+func (s *mockTokenStore) GetTokenBalances(
+	owner, contract string, cursor uint64, limit int,
+) ([]*mysql.TokenBalance, error) {
+	return s.balances, nil
+}
+
+func (s *mockTokenStore) TokenBalanceSince() (uint64, bool, error) {
+	return s.since, true, nil
+}
+
+func (s *mockTokenStore) GetTokenTransfers(filter mysql.TokenTransferFilter) ([]*mysql.TokenTransfer, error) {
+	return nil, nil
+}
+
+func TestGetTokenBalancesPartial(t *testing.T) {
+	token := "0x00000000000000000000000000000000000000c0"
+	store := &mockTokenStore{balances: []*mysql.TokenBalance{
+		{ID: 1, Contract: token, Standard: mysql.TokenStandardERC20, Balance: "100"},
+		{ID: 2, Contract: token, Standard: mysql.TokenStandardERC20, Balance: "-30"},
+	}}
+
+	api := &confuraAPI{confuraAPIOption: confuraAPIOption{TokenStore: store}}
+	owner := common.HexToAddress("0x0000000000000000000000000000000000000a11")
+
+	// accumulated from genesis
+	page, err := api.GetTokenBalances(context.Background(), owner, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, page.Since)
+	assert.Equal(t, 2, len(page.Balances))
+	assert.Equal(t, (*hexutil.Big)(big.NewInt(100)), page.Balances[0].Balance)
+	assert.False(t, page.Balances[0].Partial)
+
+	// negative balance clamped and flagged
+	assert.Equal(t, (*hexutil.Big)(big.NewInt(0)), page.Balances[1].Balance)
+	assert.True(t, page.Balances[1].Partial)
+
+	// accumulated from non-genesis block
+	store.since = 1000
+	page, err = api.GetTokenBalances(context.Background(), owner, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(1000), *page.Since)
+}
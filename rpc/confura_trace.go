@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+var errTraceIndexNotEnabled = errors.New("internal transaction index not enabled")
+
+// InternalTxStore store to query the indexed internal transactions.
+type InternalTxStore interface {
+	GetInternalTxs(filter mysql.InternalTxFilter) ([]*mysql.InternalTx, error)
+}
+
+// InternalTxQuery conditions to query internal transactions, where either transaction hash or
+// address is required.
+type InternalTxQuery struct {
+	TransactionHash *common.Hash    `json:"transactionHash"`
+	Address         *common.Address `json:"address"`
+	Direction       string          `json:"direction"` // `in`, `out` or empty for both
+	FromBlock       hexutil.Uint64  `json:"fromBlock"`
+	ToBlock         hexutil.Uint64  `json:"toBlock"`
+	Cursor          hexutil.Uint64  `json:"cursor"` // `nextCursor` of the last page
+	Limit           hexutil.Uint64  `json:"limit"`
+}
+
+type InternalTx struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	TraceAddress     string         `json:"traceAddress"`
+	Type             string         `json:"type"`
+	CallType         string         `json:"callType,omitempty"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+	Error            string         `json:"error,omitempty"`
+}
+
+// InternalTxPage a page of internal transactions in reverse chronological order, where `nextCursor`
+// is nil if no more.
+type InternalTxPage struct {
+	InternalTransactions []*InternalTx   `json:"internalTransactions"`
+	NextCursor           *hexutil.Uint64 `json:"nextCursor"`
+}
+
+// GetInternalTransactions returns the internal transactions (nested calls, contract creations and
+// self destructs) of a transaction or an address in pages.
+func (api *confuraAPI) GetInternalTransactions(ctx context.Context, query InternalTxQuery) (*InternalTxPage, error) {
+	if api.InternalTxStore == nil {
+		return nil, errTraceIndexNotEnabled
+	}
+
+	if query.TransactionHash == nil && query.Address == nil {
+		return nil, errors.New("either transactionHash or address is required")
+	}
+
+	if err := validateIndexQuery(query.Direction, query.FromBlock, query.ToBlock); err != nil {
+		return nil, err
+	}
+
+	filter := mysql.InternalTxFilter{
+		Direction: query.Direction,
+		FromBlock: uint64(query.FromBlock),
+		ToBlock:   uint64(query.ToBlock),
+		Cursor:    uint64(query.Cursor),
+		Limit:     normalizeIndexQueryLimit(query.Limit, mysql.MaxInternalTxQueryLimit),
+	}
+
+	if query.TransactionHash != nil {
+		filter.TxHash = query.TransactionHash.Hex()
+	}
+
+	if query.Address != nil {
+		filter.Address = normalizeIndexAddress(*query.Address)
+	}
+
+	txs, err := api.InternalTxStore.GetInternalTxs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &InternalTxPage{InternalTransactions: make([]*InternalTx, 0, len(txs))}
+	for _, tx := range txs {
+		page.InternalTransactions = append(page.InternalTransactions, &InternalTx{
+			BlockNumber:      hexutil.Uint64(tx.Bn),
+			TransactionHash:  common.HexToHash(tx.TxHash),
+			TransactionIndex: hexutil.Uint64(tx.TxIndex),
+			TraceAddress:     tx.TraceAddress,
+			Type:             tx.Type,
+			CallType:         tx.CallType,
+			From:             common.HexToAddress(tx.From),
+			To:               common.HexToAddress(tx.To),
+			Value:            parseIndexDecimal(tx.Value),
+			Error:            tx.Error,
+		})
+	}
+
+	if len(txs) == filter.Limit {
+		cursor := hexutil.Uint64(txs[len(txs)-1].ID)
+		page.NextCursor = &cursor
+	}
+
+	return page, nil
+}
@@ -13,3 +13,9 @@ type debugAPI struct{}
 func (api *debugAPI) TopkStats(ctx context.Context, k int) ([]metrics.Visitor, error) {
 	return metrics.DefaultTrafficCollector().TopkVisitors(k), nil
 }
+
+// StoreHitStats returns the store hit statistics of event logs queries within the last hour for
+// topK access keys (or IP addresses) and log filter shapes with the most queries.
+func (api *debugAPI) StoreHitStats(ctx context.Context, k int) ([]metrics.StoreHitStat, error) {
+	return metrics.DefaultStoreHitCollector().TopkStats(k), nil
+}
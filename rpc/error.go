@@ -2,9 +2,25 @@ package rpc
 
 import (
 	"github.com/Conflux-Chain/confura/store"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/pkg/errors"
 )
 
+// signer-only RPC methods, which are not supported by gateway since no account is managed
+var gatewayUnsupportedMethods = []string{
+	"cfx_accounts", "cfx_sign", "cfx_signTransaction", "cfx_sendTransaction",
+	"eth_accounts", "eth_sign", "eth_signTransaction", "eth_sendTransaction", "eth_signTypedData_v4",
+}
+
+// errMethodUnsupportedByGateway returns standardized error for signer-only RPC methods rather
+// than upstream fullnode dependent behavior, so that wallet SDKs could degrade gracefully.
+func errMethodUnsupportedByGateway(method string) error {
+	return rpcutil.NewCodedError(
+		rpcutil.ErrCodeMethodUnsupported,
+		errors.Errorf("method %v not supported by gateway, please use a wallet to manage accounts", method),
+	)
+}
+
 // rpc errors conform to fullnode
 
 var (
@@ -54,3 +70,7 @@ func ErrExceedLogFilterTopicLimit(size int) error {
 		store.MaxLogFilterTopicCount, size,
 	)
 }
+
+func ErrInvalidRewardPercentile(percentile float64) error {
+	return errors.Errorf("invalid reward percentile: %v", percentile)
+}
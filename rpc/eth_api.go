@@ -1,28 +1,52 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/cache"
 	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/rpc/middlewares"
+	"github.com/Conflux-Chain/confura/util/txpool"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/openweb3/web3go"
 	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	rpcMethodEthGetLogs = "eth_getLogs"
+
+	// max number of blocks to query fee history, as geth does
+	maxFeeHistoryBlockCount = 1024
+
+	// max number of known externally owned accounts to cache empty code
+	eoaCodeCacheSize = 100_000
+	// duration to trust the empty code of known externally owned account verified with fullnode,
+	// since it could be delegated to contract code via EIP-7702 authorization at any time
+	eoaCodeCacheTTL = time.Minute
 )
 
 var (
 	ethEmptyLogs = []web3Types.Log{}
+
+	// prefix of delegation designator code set for accounts by EIP-7702 authorization
+	eip7702DelegationPrefix = []byte{0xef, 0x01, 0x00}
 )
 
 type EthAPIOption struct {
@@ -30,6 +54,26 @@ type EthAPIOption struct {
 	LogApiHandler       *handler.EthLogsApiHandler
 	TxnHandler          *handler.EthTxnHandler
 	VirtualFilterClient *vfclient.EthClient
+	LogsJobManager      *handler.EthLogsJobManager
+	BlockCache          *cache.TieredCache
+	TraceHandler        *handler.TraceHandler
+	GasStationHandler   *handler.GasStationHandler
+	LogSpanLimiter      *handler.LogSpanLimiter
+	TxTracker           *txpool.Tracker
+	NonceTracker        *txpool.NonceTracker
+	RelayCache          *txpool.RelayCache
+	LogsBackfill        *EthLogsBackfillConfig
+	PubSubFailover      *EthPubSubFailoverConfig
+	LocalAnswerer       *EthLocalAnswerer
+	StatusReporter      *StatusReporter
+	HeadStore           HeadStore // store to resolve `latest` block in consistent read mode
+	StaleCache          *cache.StaleCache
+	CallCache           *cache.CallCache
+	IdempotencyCache    *cache.IdempotencyCache // cache to dedup retries of `eth_sendRawTransaction`
+	TokenStore          TokenStore              // store to query indexed token balances and transfers
+	AddressTxStore      AddressTxStore          // store to query indexed transactions by address
+	InternalTxStore     InternalTxStore         // store to query indexed internal transactions
+	ContractIndexStore  ContractIndexStore      // store to query indexed contract creations
 }
 
 // ethAPI provides Ethereum relative API within evm space according to:
@@ -40,35 +84,69 @@ type ethAPI struct {
 	provider         *node.EthClientProvider
 	inputBlockMetric metrics.InputBlockMetric
 
+	// chain parameters resolved by chain id, which are loaded lazily from fullnode if not answered
+	// locally, so as not to block server startup in bootstrap mode
+	chainParams atomic.Value // *ethChainParams
+
+	// known externally owned accounts whose code verified empty at the latest block
+	eoaCodes *util.ExpirableLruCache
+}
+
+// ethChainParams chain specific parameters resolved by chain id.
+type ethChainParams struct {
 	// return empty data before eSpace hardfork block number
 	hardforkBlockNumber web3Types.BlockNumber
+	// signer to recover sender of the relayed transactions
+	signer gethTypes.Signer
 }
 
-func mustNewEthAPI(provider *node.EthClientProvider, option ...EthAPIOption) *ethAPI {
-	client, err := provider.GetClientRandom()
-	if err != nil {
-		logrus.WithError(err).Fatal("Failed to get eth client randomly")
+func newEthChainParams(chainId uint64) *ethChainParams {
+	return &ethChainParams{
+		hardforkBlockNumber: util.GetEthHardforkBlockNumber(chainId),
+		signer:              gethTypes.LatestSignerForChainID(new(big.Int).SetUint64(chainId)),
 	}
+}
 
-	chainId, err := client.Eth.ChainId()
-	if err != nil {
-		logrus.WithError(err).Fatal("Failed to get eth chain id")
+func newEthAPI(provider *node.EthClientProvider, option ...EthAPIOption) *ethAPI {
+	var opt EthAPIOption
+	if len(option) > 0 {
+		opt = option[0]
 	}
 
-	if chainId == nil {
-		logrus.Fatal("chain id on eSpace is nil")
+	api := &ethAPI{
+		EthAPIOption: opt,
+		provider:     provider,
+		eoaCodes:     util.NewExpirableLruCache(eoaCodeCacheSize, eoaCodeCacheTTL),
 	}
 
-	var opt EthAPIOption
-	if len(option) > 0 {
-		opt = option[0]
+	// chain id already validated against upstream fullnodes if answered locally
+	if opt.LocalAnswerer != nil {
+		api.chainParams.Store(newEthChainParams(uint64(*opt.LocalAnswerer.ChainId())))
 	}
 
-	return &ethAPI{
-		EthAPIOption:        opt,
-		provider:            provider,
-		hardforkBlockNumber: util.GetEthHardforkBlockNumber(*chainId),
+	return api
+}
+
+// getChainParams returns the chain parameters, which are resolved by the chain id of the specified
+// fullnode at the first time if not answered locally.
+func (api *ethAPI) getChainParams(w3c *web3go.Client) (*ethChainParams, error) {
+	if val := api.chainParams.Load(); val != nil {
+		return val.(*ethChainParams), nil
 	}
+
+	chainId, err := cache.EthDefault.GetChainId(w3c)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get chain id")
+	}
+
+	if chainId == nil {
+		return nil, errors.New("chain id on eSpace is nil")
+	}
+
+	params := newEthChainParams(uint64(*chainId))
+	api.chainParams.Store(params)
+
+	return params, nil
 }
 
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in
@@ -82,9 +160,30 @@ func (api *ethAPI) GetBlockByHash(
 		"blockHash": blockHash.Hex(), "includeTxs": fullTx,
 	})
 
+	// block is immutable by hash, so it's safe to be cached
+	cacheKey := fmt.Sprintf("block:%v:%v", blockHash.Hex(), fullTx)
+	if api.BlockCache != nil {
+		var block web3Types.Block
+		if api.BlockCache.Get(cacheKey, &block) {
+			logger.Debug("Loading eth data for eth_getBlockByHash hit in the cache")
+			return &block, nil
+		}
+	}
+
+	block, err := api.getBlockByHash(ctx, blockHash, fullTx, logger)
+	if err == nil && block != nil && api.BlockCache != nil {
+		api.BlockCache.Set(cacheKey, block)
+	}
+
+	return block, err
+}
+
+func (api *ethAPI) getBlockByHash(
+	ctx context.Context, blockHash common.Hash, fullTx bool, logger *logrus.Entry,
+) (*web3Types.Block, error) {
 	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByHash(ctx, blockHash, fullTx)
-		metrics.Registry.RPC.StoreHit("eth_getBlockByHash", "store").Mark(err == nil)
+		collectStoreHitStats(ctx, "eth_getBlockByHash", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getBlockByHash hit in the store")
 			return block, nil
@@ -100,6 +199,10 @@ func (api *ethAPI) GetBlockByHash(
 
 // ChainId returns the chainID value for transaction replay protection.
 func (api *ethAPI) ChainId(ctx context.Context) (*hexutil.Uint64, error) {
+	if api.LocalAnswerer != nil {
+		return api.LocalAnswerer.ChainId(), nil
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	return cache.EthDefault.GetChainId(w3c.Client)
 }
@@ -117,15 +220,17 @@ func (api *ethAPI) GetBalance(
 ) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_getBalance", w3c.Eth)
+
+	blockNumOrHash = handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash)
 	balance, err := w3c.Eth.Balance(address, blockNumOrHash)
 	return (*hexutil.Big)(balance), err
 }
 
 // GetBlockByNumber returns the requested canonical block.
-// * When blockNr is -1 the chain head is returned.
-// * When blockNr is -2 the pending chain head is returned.
-// * When fullTx is true all transactions in the block are returned, otherwise
-//   only the transaction hash is returned.
+//   - When blockNr is -1 the chain head is returned.
+//   - When blockNr is -2 the pending chain head is returned.
+//   - When fullTx is true all transactions in the block are returned, otherwise
+//     only the transaction hash is returned.
 func (api *ethAPI) GetBlockByNumber(
 	ctx context.Context, blockNum web3Types.BlockNumber, fullTx bool,
 ) (*web3Types.Block, error) {
@@ -139,8 +244,11 @@ func (api *ethAPI) GetBlockByNumber(
 	api.inputBlockMetric.Update1(&blockNum, "eth_getBlockByNumber", w3c.Eth)
 
 	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+		// resolve block tag, so that store and fullnode are queried consistently
+		blockNum = handler.ResolveEthBlockTag(w3c, blockNum)
+
 		block, err := api.StoreHandler.GetBlockByNumber(ctx, &blockNum, fullTx)
-		metrics.Registry.RPC.StoreHit("eth_getBlockByNumber", "store").Mark(err == nil)
+		collectStoreHitStats(ctx, "eth_getBlockByNumber", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getBlockByNumber hit in the store")
 			return block, nil
@@ -190,6 +298,8 @@ func (api *ethAPI) GetStorageAt(
 ) (common.Hash, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_getStorageAt", w3c.Eth)
+
+	blockNumOrHash = handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash)
 	return w3c.Eth.StorageAt(address, (*big.Int)(location), blockNumOrHash)
 }
 
@@ -200,7 +310,73 @@ func (api *ethAPI) GetCode(
 ) (hexutil.Bytes, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_getCode", w3c.Eth)
-	return w3c.Eth.CodeAt(account, blockNumOrHash)
+
+	// short-circuit for externally owned accounts at the latest block, whose code verified empty
+	// recently, since EOA could be delegated to contract code via EIP-7702 authorization
+	if !isLatestBlockParam(blockNumOrHash) {
+		return w3c.Eth.CodeAt(account, handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash))
+	}
+
+	if _, ok := api.eoaCodes.Get(account); ok {
+		metrics.Registry.RPC.Percentage("eth_getCode", "eoa/hit").Mark(true)
+		return hexutil.Bytes{}, nil
+	}
+
+	code, err := w3c.Eth.CodeAt(account, handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash))
+	if err != nil || len(code) > 0 && !isEip7702Delegation(code) {
+		return code, err
+	}
+
+	if api.isKnownEoa(w3c, account) {
+		metrics.Registry.RPC.Percentage("eth_getCode", "eoa/hit").Mark(false)
+
+		delegated := len(code) > 0
+		metrics.Registry.RPC.Percentage("eth_getCode", "eoa/delegated").Mark(delegated)
+
+		// delegated account is never cached, so that code always queried from fullnode
+		if !delegated {
+			api.eoaCodes.Add(account, struct{}{})
+		}
+	}
+
+	return code, nil
+}
+
+// isKnownEoa checks if the account is known as externally owned account, which has ever sent any
+// transaction at finalized heights, so that it will never be reverted due to chain reorg.
+func (api *ethAPI) isKnownEoa(w3c *node.Web3goClient, account common.Address) bool {
+	if api.ContractIndexStore == nil {
+		return false
+	}
+
+	bn, ok, err := api.ContractIndexStore.GetExternallyOwnedAccount(normalizeIndexAddress(account))
+	if err != nil || !ok {
+		return false
+	}
+
+	finalized, err := handler.ResolveEthBlock(w3c, web3Types.FinalizedBlockNumber)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to get finalized block number for eth_getCode")
+		return false
+	}
+
+	return bn <= finalized
+}
+
+// isLatestBlockParam checks if the block param is omitted or refers to the `latest` block.
+func isLatestBlockParam(blockNumOrHash *web3Types.BlockNumberOrHash) bool {
+	if blockNumOrHash == nil {
+		return true
+	}
+
+	bn, ok := blockNumOrHash.Number()
+	return ok && bn == web3Types.LatestBlockNumber
+}
+
+// isEip7702Delegation checks if the code is the delegation designator of EIP-7702, which is set
+// for externally owned account to delegate to contract code.
+func isEip7702Delegation(code []byte) bool {
+	return bytes.HasPrefix(code, eip7702DelegationPrefix)
 }
 
 // GetTransactionCount returns the number of transactions (nonce) sent from the given account.
@@ -210,7 +386,19 @@ func (api *ethAPI) GetTransactionCount(
 ) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_getTransactionCount", w3c.Eth)
-	count, err := w3c.Eth.TransactionCount(account, blockNumOrHash)
+
+	// note, `pending` block is never resolved, which is accelerated below
+	count, err := w3c.Eth.TransactionCount(account, handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash))
+	if err != nil || count == nil || !count.IsUint64() {
+		return (*hexutil.Big)(count), err
+	}
+
+	// accelerate pending nonce with the transactions relayed through gateway, in case of lagging fullnode
+	if blockNumOrHash != nil && blockNumOrHash.BlockNumber != nil && *blockNumOrHash.BlockNumber == web3Types.PendingBlockNumber {
+		nonce := api.NonceTracker.PendingNonce(account.Hex(), count.Uint64())
+		count = new(big.Int).SetUint64(nonce)
+	}
+
 	return (*hexutil.Big)(count), err
 }
 
@@ -219,14 +407,164 @@ func (api *ethAPI) GetTransactionCount(
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (api *ethAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (common.Hash, error) {
+	key, ok := api.idempotencyKey(ctx)
+	if !ok {
+		return api.sendRawTransaction(ctx, signedTx)
+	}
+
+	result, cached, err := api.IdempotencyCache.Do(ctx, key, signedTx, func() (interface{}, error) {
+		return api.sendRawTransaction(ctx, signedTx)
+	})
+
+	if cached {
+		metrics.Registry.RPC.IdempotentReplays("eth_sendRawTransaction").Mark(1)
+	}
+
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return result.(common.Hash), nil
+}
+
+// idempotencyKey returns the idempotency key specified by client, which is scoped by API key or
+// client IP to avoid collision among clients, or false if not specified or not enabled.
+func (api *ethAPI) idempotencyKey(ctx context.Context) (string, bool) {
+	if api.IdempotencyCache == nil {
+		return "", false
+	}
+
+	key, ok := handlers.GetIdempotencyKeyFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	scope, ok := handlers.GetAccessTokenFromContext(ctx)
+	if !ok || len(scope) == 0 {
+		scope, _ = handlers.GetIPAddressFromContext(ctx)
+	}
+
+	return scope + "/" + key, true
+}
+
+func (api *ethAPI) sendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (common.Hash, error) {
 	w3c := GetEthClientFromContext(ctx)
 
+	var txHash common.Hash
+	var err error
+
 	if api.TxnHandler != nil {
 		cgroup := GetClientGroupFromContext(ctx)
-		return api.TxnHandler.SendRawTxn(w3c, cgroup, signedTx)
+		txHash, err = api.TxnHandler.SendRawTxn(w3c, cgroup, signedTx)
+	} else {
+		txHash, err = w3c.Eth.SendRawTransaction(signedTx)
+	}
+
+	// track pending transaction to detect the stuck or nonce gapped ones
+	if err == nil {
+		api.TxTracker.Track(txHash.Hex(), signedTx)
+		api.RelayCache.Add(txHash.Hex(), w3c.URL, signedTx)
+		api.relayNonce(w3c, signedTx)
+	}
+
+	return txHash, err
+}
+
+// relayNonce records the nonce of relayed transaction to accelerate the pending nonce of sender.
+func (api *ethAPI) relayNonce(w3c *node.Web3goClient, signedTx hexutil.Bytes) {
+	if api.NonceTracker == nil {
+		return
+	}
+
+	var tx gethTypes.Transaction
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		return
+	}
+
+	params, err := api.getChainParams(w3c.Client)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to get chain params to recover sender of relayed transaction")
+		return
+	}
+
+	sender, err := gethTypes.Sender(params.signer, &tx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to recover sender of relayed transaction")
+		return
+	}
+
+	api.NonceTracker.Relay(sender.Hex(), tx.Nonce())
+}
+
+// relayedClient returns client of the fullnode which accepted the relayed transaction for
+// read-your-writes, or the routed one if not relayed through gateway or not available.
+func (api *ethAPI) relayedClient(ctx context.Context, txHash common.Hash) (*node.Web3goClient, *txpool.RelayedTx) {
+	w3c := GetEthClientFromContext(ctx)
+
+	relayed, ok := api.RelayCache.Get(txHash.Hex())
+	if !ok || relayed.Node == w3c.URL {
+		return w3c, relayed
+	}
+
+	client, err := api.provider.GetClientByURL(relayed.Node, GetClientGroupFromContext(ctx))
+	if err != nil {
+		logrus.WithField("node", relayed.Node).
+			WithError(err).
+			Debug("Failed to get client of the fullnode which accepted relayed transaction")
+		return w3c, relayed
 	}
 
-	return w3c.Eth.SendRawTransaction(signedTx)
+	return client.WithDeadline(ctx), relayed
+}
+
+// pendingTxFromRelay builds the pending transaction from the relayed raw transaction.
+func (api *ethAPI) pendingTxFromRelay(
+	w3c *node.Web3goClient, relayed *txpool.RelayedTx,
+) (*web3Types.TransactionDetail, error) {
+	var tx gethTypes.Transaction
+	if err := tx.UnmarshalBinary(relayed.SignedTx); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode relayed transaction")
+	}
+
+	params, err := api.getChainParams(w3c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := gethTypes.Sender(params.signer, &tx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to recover sender of relayed transaction")
+	}
+
+	v, r, s := tx.RawSignatureValues()
+	txType := uint64(tx.Type())
+
+	detail := web3Types.TransactionDetail{
+		From:     sender,
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Hash:     tx.Hash(),
+		Input:    tx.Data(),
+		Nonce:    tx.Nonce(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Type:     &txType,
+		V:        v,
+		R:        r,
+		S:        s,
+	}
+
+	if tx.Type() != gethTypes.LegacyTxType {
+		detail.ChainID = tx.ChainId()
+		detail.Accesses = tx.AccessList()
+	}
+
+	if tx.Type() == gethTypes.DynamicFeeTxType {
+		detail.MaxFeePerGas = tx.GasFeeCap()
+		detail.MaxPriorityFeePerGas = tx.GasTipCap()
+	}
+
+	return &detail, nil
 }
 
 // SubmitTransaction is an alias of `SendRawTransaction` method.
@@ -240,7 +578,58 @@ func (api *ethAPI) Call(
 ) (hexutil.Bytes, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_call", w3c.Eth)
-	return w3c.Eth.Call(request, blockNumOrHash)
+
+	// resolve block tag before cache lookup, e.g., `finalized` block is cacheable once resolved
+	blockNumOrHash = handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash)
+
+	cacheKey, cacheable := api.callCacheKey(w3c, request, blockNumOrHash)
+	if cacheable {
+		result, ok := api.CallCache.Get(cacheKey)
+		metrics.Registry.RPC.Percentage("eth_call", "cache/hit").Mark(ok)
+
+		if ok {
+			return result, nil
+		}
+	}
+
+	result, err := w3c.Eth.Call(request, blockNumOrHash)
+	if err == nil && cacheable {
+		api.CallCache.Set(cacheKey, result)
+	}
+
+	return result, err
+}
+
+// callCacheKey returns the cache key of `eth_call`, or false if not cacheable, e.g., at `latest` or
+// `pending` block, by block hash or above the finalized block.
+func (api *ethAPI) callCacheKey(
+	w3c *node.Web3goClient, request web3Types.CallRequest, blockNumOrHash *web3Types.BlockNumberOrHash,
+) (string, bool) {
+	if api.CallCache == nil || blockNumOrHash == nil || blockNumOrHash.BlockNumber == nil {
+		return "", false
+	}
+
+	blockNum := *blockNumOrHash.BlockNumber
+	if blockNum < 0 { // block tag
+		return "", false
+	}
+
+	finalized, err := handler.ResolveEthBlock(w3c, web3Types.FinalizedBlockNumber)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to get finalized block number for eth_call cache")
+		return "", false
+	}
+
+	if uint64(blockNum) > finalized {
+		return "", false
+	}
+
+	key, err := cache.CallCacheKey(uint64(blockNum), request)
+	if err != nil {
+		return "", false
+	}
+
+	return key, true
 }
 
 // EstimateGas generates and returns an estimate of how much gas is necessary to allow the transaction
@@ -252,6 +641,8 @@ func (api *ethAPI) EstimateGas(
 ) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_estimateGas", w3c.Eth)
+
+	blockNumOrHash = handler.ResolveEthBlockNumberOrHash(w3c, blockNumOrHash)
 	gas, err := w3c.Eth.EstimateGas(request, blockNumOrHash)
 	return (*hexutil.Big)(gas), err
 }
@@ -262,7 +653,7 @@ func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (
 
 	if !store.EthStoreConfig().IsChainTxnDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		tx, err := api.StoreHandler.GetTransactionByHash(ctx, hash)
-		metrics.Registry.RPC.StoreHit("eth_getTransactionByHash", "store").Mark(err == nil)
+		collectStoreHitStats(ctx, "eth_getTransactionByHash", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getTransactionByHash hit in the store")
 			return tx, nil
@@ -273,8 +664,16 @@ func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (
 
 	logger.Debug("Delegating eth_getTransactionByHash rpc request to fullnode")
 
-	w3c := GetEthClientFromContext(ctx)
-	return w3c.Eth.TransactionByHash(hash)
+	// read-your-writes for the transaction relayed through gateway
+	w3c, relayed := api.relayedClient(ctx, hash)
+
+	tx, err := w3c.Eth.TransactionByHash(hash)
+	if err == nil && tx == nil && relayed != nil {
+		logger.Debug("Answering eth_getTransactionByHash from the relay cache")
+		return api.pendingTxFromRelay(w3c, relayed)
+	}
+
+	return tx, err
 }
 
 // TransactionReceipt returns the receipt of a transaction by transaction hash.
@@ -284,7 +683,7 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 
 	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		tx, err := api.StoreHandler.GetTransactionReceipt(ctx, txHash)
-		metrics.Registry.RPC.StoreHit("eth_getTransactionReceipt", "store").Mark(err == nil)
+		collectStoreHitStats(ctx, "eth_getTransactionReceipt", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getTransactionReceipt hit in the ethstore")
 			return tx, nil
@@ -295,7 +694,8 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 
 	logger.Debug("Delegating eth_getTransactionReceipt rpc request to fullnode")
 
-	w3c := GetEthClientFromContext(ctx)
+	// read-your-writes for the transaction relayed through gateway
+	w3c, _ := api.relayedClient(ctx, txHash)
 	receipt, err := w3c.Eth.TransactionReceipt(txHash)
 	if err != nil {
 		metrics.Registry.RPC.Percentage("eth_getTransactionReceipt", "notfound").Mark(receipt == nil)
@@ -304,10 +704,168 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 	return receipt, err
 }
 
+// GetBlockReceipts returns all the transaction receipts of the given block.
+func (api *ethAPI) GetBlockReceipts(
+	ctx context.Context, blockNumOrHash web3Types.BlockNumberOrHash,
+) ([]*web3Types.Receipt, error) {
+	logger := logrus.WithField("blockNumOrHash", blockNumOrHash)
+
+	w3c := GetEthClientFromContext(ctx)
+	api.inputBlockMetric.Update2(&blockNumOrHash, "eth_getBlockReceipts", w3c.Eth)
+
+	// resolve block tag, so that store and fullnode are queried consistently
+	blockNumOrHash = *handler.ResolveEthBlockNumberOrHash(w3c, &blockNumOrHash)
+
+	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+		receipts, err := api.StoreHandler.GetBlockReceipts(ctx, &blockNumOrHash)
+		collectStoreHitStats(ctx, "eth_getBlockReceipts", err == nil)
+		if err == nil {
+			logger.Debug("Loading eth data for eth_getBlockReceipts hit in the ethstore")
+			return receipts, nil
+		}
+
+		logger.WithError(err).Debug("Loading eth data for eth_getBlockReceipts missed from the ethstore")
+	}
+
+	logger.Debug("Delegating eth_getBlockReceipts rpc request to fullnode")
+
+	var block *web3Types.Block
+	var err error
+
+	if blockNumOrHash.BlockHash != nil {
+		block, err = w3c.Eth.BlockByHash(*blockNumOrHash.BlockHash, false)
+	} else if blockNumOrHash.BlockNumber != nil {
+		block, err = w3c.Eth.BlockByNumber(*blockNumOrHash.BlockNumber, false)
+	} else {
+		block, err = w3c.Eth.BlockByNumber(web3Types.LatestBlockNumber, false)
+	}
+
+	if err != nil || block == nil {
+		return nil, err
+	}
+
+	// fetch receipts from fullnode one by one
+	txHashes := block.Transactions.Hashes()
+	receipts := make([]*web3Types.Receipt, 0, len(txHashes))
+
+	for _, txHash := range txHashes {
+		receipt, err := w3c.Eth.TransactionReceipt(txHash)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get receipt of tx %v", txHash)
+		}
+
+		if receipt != nil {
+			receipts = append(receipts, receipt)
+		}
+	}
+
+	return receipts, nil
+}
+
+// FeeHistory returns the base fees, gas used ratios and priority fee rewards at the requested
+// percentiles of a range of blocks. It's computed from store, and only the blocks not synced
+// into store are delegated to fullnode.
+func (api *ethAPI) FeeHistory(
+	ctx context.Context, blockCount hexutil.Uint64, newestBlock web3Types.BlockNumber, rewardPercentiles []float64,
+) (*citypes.FeeHistory, error) {
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 || (i > 0 && p < rewardPercentiles[i-1]) {
+			return nil, ErrInvalidRewardPercentile(p)
+		}
+	}
+
+	w3c := GetEthClientFromContext(ctx)
+	api.inputBlockMetric.Update1(&newestBlock, "eth_feeHistory", w3c.Eth)
+
+	if blockCount == 0 {
+		return &citypes.FeeHistory{OldestBlock: (*hexutil.Big)(big.NewInt(0))}, nil
+	}
+
+	if blockCount > maxFeeHistoryBlockCount {
+		blockCount = maxFeeHistoryBlockCount
+	}
+
+	// resolve block tag consistently with other handlers
+	newestBlock = handler.ResolveEthBlockTag(w3c, newestBlock)
+
+	params, err := api.getChainParams(w3c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	newestBlockNum, err := util.NormalizeEthBlockNumber(w3c.Client, &newestBlock, params.hardforkBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	newest := uint64(*newestBlockNum)
+	if uint64(blockCount) > newest+1 {
+		blockCount = hexutil.Uint64(newest + 1)
+	}
+
+	oldest := newest + 1 - uint64(blockCount)
+	logger := logrus.WithFields(logrus.Fields{
+		"oldest": oldest, "newest": newest, "rewardPercentiles": rewardPercentiles,
+	})
+
+	history := &citypes.FeeHistory{OldestBlock: (*hexutil.Big)(new(big.Int).SetUint64(oldest))}
+
+	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+		shistory, err := api.StoreHandler.FeeHistory(ctx, oldest, newest, rewardPercentiles)
+		collectStoreHitStats(ctx, "eth_feeHistory", err == nil)
+
+		if err == nil {
+			history = shistory
+		} else {
+			logger.WithError(err).Debug("Loading eth data for eth_feeHistory missed from the ethstore")
+		}
+	}
+
+	// all blocks (including the next block of the newest one) hit in store
+	numStored := uint64(len(history.GasUsedRatio))
+	if numStored == uint64(blockCount) && len(history.BaseFee) > int(numStored) {
+		logger.Debug("Loading eth data for eth_feeHistory hit in the ethstore")
+		return history, nil
+	}
+
+	// Delegate the un-synced blocks to fullnode. Note, at least the newest block is required to
+	// query the base fee of the next block.
+	start := util.MinUint64(oldest+numStored, newest)
+
+	logger.WithField("start", start).Debug("Delegating eth_feeHistory rpc request to fullnode")
+
+	var fnhistory citypes.FeeHistory
+	err = w3c.CallContext(
+		ctx, &fnhistory, "eth_feeHistory",
+		hexutil.Uint64(newest-start+1), web3Types.BlockNumber(newest), rewardPercentiles,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip the newest block if already stored
+	skip := int(oldest + numStored - start)
+	if len(fnhistory.GasUsedRatio) < skip || len(fnhistory.BaseFee) < skip {
+		return nil, errors.New("invalid fee history from fullnode")
+	}
+
+	history.BaseFee = append(history.BaseFee[:numStored], fnhistory.BaseFee[skip:]...)
+	history.GasUsedRatio = append(history.GasUsedRatio, fnhistory.GasUsedRatio[skip:]...)
+
+	if len(rewardPercentiles) > 0 && len(fnhistory.Reward) >= skip {
+		history.Reward = append(history.Reward, fnhistory.Reward[skip:]...)
+	}
+
+	return history, nil
+}
+
 // GetLogs returns an array of all logs matching a given filter object.
-func (api *ethAPI) GetLogs(ctx context.Context, fq web3Types.FilterQuery) ([]web3Types.Log, error) {
+//
+// Event logs from store are streamed through conversion into the JSON-RPC response encoder rather
+// than materialized as a full slice, so as to reduce memory spikes for large responses.
+func (api *ethAPI) GetLogs(ctx context.Context, fq web3Types.FilterQuery) (handler.EthLogs, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return api.getLogs(ctx, w3c, &fq, rpcMethodEthGetLogs)
+	return api.getLazyLogs(ctx, w3c, &fq, rpcMethodEthGetLogs)
 }
 
 // getLogs helper method to get logs from store or fullnode.
@@ -317,34 +875,116 @@ func (api *ethAPI) getLogs(
 	fq *web3Types.FilterQuery,
 	rpcMethod string,
 ) ([]web3Types.Log, error) {
+	logs, err := api.getLazyLogs(ctx, w3c, fq, rpcMethod)
+	if err != nil {
+		return ethEmptyLogs, err
+	}
+
+	return logs.Logs(), nil
+}
+
+// getLazyLogs helper method to get logs from store or fullnode, in which event logs from store
+// are not converted until encoded.
+func (api *ethAPI) getLazyLogs(
+	ctx context.Context,
+	w3c *node.Web3goClient,
+	fq *web3Types.FilterQuery,
+	rpcMethod string,
+) (handler.EthLogs, error) {
 	metrics.UpdateEthRpcLogFilter(rpcMethod, w3c.Eth, fq)
 
 	flag, ok := ParseEthLogFilterType(fq)
 	if !ok {
-		return ethEmptyLogs, ErrInvalidEthLogFilter
+		return nil, ErrInvalidEthLogFilter
 	}
 
-	if err := NormalizeEthLogFilter(w3c.Client, flag, fq, api.hardforkBlockNumber); err != nil {
-		return ethEmptyLogs, err
+	params, err := api.getChainParams(w3c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := NormalizeEthLogFilter(w3c, flag, fq, params.hardforkBlockNumber); err != nil {
+		return nil, err
 	}
 
 	if err := ValidateEthLogFilter(flag, fq); err != nil {
-		return ethEmptyLogs, err
+		return nil, err
+	}
+
+	// cap the block span by request tier before splitting log filter
+	span := EthLogFilterSpan(flag, fq)
+	if err := api.LogSpanLimiter.Validate(ctx, span); err != nil {
+		return nil, rpcutil.ResponseError(err)
 	}
 
 	// return empty directly if filter block range before eSpace hardfork
-	if fq.ToBlock != nil && *fq.ToBlock <= api.hardforkBlockNumber {
-		return ethEmptyLogs, nil
+	if fq.ToBlock != nil && *fq.ToBlock <= params.hardforkBlockNumber {
+		return nil, nil
 	}
 
 	if api.LogApiHandler != nil {
-		logs, hitStore, err := api.LogApiHandler.GetLogs(ctx, w3c.Client.Eth, fq, rpcMethod)
-		metrics.Registry.RPC.StoreHit(rpcMethod, "store").Mark(hitStore)
-		return uniformEthLogs(logs), err
+		logs, hitStore, err := api.LogApiHandler.GetLogsLazily(ctx, w3c, fq, rpcMethod)
+		collectStoreHitStats(ctx, rpcMethod, hitStore)
+
+		var start uint64
+		if span > 0 {
+			start = uint64(*fq.FromBlock)
+		}
+
+		return logs, rpcutil.ResponseError(hintLogsTooLarge(err, start, span))
 	}
 
 	// fail over to fullnode if no handler configured
-	return w3c.Eth.Logs(*fq)
+	logs, err := w3c.Eth.Logs(*fq)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.NewEthLogs(logs), nil
+}
+
+// ExplainLogs returns the planned execution of `eth_getLogs` for the log filter without execution,
+// so as to understand why some query is slow or rejected.
+func (api *ethAPI) ExplainLogs(ctx context.Context, fq web3Types.FilterQuery) (*handler.LogsQueryPlan, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	flag, ok := ParseEthLogFilterType(&fq)
+	if !ok {
+		return nil, ErrInvalidEthLogFilter
+	}
+
+	params, err := api.getChainParams(w3c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := NormalizeEthLogFilter(w3c, flag, &fq, params.hardforkBlockNumber); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateEthLogFilter(flag, &fq); err != nil {
+		return handler.NewLogsQueryPlan().Reject(err), nil
+	}
+
+	if err := api.LogSpanLimiter.Validate(ctx, EthLogFilterSpan(flag, &fq)); err != nil {
+		return handler.NewLogsQueryPlan().Reject(err), nil
+	}
+
+	// nothing to query if filter block range before eSpace hardfork
+	if fq.ToBlock != nil && *fq.ToBlock <= params.hardforkBlockNumber {
+		return handler.NewLogsQueryPlan(), nil
+	}
+
+	if api.LogApiHandler != nil {
+		plan, err := api.LogApiHandler.ExplainLogs(ctx, w3c, &fq)
+		return plan, rpcutil.ResponseError(err)
+	}
+
+	// delegated to fullnode if no handler configured
+	plan := handler.NewLogsQueryPlan()
+	plan.Fullnode = fq
+
+	return plan, nil
 }
 
 // GetBlockTransactionCountByHash returns the total number of transactions in the given block.
@@ -361,6 +1001,8 @@ func (api *ethAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNu
 ) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update1(&blockNum, "eth_getBlockTransactionCountByNumber", w3c.Eth)
+
+	blockNum = handler.ResolveEthBlockTag(w3c, blockNum)
 	count, err := w3c.Eth.BlockTransactionCountByNumber(blockNum)
 	return (*hexutil.Big)(count), err
 }
@@ -368,6 +1010,10 @@ func (api *ethAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNu
 // Syncing returns an object with data about the sync status or false.
 // https://openethereum.github.io/JSONRPC-eth-module#eth_syncing
 func (api *ethAPI) Syncing(ctx context.Context) (web3Types.SyncStatus, error) {
+	if api.LocalAnswerer != nil {
+		return api.LocalAnswerer.Syncing(), nil
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	return w3c.Eth.Syncing()
 }
@@ -400,10 +1046,64 @@ func (api *ethAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, erro
 	return (*hexutil.Big)(priorityFee), err
 }
 
-// Accounts returns a list of addresses owned by client.
-func (api *ethAPI) Accounts(ctx context.Context) ([]common.Address, error) {
-	w3c := GetEthClientFromContext(ctx)
-	return w3c.Eth.Accounts()
+// Accounts returns a list of addresses owned by client, which is not supported by gateway unless
+// signing methods allowed.
+func (api *ethAPI) Accounts(ctx context.Context) (result []common.Address, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("eth_accounts")
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(ctx, &result, "eth_accounts")
+	return result, err
+}
+
+// Sign signs data with the account, which is not supported by gateway unless signing methods allowed.
+func (api *ethAPI) Sign(
+	ctx context.Context, address common.Address, data hexutil.Bytes,
+) (result hexutil.Bytes, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("eth_sign")
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(ctx, &result, "eth_sign", address, data)
+	return result, err
+}
+
+// SignTransaction signs transaction with the account, which is not supported by gateway unless
+// signing methods allowed.
+func (api *ethAPI) SignTransaction(ctx context.Context, args interface{}) (result hexutil.Bytes, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("eth_signTransaction")
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(ctx, &result, "eth_signTransaction", args)
+	return result, err
+}
+
+// SendTransaction signs and sends transaction with the account, which is not supported by gateway
+// unless signing methods allowed.
+func (api *ethAPI) SendTransaction(ctx context.Context, args interface{}) (result common.Hash, err error) {
+	if !middlewares.SigningAllowed() {
+		return common.Hash{}, errMethodUnsupportedByGateway("eth_sendTransaction")
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(ctx, &result, "eth_sendTransaction", args)
+	return result, err
+}
+
+// SignTypedData_v4 signs EIP-712 typed data with the account, which is not supported by gateway
+// unless signing methods allowed.
+func (api *ethAPI) SignTypedData_v4(
+	ctx context.Context, address common.Address, typedData interface{},
+) (result hexutil.Bytes, err error) {
+	if !middlewares.SigningAllowed() {
+		return nil, errMethodUnsupportedByGateway("eth_signTypedData_v4")
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(
+		ctx, &result, "eth_signTypedData_v4", address, typedData,
+	)
+	return result, err
 }
 
 // SubmitHashrate used for submitting mining hashrate.
@@ -439,6 +1139,8 @@ func (api *ethAPI) GetTransactionByBlockNumberAndIndex(
 ) (*web3Types.TransactionDetail, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update1(&blockNum, "eth_getTransactionByBlockNumberAndIndex", w3c.Eth)
+
+	blockNum = handler.ResolveEthBlockTag(w3c, blockNum)
 	return w3c.Eth.TransactionByBlockNumberAndIndex(blockNum, uint(index))
 }
 
@@ -34,7 +34,7 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 
 	rpcSub := psCtx.notifier.CreateSubscription()
 
-	headersCh := make(chan *types.Header, pubsubChannelBufferSize)
+	headersCh := make(chan *types.Header, pubsubBufferCfg.Size)
 	dClient := getOrNewEthDelegateClient(psCtx.eth)
 
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
@@ -46,23 +46,58 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	logger := logrus.WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
-	counter := metrics.Registry.PubSub.Sessions("eth", "new_heads", nodeName)
-	counter.Inc(1)
+	metrics.Registry.PubSub.Sessions("eth", nhCtxName, nodeName).Inc(1)
 
 	go func() {
-		defer dSub.unsubscribe()
-		defer counter.Dec(1)
+		defer func() { dSub.unsubscribe() }()
+		defer func() { // node may be changed due to failover
+			nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
+			metrics.Registry.PubSub.Sessions("eth", nhCtxName, nodeName).Dec(1)
+		}()
+
+		notify := func(blockHeader *types.Header) {
+			psCtx.notifier.Notify(rpcSub.ID, blockHeader)
+		}
+
+		// the last notified block, and the last replayed block after failover
+		var lastBlock, replayedTo uint64
 
 		for {
 			select {
 			case blockHeader := <-headersCh:
 				logger.WithField("blockHeader", blockHeader).Debug("Received new block header from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, blockHeader)
+				if bn := blockHeader.Number.Uint64(); bn <= replayedTo { // already replayed
+					continue
+				}
+
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
+				notify(blockHeader)
+				lastBlock = blockHeader.Number.Uint64()
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from newHeads pubsub delegate")
-				psCtx.rpcClient.Close()
-				return
+
+				newSub, newReplayedTo, err := api.failoverPubSub(psCtx, nhCtxName, dSub, err,
+					func(dClient *ethDelegateClient) (*delegateSubscription, error) {
+						return dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
+					},
+					func(w3c *node.Web3goClient) (uint64, error) {
+						if lastBlock == 0 { // nothing notified yet
+							return 0, nil
+						}
+
+						return api.replayHeads(detachedContext{ctx}, w3c, lastBlock+1, notify)
+					},
+				)
+				if err != nil {
+					psCtx.rpcClient.Close()
+					return
+				}
+
+				dSub, replayedTo = newSub, newReplayedTo
+				if replayedTo > lastBlock {
+					lastBlock = replayedTo
+				}
 
 			case err = <-rpcSub.Err(): // client connection closed or error
 				logger.WithError(err).Debug("NewHeads pubsub subscription error")
@@ -93,9 +128,14 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	// backfill event logs since `fromBlock` before handing off to live stream if enabled
+	if rpcSub, ok, err := api.backfillLogs(ctx, psCtx, filter); ok {
+		return rpcSub, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
-	logsCh := make(chan *types.Log, pubsubChannelBufferSize)
+	logsCh := make(chan *types.Log, pubsubBufferCfg.Size)
 	dClient := getOrNewEthDelegateClient(psCtx.eth)
 
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
@@ -107,23 +147,66 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 	logger := logrus.WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
-	counter := metrics.Registry.PubSub.Sessions("eth", "logs", nodeName)
-	counter.Inc(1)
+	metrics.Registry.PubSub.Sessions("eth", logsCtxName, nodeName).Inc(1)
+
+	// the last block covered by the notified event logs, and the last replayed block after failover
+	var lastBlock, replayedTo uint64
+	if api.PubSubFailover != nil {
+		if latest, err := psCtx.eth.Eth.BlockNumber(); err == nil {
+			lastBlock = latest.Uint64()
+		}
+	}
 
 	go func() {
-		defer dSub.unsubscribe()
-		defer counter.Dec(1)
+		defer func() { dSub.unsubscribe() }()
+		defer func() { // node may be changed due to failover
+			nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
+			metrics.Registry.PubSub.Sessions("eth", logsCtxName, nodeName).Dec(1)
+		}()
+
+		notify := func(log *types.Log) {
+			psCtx.notifier.Notify(rpcSub.ID, log)
+		}
 
 		for {
 			select {
 			case log := <-logsCh:
 				logger.WithField("log", log).Debug("Received new log from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, log)
+				if log.BlockNumber <= replayedTo && !log.Removed { // already replayed
+					continue
+				}
+
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
+				notify(log)
+
+				if log.BlockNumber > lastBlock {
+					lastBlock = log.BlockNumber
+				}
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from logs pubsub delegate")
-				psCtx.rpcClient.Close()
-				return
+
+				newSub, newReplayedTo, err := api.failoverPubSub(psCtx, logsCtxName, dSub, err,
+					func(dClient *ethDelegateClient) (*delegateSubscription, error) {
+						return dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
+					},
+					func(w3c *node.Web3goClient) (uint64, error) {
+						if lastBlock == 0 { // no start block known
+							return 0, nil
+						}
+
+						return api.replayLogs(detachedContext{ctx}, w3c, filter, lastBlock+1, notify)
+					},
+				)
+				if err != nil {
+					psCtx.rpcClient.Close()
+					return
+				}
+
+				dSub, replayedTo = newSub, newReplayedTo
+				if replayedTo > lastBlock {
+					lastBlock = replayedTo
+				}
 
 			case err = <-rpcSub.Err():
 				logger.WithError(err).Debugf("Logs pubsub subscription error")
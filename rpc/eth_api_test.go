@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContractIndexStore map[string]uint64
+
+func (s testContractIndexStore) GetContractCreation(address string) (*mysql.ContractCreation, bool, error) {
+	return nil, false, nil
+}
+
+func (s testContractIndexStore) GetExternallyOwnedAccount(address string) (uint64, bool, error) {
+	bn, ok := s[address]
+	return bn, ok, nil
+}
+
+func TestGetCodeOfKnownEoa(t *testing.T) {
+	eoa := common.HexToAddress("0x0000000000000000000000000000000000000e0a")
+	delegated := common.HexToAddress("0x00000000000000000000000000000000000de1e9")
+	delegation := "0xef0100000000000000000000000000000000000000c0de"
+
+	var getCodeCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		result := `null`
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			result = `{"number":"0x5a","difficulty":"0x0"}`
+		case "eth_getCode":
+			atomic.AddInt32(&getCodeCalls, 1)
+
+			result = `"0x"`
+			if string(req.Params[0]) == fmt.Sprintf(`"%v"`, delegated.Hex()) {
+				result = fmt.Sprintf(`"%v"`, delegation)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer server.Close()
+
+	eth, err := rpcutil.NewEthClient(server.URL)
+	assert.NoError(t, err)
+	defer eth.Close()
+
+	ctx := context.WithValue(
+		context.Background(), ctxKeyClient, &node.Web3goClient{Client: eth, URL: server.URL},
+	)
+
+	api := newEthAPI(nil, EthAPIOption{ContractIndexStore: testContractIndexStore{
+		normalizeIndexAddress(eoa):       1,
+		normalizeIndexAddress(delegated): 1,
+	}})
+
+	// verified with fullnode for the first time, and then served from cache
+	for i := 0; i < 2; i++ {
+		code, err := api.GetCode(ctx, eoa, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&getCodeCalls))
+
+	latest := web3Types.BlockNumberOrHashWithNumber(web3Types.LatestBlockNumber)
+	_, err = api.GetCode(ctx, eoa, &latest)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&getCodeCalls))
+
+	// historical block always queried from fullnode
+	historical := web3Types.BlockNumberOrHashWithNumber(16)
+	_, err = api.GetCode(ctx, eoa, &historical)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&getCodeCalls))
+
+	// EIP-7702 delegated account always queried from fullnode
+	for i := 0; i < 2; i++ {
+		code, err := api.GetCode(ctx, delegated, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, delegation, code.String())
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&getCodeCalls))
+}
+
+func TestIsLatestBlockParam(t *testing.T) {
+	latest := web3Types.BlockNumberOrHashWithNumber(web3Types.LatestBlockNumber)
+	finalized := web3Types.BlockNumberOrHashWithNumber(web3Types.FinalizedBlockNumber)
+	number := web3Types.BlockNumberOrHashWithNumber(100)
+	hash := web3Types.BlockNumberOrHashWithHash(common.Hash{1}, false)
+
+	assert.True(t, isLatestBlockParam(nil))
+	assert.True(t, isLatestBlockParam(&latest))
+	assert.False(t, isLatestBlockParam(&finalized))
+	assert.False(t, isLatestBlockParam(&number))
+	assert.False(t, isLatestBlockParam(&hash))
+}
+
+func TestEthAPIChainParamsLazilyLoaded(t *testing.T) {
+	var available int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if atomic.LoadInt32(&available) == 0 {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32603,"message":"unavailable"}}`, req.ID)
+			return
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x47"}`, req.ID)
+	}))
+	defer server.Close()
+
+	eth, err := rpcutil.NewEthClient(server.URL)
+	assert.NoError(t, err)
+	defer eth.Close()
+
+	// never blocked to construct even if no fullnode available
+	api := newEthAPI(nil)
+	assert.Nil(t, api.chainParams.Load())
+
+	_, err = api.getChainParams(eth)
+	assert.Error(t, err)
+	assert.Nil(t, api.chainParams.Load())
+
+	atomic.StoreInt32(&available, 1)
+
+	params, err := api.getChainParams(eth)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0x47), params.signer.ChainID())
+
+	// resolved only once
+	atomic.StoreInt32(&available, 0)
+
+	cached, err := api.getChainParams(eth)
+	assert.NoError(t, err)
+	assert.Same(t, params, cached)
+}
@@ -0,0 +1,248 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EthLocalAnswerConfig configurations to answer the static or semi-static methods (`eth_chainId`,
+// `net_version`, `web3_clientVersion` and `eth_syncing`) locally without touching any fullnode.
+type EthLocalAnswerConfig struct {
+	// whether to answer the static or semi-static methods locally
+	Enabled bool
+	// expected chain id validated against upstream fullnodes, 0 to adopt the upstream one
+	ChainId uint64
+	// expected network id validated against upstream fullnodes, empty to adopt the upstream one
+	NetVersion string
+	// client version to advertise, empty to adopt the upstream one
+	ClientVersion string
+	// interval to poll the sync status of upstream fullnodes
+	SyncingInterval time.Duration `default:"10s"`
+}
+
+// upstreamInfo static or semi-static values reported by upstream fullnode.
+type upstreamInfo struct {
+	chainId       uint64
+	netVersion    string
+	clientVersion string
+}
+
+// EthLocalAnswerer answers the static or semi-static methods locally, whose values are validated
+// against the upstream fullnodes of group `ethhttp` at startup.
+//
+// Note, the sync status is aggregated from the upstream fullnodes periodically, which is regarded
+// as not syncing as long as any upstream fullnode has been synced.
+type EthLocalAnswerer struct {
+	conf EthLocalAnswerConfig
+
+	chainId       hexutil.Uint64
+	netVersion    string
+	clientVersion string
+
+	upstreams map[string]*web3go.Client // node name => client
+	syncing   atomic.Value              // web3Types.SyncStatus
+}
+
+// MustNewEthLocalAnswererFromViper creates local answerer from viper settings, or returns false if
+// not enabled. It will panic if upstream fullnodes disagree with each other or the expected values.
+func MustNewEthLocalAnswererFromViper() (*EthLocalAnswerer, bool) {
+	var conf EthLocalAnswerConfig
+	viper.MustUnmarshalKey("ethrpc.localAnswer", &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	urls := node.EthUrlConfig()[node.GroupEthHttp].Nodes
+	if len(urls) == 0 {
+		logrus.Fatal("No upstream fullnode configured to validate local answers")
+	}
+
+	upstreams := make(map[string]*web3go.Client, len(urls))
+	for _, url := range urls {
+		upstreams[rpcutil.Url2NodeName(url)] = rpcutil.MustNewEthClient(url)
+	}
+
+	answerer, err := NewEthLocalAnswerer(conf, upstreams)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to validate local answers against upstream fullnodes")
+	}
+
+	return answerer, true
+}
+
+// NewEthLocalAnswerer creates local answerer after validating the static values against upstream
+// fullnodes, where unreachable upstreams are skipped but at least one is required.
+func NewEthLocalAnswerer(conf EthLocalAnswerConfig, upstreams map[string]*web3go.Client) (*EthLocalAnswerer, error) {
+	var infos []*upstreamInfo
+
+	for name, client := range upstreams {
+		info, err := queryUpstreamInfo(client)
+		if err != nil {
+			logrus.WithField("node", name).WithError(err).Warn("Failed to query upstream fullnode for local answers")
+			continue
+		}
+
+		infos = append(infos, info)
+	}
+
+	if len(infos) == 0 {
+		return nil, errors.New("no upstream fullnode available")
+	}
+
+	answerer := EthLocalAnswerer{
+		conf:          conf,
+		chainId:       hexutil.Uint64(conf.ChainId),
+		netVersion:    conf.NetVersion,
+		clientVersion: conf.ClientVersion,
+		upstreams:     upstreams,
+	}
+
+	if answerer.chainId == 0 {
+		answerer.chainId = hexutil.Uint64(infos[0].chainId)
+	}
+
+	if len(answerer.netVersion) == 0 {
+		answerer.netVersion = infos[0].netVersion
+	}
+
+	if len(answerer.clientVersion) == 0 {
+		answerer.clientVersion = infos[0].clientVersion
+	}
+
+	for _, info := range infos {
+		if info.chainId != uint64(answerer.chainId) {
+			return nil, errors.Errorf("chain id mismatched, expected %v, upstream %v", answerer.chainId, info.chainId)
+		}
+
+		if info.netVersion != answerer.netVersion {
+			return nil, errors.Errorf(
+				"net version mismatched, expected %v, upstream %v", answerer.netVersion, info.netVersion,
+			)
+		}
+
+		// client versions may differ during rolling upgrade of fullnodes
+		if len(conf.ClientVersion) == 0 && info.clientVersion != answerer.clientVersion {
+			logrus.WithFields(logrus.Fields{
+				"advertised": answerer.clientVersion,
+				"upstream":   info.clientVersion,
+			}).Warn("Client versions of upstream fullnodes mismatched")
+		}
+	}
+
+	answerer.refreshSyncing()
+
+	return &answerer, nil
+}
+
+func queryUpstreamInfo(client *web3go.Client) (*upstreamInfo, error) {
+	chainId, err := client.Eth.ChainId()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get chain id")
+	}
+
+	if chainId == nil {
+		return nil, errors.New("chain id is nil")
+	}
+
+	netVersion, err := client.Eth.NetVersion()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get net version")
+	}
+
+	clientVersion, err := client.Eth.ClientVersion()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get client version")
+	}
+
+	return &upstreamInfo{
+		chainId:       *chainId,
+		netVersion:    netVersion,
+		clientVersion: clientVersion,
+	}, nil
+}
+
+// Run polls the sync status of upstream fullnodes periodically until context done.
+func (a *EthLocalAnswerer) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.conf.SyncingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.refreshSyncing()
+		}
+	}
+}
+
+// refreshSyncing aggregates the sync status of upstream fullnodes, and keeps the last one if all
+// upstream fullnodes unavailable.
+func (a *EthLocalAnswerer) refreshSyncing() {
+	var statuses []web3Types.SyncStatus
+
+	for name, client := range a.upstreams {
+		status, err := client.Eth.Syncing()
+		if err != nil {
+			logrus.WithField("node", name).WithError(err).Debug("Failed to poll sync status of upstream fullnode")
+			continue
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	if status, ok := aggregateSyncStatus(statuses); ok {
+		a.syncing.Store(status)
+	}
+}
+
+// aggregateSyncStatus returns not syncing if any upstream fullnode has been synced, otherwise the
+// most advanced sync progress.
+func aggregateSyncStatus(statuses []web3Types.SyncStatus) (result web3Types.SyncStatus, ok bool) {
+	for _, status := range statuses {
+		if !status.IsSyncing {
+			return web3Types.SyncStatus{}, true
+		}
+
+		if !ok || (status.SyncInfo != nil &&
+			(result.SyncInfo == nil || status.SyncInfo.CurrentBlock > result.SyncInfo.CurrentBlock)) {
+			result, ok = status, true
+		}
+	}
+
+	return result, ok
+}
+
+// ChainId returns the validated chain id.
+func (a *EthLocalAnswerer) ChainId() *hexutil.Uint64 {
+	chainId := a.chainId
+	return &chainId
+}
+
+// NetVersion returns the validated network id.
+func (a *EthLocalAnswerer) NetVersion() string {
+	return a.netVersion
+}
+
+// ClientVersion returns the advertised client version.
+func (a *EthLocalAnswerer) ClientVersion() string {
+	return a.clientVersion
+}
+
+// Syncing returns the aggregated sync status of upstream fullnodes, or false if never polled
+// successfully.
+func (a *EthLocalAnswerer) Syncing() web3Types.SyncStatus {
+	status, _ := a.syncing.Load().(web3Types.SyncStatus)
+	return status
+}
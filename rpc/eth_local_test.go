@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateSyncStatus(t *testing.T) {
+	syncing := func(current uint64) web3Types.SyncStatus {
+		return web3Types.SyncStatus{
+			IsSyncing: true,
+			SyncInfo:  &web3Types.SyncProgress{CurrentBlock: hexutil.Uint64(current), HighestBlock: 100},
+		}
+	}
+
+	// all upstreams unavailable
+	_, ok := aggregateSyncStatus(nil)
+	assert.False(t, ok)
+
+	// most advanced sync progress if all upstreams syncing
+	status, ok := aggregateSyncStatus([]web3Types.SyncStatus{syncing(10), syncing(30), syncing(20)})
+	assert.True(t, ok)
+	assert.True(t, status.IsSyncing)
+	assert.Equal(t, uint64(30), uint64(status.SyncInfo.CurrentBlock))
+
+	// not syncing if any upstream synced
+	status, ok = aggregateSyncStatus([]web3Types.SyncStatus{syncing(10), {}, syncing(20)})
+	assert.True(t, ok)
+	assert.False(t, status.IsSyncing)
+}
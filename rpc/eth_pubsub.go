@@ -80,9 +80,10 @@ func (client *ethDelegateClient) delegateSubscribeLogs(
 		return nil, errDelegateNotReady
 	}
 
-	return dCtx.registerDelegateSub(client.proxySubscribeLogs, subId, channel, func(item interface{}) bool {
+	groupId := ethLogFilterGroupId(filter)
+	return dCtx.registerGroupedDelegateSub(client.proxySubscribeLogs, subId, channel, groupId, func(item interface{}) bool {
 		log, ok := item.(*types.Log)
-		return !ok || !matchEthPubSubLogFilter(log, &filter)
+		return ok && matchEthPubSubLogFilter(log, &filter)
 	})
 }
 
@@ -129,6 +130,26 @@ func matchEthPubSubLogFilter(log *types.Log, filter *types.FilterQuery) bool {
 	return util.IncludeEthLogAddrs(log, filter.Addresses) && util.MatchEthLogTopics(log, filter.Topics)
 }
 
+// ethLogFilterGroupId returns the canonical log filter as subscription group ID.
+func ethLogFilterGroupId(filter types.FilterQuery) string {
+	addrs := make([]string, 0, len(filter.Addresses))
+	for i := range filter.Addresses {
+		addrs = append(addrs, filter.Addresses[i].Hex())
+	}
+
+	topics := make([][]string, 0, len(filter.Topics))
+	for i := range filter.Topics {
+		topic := make([]string, 0, len(filter.Topics[i]))
+		for j := range filter.Topics[i] {
+			topic = append(topic, filter.Topics[i][j].Hex())
+		}
+
+		topics = append(topics, topic)
+	}
+
+	return canonicalLogFilter(addrs, topics)
+}
+
 func isEmptyEthLogFilter(filter types.FilterQuery) bool {
 	if len(filter.Addresses) > 0 {
 		return false
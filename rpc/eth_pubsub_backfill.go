@@ -0,0 +1,477 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rpcMethodEthSubscribeLogs = "eth_subscribe_logs"
+
+	// max number of live logs buffered during backfill
+	maxBackfillPendingLogs = 10 * pubsubChannelBufferSize
+
+	// max number of times to query a batch again due to chain reorg during backfill
+	maxBackfillReorgRetries = 3
+)
+
+var (
+	errLogsBackfillTooLarge = errors.New("subscription fromBlock too far behind the latest block")
+	errLogsBackfillReorged  = errors.New("chain reorged across the backfilled blocks")
+)
+
+// EthLogsBackfillConfig configurations to backfill `logs` subscription from the specified
+// `fromBlock` before handing off to the live stream.
+type EthLogsBackfillConfig struct {
+	// whether to backfill `logs` subscription with `fromBlock` specified
+	Enabled bool
+	// max number of blocks to backfill
+	MaxBlocks uint64 `default:"10000"`
+	// number of blocks to query event logs per batch
+	BatchBlocks uint64 `default:"1000"`
+	// number of the latest backfilled blocks tracked, whose event logs will be notified with
+	// `removed: true` if reverted by chain reorg after handoff
+	ReorgWindow uint64 `default:"100"`
+}
+
+// MustNewEthLogsBackfillConfigFromViper loads logs backfill configurations from viper, or returns
+// nil if not enabled.
+func MustNewEthLogsBackfillConfigFromViper() *EthLogsBackfillConfig {
+	var conf EthLogsBackfillConfig
+	viper.MustUnmarshalKey("ethrpc.logsBackfill", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return &conf
+}
+
+// detachedContext keeps the values (eg., request tier) of the parent context, but will never be
+// canceled when the subscription request returns.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// trackedBlock backfilled block whose event logs have been notified.
+type trackedBlock struct {
+	hash common.Hash
+	logs []*types.Log
+}
+
+// ethLogsBackfill hybrid `logs` subscription session, which streams the event logs from
+// `fromBlock` to the handoff block from store at first, and then hands off to the live stream
+// delegated from fullnode seamlessly:
+//
+// 1. live event logs are buffered until backfill completed;
+// 2. live event logs not later than the handoff block are deduplicated against the backfilled ones;
+// 3. backfilled event logs reverted by chain reorg are notified with `removed: true`.
+type ethLogsBackfill struct {
+	conf   *EthLogsBackfillConfig
+	filter types.FilterQuery
+	notify func(log *types.Log)
+
+	fromBlock    uint64
+	handoffBlock uint64
+
+	done    bool
+	pending []*types.Log             // live event logs buffered during backfill
+	tracked map[uint64]*trackedBlock // block number => backfilled block within reorg window
+}
+
+func newEthLogsBackfill(
+	conf *EthLogsBackfillConfig, filter types.FilterQuery, handoffBlock uint64, notify func(log *types.Log),
+) *ethLogsBackfill {
+	return &ethLogsBackfill{
+		conf:         conf,
+		filter:       filter,
+		notify:       notify,
+		fromBlock:    uint64(*filter.FromBlock),
+		handoffBlock: handoffBlock,
+		tracked:      make(map[uint64]*trackedBlock),
+	}
+}
+
+// ethLogsBackfillSource queries the event logs and canonical blocks to backfill.
+type ethLogsBackfillSource interface {
+	// getLogs returns the event logs of the log filter.
+	getLogs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error)
+	// getBlock returns the hash and parent hash of the canonical block.
+	getBlock(bn uint64) (hash, parentHash common.Hash, err error)
+}
+
+// ethBackfillSource queries event logs from store (or fullnode if not available in store), and
+// canonical blocks from fullnode.
+type ethBackfillSource struct {
+	api *ethAPI
+	w3c *node.Web3goClient
+}
+
+func (s *ethBackfillSource) getLogs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error) {
+	return s.api.getLogs(ctx, s.w3c, filter, rpcMethodEthSubscribeLogs)
+}
+
+func (s *ethBackfillSource) getBlock(bn uint64) (common.Hash, common.Hash, error) {
+	block, err := s.w3c.Eth.BlockByNumber(types.BlockNumber(bn), false)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+
+	if block == nil {
+		return common.Hash{}, common.Hash{}, errors.Errorf("block %v not found", bn)
+	}
+
+	return block.Hash, block.ParentHash, nil
+}
+
+// backfillBatch event logs of a block range batch, along with the hash of the last block and
+// the parent hash of the first block to check chain continuity between batches.
+type backfillBatch struct {
+	from, to   uint64
+	hash       common.Hash
+	parentHash common.Hash
+	logs       []types.Log
+}
+
+// run queries the event logs in batches, and sends them to the channel in order. It returns once
+// all batches sent or context canceled.
+//
+// Like the head syncer, the parent hash of each batch is checked against the last block of the
+// previous batch, so that event logs orphaned by chain reorg during backfill will never be sent.
+// To do so, each batch is held until the next one verified, and queried again if chain reorged.
+func (b *ethLogsBackfill) run(
+	ctx context.Context, src ethLogsBackfillSource, batchCh chan<- []types.Log,
+) error {
+	var held *backfillBatch // batch queried but not sent yet
+	var sentHash *common.Hash
+	var retries int
+
+	send := func(batch *backfillBatch) error {
+		select {
+		case batchCh <- batch.logs:
+			sentHash = &batch.hash
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for from := b.fromBlock; from <= b.handoffBlock; {
+		to := from + b.conf.BatchBlocks - 1
+		if to > b.handoffBlock {
+			to = b.handoffBlock
+		}
+
+		batch, err := b.query(ctx, src, from, to)
+		if err != nil {
+			return err
+		}
+
+		prevHash := sentHash
+		if held != nil {
+			prevHash = &held.hash
+		}
+
+		reorged := batch == nil || (prevHash != nil && batch.parentHash != *prevHash)
+		if reorged {
+			if batch != nil && held == nil { // chain reorged across the sent batches
+				return errors.WithMessagef(errLogsBackfillReorged, "parent hash mismatch at block %v", from)
+			}
+
+			if retries++; retries > maxBackfillReorgRetries {
+				return errors.WithMessagef(errLogsBackfillReorged, "too many retries at block %v", from)
+			}
+
+			// query the current batch again if reorged during query, otherwise the held batch
+			if batch != nil {
+				from, held = held.from, nil
+			}
+
+			continue
+		}
+
+		if held != nil {
+			if err := send(held); err != nil {
+				return err
+			}
+		}
+
+		held, from = batch, to+1
+	}
+
+	if held != nil {
+		return send(held)
+	}
+
+	return nil
+}
+
+// query queries the event logs of the block range along with the boundary blocks, or returns nil
+// if chain reorged during query.
+func (b *ethLogsBackfill) query(
+	ctx context.Context, src ethLogsBackfillSource, from, to uint64,
+) (*backfillBatch, error) {
+	fq := b.filter
+	fromBlock, toBlock := types.BlockNumber(from), types.BlockNumber(to)
+	fq.FromBlock, fq.ToBlock, fq.BlockHash = &fromBlock, &toBlock, nil
+
+	logs, err := src.getLogs(ctx, &fq)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get logs from block %v to %v", from, to)
+	}
+
+	batch := backfillBatch{from: from, to: to, logs: logs}
+
+	var fromHash common.Hash
+	if fromHash, batch.parentHash, err = src.getBlock(from); err != nil {
+		return nil, errors.WithMessagef(err, "failed to get block %v", from)
+	}
+
+	batch.hash = fromHash
+	if to != from {
+		if batch.hash, _, err = src.getBlock(to); err != nil {
+			return nil, errors.WithMessagef(err, "failed to get block %v", to)
+		}
+	}
+
+	// event logs of boundary blocks must be of the canonical blocks queried
+	for i := range logs {
+		if (logs[i].BlockNumber == from && logs[i].BlockHash != fromHash) ||
+			(logs[i].BlockNumber == to && logs[i].BlockHash != batch.hash) {
+			return nil, nil
+		}
+	}
+
+	return &batch, nil
+}
+
+// onBackfilled notifies the backfilled event logs.
+func (b *ethLogsBackfill) onBackfilled(logs []types.Log) {
+	for i := range logs {
+		if b.track(&logs[i]) {
+			b.notify(&logs[i])
+		}
+	}
+}
+
+// onCompleted hands off to live stream once backfill completed.
+func (b *ethLogsBackfill) onCompleted() {
+	b.done = true
+
+	for _, log := range b.pending {
+		b.onLive(log)
+	}
+
+	b.pending = nil
+}
+
+// onLive handles the event log from live stream, and returns false if too many live event logs
+// buffered during backfill.
+func (b *ethLogsBackfill) onLive(log *types.Log) bool {
+	if !b.done {
+		b.pending = append(b.pending, log)
+		return len(b.pending) <= maxBackfillPendingLogs
+	}
+
+	if log.BlockNumber > b.handoffBlock {
+		b.notify(log)
+		return true
+	}
+
+	// out of reorg window, which is regarded as backfilled already
+	if !b.inReorgWindow(log.BlockNumber) {
+		return true
+	}
+
+	if log.Removed {
+		// only revert the event logs notified before
+		if tb, ok := b.tracked[log.BlockNumber]; ok && tb.hash == log.BlockHash {
+			b.revert(log.BlockNumber)
+		}
+
+		return true
+	}
+
+	if tb, ok := b.tracked[log.BlockNumber]; ok && tb.hash != log.BlockHash {
+		// new block reorged in, revert the notified blocks since then
+		b.revert(log.BlockNumber)
+	}
+
+	if b.track(log) {
+		b.notify(log)
+	}
+
+	return true
+}
+
+func (b *ethLogsBackfill) inReorgWindow(bn uint64) bool {
+	return bn <= b.handoffBlock && bn+b.conf.ReorgWindow > b.handoffBlock
+}
+
+// track tracks the event log to notify if within reorg window, and returns false if the event log
+// has already been notified before.
+func (b *ethLogsBackfill) track(log *types.Log) bool {
+	if !b.inReorgWindow(log.BlockNumber) {
+		return true
+	}
+
+	tb, ok := b.tracked[log.BlockNumber]
+	if !ok {
+		tb = &trackedBlock{hash: log.BlockHash}
+		b.tracked[log.BlockNumber] = tb
+	}
+
+	for _, v := range tb.logs {
+		if v.Index == log.Index {
+			return false
+		}
+	}
+
+	tb.logs = append(tb.logs, log)
+
+	return true
+}
+
+// revert notifies the tracked event logs since the specified block in reverse order with
+// `removed: true`, and stops tracking the reverted blocks.
+func (b *ethLogsBackfill) revert(fromBlock uint64) {
+	for bn := b.handoffBlock; bn >= fromBlock; bn-- {
+		if tb, ok := b.tracked[bn]; ok {
+			for i := len(tb.logs) - 1; i >= 0; i-- {
+				removed := *tb.logs[i]
+				removed.Removed = true
+				b.notify(&removed)
+			}
+
+			delete(b.tracked, bn)
+		}
+
+		if bn == 0 {
+			break
+		}
+	}
+}
+
+// backfillLogs serves the `logs` subscription in hybrid mode, which backfills event logs from
+// `fromBlock` before handing off to the live stream, and returns false if backfill not applicable.
+func (api *ethAPI) backfillLogs(
+	ctx context.Context, psCtx *epubsubContext, filter types.FilterQuery,
+) (*rpc.Subscription, bool, error) {
+	if api.LogsBackfill == nil || filter.FromBlock == nil || *filter.FromBlock < 0 || filter.BlockHash != nil {
+		return nil, false, nil
+	}
+
+	latestBlock, err := psCtx.eth.Eth.BlockNumber()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get latest block number for logs backfill")
+		return &rpc.Subscription{}, true, errSubscriptionProxyError
+	}
+
+	fromBlock, handoffBlock := uint64(*filter.FromBlock), latestBlock.Uint64()
+	if fromBlock > handoffBlock { // nothing to backfill
+		return nil, false, nil
+	}
+
+	span := handoffBlock - fromBlock + 1
+	if span > api.LogsBackfill.MaxBlocks {
+		return &rpc.Subscription{}, true, errLogsBackfillTooLarge
+	}
+
+	if err := api.LogSpanLimiter.Validate(ctx, span); err != nil {
+		return &rpc.Subscription{}, true, err
+	}
+
+	rpcSub := psCtx.notifier.CreateSubscription()
+
+	logger := logrus.WithFields(logrus.Fields{
+		"rpcSubID": rpcSub.ID, "fromBlock": fromBlock, "handoffBlock": handoffBlock,
+	})
+
+	// subscribe live stream at first so as not to miss any event log after the handoff block
+	logsCh := make(chan *types.Log, pubsubBufferCfg.Size)
+	dClient := getOrNewEthDelegateClient(psCtx.eth)
+
+	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
+	if err != nil {
+		logger.WithError(err).Error("Failed to delegate pubsub logs subscription for backfill")
+		return &rpc.Subscription{}, true, errSubscriptionProxyError
+	}
+
+	backfill := newEthLogsBackfill(api.LogsBackfill, filter, handoffBlock, func(log *types.Log) {
+		psCtx.notifier.Notify(rpcSub.ID, log)
+	})
+
+	nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
+	counter := metrics.Registry.PubSub.Sessions("eth", "logs", nodeName)
+	counter.Inc(1)
+
+	// backfill in the background, which is canceled once subscription terminated
+	bctx, cancel := context.WithCancel(detachedContext{ctx})
+	batchCh := make(chan []types.Log)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- backfill.run(bctx, &ethBackfillSource{api: api, w3c: psCtx.eth}, batchCh)
+	}()
+
+	go func() {
+		defer dSub.unsubscribe()
+		defer counter.Dec(1)
+		defer cancel()
+
+		for {
+			select {
+			case logs := <-batchCh:
+				backfill.onBackfilled(logs)
+
+			case err := <-errCh:
+				if err != nil {
+					logger.WithError(err).Info("Failed to backfill logs subscription")
+					psCtx.rpcClient.Close()
+					return
+				}
+
+				logger.Debug("Logs subscription backfilled and handed off to live stream")
+				backfill.onCompleted()
+
+			case log := <-logsCh:
+				dSub.notifyMissed(psCtx.notifier, rpcSub.ID)
+
+				if !backfill.onLive(log) {
+					logger.Info("Too many live logs buffered during backfill")
+					psCtx.rpcClient.Close()
+					return
+				}
+
+			case err := <-dSub.err: // delegate subscription error
+				logger.WithError(err).Debug("Received error from logs pubsub delegate")
+				psCtx.rpcClient.Close()
+				return
+
+			case err := <-rpcSub.Err():
+				logger.WithError(err).Debug("Logs pubsub subscription error")
+				return
+
+			case <-psCtx.notifier.Closed():
+				logger.Debug("Logs pubsub connection closed")
+				return
+			}
+		}
+	}()
+
+	return rpcSub, true, nil
+}
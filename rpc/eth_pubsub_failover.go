@@ -0,0 +1,248 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var errPubSubReplayTooLarge = errors.New("too many missed blocks to replay after failover")
+
+// EthPubSubFailoverConfig configurations to fail over the upstream `newHeads` and `logs`
+// subscriptions to some other healthy fullnode once the serving fullnode failed.
+type EthPubSubFailoverConfig struct {
+	// whether to fail over the upstream subscriptions
+	Enabled bool
+	// max number of attempts to re-establish the upstream subscription
+	MaxRetries int `default:"3"`
+	// interval between attempts
+	RetryInterval time.Duration `default:"1s"`
+	// max number of missed blocks to replay, otherwise the subscription connection is closed
+	MaxReplayBlocks uint64 `default:"1000"`
+}
+
+// MustNewEthPubSubFailoverConfigFromViper loads pubsub failover configurations from viper, or
+// returns nil if not enabled.
+func MustNewEthPubSubFailoverConfigFromViper() *EthPubSubFailoverConfig {
+	var conf EthPubSubFailoverConfig
+	viper.MustUnmarshalKey("ethrpc.pubsubFailover", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return &conf
+}
+
+// failoverPubSub re-establishes the upstream subscription on some other healthy fullnode than the
+// failed one, and then replays the missed data before resuming live delivery. It returns the new
+// delegate subscription along with the last replayed block number, whose live data shall be skipped
+// as duplicates.
+//
+// Note, the original error is returned as it is if failover disabled, or the error is not caused by
+// the upstream fullnode, e.g. subscription queue overflowed due to slow subscriber.
+func (api *ethAPI) failoverPubSub(
+	psCtx *epubsubContext, topic string, failed *delegateSubscription, cause error,
+	subscribe func(dClient *ethDelegateClient) (*delegateSubscription, error),
+	replay func(w3c *node.Web3goClient) (uint64, error),
+) (*delegateSubscription, uint64, error) {
+	if api.PubSubFailover == nil {
+		return nil, 0, cause
+	}
+
+	// subscriber consumes too slow, which is not the fault of upstream fullnode
+	if isLocalPubSubError(cause) {
+		return nil, 0, cause
+	}
+
+	failed.unsubscribe()
+
+	failedNode := rpcutil.Url2NodeName(psCtx.eth.URL)
+	logger := logrus.WithFields(logrus.Fields{
+		"topic": topic, "failedNode": failedNode, "cause": cause,
+	})
+
+	dSub, eth, err := api.resubscribe(failedNode, subscribe)
+	metrics.Registry.PubSub.Failover("eth", topic, err).Mark(1)
+
+	if err != nil {
+		logger.WithError(err).Info("Failed to fail over upstream pubsub subscription")
+		return nil, 0, err
+	}
+
+	metrics.Registry.PubSub.Sessions("eth", topic, failedNode).Dec(1)
+	metrics.Registry.PubSub.Sessions("eth", topic, rpcutil.Url2NodeName(eth.URL)).Inc(1)
+	psCtx.eth = eth
+
+	replayedTo, err := replay(eth)
+	if err != nil {
+		logger.WithError(err).Info("Failed to replay missed data after pubsub failover")
+		dSub.unsubscribe()
+		return nil, 0, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"node": rpcutil.Url2NodeName(eth.URL), "replayedTo": replayedTo,
+	}).Debug("Upstream pubsub subscription failed over")
+
+	return dSub, replayedTo, nil
+}
+
+// resubscribe subscribes on some other healthy fullnode than the failed one with retry.
+func (api *ethAPI) resubscribe(
+	failedNode string, subscribe func(dClient *ethDelegateClient) (*delegateSubscription, error),
+) (*delegateSubscription, *node.Web3goClient, error) {
+	err := errSubscriptionProxyError
+
+	for i := 0; i < api.PubSubFailover.MaxRetries; i++ {
+		if i > 0 {
+			time.Sleep(api.PubSubFailover.RetryInterval)
+		}
+
+		var eth *node.Web3goClient
+		if eth, err = api.provider.GetAlternativeClient(failedNode, node.GroupEthWs); err != nil {
+			continue
+		}
+
+		var dSub *delegateSubscription
+		if dSub, err = subscribe(getOrNewEthDelegateClient(eth)); err == nil {
+			return dSub, eth, nil
+		}
+	}
+
+	return nil, nil, err
+}
+
+// isLocalPubSubError checks if the delegate subscription error is raised locally rather than by the
+// upstream fullnode, in which case failover makes no sense.
+func isLocalPubSubError(err error) bool {
+	return errors.Is(err, rpc.ErrSubscriptionQueueOverflow)
+}
+
+// replayRange returns the latest block number of the fullnode failed over to, which is the last
+// block to replay since `fromBlock`.
+func (api *ethAPI) replayRange(w3c *node.Web3goClient, fromBlock uint64) (uint64, error) {
+	latest, err := w3c.Eth.BlockNumber()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	return checkReplayRange(fromBlock, latest.Uint64(), api.PubSubFailover.MaxReplayBlocks)
+}
+
+// checkReplayRange returns the last block to replay since `fromBlock`, or error if the number of
+// blocks to replay exceeds the max limit.
+func checkReplayRange(fromBlock, toBlock, maxReplayBlocks uint64) (uint64, error) {
+	if toBlock >= fromBlock && toBlock-fromBlock+1 > maxReplayBlocks {
+		metrics.Registry.PubSub.ReplayTooLarge("eth").Mark(1)
+		return 0, errPubSubReplayTooLarge
+	}
+
+	return toBlock, nil
+}
+
+// replayHeads notifies the block headers since `fromBlock` up to the latest block of the fullnode
+// failed over to, and returns the last replayed block number.
+func (api *ethAPI) replayHeads(
+	ctx context.Context, w3c *node.Web3goClient, fromBlock uint64, notify func(header *types.Header),
+) (uint64, error) {
+	toBlock, err := api.replayRange(w3c, fromBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	if toBlock < fromBlock { // fullnode failed over to lags behind
+		return fromBlock - 1, nil
+	}
+
+	for bn := fromBlock; bn <= toBlock; bn++ {
+		header, err := api.loadHeader(ctx, w3c, bn)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed to load block header %v", bn)
+		}
+
+		notify(header)
+	}
+
+	return toBlock, nil
+}
+
+// loadHeader loads block header from store, or the fullnode if not available in store.
+func (api *ethAPI) loadHeader(ctx context.Context, w3c *node.Web3goClient, bn uint64) (*types.Header, error) {
+	blockNum := types.BlockNumber(bn)
+
+	var block *types.Block
+	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+		block, _ = api.StoreHandler.GetBlockByNumber(ctx, &blockNum, false)
+	}
+
+	if block == nil {
+		var err error
+		if block, err = w3c.Eth.BlockByNumber(blockNum, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+
+	return ethBlockHeader(block)
+}
+
+// ethBlockHeader converts block to header, which shares the same JSON fields with block.
+func ethBlockHeader(block *types.Block) (*types.Header, error) {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var header types.Header
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	return &header, nil
+}
+
+// replayLogs notifies the event logs matched with the filter since `fromBlock` up to the latest block
+// of the fullnode failed over to, and returns the last replayed block number.
+func (api *ethAPI) replayLogs(
+	ctx context.Context, w3c *node.Web3goClient, filter types.FilterQuery, fromBlock uint64,
+	notify func(log *types.Log),
+) (uint64, error) {
+	toBlock, err := api.replayRange(w3c, fromBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	if toBlock < fromBlock { // fullnode failed over to lags behind
+		return fromBlock - 1, nil
+	}
+
+	fq := filter
+	from, to := types.BlockNumber(fromBlock), types.BlockNumber(toBlock)
+	fq.FromBlock, fq.ToBlock, fq.BlockHash = &from, &to, nil
+
+	logs, err := api.getLogs(ctx, w3c, &fq, rpcMethodEthSubscribeLogs)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed to get logs from block %v to %v", fromBlock, toBlock)
+	}
+
+	for i := range logs {
+		notify(&logs[i])
+	}
+
+	return toBlock, nil
+}
@@ -1,23 +1,60 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 
+	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/web3go/types"
 )
 
 // ethTraceAPI provides evm space trace RPC proxy API.
-type ethTraceAPI struct{}
+type ethTraceAPI struct {
+	handler *handler.TraceHandler
+}
+
+func (api *ethTraceAPI) Block(ctx context.Context, blockNumOrHash types.BlockNumberOrHash) (json.RawMessage, error) {
+	return ethTraceCall(ctx, api.handler, "trace_block", "", blockNumOrHash)
+}
+
+func (api *ethTraceAPI) Filter(ctx context.Context, filter types.TraceFilter) (json.RawMessage, error) {
+	return ethTraceCall(ctx, api.handler, "trace_filter", "", filter)
+}
 
-func (api *ethTraceAPI) Block(ctx context.Context, blockNumOrHash types.BlockNumberOrHash) ([]types.LocalizedTrace, error) {
-	return GetEthClientFromContext(ctx).Trace.Blocks(blockNumOrHash)
+func (api *ethTraceAPI) Transaction(ctx context.Context, txHash common.Hash) (json.RawMessage, error) {
+	return ethTraceCall(ctx, api.handler, "trace_transaction", txHash.Hex(), txHash)
 }
 
-func (api *ethTraceAPI) Filter(ctx context.Context, filter types.TraceFilter) ([]types.LocalizedTrace, error) {
-	return GetEthClientFromContext(ctx).Trace.Filter(filter)
+// ethDebugAPI provides evm space debug RPC proxy API.
+type ethDebugAPI struct {
+	handler *handler.TraceHandler
+}
+
+// TraceTransaction returns the structured logs created during the execution of the transaction.
+func (api *ethDebugAPI) TraceTransaction(
+	ctx context.Context, txHash common.Hash, opts *json.RawMessage,
+) (json.RawMessage, error) {
+	if opts == nil {
+		return ethTraceCall(ctx, api.handler, "debug_traceTransaction", txHash.Hex(), txHash)
+	}
+
+	// traces vary from tracer options, so cache by options as well
+	var cacheKey string
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, *opts); err == nil {
+		cacheKey = txHash.Hex() + ":" + buf.String()
+	}
+
+	return ethTraceCall(ctx, api.handler, "debug_traceTransaction", cacheKey, txHash, opts)
 }
 
-func (api *ethTraceAPI) Transaction(ctx context.Context, txHash common.Hash) ([]types.LocalizedTrace, error) {
-	return GetEthClientFromContext(ctx).Trace.Transactions(txHash)
+func ethTraceCall(
+	ctx context.Context, h *handler.TraceHandler, method, cacheKey string, args ...interface{},
+) (json.RawMessage, error) {
+	w3c := GetEthClientFromContext(ctx)
+	return h.Call(method, cacheKey, func(result *json.RawMessage) error {
+		return w3c.CallContext(ctx, result, method, args...)
+	})
 }
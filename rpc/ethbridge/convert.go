@@ -2,6 +2,7 @@ package ethbridge
 
 import (
 	"math/big"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util"
@@ -87,7 +88,7 @@ func ConvertTx(tx *cfxtypes.Transaction, txExt *store.TransactionExtra) *types.T
 	}
 
 	if !util.IsEip155Tx(ethTxn) { // only return chainID for EIP155 tx
-		tx.ChainID = nil
+		ethTxn.ChainID = nil
 	}
 
 	// fill missed data field `Accesses`, `BlockNumber`, `MaxFeePerGas`, `MaxPriorityFeePerGas`, `type`, `StandardV`
@@ -183,6 +184,10 @@ func ConvertBlockHeader(value *cfxtypes.BlockHeader, blockExt *store.BlockExtra)
 
 // convert cfx block summary => eth block
 func ConvertBlockSummary(value *cfxtypes.BlockSummary, blockExt *store.BlockExtra) *types.Block {
+	if value == nil {
+		return nil
+	}
+
 	block := ConvertBlockHeader(&value.BlockHeader, blockExt)
 
 	txHashes := make([]common.Hash, len(value.Transactions))
@@ -196,12 +201,16 @@ func ConvertBlockSummary(value *cfxtypes.BlockSummary, blockExt *store.BlockExtr
 
 // convert cfx block => eth block
 func ConvertBlock(value *cfxtypes.Block, blockExt *store.BlockExtra) *types.Block {
+	if value == nil {
+		return nil
+	}
+
 	block := ConvertBlockHeader(&value.BlockHeader, blockExt)
 
 	txs := make([]types.TransactionDetail, len(value.Transactions))
 	for i, tx := range value.Transactions {
 		var txnExt *store.TransactionExtra
-		if i < len(blockExt.TxnExts) {
+		if blockExt != nil && i < len(blockExt.TxnExts) {
 			txnExt = blockExt.TxnExts[i]
 		}
 
@@ -240,9 +249,14 @@ func ConvertReceipt(value *cfxtypes.TransactionReceipt, rcptExtra *store.Receipt
 		root, _ = hexutil.Decode(string(value.StateRoot))
 	}
 
+	var blockNumber uint64
+	if value.EpochNumber != nil {
+		blockNumber = uint64(*value.EpochNumber)
+	}
+
 	receipt := &types.Receipt{
 		BlockHash:        ConvertHash(value.BlockHash),
-		BlockNumber:      uint64(*value.EpochNumber),
+		BlockNumber:      blockNumber,
 		ContractAddress:  contractAddr,
 		From:             from,
 		GasUsed:          value.GasUsed.ToInt().Uint64(),
@@ -272,16 +286,65 @@ func ConvertReceipt(value *cfxtypes.TransactionReceipt, rcptExtra *store.Receipt
 	return receipt
 }
 
+// convert stored cfx receipts => eth receipts, e.g., receipts of the same block
+func ConvertReceipts(srcpts []*store.TransactionReceipt) []*types.Receipt {
+	receipts := make([]*types.Receipt, len(srcpts))
+	for i := range srcpts {
+		receipts[i] = ConvertReceipt(srcpts[i].CfxReceipt, srcpts[i].Extra)
+	}
+
+	return receipts
+}
+
+// logPool pools the evm space event logs bridged on hot path, e.g., to encode event logs from store.
+var logPool = sync.Pool{New: func() interface{} { return new(types.Log) }}
+
 // convert cfx log => eth log
 func ConvertLog(log *cfxtypes.Log, logExtra *store.LogExtra) *types.Log {
 	if log == nil {
 		return nil
 	}
 
+	ethLog := new(types.Log)
+	convertLogTo(ethLog, log, logExtra)
+
+	return ethLog
+}
+
+// AcquireLog is similar to `ConvertLog`, but the returned eth log is taken from pool, and should be
+// released by `ReleaseLog` once no longer used.
+func AcquireLog(log *cfxtypes.Log, logExtra *store.LogExtra) *types.Log {
+	if log == nil {
+		return nil
+	}
+
+	ethLog := logPool.Get().(*types.Log)
+	convertLogTo(ethLog, log, logExtra)
+
+	return ethLog
+}
+
+// ReleaseLog puts the eth log acquired by `AcquireLog` back into pool, which should not be referenced
+// anymore (including the topics).
+func ReleaseLog(log *types.Log) {
+	if log == nil {
+		return
+	}
+
+	*log = types.Log{Topics: log.Topics[:0]}
+	logPool.Put(log)
+}
+
+// convertLogTo converts cfx log into the dst eth log, and reuses the topics of dst if any.
+func convertLogTo(dst *types.Log, log *cfxtypes.Log, logExtra *store.LogExtra) {
 	ethAddr, _ := ConvertAddress(log.Address)
-	topics := make([]common.Hash, len(log.Topics))
+	topics := dst.Topics[:0]
+	if topics == nil {
+		topics = make([]common.Hash, 0, len(log.Topics))
+	}
+
 	for i := range log.Topics {
-		topics[i] = ConvertHash(log.Topics[i])
+		topics = append(topics, ConvertHash(log.Topics[i]))
 	}
 
 	var txnLogIndex *uint
@@ -290,7 +353,7 @@ func ConvertLog(log *cfxtypes.Log, logExtra *store.LogExtra) *types.Log {
 		txnLogIndex = &v
 	}
 
-	ethLog := &types.Log{
+	*dst = types.Log{
 		Address:             ethAddr,
 		BlockHash:           ConvertHashNullable(log.BlockHash),
 		BlockNumber:         log.EpochNumber.ToInt().Uint64(),
@@ -304,12 +367,10 @@ func ConvertLog(log *cfxtypes.Log, logExtra *store.LogExtra) *types.Log {
 
 	// fill missed data field `LogType`, `Removed`
 	if logExtra != nil {
-		ethLog.LogType = logExtra.LogType
+		dst.LogType = logExtra.LogType
 
 		if logExtra.Removed != nil {
-			ethLog.Removed = *logExtra.Removed
+			dst.Removed = *logExtra.Removed
 		}
 	}
-
-	return ethLog
 }
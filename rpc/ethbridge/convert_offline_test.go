@@ -0,0 +1,108 @@
+package ethbridge
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/rpc/cfxbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertReceiptRoundTrip(t *testing.T) {
+	chainId := uint32(71)
+	status := uint64(0) // failed
+	txnLogIndex := uint(0)
+	// internal contract address (eg., CrossSpaceCall) should be kept as is
+	internalContract := common.HexToAddress("0x0888000000000000000000000000000000000006")
+	contractCreated := common.HexToAddress("0x8ba1f109551bd432803012645ac136ddd64dba72")
+
+	ethReceipt := &ethTypes.Receipt{
+		BlockHash:        common.HexToHash("0x01"),
+		BlockNumber:      100,
+		ContractAddress:  &contractCreated,
+		From:             common.HexToAddress("0x1a642f0e3c3af545e7acbd38b07251b3990914f1"),
+		GasUsed:          21000,
+		Status:           &status,
+		TransactionHash:  common.HexToHash("0x02"),
+		TransactionIndex: 3,
+		TxExecErrorMsg:   new(string),
+		Logs: []*ethTypes.Log{{
+			Address:             internalContract,
+			BlockHash:           common.HexToHash("0x01"),
+			BlockNumber:         100,
+			Topics:              []common.Hash{common.HexToHash("0x03")},
+			TxHash:              common.HexToHash("0x02"),
+			TxIndex:             3,
+			Index:               5,
+			TransactionLogIndex: &txnLogIndex,
+		}},
+	}
+
+	cfxReceipt := cfxbridge.ConvertReceipt(ethReceipt, chainId)
+	receipts := ConvertReceipts([]*store.TransactionReceipt{{CfxReceipt: cfxReceipt}})
+	assert.Equal(t, 1, len(receipts))
+
+	receipt := receipts[0]
+	assert.Equal(t, ethReceipt.BlockHash, receipt.BlockHash)
+	assert.Equal(t, ethReceipt.BlockNumber, receipt.BlockNumber)
+	assert.Equal(t, ethReceipt.ContractAddress, receipt.ContractAddress)
+	assert.Nil(t, receipt.To)
+	assert.Equal(t, ethReceipt.From, receipt.From)
+	assert.Equal(t, ethReceipt.Status, receipt.Status)
+	assert.Equal(t, ethReceipt.TransactionIndex, receipt.TransactionIndex)
+	assert.Nil(t, receipt.Root)
+
+	assert.Equal(t, 1, len(receipt.Logs))
+	assert.Equal(t, internalContract, receipt.Logs[0].Address)
+	assert.Equal(t, ethReceipt.Logs[0].Topics, receipt.Logs[0].Topics)
+	assert.Equal(t, ethReceipt.Logs[0].Index, receipt.Logs[0].Index)
+	assert.Equal(t, ethReceipt.Logs[0].TransactionLogIndex, receipt.Logs[0].TransactionLogIndex)
+}
+
+func TestConvertNilValues(t *testing.T) {
+	assert.Nil(t, ConvertTx(nil, nil))
+	assert.Nil(t, ConvertBlockHeader(nil, nil))
+	assert.Nil(t, ConvertBlockSummary(nil, nil))
+	assert.Nil(t, ConvertBlock(nil, nil))
+	assert.Nil(t, ConvertReceipt(nil, nil))
+	assert.Nil(t, ConvertLog(nil, nil))
+	assert.Empty(t, ConvertReceipts(nil))
+}
+
+func TestConvertBlockWithoutExtra(t *testing.T) {
+	to := common.HexToAddress("0x0888000000000000000000000000000000000006")
+	ethBlock := &ethTypes.Block{
+		Number:     big.NewInt(100),
+		Hash:       common.HexToHash("0x01"),
+		ParentHash: common.HexToHash("0x02"),
+		Difficulty: big.NewInt(0),
+		GasLimit:   30000000,
+		Miner:      common.HexToAddress("0x1a642f0e3c3af545e7acbd38b07251b3990914f1"),
+		Timestamp:  1,
+		Transactions: *ethTypes.NewTxOrHashListByTxs([]ethTypes.TransactionDetail{{
+			BlockHash: &common.Hash{},
+			From:      common.HexToAddress("0x1a642f0e3c3af545e7acbd38b07251b3990914f1"),
+			Hash:      common.HexToHash("0x03"),
+			To:        &to,
+			GasPrice:  big.NewInt(1),
+			Value:     big.NewInt(0),
+			V:         big.NewInt(27), // non-EIP155 tx
+			R:         big.NewInt(1),
+			S:         big.NewInt(1),
+		}}),
+	}
+
+	cfxBlock := cfxbridge.ConvertBlock(ethBlock, 71)
+
+	block := ConvertBlock(cfxBlock, nil)
+	assert.Equal(t, ethBlock.Number, block.Number)
+	assert.Equal(t, ethBlock.Hash, block.Hash)
+
+	txs := block.Transactions.Transactions()
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, &to, txs[0].To)
+	assert.Nil(t, txs[0].ChainID)
+}
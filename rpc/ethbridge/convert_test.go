@@ -21,6 +21,9 @@ var (
 	cfxClient sdk.ClientOperator
 
 	ethNetworkId *uint64
+
+	// error to setup testnet clients, so that only tests requiring testnet are skipped
+	setupErr error
 )
 
 func setup() error {
@@ -54,9 +57,7 @@ func teardown() (err error) {
 }
 
 func TestMain(m *testing.M) {
-	if err := setup(); err != nil {
-		panic(errors.WithMessage(err, "failed to setup"))
-	}
+	setupErr = setup()
 
 	code := m.Run()
 
@@ -67,7 +68,16 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// requireTestnet skips the test if testnet fullnodes unavailable, e.g. running offline.
+func requireTestnet(t *testing.T) {
+	if setupErr != nil {
+		t.Skipf("Testnet unavailable: %v", setupErr)
+	}
+}
+
 func TestConvertBlockHeader(t *testing.T) {
+	requireTestnet(t)
+
 	blockNum := ethTypes.BlockNumber(64630500)
 
 	ethBlock, err := ethClient.Eth.BlockByNumber(blockNum, false)
@@ -85,6 +95,8 @@ func TestConvertBlockHeader(t *testing.T) {
 }
 
 func TestConvertReceipt(t *testing.T) {
+	requireTestnet(t)
+
 	txHash := "0xff2438365f72360a0eb60faf217b4d2ea2cc3599d59f5141113b68a58802452c"
 	ethTxHash := common.HexToHash(txHash)
 
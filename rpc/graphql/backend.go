@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// Backend provides evm space chain data for GraphQL queries, and returns nil if not found.
+type Backend interface {
+	LatestBlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.TransactionDetail, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+	// Logs returns event logs of the filter, whose block range is always specified.
+	Logs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error)
+}
+
+// StoreBackend backend to query evm space chain data from database store.
+type StoreBackend struct {
+	db      *mysql.MysqlStore
+	handler *handler.EthStoreHandler
+
+	// to fetch the network id to bridge contract addresses of log filter
+	clientProvider *node.EthClientProvider
+	networkId      atomic.Value
+}
+
+func NewStoreBackend(db *mysql.MysqlStore, clientProvider *node.EthClientProvider) *StoreBackend {
+	return &StoreBackend{
+		db:             db,
+		handler:        handler.NewEthStoreHandler(db, nil),
+		clientProvider: clientProvider,
+	}
+}
+
+func (b *StoreBackend) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	// for evm space, epoch number is the same as block number
+	bn, ok, err := b.db.MaxEpoch()
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+
+	return bn, nil
+}
+
+func (b *StoreBackend) BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error) {
+	blockNum := types.BlockNumber(bn)
+	block, err := b.handler.GetBlockByNumber(ctx, &blockNum, true)
+
+	return block, b.ignoreNotFound(err)
+}
+
+func (b *StoreBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := b.handler.GetBlockByHash(ctx, hash, true)
+	return block, b.ignoreNotFound(err)
+}
+
+func (b *StoreBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.TransactionDetail, error) {
+	tx, err := b.handler.GetTransactionByHash(ctx, hash)
+	return tx, b.ignoreNotFound(err)
+}
+
+func (b *StoreBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.handler.GetTransactionReceipt(ctx, hash)
+	return receipt, b.ignoreNotFound(err)
+}
+
+// BlockReceipts returns the transaction receipts of the block, which is not required by
+// GraphQL queries but shared with other typed query services (eg., gRPC).
+func (b *StoreBackend) BlockReceipts(ctx context.Context, bn uint64) ([]*types.Receipt, error) {
+	blockNum := types.BlockNumber(bn)
+	receipts, err := b.handler.GetBlockReceipts(ctx, &types.BlockNumberOrHash{BlockNumber: &blockNum})
+
+	return receipts, b.ignoreNotFound(err)
+}
+
+func (b *StoreBackend) Logs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error) {
+	networkId, err := b.getNetworkId()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get network id")
+	}
+
+	bnFrom, bnTo := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	sfilter := store.ParseEthLogFilter(bnFrom, bnTo, filter, networkId)
+
+	return b.handler.GetLogs(ctx, sfilter)
+}
+
+func (b *StoreBackend) getNetworkId() (uint32, error) {
+	if val := b.networkId.Load(); val != nil {
+		return val.(uint32), nil
+	}
+
+	w3c, err := b.clientProvider.GetClientRandom()
+	if err != nil {
+		return 0, err
+	}
+
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil {
+		return 0, err
+	}
+
+	networkId := uint32(*chainId)
+	b.networkId.Store(networkId)
+
+	return networkId, nil
+}
+
+func (b *StoreBackend) ignoreNotFound(err error) error {
+	if err != nil && b.db.IsRecordNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,96 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+type ctxKeyComplexityBudget struct{}
+
+// ErrQueryTooComplex is returned if query requires too many data loads from backend.
+var ErrQueryTooComplex = errors.New("query too complex")
+
+// complexityBudget remaining number of data loads from backend to execute the query, which is
+// shared by the resolvers executed in parallel.
+type complexityBudget struct {
+	remaining int64
+}
+
+func withComplexityBudget(ctx context.Context, budget int64) context.Context {
+	return context.WithValue(ctx, ctxKeyComplexityBudget{}, &complexityBudget{remaining: budget})
+}
+
+// charge consumes the complexity budget in context if any, or returns error if exhausted.
+func charge(ctx context.Context) error {
+	budget, ok := ctx.Value(ctxKeyComplexityBudget{}).(*complexityBudget)
+	if !ok {
+		return nil
+	}
+
+	if atomic.AddInt64(&budget.remaining, -1) < 0 {
+		return ErrQueryTooComplex
+	}
+
+	return nil
+}
+
+// meteredBackend charges the complexity budget of query for each data load from backend, so that
+// queries fanning out too many loads (e.g. nested `transaction` of each log) are aborted, which is
+// not bounded by the max depth of query.
+type meteredBackend struct {
+	Backend
+}
+
+func (b *meteredBackend) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	if err := charge(ctx); err != nil {
+		return 0, err
+	}
+
+	return b.Backend.LatestBlockNumber(ctx)
+}
+
+func (b *meteredBackend) BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.Backend.BlockByNumber(ctx, bn)
+}
+
+func (b *meteredBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.Backend.BlockByHash(ctx, hash)
+}
+
+func (b *meteredBackend) TransactionByHash(
+	ctx context.Context, hash common.Hash,
+) (*types.TransactionDetail, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.Backend.TransactionByHash(ctx, hash)
+}
+
+func (b *meteredBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.Backend.TransactionReceipt(ctx, hash)
+}
+
+func (b *meteredBackend) Logs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.Backend.Logs(ctx, filter)
+}
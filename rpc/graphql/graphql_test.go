@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBackend struct {
+	blocks   []*types.Block
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newMockBackend() *mockBackend {
+	b := &mockBackend{receipts: make(map[common.Hash]*types.Receipt)}
+
+	for bn := int64(0); bn < 3; bn++ {
+		blockHash := common.BigToHash(big.NewInt(100 + bn))
+		txHash := common.BigToHash(big.NewInt(200 + bn))
+		txIndex := uint64(0)
+
+		tx := types.TransactionDetail{
+			BlockHash:        &blockHash,
+			BlockNumber:      big.NewInt(bn),
+			Hash:             txHash,
+			TransactionIndex: &txIndex,
+			Value:            big.NewInt(bn),
+			GasPrice:         big.NewInt(1),
+		}
+
+		block := &types.Block{
+			Number:       big.NewInt(bn),
+			Hash:         blockHash,
+			ParentHash:   common.BigToHash(big.NewInt(99 + bn)),
+			Difficulty:   big.NewInt(0),
+			Transactions: *types.NewTxOrHashListByTxs([]types.TransactionDetail{tx}),
+		}
+		b.blocks = append(b.blocks, block)
+
+		status := uint64(1)
+		b.receipts[txHash] = &types.Receipt{
+			BlockHash:       blockHash,
+			BlockNumber:     uint64(bn),
+			TransactionHash: txHash,
+			Status:          &status,
+			GasUsed:         21000,
+			Logs: []*types.Log{{
+				Address:     common.HexToAddress("0x1"),
+				BlockNumber: uint64(bn),
+				TxHash:      txHash,
+				Topics:      []common.Hash{common.HexToHash("0xa")},
+			}},
+		}
+	}
+
+	return b
+}
+
+func (b *mockBackend) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(b.blocks) - 1), nil
+}
+
+func (b *mockBackend) BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error) {
+	if bn >= uint64(len(b.blocks)) {
+		return nil, nil
+	}
+
+	return b.blocks[bn], nil
+}
+
+func (b *mockBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash == hash {
+			return block, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (b *mockBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.TransactionDetail, error) {
+	for _, block := range b.blocks {
+		for _, tx := range block.Transactions.Transactions() {
+			if tx.Hash == hash {
+				return &tx, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (b *mockBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *mockBackend) Logs(ctx context.Context, filter *types.FilterQuery) (logs []types.Log, err error) {
+	for bn := uint64(*filter.FromBlock); bn <= uint64(*filter.ToBlock) && bn < uint64(len(b.blocks)); bn++ {
+		for _, log := range b.receipts[b.blocks[bn].Transactions.Transactions()[0].Hash].Logs {
+			logs = append(logs, *log)
+		}
+	}
+
+	return logs, nil
+}
+
+func execQuery(t *testing.T, query string) (map[string]interface{}, []string) {
+	conf := Config{MaxBlocks: 2, MaxBlockRange: 10, MaxDepth: 10}
+	schema := graphqlgo.MustParseSchema(schema, &Resolver{conf: conf, backend: newMockBackend()})
+
+	resp := schema.Exec(context.Background(), query, "", nil)
+
+	var errs []string
+	for _, err := range resp.Errors {
+		errs = append(errs, err.Message)
+	}
+
+	var data map[string]interface{}
+	if len(resp.Data) > 0 {
+		assert.NoError(t, json.Unmarshal(resp.Data, &data))
+	}
+
+	return data, errs
+}
+
+func TestQueryBlock(t *testing.T) {
+	data, errs := execQuery(t, `{
+		block { number parent { number } transactions { value status gasUsed logs { index } } }
+	}`)
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]interface{}{
+		"block": map[string]interface{}{
+			"number": float64(2),
+			"parent": map[string]interface{}{"number": float64(1)},
+			"transactions": []interface{}{map[string]interface{}{
+				"value":   "0x2",
+				"status":  float64(1),
+				"gasUsed": float64(21000),
+				"logs":    []interface{}{map[string]interface{}{"index": float64(0)}},
+			}},
+		},
+	}, data)
+
+	data, errs = execQuery(t, `{ block(number: "0x5") { number } }`)
+	assert.Empty(t, errs)
+	assert.Nil(t, data["block"])
+}
+
+func TestQueryBlocksAndLogs(t *testing.T) {
+	data, errs := execQuery(t, `{ blocks(from: 1) { number } }`)
+	assert.Empty(t, errs)
+	assert.Equal(t, 2, len(data["blocks"].([]interface{})))
+
+	_, errs = execQuery(t, `{ blocks(from: 0) { number } }`)
+	assert.NotEmpty(t, errs)
+
+	data, errs = execQuery(t, `{ logs(filter: {fromBlock: 0, toBlock: 2}) { transaction { hash } } }`)
+	assert.Empty(t, errs)
+	assert.Equal(t, 3, len(data["logs"].([]interface{})))
+
+	_, errs = execQuery(t, `{ logs(filter: {fromBlock: 0, toBlock: 100}) { index } }`)
+	assert.NotEmpty(t, errs)
+}
+
+func TestQueryComplexityBounded(t *testing.T) {
+	conf := Config{MaxBlocks: 10, MaxBlockRange: 10, MaxDepth: 10, MaxComplexity: 4}
+	schema := graphqlgo.MustParseSchema(schema, &Resolver{conf: conf, backend: &meteredBackend{newMockBackend()}})
+
+	// 3 blocks loaded
+	ctx := withComplexityBudget(context.Background(), conf.MaxComplexity)
+	resp := schema.Exec(ctx, `{ blocks(from: 0, to: 2) { number } }`, "", nil)
+	assert.Empty(t, resp.Errors)
+
+	// 3 blocks and 2 parents (except genesis) loaded
+	ctx = withComplexityBudget(context.Background(), conf.MaxComplexity)
+	resp = schema.Exec(ctx, `{ blocks(from: 0, to: 2) { parent { number } } }`, "", nil)
+	assert.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0].Message, ErrQueryTooComplex.Error())
+}
+
+func TestServeRequestBodyBounded(t *testing.T) {
+	conf := Config{MaxBlocks: 10, MaxBlockRange: 10, MaxDepth: 10, MaxBodySize: 64, Timeout: time.Second}
+	server := MustNewServer(conf, newMockBackend())
+
+	serve := func(query string) int {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(`{ block { number } }`))
+	assert.NotEqual(t, http.StatusOK, serve(`{ block { number `+strings.Repeat("hash ", 20)+` } }`))
+}
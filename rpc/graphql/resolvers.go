@@ -0,0 +1,549 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// Long 64 bit integer of GraphQL scalar type `Long`.
+type Long int64
+
+// ImplementsGraphQLType returns true if Long implements the specified GraphQL type.
+func (Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		var v uint64
+		var err error
+
+		if strings.HasPrefix(input, "0x") {
+			v, err = hexutil.DecodeUint64(input)
+		} else {
+			v, err = strconv.ParseUint(input, 10, 64)
+		}
+
+		*l = Long(v)
+		return err
+	case int32:
+		*l = Long(input)
+	case int64:
+		*l = Long(input)
+	case float64:
+		*l = Long(input)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+
+	return nil
+}
+
+func newLongPtr(v uint64) *Long {
+	l := Long(v)
+	return &l
+}
+
+func newBigPtr(v *big.Int) *hexutil.Big {
+	if v == nil {
+		return nil
+	}
+
+	return (*hexutil.Big)(v)
+}
+
+func bigOrZero(v *big.Int) hexutil.Big {
+	if v == nil {
+		return hexutil.Big{}
+	}
+
+	return hexutil.Big(*v)
+}
+
+// Resolver resolves the root GraphQL queries.
+type Resolver struct {
+	conf    Config
+	backend Backend
+}
+
+func (r *Resolver) latestBlockNumber(ctx context.Context) (uint64, error) {
+	bn, err := r.backend.LatestBlockNumber(ctx)
+	return bn, errors.WithMessage(err, "failed to get latest block number")
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	var block *types.Block
+	var err error
+
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, errors.New("only one of number or hash must be specified")
+	case args.Hash != nil:
+		block, err = r.backend.BlockByHash(ctx, *args.Hash)
+	default:
+		var bn uint64
+		if args.Number != nil {
+			bn = uint64(*args.Number)
+		} else if bn, err = r.latestBlockNumber(ctx); err != nil {
+			return nil, err
+		}
+
+		block, err = r.backend.BlockByNumber(ctx, bn)
+	}
+
+	if err != nil || block == nil {
+		return nil, err
+	}
+
+	return &Block{r: r, block: block}, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From Long
+	To   *Long
+}) ([]*Block, error) {
+	from := uint64(args.From)
+
+	var to uint64
+	if args.To != nil {
+		to = uint64(*args.To)
+	} else {
+		latest, err := r.latestBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		to = latest
+	}
+
+	if from > to {
+		return []*Block{}, nil
+	}
+
+	if to-from+1 > r.conf.MaxBlocks {
+		return nil, errors.Errorf("block range exceeds the max limit %v", r.conf.MaxBlocks)
+	}
+
+	blocks := make([]*Block, 0, to-from+1)
+	for bn := from; bn <= to; bn++ {
+		block, err := r.backend.BlockByNumber(ctx, bn)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == nil { // not synced yet
+			break
+		}
+
+		blocks = append(blocks, &Block{r: r, block: block})
+	}
+
+	return blocks, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx, err := r.backend.TransactionByHash(ctx, args.Hash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+
+	return &Transaction{r: r, tx: tx}, nil
+}
+
+// FilterCriteria criteria of logs query.
+type FilterCriteria struct {
+	FromBlock *Long
+	ToBlock   *Long
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	var from, to uint64
+
+	if args.Filter.FromBlock == nil || args.Filter.ToBlock == nil {
+		latest, err := r.latestBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		from, to = latest, latest
+	}
+
+	if args.Filter.FromBlock != nil {
+		from = uint64(*args.Filter.FromBlock)
+	}
+
+	if args.Filter.ToBlock != nil {
+		to = uint64(*args.Filter.ToBlock)
+	}
+
+	if from > to {
+		return nil, errors.New("invalid block range (from > to)")
+	}
+
+	if to-from+1 > r.conf.MaxBlockRange {
+		return nil, errors.Errorf("block range exceeds the max limit %v", r.conf.MaxBlockRange)
+	}
+
+	return r.logs(ctx, from, to, args.Filter.Addresses, args.Filter.Topics)
+}
+
+func (r *Resolver) logs(
+	ctx context.Context, from, to uint64, addresses *[]common.Address, topics *[][]common.Hash,
+) ([]*Log, error) {
+	fromBlock, toBlock := types.BlockNumber(from), types.BlockNumber(to)
+	filter := &types.FilterQuery{FromBlock: &fromBlock, ToBlock: &toBlock}
+
+	if addresses != nil {
+		filter.Addresses = *addresses
+	}
+
+	if topics != nil {
+		filter.Topics = *topics
+	}
+
+	if len(filter.Topics) > 4 {
+		return nil, errors.New("too many topics")
+	}
+
+	logs, err := r.backend.Logs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Log, 0, len(logs))
+	for i := range logs {
+		result = append(result, &Log{r: r, log: &logs[i]})
+	}
+
+	return result, nil
+}
+
+// Block resolves GraphQL type `Block`.
+type Block struct {
+	r     *Resolver
+	block *types.Block
+}
+
+func (b *Block) Number() Long {
+	return Long(b.block.Number.Int64())
+}
+
+func (b *Block) Hash() common.Hash {
+	return b.block.Hash
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.Number.Sign() == 0 {
+		return nil, nil
+	}
+
+	block, err := b.r.backend.BlockByHash(ctx, b.block.ParentHash)
+	if err != nil || block == nil {
+		return nil, err
+	}
+
+	return &Block{r: b.r, block: block}, nil
+}
+
+func (b *Block) Nonce() hexutil.Bytes {
+	if b.block.Nonce == nil {
+		return hexutil.Bytes{}
+	}
+
+	return b.block.Nonce[:]
+}
+
+func (b *Block) TransactionsRoot() common.Hash {
+	return b.block.TransactionsRoot
+}
+
+func (b *Block) TransactionCount() Long {
+	return Long(len(b.block.Transactions.Transactions()))
+}
+
+func (b *Block) StateRoot() common.Hash {
+	return b.block.StateRoot
+}
+
+func (b *Block) ReceiptsRoot() common.Hash {
+	return b.block.ReceiptsRoot
+}
+
+func (b *Block) Miner() common.Address {
+	return b.block.Miner
+}
+
+func (b *Block) ExtraData() hexutil.Bytes {
+	return b.block.ExtraData
+}
+
+func (b *Block) GasLimit() Long {
+	return Long(b.block.GasLimit)
+}
+
+func (b *Block) GasUsed() Long {
+	return Long(b.block.GasUsed)
+}
+
+func (b *Block) BaseFeePerGas() *hexutil.Big {
+	return newBigPtr(b.block.BaseFeePerGas)
+}
+
+func (b *Block) Timestamp() Long {
+	return Long(b.block.Timestamp)
+}
+
+func (b *Block) LogsBloom() hexutil.Bytes {
+	return b.block.LogsBloom.Bytes()
+}
+
+func (b *Block) MixHash() common.Hash {
+	if b.block.MixHash == nil {
+		return common.Hash{}
+	}
+
+	return *b.block.MixHash
+}
+
+func (b *Block) Difficulty() hexutil.Big {
+	return bigOrZero(b.block.Difficulty)
+}
+
+func (b *Block) Transactions() []*Transaction {
+	txs := b.block.Transactions.Transactions()
+
+	result := make([]*Transaction, 0, len(txs))
+	for i := range txs {
+		result = append(result, &Transaction{r: b.r, tx: &txs[i]})
+	}
+
+	return result
+}
+
+func (b *Block) TransactionAt(args struct{ Index Long }) *Transaction {
+	txs := b.block.Transactions.Transactions()
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil
+	}
+
+	return &Transaction{r: b.r, tx: &txs[args.Index]}
+}
+
+// BlockFilterCriteria criteria of logs query within block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	bn := b.block.Number.Uint64()
+	return b.r.logs(ctx, bn, bn, args.Filter.Addresses, args.Filter.Topics)
+}
+
+// Transaction resolves GraphQL type `Transaction`.
+type Transaction struct {
+	r  *Resolver
+	tx *types.TransactionDetail
+
+	mu      sync.Mutex
+	receipt *types.Receipt // lazy loaded
+}
+
+func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.receipt != nil {
+		return t.receipt, nil
+	}
+
+	receipt, err := t.r.backend.TransactionReceipt(ctx, t.tx.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	t.receipt = receipt
+	return receipt, nil
+}
+
+func (t *Transaction) Hash() common.Hash {
+	return t.tx.Hash
+}
+
+func (t *Transaction) Nonce() Long {
+	return Long(t.tx.Nonce)
+}
+
+func (t *Transaction) Index() *Long {
+	if t.tx.TransactionIndex == nil {
+		return nil
+	}
+
+	return newLongPtr(*t.tx.TransactionIndex)
+}
+
+func (t *Transaction) From() common.Address {
+	return t.tx.From
+}
+
+func (t *Transaction) To() *common.Address {
+	return t.tx.To
+}
+
+func (t *Transaction) Value() hexutil.Big {
+	return bigOrZero(t.tx.Value)
+}
+
+func (t *Transaction) GasPrice() hexutil.Big {
+	return bigOrZero(t.tx.GasPrice)
+}
+
+func (t *Transaction) MaxFeePerGas() *hexutil.Big {
+	return newBigPtr(t.tx.MaxFeePerGas)
+}
+
+func (t *Transaction) MaxPriorityFeePerGas() *hexutil.Big {
+	return newBigPtr(t.tx.MaxPriorityFeePerGas)
+}
+
+func (t *Transaction) Gas() Long {
+	return Long(t.tx.Gas)
+}
+
+func (t *Transaction) InputData() hexutil.Bytes {
+	return t.tx.Input
+}
+
+func (t *Transaction) Type() *Long {
+	if t.tx.Type == nil {
+		return nil
+	}
+
+	return newLongPtr(*t.tx.Type)
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if t.tx.BlockHash == nil {
+		return nil, nil
+	}
+
+	block, err := t.r.backend.BlockByHash(ctx, *t.tx.BlockHash)
+	if err != nil || block == nil {
+		return nil, err
+	}
+
+	return &Block{r: t.r, block: block}, nil
+}
+
+func (t *Transaction) Status(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || receipt.Status == nil {
+		return nil, err
+	}
+
+	return newLongPtr(*receipt.Status), nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	return newLongPtr(receipt.GasUsed), nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	return newLongPtr(receipt.CumulativeGasUsed), nil
+}
+
+func (t *Transaction) EffectiveGasPrice(ctx context.Context) (*hexutil.Big, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	return newBigPtr(new(big.Int).SetUint64(receipt.EffectiveGasPrice)), nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context) (*common.Address, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	return receipt.ContractAddress, nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	logs := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		logs = append(logs, &Log{r: t.r, log: log, tx: t})
+	}
+
+	return &logs, nil
+}
+
+// Log resolves GraphQL type `Log`.
+type Log struct {
+	r   *Resolver
+	log *types.Log
+	tx  *Transaction // lazy loaded
+}
+
+func (l *Log) Index() Long {
+	return Long(l.log.Index)
+}
+
+func (l *Log) Address() common.Address {
+	return l.log.Address
+}
+
+func (l *Log) Topics() []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data() hexutil.Bytes {
+	return l.log.Data
+}
+
+func (l *Log) Transaction(ctx context.Context) (*Transaction, error) {
+	if l.tx != nil {
+		return l.tx, nil
+	}
+
+	tx, err := l.r.backend.TransactionByHash(ctx, l.log.TxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx == nil {
+		return nil, errors.Errorf("transaction %v not found", l.log.TxHash)
+	}
+
+	return &Transaction{r: l.r, tx: tx}, nil
+}
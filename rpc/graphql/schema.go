@@ -0,0 +1,105 @@
+package graphql
+
+// schema GraphQL schema of evm space chain data in store, which is a subset of geth's GraphQL
+// schema (EIP-1767) without account state and pending data, since only the synced blocks,
+// transactions, receipts and event logs are available in store.
+const schema string = `
+    # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes32
+    # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
+    scalar Address
+    # Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes
+    # BigInt is a large integer, represented as 0x-prefixed hexadecimal.
+    scalar BigInt
+    # Long is a 64 bit unsigned integer, input as decimal or 0x-prefixed hexadecimal.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    type Query {
+        # Block fetches a block by number or hash, or the latest synced block if neither specified.
+        block(number: Long, hash: Bytes32): Block
+        # Blocks returns all the blocks in the range [from, to], where "to" defaults to the latest
+        # synced block.
+        blocks(from: Long!, to: Long): [Block!]!
+        # Transaction returns a transaction by hash.
+        transaction(hash: Bytes32!): Transaction
+        # Logs returns the event logs matching the filter criteria.
+        logs(filter: FilterCriteria!): [Log!]!
+    }
+
+    # FilterCriteria encapsulates criteria passed to a logs query.
+    input FilterCriteria {
+        # Block number to start from, defaults to the latest synced block.
+        fromBlock: Long
+        # Block number to end at, defaults to the latest synced block.
+        toBlock: Long
+        # Contract addresses to match, empty for any contract.
+        addresses: [Address!]
+        # Topics to match at each position, empty for any topic at the position.
+        topics: [[Bytes32!]!]
+    }
+
+    # BlockFilterCriteria encapsulates criteria passed to a logs query within a block.
+    input BlockFilterCriteria {
+        addresses: [Address!]
+        topics: [[Bytes32!]!]
+    }
+
+    type Block {
+        number: Long!
+        hash: Bytes32!
+        parent: Block
+        nonce: Bytes!
+        transactionsRoot: Bytes32!
+        transactionCount: Long!
+        stateRoot: Bytes32!
+        receiptsRoot: Bytes32!
+        miner: Address!
+        extraData: Bytes!
+        gasLimit: Long!
+        gasUsed: Long!
+        baseFeePerGas: BigInt
+        timestamp: Long!
+        logsBloom: Bytes!
+        mixHash: Bytes32!
+        difficulty: BigInt!
+        transactions: [Transaction!]!
+        transactionAt(index: Long!): Transaction
+        logs(filter: BlockFilterCriteria!): [Log!]!
+    }
+
+    type Transaction {
+        hash: Bytes32!
+        nonce: Long!
+        index: Long
+        from: Address!
+        to: Address
+        value: BigInt!
+        gasPrice: BigInt!
+        maxFeePerGas: BigInt
+        maxPriorityFeePerGas: BigInt
+        gas: Long!
+        inputData: Bytes!
+        type: Long
+        block: Block
+        # Receipt fields, which are null if receipt not found.
+        status: Long
+        gasUsed: Long
+        cumulativeGasUsed: Long
+        effectiveGasPrice: BigInt
+        createdContract: Address
+        logs: [Log!]
+    }
+
+    type Log {
+        index: Long!
+        address: Address!
+        topics: [Bytes32!]!
+        data: Bytes!
+        transaction: Transaction!
+    }
+`
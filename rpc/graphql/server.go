@@ -0,0 +1,130 @@
+package graphql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sirupsen/logrus"
+)
+
+// GraphQL server configurations
+type Config struct {
+	// HTTP endpoint to serve GraphQL queries at path `/graphql`, empty means disabled
+	Endpoint string
+	// max number of blocks of `blocks` query
+	MaxBlocks uint64 `default:"100"`
+	// max block range of `logs` query
+	MaxBlockRange uint64 `default:"1000"`
+	// max depth of nested query
+	MaxDepth int `default:"10"`
+	// max number of data loads from backend to execute each query, e.g. `parent` of each block
+	MaxComplexity int64 `default:"1000"`
+	// max size of request body in bytes
+	MaxBodySize int64 `default:"1048576"`
+	// timeout to execute each query
+	Timeout time.Duration `default:"10s"`
+}
+
+// Server serves GraphQL queries over HTTP, so that dashboard builders could query blocks,
+// transactions, receipts and event logs together without chaining many JSON-RPC calls.
+type Server struct {
+	conf    Config
+	handler http.Handler
+
+	// HTTP middlewares executed in order, e.g. authentication and rate limit
+	middlewares []handlers.Middleware
+}
+
+// MustNewServerFromViper creates GraphQL server from viper settings of the specified key,
+// or returns false if not enabled.
+func MustNewServerFromViper(key string, backend Backend, middlewares ...handlers.Middleware) (*Server, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if len(conf.Endpoint) == 0 {
+		return nil, false
+	}
+
+	return MustNewServer(conf, backend, middlewares...), true
+}
+
+func MustNewServer(conf Config, backend Backend, middlewares ...handlers.Middleware) *Server {
+	schema := graphqlgo.MustParseSchema(
+		schema,
+		&Resolver{conf: conf, backend: &meteredBackend{backend}},
+		graphqlgo.MaxDepth(conf.MaxDepth),
+	)
+
+	return &Server{
+		conf:        conf,
+		handler:     &relay.Handler{Schema: schema},
+		middlewares: middlewares,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST method supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.conf.MaxBodySize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.conf.MaxBodySize)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.conf.Timeout)
+	defer cancel()
+
+	if s.conf.MaxComplexity > 0 {
+		ctx = withComplexityBudget(ctx, s.conf.MaxComplexity)
+	}
+
+	s.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Handler returns the HTTP handler wrapped with middlewares.
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = s
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// MustServeGraceful serves GraphQL queries over HTTP until graceful shutdown.
+func (s *Server) MustServeGraceful(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logger := logrus.WithField("endpoint", s.conf.Endpoint)
+
+	listener, err := net.Listen("tcp", s.conf.Endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to endpoint for GraphQL server")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", s.Handler())
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	logger.Info("GraphQL HTTP server started")
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.WithError(err).Error("Failed to shutdown GraphQL HTTP server")
+	}
+}
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"math/big"
+
+	"github.com/Conflux-Chain/confura/rpc/grpc/pb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+)
+
+func convertBlock(block *types.Block, fullTxs bool) *pb.Block {
+	result := &pb.Block{
+		Number:           bigToUint64(block.Number),
+		Hash:             block.Hash.Bytes(),
+		ParentHash:       block.ParentHash.Bytes(),
+		Timestamp:        block.Timestamp,
+		Miner:            block.Miner.Bytes(),
+		StateRoot:        block.StateRoot.Bytes(),
+		TransactionsRoot: block.TransactionsRoot.Bytes(),
+		ReceiptsRoot:     block.ReceiptsRoot.Bytes(),
+		LogsBloom:        block.LogsBloom.Bytes(),
+		GasLimit:         block.GasLimit,
+		GasUsed:          block.GasUsed,
+		BaseFeePerGas:    bigToBytes(block.BaseFeePerGas),
+		Difficulty:       bigToBytes(block.Difficulty),
+		ExtraData:        block.ExtraData,
+		Size:             block.Size,
+	}
+
+	txs := block.Transactions.Transactions()
+	for i := range txs {
+		tx := &txs[i]
+		result.TransactionHashes = append(result.TransactionHashes, tx.Hash.Bytes())
+
+		if fullTxs {
+			result.Transactions = append(result.Transactions, convertTransaction(tx))
+		}
+	}
+
+	for _, hash := range block.Transactions.Hashes() {
+		result.TransactionHashes = append(result.TransactionHashes, hash.Bytes())
+	}
+
+	return result
+}
+
+func convertTransaction(tx *types.TransactionDetail) *pb.Transaction {
+	result := &pb.Transaction{
+		Hash:                 tx.Hash.Bytes(),
+		Nonce:                tx.Nonce,
+		BlockNumber:          bigToUint64(tx.BlockNumber),
+		From:                 tx.From.Bytes(),
+		To:                   addressToBytes(tx.To),
+		Value:                bigToBytes(tx.Value),
+		Gas:                  tx.Gas,
+		GasPrice:             bigToBytes(tx.GasPrice),
+		MaxFeePerGas:         bigToBytes(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: bigToBytes(tx.MaxPriorityFeePerGas),
+		Input:                tx.Input,
+		Status:               tx.Status,
+	}
+
+	if tx.BlockHash != nil {
+		result.BlockHash = tx.BlockHash.Bytes()
+	}
+
+	if tx.TransactionIndex != nil {
+		result.TransactionIndex = *tx.TransactionIndex
+	}
+
+	if tx.Type != nil {
+		result.Type = *tx.Type
+	}
+
+	return result
+}
+
+func convertReceipt(receipt *types.Receipt) *pb.Receipt {
+	result := &pb.Receipt{
+		TransactionHash:   receipt.TransactionHash.Bytes(),
+		TransactionIndex:  receipt.TransactionIndex,
+		BlockHash:         receipt.BlockHash.Bytes(),
+		BlockNumber:       receipt.BlockNumber,
+		From:              receipt.From.Bytes(),
+		To:                addressToBytes(receipt.To),
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: receipt.EffectiveGasPrice,
+		ContractAddress:   addressToBytes(receipt.ContractAddress),
+		LogsBloom:         receipt.LogsBloom.Bytes(),
+		Status:            receipt.Status,
+		TxExecErrorMsg:    receipt.TxExecErrorMsg,
+	}
+
+	for _, log := range receipt.Logs {
+		result.Logs = append(result.Logs, convertLog(log))
+	}
+
+	return result
+}
+
+func convertLog(log *types.Log) *pb.Log {
+	result := &pb.Log{
+		Address:          log.Address.Bytes(),
+		Data:             log.Data,
+		BlockNumber:      log.BlockNumber,
+		BlockHash:        log.BlockHash.Bytes(),
+		TransactionHash:  log.TxHash.Bytes(),
+		TransactionIndex: uint64(log.TxIndex),
+		LogIndex:         uint64(log.Index),
+		Removed:          log.Removed,
+	}
+
+	for _, topic := range log.Topics {
+		result.Topics = append(result.Topics, topic.Bytes())
+	}
+
+	return result
+}
+
+func bigToUint64(v *big.Int) uint64 {
+	if v == nil {
+		return 0
+	}
+
+	return v.Uint64()
+}
+
+func bigToBytes(v *big.Int) []byte {
+	if v == nil {
+		return nil
+	}
+
+	return v.Bytes()
+}
+
+func addressToBytes(addr *common.Address) []byte {
+	if addr == nil {
+		return nil
+	}
+
+	return addr.Bytes()
+}
@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/grpc/pb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockBackend struct {
+	blocks map[uint64]*types.Block
+	delay  time.Duration
+}
+
+func newMockBackend() *mockBackend {
+	txs := []types.TransactionDetail{{Hash: common.HexToHash("0xa1")}, {Hash: common.HexToHash("0xa2")}}
+	block := &types.Block{
+		Number:       big.NewInt(10),
+		Hash:         common.HexToHash("0x10"),
+		Transactions: *types.NewTxOrHashListByTxs(txs),
+	}
+
+	return &mockBackend{blocks: map[uint64]*types.Block{10: block}}
+}
+
+func (b *mockBackend) BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error) {
+	return b.blocks[bn], nil
+}
+
+func (b *mockBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash == hash {
+			return block, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (b *mockBackend) BlockReceipts(ctx context.Context, bn uint64) ([]*types.Receipt, error) {
+	block := b.blocks[bn]
+	if block == nil {
+		return nil, nil
+	}
+
+	var receipts []*types.Receipt
+	for i, tx := range block.Transactions.Transactions() {
+		receipts = append(receipts, &types.Receipt{
+			TransactionHash:  tx.Hash,
+			TransactionIndex: uint64(i),
+			BlockNumber:      bn,
+		})
+	}
+
+	return receipts, nil
+}
+
+func (b *mockBackend) Logs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(b.delay):
+	}
+
+	var logs []types.Log
+	for bn := uint64(*filter.FromBlock); bn <= uint64(*filter.ToBlock); bn++ {
+		logs = append(logs, types.Log{BlockNumber: bn, Address: filter.Addresses[0]})
+	}
+
+	return logs, nil
+}
+
+func newTestClient(t *testing.T, backend Backend) pb.EthClient {
+	server := NewServer(Config{MaxBlockRange: 100, Timeout: time.Second}, backend)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.server.Stop)
+
+	conn, err := grpcgo.Dial(
+		"bufnet",
+		grpcgo.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpcgo.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewEthClient(conn)
+}
+
+func TestGetBlock(t *testing.T) {
+	client := newTestClient(t, newMockBackend())
+
+	block, err := client.GetBlock(context.Background(), &pb.GetBlockRequest{
+		Block: &pb.BlockId{Id: &pb.BlockId_Number{Number: 10}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), block.Number)
+	assert.Equal(t, 2, len(block.TransactionHashes))
+	assert.Empty(t, block.Transactions)
+
+	block, err = client.GetBlock(context.Background(), &pb.GetBlockRequest{
+		Block:            &pb.BlockId{Id: &pb.BlockId_Hash{Hash: common.HexToHash("0x10").Bytes()}},
+		FullTransactions: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0xa2").Bytes(), block.Transactions[1].Hash)
+
+	_, err = client.GetBlock(context.Background(), &pb.GetBlockRequest{
+		Block: &pb.BlockId{Id: &pb.BlockId_Number{Number: 11}},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetReceipts(t *testing.T) {
+	client := newTestClient(t, newMockBackend())
+
+	stream, err := client.GetReceipts(context.Background(), &pb.GetReceiptsRequest{
+		Block: &pb.BlockId{Id: &pb.BlockId_Hash{Hash: common.HexToHash("0x10").Bytes()}},
+	})
+	require.NoError(t, err)
+
+	var hashes []common.Hash
+	for {
+		receipt, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		hashes = append(hashes, common.BytesToHash(receipt.TransactionHash))
+	}
+
+	assert.Equal(t, []common.Hash{common.HexToHash("0xa1"), common.HexToHash("0xa2")}, hashes)
+}
+
+func TestGetLogs(t *testing.T) {
+	backend := newMockBackend()
+	client := newTestClient(t, backend)
+
+	addr := common.HexToAddress("0x1")
+	filter := &pb.LogFilter{FromBlock: 1, ToBlock: 3, Addresses: [][]byte{addr.Bytes()}}
+
+	stream, err := client.GetLogs(context.Background(), filter)
+	require.NoError(t, err)
+
+	var bns []uint64
+	for {
+		log, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		assert.Equal(t, addr.Bytes(), log.Address)
+		bns = append(bns, log.BlockNumber)
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3}, bns)
+
+	// block range exceeds limit
+	stream, err = client.GetLogs(context.Background(), &pb.LogFilter{FromBlock: 1, ToBlock: 200})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// client deadline propagated to backend
+	backend.delay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	stream, err = client.GetLogs(ctx, filter)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
@@ -0,0 +1,234 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EthClient is the client API for Eth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EthClient interface {
+	// GetBlock returns the block by number or hash, or NOT_FOUND error if not found.
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	// GetReceipts streams the transaction receipts of the block by number or hash.
+	GetReceipts(ctx context.Context, in *GetReceiptsRequest, opts ...grpc.CallOption) (Eth_GetReceiptsClient, error)
+	// GetLogs streams the event logs matched by the filter in ascending order.
+	GetLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Eth_GetLogsClient, error)
+}
+
+type ethClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEthClient(cc grpc.ClientConnInterface) EthClient {
+	return &ethClient{cc}
+}
+
+func (c *ethClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/confura.eth.v1.Eth/GetBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetReceipts(ctx context.Context, in *GetReceiptsRequest, opts ...grpc.CallOption) (Eth_GetReceiptsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[0], "/confura.eth.v1.Eth/GetReceipts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethGetReceiptsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_GetReceiptsClient interface {
+	Recv() (*Receipt, error)
+	grpc.ClientStream
+}
+
+type ethGetReceiptsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethGetReceiptsClient) Recv() (*Receipt, error) {
+	m := new(Receipt)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ethClient) GetLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Eth_GetLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[1], "/confura.eth.v1.Eth/GetLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethGetLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_GetLogsClient interface {
+	Recv() (*Log, error)
+	grpc.ClientStream
+}
+
+type ethGetLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethGetLogsClient) Recv() (*Log, error) {
+	m := new(Log)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EthServer is the server API for Eth service.
+// All implementations must embed UnimplementedEthServer
+// for forward compatibility
+type EthServer interface {
+	// GetBlock returns the block by number or hash, or NOT_FOUND error if not found.
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	// GetReceipts streams the transaction receipts of the block by number or hash.
+	GetReceipts(*GetReceiptsRequest, Eth_GetReceiptsServer) error
+	// GetLogs streams the event logs matched by the filter in ascending order.
+	GetLogs(*LogFilter, Eth_GetLogsServer) error
+	mustEmbedUnimplementedEthServer()
+}
+
+// UnimplementedEthServer must be embedded to have forward compatible implementations.
+type UnimplementedEthServer struct {
+}
+
+func (UnimplementedEthServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedEthServer) GetReceipts(*GetReceiptsRequest, Eth_GetReceiptsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetReceipts not implemented")
+}
+func (UnimplementedEthServer) GetLogs(*LogFilter, Eth_GetLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+}
+func (UnimplementedEthServer) mustEmbedUnimplementedEthServer() {}
+
+// UnsafeEthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EthServer will
+// result in compilation errors.
+type UnsafeEthServer interface {
+	mustEmbedUnimplementedEthServer()
+}
+
+func RegisterEthServer(s grpc.ServiceRegistrar, srv EthServer) {
+	s.RegisterService(&Eth_ServiceDesc, srv)
+}
+
+func _Eth_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/confura.eth.v1.Eth/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetReceipts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetReceiptsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).GetReceipts(m, &ethGetReceiptsServer{stream})
+}
+
+type Eth_GetReceiptsServer interface {
+	Send(*Receipt) error
+	grpc.ServerStream
+}
+
+type ethGetReceiptsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethGetReceiptsServer) Send(m *Receipt) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Eth_GetLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).GetLogs(m, &ethGetLogsServer{stream})
+}
+
+type Eth_GetLogsServer interface {
+	Send(*Log) error
+	grpc.ServerStream
+}
+
+type ethGetLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethGetLogsServer) Send(m *Log) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Eth_ServiceDesc is the grpc.ServiceDesc for Eth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Eth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "confura.eth.v1.Eth",
+	HandlerType: (*EthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlock",
+			Handler:    _Eth_GetBlock_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetReceipts",
+			Handler:       _Eth_GetReceipts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetLogs",
+			Handler:       _Eth_GetLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "eth.proto",
+}
@@ -0,0 +1,124 @@
+// Package grpc serves the evm space chain data in store over gRPC, so that internal services
+// could access blocks, receipts and event logs with typed messages instead of JSON-RPC.
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/grpc/pb"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// gRPC server configurations
+type Config struct {
+	// endpoint to serve gRPC requests, empty means disabled
+	Endpoint string
+	// max block range of `GetLogs` request
+	MaxBlockRange uint64 `default:"1000"`
+	// max timeout to handle each request, which also applies if client deadline is later or not set
+	Timeout time.Duration `default:"10s"`
+	// whether to register server reflection service for tools like `grpcurl`
+	Reflection bool `default:"true"`
+}
+
+// Server serves gRPC requests until graceful shutdown.
+type Server struct {
+	conf   Config
+	server *grpcgo.Server
+}
+
+// MustNewServerFromViper creates gRPC server from viper settings of the specified key,
+// or returns false if not enabled.
+func MustNewServerFromViper(key string, backend Backend) (*Server, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if len(conf.Endpoint) == 0 {
+		return nil, false
+	}
+
+	return NewServer(conf, backend), true
+}
+
+func NewServer(conf Config, backend Backend) *Server {
+	server := grpcgo.NewServer(
+		grpcgo.UnaryInterceptor(newUnaryTimeoutInterceptor(conf.Timeout)),
+		grpcgo.StreamInterceptor(newStreamTimeoutInterceptor(conf.Timeout)),
+	)
+
+	pb.RegisterEthServer(server, &ethService{conf: conf, backend: backend})
+
+	if conf.Reflection {
+		reflection.Register(server)
+	}
+
+	return &Server{conf: conf, server: server}
+}
+
+// Serve serves gRPC requests on the listener until stopped.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// MustServeGraceful serves gRPC requests until graceful shutdown.
+func (s *Server) MustServeGraceful(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logger := logrus.WithField("endpoint", s.conf.Endpoint)
+
+	listener, err := net.Listen("tcp", s.conf.Endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to endpoint for gRPC server")
+	}
+
+	go s.server.Serve(listener)
+
+	logger.Info("gRPC server started")
+
+	<-ctx.Done()
+
+	// pending requests are bounded by the request timeout
+	s.server.GracefulStop()
+
+	logger.Info("gRPC server shutdown")
+}
+
+// newUnaryTimeoutInterceptor bounds the request context with timeout, whereas the client deadline
+// if earlier is propagated by gRPC to the context and then to the backend.
+func newUnaryTimeoutInterceptor(timeout time.Duration) grpcgo.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler,
+	) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+func newStreamTimeoutInterceptor(timeout time.Duration) grpcgo.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler,
+	) error {
+		ctx, cancel := context.WithTimeout(ss.Context(), timeout)
+		defer cancel()
+
+		return handler(srv, &timeoutServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type timeoutServerStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *timeoutServerStream) Context() context.Context {
+	return s.ctx
+}
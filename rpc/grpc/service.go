@@ -0,0 +1,175 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/rpc/grpc/pb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Backend provides evm space chain data from store, and returns nil if not found.
+type Backend interface {
+	// BlockByNumber returns the block with transaction details by number.
+	BlockByNumber(ctx context.Context, bn uint64) (*types.Block, error)
+	// BlockByHash returns the block with transaction details by hash.
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	BlockReceipts(ctx context.Context, bn uint64) ([]*types.Receipt, error)
+	// Logs returns event logs of the filter, whose block range is always specified.
+	Logs(ctx context.Context, filter *types.FilterQuery) ([]types.Log, error)
+}
+
+// ethService implements gRPC `Eth` service over store backend.
+type ethService struct {
+	pb.UnimplementedEthServer
+
+	conf    Config
+	backend Backend
+}
+
+func (s *ethService) GetBlock(ctx context.Context, req *pb.GetBlockRequest) (*pb.Block, error) {
+	block, err := s.getBlock(ctx, req.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBlock(block, req.FullTransactions), nil
+}
+
+func (s *ethService) GetReceipts(req *pb.GetReceiptsRequest, stream pb.Eth_GetReceiptsServer) error {
+	ctx := stream.Context()
+
+	bn, err := s.getBlockNumber(ctx, req.Block)
+	if err != nil {
+		return err
+	}
+
+	receipts, err := s.backend.BlockReceipts(ctx, bn)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	if receipts == nil {
+		return status.Error(codes.NotFound, "block not found")
+	}
+
+	for _, receipt := range receipts {
+		if err := stream.Send(convertReceipt(receipt)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ethService) GetLogs(req *pb.LogFilter, stream pb.Eth_GetLogsServer) error {
+	filter, err := s.parseLogFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	logs, err := s.backend.Logs(stream.Context(), filter)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	for i := range logs {
+		if err := stream.Send(convertLog(&logs[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ethService) getBlock(ctx context.Context, id *pb.BlockId) (block *types.Block, err error) {
+	switch v := id.GetId().(type) {
+	case *pb.BlockId_Number:
+		block, err = s.backend.BlockByNumber(ctx, v.Number)
+	case *pb.BlockId_Hash:
+		if len(v.Hash) != common.HashLength {
+			return nil, status.Error(codes.InvalidArgument, "invalid block hash")
+		}
+
+		block, err = s.backend.BlockByHash(ctx, common.BytesToHash(v.Hash))
+	default:
+		return nil, status.Error(codes.InvalidArgument, "block number or hash required")
+	}
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+
+	return block, nil
+}
+
+func (s *ethService) getBlockNumber(ctx context.Context, id *pb.BlockId) (uint64, error) {
+	if v, ok := id.GetId().(*pb.BlockId_Number); ok {
+		return v.Number, nil
+	}
+
+	block, err := s.getBlock(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return block.Number.Uint64(), nil
+}
+
+func (s *ethService) parseLogFilter(req *pb.LogFilter) (*types.FilterQuery, error) {
+	if req.FromBlock > req.ToBlock {
+		return nil, errors.New("invalid block range (from > to)")
+	}
+
+	if req.ToBlock-req.FromBlock+1 > s.conf.MaxBlockRange {
+		return nil, errors.Errorf("block range exceeds the max limit %v", s.conf.MaxBlockRange)
+	}
+
+	if len(req.Topics) > 4 {
+		return nil, errors.New("too many topics")
+	}
+
+	fromBlock, toBlock := types.BlockNumber(req.FromBlock), types.BlockNumber(req.ToBlock)
+	filter := &types.FilterQuery{FromBlock: &fromBlock, ToBlock: &toBlock}
+
+	for _, addr := range req.Addresses {
+		if len(addr) != common.AddressLength {
+			return nil, errors.New("invalid address")
+		}
+
+		filter.Addresses = append(filter.Addresses, common.BytesToAddress(addr))
+	}
+
+	for _, topics := range req.Topics {
+		var position []common.Hash
+
+		for _, topic := range topics.Topics {
+			if len(topic) != common.HashLength {
+				return nil, errors.New("invalid topic")
+			}
+
+			position = append(position, common.BytesToHash(topic))
+		}
+
+		filter.Topics = append(filter.Topics, position)
+	}
+
+	return filter, nil
+}
+
+// toStatusError converts backend error to gRPC status error, so that the deadline exceeded or
+// canceled error could be propagated to client with the right status code.
+func toStatusError(err error) error {
+	if s := status.FromContextError(errors.Cause(err)); s.Code() != codes.Unknown {
+		return s.Err()
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
@@ -8,26 +8,38 @@ import (
 
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/store/mysql"
-	"github.com/Conflux-Chain/confura/util/metrics"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 var (
-	errEventLogsTooStale = errors.New("event logs are too stale (already pruned)")
+	errEventLogsTooStale = rpcutil.NewCodedError(
+		rpcutil.ErrCodeDataPruned, errors.New("event logs are too stale (already pruned)"),
+	)
 )
 
 // CfxLogsApiHandler RPC handler to get core space event logs from store or fullnode.
 type CfxLogsApiHandler struct {
-	ms *mysql.MysqlStore
+	logsApiHandler
 
 	prunedHandler *CfxPrunedLogsHandler // optional
 }
 
 func NewCfxLogsApiHandler(ms *mysql.MysqlStore, prunedHandler *CfxPrunedLogsHandler) *CfxLogsApiHandler {
-	return &CfxLogsApiHandler{ms, prunedHandler}
+	return &CfxLogsApiHandler{
+		logsApiHandler: logsApiHandler{
+			ms:         ms,
+			prefetcher: newLogsPrefetcherFromViper("cfx"),
+			bounds:     newStoreBoundsFromViper("cfx"),
+			rpcMethod:  "cfx_getLogs",
+		},
+		prunedHandler: prunedHandler,
+	}
 }
 
 func (handler *CfxLogsApiHandler) GetLogs(
@@ -36,142 +48,223 @@ func (handler *CfxLogsApiHandler) GetLogs(
 	filter *types.LogFilter,
 	delegatedRpcMethod string,
 ) ([]types.Log, bool, error) {
-	timeoutCtx, cancel := context.WithTimeout(ctx, store.TimeoutGetLogs)
-	defer cancel()
-
-	// record the reorg version before query to ensure data consistence
-	lastReorgVersion, err := handler.ms.GetReorgVersion()
+	logs, hitStore, err := handler.getLogs(ctx, handler.space(cfx, filter), delegatedRpcMethod)
 	if err != nil {
 		return nil, false, err
 	}
 
-	for {
-		logs, hitStore, err := handler.getLogsReorgGuard(timeoutCtx, cfx, filter, delegatedRpcMethod)
-		if err != nil {
-			return nil, false, err
-		}
+	return logs.(cfxLogs), hitStore, nil
+}
 
-		// check the reorg version after query
-		reorgVersion, err := handler.ms.GetReorgVersion()
-		if err != nil {
-			return nil, false, err
-		}
+// ExplainLogs returns the planned execution of the log filter without execution, including
+// the block ranges split for database and fullnode and chosen indexes.
+//
+// Note, event logs already pruned from database could only be detected during execution.
+func (handler *CfxLogsApiHandler) ExplainLogs(
+	ctx context.Context, cfx sdk.ClientOperator, filter *types.LogFilter,
+) (*LogsQueryPlan, error) {
+	return handler.explainLogs(handler.space(cfx, filter))
+}
 
-		if reorgVersion == lastReorgVersion {
-			return logs, hitStore, nil
-		}
+func (handler *CfxLogsApiHandler) space(cfx sdk.ClientOperator, filter *types.LogFilter) *cfxLogsSpace {
+	return &cfxLogsSpace{handler: handler, cfx: cfx, filter: filter}
+}
 
-		// when reorg occurred, check timeout before retry.
-		if err := checkTimeout(timeoutCtx); err != nil {
-			return nil, false, err
-		}
+// cfxLogs core space event logs.
+type cfxLogs []types.Log
 
-		// reorg version changed during data query and try again.
-		lastReorgVersion = reorgVersion
-	}
+func (logs cfxLogs) len() int {
+	return len(logs)
 }
 
-func (handler *CfxLogsApiHandler) getLogsReorgGuard(
-	ctx context.Context,
-	cfx sdk.ClientOperator,
-	filter *types.LogFilter,
-	delegatedRpcMethod string,
-) ([]types.Log, bool, error) {
-	// Try to query event logs from database and fullnode.
-	// Note, if multiple block hashes specified in log filter, then split the block hashes
-	// into multiple block number ranges.
-	dbFilters, fnFilter, err := handler.splitLogFilter(cfx, filter)
-	if err != nil {
-		return nil, false, err
+func (logs cfxLogs) append(other spaceLogs) spaceLogs {
+	return append(logs, other.(cfxLogs)...)
+}
+
+// cfxLogsSpace core space operations to query event logs.
+type cfxLogsSpace struct {
+	handler *CfxLogsApiHandler
+	cfx     sdk.ClientOperator
+	filter  *types.LogFilter
+}
+
+// scanRange implements logsSpace, and only epoch range log filter is applicable.
+func (s *cfxLogsSpace) scanRange() (from, to uint64, ok bool) {
+	return logFilterEpochRange(s.filter)
+}
+
+func (s *cfxLogsSpace) scannerKey(ctx context.Context) string {
+	return logsScannerKey(ctx, s.filter.Address, s.filter.Topics)
+}
+
+func (s *cfxLogsSpace) shape() string {
+	rangeType := "epoch"
+	switch {
+	case len(s.filter.BlockHashes) > 0:
+		rangeType = "blockhash"
+	case s.filter.FromBlock != nil || s.filter.ToBlock != nil:
+		rangeType = "block"
 	}
 
-	metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/alldatabase").Mark(fnFilter == nil)
-	metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/allfullnode").Mark(len(dbFilters) == 0)
-	metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/partial").Mark(len(dbFilters) > 0 && fnFilter != nil)
+	topics := make([]bool, len(s.filter.Topics))
+	for i := range s.filter.Topics {
+		topics[i] = len(s.filter.Topics[i]) > 0
+	}
 
-	var logs []types.Log
+	return logFilterShape(rangeType, len(s.filter.Address), topics)
+}
 
-	// query data from database
-	for i := range dbFilters {
-		if err := checkTimeout(ctx); err != nil {
-			return nil, false, err
-		}
+func (s *cfxLogsSpace) withRange(from, to uint64) logsSpace {
+	filter := *s.filter
+	filter.FromEpoch = types.NewEpochNumberUint64(from)
+	filter.ToEpoch = types.NewEpochNumberUint64(to)
 
-		dbLogs, err := handler.ms.GetLogs(ctx, dbFilters[i])
+	return s.handler.space(s.cfx, &filter)
+}
 
-		// succeeded to get logs from database
-		if err == nil {
-			for _, v := range dbLogs {
-				log, _ := v.ToCfxLog()
-				logs = append(logs, *log)
-			}
+// costFilter implements logsSpace, which converts the epoch range into block range by store, or not
+// estimated if the epochs not synced into store yet. Note, log filter of block hashes is not estimated.
+func (s *cfxLogsSpace) costFilter() (*store.LogFilter, error) {
+	filter := s.filter
+	if len(filter.BlockHashes) > 0 {
+		return nil, nil
+	}
 
-			continue
+	if filter.FromBlock != nil && filter.ToBlock != nil {
+		blockFrom, blockTo := filter.FromBlock.ToInt().Uint64(), filter.ToBlock.ToInt().Uint64()
+		if blockFrom > blockTo {
+			return nil, nil
 		}
 
-		if !errors.Is(err, store.ErrAlreadyPruned) {
-			return nil, false, err
-		}
+		sfilter := store.ParseCfxLogFilter(blockFrom, blockTo, filter)
+		return &sfilter, nil
+	}
 
-		// data already pruned
-		if handler.prunedHandler == nil {
-			return nil, false, errEventLogsTooStale
-		}
+	if filter.FromEpoch == nil || filter.ToEpoch == nil {
+		return nil, nil
+	}
 
-		// try to query pruned logs from archive fullnode
-		originalFilter := dbFilters[i].Cfx()
-		if originalFilter == nil {
-			return nil, false, errors.WithMessage(
-				errEventLogsTooStale, "missing original log filter",
-			)
-		}
+	// resolve epoch tags if any, or not estimated if failed to resolve
+	epochFrom, ok := resolveEpochNumber(s.cfx, filter.FromEpoch)
+	if !ok {
+		return nil, nil
+	}
 
-		// ensure fullnode delegation is rational
-		if err := handler.checkFullnodeLogFilter(originalFilter); err != nil {
-			return nil, false, err
-		}
+	epochTo, ok := resolveEpochNumber(s.cfx, filter.ToEpoch)
+	if !ok || epochFrom > epochTo {
+		return nil, nil
+	}
 
-		fnLogs, err := handler.prunedHandler.GetLogs(ctx, *originalFilter)
-		if err != nil {
-			return nil, false, err
-		}
+	fromRange, ok, err := s.handler.ms.BlockRange(epochFrom)
+	if err != nil || !ok {
+		return nil, err
+	}
 
-		logs = append(logs, fnLogs...)
+	toRange, ok, err := s.handler.ms.BlockRange(epochTo)
+	if err != nil || !ok {
+		return nil, err
 	}
 
-	// query data from fullnode
-	if fnFilter != nil {
-		// timeout check before fullnode delegation
-		if err := checkTimeout(ctx); err != nil {
-			return nil, false, err
-		}
+	sfilter := store.ParseCfxLogFilter(fromRange.From, toRange.To, filter)
+	return &sfilter, nil
+}
 
-		// ensure split log filter for fullnode is rational
-		if err := handler.checkFullnodeLogFilter(fnFilter); err != nil {
-			return nil, false, err
-		}
+// resolveEpochNumber resolves epoch tag into epoch number by the cached status of fullnode. Note, epoch
+// number is returned as it is, and false is returned if failed to resolve.
+func resolveEpochNumber(cfx sdk.ClientOperator, epoch *types.Epoch) (uint64, bool) {
+	if num, ok := epoch.ToInt(); ok {
+		return num.Uint64(), true
+	}
 
-		fnLogs, err := cfx.GetLogs(*fnFilter)
-		if err != nil {
-			return nil, false, err
-		}
+	num, err := ResolveCfxEpoch(cfx, epoch)
+	if err != nil {
+		logrus.WithField("epoch", epoch).WithError(err).Debug("Failed to resolve epoch tag of log filter")
+		return 0, false
+	}
+
+	return num, true
+}
+
+func (s *cfxLogsSpace) split() ([]store.LogFilter, interface{}, error) {
+	// Note, if multiple block hashes specified in log filter, then split the block hashes
+	// into multiple block number ranges.
+	dbFilters, fnFilter, err := s.handler.splitLogFilter(s.cfx, s.filter)
+	if err != nil || fnFilter == nil {
+		return dbFilters, nil, err
+	}
+
+	return dbFilters, fnFilter, nil
+}
+
+func (s *cfxLogsSpace) checkFullnode(fnFilter interface{}) error {
+	return s.handler.checkFullnodeLogFilter(fnFilter.(*types.LogFilter))
+}
+
+func (s *cfxLogsSpace) newLogs() spaceLogs {
+	return cfxLogs(nil)
+}
+
+func (s *cfxLogsSpace) storeLogs(dbLogs []*store.Log) spaceLogs {
+	logs := make(cfxLogs, 0, len(dbLogs))
+	for _, v := range dbLogs {
+		log, _ := v.ToCfxLog()
+		logs = append(logs, *log)
+	}
+
+	return logs
+}
+
+// prunedLogs implements logsSpace to query pruned event logs from archive fullnode if configured.
+func (s *cfxLogsSpace) prunedLogs(ctx context.Context, dbFilter store.LogFilter) (spaceLogs, error) {
+	if s.handler.prunedHandler == nil {
+		return nil, s.handler.errLogsPruned(errEventLogsTooStale)
+	}
+
+	originalFilter := dbFilter.Cfx()
+	if originalFilter == nil {
+		return nil, errors.WithMessage(errEventLogsTooStale, "missing original log filter")
+	}
+
+	// ensure fullnode delegation is rational
+	if err := s.handler.checkFullnodeLogFilter(originalFilter); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.handler.prunedHandler.GetLogs(ctx, *originalFilter)
+	return cfxLogs(logs), err
+}
+
+func (s *cfxLogsSpace) fullnodeLogs(fnFilter interface{}) (spaceLogs, error) {
+	logs, err := s.cfx.GetLogs(*fnFilter.(*types.LogFilter))
+	return cfxLogs(logs), err
+}
 
-		logs = append(logs, fnLogs...)
+// logFilterEpochRange returns the numbered epoch range of the normalized log filter, or false if
+// not an epoch range log filter.
+func logFilterEpochRange(filter *types.LogFilter) (from, to uint64, ok bool) {
+	if filter.FromEpoch == nil || filter.ToEpoch == nil || filter.FromBlock != nil || len(filter.BlockHashes) > 0 {
+		return 0, 0, false
 	}
 
-	// ensure result set never oversized
-	if len(logs) > int(store.MaxLogLimit) {
-		return nil, false, store.ErrGetLogsResultSetTooLarge
+	epochFrom, ok1 := filter.FromEpoch.ToInt()
+	epochTo, ok2 := filter.ToEpoch.ToInt()
+	if !ok1 || !ok2 || epochFrom.Cmp(epochTo) > 0 {
+		return 0, 0, false
 	}
 
-	return logs, len(dbFilters) > 0, nil
+	return epochFrom.Uint64(), epochTo.Uint64(), true
 }
 
 func (handler *CfxLogsApiHandler) splitLogFilter(
 	cfx sdk.ClientOperator,
 	filter *types.LogFilter,
 ) ([]store.LogFilter, *types.LogFilter, error) {
-	maxEpoch, ok, err := handler.ms.MaxEpoch()
+	// event logs not persisted in store if selective sync enabled
+	if sfilter := store.ParseCfxLogFilter(0, 0, filter); !handler.ms.CoversLogFilter(&sfilter) {
+		return nil, filter, nil
+	}
+
+	epochRange, ok, err := handler.storeEpochRange()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -180,7 +273,7 @@ func (handler *CfxLogsApiHandler) splitLogFilter(
 		return nil, filter, nil
 	}
 
-	blockRange, ok, err := handler.ms.BlockRange(maxEpoch)
+	blockRange, ok, err := handler.ms.BlockRange(epochRange.To)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -190,20 +283,37 @@ func (handler *CfxLogsApiHandler) splitLogFilter(
 	}
 
 	if len(filter.BlockHashes) > 0 {
-		return handler.splitLogFilterByBlockHashes(cfx, filter, maxEpoch)
+		return handler.splitLogFilterByBlockHashes(cfx, filter, epochRange)
 	}
 
 	if filter.FromBlock != nil && filter.ToBlock != nil {
-		return handler.splitLogFilterByBlockRange(cfx, filter, blockRange.To)
+		// convert the first epoch into block number if sync started from some epoch
+		var minBlock uint64
+		if epochRange.From > 0 {
+			minBlockRange, ok, err := handler.ms.BlockRange(epochRange.From)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if !ok {
+				return nil, filter, nil
+			}
+
+			minBlock = minBlockRange.From
+		}
+
+		return handler.splitLogFilterByBlockRange(
+			cfx, filter, citypes.RangeUint64{From: minBlock, To: blockRange.To},
+		)
 	}
 
-	return handler.splitLogFilterByEpochRange(cfx, filter, maxEpoch, blockRange.To)
+	return handler.splitLogFilterByEpochRange(cfx, filter, epochRange, blockRange.To)
 }
 
 func (handler *CfxLogsApiHandler) splitLogFilterByBlockHashes(
 	cfx sdk.ClientOperator,
 	filter *types.LogFilter,
-	maxEpoch uint64,
+	epochRange citypes.RangeUint64,
 ) ([]store.LogFilter, *types.LogFilter, error) {
 	var dbBlockNumbers []int
 	var fnBlockHashes []types.Hash
@@ -220,7 +330,9 @@ func (handler *CfxLogsApiHandler) splitLogFilterByBlockHashes(
 		// Fullnode will return error if any block hash not found.
 		// Error processing request: Filter error: Unable to identify block 0xaaaa...
 		if block == nil {
-			return nil, nil, fmt.Errorf("unable to identify block %v", hash)
+			return nil, nil, rpcutil.NewCodedError(
+				rpcutil.ErrCodeResourceNotFound, errors.Errorf("unable to identify block %v", hash),
+			)
 		}
 
 		if block.BlockNumber == nil { // block already mined but not ordered yet?
@@ -236,7 +348,7 @@ func (handler *CfxLogsApiHandler) splitLogFilterByBlockHashes(
 
 		blockNumToHash[bn] = hash
 
-		if epoch := block.EpochNumber.ToInt().Uint64(); epoch <= maxEpoch {
+		if epoch := block.EpochNumber.ToInt().Uint64(); epoch >= epochRange.From && epoch <= epochRange.To {
 			dbBlockNumbers = append(dbBlockNumbers, bn)
 		} else {
 			fnBlockHashes = append(fnBlockHashes, hash)
@@ -268,11 +380,13 @@ func (handler *CfxLogsApiHandler) splitLogFilterByBlockHashes(
 func (handler *CfxLogsApiHandler) splitLogFilterByBlockRange(
 	cfx sdk.ClientOperator,
 	filter *types.LogFilter,
-	maxBlock uint64,
+	blockRange citypes.RangeUint64,
 ) ([]store.LogFilter, *types.LogFilter, error) {
-	// no data in database
+	maxBlock := blockRange.To
+
+	// no data in database, or partial data before the first synced block
 	blockFrom := filter.FromBlock.ToInt().Uint64()
-	if blockFrom > maxBlock {
+	if blockFrom > maxBlock || blockFrom < blockRange.From {
 		return nil, filter, nil
 	}
 
@@ -301,15 +415,18 @@ func (handler *CfxLogsApiHandler) splitLogFilterByBlockRange(
 func (handler *CfxLogsApiHandler) splitLogFilterByEpochRange(
 	cfx sdk.ClientOperator,
 	filter *types.LogFilter,
-	maxEpoch, maxBlock uint64,
+	epochRange citypes.RangeUint64,
+	maxBlock uint64,
 ) ([]store.LogFilter, *types.LogFilter, error) {
 	epochFrom, ok := filter.FromEpoch.ToInt()
 	if !ok {
 		return nil, filter, nil
 	}
 
-	// no data in database
-	if epochFrom.Uint64() > maxEpoch {
+	maxEpoch := epochRange.To
+
+	// no data in database, or partial data before the first synced epoch
+	if epochFrom.Uint64() > maxEpoch || epochFrom.Uint64() < epochRange.From {
 		return nil, filter, nil
 	}
 
@@ -388,14 +505,3 @@ func (handler *CfxLogsApiHandler) checkFullnodeLogFilter(filter *types.LogFilter
 
 	return nil
 }
-
-// checkTimeout checks if operation is timed out.
-func checkTimeout(ctx context.Context) error {
-	select {
-	case <-ctx.Done():
-		return store.ErrGetLogsTimeout
-	default:
-	}
-
-	return nil
-}
@@ -88,8 +88,8 @@ func (h *CfxPrunedLogsHandler) getLogsByUser(ctx context.Context, filter types.L
 		return nil, false, nil
 	}
 
-	// TODO cache client for user
-	client, err := sdk.NewClient(user.NodeUrl)
+	// client of dedicated full node is shared across requests of user
+	client, err := rpc.CfxClientPool.GetCfxClient(user.NodeUrl)
 	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"user": user.Name,
@@ -97,7 +97,6 @@ func (h *CfxPrunedLogsHandler) getLogsByUser(ctx context.Context, filter types.L
 		}).Warn("Failed to connect to full node for user")
 		return nil, false, err
 	}
-	defer client.Close()
 
 	logs, err := h.getLogsThrottled(client, filter)
 	if err != nil {
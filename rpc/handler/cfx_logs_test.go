@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCfxLogsCostFilter(t *testing.T) {
+	handler := &CfxLogsApiHandler{}
+
+	// block range estimated as it is
+	space := handler.space(nil, &types.LogFilter{FromBlock: types.NewBigInt(10), ToBlock: types.NewBigInt(20)})
+	sfilter, err := space.costFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), sfilter.BlockFrom)
+	assert.Equal(t, uint64(20), sfilter.BlockTo)
+
+	// not estimated for invalid block range or block hashes
+	for _, filter := range []*types.LogFilter{
+		{FromBlock: types.NewBigInt(20), ToBlock: types.NewBigInt(10)},
+		{BlockHashes: []types.Hash{"0x01"}},
+		{},
+	} {
+		sfilter, err = handler.space(nil, filter).costFilter()
+		assert.NoError(t, err)
+		assert.Nil(t, sfilter)
+	}
+
+	// not estimated for invalid epoch range, which is resolved without fullnode
+	filter := &types.LogFilter{FromEpoch: types.NewEpochNumberUint64(10), ToEpoch: types.EpochEarliest}
+	sfilter, err = handler.space(nil, filter).costFilter()
+	assert.NoError(t, err)
+	assert.Nil(t, sfilter)
+}
+
+func TestResolveEpochNumber(t *testing.T) {
+	num, ok := resolveEpochNumber(nil, types.NewEpochNumberUint64(100))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), num)
+
+	num, ok = resolveEpochNumber(nil, types.EpochEarliest)
+	assert.True(t, ok)
+	assert.Zero(t, num)
+}
@@ -13,15 +13,16 @@ import (
 
 // CfxStoreHandler RPC handler to get block/txn/receipt data from store.
 type CfxStoreHandler struct {
-	sname string // store name
-	store store.Readable
+	sname      string // store name
+	store      store.Readable
+	storeRange *cachedStoreRange // epoch range served by store, nil if unbounded
 
 	next *CfxStoreHandler
 }
 
 func NewCfxCommonStoreHandler(sname string, store store.Readable, next *CfxStoreHandler) *CfxStoreHandler {
 	return &CfxStoreHandler{
-		sname: sname, store: store, next: next,
+		sname: sname, store: store, storeRange: newCachedStoreRange("cfx", store), next: next,
 	}
 }
 
@@ -56,9 +57,12 @@ func (h *CfxStoreHandler) GetBlockByEpochNumber(
 
 	epochNo := epBigInt.Uint64()
 
-	if includeTxs {
+	switch {
+	case !h.storeRange.contains(epochNo):
+		err = store.ErrNotFound
+	case includeTxs:
 		block, err = h.store.GetBlockByEpoch(ctx, epochNo)
-	} else {
+	default:
 		block, err = h.store.GetBlockSummaryByEpoch(ctx, epochNo)
 	}
 
@@ -99,7 +103,11 @@ func (h *CfxStoreHandler) GetBlocksByEpoch(
 	}
 
 	epochNo := epBigInt.Uint64()
-	blockHashes, err = h.store.GetBlocksByEpoch(ctx, epochNo)
+	if h.storeRange.contains(epochNo) {
+		blockHashes, err = h.store.GetBlocksByEpoch(ctx, epochNo)
+	} else {
+		err = store.ErrNotFound
+	}
 
 	h.collectHitStats("cfx_getBlocksByEpoch", err)
 
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"github.com/Conflux-Chain/confura/node"
-	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/relay"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
@@ -17,9 +16,9 @@ import (
 // and replicating txn sending synchronously to all full nodes of some node group to improve consistency
 // and availability once consistent hashing LB repartitioned.
 type CfxTxnHandler struct {
-	relayer relay.TxnRelayer    // transaction relayer
-	nclient *rpc.Client         // node RPC client
-	clients *util.ConcurrentMap // sdk clients: node name => RPC client
+	relayer     relay.TxnRelayer   // transaction relayer
+	broadcaster *relay.Broadcaster // optional transaction broadcaster
+	nclient     *rpc.Client        // node RPC client
 }
 
 func MustNewCfxTxnHandler(relayer relay.TxnRelayer) *CfxTxnHandler {
@@ -37,13 +36,17 @@ func MustNewCfxTxnHandler(relayer relay.TxnRelayer) *CfxTxnHandler {
 	}
 
 	return &CfxTxnHandler{
-		relayer: relayer,
-		nclient: nodeRpcClient,
-		clients: &util.ConcurrentMap{},
+		relayer:     relayer,
+		broadcaster: relay.MustNewBroadcasterFromViper("cfx"),
+		nclient:     nodeRpcClient,
 	}
 }
 
 func (h *CfxTxnHandler) SendRawTxn(cfx sdk.ClientOperator, group node.Group, signedTx hexutil.Bytes) (types.Hash, error) {
+	if h.broadcaster != nil {
+		return h.broadcastRawTxn(cfx, group, signedTx)
+	}
+
 	txHash, err := cfx.SendRawTransaction(signedTx)
 	if err != nil {
 		return txHash, err
@@ -59,6 +62,58 @@ func (h *CfxTxnHandler) SendRawTxn(cfx sdk.ClientOperator, group node.Group, sig
 	return txHash, err
 }
 
+// broadcastRawTxn broadcasts raw txn to the routed full node along with the healthy full nodes of
+// some specific group concurrently.
+func (h *CfxTxnHandler) broadcastRawTxn(cfx sdk.ClientOperator, group node.Group, signedTx hexutil.Bytes) (types.Hash, error) {
+	routedUrl := cfx.GetNodeURL()
+
+	txHash, err := h.broadcaster.Broadcast(routedUrl, h.healthyGroupNodeUrls(group), func(url string) (string, error) {
+		client := cfx
+		if url != routedUrl {
+			var err error
+			if client, err = h.nodeClient(url); err != nil {
+				return "", err
+			}
+		}
+
+		txHash, err := client.SendRawTransaction(signedTx)
+		return string(txHash), err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// relay transaction broadcasting asynchronously
+	if h.relayer != nil && !h.relayer.Relay(signedTx) {
+		logrus.Info("Txn relay pool is full, dropping transaction")
+	}
+
+	return types.Hash(txHash), nil
+}
+
+// healthyGroupNodeUrls returns the URLs of healthy full nodes of some specific group.
+func (h *CfxTxnHandler) healthyGroupNodeUrls(group node.Group) []string {
+	if h.nclient != nil { // fetch healthy group nodes from node RPC
+		var nodeUrls []string
+
+		if err := h.nclient.Call(&nodeUrls, "node_listHealthy", group); err != nil {
+			logrus.WithField("group", group).
+				WithError(err).
+				Error("Txn handler failed to get healthy group full nodes from node RPC")
+			return nil
+		}
+
+		return nodeUrls
+	}
+
+	// otherwise get group nodes from local config, of which health status is unknown
+	if conf, ok := node.CfxUrlConfig()[group]; ok {
+		return conf.Nodes
+	}
+
+	return nil
+}
+
 // replicateRawTxnSendingByGroup synchronously replicate raw txn sending to all full nodes of some specific group
 func (h *CfxTxnHandler) replicateRawTxnSendingByGroup(group node.Group, signedTx hexutil.Bytes) {
 	if h.nclient != nil { // fetch group nodes from node RPC
@@ -83,11 +138,7 @@ func (h *CfxTxnHandler) replicateRawTxnSendingByGroup(group node.Group, signedTx
 
 func (h *CfxTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedTx hexutil.Bytes) {
 	for _, url := range nodeUrls {
-		nodeName := rpcutil.Url2NodeName(url)
-		c, _, err := h.clients.LoadOrStoreFnErr(nodeName, func(interface{}) (interface{}, error) {
-			return rpcutil.NewCfxClient(url)
-		})
-
+		c, err := h.nodeClient(url)
 		if err != nil {
 			logrus.WithField("url", url).
 				WithError(err).
@@ -95,7 +146,7 @@ func (h *CfxTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedT
 			continue
 		}
 
-		_, err = c.(sdk.ClientOperator).SendRawTransaction(signedTx)
+		_, err = c.SendRawTransaction(signedTx)
 		if err != nil && !utils.IsRPCJSONError(err) {
 			logrus.WithField("url", url).
 				WithError(err).
@@ -103,3 +154,8 @@ func (h *CfxTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedT
 		}
 	}
 }
+
+// nodeClient gets or creates sdk client of the full node from the shared client pool.
+func (h *CfxTxnHandler) nodeClient(url string) (sdk.ClientOperator, error) {
+	return rpcutil.CfxClientPool.GetCfxClient(url)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	itypes "github.com/Conflux-Chain/confura/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
+)
+
+// FeeHistory computes fee history of blocks from the `oldest` block up to the `newest` block
+// from store, and stops at the first block not synced into store yet. Besides, base fee of
+// the block next to the `newest` block is also included if synced.
+//
+// Note, receipts are required from store to compute rewards if any reward percentile requested.
+func (h *EthStoreHandler) FeeHistory(
+	ctx context.Context, oldest, newest uint64, rewardPercentiles []float64,
+) (*itypes.FeeHistory, error) {
+	history := &itypes.FeeHistory{
+		OldestBlock: (*hexutil.Big)(new(big.Int).SetUint64(oldest)),
+	}
+
+	var err error
+	for bn := oldest; bn <= newest+1; bn++ {
+		var sblocksum *store.BlockSummary
+		if sblocksum, err = h.store.GetBlockSummaryByBlockNumber(ctx, bn); err != nil {
+			break
+		}
+
+		block := ethbridge.ConvertBlockSummary(sblocksum.CfxBlockSummary, sblocksum.Extra)
+
+		baseFee := block.BaseFeePerGas
+		if baseFee == nil { // before CIP-1559 hardfork
+			baseFee = big.NewInt(0)
+		}
+
+		if bn > newest { // base fee of the next block
+			history.BaseFee = append(history.BaseFee, (*hexutil.Big)(baseFee))
+			break
+		}
+
+		if len(rewardPercentiles) > 0 {
+			var receipts []*web3Types.Receipt
+			if receipts, err = h.getBlockReceipts(ctx, bn); err != nil {
+				break
+			}
+
+			rewards := ComputeFeeRewards(baseFee, block.GasUsed, receipts, rewardPercentiles)
+			history.Reward = append(history.Reward, rewards)
+		}
+
+		var gasUsedRatio float64
+		if block.GasLimit > 0 {
+			gasUsedRatio = float64(block.GasUsed) / float64(block.GasLimit)
+		}
+
+		history.BaseFee = append(history.BaseFee, (*hexutil.Big)(baseFee))
+		history.GasUsedRatio = append(history.GasUsedRatio, gasUsedRatio)
+	}
+
+	if len(history.GasUsedRatio) == 0 {
+		logrus.WithFields(logrus.Fields{
+			"oldest": oldest, "newest": newest,
+		}).WithError(err).Debug("ETH handler failed to handle FeeHistory")
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (h *EthStoreHandler) getBlockReceipts(ctx context.Context, blockNum uint64) ([]*web3Types.Receipt, error) {
+	if store.EthStoreConfig().IsChainReceiptDisabled() {
+		return nil, store.ErrUnsupported
+	}
+
+	// for evm space, epoch number is the same as block number
+	srcpts, err := h.store.GetReceiptsByEpoch(ctx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*web3Types.Receipt, len(srcpts))
+	for i := range srcpts {
+		receipts[i] = ethbridge.ConvertReceipt(srcpts[i].CfxReceipt, srcpts[i].Extra)
+	}
+
+	return receipts, nil
+}
+
+// ComputeFeeRewards computes the effective priority fee rewards of some block at the specified
+// percentiles, which are weighted by the gas used of each transaction as geth does.
+func ComputeFeeRewards(
+	baseFee *big.Int, gasUsed uint64, receipts []*web3Types.Receipt, percentiles []float64,
+) []*hexutil.Big {
+	rewards := make([]*hexutil.Big, len(percentiles))
+
+	if len(receipts) == 0 {
+		for i := range rewards {
+			rewards[i] = (*hexutil.Big)(big.NewInt(0))
+		}
+
+		return rewards
+	}
+
+	type txGasAndReward struct {
+		gasUsed uint64
+		reward  *big.Int
+	}
+
+	sorted := make([]txGasAndReward, len(receipts))
+	for i, rcpt := range receipts {
+		reward := new(big.Int).SetUint64(rcpt.EffectiveGasPrice)
+		if reward.Sub(reward, baseFee).Sign() < 0 {
+			reward.SetUint64(0)
+		}
+
+		sorted[i] = txGasAndReward{gasUsed: rcpt.GasUsed, reward: reward}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].reward.Cmp(sorted[j].reward) < 0
+	})
+
+	var txIndex int
+	sumGasUsed := sorted[0].gasUsed
+
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(gasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+
+		rewards[i] = (*hexutil.Big)(sorted[txIndex].reward)
+	}
+
+	return rewards
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeFeeRewards(t *testing.T) {
+	baseFee := big.NewInt(10)
+	percentiles := []float64{0, 25, 50, 100}
+
+	// no transaction in block
+	rewards := ComputeFeeRewards(baseFee, 0, nil, percentiles)
+	assert.Equal(t, []string{"0x0", "0x0", "0x0", "0x0"}, hexBigsToStrings(rewards))
+
+	receipts := []*web3Types.Receipt{
+		{GasUsed: 21000, EffectiveGasPrice: 40},
+		{GasUsed: 63000, EffectiveGasPrice: 15},
+		{GasUsed: 21000, EffectiveGasPrice: 5}, // effective gas price below base fee
+	}
+
+	rewards = ComputeFeeRewards(baseFee, 105000, receipts, percentiles)
+	assert.Equal(t, []string{"0x0", "0x5", "0x5", "0x1e"}, hexBigsToStrings(rewards))
+}
+
+func hexBigsToStrings(values []*hexutil.Big) []string {
+	result := make([]string, len(values))
+	for i := range values {
+		result[i] = values[i].String()
+	}
+
+	return result
+}
@@ -1,136 +1,349 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"sync/atomic"
 
+	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/ethbridge"
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/cold"
 	"github.com/Conflux-Chain/confura/store/mysql"
-	"github.com/Conflux-Chain/confura/util/metrics"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/openweb3/web3go/client"
 	"github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
 )
 
 // EthLogsApiHandler RPC handler to get evm space event logs from store or fullnode.
 type EthLogsApiHandler struct {
-	ms *mysql.MysqlStore
+	logsApiHandler
 
+	coldStore *cold.LogStore // cold storage tier for pruned event logs, nil if disabled
 	networkId atomic.Value
 }
 
-func NewEthLogsApiHandler(ms *mysql.MysqlStore) *EthLogsApiHandler {
-	return &EthLogsApiHandler{ms: ms}
+func NewEthLogsApiHandler(ms *mysql.MysqlStore, coldStore *cold.LogStore) *EthLogsApiHandler {
+	return &EthLogsApiHandler{
+		logsApiHandler: logsApiHandler{
+			ms:         ms,
+			planner:    newLogsQueryPlannerFromViper(ms),
+			prefetcher: newLogsPrefetcherFromViper("eth"),
+			bounds:     newStoreBoundsFromViper("eth"),
+			rpcMethod:  "eth_getLogs",
+		},
+		coldStore: coldStore,
+	}
 }
 
 func (handler *EthLogsApiHandler) GetLogs(
 	ctx context.Context,
-	eth *client.RpcEthClient,
+	w3c *node.Web3goClient,
 	filter *types.FilterQuery,
 	delegatedRpcMethod string,
 ) ([]types.Log, bool, error) {
-	timeoutCtx, cancel := context.WithTimeout(ctx, store.TimeoutGetLogs)
-	defer cancel()
+	logs, hitStore, err := handler.GetLogsLazily(ctx, w3c, filter, delegatedRpcMethod)
+	if err != nil {
+		return nil, false, err
+	}
 
-	// record the reorg version before query to ensure data consistence
-	lastReorgVersion, err := handler.ms.GetReorgVersion()
+	return logs.Logs(), hitStore, nil
+}
+
+// GetLogsLazily is similar to `GetLogs`, but the event logs queried from store are not converted
+// until encoded into JSON-RPC response, so as to avoid materializing the full result set twice.
+func (handler *EthLogsApiHandler) GetLogsLazily(
+	ctx context.Context,
+	w3c *node.Web3goClient,
+	filter *types.FilterQuery,
+	delegatedRpcMethod string,
+) (EthLogs, bool, error) {
+	logs, hitStore, err := handler.getLogs(ctx, handler.space(w3c, filter), delegatedRpcMethod)
 	if err != nil {
 		return nil, false, err
 	}
 
-	for {
-		logs, hitStore, err := handler.getLogsReorgGuard(timeoutCtx, eth, filter, delegatedRpcMethod)
-		if err != nil {
-			return nil, false, err
+	return logs.(EthLogs), hitStore, nil
+}
+
+// ExplainLogs returns the planned execution of the log filter without execution, including
+// the block ranges split for database and fullnode, estimated cost and chosen indexes.
+func (handler *EthLogsApiHandler) ExplainLogs(
+	ctx context.Context, w3c *node.Web3goClient, filter *types.FilterQuery,
+) (*LogsQueryPlan, error) {
+	return handler.explainLogs(handler.space(w3c, filter))
+}
+
+func (handler *EthLogsApiHandler) space(w3c *node.Web3goClient, filter *types.FilterQuery) *ethLogsSpace {
+	return &ethLogsSpace{handler: handler, w3c: w3c, filter: filter}
+}
+
+// ethLogEncodedSizeHint is the estimated size of JSON encoded evm space event log to pre-allocate buffer.
+const ethLogEncodedSizeHint = 512
+
+// EthLogs evm space event logs, which consist of event logs queried from store and fullnode.
+//
+// Event logs from store are kept as store rows, and converted one by one (ToCfxLog => ConvertLog)
+// only when encoded into JSON, so that the full slice of converted event logs is never materialized
+// for large result set. See `MarshalJSON` for the limitation.
+type EthLogs []ethLogsChunk
+
+// ethLogsChunk is a chunk of event logs either from store or fullnode.
+type ethLogsChunk struct {
+	dbLogs []*store.Log // event logs from store to be converted lazily
+	fnLogs []types.Log  // event logs from fullnode
+}
+
+// NewEthLogs creates an instance of EthLogs with event logs from fullnode.
+func NewEthLogs(logs []types.Log) EthLogs {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	return EthLogs{{fnLogs: logs}}
+}
+
+func (logs EthLogs) len() int {
+	var n int
+	for i := range logs {
+		n += len(logs[i].dbLogs) + len(logs[i].fnLogs)
+	}
+
+	return n
+}
+
+func (logs EthLogs) append(other spaceLogs) spaceLogs {
+	return append(logs, other.(EthLogs)...)
+}
+
+// Logs converts and returns all the event logs, which is never nil.
+func (logs EthLogs) Logs() []types.Log {
+	result := make([]types.Log, 0, logs.len())
+
+	for _, chunk := range logs {
+		for _, v := range chunk.dbLogs {
+			result = append(result, *ethbridge.ConvertLog(v.ToCfxLog()))
 		}
 
-		// check the reorg version after query
-		reorgVersion, err := handler.ms.GetReorgVersion()
-		if err != nil {
-			return nil, false, err
+		result = append(result, chunk.fnLogs...)
+	}
+
+	return result
+}
+
+// MarshalJSON implements the json.Marshaler interface, which converts and encodes event logs
+// from store one by one with pooled intermediate objects into a pre-sized buffer.
+//
+// Note, the encoded result is not streamed to the client incrementally, since the JSON-RPC server
+// marshals the whole result into bytes before writing response. Only the slice of converted event
+// logs is avoided, while the encoded response is still buffered in full.
+func (logs EthLogs) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(logs.len()*ethLogEncodedSizeHint + 2)
+	buf.WriteByte('[')
+
+	// note, encoder appends a newline after each log, which is ignored by JSON-RPC response encoder
+	encoder := json.NewEncoder(&buf)
+	write := func(log *types.Log) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
 		}
 
-		if reorgVersion == lastReorgVersion {
-			return logs, hitStore, nil
+		return encoder.Encode(log)
+	}
+
+	for _, chunk := range logs {
+		for _, v := range chunk.dbLogs {
+			if err := writeStoreLog(v, write); err != nil {
+				return nil, err
+			}
 		}
 
-		// when reorg occurred, check timeout before retry.
-		if err := checkTimeout(ctx); err != nil {
-			return nil, false, err
+		for i := range chunk.fnLogs {
+			if err := write(&chunk.fnLogs[i]); err != nil {
+				return nil, err
+			}
 		}
+	}
+
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// writeStoreLog converts the event log from store with pooled intermediate objects, which are
+// released once written.
+func writeStoreLog(log *store.Log, write func(log *types.Log) error) error {
+	cfxLog, extra := log.AcquireCfxLog()
+	defer store.ReleaseCfxLog(cfxLog)
+
+	ethLog := ethbridge.AcquireLog(cfxLog, extra)
+	defer ethbridge.ReleaseLog(ethLog)
+
+	return write(ethLog)
+}
 
-		// reorg version changed during data query and try again.
-		lastReorgVersion = reorgVersion
+// ethLogsSpace evm space operations to query event logs.
+type ethLogsSpace struct {
+	handler *EthLogsApiHandler
+	w3c     *node.Web3goClient
+	filter  *types.FilterQuery
+}
+
+// scanRange implements logsSpace, and only block range log filter is applicable.
+func (s *ethLogsSpace) scanRange() (from, to uint64, ok bool) {
+	filter := s.filter
+	if filter.FromBlock == nil || filter.ToBlock == nil || *filter.FromBlock < 0 || *filter.ToBlock < *filter.FromBlock {
+		return 0, 0, false
 	}
+
+	return uint64(*filter.FromBlock), uint64(*filter.ToBlock), true
 }
 
-func (handler *EthLogsApiHandler) getLogsReorgGuard(
-	ctx context.Context,
-	eth *client.RpcEthClient,
-	filter *types.FilterQuery,
-	delegatedRpcMethod string,
-) ([]types.Log, bool, error) {
-	// Try to query event logs from database and fullnode.
-	dbFilter, fnFilter, err := handler.splitLogFilter(eth, filter)
-	if err != nil {
-		return nil, false, err
+func (s *ethLogsSpace) scannerKey(ctx context.Context) string {
+	return logsScannerKey(ctx, s.filter.Addresses, s.filter.Topics)
+}
+
+func (s *ethLogsSpace) shape() string {
+	rangeType := "block"
+	if s.filter.BlockHash != nil {
+		rangeType = "blockhash"
 	}
 
-	if len(delegatedRpcMethod) > 0 {
-		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/alldatabase").Mark(fnFilter == nil)
-		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/allfullnode").Mark(dbFilter == nil)
-		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/partial").Mark(dbFilter != nil && fnFilter != nil)
+	topics := make([]bool, len(s.filter.Topics))
+	for i := range s.filter.Topics {
+		topics[i] = len(s.filter.Topics[i]) > 0
 	}
 
-	var logs []types.Log
+	return logFilterShape(rangeType, len(s.filter.Addresses), topics)
+}
+
+func (s *ethLogsSpace) withRange(from, to uint64) logsSpace {
+	filter := *s.filter
+	fromBlock, toBlock := types.BlockNumber(from), types.BlockNumber(to)
+	filter.FromBlock, filter.ToBlock = &fromBlock, &toBlock
+
+	return s.handler.space(s.w3c, &filter)
+}
 
-	// query data from database
+func (s *ethLogsSpace) costFilter() (*store.LogFilter, error) {
+	filter := s.filter
+	if filter.FromBlock == nil || filter.ToBlock == nil {
+		return nil, nil
+	}
+
+	// resolve block tags if any in the same way as split, or not estimated if unresolvable
+	blockFrom, ok := s.handler.resolveBlockNumber(s.w3c, *filter.FromBlock)
+	if !ok {
+		return nil, nil
+	}
+
+	blockTo, ok := s.handler.resolveBlockNumber(s.w3c, *filter.ToBlock)
+	if !ok || blockFrom > blockTo {
+		return nil, nil
+	}
+
+	networkId, err := s.handler.GetNetworkId(s.w3c.Eth)
+	if err != nil {
+		return nil, err
+	}
+
+	sfilter := store.ParseEthLogFilter(blockFrom, blockTo, filter, networkId)
+	return &sfilter, nil
+}
+
+func (s *ethLogsSpace) split() ([]store.LogFilter, interface{}, error) {
+	dbFilter, fnFilter, err := s.handler.splitLogFilter(s.w3c, s.filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dbFilters []store.LogFilter
 	if dbFilter != nil {
-		dbLogs, err := handler.ms.GetLogs(ctx, *dbFilter)
-		if err != nil {
-			// TODO ErrPrunedAlready
-			return nil, false, err
-		}
+		dbFilters = append(dbFilters, *dbFilter)
+	}
 
-		for _, v := range dbLogs {
-			cfxLog, ext := v.ToCfxLog()
-			logs = append(logs, *ethbridge.ConvertLog(cfxLog, ext))
-		}
+	if fnFilter == nil {
+		return dbFilters, nil, nil
 	}
 
-	// query data from fullnode
-	if fnFilter != nil {
-		// check timeout before fullnode delegation
-		if err := checkTimeout(ctx); err != nil {
-			return nil, false, err
-		}
+	return dbFilters, fnFilter, nil
+}
+
+func (s *ethLogsSpace) checkFullnode(fnFilter interface{}) error {
+	return s.handler.checkFnEthLogFilter(fnFilter.(*types.FilterQuery))
+}
+
+func (s *ethLogsSpace) newLogs() spaceLogs {
+	return EthLogs(nil)
+}
+
+// storeLogs implements logsSpace, and defers the conversion of event logs until encoded.
+func (s *ethLogsSpace) storeLogs(dbLogs []*store.Log) spaceLogs {
+	if len(dbLogs) == 0 {
+		return EthLogs(nil)
+	}
+
+	return EthLogs{{dbLogs: dbLogs}}
+}
+
+// prunedLogs implements logsSpace to query pruned event logs from cold storage if configured,
+// and the rest not offloaded yet from store.
+func (s *ethLogsSpace) prunedLogs(ctx context.Context, dbFilter store.LogFilter) (spaceLogs, error) {
+	if s.handler.coldStore == nil {
+		return nil, s.handler.errLogsPruned(store.ErrAlreadyPruned)
+	}
+
+	coldLogs, coveredTo, err := s.handler.coldStore.GetLogs(ctx, dbFilter)
+	if errors.Is(err, store.ErrAlreadyPruned) {
+		return nil, s.handler.errLogsPruned(err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
 
-		// ensure fullnode delegation is rational
-		if err := handler.checkFnEthLogFilter(fnFilter); err != nil {
-			return nil, false, err
+	if coveredTo < dbFilter.BlockTo {
+		filter := dbFilter
+		filter.BlockFrom = coveredTo + 1
+
+		dbLogs, err := s.handler.ms.GetLogs(ctx, filter)
+		if errors.Is(err, store.ErrAlreadyPruned) { // gap between cold storage and store
+			return nil, s.handler.errLogsPruned(err)
 		}
 
-		fnLogs, err := eth.Logs(*fnFilter)
 		if err != nil {
-			return nil, false, err
+			return nil, err
 		}
 
-		logs = append(logs, fnLogs...)
+		coldLogs = append(coldLogs, dbLogs...)
+		if len(coldLogs) > int(store.MaxLogLimit) {
+			return nil, store.ErrGetLogsResultSetTooLarge
+		}
 	}
 
-	if len(logs) > int(store.MaxLogLimit) {
-		return nil, false, store.ErrGetLogsResultSetTooLarge
-	}
+	return s.storeLogs(coldLogs), nil
+}
 
-	return logs, dbFilter != nil, nil
+func (s *ethLogsSpace) fullnodeLogs(fnFilter interface{}) (spaceLogs, error) {
+	logs, err := s.w3c.Eth.Logs(*fnFilter.(*types.FilterQuery))
+	return NewEthLogs(logs), err
 }
 
 func (handler *EthLogsApiHandler) splitLogFilter(
-	eth *client.RpcEthClient,
+	w3c *node.Web3goClient,
 	filter *types.FilterQuery,
 ) (*store.LogFilter, *types.FilterQuery, error) {
-	maxBlock, ok, err := handler.ms.MaxEpoch()
+	// event logs not persisted in store if selective sync enabled
+	if sfilter := store.ParseEthLogFilterRaw(0, 0, filter); !handler.ms.CoversLogFilter(&sfilter) {
+		return nil, filter, nil
+	}
+
+	blockRange, ok, err := handler.storeEpochRange()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -140,16 +353,16 @@ func (handler *EthLogsApiHandler) splitLogFilter(
 	}
 
 	if filter.BlockHash != nil {
-		return handler.splitLogFilterByBlockHash(eth, filter, maxBlock)
+		return handler.splitLogFilterByBlockHash(w3c.Eth, filter, blockRange)
 	}
 
-	return handler.splitLogFilterByBlockRange(eth, filter, maxBlock)
+	return handler.splitLogFilterByBlockRange(w3c, filter, blockRange)
 }
 
 func (handler *EthLogsApiHandler) splitLogFilterByBlockHash(
 	eth *client.RpcEthClient,
 	filter *types.FilterQuery,
-	maxBlock uint64,
+	blockRange citypes.RangeUint64,
 ) (*store.LogFilter, *types.FilterQuery, error) {
 	block, err := eth.BlockByHash(*filter.BlockHash, false)
 	if err != nil {
@@ -157,12 +370,12 @@ func (handler *EthLogsApiHandler) splitLogFilterByBlockHash(
 	}
 
 	if block == nil || block.Number == nil {
-		return nil, nil, errors.New("unknown block")
+		return nil, nil, rpcutil.ErrUnknownBlock
 	}
 
 	bn := block.Number.Uint64()
 
-	if bn > maxBlock {
+	if bn < blockRange.From || bn > blockRange.To {
 		return nil, filter, nil
 	}
 
@@ -176,26 +389,34 @@ func (handler *EthLogsApiHandler) splitLogFilterByBlockHash(
 }
 
 func (handler *EthLogsApiHandler) splitLogFilterByBlockRange(
-	eth *client.RpcEthClient,
+	w3c *node.Web3goClient,
 	filter *types.FilterQuery,
-	maxBlock uint64,
+	blockRange citypes.RangeUint64,
 ) (*store.LogFilter, *types.FilterQuery, error) {
-	if filter.FromBlock == nil || *filter.FromBlock < 0 {
+	if filter.FromBlock == nil || filter.ToBlock == nil {
 		return nil, filter, nil
 	}
 
-	if filter.ToBlock == nil || *filter.ToBlock < 0 {
+	// resolve block tags if any, so that tag based block range could also benefit from database
+	blockFrom, ok := handler.resolveBlockNumber(w3c, *filter.FromBlock)
+	if !ok {
+		return nil, filter, nil
+	}
+
+	blockTo, ok := handler.resolveBlockNumber(w3c, *filter.ToBlock)
+	if !ok {
 		return nil, filter, nil
 	}
 
-	blockFrom, blockTo := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	maxBlock := blockRange.To
 
-	// no data in database
-	if blockFrom > maxBlock {
+	// no data in database, partial data before the first synced block, or invalid block range
+	// left to fullnode to report
+	if blockFrom > maxBlock || blockFrom < blockRange.From || blockFrom > blockTo {
 		return nil, filter, nil
 	}
 
-	networkId, err := handler.GetNetworkId(eth)
+	networkId, err := handler.GetNetworkId(w3c.Eth)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -208,10 +429,10 @@ func (handler *EthLogsApiHandler) splitLogFilterByBlockRange(
 
 	// otherwise, partial data in databse
 	dbFilter := store.ParseEthLogFilter(blockFrom, maxBlock, filter, networkId)
-	fnBlockFrom := types.BlockNumber(maxBlock + 1)
+	fnBlockFrom, fnBlockTo := types.BlockNumber(maxBlock+1), types.BlockNumber(blockTo)
 	fnFilter := types.FilterQuery{
 		FromBlock: &fnBlockFrom,
-		ToBlock:   filter.ToBlock,
+		ToBlock:   &fnBlockTo,
 		Addresses: filter.Addresses,
 		Topics:    filter.Topics,
 	}
@@ -219,6 +440,35 @@ func (handler *EthLogsApiHandler) splitLogFilterByBlockRange(
 	return &dbFilter, &fnFilter, nil
 }
 
+// resolveBlockNumber resolves block tag `latest`, `safe` or `finalized` into block number by the
+// cached block number of fullnode, so that the resolved block range is split against the store head.
+// Note, block number is returned as it is, and false is returned if unresolvable (eg., `pending`) or
+// failed to resolve, in which case the log filter falls back to fullnode.
+func (handler *EthLogsApiHandler) resolveBlockNumber(
+	w3c *node.Web3goClient, bn types.BlockNumber,
+) (uint64, bool) {
+	tag, ok := rpcutil.EthBlockTag(bn)
+	if !ok {
+		return uint64(bn), true
+	}
+
+	switch tag {
+	case rpcutil.TagLatest, rpcutil.TagSafe, rpcutil.TagFinalized:
+	default:
+		return 0, false
+	}
+
+	num, err := ResolveEthBlock(w3c, bn)
+	if err != nil {
+		logrus.WithField("tag", tag).WithError(err).Debug(
+			"Failed to resolve block tag of log filter, fall back to fullnode",
+		)
+		return 0, false
+	}
+
+	return num, true
+}
+
 func (handler *EthLogsApiHandler) GetNetworkId(eth *client.RpcEthClient) (uint32, error) {
 	if val := handler.networkId.Load(); val != nil {
 		return val.(uint32), nil
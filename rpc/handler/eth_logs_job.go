@@ -0,0 +1,416 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/client"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errLogsJobNotFound         = errors.New("logs job not found or expired")
+	errLogsJobNotCompleted     = errors.New("logs job not completed yet")
+	errLogsJobQueueFull        = errors.New("too many pending logs jobs, please try again later")
+	errLogsJobResultTooLarge   = errors.New("result set of logs job is too large, please narrow down your filter condition")
+	errLogsJobBlockRangeNeeded = errors.New("block range must be specified for logs job")
+	errLogsJobBeyondStore      = errors.New("block range of logs job is beyond the synchronized data")
+	errLogsJobNotCovered       = errors.New("event logs of logs job are not synchronized due to selective sync")
+)
+
+// LogsJobState state of logs job
+type LogsJobState string
+
+const (
+	LogsJobPending   LogsJobState = "pending"
+	LogsJobRunning   LogsJobState = "running"
+	LogsJobCompleted LogsJobState = "completed"
+	LogsJobFailed    LogsJobState = "failed"
+	LogsJobCanceled  LogsJobState = "canceled"
+)
+
+// logsJobConfig logs job configurations
+type logsJobConfig struct {
+	// whether to enable logs job
+	Enabled bool
+	// number of workers to execute logs jobs concurrently
+	Workers int `default:"2"`
+	// max number of pending logs jobs in queue
+	MaxPendingJobs int `default:"100"`
+	// number of blocks per chunk to query from store
+	ChunkBlocks uint64 `default:"1000"`
+	// max number of event logs of the job result
+	MaxResultLogs int `default:"200000"`
+	// duration to retain the finished job before expiration
+	Retention time.Duration `default:"1h"`
+}
+
+// LogsJobStatus status of logs job
+type LogsJobStatus struct {
+	ID           rpc.ID       `json:"id"`
+	State        LogsJobState `json:"state"`
+	FromBlock    uint64       `json:"fromBlock"`
+	ToBlock      uint64       `json:"toBlock"`
+	CurrentBlock uint64       `json:"currentBlock"` // next block number to query
+	Progress     float64      `json:"progress"`     // 0 ~ 1
+	NumLogs      int          `json:"numLogs"`
+	Error        string       `json:"error,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	FinishedAt   *time.Time   `json:"finishedAt,omitempty"`
+}
+
+// logsJob heavy event logs query job executed in background
+type logsJob struct {
+	mu sync.Mutex
+
+	status    LogsJobStatus
+	filter    types.FilterQuery
+	networkId uint32
+	logs      []types.Log
+
+	owner string // API key or tenant that submitted the job
+
+	cancel context.CancelFunc
+}
+
+func (job *logsJob) snapshot() LogsJobStatus {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	status := job.status
+	if status.State == LogsJobCompleted {
+		status.Progress = 1
+	} else {
+		span := status.ToBlock - status.FromBlock + 1
+		status.Progress = float64(status.CurrentBlock-status.FromBlock) / float64(span)
+	}
+
+	return status
+}
+
+func (job *logsJob) finish(state LogsJobState, err error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	now := time.Now()
+	job.status.State, job.status.FinishedAt = state, &now
+
+	if err != nil {
+		job.status.Error = err.Error()
+	}
+
+	if state != LogsJobCompleted { // release memory
+		job.logs = nil
+	}
+}
+
+// EthLogsJobManager manages evm space heavy event logs query jobs, which are executed against
+// the store in background, so that clients could poll the result later by job ID rather than
+// blocking a JSON-RPC call for minutes.
+type EthLogsJobManager struct {
+	conf    logsJobConfig
+	handler *EthLogsApiHandler
+
+	mu   sync.Mutex
+	jobs map[rpc.ID]*logsJob
+
+	queue chan *logsJob
+}
+
+// MustNewEthLogsJobManagerFromViper creates logs job manager from viper, or nil if disabled.
+func MustNewEthLogsJobManagerFromViper(ctx context.Context, handler *EthLogsApiHandler) *EthLogsJobManager {
+	var conf logsJobConfig
+	viper.MustUnmarshalKey("ethrpc.logsJob", &conf)
+
+	if !conf.Enabled || handler == nil {
+		return nil
+	}
+
+	return newEthLogsJobManager(ctx, conf, handler)
+}
+
+func newEthLogsJobManager(ctx context.Context, conf logsJobConfig, handler *EthLogsApiHandler) *EthLogsJobManager {
+	m := &EthLogsJobManager{
+		conf:    conf,
+		handler: handler,
+		jobs:    make(map[rpc.ID]*logsJob),
+		queue:   make(chan *logsJob, conf.MaxPendingJobs),
+	}
+
+	for i := 0; i < conf.Workers; i++ {
+		go m.work(ctx)
+	}
+
+	go m.gc(ctx)
+
+	return m
+}
+
+// Submit submits a new logs job with the normalized log filter on behalf of the specified owner,
+// which is the only one allowed to access the job later.
+func (m *EthLogsJobManager) Submit(eth *client.RpcEthClient, filter types.FilterQuery, owner string) (rpc.ID, error) {
+	if filter.BlockHash != nil || filter.FromBlock == nil || filter.ToBlock == nil {
+		return "", errLogsJobBlockRangeNeeded
+	}
+
+	if *filter.FromBlock < 0 || *filter.ToBlock < 0 || *filter.FromBlock > *filter.ToBlock {
+		return "", errLogsJobBlockRangeNeeded
+	}
+
+	if sfilter := store.ParseEthLogFilterRaw(0, 0, &filter); !m.handler.ms.CoversLogFilter(&sfilter) {
+		return "", errLogsJobNotCovered
+	}
+
+	blockRange, ok, err := m.handler.storeEpochRange()
+	if err != nil {
+		return "", err
+	}
+
+	if !ok || uint64(*filter.FromBlock) < blockRange.From || uint64(*filter.ToBlock) > blockRange.To {
+		return "", errors.WithMessagef(errLogsJobBeyondStore, "synchronized block range %v", blockRange)
+	}
+
+	networkId, err := m.handler.GetNetworkId(eth)
+	if err != nil {
+		return "", err
+	}
+
+	job := &logsJob{
+		filter:    filter,
+		networkId: networkId,
+		owner:     owner,
+		status: LogsJobStatus{
+			ID:           rpc.NewID(),
+			State:        LogsJobPending,
+			FromBlock:    uint64(*filter.FromBlock),
+			ToBlock:      uint64(*filter.ToBlock),
+			CurrentBlock: uint64(*filter.FromBlock),
+			CreatedAt:    time.Now(),
+		},
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case m.queue <- job:
+		m.jobs[job.status.ID] = job
+		return job.status.ID, nil
+	default:
+		return "", errLogsJobQueueFull
+	}
+}
+
+// Status returns the status of the specified logs job.
+func (m *EthLogsJobManager) Status(id rpc.ID, owner string) (*LogsJobStatus, error) {
+	job, ok := m.get(id, owner)
+	if !ok {
+		return nil, errLogsJobNotFound
+	}
+
+	status := job.snapshot()
+	return &status, nil
+}
+
+// Result returns the event logs of the specified completed logs job.
+func (m *EthLogsJobManager) Result(id rpc.ID, owner string) ([]types.Log, error) {
+	job, ok := m.get(id, owner)
+	if !ok {
+		return nil, errLogsJobNotFound
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	switch job.status.State {
+	case LogsJobCompleted:
+		return job.logs, nil
+	case LogsJobFailed, LogsJobCanceled:
+		return nil, errors.Errorf("logs job %v: %v", job.status.State, job.status.Error)
+	default:
+		return nil, errLogsJobNotCompleted
+	}
+}
+
+// Cancel cancels the specified logs job if not finished yet.
+func (m *EthLogsJobManager) Cancel(id rpc.ID, owner string) bool {
+	job, ok := m.get(id, owner)
+	if !ok {
+		return false
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	switch job.status.State {
+	case LogsJobPending:
+		now := time.Now()
+		job.status.State, job.status.FinishedAt = LogsJobCanceled, &now
+		return true
+	case LogsJobRunning:
+		job.cancel()
+		return true
+	}
+
+	return false
+}
+
+// get returns the specified logs job, which is treated as not found if submitted by
+// others, so as not to leak the existence of jobs among clients.
+func (m *EthLogsJobManager) get(id rpc.ID, owner string) (*logsJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok || job.owner != owner {
+		return nil, false
+	}
+
+	return job, true
+}
+
+func (m *EthLogsJobManager) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-m.queue:
+			m.execute(ctx, job)
+		}
+	}
+}
+
+func (m *EthLogsJobManager) execute(ctx context.Context, job *logsJob) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	job.mu.Lock()
+	if job.status.State != LogsJobPending { // canceled already
+		job.mu.Unlock()
+		return
+	}
+
+	job.status.State, job.cancel = LogsJobRunning, cancel
+	job.mu.Unlock()
+
+	err := m.query(ctx, job)
+	switch {
+	case err == nil:
+		job.finish(LogsJobCompleted, nil)
+	case ctx.Err() != nil:
+		job.finish(LogsJobCanceled, ctx.Err())
+	default:
+		job.finish(LogsJobFailed, err)
+		logrus.WithField("job", job.status.ID).WithError(err).Info("Failed to execute logs job")
+	}
+}
+
+// query queries event logs from store chunk by chunk.
+func (m *EthLogsJobManager) query(ctx context.Context, job *logsJob) error {
+	for from, to := job.status.FromBlock, job.status.ToBlock; from <= to; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := from + m.conf.ChunkBlocks - 1
+		if end > to {
+			end = to
+		}
+
+		logs, err := m.queryChunk(ctx, &job.filter, from, end, job.networkId)
+		if errors.Is(err, store.ErrGetLogsResultSetTooLarge) && end > from {
+			// too many event logs within chunk, narrow down the chunk and try again
+			end = from + (end-from)/2
+			logs, err = m.queryChunk(ctx, &job.filter, from, end, job.networkId)
+		}
+
+		if err != nil {
+			return errors.WithMessagef(err, "failed to query logs from block %v to %v", from, end)
+		}
+
+		job.mu.Lock()
+		job.logs = append(job.logs, logs...)
+		job.status.NumLogs = len(job.logs)
+		job.status.CurrentBlock = end + 1
+		job.mu.Unlock()
+
+		if len(job.logs) > m.conf.MaxResultLogs {
+			return errLogsJobResultTooLarge
+		}
+
+		from = end + 1
+	}
+
+	return nil
+}
+
+// queryChunk queries event logs of the specified block range from store with reorg guard.
+func (m *EthLogsJobManager) queryChunk(
+	ctx context.Context, filter *types.FilterQuery, from, to uint64, networkId uint32,
+) ([]types.Log, error) {
+	ms := m.handler.ms
+	dbFilter := store.ParseEthLogFilter(from, to, filter, networkId)
+
+	for {
+		lastReorgVersion, err := ms.GetReorgVersion()
+		if err != nil {
+			return nil, err
+		}
+
+		timeoutCtx, cancel := rpcutil.WithStoreTimeout(ctx, "eth_getLogs")
+		dbLogs, err := ms.GetLogs(timeoutCtx, dbFilter)
+		cancel()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reorgVersion, err := ms.GetReorgVersion()
+		if err != nil {
+			return nil, err
+		}
+
+		if reorgVersion != lastReorgVersion { // reorg occurred, try again
+			continue
+		}
+
+		logs := make([]types.Log, 0, len(dbLogs))
+		for _, v := range dbLogs {
+			cfxLog, ext := v.ToCfxLog()
+			logs = append(logs, *ethbridge.ConvertLog(cfxLog, ext))
+		}
+
+		return logs, nil
+	}
+}
+
+// gc removes the expired finished jobs periodically.
+func (m *EthLogsJobManager) gc(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		for id, job := range m.jobs {
+			status := job.snapshot()
+			if status.FinishedAt != nil && time.Since(*status.FinishedAt) > m.conf.Retention {
+				delete(m.jobs, id)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthLogsJobOwner(t *testing.T) {
+	job := &logsJob{
+		owner:  "alice",
+		logs:   []types.Log{{BlockNumber: 5}},
+		status: LogsJobStatus{ID: rpc.ID("0x1"), State: LogsJobCompleted},
+	}
+
+	m := &EthLogsJobManager{
+		jobs: map[rpc.ID]*logsJob{job.status.ID: job},
+	}
+
+	// accessible to the owner
+	status, err := m.Status(job.status.ID, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, LogsJobCompleted, status.State)
+
+	logs, err := m.Result(job.status.ID, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(logs))
+
+	// treated as not found for others
+	_, err = m.Status(job.status.ID, "bob")
+	assert.Equal(t, errLogsJobNotFound, err)
+
+	_, err = m.Result(job.status.ID, "bob")
+	assert.Equal(t, errLogsJobNotFound, err)
+
+	assert.False(t, m.Cancel(job.status.ID, "bob"))
+
+	_, err = m.Status(job.status.ID, "")
+	assert.Equal(t, errLogsJobNotFound, err)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStoreLog(epoch, logIndex uint64) *store.Log {
+	addr := cfxaddress.MustNewFromHex("0x8b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27", 1030)
+	blockHash := cfxtypes.Hash("0x00000000000000000000000000000000000000000000000000000000000000b1")
+	txHash := cfxtypes.Hash("0x00000000000000000000000000000000000000000000000000000000000000c1")
+
+	log := cfxtypes.Log{
+		Address:             addr,
+		Topics:              []cfxtypes.Hash{txHash},
+		Data:                []byte{1, 2, 3},
+		BlockHash:           &blockHash,
+		EpochNumber:         cfxtypes.NewBigInt(epoch),
+		TransactionHash:     &txHash,
+		TransactionIndex:    cfxtypes.NewBigInt(0),
+		LogIndex:            cfxtypes.NewBigInt(logIndex),
+		TransactionLogIndex: cfxtypes.NewBigInt(logIndex),
+	}
+
+	return store.ParseCfxLog(&log, 1, epoch, nil)
+}
+
+func TestEthLogsMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(EthLogs(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	fnLogs := []types.Log{{BlockNumber: 102, Index: 0}, {BlockNumber: 103, Index: 1}}
+
+	var logs spaceLogs = EthLogs(nil)
+	logs = logs.append(EthLogs{{dbLogs: []*store.Log{newTestStoreLog(100, 0), newTestStoreLog(101, 1)}}})
+	logs = logs.append(NewEthLogs(fnLogs))
+	assert.Equal(t, 4, logs.len())
+
+	materialized := logs.(EthLogs).Logs()
+	assert.Equal(t, 4, len(materialized))
+	assert.Equal(t, uint64(100), materialized[0].BlockNumber)
+	assert.Equal(t, uint64(103), materialized[3].BlockNumber)
+
+	expected, err := json.Marshal(materialized)
+	assert.NoError(t, err)
+
+	streamed, err := json.Marshal(logs)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(streamed))
+}
+
+func newBenchEthLogs() EthLogs {
+	dbLogs := make([]*store.Log, 0, 1000)
+	for i := uint64(0); i < 1000; i++ {
+		dbLogs = append(dbLogs, newTestStoreLog(100+i, i))
+	}
+
+	return EthLogs{{dbLogs: dbLogs}}
+}
+
+func BenchmarkEthLogsMaterialized(b *testing.B) {
+	logs := newBenchEthLogs()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(logs.Logs())
+	}
+}
+
+func BenchmarkEthLogsMarshalJSON(b *testing.B) {
+	logs := newBenchEthLogs()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(logs)
+	}
+}
+
+// newTestWeb3goClient creates client of fake fullnode, which responds the latest block 100, safe
+// block 90 and fails to resolve the finalized block.
+func newTestWeb3goClient(t *testing.T) (*node.Web3goClient, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		result := `null`
+		switch {
+		case req.Method == "eth_chainId":
+			result = `"0x47"`
+		case req.Method == "eth_blockNumber":
+			result = `"0x64"`
+		case req.Method == "eth_getBlockByNumber" && string(req.Params[0]) == `"safe"`:
+			result = `{"number":"0x5a","difficulty":"0x0"}`
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+
+	eth, err := rpcutil.NewEthClient(server.URL)
+	assert.NoError(t, err)
+
+	return &node.Web3goClient{Client: eth, URL: server.URL}, func() {
+		eth.Close()
+		server.Close()
+	}
+}
+
+func TestSplitEthLogFilterByBlockTags(t *testing.T) {
+	w3c, closer := newTestWeb3goClient(t)
+	defer closer()
+
+	handler := &EthLogsApiHandler{}
+	storeRange := citypes.RangeUint64{From: 10, To: 95}
+
+	newFilter := func(from, to types.BlockNumber) *types.FilterQuery {
+		return &types.FilterQuery{FromBlock: &from, ToBlock: &to}
+	}
+
+	// `latest` resolved beyond store head, and split by store head
+	filter := newFilter(20, types.LatestBlockNumber)
+	dbFilter, fnFilter, err := handler.splitLogFilterByBlockRange(w3c, filter, storeRange)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), dbFilter.BlockFrom)
+	assert.Equal(t, uint64(95), dbFilter.BlockTo)
+	assert.Equal(t, types.BlockNumber(96), *fnFilter.FromBlock)
+	assert.Equal(t, types.BlockNumber(100), *fnFilter.ToBlock)
+
+	// `safe` resolved within store
+	filter = newFilter(20, types.SafeBlockNumber)
+	dbFilter, fnFilter, err = handler.splitLogFilterByBlockRange(w3c, filter, storeRange)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), dbFilter.BlockFrom)
+	assert.Equal(t, uint64(90), dbFilter.BlockTo)
+	assert.Nil(t, fnFilter)
+
+	// `pending` unresolvable, and `finalized` failed to resolve, both fall back to fullnode
+	for _, to := range []types.BlockNumber{types.PendingBlockNumber, types.FinalizedBlockNumber} {
+		filter = newFilter(20, to)
+		dbFilter, fnFilter, err = handler.splitLogFilterByBlockRange(w3c, filter, storeRange)
+		assert.NoError(t, err)
+		assert.Nil(t, dbFilter)
+		assert.Equal(t, filter, fnFilter)
+	}
+}
+
+func TestEthLogsCostFilterByBlockTags(t *testing.T) {
+	w3c, closer := newTestWeb3goClient(t)
+	defer closer()
+
+	handler := &EthLogsApiHandler{}
+
+	costFilter := func(from, to types.BlockNumber) *store.LogFilter {
+		space := handler.space(w3c, &types.FilterQuery{FromBlock: &from, ToBlock: &to})
+
+		sfilter, err := space.costFilter()
+		assert.NoError(t, err)
+
+		return sfilter
+	}
+
+	// `latest` and `safe` resolved as the split
+	sfilter := costFilter(20, types.LatestBlockNumber)
+	assert.Equal(t, uint64(20), sfilter.BlockFrom)
+	assert.Equal(t, uint64(100), sfilter.BlockTo)
+
+	sfilter = costFilter(types.SafeBlockNumber, types.LatestBlockNumber)
+	assert.Equal(t, uint64(90), sfilter.BlockFrom)
+	assert.Equal(t, uint64(100), sfilter.BlockTo)
+
+	// not estimated if unresolvable, failed to resolve or invalid block range
+	assert.Nil(t, costFilter(20, types.PendingBlockNumber))
+	assert.Nil(t, costFilter(20, types.FinalizedBlockNumber))
+	assert.Nil(t, costFilter(types.LatestBlockNumber, 20))
+
+	// not estimated for block hash
+	space := handler.space(w3c, &types.FilterQuery{})
+	sfilter, err := space.costFilter()
+	assert.NoError(t, err)
+	assert.Nil(t, sfilter)
+}
@@ -14,12 +14,17 @@ import (
 
 // EthStoreHandler RPC handler to get block/txn/receipt data from store.
 type EthStoreHandler struct {
-	store store.Readable
-	next  *EthStoreHandler
+	store      store.Readable
+	storeRange *cachedStoreRange // block range served by store, nil if unbounded
+	next       *EthStoreHandler
 }
 
 func NewEthStoreHandler(store store.Readable, next *EthStoreHandler) *EthStoreHandler {
-	return &EthStoreHandler{store: store, next: next}
+	return &EthStoreHandler{
+		store:      store,
+		storeRange: newCachedStoreRange("eth", store),
+		next:       next,
+	}
 }
 
 func (h *EthStoreHandler) GetBlockByHash(ctx context.Context, blockHash common.Hash, includeTxs bool) (
@@ -72,9 +77,12 @@ func (h *EthStoreHandler) GetBlockByNumber(ctx context.Context, blockNum *web3Ty
 	var sblock *store.Block
 	var sblocksum *store.BlockSummary
 
-	if includeTxs {
+	switch {
+	case !h.storeRange.contains(uint64(*blockNum)):
+		err = store.ErrNotFound
+	case includeTxs:
 		sblock, err = h.store.GetBlockByBlockNumber(ctx, uint64(*blockNum))
-	} else {
+	default:
 		sblocksum, err = h.store.GetBlockSummaryByBlockNumber(ctx, uint64(*blockNum))
 	}
 
@@ -150,3 +158,46 @@ func (h *EthStoreHandler) GetTransactionReceipt(ctx context.Context, txHash comm
 
 	return nil, err
 }
+
+func (h *EthStoreHandler) GetBlockReceipts(ctx context.Context, blockNumOrHash *web3Types.BlockNumberOrHash) (
+	receipts []*web3Types.Receipt, err error,
+) {
+	logger := logrus.WithField("blockNumOrHash", blockNumOrHash)
+
+	var blockNum uint64
+	if blockNumOrHash.BlockHash != nil {
+		cfxBlockHash := cfxbridge.ConvertHash(*blockNumOrHash.BlockHash)
+
+		var sblocksum *store.BlockSummary
+		if sblocksum, err = h.store.GetBlockSummaryByHash(ctx, cfxBlockHash); err == nil {
+			blockNum = sblocksum.CfxBlockSummary.EpochNumber.ToInt().Uint64()
+		}
+	} else if blockNumOrHash.BlockNumber == nil || *blockNumOrHash.BlockNumber <= 0 {
+		return nil, store.ErrUnsupported
+	} else {
+		blockNum = uint64(*blockNumOrHash.BlockNumber)
+	}
+
+	// out of the block range served by store, eg., not synced yet or pruned
+	if err == nil && !h.storeRange.contains(blockNum) {
+		err = store.ErrNotFound
+	}
+
+	var srcpts []*store.TransactionReceipt
+	if err == nil {
+		// for evm space, epoch number is the same as block number
+		srcpts, err = h.store.GetReceiptsByEpoch(ctx, blockNum)
+	}
+
+	if err != nil {
+		logger.WithError(err).Debug("ETH handler failed to handle GetBlockReceipts")
+
+		if !util.IsInterfaceValNil(h.next) {
+			return h.next.GetBlockReceipts(ctx, blockNumOrHash)
+		}
+
+		return nil, err
+	}
+
+	return ethbridge.ConvertReceipts(srcpts), nil
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"github.com/Conflux-Chain/confura/node"
-	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/relay"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/ethereum/go-ethereum/common"
@@ -15,9 +14,9 @@ import (
 
 // EthTxnHandler evm space RPC handler to optimize sending transaction by relay and replication.
 type EthTxnHandler struct {
-	relayer relay.TxnRelayer    // transaction relayer
-	nclient *rpc.Client         // node RPC client
-	clients *util.ConcurrentMap // sdk clients: node name => RPC client
+	relayer     relay.TxnRelayer   // transaction relayer
+	broadcaster *relay.Broadcaster // optional transaction broadcaster
+	nclient     *rpc.Client        // node RPC client
 }
 
 func MustNewEthTxnHandler(relayer relay.TxnRelayer) *EthTxnHandler {
@@ -35,13 +34,17 @@ func MustNewEthTxnHandler(relayer relay.TxnRelayer) *EthTxnHandler {
 	}
 
 	return &EthTxnHandler{
-		relayer: relayer,
-		nclient: nodeRpcClient,
-		clients: &util.ConcurrentMap{},
+		relayer:     relayer,
+		broadcaster: relay.MustNewBroadcasterFromViper("eth"),
+		nclient:     nodeRpcClient,
 	}
 }
 
 func (h *EthTxnHandler) SendRawTxn(w3c *node.Web3goClient, group node.Group, signedTx hexutil.Bytes) (common.Hash, error) {
+	if h.broadcaster != nil {
+		return h.broadcastRawTxn(w3c, group, signedTx)
+	}
+
 	txHash, err := w3c.Eth.SendRawTransaction(signedTx)
 	if err != nil {
 		return txHash, err
@@ -57,6 +60,56 @@ func (h *EthTxnHandler) SendRawTxn(w3c *node.Web3goClient, group node.Group, sig
 	return txHash, err
 }
 
+// broadcastRawTxn broadcasts raw txn to the routed full node along with the healthy full nodes of
+// some specific group concurrently.
+func (h *EthTxnHandler) broadcastRawTxn(w3c *node.Web3goClient, group node.Group, signedTx hexutil.Bytes) (common.Hash, error) {
+	txHash, err := h.broadcaster.Broadcast(w3c.URL, h.healthyGroupNodeUrls(group), func(url string) (string, error) {
+		client := w3c.Client
+		if url != w3c.URL {
+			var err error
+			if client, err = h.nodeClient(url); err != nil {
+				return "", err
+			}
+		}
+
+		txHash, err := client.Eth.SendRawTransaction(signedTx)
+		return txHash.Hex(), err
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// relay transaction broadcasting asynchronously
+	if h.relayer != nil && !h.relayer.Relay(signedTx) {
+		logrus.Info("Txn relay pool is full, dropping transaction")
+	}
+
+	return common.HexToHash(txHash), nil
+}
+
+// healthyGroupNodeUrls returns the URLs of healthy full nodes of some specific group.
+func (h *EthTxnHandler) healthyGroupNodeUrls(group node.Group) []string {
+	if h.nclient != nil { // fetch healthy group nodes from node RPC
+		var nodeUrls []string
+
+		if err := h.nclient.Call(&nodeUrls, "node_listHealthy", group); err != nil {
+			logrus.WithField("group", group).
+				WithError(err).
+				Error("Txn handler failed to get healthy group full nodes from node RPC")
+			return nil
+		}
+
+		return nodeUrls
+	}
+
+	// otherwise get group nodes from local config, of which health status is unknown
+	if conf, ok := node.EthUrlConfig()[group]; ok {
+		return conf.Nodes
+	}
+
+	return nil
+}
+
 // replicateRawTxnSendingByGroup synchronously replicate raw txn sending to all full nodes of some specific group
 func (h *EthTxnHandler) replicateRawTxnSendingByGroup(group node.Group, signedTx hexutil.Bytes) {
 	if h.nclient != nil { // fetch group nodes from node RPC
@@ -81,11 +134,7 @@ func (h *EthTxnHandler) replicateRawTxnSendingByGroup(group node.Group, signedTx
 
 func (h *EthTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedTx hexutil.Bytes) {
 	for _, url := range nodeUrls {
-		nodeName := rpcutil.Url2NodeName(url)
-		c, _, err := h.clients.LoadOrStoreFnErr(nodeName, func(interface{}) (interface{}, error) {
-			return rpcutil.NewEthClient(url)
-		})
-
+		c, err := h.nodeClient(url)
 		if err != nil {
 			logrus.WithField("url", url).
 				WithError(err).
@@ -93,7 +142,7 @@ func (h *EthTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedT
 			continue
 		}
 
-		_, err = c.(*web3go.Client).Eth.SendRawTransaction(signedTx)
+		_, err = c.Eth.SendRawTransaction(signedTx)
 		if err != nil && !utils.IsRPCJSONError(err) {
 			logrus.WithField("url", url).
 				WithError(err).
@@ -101,3 +150,8 @@ func (h *EthTxnHandler) replicateRawTxnSendingToNodes(nodeUrls []string, signedT
 		}
 	}
 }
+
+// nodeClient gets or creates web3go client of the full node from the shared client pool.
+func (h *EthTxnHandler) nodeClient(url string) (*web3go.Client, error) {
+	return rpcutil.EthClientPool.GetEthClient(url)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Conflux-Chain/confura/store"
 	itypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/gasstation"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/sirupsen/logrus"
 )
@@ -32,13 +33,23 @@ var (
 // GasStationHandler RPC handler to serve gas price estimation etc.,
 type GasStationHandler struct {
 	db, cache store.Configurable
+	oracle    *gasstation.Oracle // could be nil if gas price oracle disabled
 }
 
-func NewGasStationHandler(db, cache store.Configurable) *GasStationHandler {
-	return &GasStationHandler{db: db, cache: cache}
+func NewGasStationHandler(db, cache store.Configurable, oracle *gasstation.Oracle) *GasStationHandler {
+	return &GasStationHandler{db: db, cache: cache, oracle: oracle}
 }
 
 func (handler *GasStationHandler) GetPrice() (*itypes.GasStationPrice, error) {
+	if handler.oracle != nil { // suggested by gas price oracle first
+		price, err := handler.oracle.Suggest()
+		if err == nil {
+			return price, nil
+		}
+
+		logrus.WithError(err).Debug("Gas station failed to get gas price suggested by oracle")
+	}
+
 	gasStationPriceConfs := []string{ // order is important !!!
 		ConfGasStationPriceFast,
 		ConfGasStationPriceFastest,
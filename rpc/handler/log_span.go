@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// tier of requests without VIP or SVIP status
+	LogSpanTierFree = "free"
+	// tier of requests with SVIP status (by registered rate limit key)
+	LogSpanTierSVip = "svip"
+)
+
+// LogSpanTier returns the tier of request to cap the block span of log filter, which could be
+// `vip<N>` (eg., `vip1`, `vip100` for billing), `svip` or `free`.
+func LogSpanTier(ctx context.Context) string {
+	if vip, ok := handlers.VipStatusFromContext(ctx); ok {
+		return fmt.Sprintf("vip%d", vip.Tier)
+	}
+
+	if _, ok := rate.SVipStatusFromContext(ctx); ok {
+		return LogSpanTierSVip
+	}
+
+	return LogSpanTierFree
+}
+
+// LogSpanLimiter enforces per-tier max block span (epoch span for core space) of log filter,
+// which could be overridden for some rate limit key as granted by admin.
+type LogSpanLimiter struct {
+	mu sync.RWMutex
+
+	caps      map[string]uint64                 // tier => max span
+	overrides map[string]*mysql.LogSpanOverride // limit key => override
+}
+
+// MustNewLogSpanLimiterFromViper creates log span limiter from viper settings, or returns nil
+// if no span cap configured.
+func MustNewLogSpanLimiterFromViper() *LogSpanLimiter {
+	var conf struct {
+		// tier => max block span, 0 or absent means unlimited
+		SpanCaps map[string]uint64
+	}
+
+	viper.MustUnmarshalKey("constraints.logfilter", &conf)
+
+	if len(conf.SpanCaps) == 0 {
+		return nil
+	}
+
+	return NewLogSpanLimiter(conf.SpanCaps)
+}
+
+func NewLogSpanLimiter(caps map[string]uint64) *LogSpanLimiter {
+	return &LogSpanLimiter{
+		caps:      caps,
+		overrides: make(map[string]*mysql.LogSpanOverride),
+	}
+}
+
+// Validate validates the block span of log filter against the per-key override if granted,
+// or the cap of request tier.
+//
+// Note, limiter is nil-safe, in which case no span is capped.
+func (l *LogSpanLimiter) Validate(ctx context.Context, span uint64) error {
+	if l == nil {
+		return nil
+	}
+
+	maxSpan, tier := l.maxSpan(ctx)
+	if maxSpan == 0 || span <= maxSpan {
+		return nil
+	}
+
+	err := errors.Errorf(
+		"block range %v exceeds the max span %v of tier %v, please narrow down your filter condition "+
+			"or request for an override", span, maxSpan, tier,
+	)
+	hints := &rpcutil.ErrorHints{MaxBlockRange: &maxSpan}
+	return rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, err, hints)
+}
+
+// maxSpan returns the max block span of log filter along with the applied tier.
+func (l *LogSpanLimiter) maxSpan(ctx context.Context) (uint64, string) {
+	if limitKey, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(limitKey) > 0 {
+		l.mu.RLock()
+		override, ok := l.overrides[limitKey]
+		l.mu.RUnlock()
+
+		if ok && !override.Expired(time.Now()) {
+			return override.MaxSpan, "override"
+		}
+	}
+
+	tier := LogSpanTier(ctx)
+	return l.caps[tier], tier
+}
+
+// AutoReload reloads the per-key overrides periodically.
+func (l *LogSpanLimiter) AutoReload(interval time.Duration, reloader func() ([]*mysql.LogSpanOverride, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if overrides, err := reloader(); err != nil {
+			logrus.WithError(err).Error("Failed to load log span overrides")
+		} else {
+			l.reload(overrides)
+		}
+
+		<-ticker.C
+	}
+}
+
+func (l *LogSpanLimiter) reload(overrides []*mysql.LogSpanOverride) {
+	key2Overrides := make(map[string]*mysql.LogSpanOverride, len(overrides))
+	for _, o := range overrides {
+		key2Overrides[o.LimitKey] = o
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides = key2Overrides
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSpanLimiterValidate(t *testing.T) {
+	limiter := NewLogSpanLimiter(map[string]uint64{LogSpanTierFree: 100})
+
+	expired := time.Now().Add(-time.Minute)
+	limiter.reload([]*mysql.LogSpanOverride{
+		{LimitKey: "granted", MaxSpan: 1000},
+		{LimitKey: "expired", MaxSpan: 1000, ExpiresAt: &expired},
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, limiter.Validate(ctx, 100))
+	assert.Error(t, limiter.Validate(ctx, 101))
+
+	grantedCtx := context.WithValue(ctx, handlers.CtxKeyAccessToken, "granted")
+	assert.NoError(t, limiter.Validate(grantedCtx, 1000))
+	assert.Error(t, limiter.Validate(grantedCtx, 1001))
+
+	expiredCtx := context.WithValue(ctx, handlers.CtxKeyAccessToken, "expired")
+	assert.Error(t, limiter.Validate(expiredCtx, 101))
+
+	// nil limiter caps nothing
+	var nilLimiter *LogSpanLimiter
+	assert.NoError(t, nilLimiter.Validate(ctx, 1000000))
+}
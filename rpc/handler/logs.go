@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+)
+
+// logsSpace abstracts the space specific operations to query event logs, including log filter
+// parsing, splitting for store and fullnode, and bridging of store data, so that core space and
+// evm space could share the same logs handler.
+//
+// Note, each implementation is bound to the fullnode client and log filter of a single request.
+type logsSpace interface {
+	// scanRange returns the numbered epoch (block for evm space) range of the log filter, or false
+	// if not applicable for sequential scanning.
+	scanRange() (from, to uint64, ok bool)
+
+	// scannerKey returns the key to identify the sequential scanner of the log filter.
+	scannerKey(ctx context.Context) string
+
+	// shape returns the shape of the log filter to aggregate store hit statistics, which is
+	// composed of range type, number of contract addresses and specified topic positions.
+	shape() string
+
+	// withRange returns a copy bound with the log filter of the specified numbered range.
+	withRange(from, to uint64) logsSpace
+
+	// costFilter returns the store log filter to estimate query cost, or nil if not applicable.
+	costFilter() (*store.LogFilter, error)
+
+	// split splits the log filter into store log filters and fullnode log filter (nil if all data
+	// in store).
+	split() (dbFilters []store.LogFilter, fnFilter interface{}, err error)
+
+	// checkFullnode checks if the log filter is rational for fullnode delegation.
+	checkFullnode(fnFilter interface{}) error
+
+	// newLogs returns empty event logs of the space.
+	newLogs() spaceLogs
+
+	// storeLogs bridges the event logs queried from store.
+	storeLogs(dbLogs []*store.Log) spaceLogs
+
+	// prunedLogs queries the event logs already pruned from store, e.g., from archive fullnode.
+	prunedLogs(ctx context.Context, dbFilter store.LogFilter) (spaceLogs, error)
+
+	// fullnodeLogs queries the event logs from fullnode.
+	fullnodeLogs(fnFilter interface{}) (spaceLogs, error)
+}
+
+// spaceLogs event logs of the specific space.
+type spaceLogs interface {
+	len() int
+	append(other spaceLogs) spaceLogs
+}
+
+// logsApiHandler RPC handler to get event logs from store or fullnode, which is shared by
+// core space and evm space.
+type logsApiHandler struct {
+	ms         *mysql.MysqlStore
+	planner    *LogsQueryPlanner // optional
+	prefetcher *LogsPrefetcher   // optional
+	bounds     storeBounds       // epoch (or block) range configured to sync into store
+
+	// RPC method to bound the time to query event logs by
+	rpcMethod string
+}
+
+func (handler *logsApiHandler) getLogs(
+	ctx context.Context, space logsSpace, delegatedRpcMethod string,
+) (spaceLogs, bool, error) {
+	logs, hitStore, err := handler.getLogsPrefetched(ctx, space, delegatedRpcMethod)
+	if err == nil {
+		// aggregate store hits per access key and log filter shape for acceleration ratio report
+		metrics.DefaultStoreHitCollector().MarkHit(logsAccessKey(ctx), space.shape(), hitStore)
+	}
+
+	return logs, hitStore, err
+}
+
+func (handler *logsApiHandler) getLogsPrefetched(
+	ctx context.Context, space logsSpace, delegatedRpcMethod string,
+) (spaceLogs, bool, error) {
+	from, to, ok := space.scanRange()
+	if handler.prefetcher == nil || !ok {
+		return handler.queryLogs(ctx, space, delegatedRpcMethod)
+	}
+
+	key := space.scannerKey(ctx)
+
+	reorgVersion, err := handler.ms.GetReorgVersion()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// serve with the prefetched event logs if sequential scanner detected
+	if logs, ok := handler.prefetcher.take(key, from, to, reorgVersion); ok {
+		handler.prefetchNext(space, key, from, to)
+		return logs.(spaceLogs), true, nil
+	}
+
+	logs, hitStore, err := handler.queryLogs(ctx, space, delegatedRpcMethod)
+	if err == nil {
+		handler.prefetchNext(space, key, from, to)
+	}
+
+	return logs, hitStore, err
+}
+
+// prefetchNext prefetches event logs of the next adjacent range in background if sequential
+// access detected.
+func (handler *logsApiHandler) prefetchNext(space logsSpace, key string, from, to uint64) {
+	nextFrom, nextTo, ok := handler.prefetcher.observe(key, from, to)
+	if !ok {
+		return
+	}
+
+	// only prefetch the range already persisted in store, which could be invalidated by
+	// the reorg version of store
+	epochRange, ok, err := handler.storeEpochRange()
+	if err != nil || !ok || nextFrom < epochRange.From || nextTo > epochRange.To {
+		return
+	}
+
+	next := space.withRange(nextFrom, nextTo)
+
+	handler.prefetcher.prefetch(key, nextFrom, nextTo, func(ctx context.Context, from, to uint64) (interface{}, int, error) {
+		reorgVersion, err := handler.ms.GetReorgVersion()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		logs, _, err := handler.queryLogs(ctx, next, "")
+		return logs, reorgVersion, err
+	})
+}
+
+func (handler *logsApiHandler) queryLogs(
+	ctx context.Context, space logsSpace, delegatedRpcMethod string,
+) (spaceLogs, bool, error) {
+	// estimate query cost to reject or queue heavy queries before execution
+	release, err := handler.admit(ctx, space, delegatedRpcMethod)
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
+	// store queries are bounded by their own time budget, so that the remaining time budget of
+	// RPC call is left for fullnode fallback
+	storeCtx, cancel := rpcutil.WithStoreTimeout(ctx, handler.rpcMethod)
+	defer cancel()
+
+	// record the reorg version before query to ensure data consistence
+	lastReorgVersion, err := handler.ms.GetReorgVersion()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		logs, hitStore, err := handler.getLogsReorgGuard(ctx, storeCtx, space, delegatedRpcMethod)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// check the reorg version after query
+		reorgVersion, err := handler.ms.GetReorgVersion()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if reorgVersion == lastReorgVersion {
+			return logs, hitStore, nil
+		}
+
+		// when reorg occurred, check timeout before retry.
+		if err := checkTimeout(storeCtx); err != nil {
+			return nil, false, rpcutil.ErrReorgInProgress
+		}
+
+		// reorg version changed during data query and try again.
+		lastReorgVersion = reorgVersion
+	}
+}
+
+// admit estimates the cost of the log filter with query planner to decide whether to execute it.
+func (handler *logsApiHandler) admit(
+	ctx context.Context, space logsSpace, delegatedRpcMethod string,
+) (func(), error) {
+	if handler.planner == nil {
+		return func() {}, nil
+	}
+
+	sfilter, err := space.costFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	if sfilter == nil {
+		return func() {}, nil
+	}
+
+	release, err := handler.planner.Admit(ctx, sfilter)
+	if len(delegatedRpcMethod) > 0 {
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/cost/rejected").Mark(err != nil)
+	}
+
+	return release, err
+}
+
+// getLogsReorgGuard queries event logs from database bounded by the store context, and from fullnode
+// bounded by the RPC call context.
+func (handler *logsApiHandler) getLogsReorgGuard(
+	ctx, storeCtx context.Context, space logsSpace, delegatedRpcMethod string,
+) (spaceLogs, bool, error) {
+	// Try to query event logs from database and fullnode.
+	dbFilters, fnFilter, err := space.split()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(delegatedRpcMethod) > 0 {
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/alldatabase").Mark(fnFilter == nil)
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/allfullnode").Mark(len(dbFilters) == 0)
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/partial").Mark(len(dbFilters) > 0 && fnFilter != nil)
+	}
+
+	logs := space.newLogs()
+
+	// query data from database
+	for i := range dbFilters {
+		if err := checkTimeout(storeCtx); err != nil {
+			return nil, false, err
+		}
+
+		dbLogs, err := handler.ms.GetLogs(storeCtx, dbFilters[i])
+
+		// succeeded to get logs from database
+		if err == nil {
+			logs = logs.append(space.storeLogs(dbLogs))
+			continue
+		}
+
+		if !errors.Is(err, store.ErrAlreadyPruned) {
+			return nil, false, err
+		}
+
+		// data already pruned
+		prunedLogs, err := space.prunedLogs(ctx, dbFilters[i])
+		if err != nil {
+			return nil, false, err
+		}
+
+		logs = logs.append(prunedLogs)
+	}
+
+	// query data from fullnode
+	if fnFilter != nil {
+		// check timeout before fullnode delegation
+		if err := checkTimeout(ctx); err != nil {
+			return nil, false, err
+		}
+
+		// ensure split log filter for fullnode is rational
+		if err := space.checkFullnode(fnFilter); err != nil {
+			return nil, false, err
+		}
+
+		fnLogs, err := space.fullnodeLogs(fnFilter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		logs = logs.append(fnLogs)
+	}
+
+	// ensure result set never oversized
+	if logs.len() > int(store.MaxLogLimit) {
+		return nil, false, store.ErrGetLogsResultSetTooLarge
+	}
+
+	return logs, len(dbFilters) > 0, nil
+}
+
+// explainLogs returns the planned execution of the log filter without execution, including
+// the block ranges split for database and fullnode, estimated cost and chosen indexes.
+//
+// Note, event logs already pruned from database could only be detected during execution.
+func (handler *logsApiHandler) explainLogs(space logsSpace) (*LogsQueryPlan, error) {
+	plan := NewLogsQueryPlan()
+
+	if handler.planner != nil {
+		sfilter, err := space.costFilter()
+		if err != nil {
+			return nil, err
+		}
+
+		if sfilter != nil {
+			if err := plan.explainCost(handler.planner, sfilter); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dbFilters, fnFilter, err := space.split()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := plan.explainDatabase(handler.ms, dbFilters...); err != nil {
+		return nil, err
+	}
+
+	if fnFilter != nil {
+		plan.Fullnode = fnFilter
+
+		if err := space.checkFullnode(fnFilter); err != nil {
+			plan.Reject(err)
+		}
+	}
+
+	return plan, nil
+}
+
+// errLogsPruned returns the error for event logs already pruned from store along with the earliest
+// retained block if log retention enabled, otherwise the specified default error.
+func (handler *logsApiHandler) errLogsPruned(defaultErr error) error {
+	bn, ok, err := handler.ms.EarliestRetainedBlock()
+	if err != nil || !ok {
+		return defaultErr
+	}
+
+	return rpcutil.NewCodedError(
+		rpcutil.ErrCodeDataPruned, errors.Errorf("event logs before block %v are already pruned", bn),
+	)
+}
+
+// logFilterShape returns the shape of log filter by range type, number of contract addresses
+// and specified topic positions, e.g. "block/addr:1/topics:0,2".
+func logFilterShape(rangeType string, numAddresses int, topics []bool) string {
+	var addr string
+	switch {
+	case numAddresses == 0:
+		addr = "0"
+	case numAddresses == 1:
+		addr = "1"
+	default:
+		addr = "n"
+	}
+
+	var positions []string
+	for i, specified := range topics {
+		if specified {
+			positions = append(positions, strconv.Itoa(i))
+		}
+	}
+
+	if len(positions) == 0 {
+		positions = append(positions, "-")
+	}
+
+	return fmt.Sprintf("%v/addr:%v/topics:%v", rangeType, addr, strings.Join(positions, ","))
+}
+
+// checkTimeout checks if operation is timed out.
+func checkTimeout(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return store.ErrGetLogsTimeout
+	default:
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// query to be executed immediately
+	LogsQueryDecisionExecute = "execute"
+	// query to be queued due to heavy cost
+	LogsQueryDecisionQueue = "queue"
+	// query to be rejected
+	LogsQueryDecisionReject = "reject"
+)
+
+// LogsQueryRange block range of event logs query.
+type LogsQueryRange struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+// LogsQueryPlan planned execution of event logs query, which is explained without execution
+// so as to understand why some query is slow or rejected.
+type LogsQueryPlan struct {
+	// block ranges to query from database
+	Database []LogsQueryRange `json:"database"`
+	// log filter delegated to fullnode if any
+	Fullnode interface{} `json:"fullnode,omitempty"`
+	// tables and indexes chosen to query from database
+	Indexes []mysql.LogsQueryIndex `json:"indexes"`
+	// estimated query cost if query cost estimation enabled
+	Cost          *LogsQueryCost `json:"cost,omitempty"`
+	EstimatedCost *uint64        `json:"estimatedCost,omitempty"`
+	// whether to execute, queue or reject the query
+	Decision string `json:"decision"`
+	// reason of rejection if any
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewLogsQueryPlan creates an empty plan to execute immediately.
+func NewLogsQueryPlan() *LogsQueryPlan {
+	return &LogsQueryPlan{
+		Database: []LogsQueryRange{},
+		Indexes:  []mysql.LogsQueryIndex{},
+		Decision: LogsQueryDecisionExecute,
+	}
+}
+
+// Reject marks the query plan as rejected with the specified reason.
+func (plan *LogsQueryPlan) Reject(reason error) *LogsQueryPlan {
+	plan.Decision = LogsQueryDecisionReject
+	plan.Reason = reason.Error()
+
+	return plan
+}
+
+// explainDatabase explains the planned execution of the split log filters for database.
+func (plan *LogsQueryPlan) explainDatabase(ms *mysql.MysqlStore, dbFilters ...store.LogFilter) error {
+	for _, filter := range dbFilters {
+		plan.Database = append(plan.Database, LogsQueryRange{
+			FromBlock: hexutil.Uint64(filter.BlockFrom),
+			ToBlock:   hexutil.Uint64(filter.BlockTo),
+		})
+
+		indexes, err := ms.ExplainLogs(filter)
+		if err != nil {
+			return err
+		}
+
+		plan.Indexes = append(plan.Indexes, indexes...)
+	}
+
+	return nil
+}
+
+// explainCost explains the estimated cost of log filter along with the decision of planner.
+func (plan *LogsQueryPlan) explainCost(planner *LogsQueryPlanner, filter *store.LogFilter) error {
+	cost, decision, err := planner.Explain(filter)
+	if err != nil {
+		return err
+	}
+
+	est := cost.Estimate()
+	plan.Cost, plan.EstimatedCost = &cost, &est
+
+	if decision == LogsQueryDecisionReject {
+		plan.Reject(errors.WithMessagef(
+			errLogsQueryCostTooHigh, "%v exceeds max budget %v", cost, planner.conf.MaxBudget,
+		))
+	} else {
+		plan.Decision = decision
+	}
+
+	return nil
+}
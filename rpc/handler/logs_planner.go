@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// max number of contracts to cache the event log count
+const maxCachedContractLogCounts = 10000
+
+var (
+	errLogsQueryCostTooHigh = rpcutil.NewCodedError(rpcutil.ErrCodeLimitExceeded, errors.New(
+		"estimated query cost is too high, please narrow down your filter condition",
+	))
+	errLogsQueryQueueBusy = rpcutil.NewCodedError(rpcutil.ErrCodeLimitExceeded, errors.New(
+		"too many heavy queries queued, please try again later",
+	))
+)
+
+// logsCostConfig log query cost estimation configurations
+type logsCostConfig struct {
+	// whether to enable query cost estimation
+	Enabled bool
+	// max estimated cost (number of event logs to scan) to execute immediately
+	Budget uint64 `default:"100000"`
+	// max estimated cost to be queued, queries above which will be rejected
+	MaxBudget uint64 `default:"1000000"`
+	// max number of queued heavy queries to execute concurrently
+	QueueConcurrency int `default:"2"`
+	// max duration to wait in queue
+	QueueTimeout time.Duration `default:"1s"`
+	// selectivity of each specified topic value, since there is no topic statistics in store
+	TopicSelectivity float64 `default:"0.1"`
+	// interval to refresh event log statistics (including per contract event log counts) from store
+	StatsRefreshInterval time.Duration `default:"1m"`
+}
+
+// LogsQueryCost estimated cost to query event logs from store.
+type LogsQueryCost struct {
+	BlockSpan          uint64  `json:"blockSpan"`          // number of blocks to query
+	LogsPerBlock       float64 `json:"logsPerBlock"`       // average number of event logs per block
+	AddressSelectivity float64 `json:"addressSelectivity"` // ratio of event logs matched by the contract address(es)
+	TopicSelectivity   float64 `json:"topicSelectivity"`   // ratio of event logs matched by the topics
+}
+
+// Estimate returns the estimated number of event logs to scan.
+func (cost LogsQueryCost) Estimate() uint64 {
+	est := float64(cost.BlockSpan) * cost.LogsPerBlock * cost.AddressSelectivity * cost.TopicSelectivity
+	return uint64(math.Ceil(est))
+}
+
+func (cost LogsQueryCost) String() string {
+	return fmt.Sprintf(
+		"estimated cost %v (block span %v × logs/block %.2f × address selectivity %.4f × topic selectivity %.4f)",
+		cost.Estimate(), cost.BlockSpan, cost.LogsPerBlock, cost.AddressSelectivity, cost.TopicSelectivity,
+	)
+}
+
+// logStatsStore store to query the event log statistics for cost estimation.
+type logStatsStore interface {
+	GetLogStatistics() (mysql.LogStatistics, error)
+	GetContractLogCount(contract string) (uint64, bool, error)
+}
+
+// logStatsSnapshot cached event log statistics
+type logStatsSnapshot struct {
+	stats     mysql.LogStatistics
+	updatedAt time.Time
+}
+
+// LogsQueryPlanner estimates the cost of event log queries with the statistics in store before
+// execution, so as to reject or queue the heavy queries which may overload the database.
+type LogsQueryPlanner struct {
+	conf logsCostConfig
+	ms   logStatsStore
+
+	// semaphore to limit the concurrency of queued heavy queries
+	queue chan struct{}
+
+	// statistics are refreshed from store by only one request at a time, while the others
+	// are served by the cached (even expired) statistics if available
+	refreshGroup   singleflight.Group
+	stats          atomic.Value            // *logStatsSnapshot
+	contractCounts *util.ExpirableLruCache // contract => number of event logs
+}
+
+// newLogsQueryPlannerFromViper creates query planner from viper, or nil if disabled.
+func newLogsQueryPlannerFromViper(ms *mysql.MysqlStore) *LogsQueryPlanner {
+	var conf logsCostConfig
+	viper.MustUnmarshalKey("constraints.logfilter.cost", &conf)
+
+	if !conf.Enabled || ms == nil {
+		return nil
+	}
+
+	return newLogsQueryPlanner(conf, ms)
+}
+
+func newLogsQueryPlanner(conf logsCostConfig, ms logStatsStore) *LogsQueryPlanner {
+	if conf.QueueConcurrency <= 0 {
+		conf.QueueConcurrency = 1
+	}
+
+	return &LogsQueryPlanner{
+		conf:  conf,
+		ms:    ms,
+		queue: make(chan struct{}, conf.QueueConcurrency),
+		contractCounts: util.NewExpirableLruCache(
+			maxCachedContractLogCounts, conf.StatsRefreshInterval,
+		),
+	}
+}
+
+// Estimate estimates the cost of the specified store log filter.
+func (p *LogsQueryPlanner) Estimate(filter *store.LogFilter) (LogsQueryCost, error) {
+	cost := LogsQueryCost{AddressSelectivity: 1, TopicSelectivity: 1}
+
+	if filter.BlockTo >= filter.BlockFrom {
+		cost.BlockSpan = filter.BlockTo - filter.BlockFrom + 1
+	}
+
+	stats, err := p.statistics()
+	if err != nil {
+		return cost, errors.WithMessage(err, "failed to get log statistics")
+	}
+
+	cost.LogsPerBlock = stats.LogsPerBlock()
+
+	if contracts := filter.Contracts.ToSlice(); len(contracts) > 0 && stats.NumLogs > 0 {
+		var numLogs uint64
+		for _, contract := range contracts {
+			count, err := p.contractLogCount(contract)
+			if err != nil {
+				return cost, errors.WithMessage(err, "failed to get contract log count")
+			}
+
+			numLogs += count
+		}
+
+		cost.AddressSelectivity = math.Min(1, float64(numLogs)/float64(stats.NumLogs))
+	}
+
+	for _, topic := range filter.Topics {
+		if n := len(topic.ToSlice()); n > 0 {
+			cost.TopicSelectivity *= math.Min(1, p.conf.TopicSelectivity*float64(n))
+		}
+	}
+
+	return cost, nil
+}
+
+// Admit estimates the cost of the specified log filter and then decides whether to execute
+// it immediately, queue it or reject it. If admitted, the returned release function must
+// be called after the query completed.
+func (p *LogsQueryPlanner) Admit(ctx context.Context, filter *store.LogFilter) (release func(), err error) {
+	noop := func() {}
+
+	cost, err := p.Estimate(filter)
+	if err != nil {
+		// tolerate estimation failure, which should not block the query
+		logrus.WithError(err).Debug("Failed to estimate event logs query cost")
+		return noop, nil
+	}
+
+	switch p.decide(cost) {
+	case LogsQueryDecisionExecute:
+		return noop, nil
+	case LogsQueryDecisionReject:
+		return nil, errors.WithMessagef(errLogsQueryCostTooHigh, "%v exceeds max budget %v", cost, p.conf.MaxBudget)
+	}
+
+	timer := time.NewTimer(p.conf.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.queue <- struct{}{}:
+		return func() { <-p.queue }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, errors.WithMessagef(errLogsQueryQueueBusy, "%v exceeds budget %v", cost, p.conf.Budget)
+	}
+}
+
+// Explain estimates the cost of the specified log filter and decides whether to execute it
+// immediately, queue it or reject it, but without admission.
+func (p *LogsQueryPlanner) Explain(filter *store.LogFilter) (LogsQueryCost, string, error) {
+	cost, err := p.Estimate(filter)
+	if err != nil {
+		return cost, LogsQueryDecisionExecute, err
+	}
+
+	return cost, p.decide(cost), nil
+}
+
+// decide decides whether to execute, queue or reject the query by the estimated cost.
+func (p *LogsQueryPlanner) decide(cost LogsQueryCost) string {
+	est := cost.Estimate()
+
+	switch {
+	case est <= p.conf.Budget:
+		return LogsQueryDecisionExecute
+	case est > p.conf.MaxBudget:
+		return LogsQueryDecisionReject
+	default:
+		return LogsQueryDecisionQueue
+	}
+}
+
+// statistics returns the cached event log statistics, which is refreshed periodically.
+func (p *LogsQueryPlanner) statistics() (mysql.LogStatistics, error) {
+	snapshot, ok := p.stats.Load().(*logStatsSnapshot)
+	if ok && time.Since(snapshot.updatedAt) < p.conf.StatsRefreshInterval {
+		return snapshot.stats, nil
+	}
+
+	ch := p.refreshGroup.DoChan("", func() (interface{}, error) {
+		stats, err := p.ms.GetLogStatistics()
+		if err != nil {
+			logrus.WithError(err).Debug("Failed to refresh event log statistics")
+			return nil, err
+		}
+
+		p.stats.Store(&logStatsSnapshot{stats: stats, updatedAt: time.Now()})
+		return stats, nil
+	})
+
+	if ok { // serve the stale statistics while refreshing
+		return snapshot.stats, nil
+	}
+
+	res := <-ch
+	if res.Err != nil {
+		return mysql.LogStatistics{}, res.Err
+	}
+
+	return res.Val.(mysql.LogStatistics), nil
+}
+
+// contractLogCount returns the cached number of event logs of the specified contract, which
+// is refreshed in the same interval as the event log statistics.
+func (p *LogsQueryPlanner) contractLogCount(contract string) (uint64, error) {
+	cached, ok := p.contractCounts.Get(contract)
+	if ok {
+		return cached.(uint64), nil
+	}
+
+	ch := p.refreshGroup.DoChan("contract:"+contract, func() (interface{}, error) {
+		count, _, err := p.ms.GetContractLogCount(contract)
+		if err != nil {
+			logrus.WithError(err).WithField("contract", contract).Debug(
+				"Failed to refresh contract event log count",
+			)
+			return nil, err
+		}
+
+		p.contractCounts.Add(contract, count)
+		return count, nil
+	})
+
+	if cached != nil { // serve the expired count while refreshing
+		return cached.(uint64), nil
+	}
+
+	res := <-ch
+	if res.Err != nil {
+		return 0, res.Err
+	}
+
+	return res.Val.(uint64), nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsQueryPlannerDecide(t *testing.T) {
+	planner := newLogsQueryPlanner(logsCostConfig{Budget: 100, MaxBudget: 1000}, nil)
+
+	cost := LogsQueryCost{BlockSpan: 10, LogsPerBlock: 10, AddressSelectivity: 1, TopicSelectivity: 1}
+	assert.Equal(t, LogsQueryDecisionExecute, planner.decide(cost))
+
+	cost.BlockSpan = 100
+	assert.Equal(t, LogsQueryDecisionQueue, planner.decide(cost))
+
+	cost.BlockSpan = 101
+	assert.Equal(t, LogsQueryDecisionReject, planner.decide(cost))
+
+	cost.TopicSelectivity = 0.1
+	assert.Equal(t, LogsQueryDecisionQueue, planner.decide(cost))
+}
+
+type mockLogStatsStore struct {
+	statsCalls, countCalls int32
+}
+
+func (s *mockLogStatsStore) GetLogStatistics() (mysql.LogStatistics, error) {
+	atomic.AddInt32(&s.statsCalls, 1)
+	time.Sleep(50 * time.Millisecond)
+	return mysql.LogStatistics{NumLogs: 1000, NumBlocks: 100}, nil
+}
+
+func (s *mockLogStatsStore) GetContractLogCount(contract string) (uint64, bool, error) {
+	atomic.AddInt32(&s.countCalls, 1)
+	return 10, true, nil
+}
+
+func TestLogsQueryPlannerStatistics(t *testing.T) {
+	ms := &mockLogStatsStore{}
+	planner := newLogsQueryPlanner(logsCostConfig{StatsRefreshInterval: time.Minute}, ms)
+
+	// refreshed by only one of the concurrent requests
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			stats, err := planner.statistics()
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1000), stats.NumLogs)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ms.statsCalls))
+
+	// contract log count cached
+	for i := 0; i < 3; i++ {
+		count, err := planner.contractLogCount("0x1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(10), count)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ms.countCalls))
+
+	// expired statistics served while refreshing
+	planner.stats.Store(&logStatsSnapshot{stats: mysql.LogStatistics{NumLogs: 1}})
+
+	stats, err := planner.statistics()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.NumLogs)
+
+	assert.Eventually(t, func() bool {
+		stats, _ := planner.statistics()
+		return stats.NumLogs == 1000
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&ms.statsCalls))
+}
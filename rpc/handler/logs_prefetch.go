@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/sirupsen/logrus"
+)
+
+// logsPrefetchConfig background prefetching configurations for sequential log scanners
+type logsPrefetchConfig struct {
+	// whether to enable background prefetching
+	Enabled bool
+	// min number of consecutive adjacent ranges with the same span to be regarded as sequential scanner
+	MinSequential int `default:"3"`
+	// max number of tracked scanners
+	MaxScanners int `default:"10000"`
+	// max number of prefetched results cached
+	MaxEntries int `default:"100"`
+	// expiration duration of prefetched results
+	TTL time.Duration `default:"30s"`
+	// max number of concurrent prefetching in background
+	MaxConcurrency int `default:"4"`
+}
+
+// logsScanner access pattern of some scanner, which is identified by access key (or IP address)
+// along with the log filter conditions other than block range.
+type logsScanner struct {
+	nextFrom uint64 // expected start of the next range
+	span     uint64 // span of the last range
+	streak   int    // number of consecutive adjacent ranges with the same span
+}
+
+// prefetchedLogs prefetched event logs of the next range for some scanner.
+type prefetchedLogs struct {
+	from, to     uint64
+	reorgVersion int // reorg version of store before prefetched
+	logs         interface{}
+	expireAt     time.Time
+}
+
+// logsPrefetchFunc fetches event logs of the range, along with the reorg version of store
+// before fetched.
+type logsPrefetchFunc func(ctx context.Context, from, to uint64) (logs interface{}, reorgVersion int, err error)
+
+// LogsPrefetcher detects sequential access patterns of log filter (eg., indexer scanning the chain)
+// per scanner, and prefetches event logs of the next adjacent range in background ahead of the
+// next request, so as to smooth the scan throughput.
+type LogsPrefetcher struct {
+	space string
+	conf  logsPrefetchConfig
+
+	mu       sync.Mutex
+	scanners *simplelru.LRU // scanner key => *logsScanner
+	results  *simplelru.LRU // scanner key => *prefetchedLogs
+	inflight map[string]bool
+
+	// semaphore to limit the concurrency of prefetching
+	sem chan struct{}
+}
+
+// newLogsPrefetcherFromViper creates logs prefetcher from viper, or nil if disabled.
+func newLogsPrefetcherFromViper(space string) *LogsPrefetcher {
+	var conf logsPrefetchConfig
+	viper.MustUnmarshalKey("constraints.logfilter.prefetch", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return newLogsPrefetcher(space, conf)
+}
+
+func newLogsPrefetcher(space string, conf logsPrefetchConfig) *LogsPrefetcher {
+	if conf.MaxConcurrency <= 0 {
+		conf.MaxConcurrency = 1
+	}
+
+	scanners, _ := simplelru.NewLRU(conf.MaxScanners, nil)
+	results, _ := simplelru.NewLRU(conf.MaxEntries, nil)
+
+	return &LogsPrefetcher{
+		space:    space,
+		conf:     conf,
+		scanners: scanners,
+		results:  results,
+		inflight: make(map[string]bool),
+		sem:      make(chan struct{}, conf.MaxConcurrency),
+	}
+}
+
+// logsScannerKey returns the scanner key by access key (or IP address if not provided) along
+// with the log filter conditions other than block range.
+func logsScannerKey(ctx context.Context, conditions ...interface{}) string {
+	return fmt.Sprintf("%v/%v", logsAccessKey(ctx), conditions)
+}
+
+// logsAccessKey returns the access key of request, or IP address if access key not provided.
+func logsAccessKey(ctx context.Context) string {
+	accessKey, ok := handlers.GetAccessTokenFromContext(ctx)
+	if !ok || len(accessKey) == 0 {
+		accessKey, _ = handlers.GetIPAddressFromContext(ctx)
+	}
+
+	return accessKey
+}
+
+// take takes away the prefetched event logs of the range for the scanner if any, which must be
+// consistent with the current reorg version of store.
+func (p *LogsPrefetcher) take(key string, from, to uint64, reorgVersion int) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	val, ok := p.results.Peek(key)
+	if !ok {
+		metrics.Registry.RPC.LogsPrefetchHit(p.space).Mark(false)
+		return nil, false
+	}
+
+	prefetched := val.(*prefetchedLogs)
+	if prefetched.from != from || prefetched.to != to {
+		metrics.Registry.RPC.LogsPrefetchHit(p.space).Mark(false)
+		return nil, false
+	}
+
+	p.results.Remove(key)
+
+	hit := prefetched.reorgVersion == reorgVersion && time.Now().Before(prefetched.expireAt)
+	metrics.Registry.RPC.LogsPrefetchHit(p.space).Mark(hit)
+
+	return prefetched.logs, hit
+}
+
+// observe records the range requested by the scanner, and returns the next adjacent range to
+// prefetch if sequential access detected.
+func (p *LogsPrefetcher) observe(key string, from, to uint64) (nextFrom, nextTo uint64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	span := to - from + 1
+
+	scanner := &logsScanner{nextFrom: to + 1, span: span, streak: 1}
+	if val, found := p.scanners.Get(key); found {
+		if last := val.(*logsScanner); last.nextFrom == from && last.span == span {
+			scanner.streak = last.streak + 1
+		}
+	}
+
+	p.scanners.Add(key, scanner)
+
+	if scanner.streak < p.conf.MinSequential {
+		return 0, 0, false
+	}
+
+	return to + 1, to + span, true
+}
+
+// prefetch prefetches event logs of the range for the scanner in background, unless the range
+// already prefetched or prefetching, or too many prefetching in progress.
+func (p *LogsPrefetcher) prefetch(key string, from, to uint64, fetch logsPrefetchFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.inflight[key] {
+		return
+	}
+
+	if val, ok := p.results.Peek(key); ok {
+		if prefetched := val.(*prefetchedLogs); prefetched.from == from && prefetched.to == to {
+			return
+		}
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	default: // never block the request for prefetching
+		metrics.Registry.RPC.LogsPrefetchSkipped(p.space).Mark(1)
+		return
+	}
+
+	p.inflight[key] = true
+
+	go func() {
+		defer func() { <-p.sem }()
+
+		ctx, cancel := rpcutil.WithStoreTimeout(context.Background(), p.space+"_getLogs")
+		defer cancel()
+
+		logs, reorgVersion, err := fetch(ctx, from, to)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.inflight, key)
+
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"space": p.space,
+				"key":   key,
+				"from":  from,
+				"to":    to,
+			}).WithError(err).Debug("Logs prefetcher failed to prefetch the next range")
+			return
+		}
+
+		p.results.Add(key, &prefetchedLogs{
+			from:         from,
+			to:           to,
+			reorgVersion: reorgVersion,
+			logs:         logs,
+			expireAt:     time.Now().Add(p.conf.TTL),
+		})
+	}()
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsPrefetcherObserve(t *testing.T) {
+	prefetcher := newLogsPrefetcher("eth", logsPrefetchConfig{MinSequential: 3, MaxScanners: 10, MaxEntries: 10})
+
+	_, _, ok := prefetcher.observe("k", 1, 100)
+	assert.False(t, ok)
+
+	_, _, ok = prefetcher.observe("k", 101, 200)
+	assert.False(t, ok)
+
+	from, to, ok := prefetcher.observe("k", 201, 300)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(301), from)
+	assert.Equal(t, uint64(400), to)
+
+	// different span breaks the sequential access
+	_, _, ok = prefetcher.observe("k", 301, 350)
+	assert.False(t, ok)
+
+	// scanners are tracked separately
+	_, _, ok = prefetcher.observe("other", 351, 400)
+	assert.False(t, ok)
+}
+
+func TestLogsPrefetcherTake(t *testing.T) {
+	prefetcher := newLogsPrefetcher("eth", logsPrefetchConfig{MaxScanners: 10, MaxEntries: 10, TTL: time.Minute})
+
+	done := make(chan struct{})
+	prefetcher.prefetch("k", 101, 200, func(ctx context.Context, from, to uint64) (interface{}, int, error) {
+		defer close(done)
+		return []int{1, 2, 3}, 5, nil
+	})
+	<-done
+
+	// wait for the prefetched result to be cached
+	assert.Eventually(t, func() bool {
+		prefetcher.mu.Lock()
+		defer prefetcher.mu.Unlock()
+		return prefetcher.results.Len() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// range mismatched
+	_, ok := prefetcher.take("k", 101, 150, 5)
+	assert.False(t, ok)
+
+	logs, ok := prefetcher.take("k", 101, 200, 5)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1, 2, 3}, logs)
+
+	// taken away already
+	_, ok = prefetcher.take("k", 101, 200, 5)
+	assert.False(t, ok)
+}
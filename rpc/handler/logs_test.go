@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockLogs event logs identified by log index only.
+type mockLogs []uint64
+
+func (l mockLogs) len() int { return len(l) }
+
+func (l mockLogs) append(other spaceLogs) spaceLogs {
+	return append(l, other.(mockLogs)...)
+}
+
+// mockLogsSpace delegates all event logs to fullnode, which never requires database.
+type mockLogsSpace struct {
+	fnFilter     interface{}
+	fnLogs       mockLogs
+	fnCheckErr   error
+	fnQueried    bool
+	costFilterFn func() (*store.LogFilter, error)
+}
+
+func (s *mockLogsSpace) scanRange() (uint64, uint64, bool)              { return 0, 0, false }
+func (s *mockLogsSpace) scannerKey(ctx context.Context) string          { return "" }
+func (s *mockLogsSpace) shape() string                                  { return "mock" }
+func (s *mockLogsSpace) withRange(from, to uint64) logsSpace            { return s }
+func (s *mockLogsSpace) newLogs() spaceLogs                             { return mockLogs{} }
+func (s *mockLogsSpace) storeLogs(dbLogs []*store.Log) spaceLogs        { return mockLogs{} }
+func (s *mockLogsSpace) checkFullnode(fnFilter interface{}) error       { return s.fnCheckErr }
+func (s *mockLogsSpace) split() ([]store.LogFilter, interface{}, error) { return nil, s.fnFilter, nil }
+
+func (s *mockLogsSpace) costFilter() (*store.LogFilter, error) {
+	if s.costFilterFn == nil {
+		return nil, nil
+	}
+
+	return s.costFilterFn()
+}
+
+func (s *mockLogsSpace) prunedLogs(ctx context.Context, dbFilter store.LogFilter) (spaceLogs, error) {
+	return mockLogs{}, nil
+}
+
+func (s *mockLogsSpace) fullnodeLogs(fnFilter interface{}) (spaceLogs, error) {
+	s.fnQueried = true
+	return s.fnLogs, nil
+}
+
+func TestLogsGetLogsReorgGuardFullnode(t *testing.T) {
+	handler := &logsApiHandler{}
+	ctx := context.Background()
+
+	space := &mockLogsSpace{fnFilter: "filter", fnLogs: mockLogs{1, 2, 3}}
+	logs, hitStore, err := handler.getLogsReorgGuard(ctx, ctx, space, "")
+	assert.NoError(t, err)
+	assert.False(t, hitStore)
+	assert.Equal(t, mockLogs{1, 2, 3}, logs)
+
+	// nothing to query
+	space = &mockLogsSpace{}
+	logs, _, err = handler.getLogsReorgGuard(ctx, ctx, space, "")
+	assert.NoError(t, err)
+	assert.Zero(t, logs.len())
+	assert.False(t, space.fnQueried)
+}
+
+func TestLogsGetLogsReorgGuardFullnodeRejected(t *testing.T) {
+	handler := &logsApiHandler{}
+	ctx := context.Background()
+
+	// irrational log filter never delegated to fullnode
+	errIrrational := errors.New("block range too large")
+	space := &mockLogsSpace{fnFilter: "filter", fnCheckErr: errIrrational}
+	_, _, err := handler.getLogsReorgGuard(ctx, ctx, space, "")
+	assert.Equal(t, errIrrational, err)
+	assert.False(t, space.fnQueried)
+
+	// timed out before fullnode delegation
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	space = &mockLogsSpace{fnFilter: "filter"}
+	_, _, err = handler.getLogsReorgGuard(cancelledCtx, ctx, space, "")
+	assert.Equal(t, store.ErrGetLogsTimeout, err)
+	assert.False(t, space.fnQueried)
+
+	// result set oversized
+	space = &mockLogsSpace{fnFilter: "filter", fnLogs: make(mockLogs, store.MaxLogLimit+1)}
+	_, _, err = handler.getLogsReorgGuard(ctx, ctx, space, "")
+	assert.Equal(t, store.ErrGetLogsResultSetTooLarge, err)
+}
+
+func TestLogsAdmit(t *testing.T) {
+	ctx := context.Background()
+
+	// query planner not configured
+	handler := &logsApiHandler{}
+	release, err := handler.admit(ctx, &mockLogsSpace{}, "")
+	assert.NoError(t, err)
+	release()
+
+	// cost filter not applicable
+	handler.planner = newLogsQueryPlanner(logsCostConfig{Budget: 100, MaxBudget: 1000}, nil)
+	release, err = handler.admit(ctx, &mockLogsSpace{}, "")
+	assert.NoError(t, err)
+	release()
+
+	// failed to get cost filter
+	errCostFilter := errors.New("failed to resolve block tag")
+	space := &mockLogsSpace{costFilterFn: func() (*store.LogFilter, error) { return nil, errCostFilter }}
+	_, err = handler.admit(ctx, space, "")
+	assert.Equal(t, errCostFilter, err)
+}
+
+func TestLogFilterShape(t *testing.T) {
+	assert.Equal(t, "block/addr:0/topics:-", logFilterShape("block", 0, nil))
+	assert.Equal(t, "epoch/addr:1/topics:0", logFilterShape("epoch", 1, []bool{true, false}))
+	assert.Equal(t, "hash/addr:n/topics:0,2", logFilterShape("hash", 3, []bool{true, false, true, false}))
+}
+
+func TestCheckTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, checkTimeout(ctx))
+
+	cancel()
+	assert.Equal(t, store.ErrGetLogsTimeout, checkTimeout(ctx))
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// storeBounds epoch (or block) range configured to sync into store, out of which data is served by
+// fullnode instead, eg., sync started from an arbitrary epoch or stopped at an end block for fixed
+// range analytic stores.
+type storeBounds struct {
+	From uint64 // first epoch (or block) to sync
+	To   uint64 // last epoch (or block) to sync, 0 if unbounded
+}
+
+// newStoreBoundsFromViper loads the sync bounds of core space (`sync.fromEpoch` and `sync.toEpoch`)
+// or evm space (`sync.eth.fromBlock` and `sync.eth.toBlock`) from viper.
+func newStoreBoundsFromViper(space string) storeBounds {
+	if space == "eth" {
+		var conf struct {
+			FromBlock uint64
+			ToBlock   uint64
+		}
+		viper.MustUnmarshalKey("sync.eth", &conf)
+
+		// genesis block is never synced, which has no event logs anyway
+		if conf.FromBlock <= 1 {
+			conf.FromBlock = 0
+		}
+
+		return storeBounds{From: conf.FromBlock, To: conf.ToBlock}
+	}
+
+	var conf struct {
+		FromEpoch uint64
+		ToEpoch   uint64
+	}
+	viper.MustUnmarshalKey("sync", &conf)
+
+	return storeBounds{From: conf.FromEpoch, To: conf.ToEpoch}
+}
+
+// epochRangeStore store to retrieve the epoch (or block) range of data synced into store, which
+// changes along with sync and pruning.
+type epochRangeStore interface {
+	MinEpoch() (uint64, bool, error)
+	MaxEpoch() (uint64, bool, error)
+}
+
+var _ epochRangeStore = (*mysql.MysqlStore)(nil)
+
+// clamp returns the epoch (or block) range served by store with the specified epoch range of data
+// synced into store, or false if no data served by store at all.
+func (b storeBounds) clamp(minEpoch, maxEpoch uint64) (citypes.RangeUint64, bool) {
+	if minEpoch < b.From {
+		minEpoch = b.From
+	}
+
+	if b.To > 0 && maxEpoch > b.To {
+		maxEpoch = b.To
+	}
+
+	if maxEpoch < minEpoch {
+		return citypes.RangeUint64{}, false
+	}
+
+	return citypes.RangeUint64{From: minEpoch, To: maxEpoch}, true
+}
+
+// storeRange returns the epoch (or block) range served by store, which is derived from the data
+// synced into store (eg., pruned or not synced yet) and clamped by the configured bounds, or false
+// if no data served by store at all.
+func (b storeBounds) storeRange(s epochRangeStore) (citypes.RangeUint64, bool, error) {
+	minEpoch, ok, err := s.MinEpoch()
+	if err != nil || !ok {
+		return citypes.RangeUint64{}, false, err
+	}
+
+	maxEpoch, ok, err := s.MaxEpoch()
+	if err != nil || !ok {
+		return citypes.RangeUint64{}, false, err
+	}
+
+	epochRange, ok := b.clamp(minEpoch, maxEpoch)
+	return epochRange, ok, nil
+}
+
+// storeRangeCacheTTL is the duration to cache the epoch range served by store for block handlers.
+const storeRangeCacheTTL = time.Second
+
+// cachedStoreRange caches the epoch (or block) range served by store for a short while, so that block
+// handlers could check whether the requested epoch is served by store without querying the range on
+// each request. Note, data queried by hash (eg., transactions) is never out of range once found in
+// store, since only data within the range is synced and retained.
+type cachedStoreRange struct {
+	bounds storeBounds
+	store  epochRangeStore
+
+	mu         sync.Mutex
+	epochRange citypes.RangeUint64
+	ok         bool
+	expireAt   time.Time
+}
+
+// newCachedStoreRange creates an instance of cachedStoreRange if the store synced by sync service,
+// otherwise nil, eg., cache store.
+func newCachedStoreRange(space string, s interface{}) *cachedStoreRange {
+	rs, ok := s.(epochRangeStore)
+	if !ok {
+		return nil
+	}
+
+	return &cachedStoreRange{bounds: newStoreBoundsFromViper(space), store: rs}
+}
+
+// contains returns whether the epoch (or block) is served by store, which is always true if no range
+// available, so that store is queried as before.
+func (c *cachedStoreRange) contains(epoch uint64) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := time.Now(); now.After(c.expireAt) {
+		epochRange, ok, err := c.bounds.storeRange(c.store)
+		if err != nil {
+			logrus.WithError(err).Debug("Failed to get epoch range served by store")
+			return true
+		}
+
+		c.epochRange, c.ok, c.expireAt = epochRange, ok, now.Add(storeRangeCacheTTL)
+	}
+
+	return c.ok && epoch >= c.epochRange.From && epoch <= c.epochRange.To
+}
+
+// storeEpochRange returns the epoch (or block) range served by store, or false if no data in store
+// or out of the configured bounds.
+//
+// Note, event logs before the min epoch in store are still queried from store, which reports them
+// pruned or serves them from cold storage tier if configured.
+func (handler *logsApiHandler) storeEpochRange() (citypes.RangeUint64, bool, error) {
+	maxEpoch, ok, err := handler.ms.MaxEpoch()
+	if err != nil || !ok {
+		return citypes.RangeUint64{}, false, err
+	}
+
+	epochRange, ok := handler.bounds.clamp(0, maxEpoch)
+	return epochRange, ok, nil
+}
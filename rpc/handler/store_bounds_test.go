@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreBoundsClamp(t *testing.T) {
+	// unbounded
+	r, ok := storeBounds{}.clamp(0, 100)
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 0, To: 100}, r)
+
+	// started from some epoch
+	r, ok = storeBounds{From: 50}.clamp(0, 100)
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 50, To: 100}, r)
+
+	// range limited
+	r, ok = storeBounds{From: 50, To: 80}.clamp(0, 100)
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 50, To: 80}, r)
+
+	// pruned beyond the configured start
+	r, ok = storeBounds{From: 50, To: 80}.clamp(60, 100)
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 60, To: 80}, r)
+
+	// not synced into bounds yet
+	_, ok = storeBounds{From: 200}.clamp(0, 100)
+	assert.False(t, ok)
+
+	// pruned beyond the configured end
+	_, ok = storeBounds{From: 50, To: 80}.clamp(90, 100)
+	assert.False(t, ok)
+}
+
+type mockEpochRangeStore struct {
+	minEpoch, maxEpoch uint64
+	empty              bool
+	queries            int
+}
+
+func (s *mockEpochRangeStore) MinEpoch() (uint64, bool, error) {
+	s.queries++
+	return s.minEpoch, !s.empty, nil
+}
+
+func (s *mockEpochRangeStore) MaxEpoch() (uint64, bool, error) {
+	return s.maxEpoch, !s.empty, nil
+}
+
+func TestStoreBoundsStoreRange(t *testing.T) {
+	s := &mockEpochRangeStore{minEpoch: 10, maxEpoch: 100}
+
+	r, ok, err := storeBounds{To: 80}.storeRange(s)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 10, To: 80}, r)
+
+	// nothing synced yet
+	s.empty = true
+	_, ok, err = storeBounds{}.storeRange(s)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCachedStoreRangeContains(t *testing.T) {
+	// not a synced store, eg., cache store
+	var unbounded *cachedStoreRange
+	assert.True(t, unbounded.contains(1))
+	assert.Nil(t, newCachedStoreRange("eth", struct{}{}))
+
+	s := &mockEpochRangeStore{minEpoch: 10, maxEpoch: 100}
+	c := &cachedStoreRange{bounds: storeBounds{From: 20}, store: s}
+
+	assert.False(t, c.contains(10)) // before the configured start
+	assert.True(t, c.contains(20))
+	assert.True(t, c.contains(100))
+	assert.False(t, c.contains(101)) // not synced yet
+	assert.Equal(t, 1, s.queries)
+
+	// reloaded once expired
+	s.maxEpoch = 200
+	assert.False(t, c.contains(150))
+
+	c.expireAt = time.Now().Add(-time.Millisecond)
+	assert.True(t, c.contains(150))
+	assert.Equal(t, 2, s.queries)
+}
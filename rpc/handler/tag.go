@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/cache"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// ResolveCfxEpoch resolves core space epoch tag into epoch number by the status of the routed fullnode,
+// which is cached for a short while, so that the tag is mapped consistently across store queries and
+// fullnode calls. Note, epoch number is returned as it is.
+func ResolveCfxEpoch(cfx sdk.ClientOperator, epoch *types.Epoch) (uint64, error) {
+	num, err := cache.CfxDefault.GetEpochNumber(cfx, epoch)
+	if err != nil {
+		return 0, err
+	}
+
+	return num.ToInt().Uint64(), nil
+}
+
+// ResolveEthBlock resolves evm space block tag into block number by the routed fullnode, which is
+// cached for a short while, so that the tag is mapped consistently across store queries and fullnode
+// calls. Note, `pending` block is unresolvable since not executed yet.
+func ResolveEthBlock(w3c *node.Web3goClient, bn web3Types.BlockNumber) (uint64, error) {
+	tag, ok := rpcutil.EthBlockTag(bn)
+	if !ok {
+		return uint64(bn), nil
+	}
+
+	switch tag {
+	case rpcutil.TagLatest:
+		num, err := cache.EthDefault.GetBlockNumber(w3c)
+		if err != nil {
+			return 0, err
+		}
+
+		return num.ToInt().Uint64(), nil
+	case rpcutil.TagSafe:
+		return cache.EthDefault.GetSafeBlockNumber(w3c)
+	case rpcutil.TagFinalized:
+		return cache.EthDefault.GetFinalizedBlockNumber(w3c)
+	default:
+		return 0, errors.Errorf("block tag %v unresolvable", tag)
+	}
+}
+
+// ResolveCfxEpochTag resolves core space epoch tag into epoch number, or returns the epoch as it is
+// if not a resolvable tag, eg., epoch number or hash.
+func ResolveCfxEpochTag(cfx sdk.ClientOperator, epoch *types.Epoch) *types.Epoch {
+	if tag, ok := rpcutil.CfxEpochTag(epoch); !ok || tag == rpcutil.TagEarliest {
+		return epoch
+	}
+
+	num, err := ResolveCfxEpoch(cfx, epoch)
+	if err != nil {
+		return epoch
+	}
+
+	return types.NewEpochNumberUint64(num)
+}
+
+// ResolveEthBlockTag resolves evm space block tag into block number, or returns the block number as
+// it is if not a resolvable tag, eg., `pending`.
+func ResolveEthBlockTag(w3c *node.Web3goClient, bn web3Types.BlockNumber) web3Types.BlockNumber {
+	if _, ok := rpcutil.EthBlockTag(bn); !ok {
+		return bn
+	}
+
+	num, err := ResolveEthBlock(w3c, bn)
+	if err != nil {
+		return bn
+	}
+
+	return web3Types.BlockNumber(num)
+}
+
+// ResolveCfxEpochOrBlockHash resolves core space epoch tag of the epoch or block hash param, or
+// returns the param as it is if not a resolvable tag, eg., block hash.
+func ResolveCfxEpochOrBlockHash(cfx sdk.ClientOperator, epoch *types.EpochOrBlockHash) *types.EpochOrBlockHash {
+	if epoch == nil {
+		return nil
+	}
+
+	e, ok := epoch.IsEpoch()
+	if !ok {
+		return epoch
+	}
+
+	if resolved := ResolveCfxEpochTag(cfx, e); resolved != e {
+		return types.NewEpochOrBlockHashWithEpoch(resolved)
+	}
+
+	return epoch
+}
+
+// ResolveEthBlockNumberOrHash resolves evm space block tag of the block number or hash param, or
+// returns the param as it is if not a resolvable tag, eg., block hash or `pending`.
+func ResolveEthBlockNumberOrHash(
+	w3c *node.Web3goClient, blockNumOrHash *web3Types.BlockNumberOrHash,
+) *web3Types.BlockNumberOrHash {
+	if blockNumOrHash == nil || blockNumOrHash.BlockNumber == nil {
+		return blockNumOrHash
+	}
+
+	bn := *blockNumOrHash.BlockNumber
+	if resolved := ResolveEthBlockTag(w3c, bn); resolved != bn {
+		result := web3Types.BlockNumberOrHashWithNumber(resolved)
+		return &result
+	}
+
+	return blockNumOrHash
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEthBlockNumberOrHashUnresolvable(t *testing.T) {
+	assert.Nil(t, ResolveEthBlockNumberOrHash(nil, nil))
+
+	// block hash
+	byHash := web3Types.BlockNumberOrHashWithHash(common.HexToHash("0x01"), true)
+	assert.Equal(t, &byHash, ResolveEthBlockNumberOrHash(nil, &byHash))
+
+	// block number, `pending` and `earliest` are returned as they are
+	for _, bn := range []web3Types.BlockNumber{
+		100, web3Types.PendingBlockNumber, web3Types.EarliestBlockNumber,
+	} {
+		byNumber := web3Types.BlockNumberOrHashWithNumber(bn)
+		assert.Equal(t, &byNumber, ResolveEthBlockNumberOrHash(nil, &byNumber))
+	}
+}
+
+func TestResolveCfxEpochOrBlockHashUnresolvable(t *testing.T) {
+	assert.Nil(t, ResolveCfxEpochOrBlockHash(nil, nil))
+
+	// block hash
+	byHash := types.NewEpochOrBlockHashWithBlockHash(types.Hash("0x01"))
+	assert.Equal(t, byHash, ResolveCfxEpochOrBlockHash(nil, byHash))
+
+	// epoch number and `earliest` are returned as they are
+	for _, epoch := range []*types.Epoch{types.NewEpochNumberUint64(100), types.EpochEarliest} {
+		byEpoch := types.NewEpochOrBlockHashWithEpoch(epoch)
+		assert.Equal(t, byEpoch, ResolveCfxEpochOrBlockHash(nil, byEpoch))
+	}
+}
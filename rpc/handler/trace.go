@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/Conflux-Chain/confura/rpc/cache"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+)
+
+// trace-family RPC proxy configurations
+type traceConfig struct {
+	// max size (in bytes) of trace response, 0 means unlimited
+	MaxResponseSize int `default:"10485760"`
+}
+
+// TraceCaller calls some trace RPC of fullnode and decodes the raw response into `result`.
+type TraceCaller func(result *json.RawMessage) error
+
+// TraceHandler RPC handler to proxy trace-family RPCs, with response size limited and
+// results of transaction traces optionally cached by transaction hash.
+type TraceHandler struct {
+	maxResponseSize int
+	cache           *cache.TieredCache // could be nil if caching disabled
+}
+
+// MustNewTraceHandlerFromViper creates trace handler from viper settings of the specified key.
+func MustNewTraceHandlerFromViper(space, key string) *TraceHandler {
+	var conf traceConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	h := &TraceHandler{maxResponseSize: conf.MaxResponseSize}
+
+	if c, ok := cache.MustNewTieredCacheFromViper(space+"_trace", key+".cache"); ok {
+		h.cache = c
+	}
+
+	return h
+}
+
+// Call proxies the trace RPC with response size limited. Besides, the result will be cached
+// by the key if not empty, which is only applicable to immutable traces (eg., by tx hash).
+//
+// Note, handler is nil-safe, in which case trace RPC is called without limit or cache.
+func (h *TraceHandler) Call(method, cacheKey string, caller TraceCaller) (json.RawMessage, error) {
+	var result json.RawMessage
+
+	if h == nil {
+		err := caller(&result)
+		return result, err
+	}
+
+	cacheable := h.cache != nil && len(cacheKey) > 0
+	if cacheable && h.cache.Get(method+":"+cacheKey, &result) {
+		return result, nil
+	}
+
+	if err := caller(&result); err != nil {
+		return nil, err
+	}
+
+	metrics.Registry.RPC.TraceResponseSize(method).Update(int64(len(result)))
+
+	if h.maxResponseSize > 0 && len(result) > h.maxResponseSize {
+		err := errors.Errorf(
+			"trace response size %v exceeds limit %v, please narrow down your query", len(result), h.maxResponseSize,
+		)
+		return nil, rpcutil.NewCodedError(rpcutil.ErrCodeLimitExceeded, err)
+	}
+
+	// traces not available yet (eg., tx pending)
+	if cacheable && len(result) > 0 && string(result) != "null" {
+		h.cache.Set(method+":"+cacheKey, result)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/rpc/cache"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceHandlerCall(t *testing.T) {
+	h := &TraceHandler{
+		maxResponseSize: 16,
+		cache: cache.NewTieredCache("test_trace", cache.TieredCacheConfig{
+			HotCapacity: 1024, HotEntries: 10, MaxHotEntrySize: 1024, PromoteHits: 3,
+		}, nil),
+	}
+
+	calls := 0
+	caller := func(resp string) TraceCaller {
+		return func(result *json.RawMessage) error {
+			calls++
+			*result = json.RawMessage(resp)
+			return nil
+		}
+	}
+
+	// cached by key
+	result, err := h.Call("trace_transaction", "0x1", caller(`[{"a":1}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"a":1}]`, string(result))
+
+	result, err = h.Call("trace_transaction", "0x1", caller(`[{"a":2}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"a":1}]`, string(result))
+	assert.Equal(t, 1, calls)
+
+	// null result not cached
+	h.Call("trace_transaction", "0x2", caller(`null`))
+	h.Call("trace_transaction", "0x2", caller(`null`))
+	assert.Equal(t, 3, calls)
+
+	// response size limited
+	_, err = h.Call("trace_filter", "", caller(`[{"a":1},{"b":2},{"c":3}]`))
+	if assert.Error(t, err) {
+		assert.Equal(t, rpcutil.ErrCodeLimitExceeded, err.(*rpcutil.CodedError).ErrorCode())
+	}
+
+	// nil-safe handler
+	var nilHandler *TraceHandler
+	result, err = nilHandler.Call("trace_filter", "", caller(`[{"a":1},{"b":2},{"c":3}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"a":1},{"b":2},{"c":3}]`, string(result))
+}
@@ -3,12 +3,14 @@ package rpc
 import (
 	"math/bits"
 
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/openweb3/web3go"
 	web3Types "github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
 )
@@ -82,7 +84,7 @@ func ParseEthLogFilterType(filter *web3Types.FilterQuery) (LogFilterType, bool)
 }
 
 func NormalizeEthLogFilter(
-	w3c *web3go.Client, flag LogFilterType,
+	w3c *node.Web3goClient, flag LogFilterType,
 	filter *web3Types.FilterQuery, hardforkBlockNum web3Types.BlockNumber,
 ) error {
 	if flag&LogFilterTypeBlockRange == 0 { // not a blockrange log filter
@@ -104,7 +106,10 @@ func NormalizeEthLogFilter(
 
 	var blocks [2]*web3Types.BlockNumber
 	for i, b := range []*web3Types.BlockNumber{filter.FromBlock, filter.ToBlock} {
-		block, err := util.NormalizeEthBlockNumber(w3c, b, hardforkBlockNum)
+		// resolve block tag consistently with other handlers
+		bn := handler.ResolveEthBlockTag(w3c, *b)
+
+		block, err := util.NormalizeEthBlockNumber(w3c.Client, &bn, hardforkBlockNum)
 		if err != nil {
 			return errors.WithMessage(err, "failed to normalize block number")
 		}
@@ -149,6 +154,16 @@ func ValidateEthLogFilter(flag LogFilterType, filter *web3Types.FilterQuery) err
 	return nil
 }
 
+// EthLogFilterSpan returns the block span of the normalized log filter, or 0 for block hash
+// log filter.
+func EthLogFilterSpan(flag LogFilterType, filter *web3Types.FilterQuery) uint64 {
+	if flag&LogFilterTypeBlockRange == 0 || filter.FromBlock == nil || filter.ToBlock == nil {
+		return 0
+	}
+
+	return uint64(*filter.ToBlock-*filter.FromBlock) + 1
+}
+
 func NormalizeLogFilter(cfx sdk.ClientOperator, flag LogFilterType, filter *types.LogFilter) error {
 	// set default epoch range if not set and convert to numbered epoch if necessary
 	if flag&LogFilterTypeEpochRange != 0 {
@@ -164,12 +179,18 @@ func NormalizeLogFilter(cfx sdk.ClientOperator, flag LogFilterType, filter *type
 
 		var epochs [2]*types.Epoch
 		for i, e := range []*types.Epoch{filter.FromEpoch, filter.ToEpoch} {
-			epoch, err := util.ConvertToNumberedEpoch(cfx, e)
+			if _, ok := e.ToInt(); ok { // already a numbered epoch
+				epochs[i] = e
+				continue
+			}
+
+			// resolve epoch tag consistently with other handlers
+			epoch, err := handler.ResolveCfxEpoch(cfx, e)
 			if err != nil {
 				return errors.WithMessagef(err, "failed to convert numbered epoch for %v", e)
 			}
 
-			epochs[i] = epoch
+			epochs[i] = types.NewEpochNumberUint64(epoch)
 		}
 
 		filter.FromEpoch, filter.ToEpoch = epochs[0], epochs[1]
@@ -231,6 +252,60 @@ func ValidateLogFilter(flag LogFilterType, filter *types.LogFilter) error {
 	return nil
 }
 
+// LogFilterSpan returns the block span (epoch span for epoch range) of the normalized log filter,
+// or 0 for block hashes log filter.
+func LogFilterSpan(flag LogFilterType, filter *types.LogFilter) uint64 {
+	switch {
+	case flag&LogFilterTypeBlockRange != 0:
+		fromBlock := filter.FromBlock.ToInt().Uint64()
+		toBlock := filter.ToBlock.ToInt().Uint64()
+
+		return toBlock - fromBlock + 1
+	case flag&LogFilterTypeEpochRange != 0:
+		epochFrom, _ := filter.FromEpoch.ToInt()
+		epochTo, _ := filter.ToEpoch.ToInt()
+
+		return epochTo.Uint64() - epochFrom.Uint64() + 1
+	}
+
+	return 0
+}
+
+// logFilterStart returns the start block (start epoch for epoch range) of the normalized log
+// filter, or 0 for block hashes log filter.
+func logFilterStart(flag LogFilterType, filter *types.LogFilter) uint64 {
+	switch {
+	case flag&LogFilterTypeBlockRange != 0:
+		return filter.FromBlock.ToInt().Uint64()
+	case flag&LogFilterTypeEpochRange != 0:
+		epochFrom, _ := filter.FromEpoch.ToInt()
+		return epochFrom.Uint64()
+	}
+
+	return 0
+}
+
+// hintLogsTooLarge hints the client to paginate the block range (epoch range for core space)
+// by half if the query set or result set of log filter is too large, otherwise returns the
+// error as it is.
+func hintLogsTooLarge(err error, start, span uint64) error {
+	if !errors.Is(err, store.ErrGetLogsResultSetTooLarge) && !errors.Is(err, store.ErrGetLogsQuerySetTooLarge) {
+		return err
+	}
+
+	if span <= 1 { // block hashes log filter or could not be narrowed down any more
+		return err
+	}
+
+	pageSize := span / 2
+	hints := &rpcutil.ErrorHints{
+		MaxBlockRange: &pageSize,
+		Pagination:    &rpcutil.PaginationHint{FromBlock: start, ToBlock: start + pageSize - 1},
+	}
+
+	return rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, err, hints).JsonError()
+}
+
 // dedupLogFilter deduplicate log filter such as block hashes, contract addresses and topics.
 func dedupLogFilter(filter *types.LogFilter) {
 	// dedup block hashes
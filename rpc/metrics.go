@@ -1,8 +1,11 @@
 package rpc
 
 import (
+	"context"
 	"strings"
 
+	"github.com/Conflux-Chain/confura/util/audit"
+	infuraMetrics "github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/ethereum/go-ethereum/metrics"
 )
 
@@ -24,3 +27,10 @@ func init() {
 		metrics.DefaultRegistry.Unregister(v)
 	}
 }
+
+// collectStoreHitStats collects the store hit ratio of RPC method, and marks the RPC call as store
+// hit for audit logging.
+func collectStoreHitStats(ctx context.Context, method string, hit bool) {
+	infuraMetrics.Registry.RPC.StoreHit(method, "store").Mark(hit)
+	audit.MarkStoreHit(ctx, hit)
+}
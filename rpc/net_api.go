@@ -7,10 +7,16 @@ import (
 )
 
 // netAPI provides evm space net RPC proxy API.
-type netAPI struct{}
+type netAPI struct {
+	local *EthLocalAnswerer // answers locally if not nil
+}
 
 // Version returns the current network id.
 func (api *netAPI) Version(ctx context.Context) (string, error) {
+	if api.local != nil {
+		return api.local.NetVersion(), nil
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	return cache.EthDefault.GetNetVersion(w3c.Client)
 }
@@ -3,13 +3,17 @@ package rpc
 import (
 	"context"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -26,6 +30,11 @@ const (
 	// default pubsub channel buffer size
 	pubsubChannelBufferSize = 2000
 
+	// pre-defined policies once subscription buffer overflowed
+	pubsubBufferPolicyClose      = "close"      // close the connection of slow consumer
+	pubsubBufferPolicyDropNewest = "dropNewest" // drop the incoming event
+	pubsubBufferPolicyDropOldest = "dropOldest" // drop the oldest buffered event
+
 	// pre-defined delegate context name
 	nhCtxName      = "new_heads"           // for newHeads subscription
 	lmEpochCtxName = "latest_mined_epochs" // for latest minted epoch subscription
@@ -41,10 +50,42 @@ var (
 
 	// delegateClients cache store delegate clients
 	delegateClients util.ConcurrentMap // node name => *delegateClient
+
+	pubsubBufferCfg PubSubBufferConfig
 )
 
+// PubSubBufferConfig bounded buffer configurations per subscription, which protect the upstream
+// reader from slow consumers.
+type PubSubBufferConfig struct {
+	// max number of events buffered per subscription
+	Size int `default:"2000"`
+	// policy once buffer overflowed, `close`, `dropNewest` or `dropOldest`
+	Policy string `default:"close"`
+	// whether to notify subscriber of the number of dropped events
+	NotifyMissed bool
+}
+
+// missedEventsNotification notification of the number of events dropped due to buffer overflow
+// since the last notification.
+type missedEventsNotification struct {
+	MissedEvents uint64 `json:"missedEvents"`
+}
+
+func init() {
+	viper.MustUnmarshalKey("rpc.pubsubBuffer", &pubsubBufferCfg)
+}
+
 type delegateSubFilter func(item interface{}) bool // result filter for delegate subscription
 
+// delegateSubGroup group of delegate subscriptions with the same filter pattern, by which result
+// is matched only once per group at ingestion time and then delivered to all the subscriptions
+// directly, rather than re-matched per subscriber.
+type delegateSubGroup struct {
+	id      string                           // group ID, eg., canonical log filter
+	matcher delegateSubFilter                // returns true if result matched
+	subs    map[rpc.ID]*delegateSubscription // client subscription ID => *delegateSubscription
+}
+
 // delegateSubscription is a subscription established through the delegateClient's `Subscribe` methods.
 type delegateSubscription struct {
 	dCtx     *delegateContext
@@ -55,6 +96,8 @@ type delegateSubscription struct {
 	quit     chan struct{}       // quit is closed when the subscription exits
 	err      chan error          // channel to send/receive delegate error
 	filters  []delegateSubFilter // blacklist filter chain
+	group    *delegateSubGroup   // subscription group if any
+	missed   uint64              // number of events dropped since last notification
 }
 
 func newDelegateSubscription(
@@ -99,13 +142,46 @@ func (sub *delegateSubscription) deliver(result interface{}) bool {
 	case 1: // sub.channel<-
 		return true
 	case 2: // never blocking for subscription queue overflow
-		sub.err <- rpc.ErrSubscriptionQueueOverflow
-		return false
+		return sub.overflow(result)
 	}
 
 	return false
 }
 
+// overflow handles the result once subscription buffer is full according to the buffer policy.
+func (sub *delegateSubscription) overflow(result interface{}) bool {
+	switch pubsubBufferCfg.Policy {
+	case pubsubBufferPolicyDropNewest:
+		atomic.AddUint64(&sub.missed, 1)
+		metrics.Registry.PubSub.BufferOverflow(pubsubBufferCfg.Policy).Mark(1)
+		return false
+	case pubsubBufferPolicyDropOldest:
+		if sub.channel.Type().ChanDir()&reflect.RecvDir != 0 {
+			sub.channel.TryRecv()
+		}
+
+		atomic.AddUint64(&sub.missed, 1)
+		metrics.Registry.PubSub.BufferOverflow(pubsubBufferCfg.Policy).Mark(1)
+		return sub.channel.TrySend(reflect.ValueOf(result))
+	default:
+		select { // already closing
+		case sub.err <- rpc.ErrSubscriptionQueueOverflow:
+			metrics.Registry.PubSub.BufferOverflow(pubsubBufferCfg.Policy).Mark(1)
+		default:
+		}
+
+		return false
+	}
+}
+
+// notifyMissed notifies the subscriber of the number of events dropped due to buffer overflow since
+// the last notification, if any and enabled.
+func (sub *delegateSubscription) notifyMissed(notifier *rpc.Notifier, id rpc.ID) {
+	if missed := atomic.SwapUint64(&sub.missed, 0); missed > 0 && pubsubBufferCfg.NotifyMissed {
+		notifier.Notify(id, &missedEventsNotification{MissedEvents: missed})
+	}
+}
+
 // unsubscribe the notification and closes the error channel.
 // It can safely be called more than once.
 func (sub *delegateSubscription) unsubscribe() {
@@ -123,7 +199,8 @@ type delegateContext struct {
 	oncer        sync.Once
 	lock         sync.RWMutex
 	status       delegateStatus
-	delegateSubs util.ConcurrentMap // client subscription ID => *delegateSubscription
+	delegateSubs util.ConcurrentMap           // client subscription ID => *delegateSubscription
+	subGroups    map[string]*delegateSubGroup // group ID => *delegateSubGroup
 
 	epoch *types.Epoch // epochs subscription type
 }
@@ -132,7 +209,10 @@ type delegateContext struct {
 type delegateCtxOption func(ctx *delegateContext)
 
 func newDelegateContext(options ...delegateCtxOption) *delegateContext {
-	ctx := &delegateContext{status: delegateStatusInit}
+	ctx := &delegateContext{
+		status:    delegateStatusInit,
+		subGroups: make(map[string]*delegateSubGroup),
+	}
 	for i := 0; i < len(options); i++ {
 		options[i](ctx)
 	}
@@ -161,8 +241,52 @@ func (dctx *delegateContext) registerDelegateSub(
 	dctx.lock.Lock()
 	defer dctx.lock.Unlock()
 
+	if err := dctx.startRunLoopOnce(pubsubRunLoop); err != nil {
+		return nil, err
+	}
+
+	delegateSub := newDelegateSubscription(dctx, subId, channel, filters...)
+	dctx.delegateSubs.Store(subId, delegateSub)
+
+	return delegateSub, nil
+}
+
+// registerGroupedDelegateSub registers delegate subscription into the subscription group
+// of the specified group ID, which will be created with the matcher if not existed.
+func (dctx *delegateContext) registerGroupedDelegateSub(
+	pubsubRunLoop func(dctx *delegateContext) error,
+	subId rpc.ID, channel interface{}, groupId string, matcher delegateSubFilter,
+) (*delegateSubscription, error) {
+	dctx.lock.Lock()
+	defer dctx.lock.Unlock()
+
+	if err := dctx.startRunLoopOnce(pubsubRunLoop); err != nil {
+		return nil, err
+	}
+
+	group, ok := dctx.subGroups[groupId]
+	if !ok {
+		group = &delegateSubGroup{
+			id:      groupId,
+			matcher: matcher,
+			subs:    make(map[rpc.ID]*delegateSubscription),
+		}
+		dctx.subGroups[groupId] = group
+	}
+
+	delegateSub := newDelegateSubscription(dctx, subId, channel)
+	delegateSub.group = group
+
+	group.subs[subId] = delegateSub
+	dctx.delegateSubs.Store(subId, delegateSub)
+
+	return delegateSub, nil
+}
+
+// startRunLoopOnce starts delegate pubsub run loop once, must be called with lock held.
+func (dctx *delegateContext) startRunLoopOnce(pubsubRunLoop func(dctx *delegateContext) error) error {
 	var err error
-	if pubsubRunLoop != nil { // start delegate pubsub run loop once
+	if pubsubRunLoop != nil {
 		dctx.oncer.Do(func() {
 			err = pubsubRunLoop(dctx)
 		})
@@ -170,24 +294,30 @@ func (dctx *delegateContext) registerDelegateSub(
 
 	if err != nil {
 		dctx.oncer = sync.Once{}
-		return nil, err
 	}
 
-	delegateSub := newDelegateSubscription(dctx, subId, channel, filters...)
-	dctx.delegateSubs.Store(subId, delegateSub)
-
-	return delegateSub, nil
+	return err
 }
 
 func (dctx *delegateContext) deregisterDelegateSub(subId rpc.ID) *delegateSubscription {
 	dctx.lock.Lock()
 	defer dctx.lock.Unlock()
 
-	if dsub, loaded := dctx.delegateSubs.LoadAndDelete(subId); loaded {
-		return dsub.(*delegateSubscription)
+	v, loaded := dctx.delegateSubs.LoadAndDelete(subId)
+	if !loaded {
+		return nil
 	}
 
-	return nil
+	dsub := v.(*delegateSubscription)
+	if group := dsub.group; group != nil { // leave the subscription group
+		delete(group.subs, subId)
+
+		if len(group.subs) == 0 {
+			delete(dctx.subGroups, group.id)
+		}
+	}
+
+	return dsub
 }
 
 // cancel all delegated subscriptions
@@ -207,6 +337,7 @@ func (dctx *delegateContext) cancel(err error) {
 		return true
 	})
 
+	dctx.subGroups = make(map[string]*delegateSubGroup)
 	dctx.oncer = sync.Once{}
 }
 
@@ -216,11 +347,30 @@ func (dctx *delegateContext) notify(result interface{}) {
 	defer dctx.lock.RUnlock()
 
 	dctx.delegateSubs.Range(func(key, value interface{}) bool {
-		dsub := value.(*delegateSubscription)
-		dsub.deliver(result)
+		if dsub := value.(*delegateSubscription); dsub.group == nil { // grouped ones delivered below
+			dsub.deliver(result)
+		}
 
 		return true
 	})
+
+	// deliver to all the subscriptions of the matched groups directly
+	for _, group := range dctx.matchSubGroups(result) {
+		for _, dsub := range group.subs {
+			dsub.deliver(result)
+		}
+	}
+}
+
+// matchSubGroups tags the result with subscription groups, whose filter pattern is matched.
+func (dctx *delegateContext) matchSubGroups(result interface{}) (matched []*delegateSubGroup) {
+	for _, group := range dctx.subGroups {
+		if group.matcher(result) {
+			matched = append(matched, group)
+		}
+	}
+
+	return matched
 }
 
 // delegateClient client delegated for pubsub subscription
@@ -352,9 +502,10 @@ func (client *delegateClient) delegateSubscribeLogs(
 		return nil, errDelegateNotReady
 	}
 
-	return dCtx.registerDelegateSub(client.proxySubscribeLogs, subId, channel, func(item interface{}) bool {
+	groupId := logFilterGroupId(filter)
+	return dCtx.registerGroupedDelegateSub(client.proxySubscribeLogs, subId, channel, groupId, func(item interface{}) bool {
 		log, ok := item.(*types.SubscriptionLog)
-		return !ok || !matchPubSubLogFilter(log, &filter)
+		return ok && matchPubSubLogFilter(log, &filter)
 	})
 }
 
@@ -401,6 +552,53 @@ func matchPubSubLogFilter(log *types.SubscriptionLog, filter *types.LogFilter) b
 	return util.IncludeCfxLogAddrs(log.Log, filter.Address) && util.MatchCfxLogTopics(log.Log, filter.Topics)
 }
 
+// logFilterGroupId returns the canonical log filter as subscription group ID, so that subscriptions
+// with the same filter pattern (regardless of the order of addresses or topics) share the group.
+func logFilterGroupId(filter types.LogFilter) string {
+	addrs := make([]string, 0, len(filter.Address))
+	for i := range filter.Address {
+		addrs = append(addrs, filter.Address[i].String())
+	}
+
+	topics := make([][]string, 0, len(filter.Topics))
+	for i := range filter.Topics {
+		topic := make([]string, 0, len(filter.Topics[i]))
+		for j := range filter.Topics[i] {
+			topic = append(topic, filter.Topics[i][j].String())
+		}
+
+		topics = append(topics, topic)
+	}
+
+	return canonicalLogFilter(addrs, topics)
+}
+
+func canonicalLogFilter(addrs []string, topics [][]string) string {
+	var sb strings.Builder
+	sb.WriteString(joinSortedLower(addrs))
+
+	// trailing wildcard topics make no difference
+	for len(topics) > 0 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+
+	for _, topic := range topics {
+		sb.WriteString("|")
+		sb.WriteString(joinSortedLower(topic))
+	}
+
+	return sb.String()
+}
+
+func joinSortedLower(strs []string) string {
+	for i := range strs {
+		strs[i] = strings.ToLower(strs[i])
+	}
+
+	sort.Strings(strs)
+	return strings.Join(strs, ",")
+}
+
 func isEmptyLogFilter(filter types.LogFilter) bool {
 	if len(filter.Address) > 0 {
 		return false
@@ -1,13 +1,18 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
+	"math/big"
 	"testing"
 
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,6 +44,90 @@ func TestDelegateContextRegister(t *testing.T) {
 	assert.Equal(t, 0, numDelSubs)
 }
 
+func TestDelegateContextGroupedNotify(t *testing.T) {
+	dctx := newDelegateContext()
+
+	matches := 0
+	evenMatcher := func(item interface{}) bool {
+		matches++
+		return item.(int)%2 == 0
+	}
+
+	ch1, ch2, ch3 := make(chan interface{}, 1), make(chan interface{}, 1), make(chan interface{}, 1)
+	id1, id2, id3 := rpc.NewID(), rpc.NewID(), rpc.NewID()
+
+	dctx.registerGroupedDelegateSub(nil, id1, ch1, "even", evenMatcher)
+	dctx.registerGroupedDelegateSub(nil, id2, ch2, "even", evenMatcher)
+	dctx.registerDelegateSub(nil, id3, ch3)
+	assert.Equal(t, 1, len(dctx.subGroups))
+
+	// matched only once for the group
+	dctx.notify(2)
+	assert.Equal(t, 1, matches)
+	assert.Equal(t, 2, <-ch1)
+	assert.Equal(t, 2, <-ch2)
+	assert.Equal(t, 2, <-ch3)
+
+	dctx.notify(3)
+	assert.Equal(t, 0, len(ch1))
+	assert.Equal(t, 3, <-ch3)
+
+	// group removed once all subscriptions left
+	dctx.deregisterDelegateSub(id1)
+	assert.Equal(t, 1, len(dctx.subGroups))
+	dctx.deregisterDelegateSub(id2)
+	assert.Equal(t, 0, len(dctx.subGroups))
+}
+
+func TestDelegateSubscriptionOverflow(t *testing.T) {
+	defer func(conf PubSubBufferConfig) { pubsubBufferCfg = conf }(pubsubBufferCfg)
+
+	// drop the newest
+	pubsubBufferCfg.Policy = pubsubBufferPolicyDropNewest
+
+	ch := make(chan int, 2)
+	dsub := newDelegateSubscription(newDelegateContext(), rpc.NewID(), ch)
+
+	for i := 1; i <= 3; i++ {
+		dsub.deliver(i)
+	}
+
+	assert.Equal(t, []int{1, 2}, []int{<-ch, <-ch})
+	assert.Equal(t, uint64(1), dsub.missed)
+
+	// drop the oldest
+	pubsubBufferCfg.Policy = pubsubBufferPolicyDropOldest
+
+	dsub = newDelegateSubscription(newDelegateContext(), rpc.NewID(), ch)
+
+	for i := 1; i <= 3; i++ {
+		assert.True(t, dsub.deliver(i))
+	}
+
+	assert.Equal(t, []int{2, 3}, []int{<-ch, <-ch})
+	assert.Equal(t, uint64(1), dsub.missed)
+
+	// close once overflowed, without blocking for the subsequent ones
+	pubsubBufferCfg.Policy = pubsubBufferPolicyClose
+
+	dsub = newDelegateSubscription(newDelegateContext(), rpc.NewID(), ch)
+
+	for i := 1; i <= 4; i++ {
+		dsub.deliver(i)
+	}
+
+	assert.Equal(t, rpc.ErrSubscriptionQueueOverflow, <-dsub.err)
+}
+
+func TestCanonicalLogFilter(t *testing.T) {
+	id1 := canonicalLogFilter([]string{"0xB", "0xa"}, [][]string{{"0x2", "0x1"}, {}})
+	id2 := canonicalLogFilter([]string{"0xA", "0xb"}, [][]string{{"0x1", "0x2"}})
+	assert.Equal(t, id1, id2)
+
+	id3 := canonicalLogFilter([]string{"0xa", "0xb"}, [][]string{{}, {"0x1", "0x2"}})
+	assert.NotEqual(t, id1, id3)
+}
+
 func TestMatchLogFilterAddr(t *testing.T) {
 	var (
 		logFilter1 = &types.LogFilter{
@@ -256,3 +345,219 @@ func TestMatchPubSubLogFilter(t *testing.T) {
 		assert.Equal(t, tc.expectMatch, matchPubSubLogFilter(&log, tc.logFilter))
 	}
 }
+
+func TestEthLogsBackfillHandoff(t *testing.T) {
+	newLog := func(bn uint64, hash string, index uint) *web3Types.Log {
+		return &web3Types.Log{BlockNumber: bn, BlockHash: common.HexToHash(hash), Index: index}
+	}
+
+	var notified []web3Types.Log
+
+	fromBlock := web3Types.BlockNumber(1)
+	conf := &EthLogsBackfillConfig{BatchBlocks: 10, ReorgWindow: 3}
+	backfill := newEthLogsBackfill(conf, web3Types.FilterQuery{FromBlock: &fromBlock}, 10, func(log *web3Types.Log) {
+		notified = append(notified, *log)
+	})
+
+	// live logs buffered during backfill
+	assert.True(t, backfill.onLive(newLog(10, "0xa", 1)))
+	assert.True(t, backfill.onLive(newLog(11, "0xb", 0)))
+	assert.Empty(t, notified)
+
+	backfill.onBackfilled([]web3Types.Log{*newLog(2, "0x2", 0), *newLog(9, "0x9", 0), *newLog(10, "0xa", 1)})
+	backfill.onCompleted()
+
+	// duplicate live log of block 10 skipped
+	assert.Equal(t, []uint64{2, 9, 10, 11}, blockNumbers(notified))
+
+	// block 9 reorged, backfilled logs of block 9 and 10 reverted in reverse order
+	notified = nil
+	backfill.onLive(newLog(9, "0x99", 0))
+	assert.Equal(t, []uint64{10, 9, 9}, blockNumbers(notified))
+	assert.True(t, notified[0].Removed && notified[1].Removed)
+	assert.False(t, notified[2].Removed)
+
+	// removed logs of blocks already reverted or out of reorg window are skipped
+	notified = nil
+	backfill.onLive(&web3Types.Log{BlockNumber: 10, BlockHash: common.HexToHash("0xa"), Removed: true})
+	backfill.onLive(&web3Types.Log{BlockNumber: 2, BlockHash: common.HexToHash("0x2"), Removed: true})
+	assert.Empty(t, notified)
+
+	// removed logs of live block reorged in are notified
+	backfill.onLive(&web3Types.Log{BlockNumber: 9, BlockHash: common.HexToHash("0x99"), Removed: true})
+	assert.Equal(t, []uint64{9}, blockNumbers(notified))
+	assert.True(t, notified[0].Removed)
+}
+
+func blockNumbers(logs []web3Types.Log) (bns []uint64) {
+	for _, log := range logs {
+		bns = append(bns, log.BlockNumber)
+	}
+
+	return bns
+}
+
+func TestEthBlockHeader(t *testing.T) {
+	block := &web3Types.Block{
+		Difficulty:   big.NewInt(0),
+		Hash:         common.HexToHash("0x01"),
+		Number:       big.NewInt(100),
+		ParentHash:   common.HexToHash("0x02"),
+		Timestamp:    1000,
+		Transactions: *web3Types.NewTxOrHashList(false),
+	}
+
+	// mix hash and nonce not available in store
+	header, err := ethBlockHeader(block)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), header.Number.Uint64())
+	assert.Equal(t, block.Hash, *header.HeaderExtra.Hash)
+	assert.Equal(t, block.ParentHash, header.ParentHash)
+	assert.Equal(t, block.Timestamp, header.Time)
+}
+
+func TestFailoverPubSubOnLocalError(t *testing.T) {
+	api := &ethAPI{EthAPIOption: EthAPIOption{PubSubFailover: &EthPubSubFailoverConfig{Enabled: true}}}
+
+	// never fail over if subscriber consumes too slow
+	subscribed := false
+	_, _, err := api.failoverPubSub(nil, logsCtxName, nil, rpc.ErrSubscriptionQueueOverflow,
+		func(dClient *ethDelegateClient) (*delegateSubscription, error) {
+			subscribed = true
+			return nil, nil
+		}, nil,
+	)
+	assert.Equal(t, rpc.ErrSubscriptionQueueOverflow, err)
+	assert.False(t, subscribed)
+
+	assert.True(t, isLocalPubSubError(errors.WithMessage(rpc.ErrSubscriptionQueueOverflow, "closed")))
+	assert.False(t, isLocalPubSubError(rpc.ErrClientQuit))
+	assert.False(t, isLocalPubSubError(errors.New("websocket: close 1006")))
+}
+
+func TestCheckReplayRange(t *testing.T) {
+	toBlock, err := checkReplayRange(101, 200, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), toBlock)
+
+	_, err = checkReplayRange(100, 200, 100)
+	assert.Equal(t, errPubSubReplayTooLarge, err)
+
+	// fullnode failed over to lags behind
+	toBlock, err = checkReplayRange(201, 200, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), toBlock)
+}
+
+// mockBackfillChain canonical chain with one event log per block, which could be reorged from
+// some block by hook once event logs queried.
+type mockBackfillChain struct {
+	forks     map[uint64]int64 // block number => fork of canonical block
+	onGetLogs func(from uint64)
+}
+
+func newMockBackfillChain() *mockBackfillChain {
+	return &mockBackfillChain{forks: make(map[uint64]int64)}
+}
+
+func (c *mockBackfillChain) hash(bn uint64) common.Hash {
+	return common.BigToHash(big.NewInt(int64(bn)*100 + c.forks[bn]))
+}
+
+func (c *mockBackfillChain) reorg(fromBlock uint64) {
+	for bn := fromBlock; bn <= 100; bn++ {
+		c.forks[bn]++
+	}
+}
+
+func (c *mockBackfillChain) getLogs(ctx context.Context, filter *web3Types.FilterQuery) (logs []web3Types.Log, err error) {
+	from, to := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	for bn := from; bn <= to; bn++ {
+		logs = append(logs, web3Types.Log{BlockNumber: bn, BlockHash: c.hash(bn)})
+	}
+
+	if c.onGetLogs != nil {
+		c.onGetLogs(from)
+	}
+
+	return logs, nil
+}
+
+func (c *mockBackfillChain) getBlock(bn uint64) (common.Hash, common.Hash, error) {
+	return c.hash(bn), c.hash(bn - 1), nil
+}
+
+func runEthLogsBackfill(chain *mockBackfillChain, fromBlock, handoffBlock uint64) ([]web3Types.Log, error) {
+	from := web3Types.BlockNumber(fromBlock)
+	conf := &EthLogsBackfillConfig{BatchBlocks: 3, ReorgWindow: 3}
+	backfill := newEthLogsBackfill(conf, web3Types.FilterQuery{FromBlock: &from}, handoffBlock, nil)
+
+	batchCh := make(chan []web3Types.Log, 100)
+	err := backfill.run(context.Background(), chain, batchCh)
+	close(batchCh)
+
+	var logs []web3Types.Log
+	for batch := range batchCh {
+		logs = append(logs, batch...)
+	}
+
+	return logs, err
+}
+
+func TestEthLogsBackfillRun(t *testing.T) {
+	chain := newMockBackfillChain()
+
+	logs, err := runEthLogsBackfill(chain, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbers(logs))
+}
+
+func TestEthLogsBackfillReorgHeldBatch(t *testing.T) {
+	chain := newMockBackfillChain()
+
+	// chain reorged from block 5 after batch [4, 6] queried and held
+	chain.onGetLogs = func(from uint64) {
+		if from == 7 && chain.forks[5] == 0 {
+			chain.reorg(5)
+		}
+	}
+
+	logs, err := runEthLogsBackfill(chain, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbers(logs))
+
+	// orphaned event logs never sent
+	for _, log := range logs {
+		assert.Equal(t, chain.hash(log.BlockNumber), log.BlockHash, "block %v", log.BlockNumber)
+	}
+}
+
+func TestEthLogsBackfillReorgDuringQuery(t *testing.T) {
+	chain := newMockBackfillChain()
+
+	// event logs of block 6 queried before reorged
+	chain.onGetLogs = func(from uint64) {
+		if from == 4 && chain.forks[6] == 0 {
+			chain.reorg(6)
+		}
+	}
+
+	logs, err := runEthLogsBackfill(chain, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbers(logs))
+	assert.Equal(t, chain.hash(6), logs[5].BlockHash)
+}
+
+func TestEthLogsBackfillReorgSentBatch(t *testing.T) {
+	chain := newMockBackfillChain()
+
+	// chain reorged from block 2, whose event logs already sent
+	chain.onGetLogs = func(from uint64) {
+		if from == 10 && chain.forks[2] == 0 {
+			chain.reorg(2)
+		}
+	}
+
+	_, err := runEthLogsBackfill(chain, 1, 10)
+	assert.True(t, errors.Is(err, errLogsBackfillReorged))
+}
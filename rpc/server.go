@@ -1,10 +1,17 @@
 package rpc
 
 import (
+	"net"
+	"net/http"
+
 	infuraNode "github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/cache"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util/rate"
 	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,6 +22,7 @@ const (
 	nativeSpaceBridgeRpcServerName = "core_space_bridge_rpc"
 
 	debugRpcServerName = "debug_rpc"
+	adminRpcServerName = "admin_rpc"
 )
 
 // MustNewNativeSpaceServer new core space RPC server by specifying router, handler
@@ -23,6 +31,7 @@ const (
 // public will be exposed.
 func MustNewNativeSpaceServer(
 	registry *rate.Registry,
+	tenants *tenant.Registry,
 	clientProvider *infuraNode.CfxClientProvider,
 	gashandler *handler.GasStationHandler,
 	exposedModules []string,
@@ -38,7 +47,16 @@ func MustNewNativeSpaceServer(
 		)
 	}
 
-	middleware := httpMiddleware(registry, clientProvider)
+	var confuraOption confuraAPIOption
+	if len(option) > 0 {
+		confuraOption.StatusReporter = option[0].StatusReporter
+	}
+
+	exposedApis[confuraNamespace] = newConfuraAPI("cfx", exposedApis, confuraOption)
+
+	authenticator, _ := handlers.MustNewAuthenticatorFromViper("rpc.auth")
+	methodFilter, _ := handlers.MustNewMethodFilterFromViper("rpc.methods")
+	middleware := httpMiddleware(registry, tenants, clientProvider, nil, nil, authenticator, methodFilter)
 
 	return rpc.MustNewServer(nativeSpaceRpcServerName, exposedApis, middleware)
 }
@@ -48,12 +66,15 @@ func MustNewNativeSpaceServer(
 // list is empty, all RPC API endpoints designated public will be exposed.
 func MustNewEvmSpaceServer(
 	registry *rate.Registry,
+	tenants *tenant.Registry,
 	clientProvider *infuraNode.EthClientProvider,
 	exposedModules []string,
 	option ...EthAPIOption,
 ) *rpc.Server {
 	// retrieve all available evm space rpc apis
-	allApis, err := evmSpaceApis(clientProvider, option...)
+	ethAPI := newEthAPI(clientProvider, option...)
+
+	allApis, err := evmSpaceApis(ethAPI)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to new EVM space RPC server")
 	}
@@ -65,7 +86,29 @@ func MustNewEvmSpaceServer(
 		)
 	}
 
-	middleware := httpMiddleware(registry, clientProvider)
+	var confuraOption confuraAPIOption
+	var headLoader handlers.HeadLoader
+	var staleCache *cache.StaleCache
+	if len(option) > 0 {
+		confuraOption = confuraAPIOption{
+			StatusReporter:     option[0].StatusReporter,
+			TokenStore:         option[0].TokenStore,
+			AddressTxStore:     option[0].AddressTxStore,
+			InternalTxStore:    option[0].InternalTxStore,
+			ContractIndexStore: option[0].ContractIndexStore,
+		}
+		headLoader = storeHeadLoader(option[0].HeadStore)
+		staleCache = option[0].StaleCache
+	}
+
+	confuraOption.Eth = ethAPI
+	exposedApis[confuraNamespace] = newConfuraAPI("eth", exposedApis, confuraOption)
+
+	authenticator, _ := handlers.MustNewAuthenticatorFromViper("rpc.auth")
+	methodFilter, _ := handlers.MustNewMethodFilterFromViper("ethrpc.methods")
+	middleware := httpMiddleware(
+		registry, tenants, clientProvider, headLoader, staleCache, authenticator, methodFilter,
+	)
 
 	return rpc.MustNewServer(evmSpaceRpcServerName, exposedApis, middleware)
 }
@@ -100,3 +143,73 @@ func MustNewDebugServer() *rpc.Server {
 
 	return rpc.MustNewServer(debugRpcServerName, servedApis)
 }
+
+// MustNewGraphqlMiddleware new HTTP middleware of evm space GraphQL server, which applies the same
+// authentication, tenant quota, method filter, allowlists and rate limits as evm space RPC server
+// by treating each GraphQL query as a call of the pseudo RPC method `graphql_query`.
+func MustNewGraphqlMiddleware(registry *rate.Registry, tenants *tenant.Registry) handlers.Middleware {
+	authenticator, _ := handlers.MustNewAuthenticatorFromViper("rpc.auth")
+	methodFilter, _ := handlers.MustNewMethodFilterFromViper("ethrpc.methods")
+
+	return graphqlMiddleware(registry, tenants, authenticator, methodFilter)
+}
+
+// MustNewAdminServer new admin RPC server to manage API keys of tenants for internal use, which requires
+// requests authenticated by JWT or HMAC signature if `rpc.auth` configured, otherwise only serves
+// requests from loopback address.
+func MustNewAdminServer(store *mysql.MysqlStore) *rpc.Server {
+	servedApis := make(map[string]interface{})
+	for _, api := range adminApis(store) {
+		servedApis[api.Namespace] = api.Service
+	}
+
+	authenticator, ok := handlers.MustNewAuthenticatorFromViper("rpc.auth")
+	if !ok {
+		logrus.Warn("Admin RPC authentication not configured, only requests from loopback address are served")
+	}
+
+	return rpc.MustNewServer(adminRpcServerName, servedApis, adminAuthMiddleware(authenticator))
+}
+
+// adminAuthMiddleware rejects requests to the admin endpoint unless authenticated by JWT or HMAC
+// signature, or from loopback address if authenticator not configured.
+func adminAuthMiddleware(authenticator *handlers.Authenticator) handlers.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authenticator == nil {
+				if !isLoopbackAddr(r.RemoteAddr) {
+					http.Error(w, "admin access allowed from loopback address only", http.StatusForbidden)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = authenticator.WithAuthentication(r)
+
+			principal, ok := handlers.GetPrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "admin access requires authentication", http.StatusUnauthorized)
+				return
+			}
+
+			if principal.Err != nil {
+				http.Error(w, principal.Err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isLoopbackAddr(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -1,23 +1,34 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/cache"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/audit"
 	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/Conflux-Chain/confura/util/rpc/middlewares"
+	"github.com/Conflux-Chain/confura/util/tenant"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
 )
 
 const (
 	ctxKeyClientProvider = handlers.CtxKey("Infura-RPC-Client-Provider")
 	ctxKeyClient         = handlers.CtxKey("Infura-RPC-Client")
 	ctxKeyClientGroup    = handlers.CtxKey("Infura-RPC-Client-Group")
+	ctxKeyStaleCache     = handlers.CtxKey("Infura-RPC-Stale-Cache")
 )
 
+var errStoreHeadNotFound = errors.New("no data synced into store yet")
+
 // go-rpc-provider only supports static middlewares for RPC server.
 func init() {
 	// middlewares executed in order
@@ -25,12 +36,27 @@ func init() {
 	// panic recovery
 	rpc.HookHandleCallMsg(middlewares.Recover)
 
+	// stable error kind of coded errors
+	rpc.HookHandleCallMsg(middlewares.ErrorKind)
+
 	// anti-injection
 	rpc.HookHandleCallMsg(middlewares.AntiInjection)
 
 	// auth
 	rpc.HookHandleCallMsg(middlewares.Auth())
 
+	// audit logging
+	rpc.HookHandleCallMsg(middlewares.Audit)
+
+	// tenant tagging and usage accounting
+	rpc.HookHandleCallMsg(middlewares.Tenant)
+
+	// reject account unlocking and signing methods
+	rpc.HookHandleCallMsg(middlewares.SigningGuard())
+
+	// method allowlist and denylist per endpoint and tenant
+	rpc.HookHandleCallMsg(middlewares.MethodFilter)
+
 	// allow lists
 	rpc.HookHandleCallMsg(middlewares.Allowlists)
 
@@ -38,6 +64,12 @@ func init() {
 	rpc.HookHandleCallMsg(middlewares.DailyMaxReqRateLimit)
 	rpc.HookHandleCallMsg(middlewares.QpsRateLimit)
 
+	// websocket subscription limits
+	rpc.HookHandleCallMsg(middlewares.WebsocketLimits)
+
+	// admission control with priority queues
+	rpc.HookHandleCallMsg(middlewares.Admission())
+
 	// metrics
 	rpc.HookHandleBatch(middlewares.MetricsBatch)
 	rpc.HookHandleCallMsg(middlewares.Metrics)
@@ -46,6 +78,29 @@ func init() {
 	rpc.HookHandleBatch(middlewares.LogBatch)
 	rpc.HookHandleCallMsg(middlewares.Log)
 
+	// shadow traffic mirroring to canary fullnodes
+	rpc.HookHandleCallMsg(middlewares.Mirror())
+
+	// soft fail for batch items
+	rpc.HookHandleBatch(middlewares.SoftFailBatch())
+	rpc.HookHandleCallMsg(middlewares.SoftFail)
+
+	// result checksum
+	rpc.HookHandleBatch(middlewares.ResultChecksumBatch)
+	rpc.HookHandleCallMsg(middlewares.ResultChecksum())
+
+	// per-method response size cap
+	rpc.HookHandleCallMsg(middlewares.ResponseLimit())
+
+	// per-method timeout
+	rpc.HookHandleCallMsg(middlewares.Timeout)
+
+	// consistent read pinned to the head of store
+	rpc.HookHandleCallMsg(middlewares.ConsistentRead)
+
+	// stale-while-revalidate cache for "latest" style calls
+	rpc.HookHandleCallMsg(staleCacheMiddleware)
+
 	// cfx/eth client
 	rpc.HookHandleCallMsg(clientMiddleware)
 
@@ -54,7 +109,15 @@ func init() {
 }
 
 // Inject values into context for static RPC call middlewares, e.g. rate limit
-func httpMiddleware(registry *rate.Registry, clientProvider interface{}) handlers.Middleware {
+func httpMiddleware(
+	registry *rate.Registry,
+	tenants *tenant.Registry,
+	clientProvider interface{},
+	headLoader handlers.HeadLoader,
+	staleCache *cache.StaleCache,
+	authenticator *handlers.Authenticator,
+	methodFilter *handlers.MethodFilter,
+) handlers.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -71,33 +134,381 @@ func httpMiddleware(registry *rate.Registry, clientProvider interface{}) handler
 				ctx = context.WithValue(ctx, handlers.CtxKeyRateRegistry, registry)
 			}
 
+			if tenants != nil {
+				ctx = context.WithValue(ctx, handlers.CtxKeyTenantRegistry, tenants)
+			}
+
 			if clientProvider != nil {
 				ctx = context.WithValue(ctx, ctxKeyClientProvider, clientProvider)
 			}
 
-			next.ServeHTTP(w, r.WithContext(ctx))
+			if methodFilter != nil {
+				ctx = context.WithValue(ctx, handlers.CtxKeyMethodFilter, methodFilter)
+			}
+
+			if staleCache != nil {
+				ctx = context.WithValue(ctx, ctxKeyStaleCache, staleCache)
+			}
+
+			// optional JWT or HMAC authentication in addition to API keys
+			if authenticator != nil {
+				r = authenticator.WithAuthentication(r.WithContext(ctx))
+				ctx = r.Context()
+			}
+
+			// optional consistent read mode opted in by client
+			if headLoader != nil {
+				r = handlers.WithConsistentRead(r.WithContext(ctx), headLoader)
+				ctx = r.Context()
+			}
+
+			// optional idempotency key of write RPCs specified by client
+			r = handlers.WithIdempotencyKey(r.WithContext(ctx))
+
+			// optional checksum of large result requested by client
+			w, r = handlers.WithResultChecksum(w, r)
+
+			// remaining compute unit quotas of tenant
+			if tenants != nil {
+				w, r = handlers.WithQuotaRemaining(w, r)
+			}
+
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// graphqlQueryMethod pseudo RPC method of GraphQL queries, e.g. rate limited by `graphql_query_qps`
+// and accounted as `graphql_query` for tenants.
+const graphqlQueryMethod = "graphql_query"
+
+// graphqlCallMiddlewares static RPC call middlewares applied to GraphQL queries in order.
+var graphqlCallMiddlewares = []rpc.HandleCallMsgMiddleware{
+	middlewares.Authenticate,
+	middlewares.Tenant,
+	middlewares.MethodFilter,
+	middlewares.Allowlists,
+	middlewares.DailyMaxReqRateLimit,
+	middlewares.QpsRateLimit,
+}
+
+func graphqlMiddleware(
+	registry *rate.Registry,
+	tenants *tenant.Registry,
+	authenticator *handlers.Authenticator,
+	methodFilter *handlers.MethodFilter,
+) handlers.Middleware {
+	httpMw := httpMiddleware(registry, tenants, nil, nil, nil, authenticator, methodFilter)
+	callMw := callMsgMiddleware(graphqlQueryMethod, graphqlCallMiddlewares...)
+
+	return func(next http.Handler) http.Handler {
+		return httpMw(callMw(next))
+	}
+}
+
+// callMsgMiddleware adapts the static RPC call middlewares to HTTP middleware for non JSON-RPC
+// requests, which are handled as calls of the specified pseudo RPC method.
+func callMsgMiddleware(method string, callMiddlewares ...rpc.HandleCallMsgMiddleware) handlers.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var served bool
+
+			handle := func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+				served = true
+				next.ServeHTTP(w, r.WithContext(ctx))
+
+				return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage("null")}
+			}
+
+			for i := len(callMiddlewares) - 1; i >= 0; i-- {
+				handle = callMiddlewares[i](handle)
+			}
+
+			msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: method}
+			if resp := handle(r.Context(), msg); !served && resp.Error != nil {
+				writeCallMsgError(w, resp.Error)
+			}
+		})
+	}
+}
+
+// writeCallMsgError responds the rejection of RPC call middlewares in GraphQL error format.
+func writeCallMsgError(w http.ResponseWriter, err *rpc.JsonError) {
+	status := http.StatusForbidden
+	switch err.Code {
+	case rpcutil.ErrCodeUnauthorized:
+		status = http.StatusUnauthorized
+	case rpcutil.ErrCodeLimitExceeded:
+		status = http.StatusTooManyRequests
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []interface{}{map[string]interface{}{
+			"message":    err.Message,
+			"extensions": map[string]interface{}{"code": err.Code, "data": err.Data},
+		}},
+	})
+}
+
+// storeHeadLoader returns the head loader of store for consistent read mode, or nil if store not
+// available.
+func storeHeadLoader(store HeadStore) handlers.HeadLoader {
+	if util.IsInterfaceValNil(store) {
+		return nil
+	}
+
+	return func() (uint64, error) {
+		head, ok, err := store.MaxEpoch()
+		if err == nil && !ok {
+			err = errStoreHeadNotFound
+		}
+
+		return head, err
+	}
+}
+
+// staleCacheMiddleware serves the cached result of "latest" style calls (eg., without params or
+// with `latest` block parameter) in stale-while-revalidate manner if enabled.
+func staleCacheMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		staleCache, ok := ctx.Value(ctxKeyStaleCache).(*cache.StaleCache)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		if _, ok := staleCache.Budget(msg.Method); !ok || !isLatestStyleCall(msg.Method, msg.Params) {
+			return next(ctx, msg)
+		}
+
+		// copy the request, which may be refreshed in background after responded
+		req := *msg
+		req.Params = append(json.RawMessage(nil), msg.Params...)
+
+		key := req.Method + string(req.Params)
+		result, err := staleCache.GetOrUpdate(req.Method, key, func() (json.RawMessage, error) {
+			resp := next(detachedContext{ctx}, &req)
+			if resp.Error != nil {
+				return nil, resp.Error
+			}
+
+			return resp.Result, nil
+		})
+
+		if err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+	}
+}
+
+// blockParamIndexes index of the optional block (or epoch) param of methods, which defaults to the
+// latest block if omitted.
+var blockParamIndexes = map[string]int{
+	"eth_getBalance":                          1,
+	"eth_getCode":                             1,
+	"eth_getTransactionCount":                 1,
+	"eth_getStorageAt":                        2,
+	"eth_call":                                1,
+	"eth_estimateGas":                         1,
+	"eth_createAccessList":                    1,
+	"eth_getProof":                            2,
+	"eth_feeHistory":                          1,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"cfx_epochNumber":                         0,
+	"cfx_getBalance":                          1,
+	"cfx_getAdmin":                            1,
+	"cfx_getSponsorInfo":                      1,
+	"cfx_getStakingBalance":                   1,
+	"cfx_getDepositList":                      1,
+	"cfx_getVoteList":                         1,
+	"cfx_getCollateralInfo":                   0,
+	"cfx_getCollateralForStorage":             1,
+	"cfx_getCode":                             1,
+	"cfx_getStorageAt":                        2,
+	"cfx_getStorageRoot":                      1,
+	"cfx_getNextNonce":                        1,
+	"cfx_call":                                1,
+	"cfx_estimateGasAndCollateral":            1,
+	"cfx_checkBalanceAgainstTransaction":      5,
+	"cfx_getAccount":                          1,
+	"cfx_getInterestRate":                     0,
+	"cfx_getAccumulateInterestRate":           0,
+	"cfx_getSupplyInfo":                       0,
+	"cfx_getBlockByEpochNumber":               0,
+	"cfx_getBlocksByEpoch":                    0,
+	"cfx_getSkippedBlocksByEpoch":             0,
+	"cfx_getBlockRewardInfo":                  0,
+	"cfx_getEpochReceipts":                    0,
+	"cfx_getParamsFromVote":                   0,
+}
+
+// isLatestStyleCall returns true if the RPC params are empty or refer to the latest (or pending) block
+// of either space, including the omitted block param which defaults to the latest block.
+func isLatestStyleCall(method string, params json.RawMessage) bool {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return true
+	}
+
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		return false
+	}
+
+	switch method {
+	case "eth_getLogs", "cfx_getLogs":
+		return len(args) > 0 && isLatestStyleLogFilter(args[0])
+	}
+
+	idx, ok := blockParamIndexes[method]
+	if !ok { // no block param, or unknown method
+		for _, arg := range args {
+			if isLatestStyleParam(arg) {
+				return true
+			}
+		}
+
+		return len(args) == 0
+	}
+
+	if idx >= len(args) || isNullParam(args[idx]) {
+		return true
+	}
+
+	return isLatestStyleParam(args[idx])
+}
+
+// isLatestStyleParam checks if the block (or epoch) param is a latest style tag, eg., `latest`,
+// `pending`, `latest_state` or `latest_mined`, including EIP-1898 style object.
+func isLatestStyleParam(arg json.RawMessage) bool {
+	var tag string
+	if err := json.Unmarshal(arg, &tag); err != nil {
+		var obj struct {
+			BlockNumber *string `json:"blockNumber"`
+			EpochNumber *string `json:"epochNumber"`
+		}
+
+		if err := json.Unmarshal(arg, &obj); err != nil {
+			return false
+		}
+
+		switch {
+		case obj.BlockNumber != nil:
+			tag = *obj.BlockNumber
+		case obj.EpochNumber != nil:
+			tag = *obj.EpochNumber
+		default:
+			return false
+		}
+	}
+
+	return isLatestStyleTag(tag)
+}
+
+// isLatestStyleLogFilter checks if the log filter ranges to the latest block, which defaults to the
+// latest block if omitted.
+func isLatestStyleLogFilter(arg json.RawMessage) bool {
+	var filter struct {
+		BlockHash   json.RawMessage `json:"blockHash"`
+		BlockHashes json.RawMessage `json:"blockHashes"`
+		ToBlock     *string         `json:"toBlock"`
+		ToEpoch     *string         `json:"toEpoch"`
+	}
+
+	if err := json.Unmarshal(arg, &filter); err != nil {
+		return false
+	}
+
+	if !isNullParam(filter.BlockHash) || !isNullParam(filter.BlockHashes) {
+		return false
+	}
+
+	switch {
+	case filter.ToBlock != nil:
+		return isLatestStyleTag(*filter.ToBlock)
+	case filter.ToEpoch != nil:
+		return isLatestStyleTag(*filter.ToEpoch)
+	default:
+		return true
+	}
+}
+
+func isLatestStyleTag(s string) bool {
+	tag, ok := rpcutil.ParseTag(s)
+	return ok && (tag == rpcutil.TagLatest || tag == rpcutil.TagPending)
+}
+
+func isNullParam(arg json.RawMessage) bool {
+	arg = bytes.TrimSpace(arg)
+	return len(arg) == 0 || bytes.Equal(arg, []byte("null"))
+}
+
 func clientMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		var client interface{}
 		var grp node.Group
 		var err error
 
+		// exclude the lagging fullnodes from routing if configured
+		if isLatestStyleCall(msg.Method, msg.Params) {
+			ctx = node.WithLatestSensitive(ctx)
+		}
+
+		// select fullnode by the selection policy of method group if configured
+		ctx = node.WithRouteMethod(ctx, msg.Method, msg.Params)
+
 		if cfxProvider, ok := ctx.Value(ctxKeyClientProvider).(*node.CfxClientProvider); ok {
 			client, grp, err = getCfxClientFromProviderWithContext(ctx, msg.Method, cfxProvider)
+			if errors.Is(err, node.ErrClientUnavailable) {
+				// serve by store if possible, and fail with `no upstream available` otherwise
+				client, err = node.UnavailableCfxClient(), nil
+			}
 		} else if ethProvider, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); ok {
-			client, grp, err = getEthClientFromProviderWithContext(ctx, msg.Method, ethProvider)
+			var eth *node.Web3goClient
+			eth, grp, err = getEthClientFromProviderWithContext(ctx, msg.Method, ethProvider)
+			if errors.Is(err, node.ErrClientUnavailable) {
+				// serve by store if possible, and fail with `no upstream available` otherwise
+				client, err = node.UnavailableEthClient(), nil
+			} else if err == nil {
+				// verify critical reads against multiple fullnodes if configured, otherwise hedge
+				// tail-latency sensitive requests to some other fullnode if configured
+				if verified, ok := ethProvider.WithVerify(eth, grp, msg.Method); ok {
+					client = verified
+				} else {
+					client = ethProvider.WithHedge(eth, grp, msg.Method)
+				}
+			}
 		} else {
 			return next(ctx, msg)
 		}
 
-		if err != nil { // no fullnode available to request RPC
+		if errors.Is(err, node.ErrClientUnavailable) { // no fullnode available at all
 			return msg.ErrorResponse(err)
 		}
 
+		if err != nil { // failed to connect to fullnode to request RPC
+			return msg.ErrorResponse(rpcutil.NewCodedError(rpcutil.ErrCodeUpstreamUnavailable, err))
+		}
+
+		switch c := client.(type) {
+		case sdk.ClientOperator:
+			nodeName := rpcutil.Url2NodeName(c.GetNodeURL())
+			audit.SetNode(ctx, nodeName)
+			node.RecordRoutedNode(ctx, nodeName)
+		case *node.Web3goClient:
+			audit.SetNode(ctx, c.NodeName())
+			node.RecordRoutedNode(ctx, c.NodeName())
+			// propagate the remaining time budget of RPC call into fullnode
+			client = c.WithDeadline(ctx)
+		}
+
 		ctx = context.WithValue(ctx, ctxKeyClient, client)
 		ctx = context.WithValue(ctx, ctxKeyClientGroup, grp)
 
@@ -126,6 +537,8 @@ func getEthClientFromProviderWithContext(
 		grp = node.GroupEthLogs
 	case isEthFilterRpcMethod(rpcMethod):
 		grp = node.GroupEthFilter
+	case isTraceRpcMethod(rpcMethod):
+		grp = node.GroupEthTraces
 	default:
 		if authId, ok := handlers.GetAuthIdFromContext(ctx); ok {
 			grp, ok := p.GetRouteGroup(authId)
@@ -137,6 +550,12 @@ func getEthClientFromProviderWithContext(
 	}
 
 	client, err := p.GetClientByIP(ctx, grp)
+	if grp == node.GroupEthTraces && errors.Is(err, node.ErrClientUnavailable) {
+		// fall back to normal HTTP group if no trace node configured or available
+		grp = node.GroupEthHttp
+		client, err = p.GetClientByIP(ctx, grp)
+	}
+
 	return client, grp, err
 }
 
@@ -149,6 +568,8 @@ func getCfxClientFromProviderWithContext(
 		grp = node.GroupCfxLogs
 	case isCfxFilterRpcMethod(rpcMethod):
 		grp = node.GroupCfxFilter
+	case isTraceRpcMethod(rpcMethod):
+		grp = node.GroupCfxTraces
 	default:
 		if authId, ok := handlers.GetAuthIdFromContext(ctx); ok {
 			grp, ok := p.GetRouteGroup(authId)
@@ -160,5 +581,11 @@ func getCfxClientFromProviderWithContext(
 	}
 
 	client, err := p.GetClientByIP(ctx, grp)
+	if grp == node.GroupCfxTraces && errors.Is(err, node.ErrClientUnavailable) {
+		// fall back to normal HTTP group if no trace node configured or available
+		grp = node.GroupCfxHttp
+		client, err = p.GetClientByIP(ctx, grp)
+	}
+
 	return client, grp, err
 }
@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceClientFallbackToHttpGroup(t *testing.T) {
+	router := node.NewLocalRouter(map[node.Group][]string{
+		node.GroupEthHttp: {"http://127.0.0.1:8545"},
+	})
+
+	provider := node.NewEthClientProvider(nil, router)
+	client, grp, err := getEthClientFromProviderWithContext(context.Background(), "trace_block", provider)
+	assert.NoError(t, err)
+	assert.Equal(t, node.GroupEthHttp, grp)
+	assert.Equal(t, "http://127.0.0.1:8545", client.URL)
+}
+
+func TestTraceClientRoutedToTraceGroup(t *testing.T) {
+	router := node.NewLocalRouter(map[node.Group][]string{
+		node.GroupEthHttp:   {"http://127.0.0.1:8545"},
+		node.GroupEthTraces: {"http://127.0.0.2:8545"},
+	})
+
+	provider := node.NewEthClientProvider(nil, router)
+	client, grp, err := getEthClientFromProviderWithContext(context.Background(), "trace_block", provider)
+	assert.NoError(t, err)
+	assert.Equal(t, node.GroupEthTraces, grp)
+	assert.Equal(t, "http://127.0.0.2:8545", client.URL)
+}
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(authenticator *handlers.Authenticator, remoteAddr string, header http.Header) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		r.RemoteAddr = remoteAddr
+		for k, v := range header {
+			r.Header[k] = v
+		}
+
+		w := httptest.NewRecorder()
+		adminAuthMiddleware(authenticator)(next).ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	// loopback only if authentication not configured
+	assert.Equal(t, http.StatusOK, serve(nil, "127.0.0.1:1234", nil))
+	assert.Equal(t, http.StatusOK, serve(nil, "[::1]:1234", nil))
+	assert.Equal(t, http.StatusForbidden, serve(nil, "10.0.0.1:1234", nil))
+
+	// authentication required if configured
+	authenticator, err := handlers.NewAuthenticator(handlers.AuthConfig{
+		Jwt: handlers.JwtConfig{Enabled: true, Secret: "secret"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, serve(authenticator, "127.0.0.1:1234", nil))
+	assert.Equal(t, http.StatusUnauthorized, serve(authenticator, "127.0.0.1:1234", http.Header{
+		"Authorization": {"Bearer invalid"},
+	}))
+}
+
+func TestGraphqlMiddleware(t *testing.T) {
+	var served int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ })
+
+	authenticator, err := handlers.NewAuthenticator(handlers.AuthConfig{
+		Jwt: handlers.JwtConfig{Enabled: true, Secret: "secret"},
+	})
+	assert.NoError(t, err)
+
+	serve := func(methodFilter *handlers.MethodFilter, header http.Header) int {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{}"))
+		for k, v := range header {
+			r.Header[k] = v
+		}
+
+		w := httptest.NewRecorder()
+		graphqlMiddleware(nil, nil, authenticator, methodFilter)(next).ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(nil, nil))
+	assert.Equal(t, 1, served)
+
+	// invalid credential
+	assert.Equal(t, http.StatusUnauthorized, serve(nil, http.Header{"Authorization": {"Bearer invalid"}}))
+
+	// method filtered
+	filter := handlers.NewMethodFilter(handlers.MethodFilterConfig{
+		MethodList: handlers.MethodList{Allow: []string{"eth_*"}},
+	})
+	assert.Equal(t, http.StatusForbidden, serve(filter, nil))
+
+	filter = handlers.NewMethodFilter(handlers.MethodFilterConfig{
+		MethodList: handlers.MethodList{Allow: []string{"eth_*", "graphql_query"}},
+	})
+	assert.Equal(t, http.StatusOK, serve(filter, nil))
+	assert.Equal(t, 2, served)
+}
+
+func TestIsLatestStyleCall(t *testing.T) {
+	testCases := []struct {
+		method   string
+		params   string
+		expected bool
+	}{
+		// no params
+		{"eth_blockNumber", ``, true},
+		{"eth_gasPrice", `[]`, true},
+		{"cfx_getStatus", `null`, true},
+		// without block param
+		{"eth_getTransactionByHash", `["0x01"]`, false},
+		// evm space block tags
+		{"eth_getBalance", `["0xaa", "latest"]`, true},
+		{"eth_getBalance", `["0xaa", "pending"]`, true},
+		{"eth_getBalance", `["0xaa", "safe"]`, false},
+		{"eth_getBalance", `["0xaa", "finalized"]`, false},
+		{"eth_getBalance", `["0xaa", "0x10"]`, false},
+		{"eth_getStorageAt", `["0xaa", "0x0", "latest"]`, true},
+		{"eth_getBlockByNumber", `["latest", false]`, true},
+		{"eth_getBlockByNumber", `["0x10", false]`, false},
+		// omitted block param defaults to latest
+		{"eth_getBalance", `["0xaa"]`, true},
+		{"eth_getBalance", `["0xaa", null]`, true},
+		{"eth_call", `[{"to": "0xaa", "data": "0x6c6174657374"}]`, true},
+		{"eth_getStorageAt", `["0xaa", "0x0"]`, true},
+		// EIP-1898 style block param
+		{"eth_call", `[{"to": "0xaa"}, {"blockNumber": "latest"}]`, true},
+		{"eth_call", `[{"to": "0xaa"}, {"blockNumber": "0x10"}]`, false},
+		{"eth_call", `[{"to": "0xaa"}, {"blockHash": "0x01"}]`, false},
+		// core space epoch tags
+		{"cfx_getBalance", `["cfx:aa", "latest_state"]`, true},
+		{"cfx_getBalance", `["cfx:aa", "latest_mined"]`, true},
+		{"cfx_getBalance", `["cfx:aa", "latest_confirmed"]`, false},
+		{"cfx_getBalance", `["cfx:aa", "latest_checkpoint"]`, false},
+		{"cfx_getBalance", `["cfx:aa"]`, true},
+		{"cfx_epochNumber", `["latest_mined"]`, true},
+		{"cfx_epochNumber", `["latest_finalized"]`, false},
+		{"cfx_getBlockByEpochNumber", `["0x10", false]`, false},
+		{"cfx_call", `[{"to": "cfx:aa"}, {"epochNumber": "latest_state"}]`, true},
+		// log filters
+		{"eth_getLogs", `[{"fromBlock": "0x10"}]`, true},
+		{"eth_getLogs", `[{"fromBlock": "0x10", "toBlock": "latest"}]`, true},
+		{"eth_getLogs", `[{"fromBlock": "0x10", "toBlock": "0x20"}]`, false},
+		{"eth_getLogs", `[{"blockHash": "0x01"}]`, false},
+		{"cfx_getLogs", `[{"fromEpoch": "0x10", "toEpoch": "latest_state"}]`, true},
+		{"cfx_getLogs", `[{"fromEpoch": "0x10", "toEpoch": "0x20"}]`, false},
+		{"cfx_getLogs", `[{"blockHashes": ["0x01"]}]`, false},
+		// unknown method
+		{"debug_unknown", `["latest"]`, true},
+		{"debug_unknown", `["0x10"]`, false},
+		// malformed params
+		{"eth_getBalance", `{"address": "0xaa"}`, false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isLatestStyleCall(tc.method, []byte(tc.params)), "%v %v", tc.method, tc.params)
+	}
+}
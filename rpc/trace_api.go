@@ -2,25 +2,45 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 
+	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 )
 
 // traceAPI provides core space trace RPC proxy API.
-type traceAPI struct{}
+type traceAPI struct {
+	handler *handler.TraceHandler
+}
+
+func (api *traceAPI) Block(ctx context.Context, blockHash types.Hash) (json.RawMessage, error) {
+	return cfxTraceCall(ctx, api.handler, "trace_block", "", blockHash)
+}
+
+func (api *traceAPI) Filter(ctx context.Context, filter types.TraceFilter) (json.RawMessage, error) {
+	return cfxTraceCall(ctx, api.handler, "trace_filter", "", filter)
+}
 
-func (api *traceAPI) Block(ctx context.Context, blockHash types.Hash) (*types.LocalizedBlockTrace, error) {
-	return GetCfxClientFromContext(ctx).Trace().GetBlockTraces(blockHash)
+func (api *traceAPI) Transaction(ctx context.Context, txHash types.Hash) (json.RawMessage, error) {
+	return cfxTraceCall(ctx, api.handler, "trace_transaction", txHash.String(), txHash)
 }
 
-func (api *traceAPI) Filter(ctx context.Context, filter types.TraceFilter) ([]types.LocalizedTrace, error) {
-	return GetCfxClientFromContext(ctx).Trace().FilterTraces(filter)
+func (api *traceAPI) Epoch(ctx context.Context, epoch types.Epoch) (json.RawMessage, error) {
+	return cfxTraceCall(ctx, api.handler, "trace_epoch", "", epoch)
 }
 
-func (api *traceAPI) Transaction(ctx context.Context, txHash types.Hash) ([]types.LocalizedTrace, error) {
-	return GetCfxClientFromContext(ctx).Trace().GetTransactionTraces(txHash)
+func cfxTraceCall(
+	ctx context.Context, h *handler.TraceHandler, method, cacheKey string, args ...interface{},
+) (json.RawMessage, error) {
+	cfx := GetCfxClientFromContext(ctx)
+	return h.Call(method, cacheKey, func(result *json.RawMessage) error {
+		return cfx.CallRPC(result, method, args...)
+	})
 }
 
-func (api *traceAPI) Epoch(ctx context.Context, epoch types.Epoch) (types.EpochTrace, error) {
-	return GetCfxClientFromContext(ctx).Trace().GetEpochTraces(epoch)
+// isTraceRpcMethod checks if the RPC method is of trace family, which is served by
+// trace-enabled fullnodes only.
+func isTraceRpcMethod(method string) bool {
+	return strings.HasPrefix(method, "trace_") || strings.HasPrefix(method, "debug_trace")
 }
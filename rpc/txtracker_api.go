@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/util/txpool"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+var (
+	errTxTrackerDisabled = errors.New("transaction tracker not enabled")
+)
+
+// cfxTxTrackerAPI provides core space RPC methods to report or resubmit the pending transactions
+// submitted through gateway.
+type cfxTxTrackerAPI struct {
+	tracker *txpool.Tracker
+}
+
+// Status returns the status of pending transactions submitted through gateway for the sender,
+// including the stuck or nonce gapped ones.
+func (api *cfxTxTrackerAPI) Status(ctx context.Context, sender types.Address) (*txpool.SenderStatus, error) {
+	if api.tracker == nil {
+		return nil, errTxTrackerDisabled
+	}
+
+	return api.tracker.Status(sender.String()), nil
+}
+
+// Resubmit resubmits the pending transaction submitted through gateway.
+func (api *cfxTxTrackerAPI) Resubmit(ctx context.Context, txHash types.Hash) error {
+	if api.tracker == nil {
+		return errTxTrackerDisabled
+	}
+
+	return api.tracker.Resubmit(txHash.String())
+}
+
+// ethTxTrackerAPI provides evm space RPC methods to report or resubmit the pending transactions
+// submitted through gateway.
+type ethTxTrackerAPI struct {
+	tracker *txpool.Tracker
+}
+
+// Status returns the status of pending transactions submitted through gateway for the sender,
+// including the stuck or nonce gapped ones.
+func (api *ethTxTrackerAPI) Status(ctx context.Context, sender common.Address) (*txpool.SenderStatus, error) {
+	if api.tracker == nil {
+		return nil, errTxTrackerDisabled
+	}
+
+	return api.tracker.Status(sender.Hex()), nil
+}
+
+// Resubmit resubmits the pending transaction submitted through gateway.
+func (api *ethTxTrackerAPI) Resubmit(ctx context.Context, txHash common.Hash) error {
+	if api.tracker == nil {
+		return errTxTrackerDisabled
+	}
+
+	return api.tracker.Resubmit(txHash.Hex())
+}
@@ -7,10 +7,16 @@ import (
 )
 
 // web3API provides evm space web3 RPC proxy API.
-type web3API struct{}
+type web3API struct {
+	local *EthLocalAnswerer // answers locally if not nil
+}
 
 // ClientVersion returns the current client version.
 func (api *web3API) ClientVersion(ctx context.Context) (string, error) {
+	if api.local != nil {
+		return api.local.ClientVersion(), nil
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	return cache.EthDefault.GetClientVersion(w3c.Client)
 }
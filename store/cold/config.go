@@ -0,0 +1,57 @@
+package cold
+
+import (
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// Config cold storage configurations to offload pruned event log partitions.
+type Config struct {
+	// whether to enable cold storage tier
+	Enabled bool
+	// object storage type, eg., `s3` or `local`
+	Storage string `default:"s3"`
+
+	// S3 compatible object storage settings
+	Endpoint  string // custom endpoint, eg., `https://storage.googleapis.com` for GCS
+	Region    string `default:"us-east-1"`
+	Bucket    string
+	AccessKey string // use default credential chain if not specified
+	SecretKey string
+	PathStyle bool // whether to use path style addressing, eg., for MinIO
+
+	// local directory for `local` storage
+	Dir string
+
+	// object key prefix
+	Prefix string `default:"logs"`
+	// number of blocks of event logs in each object
+	ChunkBlocks uint64 `default:"10000"`
+	// parquet compression codec, eg., `none`, `snappy`, `gzip` or `zstd`
+	Compression string `default:"zstd"`
+	// number of decoded objects to cache in memory
+	CacheSize int `default:"16"`
+	// timeout to put or get object
+	Timeout time.Duration `default:"30s"`
+}
+
+// MustNewLogStoreFromViper creates cold event log store from viper settings of the specified key,
+// e.g., `ethstore.cold`, and returns false if disabled.
+func MustNewLogStoreFromViper(key string, ms *mysql.MysqlStore) (*LogStore, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	ls, err := NewLogStore(conf, ms)
+	if err != nil {
+		logrus.WithField("key", key).WithError(err).Fatal("Failed to create cold log store")
+	}
+
+	return ls, true
+}
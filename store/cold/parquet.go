@@ -0,0 +1,173 @@
+package cold
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// coldLog event log persisted in columnar format (parquet), in which contract address is
+// denormalized for filtering.
+type coldLog struct {
+	BlockNumber int64  `parquet:"name=bn, type=INT64"`
+	Epoch       int64  `parquet:"name=epoch, type=INT64"`
+	ContractID  int64  `parquet:"name=cid, type=INT64"`
+	Address     string `parquet:"name=address, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Topic0      string `parquet:"name=topic0, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Topic1      string `parquet:"name=topic1, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Topic2      string `parquet:"name=topic2, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Topic3      string `parquet:"name=topic3, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LogIndex    int64  `parquet:"name=log_index, type=INT64"`
+	Extra       string `parquet:"name=extra, type=BYTE_ARRAY"` // might be compressed
+}
+
+func newColdLog(log *store.Log, address string) coldLog {
+	return coldLog{
+		BlockNumber: int64(log.BlockNumber),
+		Epoch:       int64(log.Epoch),
+		ContractID:  int64(log.ContractID),
+		Address:     address,
+		Topic0:      log.Topic0,
+		Topic1:      log.Topic1,
+		Topic2:      log.Topic2,
+		Topic3:      log.Topic3,
+		LogIndex:    int64(log.LogIndex),
+		Extra:       string(log.Extra),
+	}
+}
+
+func (log *coldLog) toStoreLog() *store.Log {
+	return &store.Log{
+		ContractID:  uint64(log.ContractID),
+		BlockNumber: uint64(log.BlockNumber),
+		Epoch:       uint64(log.Epoch),
+		Topic0:      log.Topic0,
+		Topic1:      log.Topic1,
+		Topic2:      log.Topic2,
+		Topic3:      log.Topic3,
+		LogIndex:    uint64(log.LogIndex),
+		Extra:       []byte(log.Extra),
+	}
+}
+
+// matches checks if the event log matches the store log filter.
+func (log *coldLog) matches(filter *store.LogFilter) bool {
+	bn := uint64(log.BlockNumber)
+	if bn < filter.BlockFrom || bn > filter.BlockTo {
+		return false
+	}
+
+	if !filter.Contracts.IsNull() && !filter.Contracts.Contains(log.Address) {
+		return false
+	}
+
+	topics := []string{log.Topic0, log.Topic1, log.Topic2, log.Topic3}
+	for i := range filter.Topics {
+		if i >= len(topics) {
+			return false
+		}
+
+		if !filter.Topics[i].IsNull() && !filter.Topics[i].Contains(topics[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var parquetCompressions = map[string]parquet.CompressionCodec{
+	"none":   parquet.CompressionCodec_UNCOMPRESSED,
+	"snappy": parquet.CompressionCodec_SNAPPY,
+	"gzip":   parquet.CompressionCodec_GZIP,
+	"zstd":   parquet.CompressionCodec_ZSTD,
+}
+
+// encodeParquet encodes event logs in parquet format.
+func encodeParquet(logs []coldLog, compression string) ([]byte, error) {
+	codec, ok := parquetCompressions[compression]
+	if !ok {
+		return nil, errors.Errorf("unsupported parquet compression %v", compression)
+	}
+
+	file := &parquetWriteBuffer{}
+
+	pw, err := writer.NewParquetWriter(file, new(coldLog), 1)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create parquet writer")
+	}
+
+	pw.CompressionType = codec
+
+	for i := range logs {
+		if err := pw.Write(logs[i]); err != nil {
+			return nil, errors.WithMessage(err, "failed to write parquet")
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return nil, errors.WithMessage(err, "failed to flush parquet")
+	}
+
+	return file.Bytes(), nil
+}
+
+// decodeParquet decodes event logs from parquet format.
+func decodeParquet(data []byte) ([]coldLog, error) {
+	pr, err := reader.NewParquetReader(newParquetReadBuffer(data), new(coldLog), 1)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create parquet reader")
+	}
+	defer pr.ReadStop()
+
+	logs := make([]coldLog, pr.GetNumRows())
+	if err := pr.Read(&logs); err != nil {
+		return nil, errors.WithMessage(err, "failed to read parquet")
+	}
+
+	return logs, nil
+}
+
+// parquetWriteBuffer in memory parquet file for writing only.
+type parquetWriteBuffer struct {
+	bytes.Buffer
+}
+
+func (f *parquetWriteBuffer) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("seek not supported")
+}
+
+func (f *parquetWriteBuffer) Read(p []byte) (int, error)                { return 0, io.EOF }
+func (f *parquetWriteBuffer) Close() error                              { return nil }
+func (f *parquetWriteBuffer) Open(string) (source.ParquetFile, error)   { return f, nil }
+func (f *parquetWriteBuffer) Create(string) (source.ParquetFile, error) { return f, nil }
+
+// parquetReadBuffer in memory parquet file for reading only.
+type parquetReadBuffer struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newParquetReadBuffer(data []byte) *parquetReadBuffer {
+	return &parquetReadBuffer{Reader: bytes.NewReader(data), data: data}
+}
+
+func (f *parquetReadBuffer) Write(p []byte) (int, error) {
+	return 0, errors.New("write not supported")
+}
+
+func (f *parquetReadBuffer) Close() error { return nil }
+
+// Open opens another reader on the same data, which is used to read column chunks concurrently.
+func (f *parquetReadBuffer) Open(string) (source.ParquetFile, error) {
+	return newParquetReadBuffer(f.data), nil
+}
+
+func (f *parquetReadBuffer) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("create not supported")
+}
@@ -0,0 +1,48 @@
+package cold
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParquetEncodeDecode(t *testing.T) {
+	logs := []coldLog{
+		newColdLog(&store.Log{ContractID: 1, BlockNumber: 100, Epoch: 100, Topic0: "0xa", Extra: []byte{1, 2}}, "addr1"),
+		newColdLog(&store.Log{ContractID: 2, BlockNumber: 101, Epoch: 101, Topic0: "0xb", Topic1: "0xc", LogIndex: 1}, "addr2"),
+	}
+
+	for compression := range parquetCompressions {
+		data, err := encodeParquet(logs, compression)
+		assert.NoError(t, err, compression)
+
+		decoded, err := decodeParquet(data)
+		assert.NoError(t, err, compression)
+		assert.Equal(t, logs, decoded, compression)
+	}
+
+	_, err := encodeParquet(logs, "lz4")
+	assert.Error(t, err)
+}
+
+func TestColdLogMatches(t *testing.T) {
+	log := newColdLog(&store.Log{BlockNumber: 100, Topic0: "0xa", Topic1: "0xb"}, "addr1")
+
+	assert.True(t, log.matches(&store.LogFilter{BlockFrom: 100, BlockTo: 100}))
+	assert.False(t, log.matches(&store.LogFilter{BlockFrom: 101, BlockTo: 200}))
+
+	assert.True(t, log.matches(&store.LogFilter{
+		BlockFrom: 1, BlockTo: 200, Contracts: store.NewVariadicValue("addr1", "addr2"),
+	}))
+	assert.False(t, log.matches(&store.LogFilter{
+		BlockFrom: 1, BlockTo: 200, Contracts: store.NewVariadicValue("addr2"),
+	}))
+
+	assert.True(t, log.matches(&store.LogFilter{
+		BlockFrom: 1, BlockTo: 200, Topics: []store.VariadicValue{{}, store.NewVariadicValue("0xb")},
+	}))
+	assert.False(t, log.matches(&store.LogFilter{
+		BlockFrom: 1, BlockTo: 200, Topics: []store.VariadicValue{store.NewVariadicValue("0xb")},
+	}))
+}
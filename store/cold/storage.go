@@ -0,0 +1,141 @@
+package cold
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// S3 compatible object storage, eg., AWS S3, GCS (with interoperability endpoint) or MinIO
+	StorageS3 = "s3"
+	// local directory, eg., mounted bucket or for testing purpose
+	StorageLocal = "local"
+)
+
+var errObjectNotFound = errors.New("object not found")
+
+// ObjectStorage object storage to persist offloaded data.
+type ObjectStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+func newObjectStorage(conf Config) (ObjectStorage, error) {
+	switch conf.Storage {
+	case StorageS3:
+		return newS3Storage(conf)
+	case StorageLocal:
+		return newLocalStorage(conf.Dir)
+	default:
+		return nil, errors.Errorf("unsupported object storage %v", conf.Storage)
+	}
+}
+
+// s3Storage S3 compatible object storage.
+type s3Storage struct {
+	bucket string
+	client *s3.S3
+}
+
+func newS3Storage(conf Config) (*s3Storage, error) {
+	if len(conf.Bucket) == 0 {
+		return nil, errors.New("bucket not specified")
+	}
+
+	awsConf := aws.NewConfig().
+		WithRegion(conf.Region).
+		WithS3ForcePathStyle(conf.PathStyle)
+
+	if len(conf.Endpoint) > 0 {
+		awsConf = awsConf.WithEndpoint(conf.Endpoint)
+	}
+
+	// use the default credential chain (eg., environment variables) if not specified
+	if len(conf.AccessKey) > 0 {
+		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(conf.AccessKey, conf.SecretKey, ""))
+	}
+
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create session")
+	}
+
+	return &s3Storage{bucket: conf.Bucket, client: s3.New(sess)}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+
+	return err
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, errObjectNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+// localStorage object storage on local directory.
+type localStorage struct {
+	dir string
+}
+
+func newLocalStorage(dir string) (*localStorage, error) {
+	if len(dir) == 0 {
+		return nil, errors.New("directory not specified")
+	}
+
+	return &localStorage{dir: dir}, nil
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write to temp file at first to avoid partially written object
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func (s *localStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, errObjectNotFound
+	}
+
+	return data, err
+}
@@ -0,0 +1,223 @@
+package cold
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LogStore cold storage tier of event logs, which offloads universal event log partitions to
+// object storage in parquet format before pruned, and serves historical event logs with higher
+// latency afterwards.
+type LogStore struct {
+	conf    Config
+	ms      *mysql.MysqlStore
+	storage ObjectStorage
+	cache   *lru.Cache // object key => decoded event logs
+}
+
+// NewLogStore creates cold event log store.
+func NewLogStore(conf Config, ms *mysql.MysqlStore) (*LogStore, error) {
+	if _, ok := parquetCompressions[conf.Compression]; !ok {
+		return nil, errors.Errorf("unsupported parquet compression %v", conf.Compression)
+	}
+
+	if conf.ChunkBlocks == 0 {
+		return nil, errors.New("chunk blocks must be greater than 0")
+	}
+
+	storage, err := newObjectStorage(conf)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create object storage")
+	}
+
+	cache, err := lru.New(conf.CacheSize)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create object cache")
+	}
+
+	return &LogStore{conf: conf, ms: ms, storage: storage, cache: cache}, nil
+}
+
+// ArchiveLogPartition implements mysql.LogArchiver to offload event logs of the partition to
+// object storage in chunks. Chunks already offloaded will be skipped, so it is safe to retry.
+func (ls *LogStore) ArchiveLogPartition(partition mysql.LogPartition) error {
+	for from := partition.BnMin; from <= partition.BnMax; {
+		to := ls.chunkTo(from, partition.BnMax)
+		if err := ls.archiveChunk(partition.Table, from, to); err != nil {
+			return errors.WithMessagef(err, "failed to archive block range [%v, %v]", from, to)
+		}
+
+		from = to + 1
+	}
+
+	return nil
+}
+
+// chunkTo returns the end block number of chunk starting from the specified block number, which
+// is aligned by block number so that objects are predictable.
+func (ls *LogStore) chunkTo(from, bnMax uint64) uint64 {
+	to := from - from%ls.conf.ChunkBlocks + ls.conf.ChunkBlocks - 1
+	if to > bnMax {
+		to = bnMax
+	}
+
+	return to
+}
+
+func (ls *LogStore) archiveChunk(table string, bnFrom, bnTo uint64) error {
+	key := fmt.Sprintf("%v/%012d-%012d.parquet", ls.conf.Prefix, bnFrom, bnTo)
+
+	existed, err := ls.ms.HasColdLogObject(key)
+	if err != nil || existed {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ls.conf.Timeout)
+	defer cancel()
+
+	logs, err := ls.ms.ScanLogPartition(ctx, table, bnFrom, bnTo)
+	if err != nil {
+		return errors.WithMessage(err, "failed to scan event logs")
+	}
+
+	obj := &mysql.ColdLogObject{BnMin: bnFrom, BnMax: bnTo, Key: key, NumLogs: uint64(len(logs))}
+
+	// no need to put object for empty chunk, which is only recorded in catalog for coverage
+	if len(logs) > 0 {
+		data, err := ls.encode(logs)
+		if err != nil {
+			return err
+		}
+
+		if err := ls.storage.Put(ctx, key, data); err != nil {
+			return errors.WithMessage(err, "failed to put object")
+		}
+
+		obj.Size = uint64(len(data))
+	}
+
+	if err := ls.ms.AddColdLogObject(obj); err != nil {
+		return errors.WithMessage(err, "failed to add object into catalog")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"key": key, "numLogs": obj.NumLogs, "size": obj.Size,
+	}).Debug("Event logs offloaded to cold storage")
+
+	return nil
+}
+
+func (ls *LogStore) encode(logs []*store.Log) ([]byte, error) {
+	addresses := make(map[uint64]string)
+	clogs := make([]coldLog, 0, len(logs))
+
+	for _, log := range logs {
+		addr, ok := addresses[log.ContractID]
+		if !ok {
+			var existed bool
+			var err error
+
+			addr, existed, err = ls.ms.GetContractAddressById(log.ContractID)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to get contract address")
+			}
+
+			if !existed {
+				return nil, errors.Errorf("contract %v not found", log.ContractID)
+			}
+
+			addresses[log.ContractID] = addr
+		}
+
+		clogs = append(clogs, newColdLog(log, addr))
+	}
+
+	return encodeParquet(clogs, ls.conf.Compression)
+}
+
+// GetLogs returns the event logs matched with the log filter from cold storage, along with the
+// max block number covered by cold storage contiguously from the beginning of the filter block
+// range, so that the rest could be queried from the hot store.
+//
+// Returns store.ErrAlreadyPruned if the beginning of the filter block range not offloaded.
+func (ls *LogStore) GetLogs(ctx context.Context, filter store.LogFilter) ([]*store.Log, uint64, error) {
+	objs, err := ls.ms.GetColdLogObjects(filter.BlockFrom, filter.BlockTo)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "failed to get objects from catalog")
+	}
+
+	return ls.getLogs(objs, filter)
+}
+
+// getLogs returns the event logs matched with the log filter from the specified objects, which
+// are sorted by block number and might be overlapped.
+func (ls *LogStore) getLogs(objs []*mysql.ColdLogObject, filter store.LogFilter) ([]*store.Log, uint64, error) {
+	if len(objs) == 0 || objs[0].BnMin > filter.BlockFrom {
+		return nil, 0, store.ErrAlreadyPruned
+	}
+
+	var result []*store.Log
+	var coveredTo uint64
+
+	for next, i := filter.BlockFrom, 0; i < len(objs) && objs[i].BnMin <= next; i++ {
+		if objs[i].BnMax < next { // overlapped with covered range
+			continue
+		}
+
+		logs, err := ls.load(objs[i])
+		if err != nil {
+			return nil, 0, errors.WithMessagef(err, "failed to load object %v", objs[i].Key)
+		}
+
+		for j := range logs {
+			// ignore duplicate event logs of overlapped objects
+			if uint64(logs[j].BlockNumber) >= next && logs[j].matches(&filter) {
+				result = append(result, logs[j].toStoreLog())
+			}
+		}
+
+		if uint64(len(result)) > store.MaxLogLimit {
+			return nil, 0, store.ErrGetLogsResultSetTooLarge
+		}
+
+		coveredTo, next = objs[i].BnMax, objs[i].BnMax+1
+	}
+
+	return result, coveredTo, nil
+}
+
+// load loads the decoded event logs of object from cache or object storage.
+func (ls *LogStore) load(obj *mysql.ColdLogObject) ([]coldLog, error) {
+	if obj.NumLogs == 0 {
+		return nil, nil
+	}
+
+	if val, ok := ls.cache.Get(obj.Key); ok {
+		return val.([]coldLog), nil
+	}
+
+	// object storage is much slower than database, so use a dedicated timeout instead of the
+	// request one, and the decoded object will be cached to serve the retried request anyway.
+	ctx, cancel := context.WithTimeout(context.Background(), ls.conf.Timeout)
+	defer cancel()
+
+	data, err := ls.storage.Get(ctx, obj.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := decodeParquet(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ls.cache.Add(obj.Key, logs)
+
+	return logs, nil
+}
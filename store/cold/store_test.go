@@ -0,0 +1,162 @@
+package cold
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogStore(t *testing.T) *LogStore {
+	ls, err := NewLogStore(Config{
+		Storage:     StorageLocal,
+		Dir:         t.TempDir(),
+		Prefix:      "logs",
+		ChunkBlocks: 100,
+		Compression: "zstd",
+		CacheSize:   4,
+		Timeout:     time.Second,
+	}, nil)
+	assert.NoError(t, err)
+
+	return ls
+}
+
+// putTestObject puts the object of event logs at the specified block numbers into storage.
+func putTestObject(t *testing.T, ls *LogStore, bnMin, bnMax uint64, bns ...uint64) *mysql.ColdLogObject {
+	obj := &mysql.ColdLogObject{
+		BnMin: bnMin, BnMax: bnMax, Key: fmt.Sprintf("logs/%v-%v.parquet", bnMin, bnMax), NumLogs: uint64(len(bns)),
+	}
+
+	if len(bns) == 0 {
+		return obj
+	}
+
+	var logs []coldLog
+	for _, bn := range bns {
+		logs = append(logs, newColdLog(&store.Log{BlockNumber: bn, Epoch: bn, Topic0: "0xa"}, "addr1"))
+	}
+
+	data, err := encodeParquet(logs, ls.conf.Compression)
+	assert.NoError(t, err)
+	assert.NoError(t, ls.storage.Put(context.Background(), obj.Key, data))
+
+	return obj
+}
+
+func blockNumbers(logs []*store.Log) (bns []uint64) {
+	for _, log := range logs {
+		bns = append(bns, log.BlockNumber)
+	}
+
+	return bns
+}
+
+func TestNewLogStoreValidation(t *testing.T) {
+	conf := Config{Storage: StorageLocal, Dir: t.TempDir(), ChunkBlocks: 100, Compression: "zstd", CacheSize: 4}
+
+	_, err := NewLogStore(conf, nil)
+	assert.NoError(t, err)
+
+	badConf := conf
+	badConf.Compression = "lz4"
+	_, err = NewLogStore(badConf, nil)
+	assert.Error(t, err)
+
+	badConf = conf
+	badConf.ChunkBlocks = 0
+	_, err = NewLogStore(badConf, nil)
+	assert.Error(t, err)
+
+	badConf = conf
+	badConf.Storage = "ftp"
+	_, err = NewLogStore(badConf, nil)
+	assert.Error(t, err)
+
+	badConf = conf
+	badConf.Dir = ""
+	_, err = NewLogStore(badConf, nil)
+	assert.Error(t, err)
+}
+
+func TestLogStoreChunkTo(t *testing.T) {
+	ls := newTestLogStore(t)
+
+	assert.Equal(t, uint64(99), ls.chunkTo(0, 1000))
+	assert.Equal(t, uint64(199), ls.chunkTo(150, 1000)) // aligned by block number
+	assert.Equal(t, uint64(120), ls.chunkTo(100, 120))  // partition end
+}
+
+func TestLocalStorage(t *testing.T) {
+	storage, err := newLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = storage.Get(ctx, "logs/1-100.parquet")
+	assert.Equal(t, errObjectNotFound, err)
+
+	assert.NoError(t, storage.Put(ctx, "logs/1-100.parquet", []byte{1, 2, 3}))
+
+	data, err := storage.Get(ctx, "logs/1-100.parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+
+	// no temp file left
+	_, err = os.Stat(filepath.Join(storage.dir, "logs", "1-100.parquet.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLogStoreGetLogs(t *testing.T) {
+	ls := newTestLogStore(t)
+
+	objs := []*mysql.ColdLogObject{
+		putTestObject(t, ls, 1, 100, 50, 100),
+		putTestObject(t, ls, 101, 200, 180),
+		putTestObject(t, ls, 151, 250, 180, 220), // overlapped with the previous one
+		putTestObject(t, ls, 251, 300),           // empty chunk
+		putTestObject(t, ls, 401, 500, 450),      // not contiguous
+	}
+
+	logs, coveredTo, err := ls.getLogs(objs, store.LogFilter{BlockFrom: 1, BlockTo: 500})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), coveredTo)
+	assert.Equal(t, []uint64{50, 100, 180, 220}, blockNumbers(logs))
+
+	logs, coveredTo, err = ls.getLogs(objs[1:3], store.LogFilter{BlockFrom: 190, BlockTo: 230})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(250), coveredTo)
+	assert.Equal(t, []uint64{220}, blockNumbers(logs))
+
+	// beginning of block range not offloaded
+	_, _, err = ls.getLogs(objs[1:], store.LogFilter{BlockFrom: 1, BlockTo: 500})
+	assert.Equal(t, store.ErrAlreadyPruned, err)
+
+	_, _, err = ls.getLogs(nil, store.LogFilter{BlockFrom: 1, BlockTo: 500})
+	assert.Equal(t, store.ErrAlreadyPruned, err)
+}
+
+func TestLogStoreLoadCached(t *testing.T) {
+	ls := newTestLogStore(t)
+	obj := putTestObject(t, ls, 1, 100, 10, 20)
+
+	logs, err := ls.load(obj)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+
+	// served from cache once loaded
+	assert.NoError(t, os.RemoveAll(ls.storage.(*localStorage).dir))
+
+	logs, err = ls.load(obj)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+
+	_, err = ls.load(&mysql.ColdLogObject{Key: "logs/missing.parquet", NumLogs: 1})
+	assert.Equal(t, errObjectNotFound, err)
+}
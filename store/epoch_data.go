@@ -56,6 +56,10 @@ type EpochData struct {
 	// custom extra extentions
 	BlockExts   []*BlockExtra
 	ReceiptExts map[types.Hash]*ReceiptExtra
+
+	// encoded size in bytes of blocks, transactions, receipts and event logs written into store,
+	// which is measured by store at persistence time
+	Size int
 }
 
 func (epoch *EpochData) GetPivotBlock() *types.Block {
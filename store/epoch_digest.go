@@ -0,0 +1,144 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// EpochDigest digest of epoch data persisted in store, which is used to audit the data integrity
+// of store against fullnode.
+type EpochDigest struct {
+	PivotHash   string
+	BlockHashes []string // empty if block disabled
+	TxHashes    []string // hashes of executed transactions, empty if both transaction and receipt disabled
+	NumLogs     int      // number of event logs, 0 if event log disabled
+	// bloom recomputed from the contract addresses and topics of event logs, which is used as a
+	// fingerprint of event logs rather than the bloom of fullnode
+	LogsBloom ethtypes.Bloom
+}
+
+// AddLog adds the event log of the specified contract address and topics into digest.
+func (d *EpochDigest) AddLog(address string, topics ...string) {
+	d.NumLogs++
+	d.LogsBloom.Add([]byte(strings.ToLower(address)))
+
+	for _, topic := range topics {
+		if len(topic) > 0 {
+			d.LogsBloom.Add([]byte(strings.ToLower(topic)))
+		}
+	}
+}
+
+// Diff returns the names of mismatched fields with other digest.
+func (d *EpochDigest) Diff(other *EpochDigest) (fields []string) {
+	if !strings.EqualFold(d.PivotHash, other.PivotHash) {
+		fields = append(fields, "pivotHash")
+	}
+
+	if !equalFoldSlice(d.BlockHashes, other.BlockHashes) {
+		fields = append(fields, "blockHashes")
+	}
+
+	if !equalFoldSlice(d.TxHashes, other.TxHashes) {
+		fields = append(fields, "txHashes")
+	}
+
+	if d.NumLogs != other.NumLogs {
+		fields = append(fields, "numLogs")
+	}
+
+	if d.LogsBloom != other.LogsBloom {
+		fields = append(fields, "logsBloom")
+	}
+
+	return fields
+}
+
+func equalFoldSlice(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for i := range s1 {
+		if !strings.EqualFold(s1[i], s2[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EpochDigestOption options to compute the digest of epoch data in consistent with store.
+type EpochDigestOption struct {
+	// selects the event logs persisted in store (eg., selective sync), nil for all event logs
+	LogSelected func(log *types.Log) bool
+	// earliest block number of event logs retained in store, before which event logs are pruned
+	EarliestLogBlock uint64
+}
+
+// logRetained returns whether the event log of the specified block is persisted in store.
+func (opt *EpochDigestOption) logRetained(bn uint64, log *types.Log) bool {
+	if bn < opt.EarliestLogBlock {
+		return false
+	}
+
+	return opt.LogSelected == nil || opt.LogSelected(log)
+}
+
+// NewEpochDigest computes the digest of epoch data queried from fullnode, in consistent with the
+// epoch data persisted in store of the specified disabler, log selector and retention window.
+func NewEpochDigest(data *EpochData, disabler StoreDisabler, opts ...EpochDigestOption) *EpochDigest {
+	var opt EpochDigestOption
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	digest := EpochDigest{PivotHash: data.GetPivotBlock().Hash.String()}
+
+	for _, block := range data.Blocks {
+		if !disabler.IsChainBlockDisabled() {
+			digest.BlockHashes = append(digest.BlockHashes, block.Hash.String())
+		}
+
+		var bn uint64
+		if block.BlockNumber != nil {
+			bn = block.BlockNumber.ToInt().Uint64()
+		}
+
+		for _, tx := range block.Transactions {
+			receipt := data.Receipts[tx.Hash]
+
+			// unexecuted transactions are not persisted in store
+			if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+				continue
+			}
+
+			if !disabler.IsChainTxnDisabled() || !disabler.IsChainReceiptDisabled() {
+				digest.TxHashes = append(digest.TxHashes, tx.Hash.String())
+			}
+
+			if disabler.IsChainLogDisabled() {
+				continue
+			}
+
+			for i := range receipt.Logs {
+				log := &receipt.Logs[i]
+				if !opt.logRetained(bn, log) {
+					continue
+				}
+
+				topics := make([]string, 0, len(log.Topics))
+				for _, topic := range log.Topics {
+					topics = append(topics, topic.String())
+				}
+
+				digest.AddLog(log.Address.MustGetBase32Address(), topics...)
+			}
+		}
+	}
+
+	return &digest
+}
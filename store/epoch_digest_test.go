@@ -0,0 +1,62 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEpochDigestDiff(t *testing.T) {
+	d1 := EpochDigest{PivotHash: "0xAB", BlockHashes: []string{"0xab", "0xcd"}, TxHashes: []string{"0x01"}}
+	d1.AddLog("cfx:aaa", "0x11", "", "0x22")
+
+	d2 := EpochDigest{PivotHash: "0xab", BlockHashes: []string{"0xAB", "0xCD"}, TxHashes: []string{"0x01"}}
+	d2.AddLog("CFX:AAA", "0x11", "0x22")
+	assert.Empty(t, d1.Diff(&d2))
+
+	d2.TxHashes = append(d2.TxHashes, "0x02")
+	d2.AddLog("cfx:bbb")
+	assert.Equal(t, []string{"txHashes", "numLogs", "logsBloom"}, d1.Diff(&d2))
+}
+
+func TestNewEpochDigestSelectedAndRetained(t *testing.T) {
+	status := hexutil.Uint64(0)
+	blockHash := types.Hash("0x01")
+	selectedAddr := cfxaddress.MustNewFromHex("0x8b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27", 1029)
+	otherAddr := cfxaddress.MustNewFromHex("0x0b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27", 1029)
+
+	data := EpochData{Number: 10, Receipts: make(map[types.Hash]*types.TransactionReceipt)}
+	for bn, txHash := range []types.Hash{"0x11", "0x12"} {
+		tx := types.Transaction{Hash: txHash, BlockHash: &blockHash, Status: &status}
+		data.Blocks = append(data.Blocks, &types.Block{
+			BlockHeader: types.BlockHeader{
+				Hash: types.Hash(fmt.Sprintf("0x0%v", bn)), BlockNumber: types.NewBigInt(uint64(100 + bn)),
+			},
+			Transactions: []types.Transaction{tx},
+		})
+		data.Receipts[txHash] = &types.TransactionReceipt{Logs: []types.Log{
+			{Address: selectedAddr, Topics: []types.Hash{"0xaa"}},
+			{Address: otherAddr, Topics: []types.Hash{"0xbb"}},
+		}}
+	}
+
+	disabler := &storeConfig{disabledDataTypeMapping: map[string]bool{}}
+	assert.Equal(t, 4, NewEpochDigest(&data, disabler).NumLogs)
+
+	selected := func(log *types.Log) bool { return log.Address.Equals(&selectedAddr) }
+	digest := NewEpochDigest(&data, disabler, EpochDigestOption{LogSelected: selected})
+	assert.Equal(t, 2, digest.NumLogs)
+
+	// event logs of block 100 pruned
+	digest = NewEpochDigest(&data, disabler, EpochDigestOption{LogSelected: selected, EarliestLogBlock: 101})
+	assert.Equal(t, 1, digest.NumLogs)
+	assert.Len(t, digest.TxHashes, 2)
+
+	expected := EpochDigest{PivotHash: digest.PivotHash, BlockHashes: digest.BlockHashes, TxHashes: digest.TxHashes}
+	expected.AddLog(selectedAddr.MustGetBase32Address(), "0xaa")
+	assert.Empty(t, expected.Diff(digest))
+}
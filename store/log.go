@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -49,6 +50,15 @@ func (s LogSlice) Len() int           { return len(s) }
 func (s LogSlice) Less(i, j int) bool { return s[i].cmp(s[j]) < 0 }
 func (s LogSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
+var (
+	// cfxLogPool pools the intermediate core space event logs converted from store
+	cfxLogPool = sync.Pool{New: func() interface{} { return new(types.Log) }}
+	// logExtraPool pools the intermediate extension data decoded from event logs in store
+	logExtraPool = sync.Pool{New: func() interface{} { return new(logExtraData) }}
+	// logExtraBufPool pools the buffers to decompress the extension data of event logs in store
+	logExtraBufPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+)
+
 type logExtraData struct {
 	Address             cfxaddress.Address `json:"addr,omitempty"`
 	BlockHash           *types.Hash        `json:"bh,omitempty"`
@@ -90,19 +100,64 @@ func ParseCfxLog(log *types.Log, cid, bn uint64, logExt *LogExtra) *Log {
 }
 
 func (log *Log) ToCfxLog() (*types.Log, *LogExtra) {
-	var extra logExtraData
-	if err := json.Unmarshal(log.Extra, &extra); err != nil {
-		logrus.WithError(err).Error("Failed to unmarshal cfx log from Extra field")
+	cfxLog := new(types.Log)
+	return cfxLog, log.fillCfxLog(cfxLog)
+}
+
+// AcquireCfxLog is similar to `ToCfxLog`, but the returned event log is taken from pool, and should
+// be released by `ReleaseCfxLog` once no longer used, so as to reduce GC pressure on hot path, e.g.,
+// bridging event logs for evm space.
+func (log *Log) AcquireCfxLog() (*types.Log, *LogExtra) {
+	cfxLog := cfxLogPool.Get().(*types.Log)
+	return cfxLog, log.fillCfxLog(cfxLog)
+}
+
+// ReleaseCfxLog puts the event log acquired by `AcquireCfxLog` back into pool, which should not be
+// referenced anymore (including the topics).
+func ReleaseCfxLog(log *types.Log) {
+	*log = types.Log{Topics: log.Topics[:0]}
+	cfxLogPool.Put(log)
+}
+
+// fillCfxLog fills the dst event log with the persisted event log, and reuses the topics of dst
+// if any.
+func (log *Log) fillCfxLog(dst *types.Log) *LogExtra {
+	extra := logExtraPool.Get().(*logExtraData)
+	defer func() {
+		*extra = logExtraData{}
+		logExtraPool.Put(extra)
+	}()
+
+	buf := logExtraBufPool.Get().(*[]byte)
+	defer logExtraBufPool.Put(buf)
+
+	// extension data might be compressed
+	data, err := decompressLogExtraTo(*buf, log.Extra)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to decompress cfx log from Extra field")
+	} else {
+		// keep the decompression buffer for reuse, note all fields are copied during unmarshal
+		if IsLogExtraCompressed(log.Extra) {
+			*buf = data[:0]
+		}
+
+		if err := json.Unmarshal(data, extra); err != nil {
+			logrus.WithError(err).Error("Failed to unmarshal cfx log from Extra field")
+		}
 	}
 
-	var topics []types.Hash
+	topics := dst.Topics[:0]
 	for _, v := range []string{log.Topic0, log.Topic1, log.Topic2, log.Topic3} {
 		if len(v) > 0 {
 			topics = append(topics, types.Hash(v))
 		}
 	}
 
-	return &types.Log{
+	if len(topics) == 0 {
+		topics = nil
+	}
+
+	*dst = types.Log{
 		Address:             extra.Address,
 		Topics:              topics,
 		Data:                extra.Data,
@@ -112,5 +167,7 @@ func (log *Log) ToCfxLog() (*types.Log, *LogExtra) {
 		TransactionIndex:    extra.TransactionIndex,
 		LogIndex:            types.NewBigInt(log.LogIndex),
 		TransactionLogIndex: extra.TransactionLogIndex,
-	}, extra.EthExtra
+	}
+
+	return extra.EthExtra
 }
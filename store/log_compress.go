@@ -0,0 +1,89 @@
+package store
+
+import (
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// LogCompression algorithm to compress the extension data (e.g., data and topics) of event logs
+// persisted in store.
+type LogCompression string
+
+const (
+	LogCompressionNone   LogCompression = ""
+	LogCompressionSnappy LogCompression = "snappy"
+	LogCompressionZstd   LogCompression = "zstd"
+)
+
+// Compressed extension data is prefixed with a zero byte followed by the algorithm byte, which
+// never conflicts with the plain json data that always starts with `{`.
+const (
+	logCompressionMagic       byte = 0x00
+	logCompressionSnappyByte  byte = 0x01
+	logCompressionZstdByte    byte = 0x02
+	logCompressionHeaderBytes      = 2
+)
+
+var (
+	// zstd encoder and decoder are safe for concurrent use with EncodeAll and DecodeAll
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// Validate checks if the compression algorithm is supported.
+func (c LogCompression) Validate() error {
+	switch c {
+	case LogCompressionNone, LogCompressionSnappy, LogCompressionZstd:
+		return nil
+	}
+
+	return errors.Errorf("unsupported log compression %v", c)
+}
+
+// Compress compresses the extension data with header prefixed, or returns the data as it is if no
+// compression or already compressed.
+func (c LogCompression) Compress(data []byte) []byte {
+	if len(data) == 0 || IsLogExtraCompressed(data) {
+		return data
+	}
+
+	switch c {
+	case LogCompressionSnappy:
+		return append([]byte{logCompressionMagic, logCompressionSnappyByte}, snappy.Encode(nil, data)...)
+	case LogCompressionZstd:
+		dst := []byte{logCompressionMagic, logCompressionZstdByte}
+		return zstdEncoder.EncodeAll(data, dst)
+	default:
+		return data
+	}
+}
+
+// IsLogExtraCompressed checks if the extension data is compressed.
+func IsLogExtraCompressed(data []byte) bool {
+	return len(data) >= logCompressionHeaderBytes && data[0] == logCompressionMagic
+}
+
+// DecompressLogExtra decompresses the extension data, or returns the data as it is if not compressed.
+func DecompressLogExtra(data []byte) ([]byte, error) {
+	return decompressLogExtraTo(nil, data)
+}
+
+// decompressLogExtraTo decompresses the extension data into the dst buffer if large enough, or returns
+// the data as it is if not compressed.
+func decompressLogExtraTo(dst, data []byte) ([]byte, error) {
+	if !IsLogExtraCompressed(data) {
+		return data, nil
+	}
+
+	payload := data[logCompressionHeaderBytes:]
+
+	switch data[1] {
+	case logCompressionSnappyByte:
+		return snappy.Decode(dst[:cap(dst)], payload)
+	case logCompressionZstdByte:
+		return zstdDecoder.DecodeAll(payload, dst[:0])
+	default:
+		return nil, errors.Errorf("unknown log compression algorithm %v", data[1])
+	}
+}
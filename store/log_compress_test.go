@@ -0,0 +1,32 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogCompression(t *testing.T) {
+	data := []byte(`{"addr":"cfx:acc7uawf5ubtnmezvhu9dhc6sghea0403y2dgpyfjp","data":"0x0000000000000000000000000000000000000000000000000000000000000001"}`)
+
+	for _, c := range []LogCompression{LogCompressionSnappy, LogCompressionZstd} {
+		compressed := c.Compress(data)
+		assert.True(t, IsLogExtraCompressed(compressed))
+
+		// already compressed
+		assert.Equal(t, compressed, c.Compress(compressed))
+
+		decompressed, err := DecompressLogExtra(compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	// no compression
+	assert.Equal(t, data, LogCompressionNone.Compress(data))
+
+	decompressed, err := DecompressLogExtra(data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	assert.Error(t, LogCompression("gzip").Validate())
+}
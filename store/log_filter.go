@@ -1,8 +1,7 @@
 package store
 
 import (
-	"time"
-
+	"github.com/Conflux-Chain/confura/util/errcode"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
@@ -14,24 +13,21 @@ import (
 const (
 	// max number of event logs to return
 	MaxLogLimit = uint64(10000)
-
-	// max timeout to get event logs from store
-	TimeoutGetLogs = 3 * time.Second
 )
 
-var ( // common errors
-	ErrGetLogsQuerySetTooLarge = errors.New(
+var ( // common errors mapped to stable JSON-RPC error codes
+	ErrGetLogsQuerySetTooLarge = errcode.New(errcode.LimitExceeded, errors.New(
 		"query set is too large, please narrow down your filter condition",
-	)
+	))
 
-	ErrGetLogsResultSetTooLarge = errors.Errorf(
+	ErrGetLogsResultSetTooLarge = errcode.New(errcode.LimitExceeded, errors.Errorf(
 		"result set to be queried is too large with more than %v logs, %v",
 		MaxLogLimit, "please narrow down your filter condition",
-	)
+	))
 
-	ErrGetLogsTimeout = errors.Errorf(
-		"query timeout with duration exceeds %v(s)", TimeoutGetLogs,
-	)
+	ErrGetLogsTimeout = errcode.New(errcode.QueryTimeout, errors.New(
+		"query timeout, please narrow down your filter condition",
+	))
 )
 
 var ( // Log filter constants
@@ -93,7 +89,7 @@ func ParseCfxLogFilter(blockFrom, blockTo uint64, filter *types.LogFilter) LogFi
 		BlockFrom: blockFrom,
 		BlockTo:   blockTo,
 		Contracts: newVariadicValueByAddress(filter.Address),
-		Topics:    vvs,
+		Topics:    trimWildcardTopics(vvs),
 		original:  filter,
 	}
 }
@@ -133,6 +129,16 @@ func ParseEthLogFilterRaw(blockFrom, blockTo uint64, filter *web3Types.FilterQue
 		BlockFrom: blockFrom,
 		BlockTo:   blockTo,
 		Contracts: NewVariadicValue(contracts...),
-		Topics:    vvs,
+		Topics:    trimWildcardTopics(vvs),
+	}
+}
+
+// trimWildcardTopics removes the trailing wildcard topics, which match any event log. Note, wildcard
+// topics in the middle are kept as position placeholders.
+func trimWildcardTopics(topics []VariadicValue) []VariadicValue {
+	for len(topics) > 0 && topics[len(topics)-1].IsNull() {
+		topics = topics[:len(topics)-1]
 	}
+
+	return topics
 }
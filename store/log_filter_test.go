@@ -0,0 +1,30 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEthLogFilterTopics(t *testing.T) {
+	t0, t1, t2 := common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")
+
+	filter := ParseEthLogFilterRaw(1, 10, &types.FilterQuery{
+		Topics: [][]common.Hash{{t2, t0, t2}, nil, {t1}, nil},
+	})
+
+	// trailing wildcard trimmed, while the middle one kept as placeholder
+	assert.Equal(t, 3, len(filter.Topics))
+	assert.True(t, filter.Topics[1].IsNull())
+
+	// OR-list deduplicated and sorted
+	multiple, ok := filter.Topics[0].FlatMultiple()
+	assert.True(t, ok)
+	assert.Equal(t, []string{t0.Hex(), t2.Hex()}, multiple)
+
+	single, ok := filter.Topics[2].Single()
+	assert.True(t, ok)
+	assert.Equal(t, t1.Hex(), single)
+}
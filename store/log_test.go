@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLogExtra = []byte(`{"addr":"cfx:acc7uawf5ubtnmezvhu9dhc6sghea0403y2dgpyfjp","th":"0x00000000000000000000000000000000000000000000000000000000000000c1","ti":"0x0","tli":"0x1","data":"0x0000000000000000000000000000000000000000000000000000000000000001"}`)
+
+func newTestLog(compression LogCompression) *Log {
+	return &Log{
+		Epoch:    100,
+		Topic0:   "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		Topic1:   "0x0000000000000000000000000000000000000000000000000000000000000001",
+		LogIndex: 1,
+		Extra:    compression.Compress(testLogExtra),
+	}
+}
+
+func TestLogAcquireCfxLog(t *testing.T) {
+	for _, c := range []LogCompression{LogCompressionNone, LogCompressionSnappy, LogCompressionZstd} {
+		log := newTestLog(c)
+		expected, _ := log.ToCfxLog()
+
+		// acquire twice to reuse the released event log
+		for i := 0; i < 2; i++ {
+			pooled, _ := log.AcquireCfxLog()
+			assert.Equal(t, expected, pooled)
+			ReleaseCfxLog(pooled)
+		}
+	}
+}
+
+func BenchmarkLogToCfxLog(b *testing.B) {
+	log := newTestLog(LogCompressionSnappy)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.ToCfxLog()
+	}
+}
+
+func BenchmarkLogAcquireCfxLog(b *testing.B) {
+	log := newTestLog(LogCompressionSnappy)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfxLog, _ := log.AcquireCfxLog()
+		ReleaseCfxLog(cfxLog)
+	}
+}
@@ -1,11 +1,14 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	stdLog "log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	gosql "github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
@@ -25,6 +28,22 @@ var allModels = []interface{}{
 	&epochBlockMap{},
 	&bnPartition{},
 	&NodeRoute{},
+	&LogSpanOverride{},
+	&ScheduledJob{},
+	&JobRun{},
+	&ColdLogObject{},
+	&Webhook{},
+	&WebhookDelivery{},
+	&ApiKey{},
+	&ApiUsage{},
+	&StreamEvent{},
+	&TokenTransfer{},
+	&TokenBalance{},
+	&AddressTx{},
+	&InternalTx{},
+	&ContractCreation{},
+	&ExternallyOwnedAccount{},
+	&schemaMigration{},
 }
 
 // Config represents the mysql configurations to open a database instance.
@@ -42,10 +61,53 @@ type Config struct {
 	MaxOpenConns    int           `default:"10"`
 	MaxIdleConns    int           `default:"10"`
 
+	// max execution time of read-only statements, 0 for unlimited
+	StatementTimeout time.Duration
+
+	// read replica for RPC handlers
+	Replica ReplicaConfig
+
+	// write path configurations for sync
+	Write WriteConfig
+
+	// read path configurations to query event logs
+	LogQuery LogQueryConfig
+
 	AddressIndexedLogEnabled    bool   `default:"true"`
 	AddressIndexedLogPartitions uint32 `default:"100"`
 
 	MaxBnRangedArchiveLogPartitions uint32 `default:"5"`
+
+	// algorithm (`snappy` or `zstd`) to compress the extension data of event logs, empty for no compression
+	LogCompression store.LogCompression
+
+	// whether to index the new partitions of event logs by topic0 and block number, so that event
+	// logs filtered by topic0 could be queried efficiently over a large block range
+	LogTopicIndexEnabled bool
+
+	// retention of event log partitions ranged by block number
+	LogRetention LogRetentionConfig
+
+	// selective sync to persist the event logs of interest only
+	LogSelection LogSelectionConfig
+}
+
+// WriteConfig write path configurations to persist the synced epoch data, where rows of each table
+// are inserted by multi-row INSERT statements of bounded batch size.
+type WriteConfig struct {
+	// max number of epochs committed per transaction, so as to bound the transaction size of batch
+	// persistence, 0 for unlimited
+	MaxTxnEpochs int `default:"100"`
+	// whether to cache the prepared statements for multi-row inserts, which saves the statement parsing
+	// of batch persistence at the cost of server side statements
+	PrepareStmt bool
+}
+
+// LogQueryConfig read path configurations to query event logs.
+type LogQueryConfig struct {
+	// max number of concurrent queries for event logs of multiple contract addresses within a filter,
+	// 1 for sequential queries
+	Concurrency int `default:"4"`
 }
 
 func mustNewConfigFromViper(key string) *Config {
@@ -56,6 +118,10 @@ func mustNewConfigFromViper(key string) *Config {
 		return &Config{}
 	}
 
+	if err := cfg.LogCompression.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid log compression for db store")
+	}
+
 	if len(cfg.Dsn) == 0 {
 		return &cfg
 	}
@@ -110,6 +176,13 @@ func (config *Config) MustOpenOrCreate(option StoreOption) *MysqlStore {
 				WithField("partitions", config.AddressIndexedLogPartitions).
 				Fatal("Failed to create address indexed log tables")
 		}
+
+		// tables are created by the latest table models, so no schema migration needs to be applied
+		if err := markMigrationsApplied(db); err != nil {
+			logrus.WithError(err).Fatal("Failed to mark schema migrations applied")
+		}
+	} else {
+		warnPendingMigrations(db)
 	}
 
 	if sqlDb, err := db.DB(); err != nil {
@@ -122,10 +195,37 @@ func (config *Config) MustOpenOrCreate(option StoreOption) *MysqlStore {
 
 	logrus.Info("MySQL database initialized")
 
-	return mustNewStore(db, config, option)
+	ms := mustNewStore(db, config, option)
+
+	// read queries of RPC handlers are routed to the read replicas if configured
+	if len(config.Replica.dsns()) > 0 {
+		resolver := mustNewReplicaResolver(config)
+		if err := db.Use(resolver); err != nil {
+			logrus.WithError(err).Fatal("Failed to register mysql read replica resolver")
+		}
+
+		go resolver.run(context.Background())
+
+		// share the pruner with the primary store, which observes partitions and prunes on the primary
+		ms.reader = newStoreWithPruner(resolver.reader(db), config, option, ms.pruner)
+		logrus.WithField("replicas", len(resolver.replicas)).Info("MySQL read replica initialized")
+	}
+
+	return ms
 }
 
 func (config *Config) mustNewDB(database string) *gorm.DB {
+	// refer to https://github.com/go-sql-driver/mysql#dsn-data-source-name
+	dsn := fmt.Sprintf("%v:%v@tcp(%v)/%v?parseTime=true", config.Username, config.Password, config.Host, database)
+	if database == config.Database && len(config.Dsn) > 0 {
+		dsn = config.Dsn
+	}
+
+	return config.mustOpenDsn(dsn)
+}
+
+// newGormLogger creates gorm logger with log level mapped from logrus.
+func newGormLogger() gormLogger.Interface {
 	logrusLogLevel := logrus.GetLevel()
 	gLogLevel := gormLogger.Warn
 
@@ -139,7 +239,7 @@ func (config *Config) mustNewDB(database string) *gorm.DB {
 	}
 
 	// create gorm logger by customizing the default logger
-	gLogger := gormLogger.New(
+	return gormLogger.New(
 		stdLog.New(os.Stdout, "\r\n", stdLog.LstdFlags), // io writer
 		gormLogger.Config{
 			SlowThreshold:             time.Millisecond * 200, // slow SQL threshold (200ms)
@@ -148,15 +248,12 @@ func (config *Config) mustNewDB(database string) *gorm.DB {
 			Colorful:                  true,                   // use colorful print
 		},
 	)
+}
 
-	// refer to https://github.com/go-sql-driver/mysql#dsn-data-source-name
-	dsn := fmt.Sprintf("%v:%v@tcp(%v)/%v?parseTime=true", config.Username, config.Password, config.Host, database)
-	if database == config.Database && len(config.Dsn) > 0 {
-		dsn = config.Dsn
-	}
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: gLogger,
+// mustOpenDsn opens the database of the specified DSN, with the configured statement timeout.
+func (config *Config) mustOpenDsn(dsn string) *gorm.DB {
+	db, err := gorm.Open(mysql.Open(withStatementTimeout(dsn, config.StatementTimeout)), &gorm.Config{
+		Logger: newGormLogger(),
 	})
 
 	if err != nil {
@@ -166,6 +263,21 @@ func (config *Config) mustNewDB(database string) *gorm.DB {
 	return db
 }
 
+// withStatementTimeout sets the `max_execution_time` system variable (in milliseconds) for read-only
+// statements of the connections via DSN.
+func withStatementTimeout(dsn string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+
+	return fmt.Sprintf("%v%vmax_execution_time=%v", dsn, sep, timeout.Milliseconds())
+}
+
 func (config *Config) mustCreateDatabaseIfAbsent() bool {
 	db := config.mustNewDB("")
 	if mysqlDb, err := db.DB(); err != nil {
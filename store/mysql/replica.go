@@ -0,0 +1,272 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	gosql "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ctxKey string
+
+// context key to route read queries to replica
+const ctxKeyReadReplica = ctxKey("Infura-DB-Read-Replica")
+
+// ReplicaConfig read replica configurations, by which read queries of RPC handlers are routed to
+// the replicas while writes (eg., sync) are still on the primary.
+type ReplicaConfig struct {
+	// DSN of read replica, empty to disable
+	Dsn string
+	// DSNs of additional read replicas, among which read queries are balanced in round robin
+	Dsns []string
+
+	ConnMaxLifetime time.Duration `default:"3m"`
+	MaxOpenConns    int           `default:"10"`
+	MaxIdleConns    int           `default:"10"`
+
+	// max number of epochs a replica could lag behind the primary, otherwise read queries are
+	// routed to other replicas or fall back to the primary
+	MaxLag uint64
+	// interval to check the replication lag
+	LagCheckInterval time.Duration `default:"1s"`
+}
+
+// dsns returns the DSNs of all configured read replicas.
+func (conf *ReplicaConfig) dsns() (result []string) {
+	if len(conf.Dsn) > 0 {
+		result = append(result, conf.Dsn)
+	}
+
+	for _, dsn := range conf.Dsns {
+		if len(dsn) > 0 {
+			result = append(result, dsn)
+		}
+	}
+
+	return result
+}
+
+// readReplica read replica along with its replication lag state.
+type readReplica struct {
+	name string // address of replica, which is used as metric label without credentials exposed
+	db   *gorm.DB
+
+	lagging int32 // 1 if lagging behind, which is also the initial state before lag checked
+}
+
+func (r *readReplica) isLagging() bool {
+	return atomic.LoadInt32(&r.lagging) != 0
+}
+
+func (r *readReplica) setLagging(lagging bool) {
+	var v int32
+	if lagging {
+		v = 1
+	}
+
+	atomic.StoreInt32(&r.lagging, v)
+}
+
+// replicaResolver gorm plugin that routes the read queries out of transaction to the read replicas,
+// unless the replica lags behind the max epoch of primary more than configured, which is the max
+// epoch that queries might require. Read queries fall back to the primary if all replicas lag behind.
+type replicaResolver struct {
+	conf     ReplicaConfig
+	primary  *gorm.DB
+	replicas []*readReplica
+
+	next uint32 // round robin cursor of replicas
+}
+
+func mustNewReplicaResolver(config *Config) *replicaResolver {
+	resolver := &replicaResolver{conf: config.Replica}
+
+	for i, dsn := range config.Replica.dsns() {
+		db := config.mustOpenDsn(dsn)
+
+		if sqlDb, err := db.DB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to init mysql read replica")
+		} else {
+			sqlDb.SetConnMaxLifetime(config.Replica.ConnMaxLifetime)
+			sqlDb.SetMaxOpenConns(config.Replica.MaxOpenConns)
+			sqlDb.SetMaxIdleConns(config.Replica.MaxIdleConns)
+		}
+
+		resolver.replicas = append(resolver.replicas, &readReplica{
+			name: replicaName(dsn, i), db: db, lagging: 1,
+		})
+	}
+
+	return resolver
+}
+
+// replicaName returns the address of replica from DSN, or the ordinal if DSN malformed.
+func replicaName(dsn string, index int) string {
+	if conf, err := gosql.ParseDSN(dsn); err == nil && len(conf.Addr) > 0 {
+		return conf.Addr
+	}
+
+	return fmt.Sprintf("replica%v", index)
+}
+
+// Name implements the gorm.Plugin interface.
+func (r *replicaResolver) Name() string {
+	return "confura:replica"
+}
+
+// Initialize implements the gorm.Plugin interface.
+func (r *replicaResolver) Initialize(db *gorm.DB) error {
+	r.primary = db
+
+	if err := db.Callback().Query().Before("gorm:query").Register("confura:replica_query", r.resolve); err != nil {
+		return err
+	}
+
+	return db.Callback().Row().Before("gorm:row").Register("confura:replica_row", r.resolve)
+}
+
+// reader returns the db session of which the read queries are routed to replicas if not lagging behind.
+func (r *replicaResolver) reader(db *gorm.DB) *gorm.DB {
+	return db.WithContext(context.WithValue(context.Background(), ctxKeyReadReplica, true))
+}
+
+func (r *replicaResolver) resolve(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+
+	if read, _ := db.Statement.Context.Value(ctxKeyReadReplica).(bool); !read {
+		return
+	}
+
+	// queries in transaction or with locking clause (eg., `FOR UPDATE`) stay on the primary
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return
+	}
+
+	replica := r.pick()
+	metrics.Registry.Store.ReplicaHit("mysql").Mark(replica != nil)
+
+	if replica != nil {
+		db.Statement.ConnPool = replica.db.Statement.ConnPool
+	}
+}
+
+// pick returns the next replica not lagging behind in round robin, or nil if all replicas lag behind.
+func (r *replicaResolver) pick() *readReplica {
+	n := uint32(len(r.replicas))
+	if n == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint32(&r.next, 1)
+	for i := uint32(0); i < n; i++ {
+		if replica := r.replicas[(start+i)%n]; !replica.isLagging() {
+			return replica
+		}
+	}
+
+	return nil
+}
+
+// run checks the replication lag periodically until context done.
+func (r *replicaResolver) run(ctx context.Context) {
+	ticker := time.NewTicker(r.conf.LagCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		r.checkLag()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkLag checks the replication lag of each replica against the max epoch of primary.
+func (r *replicaResolver) checkLag() {
+	primaryMax, ok, err := maxEpochOf(r.primary)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get max epoch of primary db to check replication lag")
+
+		for _, replica := range r.replicas {
+			replica.setLagging(true)
+		}
+
+		return
+	}
+
+	for _, replica := range r.replicas {
+		if !ok { // no data in primary
+			replica.setLagging(false)
+			continue
+		}
+
+		lag, err := replicationLag(replica.db, primaryMax)
+		if err != nil {
+			logrus.WithError(err).WithField("replica", replica.name).Info(
+				"Failed to get max epoch of read replica to check replication lag",
+			)
+			replica.setLagging(true)
+			continue
+		}
+
+		metrics.Registry.Store.ReplicaLag("mysql", replica.name).Update(int64(lag))
+		replica.setLagging(lag > r.conf.MaxLag)
+	}
+}
+
+// replicationLag returns the number of epochs the replica lags behind the max epoch of primary.
+func replicationLag(replica *gorm.DB, primaryMax uint64) (uint64, error) {
+	replicaMax, ok, err := maxEpochOf(replica)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return primaryMax + 1, nil
+	}
+
+	if primaryMax > replicaMax {
+		return primaryMax - replicaMax, nil
+	}
+
+	return 0, nil
+}
+
+// maxEpochOf returns the max epoch synced into the specified db.
+func maxEpochOf(db *gorm.DB) (uint64, bool, error) {
+	var maxEpoch sql.NullInt64
+
+	if err := db.Model(&epochBlockMap{}).Select("MAX(epoch)").Find(&maxEpoch).Error; err != nil {
+		return 0, false, err
+	}
+
+	if !maxEpoch.Valid {
+		return 0, false, nil
+	}
+
+	return uint64(maxEpoch.Int64), true, nil
+}
+
+// ReadReplica returns the store of which the read queries (out of transaction) are routed to the read
+// replicas if configured and not lagging behind, or the store itself if read replica not configured.
+func (ms *MysqlStore) ReadReplica() *MysqlStore {
+	if ms.reader != nil {
+		return ms.reader
+	}
+
+	return ms
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type testConnPool struct {
+	gorm.ConnPool
+	name string
+}
+
+func newTestReplica(name string, lagging bool) *readReplica {
+	replica := &readReplica{
+		name: name,
+		db:   &gorm.DB{Statement: &gorm.Statement{ConnPool: &testConnPool{name: name}}},
+	}
+	replica.setLagging(lagging)
+
+	return replica
+}
+
+func resolveConnPool(r *replicaResolver, read bool) gorm.ConnPool {
+	ctx := context.Background()
+	if read {
+		ctx = context.WithValue(ctx, ctxKeyReadReplica, true)
+	}
+
+	db := &gorm.DB{Statement: &gorm.Statement{
+		Context:  ctx,
+		ConnPool: &testConnPool{name: "primary"},
+		Clauses:  map[string]clause.Clause{},
+	}}
+	r.resolve(db)
+
+	return db.Statement.ConnPool
+}
+
+func TestReplicaResolverRouting(t *testing.T) {
+	r1, r2 := newTestReplica("r1", false), newTestReplica("r2", false)
+	r := &replicaResolver{replicas: []*readReplica{r1, r2}}
+
+	// writes or queries out of reader stay on the primary
+	assert.Equal(t, "primary", resolveConnPool(r, false).(*testConnPool).name)
+
+	// balanced among healthy replicas
+	routed := map[string]int{}
+	for i := 0; i < 4; i++ {
+		routed[resolveConnPool(r, true).(*testConnPool).name]++
+	}
+	assert.Equal(t, map[string]int{"r1": 2, "r2": 2}, routed)
+
+	// lagging replica skipped
+	r1.setLagging(true)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "r2", resolveConnPool(r, true).(*testConnPool).name)
+	}
+
+	// fall back to primary if all replicas lag behind
+	r2.setLagging(true)
+	assert.Equal(t, "primary", resolveConnPool(r, true).(*testConnPool).name)
+
+	// routed to replica again once caught up
+	r1.setLagging(false)
+	assert.Equal(t, "r1", resolveConnPool(r, true).(*testConnPool).name)
+}
+
+func TestReplicaConfigDsns(t *testing.T) {
+	conf := ReplicaConfig{Dsn: "u:p@tcp(10.0.0.1:3306)/db", Dsns: []string{"", "u:p@tcp(10.0.0.2:3306)/db"}}
+	assert.Equal(t, []string{"u:p@tcp(10.0.0.1:3306)/db", "u:p@tcp(10.0.0.2:3306)/db"}, conf.dsns())
+
+	assert.Empty(t, (&ReplicaConfig{}).dsns())
+
+	assert.Equal(t, "10.0.0.1:3306", replicaName(conf.Dsn, 0))
+	assert.Equal(t, "replica1", replicaName("malformed", 1))
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"io"
 	"sort"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/store"
 	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -34,6 +36,16 @@ type MysqlStore struct {
 	*RateLimitStore
 	*VirtualFilterLogStore
 	*NodeRouteStore
+	*LogSpanOverrideStore
+	*JobStore
+	*ColdLogObjectStore
+	*WebhookStore
+	*ApiKeyStore
+	*StreamEventStore
+	*TokenStore
+	*AddressTxStore
+	*InternalTxStore
+	*ContractCreationStore
 	ls   *logStore
 	ails *AddressIndexedLogStore
 	bcls *bigContractLogStore
@@ -41,17 +53,45 @@ type MysqlStore struct {
 
 	// config
 	config *Config
+	// selector of event logs persisted in store, nil if selective sync disabled
+	selector *logSelector
 	// store chaindata disabler
 	disabler store.StoreDisabler
 	// store pruner
 	pruner *storePruner
+	// epoch data observers (optional)
+	observers []EpochDataObserver
+	// store of which read queries are routed to read replica (optional)
+	reader *MysqlStore
+	// serializes pop and backfill of epoch ranges, so that missing epochs are not backfilled
+	// while popping epochs during chain reorg
+	epochRangeMu sync.Mutex
 }
 
 func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
-	pruner := newStorePruner(db)
+	ms := newStoreWithPruner(db, config, option, newStorePruner(db))
+	ms.pruner.internalTxs = ms.InternalTxStore
+
+	return ms
+}
+
+func newStoreWithPruner(db *gorm.DB, config *Config, option StoreOption, pruner *storePruner) *MysqlStore {
 	cs := NewContractStore(db)
 	ebms := newEpochBlockMapStore(db, config)
 	ails := NewAddressIndexedLogStore(db, cs, config.AddressIndexedLogPartitions)
+	ls := newLogStore(db, cs, ebms, pruner.newBnPartitionObsChan)
+	bcls := newBigContractLogStore(db, cs, ebms, ails, pruner.newBnPartitionObsChan)
+
+	// compress the extension data of event logs if configured
+	ails.compression = config.LogCompression
+	ls.compression = config.LogCompression
+	bcls.compression = config.LogCompression
+	ls.topicIndexEnabled = config.LogTopicIndexEnabled
+	bcls.topicIndexEnabled = config.LogTopicIndexEnabled
+
+	// persist the event logs of interest only if selective sync enabled
+	selector := newLogSelector(config.LogSelection)
+	ails.selector, ls.selector, bcls.selector = selector, selector, selector
 
 	return &MysqlStore{
 		baseStore:             newBaseStore(db),
@@ -63,11 +103,22 @@ func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
 		RateLimitStore:        NewRateLimitStore(db),
 		VirtualFilterLogStore: NewVirtualFilterLogStore(db),
 		NodeRouteStore:        NewNodeRouteStore(db),
-		ls:                    newLogStore(db, cs, ebms, pruner.newBnPartitionObsChan),
-		bcls:                  newBigContractLogStore(db, cs, ebms, ails, pruner.newBnPartitionObsChan),
+		LogSpanOverrideStore:  NewLogSpanOverrideStore(db),
+		JobStore:              NewJobStore(db),
+		ColdLogObjectStore:    NewColdLogObjectStore(db),
+		WebhookStore:          NewWebhookStore(db),
+		ApiKeyStore:           NewApiKeyStore(db),
+		StreamEventStore:      NewStreamEventStore(db),
+		TokenStore:            NewTokenStore(db),
+		AddressTxStore:        NewAddressTxStore(db),
+		InternalTxStore:       NewInternalTxStore(db),
+		ContractCreationStore: NewContractCreationStore(db),
+		ls:                    ls,
+		bcls:                  bcls,
 		ails:                  ails,
 		cs:                    cs,
 		config:                config,
+		selector:              selector,
 		disabler:              option.Disabler,
 		pruner:                pruner,
 	}
@@ -130,7 +181,60 @@ func (ms *MysqlStore) Pushn(dataSlice []*store.EpochData) error {
 		return errors.New("failed to prepare epoch block map partition")
 	}
 
-	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+	// commit epoch data in bounded transactions, each of which advances the sync checkpoint
+	for i, chunk := range ms.splitTxnEpochs(dataSlice) {
+		err := ms.pushTxn(chunk, logPartition, contract2BnPartitions)
+		if err == nil {
+			continue
+		}
+
+		// revert the committed chunks, so that the epoch data slice could be pushed again as a whole
+		if i > 0 {
+			if perr := ms.Popn(dataSlice[0].Number); perr != nil {
+				logrus.WithError(perr).WithField("epoch", dataSlice[0].Number).Error(
+					"Failed to revert partially pushed epoch data",
+				)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// splitTxnEpochs splits the epoch data slice into chunks, each of which is committed in a transaction
+// of bounded size.
+func (ms *MysqlStore) splitTxnEpochs(dataSlice []*store.EpochData) (chunks [][]*store.EpochData) {
+	maxTxnEpochs := ms.config.Write.MaxTxnEpochs
+	if maxTxnEpochs <= 0 {
+		return [][]*store.EpochData{dataSlice}
+	}
+
+	for len(dataSlice) > maxTxnEpochs {
+		chunks = append(chunks, dataSlice[:maxTxnEpochs])
+		dataSlice = dataSlice[maxTxnEpochs:]
+	}
+
+	return append(chunks, dataSlice)
+}
+
+// pushTxn saves the epoch data along with the sync checkpoint in a single transaction.
+func (ms *MysqlStore) pushTxn(
+	dataSlice []*store.EpochData, logPartition bnPartition, contract2BnPartitions map[uint64]bnPartition,
+) error {
+	db := ms.baseStore.db
+	if ms.config.Write.PrepareStmt {
+		// prepared statements are cached on the db, and reused by the following transactions
+		db = db.Session(&gorm.Session{PrepareStmt: true})
+	}
+
+	return db.Transaction(func(dbTx *gorm.DB) error {
+		// measured from scratch in case of retry after failure
+		for _, data := range dataSlice {
+			data.Size = 0
+		}
+
 		if !ms.disabler.IsChainBlockDisabled() {
 			// save blocks
 			if err := ms.blockStore.Add(dbTx, dataSlice); err != nil {
@@ -174,12 +278,29 @@ func (ms *MysqlStore) Pushn(dataSlice []*store.EpochData) error {
 		}
 
 		// save epoch to block mapping data
-		return ms.epochBlockMapStore.Add(dbTx, dataSlice)
+		if err := ms.epochBlockMapStore.Add(dbTx, dataSlice); err != nil {
+			return errors.WithMessage(err, "failed to save epoch to block mapping data")
+		}
+
+		for _, observer := range ms.observers {
+			if err := observer.OnEpochDataPushed(dbTx, dataSlice); err != nil {
+				return errors.WithMessage(err, "failed to observe pushed epoch data")
+			}
+		}
+
+		// advance sync checkpoint along with the epoch data
+		lastData := dataSlice[len(dataSlice)-1]
+		pivotHash := string(lastData.GetPivotBlock().Hash)
+
+		return ms.confStore.updateSyncCheckpoint(dbTx, lastData.Number, pivotHash)
 	})
 }
 
 // Popn pops multiple epoch data from database.
 func (ms *MysqlStore) Popn(epochUntil uint64) error {
+	ms.epochRangeMu.Lock()
+	defer ms.epochRangeMu.Unlock()
+
 	maxEpoch, ok, err := ms.MaxEpoch()
 	if err != nil {
 		return errors.WithMessage(err, "failed to get max epoch")
@@ -189,6 +310,14 @@ func (ms *MysqlStore) Popn(epochUntil uint64) error {
 		return nil
 	}
 
+	// pivot hash of the new latest epoch after popped, which is used to rewind sync checkpoint
+	var newLatestPivotHash string
+	if epochUntil > 0 {
+		if newLatestPivotHash, _, err = ms.PivotHash(epochUntil - 1); err != nil {
+			return errors.WithMessage(err, "failed to get pivot hash")
+		}
+	}
+
 	updater := metrics.Registry.Store.Pop("mysql")
 	defer updater.Update()
 
@@ -232,15 +361,58 @@ func (ms *MysqlStore) Popn(epochUntil uint64) error {
 			return errors.WithMessage(err, "failed to remove epoch to block mapping data")
 		}
 
+		// revert internal transactions traced behind, which is a no-op if never indexed
+		if err := ms.InternalTxStore.RevertInternalTxs(dbTx, epochUntil); err != nil {
+			return errors.WithMessage(err, "failed to revert internal transactions")
+		}
+
+		for _, observer := range ms.observers {
+			if err := observer.OnEpochDataPopped(dbTx, epochUntil, maxEpoch); err != nil {
+				return errors.WithMessage(err, "failed to observe popped epoch data")
+			}
+		}
+
+		// rewind sync checkpoint along with the epoch data
+		if err := ms.confStore.rewindSyncCheckpoint(dbTx, epochUntil, newLatestPivotHash); err != nil {
+			return errors.WithMessage(err, "failed to rewind sync checkpoint")
+		}
+
 		// pop is always due to pivot chain switch, update reorg version too
-		return ms.confStore.createOrUpdateReorgVersion(dbTx)
+		reverted := citypes.RangeUint64{From: epochUntil, To: maxEpoch}
+		return ms.confStore.createOrUpdateReorgVersion(dbTx, reverted)
 	})
 }
 
+// GetReceiptsByEpoch returns all the receipts of the specified epoch, or `store.ErrNotFound`
+// if the epoch is not synced into store yet.
+func (ms *MysqlStore) GetReceiptsByEpoch(ctx context.Context, epochNumber uint64) ([]*store.TransactionReceipt, error) {
+	_, existed, err := ms.PivotHash(epochNumber)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get epoch pivot hash")
+	}
+
+	if !existed {
+		return nil, store.ErrNotFound
+	}
+
+	return ms.txStore.getReceiptsByEpoch(ctx, epochNumber)
+}
+
 func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter) ([]*store.Log, error) {
 	updater := metrics.Registry.Store.GetLogs()
 	defer updater.Update()
 
+	// event logs out of retention window are regarded as pruned, even though some of them
+	// (eg., address indexed event logs) might still exist in store.
+	earliest, ok, err := ms.EarliestRetainedBlock()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get earliest retained block")
+	}
+
+	if ok && storeFilter.BlockFrom < earliest {
+		return nil, errors.WithMessagef(store.ErrAlreadyPruned, "earliest retained block %v", earliest)
+	}
+
 	contracts := storeFilter.Contracts.ToSlice()
 
 	// if address not specified, query from universal event log table partition
@@ -249,13 +421,60 @@ func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter)
 		return ms.ls.GetLogs(ctx, storeFilter)
 	}
 
-	filter := LogFilter{
-		BlockFrom: storeFilter.BlockFrom,
-		BlockTo:   storeFilter.BlockTo,
-		Topics:    storeFilter.Topics,
+	// convert contract addresses to ids, and skip the contracts without any event log
+	cids := make(map[string]uint64, len(contracts))
+	for _, addr := range contracts {
+		cid, exists, err := ms.cs.GetContractIdByAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			cids[addr] = cid
+		}
+	}
+
+	result, err := ms.getContractsLogs(ctx, cids, storeFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// merge && sort log result
+	sort.Sort(store.LogSlice(result))
+
+	return result, nil
+}
+
+// LogsQueryIndex describes the table and index chosen to query event logs.
+type LogsQueryIndex struct {
+	Table    string `json:"table"`
+	Index    string `json:"index"`
+	Contract string `json:"contract,omitempty"`
+}
+
+// ExplainLogs returns the tables and indexes chosen to query event logs for the specified
+// filter without executing the query, in the same way as `GetLogs`.
+func (ms *MysqlStore) ExplainLogs(storeFilter store.LogFilter) ([]LogsQueryIndex, error) {
+	// event logs out of retention window are regarded as pruned, even though some of them
+	// (eg., address indexed event logs) might still exist in store.
+	earliest, ok, err := ms.EarliestRetainedBlock()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get earliest retained block")
+	}
+
+	if ok && storeFilter.BlockFrom < earliest {
+		return nil, errors.WithMessagef(store.ErrAlreadyPruned, "earliest retained block %v", earliest)
+	}
+
+	contracts := storeFilter.Contracts.ToSlice()
+
+	// if address not specified, query from universal event log table partition
+	// ranged by block number.
+	if len(contracts) == 0 {
+		return ms.ls.explain(storeFilter)
 	}
 
-	var result []*store.Log
+	var result []LogsQueryIndex
 	for _, addr := range contracts {
 		// convert contract address to id
 		cid, exists, err := ms.cs.GetContractIdByAddress(addr)
@@ -263,69 +482,78 @@ func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter)
 			return nil, err
 		}
 
+		// no event logs of the contract at all
 		if !exists {
 			continue
 		}
 
-		// check if the contract is a big contract or not
 		isBigContract, err := ms.bcls.IsBigContract(cid)
 		if err != nil {
 			return nil, err
 		}
 
-		// if the contract is a big contract, find the event logs from seperate table.
-		if isBigContract {
-			logs, err := ms.bcls.GetContractLogs(ctx, cid, storeFilter)
-			if err != nil {
-				return nil, err
-			}
-
-			result = append(result, logs...)
-
-			// check log count
-			if len(result) > int(store.MaxLogLimit) {
-				return nil, store.ErrGetLogsResultSetTooLarge
-			}
-
+		if !isBigContract {
+			result = append(result, LogsQueryIndex{
+				Table:    ms.ails.GetPartitionedTableName(addr),
+				Index:    "idx_cid_bn",
+				Contract: addr,
+			})
 			continue
 		}
 
-		// check timeout before query
-		select {
-		case <-ctx.Done():
-			return nil, store.ErrGetLogsTimeout
-		default:
-		}
-
-		// query from address indexed logs
-		addrFilter := AddressIndexedLogFilter{
-			LogFilter:  filter,
-			ContractId: cid,
-		}
-
-		logs, err := ms.ails.GetAddressIndexedLogs(addrFilter, addr)
+		indexes, err := ms.bcls.explain(cid, storeFilter)
 		if err != nil {
 			return nil, err
 		}
 
-		// convert to common store log
-		for _, v := range logs {
-			result = append(result, (*store.Log)(v))
+		for i := range indexes {
+			indexes[i].Contract = addr
 		}
 
-		// check log count
-		if len(result) > int(store.MaxLogLimit) {
-			return nil, store.ErrGetLogsResultSetTooLarge
-		}
+		result = append(result, indexes...)
 	}
 
-	// merge && sort log result
-	sort.Sort(store.LogSlice(result))
-
 	return result, nil
 }
 
+// ScanLogs returns all event logs within the specified block range without any result set limit.
+func (ms *MysqlStore) ScanLogs(ctx context.Context, bnFrom, bnTo uint64) ([]*store.Log, error) {
+	return ms.ls.ScanLogs(ctx, bnFrom, bnTo)
+}
+
+// GetLogStatistics returns the statistics of persisted universal event logs.
+func (ms *MysqlStore) GetLogStatistics() (LogStatistics, error) {
+	return ms.ls.Statistics()
+}
+
+// GetContractLogCount returns the number of persisted event logs of the specified contract,
+// which is not accurate due to cache but good enough for estimation.
+func (ms *MysqlStore) GetContractLogCount(contract string) (uint64, bool, error) {
+	cid, ok, err := ms.cs.GetContractIdByAddress(contract)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	if val, ok := ms.cs.cacheById.Get(cid); ok {
+		return uint64(val.(*Contract).LogCount), true, nil
+	}
+
+	c, ok, err := ms.cs.GetContractById(cid)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	return uint64(c.LogCount), true, nil
+}
+
 // Prune prune data from db store.
 func (ms *MysqlStore) Prune() {
 	go ms.pruner.schedulePrune(ms.config)
 }
+
+// PruneOnce prunes data from db store once, which is used by job scheduler rather than
+// pruning periodically by `Prune`.
+func (ms *MysqlStore) PruneOnce(ctx context.Context) error {
+	ms.pruner.prune(ms.config)
+	return ms.pruner.retain(ms.config)
+}
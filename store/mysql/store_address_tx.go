@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// max number of address transactions to query at a time
+	MaxAddressTxQueryLimit = 1000
+
+	defaultBatchSizeAddressTxInsert = 500
+)
+
+// AddressTx transaction indexed by the relevant address, namely the sender, the recipient or the
+// created contract. Note, only one record is indexed for self transfer. Addresses are persisted in
+// lower case hex.
+type AddressTx struct {
+	ID      uint64
+	Address string `gorm:"size:42;not null;index:idx_addr_bn,priority:1"`
+	Bn      uint64 `gorm:"not null;index:idx_bn;index:idx_addr_bn,priority:2"`
+	TxHash  string `gorm:"size:66;not null"`
+	TxIndex uint64 `gorm:"not null"`
+	From    string `gorm:"size:42;not null"`
+	To      string `gorm:"size:42;not null"` // address of created contract for contract creation
+	Value   string `gorm:"size:78;not null"` // decimal
+	Status  uint64 `gorm:"not null"`         // 1 for success and 0 for failure
+}
+
+func (AddressTx) TableName() string {
+	return "address_txs"
+}
+
+// AddressTxFilter filter to query address transactions, which are returned in reverse order of id.
+type AddressTxFilter struct {
+	Address   string
+	Direction string // `in`, `out` or empty for both
+	FromBlock uint64
+	ToBlock   uint64 // 0 for unlimited
+	Cursor    uint64 // id of the last returned transaction, 0 to query from the latest
+	Limit     int
+}
+
+type AddressTxStore struct {
+	*baseStore
+}
+
+func NewAddressTxStore(db *gorm.DB) *AddressTxStore {
+	return &AddressTxStore{baseStore: newBaseStore(db)}
+}
+
+// AddAddressTxs persists the address transactions within the db transaction of synced epoch data.
+func (as *AddressTxStore) AddAddressTxs(dbTx *gorm.DB, txs []*AddressTx) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	err := dbTx.CreateInBatches(txs, defaultBatchSizeAddressTxInsert).Error
+	return errors.WithMessage(err, "failed to add address transactions")
+}
+
+// RemoveAddressTxs removes the address transactions since the specified block number within the db
+// transaction of popped epoch data.
+func (as *AddressTxStore) RemoveAddressTxs(dbTx *gorm.DB, bnFrom uint64) error {
+	err := dbTx.Where("bn >= ?", bnFrom).Delete(&AddressTx{}).Error
+	return errors.WithMessage(err, "failed to remove address transactions")
+}
+
+// ReplaceAddressTxs replaces the address transactions of blocks `[bnFrom, bnTo]`, which are identified
+// by the specified block hashes, so that address transactions could be rebuilt idempotently. Note, it
+// returns `ErrBlocksNotCanonical` if the blocks are not the canonical ones synced into store any more.
+func (as *AddressTxStore) ReplaceAddressTxs(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*AddressTx,
+) error {
+	return as.db.Transaction(func(dbTx *gorm.DB) error {
+		matched, err := matchPivotHashes(dbTx, bnFrom, bnTo, blockHashes)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			return ErrBlocksNotCanonical
+		}
+
+		if err := dbTx.Where("bn BETWEEN ? AND ?", bnFrom, bnTo).Delete(&AddressTx{}).Error; err != nil {
+			return errors.WithMessage(err, "failed to remove address transactions")
+		}
+
+		return as.AddAddressTxs(dbTx, txs)
+	})
+}
+
+// ResetAddressTxs removes the address transactions since the specified block number, so that they
+// could be rebuilt from scratch.
+func (as *AddressTxStore) ResetAddressTxs(bnFrom uint64) error {
+	return as.RemoveAddressTxs(as.db, bnFrom)
+}
+
+// GetAddressTxs returns the address transactions matched with the filter in reverse order of id.
+func (as *AddressTxStore) GetAddressTxs(filter AddressTxFilter) ([]*AddressTx, error) {
+	db := as.db.Where("address = ?", filter.Address)
+
+	switch filter.Direction {
+	case "in":
+		db = db.Where("`to` = ?", filter.Address)
+	case "out":
+		db = db.Where("`from` = ?", filter.Address)
+	}
+
+	if filter.FromBlock > 0 {
+		db = db.Where("bn >= ?", filter.FromBlock)
+	}
+
+	if filter.ToBlock > 0 {
+		db = db.Where("bn <= ?", filter.ToBlock)
+	}
+
+	if filter.Cursor > 0 {
+		db = db.Where("id < ?", filter.Cursor)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxAddressTxQueryLimit {
+		limit = MaxAddressTxQueryLimit
+	}
+
+	var txs []*AddressTx
+	err := db.Order("id DESC").Limit(limit).Find(&txs).Error
+
+	return txs, err
+}
@@ -0,0 +1,153 @@
+package mysql
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApiKey API key issued to tenant, by which requests are tagged with the tenant.
+type ApiKey struct {
+	ID        uint32
+	Key       string     `gorm:"unique;size:64;not null"`
+	Tenant    string     `gorm:"index;size:128;not null"`
+	Memo      string     `gorm:"size:256"`
+	RevokedAt *time.Time // nil if not revoked
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
+
+// ApiUsage accounted requests of tenant per method per day.
+type ApiUsage struct {
+	ID       uint64
+	Tenant   string `gorm:"size:128;not null;uniqueIndex:idx_tenant_date_method,priority:1"`
+	Date     string `gorm:"size:10;not null;uniqueIndex:idx_tenant_date_method,priority:2"` // in UTC
+	Method   string `gorm:"size:128;not null;uniqueIndex:idx_tenant_date_method,priority:3"`
+	Requests uint64 `gorm:"not null;default:0"`
+	Errors   uint64 `gorm:"not null;default:0"`
+}
+
+func (ApiUsage) TableName() string {
+	return "api_usages"
+}
+
+type ApiKeyStore struct {
+	*baseStore
+}
+
+func NewApiKeyStore(db *gorm.DB) *ApiKeyStore {
+	return &ApiKeyStore{baseStore: newBaseStore(db)}
+}
+
+// AddApiKey issues a new random API key for the tenant.
+func (as *ApiKeyStore) AddApiKey(tenantName, memo string) (*ApiKey, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, errors.WithMessage(err, "failed to generate random key")
+	}
+
+	apiKey := &ApiKey{
+		Key:    hex.EncodeToString(buf[:]),
+		Tenant: tenantName,
+		Memo:   memo,
+	}
+
+	if err := as.db.Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// RevokeApiKey revokes the API key, and returns false if not found or already revoked.
+func (as *ApiKeyStore) RevokeApiKey(key string) (bool, error) {
+	res := as.db.Model(&ApiKey{}).
+		Where("`key` = ? AND revoked_at IS NULL", key).
+		Update("revoked_at", time.Now())
+
+	return res.RowsAffected > 0, res.Error
+}
+
+// ListApiKeys returns API keys of the specified tenant, or all tenants if empty.
+func (as *ApiKeyStore) ListApiKeys(tenantName string) (apiKeys []*ApiKey, err error) {
+	db := as.db
+	if len(tenantName) > 0 {
+		db = db.Where("tenant = ?", tenantName)
+	}
+
+	err = db.Order("id").Find(&apiKeys).Error
+	return
+}
+
+// GetApiKey implements `tenant.Store` interface.
+func (as *ApiKeyStore) GetApiKey(key string) (*tenant.ApiKey, bool, error) {
+	var apiKey ApiKey
+
+	exists, err := as.exists(&apiKey, "`key` = ?", key)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	return &tenant.ApiKey{
+		Key:     apiKey.Key,
+		Tenant:  apiKey.Tenant,
+		Revoked: apiKey.RevokedAt != nil,
+	}, true, nil
+}
+
+// AddUsages implements `tenant.Store` interface, which accumulates the usages in batch.
+func (as *ApiKeyStore) AddUsages(usages []*tenant.Usage) error {
+	records := make([]*ApiUsage, 0, len(usages))
+	for _, u := range usages {
+		records = append(records, &ApiUsage{
+			Tenant:   u.Tenant,
+			Date:     u.Date,
+			Method:   u.Method,
+			Requests: u.Requests,
+			Errors:   u.Errors,
+		})
+	}
+
+	return as.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests": gorm.Expr("requests + VALUES(requests)"),
+			"errors":   gorm.Expr("errors + VALUES(errors)"),
+		}),
+	}).CreateInBatches(records, 200).Error
+}
+
+// LoadUsages returns the usages of the specified tenant (or all tenants if empty) within the date
+// range (inclusive), ordered by tenant, date and method.
+func (as *ApiKeyStore) LoadUsages(tenantName, fromDate, toDate string) (usages []*ApiUsage, err error) {
+	db := as.db.Where("date BETWEEN ? AND ?", fromDate, toDate)
+	if len(tenantName) > 0 {
+		db = db.Where("tenant = ?", tenantName)
+	}
+
+	err = db.Order("tenant, date, method").Find(&usages).Error
+	return
+}
+
+// SumUsages implements `tenant.Store` interface, which sums up the usages per tenant and method
+// within the date range (inclusive).
+func (as *ApiKeyStore) SumUsages(fromDate, toDate string) ([]*tenant.Usage, error) {
+	var usages []*tenant.Usage
+
+	err := as.db.Model(&ApiUsage{}).
+		Select("tenant, method, SUM(requests) AS requests, SUM(errors) AS errors").
+		Where("date BETWEEN ? AND ?", fromDate, toDate).
+		Group("tenant, method").
+		Scan(&usages).Error
+
+	return usages, err
+}
@@ -156,7 +156,9 @@ func (bs *blockStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
 				blockExt = data.BlockExts[i]
 			}
 
-			blocks = append(blocks, newBlock(block, i == pivotIndex, blockExt))
+			blk := newBlock(block, i == pivotIndex, blockExt)
+			blocks = append(blocks, blk)
+			data.Size += len(blk.RawData) + len(blk.Extra)
 		}
 	}
 
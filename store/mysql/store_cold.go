@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"gorm.io/gorm"
+)
+
+// ColdLogObject event logs of some block range offloaded to cold storage (eg., S3) from the
+// partitions to be pruned.
+type ColdLogObject struct {
+	ID uint64
+	// min block number
+	BnMin uint64 `gorm:"not null;index:idx_bn,priority:1"`
+	// max block number
+	BnMax uint64 `gorm:"not null;index:idx_bn,priority:2"`
+	// object key in cold storage
+	Key string `gorm:"unique;size:255;not null"`
+	// number of event logs
+	NumLogs uint64 `gorm:"not null"`
+	// object size in bytes
+	Size uint64 `gorm:"not null"`
+
+	CreatedAt time.Time
+}
+
+func (ColdLogObject) TableName() string {
+	return "cold_log_objects"
+}
+
+// ColdLogObjectStore catalog of event log objects offloaded to cold storage.
+type ColdLogObjectStore struct {
+	*baseStore
+}
+
+func NewColdLogObjectStore(db *gorm.DB) *ColdLogObjectStore {
+	return &ColdLogObjectStore{baseStore: newBaseStore(db)}
+}
+
+// AddColdLogObject adds event log object offloaded to cold storage.
+func (cls *ColdLogObjectStore) AddColdLogObject(obj *ColdLogObject) error {
+	return cls.db.Create(obj).Error
+}
+
+// HasColdLogObject checks if the event log object already offloaded to cold storage.
+func (cls *ColdLogObjectStore) HasColdLogObject(key string) (bool, error) {
+	return cls.exists(&ColdLogObject{}, "`key` = ?", key)
+}
+
+// GetColdLogObjects returns the event log objects overlapped with the specified block range
+// in ascending order of block number.
+func (cls *ColdLogObjectStore) GetColdLogObjects(bnFrom, bnTo uint64) ([]*ColdLogObject, error) {
+	var objs []*ColdLogObject
+
+	err := cls.db.Where("bn_min <= ? AND bn_max >= ?", bnTo, bnFrom).
+		Order("bn_min ASC").
+		Find(&objs).Error
+
+	return objs, err
+}
+
+// LogPartition block number ranged partition of universal event logs.
+type LogPartition struct {
+	Table string // partition table name
+	BnMin uint64 // min block number
+	BnMax uint64 // max block number
+}
+
+// LogArchiver archives event logs of partition before pruned, eg., offload to cold storage.
+type LogArchiver interface {
+	ArchiveLogPartition(partition LogPartition) error
+}
+
+// SetLogArchiver sets the archiver to archive universal event log partitions before pruned,
+// and partitions will not be pruned if failed to archive.
+func (ms *MysqlStore) SetLogArchiver(archiver LogArchiver) {
+	ms.pruner.partitionedStore.archiver = archiver
+}
+
+// ScanLogPartition returns all event logs within the specified block range from the universal
+// event log partition table without any result set limit.
+func (ms *MysqlStore) ScanLogPartition(
+	ctx context.Context, table string, bnFrom, bnTo uint64,
+) ([]*store.Log, error) {
+	var logs []*log
+
+	err := ms.baseStore.db.WithContext(ctx).Table(table).
+		Where("bn BETWEEN ? AND ?", bnFrom, bnTo).
+		Order("bn ASC, log_index ASC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*store.Log, 0, len(logs))
+	for _, v := range logs {
+		result = append(result, (*store.Log)(v))
+	}
+
+	return result, nil
+}
+
+// GetContractAddressById returns contract address by id.
+func (ms *MysqlStore) GetContractAddressById(cid uint64) (string, bool, error) {
+	return ms.cs.GetContractAddressById(cid)
+}
@@ -50,6 +50,9 @@ func (partition bnPartition) IsInitial() bool {
 type bnPartitionedStore struct {
 	*baseStore
 	partitionedStore
+
+	// archiver to archive universal event log partitions before pruned (optional)
+	archiver LogArchiver
 }
 
 func newBnPartitionedStore(db *gorm.DB) *bnPartitionedStore {
@@ -277,6 +280,36 @@ func (bnps *bnPartitionedStore) expandBnRange(dbTx *gorm.DB, entity string, part
 	return dbTx.Model(&bnPartition{}).Where("id = ?", lastPart.ID).Updates(updates).Error
 }
 
+// coveringPartition returns the single entity partition of which the block number range fully covers
+// the specified block range, or false if none or the block range spans multiple partitions.
+func (bnps *bnPartitionedStore) coveringPartition(entity string, bnRange types.RangeUint64) (*bnPartition, bool, error) {
+	var partitions []*bnPartition
+
+	db := bnps.db.Where("entity = ?", entity).
+		Where("bn_min <= ? AND bn_max >= ?", bnRange.From, bnRange.To)
+	if err := db.Find(&partitions).Error; err != nil {
+		return nil, false, err
+	}
+
+	if len(partitions) != 1 {
+		return nil, false, nil
+	}
+
+	return partitions[0], true, nil
+}
+
+// deltaUpdateCountOf delta updates the accumulated data size for the specified entity partition, which
+// is not necessarily the latest one.
+func (bnps *bnPartitionedStore) deltaUpdateCountOf(dbTx *gorm.DB, partition *bnPartition, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	return dbTx.Model(&bnPartition{}).
+		Where("id = ?", partition.ID).
+		UpdateColumn("count", gorm.Expr("count + ?", delta)).Error
+}
+
 // shrinkBnRange shrink block number range from the latest entity partition.
 // Note the shrunk until block number will not be accounted for the entity range.
 func (bnps *bnPartitionedStore) shrinkBnRange(dbTx *gorm.DB, entity string, bn uint64) ([]*bnPartition, bool, error) {
@@ -471,6 +504,10 @@ func (bnps *bnPartitionedStore) pruneArchivePartitions(
 			break
 		}
 
+		if err := bnps.archivePartition(entity, tabler, i); err != nil {
+			return prunedPartitions, errors.WithMessagef(err, "failed to archive partition %d", i)
+		}
+
 		partition, err := bnps.shrinkPartition(entity, tabler, int(i))
 		if err != nil {
 			return prunedPartitions, errors.WithMessagef(err, "failed to shrink partition %d", i)
@@ -481,3 +518,25 @@ func (bnps *bnPartitionedStore) pruneArchivePartitions(
 
 	return prunedPartitions, nil
 }
+
+// archivePartition archives the universal event log partition before pruned if archiver configured.
+func (bnps *bnPartitionedStore) archivePartition(entity string, tabler schema.Tabler, partitionIndex uint32) error {
+	if bnps.archiver == nil || entity != bnPartitionedLogEntity {
+		return nil
+	}
+
+	partition, err := bnps.getPartitionByIndex(entity, partitionIndex)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get partition")
+	}
+
+	if !partition.BnMin.Valid || !partition.BnMax.Valid { // no entity data on partition
+		return nil
+	}
+
+	return bnps.archiver.ArchiveLogPartition(LogPartition{
+		Table: bnps.getPartitionedTableName(tabler, partition.Index),
+		BnMin: uint64(partition.BnMin.Int64),
+		BnMax: uint64(partition.BnMax.Int64),
+	})
+}
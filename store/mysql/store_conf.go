@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
 	"github.com/pkg/errors"
@@ -15,7 +16,12 @@ import (
 )
 
 const (
-	MysqlConfKeyReorgVersion = "reorg.version"
+	MysqlConfKeyReorgVersion   = "reorg.version"
+	MysqlConfKeyReorgHistory   = "reorg.history"
+	MysqlConfKeySyncCheckpoint = "sync.checkpoint"
+
+	// max number of the latest reorg records to keep
+	maxReorgHistory = 100
 
 	// pre-defined ratelimit strategy config key prefix
 	RateLimitStrategyConfKeyPrefix   = "ratelimit.strategy."
@@ -69,7 +75,11 @@ func (cs *confStore) LoadConfig(confNames ...string) (map[string]interface{}, er
 }
 
 func (cs *confStore) StoreConfig(confName string, confVal interface{}) error {
-	return cs.db.Clauses(clause.OnConflict{
+	return cs.storeConfig(cs.db, confName, confVal)
+}
+
+func (cs *confStore) storeConfig(db *gorm.DB, confName string, confVal interface{}) error {
+	return db.Clauses(clause.OnConflict{
 		Columns: []clause.Column{{Name: "name"}},
 		DoUpdates: clause.Assignments(map[string]interface{}{
 			"value":      confVal,
@@ -88,30 +98,196 @@ func (cs *confStore) DeleteConfig(confName string) (bool, error) {
 
 // reorg config
 
+// ReorgRecord records the depth and affected epoch range of a reorg.
+type ReorgRecord struct {
+	Version   int       // reorg version after the reorg
+	Depth     uint64    // number of reverted epochs
+	From      uint64    // first reverted epoch
+	To        uint64    // last reverted epoch
+	Timestamp time.Time // time when the reorg was handled
+}
+
 func (cs *confStore) GetReorgVersion() (int, error) {
+	return cs.getReorgVersion(cs.db)
+}
+
+func (cs *confStore) getReorgVersion(db *gorm.DB) (int, error) {
 	var result conf
-	exists, err := cs.exists(&result, "name = ?", MysqlConfKeyReorgVersion)
+	err := db.Where("name = ?", MysqlConfKeyReorgVersion).First(&result).Error
+	if cs.IsRecordNotFound(err) {
+		return 0, nil
+	}
+
 	if err != nil {
 		return 0, err
 	}
 
-	if !exists {
-		return 0, nil
+	return strconv.Atoi(result.Value)
+}
+
+// GetReorgHistory returns the latest reorg records in ascending order of version.
+func (cs *confStore) GetReorgHistory() ([]ReorgRecord, error) {
+	return cs.getReorgHistory(cs.db)
+}
+
+func (cs *confStore) getReorgHistory(db *gorm.DB) ([]ReorgRecord, error) {
+	var result conf
+	err := db.Where("name = ?", MysqlConfKeyReorgHistory).First(&result).Error
+	if cs.IsRecordNotFound(err) {
+		return nil, nil
 	}
 
-	return strconv.Atoi(result.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []ReorgRecord
+	if err := json.Unmarshal([]byte(result.Value), &history); err != nil {
+		return nil, errors.WithMessage(err, "malformed reorg history")
+	}
+
+	return history, nil
 }
 
 // thread unsafe
-func (cs *confStore) createOrUpdateReorgVersion(dbTx *gorm.DB) error {
-	version, err := cs.GetReorgVersion()
+func (cs *confStore) createOrUpdateReorgVersion(dbTx *gorm.DB, reverted citypes.RangeUint64) error {
+	version, err := cs.getReorgVersion(dbTx)
 	if err != nil {
 		return err
 	}
 
-	newVersion := strconv.Itoa(version + 1)
+	version++
+
+	if err := cs.storeConfig(dbTx, MysqlConfKeyReorgVersion, strconv.Itoa(version)); err != nil {
+		return err
+	}
+
+	// record the reorg along with the new version
+	history, err := cs.getReorgHistory(dbTx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load reorg history, which will be reset")
+		history = nil
+	}
+
+	history = append(history, ReorgRecord{
+		Version:   version,
+		Depth:     reverted.To - reverted.From + 1,
+		From:      reverted.From,
+		To:        reverted.To,
+		Timestamp: time.Now(),
+	})
+
+	if len(history) > maxReorgHistory {
+		history = history[len(history)-maxReorgHistory:]
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal reorg history")
+	}
+
+	return cs.storeConfig(dbTx, MysqlConfKeyReorgHistory, string(data))
+}
+
+// sync checkpoint config
+
+// SyncCheckpoint sync progress checkpoint, which is persisted transactionally along with
+// the epoch data so as to recover from crash without any gap or duplicate.
+type SyncCheckpoint struct {
+	// latest synchronized epoch and its pivot hash
+	Epoch     uint64
+	PivotHash string
+	// latest epoch verified against fullnode, epochs after which are still within
+	// the pending reorg window.
+	VerifiedEpoch uint64
+}
+
+// PendingReorgWindow returns the epoch range which is not verified against fullnode yet.
+func (cp *SyncCheckpoint) PendingReorgWindow() (citypes.RangeUint64, bool) {
+	if cp.VerifiedEpoch >= cp.Epoch {
+		return citypes.RangeUint64{}, false
+	}
+
+	return citypes.RangeUint64{From: cp.VerifiedEpoch + 1, To: cp.Epoch}, true
+}
+
+// LoadSyncCheckpoint loads the sync checkpoint if any.
+func (cs *confStore) LoadSyncCheckpoint() (*SyncCheckpoint, bool, error) {
+	return cs.loadSyncCheckpoint(cs.db)
+}
+
+func (cs *confStore) loadSyncCheckpoint(db *gorm.DB) (*SyncCheckpoint, bool, error) {
+	var cfg conf
+	err := db.Where("name = ?", MysqlConfKeySyncCheckpoint).First(&cfg).Error
+	if cs.IsRecordNotFound(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cp SyncCheckpoint
+	if err := json.Unmarshal([]byte(cfg.Value), &cp); err != nil {
+		return nil, false, errors.WithMessage(err, "malformed sync checkpoint")
+	}
+
+	return &cp, true, nil
+}
+
+// MarkSyncVerified marks epochs until the specified epoch as verified against fullnode.
+func (cs *confStore) MarkSyncVerified(epoch uint64) error {
+	return cs.db.Transaction(func(dbTx *gorm.DB) error {
+		cp, ok, err := cs.loadSyncCheckpoint(dbTx)
+		if err != nil || !ok {
+			return err
+		}
+
+		cp.VerifiedEpoch = epoch
+		if cp.VerifiedEpoch > cp.Epoch {
+			cp.VerifiedEpoch = cp.Epoch
+		}
+
+		return cs.storeSyncCheckpoint(dbTx, cp)
+	})
+}
+
+// thread unsafe
+func (cs *confStore) updateSyncCheckpoint(dbTx *gorm.DB, epoch uint64, pivotHash string) error {
+	cp, ok, err := cs.loadSyncCheckpoint(dbTx)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		cp = &SyncCheckpoint{}
+	}
+
+	cp.Epoch, cp.PivotHash = epoch, pivotHash
+	if cp.VerifiedEpoch > epoch { // verified epochs reverted
+		cp.VerifiedEpoch = epoch
+	}
+
+	return cs.storeSyncCheckpoint(dbTx, cp)
+}
+
+// thread unsafe
+func (cs *confStore) rewindSyncCheckpoint(dbTx *gorm.DB, epochUntil uint64, pivotHash string) error {
+	if epochUntil == 0 { // all epoch data popped
+		return dbTx.Delete(&conf{}, "name = ?", MysqlConfKeySyncCheckpoint).Error
+	}
+
+	return cs.updateSyncCheckpoint(dbTx, epochUntil-1, pivotHash)
+}
+
+// thread unsafe
+func (cs *confStore) storeSyncCheckpoint(dbTx *gorm.DB, cp *SyncCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal sync checkpoint")
+	}
 
-	return cs.StoreConfig(MysqlConfKeyReorgVersion, newVersion)
+	return cs.storeConfig(dbTx, MysqlConfKeySyncCheckpoint, string(data))
 }
 
 // access control config
@@ -177,7 +177,8 @@ func (cs *ContractStore) UpdateContractStats(
 	dbTx *gorm.DB, cid uint64, countDelta int, latestUpdatedEpoch uint64,
 ) error {
 	updates := map[string]interface{}{
-		"latest_updated_epoch": latestUpdatedEpoch,
+		// never rewinds, since event logs might be backfilled for some missing epoch
+		"latest_updated_epoch": gorm.Expr("GREATEST(latest_updated_epoch, ?)", latestUpdatedEpoch),
 	}
 
 	if countDelta != 0 {
@@ -0,0 +1,158 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultBatchSizeContractCreationInsert = 500
+
+// ContractCreation creation of contract deployed by transaction. Note, contracts created by internal
+// calls (eg., factory contracts) are not indexed. Addresses are persisted in lower case hex.
+type ContractCreation struct {
+	ID      uint64
+	Address string `gorm:"size:42;not null;uniqueIndex"`
+	Bn      uint64 `gorm:"not null;index"`
+	TxHash  string `gorm:"size:66;not null"`
+	TxIndex uint64 `gorm:"not null"`
+	Creator string `gorm:"size:42;not null"`
+}
+
+func (ContractCreation) TableName() string {
+	return "contract_creations"
+}
+
+// ExternallyOwnedAccount account which has ever sent transaction, and thus never has contract code.
+type ExternallyOwnedAccount struct {
+	ID      uint64
+	Address string `gorm:"size:42;not null;uniqueIndex"`
+	Bn      uint64 `gorm:"not null;index"` // block number of the first transaction sent
+}
+
+func (ExternallyOwnedAccount) TableName() string {
+	return "eoa_accounts"
+}
+
+type ContractCreationStore struct {
+	*baseStore
+}
+
+func NewContractCreationStore(db *gorm.DB) *ContractCreationStore {
+	return &ContractCreationStore{baseStore: newBaseStore(db)}
+}
+
+// AddContractCreations persists the contract creations and externally owned accounts within the db
+// transaction of synced epoch data, where the earliest ones are kept if already existed.
+func (ccs *ContractCreationStore) AddContractCreations(
+	dbTx *gorm.DB, creations []*ContractCreation, eoas []*ExternallyOwnedAccount,
+) error {
+	dbTx = dbTx.Clauses(clause.OnConflict{DoNothing: true})
+
+	if len(creations) > 0 {
+		if err := dbTx.CreateInBatches(creations, defaultBatchSizeContractCreationInsert).Error; err != nil {
+			return errors.WithMessage(err, "failed to add contract creations")
+		}
+	}
+
+	if len(eoas) > 0 {
+		if err := dbTx.CreateInBatches(eoas, defaultBatchSizeContractCreationInsert).Error; err != nil {
+			return errors.WithMessage(err, "failed to add externally owned accounts")
+		}
+	}
+
+	return nil
+}
+
+// RemoveContractCreations removes the contract creations and externally owned accounts since the
+// specified block number within the db transaction of popped epoch data.
+func (ccs *ContractCreationStore) RemoveContractCreations(dbTx *gorm.DB, bnFrom uint64) error {
+	if err := dbTx.Where("bn >= ?", bnFrom).Delete(&ContractCreation{}).Error; err != nil {
+		return errors.WithMessage(err, "failed to remove contract creations")
+	}
+
+	err := dbTx.Where("bn >= ?", bnFrom).Delete(&ExternallyOwnedAccount{}).Error
+	return errors.WithMessage(err, "failed to remove externally owned accounts")
+}
+
+// ReplaceContractCreations replaces the contract creations and externally owned accounts of blocks
+// `[bnFrom, bnTo]`, which are identified by the specified block hashes, so that they could be rebuilt
+// idempotently. Note, it returns `ErrBlocksNotCanonical` if the blocks are not the canonical ones synced
+// into store any more.
+func (ccs *ContractCreationStore) ReplaceContractCreations(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string,
+	creations []*ContractCreation, eoas []*ExternallyOwnedAccount,
+) error {
+	return ccs.db.Transaction(func(dbTx *gorm.DB) error {
+		matched, err := matchPivotHashes(dbTx, bnFrom, bnTo, blockHashes)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			return ErrBlocksNotCanonical
+		}
+
+		if err := dbTx.Where("bn BETWEEN ? AND ?", bnFrom, bnTo).Delete(&ContractCreation{}).Error; err != nil {
+			return errors.WithMessage(err, "failed to remove contract creations")
+		}
+
+		err = dbTx.Where("bn BETWEEN ? AND ?", bnFrom, bnTo).Delete(&ExternallyOwnedAccount{}).Error
+		if err != nil {
+			return errors.WithMessage(err, "failed to remove externally owned accounts")
+		}
+
+		if len(creations) > 0 {
+			err = dbTx.Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(creations, defaultBatchSizeContractCreationInsert).Error
+			if err != nil {
+				return errors.WithMessage(err, "failed to add contract creations")
+			}
+		}
+
+		if len(eoas) > 0 {
+			// blocks might be rebuilt in no particular order, so keep the earliest one
+			err = dbTx.Clauses(clause.OnConflict{
+				DoUpdates: clause.Assignments(map[string]interface{}{"bn": gorm.Expr("LEAST(bn, VALUES(bn))")}),
+			}).CreateInBatches(eoas, defaultBatchSizeContractCreationInsert).Error
+			if err != nil {
+				return errors.WithMessage(err, "failed to add externally owned accounts")
+			}
+		}
+
+		return nil
+	})
+}
+
+// ResetContractCreations removes the contract creations and externally owned accounts since the
+// specified block number, so that they could be rebuilt from scratch.
+func (ccs *ContractCreationStore) ResetContractCreations(bnFrom uint64) error {
+	return ccs.db.Transaction(func(dbTx *gorm.DB) error {
+		return ccs.RemoveContractCreations(dbTx, bnFrom)
+	})
+}
+
+// GetContractCreation returns the creation of the specified contract address if indexed.
+func (ccs *ContractCreationStore) GetContractCreation(address string) (*ContractCreation, bool, error) {
+	var creation ContractCreation
+
+	existed, err := ccs.exists(&creation, "address = ?", address)
+	if err != nil || !existed {
+		return nil, false, err
+	}
+
+	return &creation, true, nil
+}
+
+// GetExternallyOwnedAccount returns the block number of the first transaction sent by the specified
+// address, or false if never sent any transaction.
+func (ccs *ContractCreationStore) GetExternallyOwnedAccount(address string) (uint64, bool, error) {
+	var eoa ExternallyOwnedAccount
+
+	existed, err := ccs.exists(&eoa, "address = ?", address)
+	if err != nil || !existed {
+		return 0, false, err
+	}
+
+	return eoa.Bn, true, nil
+}
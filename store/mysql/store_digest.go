@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/pkg/errors"
+)
+
+// EpochDigest computes the digest of the persisted epoch data, which could be compared with that of
+// fullnode to audit data integrity. Note, false is returned if epoch not synced into store.
+func (ms *MysqlStore) EpochDigest(ctx context.Context, epoch uint64) (*store.EpochDigest, bool, error) {
+	pivotHash, ok, err := ms.PivotHash(epoch)
+	if err != nil || !ok {
+		return nil, false, errors.WithMessage(err, "failed to get pivot hash")
+	}
+
+	digest := store.EpochDigest{PivotHash: pivotHash}
+
+	if !ms.disabler.IsChainBlockDisabled() {
+		err := ms.baseStore.db.Model(&block{}).
+			Where("epoch = ?", epoch).
+			Order("id").
+			Pluck("hash", &digest.BlockHashes).Error
+		if err != nil {
+			return nil, false, errors.WithMessage(err, "failed to load block hashes")
+		}
+	}
+
+	if !ms.disabler.IsChainTxnDisabled() || !ms.disabler.IsChainReceiptDisabled() {
+		err := ms.baseStore.db.Model(&transaction{}).
+			Where("epoch = ?", epoch).
+			Order("id").
+			Pluck("hash", &digest.TxHashes).Error
+		if err != nil {
+			return nil, false, errors.WithMessage(err, "failed to load transaction hashes")
+		}
+	}
+
+	if ms.disabler.IsChainLogDisabled() {
+		return &digest, true, nil
+	}
+
+	bnRange, ok, err := ms.BlockRange(epoch)
+	if err != nil || !ok {
+		return nil, false, errors.WithMessage(err, "failed to get block range")
+	}
+
+	// event logs out of retention window are regarded as pruned
+	earliest, ok, err := ms.EarliestRetainedBlock()
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to get earliest retained block")
+	}
+
+	if ok && bnRange.From < earliest {
+		bnRange.From = earliest
+	}
+
+	if bnRange.From > bnRange.To {
+		return &digest, true, nil
+	}
+
+	logs, err := ms.ScanLogs(ctx, bnRange.From, bnRange.To)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to load event logs")
+	}
+
+	for _, log := range logs {
+		contract, ok, err := ms.cs.GetContractById(log.ContractID)
+		if err != nil {
+			return nil, false, errors.WithMessage(err, "failed to get contract of event log")
+		}
+
+		if !ok {
+			return nil, false, errors.Errorf("contract %v of event log not found", log.ContractID)
+		}
+
+		digest.AddLog(contract.Address, log.Topic0, log.Topic1, log.Topic2, log.Topic3)
+	}
+
+	return &digest, true, nil
+}
+
+// EpochDigestOption returns the option to compute the digest of epoch data queried from fullnode, in
+// consistent with the event logs selected and retained in store.
+func (ms *MysqlStore) EpochDigestOption() (store.EpochDigestOption, error) {
+	var opt store.EpochDigestOption
+
+	if ms.selector != nil {
+		opt.LogSelected = ms.selector.selected
+	}
+
+	earliest, ok, err := ms.EarliestRetainedBlock()
+	if err != nil {
+		return opt, errors.WithMessage(err, "failed to get earliest retained block")
+	}
+
+	if ok {
+		opt.EarliestLogBlock = earliest
+	}
+
+	return opt, nil
+}
@@ -0,0 +1,280 @@
+package mysql
+
+import (
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var (
+	errBackfillNotInGap           = errors.New("epoch data not within gap of store")
+	errBackfillPartitionUncovered = errors.New("block range not covered by single log partition")
+)
+
+// Gaps returns the missing epoch ranges within the specified epoch range [from, to] of store, which might
+// be caused by partial write failures or manual operations.
+func (e2bms *epochBlockMapStore) Gaps(from, to uint64) ([]citypes.RangeUint64, error) {
+	if from > to {
+		return nil, nil
+	}
+
+	var epochs []uint64
+
+	db := e2bms.db.Model(&epochBlockMap{}).
+		Where("epoch >= ? AND epoch <= ?", from, to).
+		Order("epoch")
+	if err := db.Pluck("epoch", &epochs).Error; err != nil {
+		return nil, err
+	}
+
+	var gaps []citypes.RangeUint64
+
+	next := from
+	for _, epoch := range epochs {
+		if epoch > next {
+			gaps = append(gaps, citypes.RangeUint64{From: next, To: epoch - 1})
+		}
+
+		next = epoch + 1
+	}
+
+	if next <= to {
+		gaps = append(gaps, citypes.RangeUint64{From: next, To: to})
+	}
+
+	return gaps, nil
+}
+
+// Backfill saves the epoch data of some missing epochs below the max epoch in store, which fails if
+// any epoch of the data slice already exists.
+//
+// Unlike `Pushn`, neither the sync checkpoint is advanced nor the epoch data observers (eg., webhooks
+// or derived tables) are notified, since the epoch data is not appended to the latest.
+func (ms *MysqlStore) Backfill(dataSlice []*store.EpochData) error {
+	if len(dataSlice) == 0 {
+		return nil
+	}
+
+	// gap is validated and filled exclusively of epochs popped during chain reorg
+	ms.epochRangeMu.Lock()
+	defer ms.epochRangeMu.Unlock()
+
+	first, last := dataSlice[0], dataSlice[len(dataSlice)-1]
+	if err := store.RequireContinuous(dataSlice, citypes.EpochNumberNil); err != nil {
+		return err
+	}
+
+	if err := ms.requireGap(first, last.Number); err != nil {
+		return err
+	}
+
+	bnRange := citypes.RangeUint64{
+		From: first.Blocks[0].BlockNumber.ToInt().Uint64(),
+		To:   last.GetPivotBlock().BlockNumber.ToInt().Uint64(),
+	}
+
+	// the log partition which covers the block range of missing epochs
+	var logPartition *bnPartition
+	// the log partitions of big contracts which cover the block range of missing epochs
+	contract2BnPartitions := make(map[uint64]bnPartition)
+
+	if !ms.disabler.IsChainLogDisabled() {
+		var ok bool
+		var err error
+
+		logPartition, ok, err = ms.ls.coveringPartition(bnPartitionedLogEntity, bnRange)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get covering log partition")
+		}
+
+		if !ok {
+			return errors.WithMessagef(errBackfillPartitionUncovered, "block range %v", bnRange)
+		}
+
+		if ms.config.AddressIndexedLogEnabled {
+			if _, err := ms.cs.AddContractByEpochData(dataSlice...); err != nil {
+				return errors.WithMessage(err, "failed to add contracts for specified epoch data slice")
+			}
+
+			if contract2BnPartitions, err = ms.bigContractPartitions(dataSlice, bnRange); err != nil {
+				return err
+			}
+		}
+	}
+
+	updater := metrics.Registry.Store.Push("mysql")
+	defer updater.Update()
+
+	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		if !ms.disabler.IsChainBlockDisabled() {
+			if err := ms.blockStore.Add(dbTx, dataSlice); err != nil {
+				return errors.WithMessage(err, "failed to save blocks")
+			}
+		}
+
+		skipTxn := ms.disabler.IsChainTxnDisabled()
+		skipRcpt := ms.disabler.IsChainReceiptDisabled()
+		if !skipRcpt || !skipTxn {
+			if err := ms.txStore.Add(dbTx, dataSlice, skipTxn, skipRcpt); err != nil {
+				return errors.WithMessage(err, "failed to save transactions")
+			}
+		}
+
+		if !ms.disabler.IsChainLogDisabled() {
+			if err := ms.backfillLogs(dbTx, dataSlice, logPartition, contract2BnPartitions); err != nil {
+				return err
+			}
+		}
+
+		if err := ms.epochBlockMapStore.Add(dbTx, dataSlice); err != nil {
+			return errors.WithMessage(err, "failed to save epoch to block mapping data")
+		}
+
+		return nil
+	})
+}
+
+// requireGap ensures the epochs [first, last] are all missing below the max epoch in store, and the
+// first epoch is linked to its previous epoch in store if any.
+func (ms *MysqlStore) requireGap(first *store.EpochData, last uint64) error {
+	maxEpoch, ok, err := ms.MaxEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	if !ok || last >= maxEpoch {
+		return errors.WithMessagef(errBackfillNotInGap, "epoch %v beyond max epoch", last)
+	}
+
+	var count int64
+	db := ms.baseStore.db.Model(&epochBlockMap{}).Where("epoch >= ? AND epoch <= ?", first.Number, last)
+	if err := db.Count(&count).Error; err != nil {
+		return errors.WithMessage(err, "failed to count existing epochs")
+	}
+
+	if count > 0 {
+		return errors.WithMessagef(
+			errBackfillNotInGap, "epoch range [%v, %v] partially existed", first.Number, last,
+		)
+	}
+
+	if first.Number == 0 {
+		return nil
+	}
+
+	prevPivotHash, ok, err := ms.PivotHash(first.Number - 1)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get pivot hash of previous epoch")
+	}
+
+	if ok && prevPivotHash != first.GetPivotBlock().ParentHash.String() {
+		return errors.WithMessagef(
+			store.ErrContinousEpochRequired, "parent hash of epoch %v mismatched", first.Number,
+		)
+	}
+
+	return nil
+}
+
+// bigContractPartitions returns the log partitions of big contracts within the epoch data slice, which
+// shall cover the specified block range.
+func (ms *MysqlStore) bigContractPartitions(
+	dataSlice []*store.EpochData, bnRange citypes.RangeUint64,
+) (map[uint64]bnPartition, error) {
+	contract2BnPartitions := make(map[uint64]bnPartition)
+
+	for caddr := range extractUniqueContractAddresses(dataSlice...) {
+		cid, _, err := ms.cs.GetContractIdByAddress(caddr)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get contract id by addr")
+		}
+
+		clEntity := ms.bcls.contractEntity(cid)
+
+		// event logs are persisted in address indexed logs if no big contract partition created
+		_, _, existed, err := ms.bcls.bnRange(clEntity)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get contract log partition range")
+		}
+
+		if !existed {
+			continue
+		}
+
+		partition, ok, err := ms.bcls.coveringPartition(clEntity, bnRange)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get covering contract log partition")
+		}
+
+		if !ok {
+			return nil, errors.WithMessagef(
+				errBackfillPartitionUncovered, "block range %v of contract %v", bnRange, caddr,
+			)
+		}
+
+		contract2BnPartitions[cid] = *partition
+	}
+
+	return contract2BnPartitions, nil
+}
+
+// backfillLogs saves event logs of missing epochs into the log partitions which cover the block range,
+// without any change of the partition block ranges.
+func (ms *MysqlStore) backfillLogs(
+	dbTx *gorm.DB,
+	dataSlice []*store.EpochData,
+	logPartition *bnPartition,
+	contract2BnPartitions map[uint64]bnPartition,
+) error {
+	if ms.config.AddressIndexedLogEnabled {
+		bigContractIds := make(map[uint64]bool, len(contract2BnPartitions))
+		for cid := range contract2BnPartitions {
+			bigContractIds[cid] = true
+		}
+
+		for _, data := range dataSlice {
+			if err := ms.ails.AddAddressIndexedLogs(dbTx, data, bigContractIds); err != nil {
+				return errors.WithMessage(err, "failed to save address indexed event logs")
+			}
+		}
+
+		contract2Logs, err := ms.bcls.collectLogs(dataSlice, contract2BnPartitions)
+		if err != nil {
+			return err
+		}
+
+		for cid, logs := range contract2Logs {
+			if len(logs) == 0 {
+				continue
+			}
+
+			partition := contract2BnPartitions[cid]
+			tblName := ms.bcls.getPartitionedTableName(ms.bcls.contractTabler(cid), partition.Index)
+			if err := dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error; err != nil {
+				return errors.WithMessage(err, "failed to save big contract logs")
+			}
+
+			if err := ms.bcls.deltaUpdateCountOf(dbTx, &partition, len(logs)); err != nil {
+				return errors.WithMessage(err, "failed to delta update partition size")
+			}
+
+			if err := ms.cs.UpdateContractStats(dbTx, cid, len(logs), logs[len(logs)-1].Epoch); err != nil {
+				return errors.WithMessage(err, "failed to update contract statistics")
+			}
+		}
+	}
+
+	logs, err := ms.ls.collectLogs(dataSlice)
+	if err != nil || len(logs) == 0 {
+		return err
+	}
+
+	tblName := ms.ls.getPartitionedTableName(&ms.ls.model, logPartition.Index)
+	if err := dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error; err != nil {
+		return errors.WithMessage(err, "failed to save event logs")
+	}
+
+	return ms.ls.deltaUpdateCountOf(dbTx, logPartition, len(logs))
+}
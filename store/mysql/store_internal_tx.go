@@ -0,0 +1,323 @@
+package mysql
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// config key of the next block to index internal transactions
+	MysqlConfKeyInternalTxNextBlock = "internaltx.next"
+
+	// max number of internal transactions to query at a time
+	MaxInternalTxQueryLimit = 1000
+
+	defaultBatchSizeInternalTxInsert = 500
+)
+
+// ErrInternalTxStale returned if the traced blocks to index are reverted or indexed concurrently.
+var ErrInternalTxStale = errors.New("traced blocks stale")
+
+// InternalTx internal transaction (namely the nested call, contract creation or self destruct)
+// traced from transaction execution. Addresses are persisted in lower case hex.
+type InternalTx struct {
+	ID           uint64
+	Bn           uint64 `gorm:"not null;index:idx_bn;index:idx_from_bn,priority:2;index:idx_to_bn,priority:2"`
+	TxHash       string `gorm:"size:66;not null;index:idx_tx_hash"`
+	TxIndex      uint64 `gorm:"not null"`
+	TraceAddress string `gorm:"size:256;not null"` // path of trace in call tree, eg., `0_1`
+	Type         string `gorm:"size:16;not null"`  // `call`, `create` or `suicide`
+	CallType     string `gorm:"size:16;not null"`  // eg., `call`, `delegatecall` or `staticcall`
+	From         string `gorm:"size:42;not null;index:idx_from_bn,priority:1"`
+	To           string `gorm:"size:42;not null;index:idx_to_bn,priority:1"` // created contract or refund address
+	Value        string `gorm:"size:78;not null"`                            // decimal
+	Error        string `gorm:"size:256;not null"`                           // empty if succeeded
+}
+
+func (InternalTx) TableName() string {
+	return "internal_txs"
+}
+
+// InternalTxFilter filter to query internal transactions, which are returned in reverse order of id.
+type InternalTxFilter struct {
+	TxHash    string // either transaction hash or address is required
+	Address   string
+	Direction string // `in`, `out` or empty for both
+	FromBlock uint64
+	ToBlock   uint64 // 0 for unlimited
+	Cursor    uint64 // id of the last returned internal transaction, 0 to query from the latest
+	Limit     int
+}
+
+type InternalTxStore struct {
+	*baseStore
+}
+
+func NewInternalTxStore(db *gorm.DB) *InternalTxStore {
+	return &InternalTxStore{baseStore: newBaseStore(db)}
+}
+
+// InitInternalTxNextBlock initializes the next block to index internal transactions if absent, and
+// returns the persisted one.
+func (its *InternalTxStore) InitInternalTxNextBlock(bn uint64) (uint64, error) {
+	err := its.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&conf{
+		Name:  MysqlConfKeyInternalTxNextBlock,
+		Value: strconv.FormatUint(bn, 10),
+	}).Error
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to init next block of internal transactions")
+	}
+
+	return its.loadNextBlock(its.db, false)
+}
+
+// loadNextBlock loads the next block to index internal transactions, and locks it if required so
+// that indexing and reverting of internal transactions are serialized.
+func (its *InternalTxStore) loadNextBlock(db *gorm.DB, lock bool) (uint64, error) {
+	if lock {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var cfg conf
+	if err := db.Where("name = ?", MysqlConfKeyInternalTxNextBlock).First(&cfg).Error; err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(cfg.Value, 10, 64)
+}
+
+func (its *InternalTxStore) storeNextBlock(dbTx *gorm.DB, bn uint64) error {
+	return dbTx.Model(&conf{}).
+		Where("name = ?", MysqlConfKeyInternalTxNextBlock).
+		Update("value", strconv.FormatUint(bn, 10)).Error
+}
+
+// AddInternalTxs persists the internal transactions traced from blocks `[bnFrom, bnTo]`, which are
+// identified by the specified block hashes, and advances the next block to index. Note, it returns
+// `ErrInternalTxStale` if the traced blocks are not the canonical ones synced into store any more.
+func (its *InternalTxStore) AddInternalTxs(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*InternalTx,
+) error {
+	return its.db.Transaction(func(dbTx *gorm.DB) error {
+		next, err := its.loadNextBlock(dbTx, true)
+		if err != nil {
+			return errors.WithMessage(err, "failed to load next block of internal transactions")
+		}
+
+		if next != bnFrom {
+			return ErrInternalTxStale
+		}
+
+		matched, err := matchPivotHashes(dbTx, bnFrom, bnTo, blockHashes)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			return ErrInternalTxStale
+		}
+
+		if len(txs) > 0 {
+			if err := dbTx.CreateInBatches(txs, defaultBatchSizeInternalTxInsert).Error; err != nil {
+				return errors.WithMessage(err, "failed to add internal transactions")
+			}
+		}
+
+		return its.storeNextBlock(dbTx, bnTo+1)
+	})
+}
+
+// InternalTxNextBlock returns the next block to index internal transactions, or false if nothing
+// indexed yet.
+func (its *InternalTxStore) InternalTxNextBlock() (uint64, bool, error) {
+	next, err := its.loadNextBlock(its.db, false)
+	if its.IsRecordNotFound(err) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return next, true, nil
+}
+
+// ReplaceInternalTxs replaces the internal transactions of the already indexed blocks `[bnFrom, bnTo]`
+// with the re-traced ones, which are identified by the specified block hashes, so that internal
+// transactions could be rebuilt idempotently. Note, it returns `ErrInternalTxStale` if the blocks are
+// reverted meanwhile, or the re-traced blocks are not the canonical ones synced into store any more.
+func (its *InternalTxStore) ReplaceInternalTxs(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*InternalTx,
+) error {
+	return its.db.Transaction(func(dbTx *gorm.DB) error {
+		next, err := its.loadNextBlock(dbTx, true)
+		if err != nil {
+			return errors.WithMessage(err, "failed to load next block of internal transactions")
+		}
+
+		if bnTo >= next {
+			return ErrInternalTxStale
+		}
+
+		matched, err := matchPivotHashes(dbTx, bnFrom, bnTo, blockHashes)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			return ErrInternalTxStale
+		}
+
+		if err := dbTx.Where("bn BETWEEN ? AND ?", bnFrom, bnTo).Delete(&InternalTx{}).Error; err != nil {
+			return errors.WithMessage(err, "failed to remove internal transactions")
+		}
+
+		if len(txs) > 0 {
+			if err := dbTx.CreateInBatches(txs, defaultBatchSizeInternalTxInsert).Error; err != nil {
+				return errors.WithMessage(err, "failed to add internal transactions")
+			}
+		}
+
+		return nil
+	})
+}
+
+// ResetInternalTxs removes the already indexed internal transactions since the specified block number,
+// so that they could be rebuilt from scratch. Note, the next block to index is kept as it is, since the
+// blocks not indexed yet are left to the indexer.
+func (its *InternalTxStore) ResetInternalTxs(bnFrom uint64) error {
+	return its.db.Transaction(func(dbTx *gorm.DB) error {
+		next, err := its.loadNextBlock(dbTx, true)
+		if its.IsRecordNotFound(err) { // not indexed yet
+			return nil
+		}
+
+		if err != nil {
+			return errors.WithMessage(err, "failed to load next block of internal transactions")
+		}
+
+		err = dbTx.Where("bn >= ? AND bn < ?", bnFrom, next).Delete(&InternalTx{}).Error
+		return errors.WithMessage(err, "failed to remove internal transactions")
+	})
+}
+
+// AdvanceInternalTxNextBlock moves the next block to index forward to the specified block number if
+// behind, eg., blocks already pruned from store, and returns the persisted one.
+func (its *InternalTxStore) AdvanceInternalTxNextBlock(bn uint64) (uint64, error) {
+	var next uint64
+
+	err := its.db.Transaction(func(dbTx *gorm.DB) (err error) {
+		next, err = its.advanceNextBlock(dbTx, bn)
+		return err
+	})
+
+	return next, err
+}
+
+func (its *InternalTxStore) advanceNextBlock(dbTx *gorm.DB, bn uint64) (uint64, error) {
+	next, err := its.loadNextBlock(dbTx, true)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to load next block of internal transactions")
+	}
+
+	if next >= bn {
+		return next, nil
+	}
+
+	if err := its.storeNextBlock(dbTx, bn); err != nil {
+		return 0, errors.WithMessage(err, "failed to advance next block of internal transactions")
+	}
+
+	return bn, nil
+}
+
+// Retain removes the internal transactions before the specified block number, and moves the next
+// block to index past the pruned range if behind. It is hooked into the store pruner along with
+// event log retention.
+func (its *InternalTxStore) Retain(bnFrom uint64) error {
+	return its.db.Transaction(func(dbTx *gorm.DB) error {
+		_, err := its.advanceNextBlock(dbTx, bnFrom)
+		if its.IsRecordNotFound(err) { // not indexed yet
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := dbTx.Where("bn < ?", bnFrom).Delete(&InternalTx{}).Error; err != nil {
+			return errors.WithMessage(err, "failed to remove internal transactions out of retention")
+		}
+
+		return nil
+	})
+}
+
+// RevertInternalTxs removes the internal transactions since the specified block number, and rewinds
+// the next block to index within the db transaction of popped epoch data, which is hooked into `Popn`
+// of store regardless of whether the indexer runs in the same process.
+func (its *InternalTxStore) RevertInternalTxs(dbTx *gorm.DB, bnFrom uint64) error {
+	next, err := its.loadNextBlock(dbTx, true)
+	if its.IsRecordNotFound(err) { // not indexed yet
+		return nil
+	}
+
+	if err != nil {
+		return errors.WithMessage(err, "failed to load next block of internal transactions")
+	}
+
+	if err := dbTx.Where("bn >= ?", bnFrom).Delete(&InternalTx{}).Error; err != nil {
+		return errors.WithMessage(err, "failed to remove internal transactions")
+	}
+
+	if next <= bnFrom {
+		return nil
+	}
+
+	return its.storeNextBlock(dbTx, bnFrom)
+}
+
+// GetInternalTxs returns the internal transactions matched with the filter in reverse order of id.
+func (its *InternalTxStore) GetInternalTxs(filter InternalTxFilter) ([]*InternalTx, error) {
+	db := its.db
+
+	if len(filter.TxHash) > 0 {
+		db = db.Where("tx_hash = ?", filter.TxHash)
+	}
+
+	if len(filter.Address) > 0 {
+		switch filter.Direction {
+		case "in":
+			db = db.Where("`to` = ?", filter.Address)
+		case "out":
+			db = db.Where("`from` = ?", filter.Address)
+		default:
+			db = db.Where("(`from` = ? OR `to` = ?)", filter.Address, filter.Address)
+		}
+	}
+
+	if filter.FromBlock > 0 {
+		db = db.Where("bn >= ?", filter.FromBlock)
+	}
+
+	if filter.ToBlock > 0 {
+		db = db.Where("bn <= ?", filter.ToBlock)
+	}
+
+	if filter.Cursor > 0 {
+		db = db.Where("id < ?", filter.Cursor)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxInternalTxQueryLimit {
+		limit = MaxInternalTxQueryLimit
+	}
+
+	var txs []*InternalTx
+	err := db.Order("id DESC").Limit(limit).Find(&txs).Error
+
+	return txs, err
+}
@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// job run status
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+
+	// max length of job run error to persist
+	maxJobRunErrorLen = 1024
+)
+
+// ScheduledJob operational job (eg., pruning) scheduled periodically, which is persisted so that
+// it could be paused or triggered by admin, and run by only one instance each time.
+type ScheduledJob struct {
+	ID        uint32
+	Name      string        `gorm:"unique;size:64;not null"`
+	Interval  time.Duration `gorm:"column:run_interval;not null"` // interval to run the job
+	Paused    bool          `gorm:"not null;default:false"`       // whether the job is paused by admin
+	NextRunAt time.Time     `gorm:"not null;index:idx_next"`      // time to run the job next
+	LastRunAt *time.Time    // time of the last run, nil if never run
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// JobRun run history of the scheduled job.
+type JobRun struct {
+	ID         uint64
+	JobName    string     `gorm:"size:64;not null;index:idx_job_started,priority:1"`
+	Instance   string     `gorm:"size:128;not null"` // instance (hostname) running the job
+	Status     string     `gorm:"size:16;not null"`
+	Error      string     `gorm:"size:1024"`
+	StartedAt  time.Time  `gorm:"not null;index:idx_job_started,priority:2"`
+	FinishedAt *time.Time // nil if still running
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+type JobStore struct {
+	*baseStore
+}
+
+func NewJobStore(db *gorm.DB) *JobStore {
+	return &JobStore{baseStore: newBaseStore(db)}
+}
+
+// RegisterJob registers the scheduled job if not registered yet, otherwise updates the interval.
+func (s *JobStore) RegisterJob(name string, interval time.Duration) error {
+	job := &ScheduledJob{
+		Name:      name,
+		Interval:  interval,
+		NextRunAt: time.Now().Add(interval),
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"run_interval", "updated_at"}),
+	}).Create(job).Error
+}
+
+// LoadJobs loads all the scheduled jobs.
+func (s *JobStore) LoadJobs() (res []*ScheduledJob, err error) {
+	err = s.db.Order("name").Find(&res).Error
+	return res, err
+}
+
+// ClaimJob claims the due job to run if not paused, and schedules the next run at the specified
+// time, so that the job will be run by only one instance. Returns false if the job is not due
+// or already claimed by others.
+func (s *JobStore) ClaimJob(name string, now, nextRunAt time.Time) (bool, error) {
+	res := s.db.Model(&ScheduledJob{}).
+		Where("name = ? AND paused = ? AND next_run_at <= ?", name, false, now).
+		Updates(map[string]interface{}{"next_run_at": nextRunAt, "last_run_at": now})
+
+	return res.RowsAffected > 0, res.Error
+}
+
+// PauseJob pauses or resumes the scheduled job.
+func (s *JobStore) PauseJob(name string, paused bool) (bool, error) {
+	res := s.db.Model(&ScheduledJob{}).Where("name = ?", name).Update("paused", paused)
+	return res.RowsAffected > 0, res.Error
+}
+
+// TriggerJob triggers the scheduled job to run as soon as possible.
+func (s *JobStore) TriggerJob(name string) (bool, error) {
+	res := s.db.Model(&ScheduledJob{}).Where("name = ?", name).Update("next_run_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+// AddJobRun adds the job run history when the job starts to run.
+func (s *JobStore) AddJobRun(run *JobRun) error {
+	return s.db.Create(run).Error
+}
+
+// FinishJobRun updates the job run history when the job finishes.
+func (s *JobStore) FinishJobRun(run *JobRun) error {
+	if len(run.Error) > maxJobRunErrorLen {
+		run.Error = run.Error[:maxJobRunErrorLen]
+	}
+
+	return s.db.Model(run).Select("status", "error", "finished_at").Updates(run).Error
+}
+
+// LoadJobRuns loads the latest run history of the specified job.
+func (s *JobStore) LoadJobRuns(name string, limit int) (res []*JobRun, err error) {
+	err = s.db.Where("job_name = ?", name).Order("started_at DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// PruneJobRuns removes the run history started before the specified time.
+func (s *JobStore) PruneJobRuns(before time.Time) (int64, error) {
+	res := s.db.Where("started_at < ?", before).Delete(&JobRun{})
+	return res.RowsAffected, res.Error
+}
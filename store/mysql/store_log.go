@@ -2,11 +2,13 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -27,13 +29,18 @@ type log struct {
 	Topic2      string `gorm:"size:66"`
 	Topic3      string `gorm:"size:66"`
 	LogIndex    uint64 `gorm:"not null"`
-	Extra       []byte `gorm:"type:mediumText"` // extension json field
+	Extra       []byte `gorm:"type:MEDIUMBLOB"` // extension json field, which might be compressed
 }
 
 func (log) TableName() string {
 	return "logs"
 }
 
+// encodedSize returns the size in bytes of encoded topics and extension data.
+func (l *log) encodedSize() int {
+	return len(l.Topic0) + len(l.Topic1) + len(l.Topic2) + len(l.Topic3) + len(l.Extra)
+}
+
 type logStore struct {
 	*bnPartitionedStore
 	cs    *ContractStore
@@ -41,6 +48,12 @@ type logStore struct {
 	model log
 	// notify channel for new bn partition created
 	bnPartitionNotifyChan chan<- *bnPartition
+	// algorithm to compress the extension data of event logs
+	compression store.LogCompression
+	// selector of event logs to persist if selective sync enabled
+	selector *logSelector
+	// whether to index new partitions by topic0 and block number
+	topicIndexEnabled bool
 }
 
 func newLogStore(db *gorm.DB, cs *ContractStore, ebms *epochBlockMapStore, notifyChan chan<- *bnPartition) *logStore {
@@ -50,10 +63,23 @@ func newLogStore(db *gorm.DB, cs *ContractStore, ebms *epochBlockMapStore, notif
 	}
 }
 
+// createTopicIndex creates index by topic0 on the new created log partition. Note, the partition is still
+// available to query by block number if failed.
+func (ls *logStore) createTopicIndex(partition bnPartition) {
+	tblName := ls.getPartitionedTableName(&ls.model, partition.Index)
+	if err := createLogTopicIndex(ls.db, tblName); err != nil {
+		logrus.WithError(err).WithField("table", tblName).Warn("Failed to create topic index on log partition")
+	}
+}
+
 // preparePartition create new log partitions if necessary.
 func (ls *logStore) preparePartition(dataSlice []*store.EpochData) (bnPartition, error) {
 	partition, newCreated, err := ls.autoPartition(bnPartitionedLogEntity, &ls.model, bnPartitionedLogVolumeSize)
 	if err == nil && newCreated {
+		if ls.topicIndexEnabled {
+			ls.createTopicIndex(partition)
+		}
+
 		partition.tabler = &ls.model
 		ls.bnPartitionNotifyChan <- &partition
 	}
@@ -62,7 +88,41 @@ func (ls *logStore) preparePartition(dataSlice []*store.EpochData) (bnPartition,
 }
 
 func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartition bnPartition) error {
-	// containers to collect event logs for batch inserting
+	logs, err := ls.collectLogs(dataSlice)
+	if err != nil {
+		return err
+	}
+
+	// update block range for log partition router
+	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
+	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
+
+	err = ls.expandBnRange(dbTx, bnPartitionedLogEntity, int(logPartition.Index), bnMin, bnMax)
+	if err != nil {
+		return errors.WithMessage(err, "failed to expand partition bn range")
+	}
+
+	if len(logs) == 0 {
+		return nil
+	}
+
+	tblName := ls.getPartitionedTableName(&ls.model, logPartition.Index)
+	err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
+	if err != nil {
+		return err
+	}
+
+	// update partition data size
+	err = ls.deltaUpdateCount(dbTx, bnPartitionedLogEntity, int(logPartition.Index), len(logs))
+	if err != nil {
+		return errors.WithMessage(err, "failed to delta update partition size")
+	}
+
+	return nil
+}
+
+// collectLogs collects event logs of executed transactions from epoch data slice for batch inserting.
+func (ls *logStore) collectLogs(dataSlice []*store.EpochData) ([]*log, error) {
 	var logs []*log
 
 	for _, data := range dataSlice {
@@ -83,9 +143,13 @@ func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartitio
 				}
 
 				for k, rlog := range receipt.Logs {
+					if !ls.selector.selected(&rlog) {
+						continue
+					}
+
 					cid, _, err := ls.cs.AddContractIfAbsent(rlog.Address.MustGetBase32Address())
 					if err != nil {
-						return errors.WithMessage(err, "failed to add contract")
+						return nil, errors.WithMessage(err, "failed to add contract")
 					}
 
 					var logExt *store.LogExtra
@@ -94,38 +158,15 @@ func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartitio
 					}
 
 					clog := store.ParseCfxLog(&rlog, cid, bn, logExt)
+					clog.Extra = ls.compression.Compress(clog.Extra)
 					logs = append(logs, (*log)(clog))
+					data.Size += (*log)(clog).encodedSize()
 				}
 			}
 		}
 	}
 
-	// update block range for log partition router
-	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
-	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
-
-	err := ls.expandBnRange(dbTx, bnPartitionedLogEntity, int(logPartition.Index), bnMin, bnMax)
-	if err != nil {
-		return errors.WithMessage(err, "failed to expand partition bn range")
-	}
-
-	if len(logs) == 0 {
-		return nil
-	}
-
-	tblName := ls.getPartitionedTableName(&ls.model, logPartition.Index)
-	err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
-	if err != nil {
-		return err
-	}
-
-	// update partition data size
-	err = ls.deltaUpdateCount(dbTx, bnPartitionedLogEntity, int(logPartition.Index), len(logs))
-	if err != nil {
-		return errors.WithMessage(err, "failed to delta update partition size")
-	}
-
-	return nil
+	return logs, nil
 }
 
 // Popn pops event logs until the specific epoch from db store.
@@ -214,9 +255,107 @@ func (ls *logStore) GetLogs(ctx context.Context, storeFilter store.LogFilter) ([
 	return result, nil
 }
 
+// explain returns the partitioned tables to query event logs for the specified filter.
+func (ls *logStore) explain(storeFilter store.LogFilter) ([]LogsQueryIndex, error) {
+	partitions, _, err := ls.searchPartitions(
+		bnPartitionedLogEntity, types.RangeUint64{
+			From: storeFilter.BlockFrom,
+			To:   storeFilter.BlockTo,
+		},
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to search partitions")
+	}
+
+	result := make([]LogsQueryIndex, 0, len(partitions))
+	for _, partition := range partitions {
+		tblName := ls.getPartitionedTableName(&log{}, partition.Index)
+		result = append(result, LogsQueryIndex{
+			Table: tblName,
+			Index: explainLogIndex(ls.db, tblName, storeFilter),
+		})
+	}
+
+	return result, nil
+}
+
+// ScanLogs returns all event logs within the specified block range from the universal event log
+// tables without any result set limit, which is used to rebuild derived data from raw event logs.
+func (ls *logStore) ScanLogs(ctx context.Context, bnFrom, bnTo uint64) ([]*store.Log, error) {
+	partitions, _, err := ls.searchPartitions(
+		bnPartitionedLogEntity, types.RangeUint64{From: bnFrom, To: bnTo},
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to search partitions")
+	}
+
+	var result []*store.Log
+	for _, partition := range partitions {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		var logs []*log
+
+		tblName := ls.getPartitionedTableName(&log{}, partition.Index)
+		err := ls.db.Table(tblName).
+			Where("bn BETWEEN ? AND ?", bnFrom, bnTo).
+			Order("bn ASC, log_index ASC").
+			Find(&logs).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range logs {
+			result = append(result, (*store.Log)(v))
+		}
+	}
+
+	return result, nil
+}
+
+// LogStatistics statistics of the persisted universal event logs.
+type LogStatistics struct {
+	NumLogs   uint64 // total number of event logs
+	NumBlocks uint64 // total number of blocks covered by event log partitions
+}
+
+// LogsPerBlock returns the average number of event logs per block.
+func (stats LogStatistics) LogsPerBlock() float64 {
+	if stats.NumBlocks == 0 {
+		return 0
+	}
+
+	return float64(stats.NumLogs) / float64(stats.NumBlocks)
+}
+
+// Statistics returns the statistics of persisted event logs from block number partitions.
+func (ls *logStore) Statistics() (LogStatistics, error) {
+	var res struct {
+		NumLogs   sql.NullInt64
+		NumBlocks sql.NullInt64
+	}
+
+	err := ls.db.Model(&bnPartition{}).
+		Select("SUM(count) AS num_logs, SUM(bn_max - bn_min + 1) AS num_blocks").
+		Where("entity = ? AND bn_min IS NOT NULL AND bn_max IS NOT NULL", bnPartitionedLogEntity).
+		Scan(&res).Error
+	if err != nil {
+		return LogStatistics{}, err
+	}
+
+	return LogStatistics{
+		NumLogs:   uint64(res.NumLogs.Int64),
+		NumBlocks: uint64(res.NumBlocks.Int64),
+	}, nil
+}
+
 // GetBnPartitionedLogs returns event logs for the specified block number partitioned log filter.
 func (ls *logStore) GetBnPartitionedLogs(filter LogFilter, partition bnPartition) ([]*log, error) {
 	filter.TableName = ls.getPartitionedTableName(&log{}, partition.Index)
+	filter.TopicIndexed = hasLogTopicIndex(ls.db, filter.TableName)
 
 	var res []*log
 	err := filter.find(ls.db, &res)
@@ -27,7 +27,7 @@ type AddressIndexedLog struct {
 	Topic2      string `gorm:"size:66"`
 	Topic3      string `gorm:"size:66"`
 	LogIndex    uint64 `gorm:"not null"`
-	Extra       []byte `gorm:"type:mediumText"` // extention json field
+	Extra       []byte `gorm:"type:MEDIUMBLOB"` // extension json field, which might be compressed
 }
 
 func (AddressIndexedLog) TableName() string {
@@ -41,6 +41,10 @@ type AddressIndexedLogStore struct {
 	cs         *ContractStore
 	model      AddressIndexedLog
 	partitions uint32
+	// algorithm to compress the extension data of event logs
+	compression store.LogCompression
+	// selector of event logs to persist if selective sync enabled
+	selector *logSelector
 }
 
 func NewAddressIndexedLogStore(db *gorm.DB, cs *ContractStore, partitions uint32) *AddressIndexedLogStore {
@@ -94,6 +98,10 @@ func (ls *AddressIndexedLogStore) convertToPartitionedLogs(
 			receiptExt := data.ReceiptExts[tx.Hash]
 
 			for i, v := range receipt.Logs {
+				if !ls.selector.selected(&v) {
+					continue
+				}
+
 				cid, _, err := ls.cs.AddContractIfAbsent(v.Address.MustGetBase32Address())
 				if err != nil {
 					return nil, nil, err
@@ -110,6 +118,7 @@ func (ls *AddressIndexedLogStore) convertToPartitionedLogs(
 				}
 
 				log := store.ParseCfxLog(&v, cid, bn, logext)
+				log.Extra = ls.compression.Compress(log.Extra)
 				partition := ls.getPartitionByAddress(v.Address.MustGetBase32Address())
 				partition2Logs[partition] = append(partition2Logs[partition], (*AddressIndexedLog)(log))
 
@@ -28,7 +28,7 @@ type contractLog struct {
 	Topic2      string `gorm:"size:66"`
 	Topic3      string `gorm:"size:66"`
 	LogIndex    uint64 `gorm:"not null"`
-	Extra       []byte `gorm:"type:mediumText"` // extension json field
+	Extra       []byte `gorm:"type:MEDIUMBLOB"` // extension json field, which might be compressed
 }
 
 func (cl contractLog) TableName() string {
@@ -44,6 +44,12 @@ type bigContractLogStore struct {
 	ails *AddressIndexedLogStore
 	// notify channel for new bn partition created
 	bnPartitionNotifyChan chan<- *bnPartition
+	// algorithm to compress the extension data of event logs
+	compression store.LogCompression
+	// selector of event logs to persist if selective sync enabled
+	selector *logSelector
+	// whether to index new partitions by topic0 and block number
+	topicIndexEnabled bool
 }
 
 func newBigContractLogStore(
@@ -93,6 +99,10 @@ func (bcls *bigContractLogStore) preparePartitions(dataSlice []*store.EpochData)
 		}
 
 		if newCreated {
+			if bcls.topicIndexEnabled {
+				bcls.createTopicIndex(clTabler, partition)
+			}
+
 			partition.tabler = clTabler
 			bcls.bnPartitionNotifyChan <- &partition
 		}
@@ -204,6 +214,55 @@ func (bcls *bigContractLogStore) contractTabler(cid uint64) *contractLog {
 func (bcls *bigContractLogStore) Add(
 	dbTx *gorm.DB, dataSlice []*store.EpochData, contract2BnPartitions map[uint64]bnPartition,
 ) error {
+	contract2Logs, err := bcls.collectLogs(dataSlice, contract2BnPartitions)
+	if err != nil {
+		return err
+	}
+
+	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
+	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
+
+	for cid, partition := range contract2BnPartitions {
+		clEntity, clTabler := bcls.contractEntity(cid), bcls.contractTabler(cid)
+
+		// update block range for contract log partition router
+		err := bcls.expandBnRange(dbTx, clEntity, int(partition.Index), bnMin, bnMax)
+		if err != nil {
+			return errors.WithMessage(err, "failed to expand partition bn range")
+		}
+
+		logs := contract2Logs[cid]
+		if len(logs) == 0 {
+			continue
+		}
+
+		tblName := bcls.getPartitionedTableName(clTabler, partition.Index)
+		err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
+		if err != nil {
+			return err
+		}
+
+		// update partition data count
+		err = bcls.deltaUpdateCount(dbTx, clEntity, int(partition.Index), len(logs))
+		if err != nil {
+			return errors.WithMessage(err, "failed to delta update partition size")
+		}
+
+		// Update contract statistics (log count and lastest updated epoch).
+		latestUpdateEpoch := logs[len(logs)-1].Epoch
+		if err := bcls.cs.UpdateContractStats(dbTx, cid, len(logs), latestUpdateEpoch); err != nil {
+			return errors.WithMessage(err, "failed to update contract statistics")
+		}
+	}
+
+	return nil
+}
+
+// collectLogs collects event logs of the specified big contracts from epoch data slice for batch
+// inserting by contract.
+func (bcls *bigContractLogStore) collectLogs(
+	dataSlice []*store.EpochData, contract2BnPartitions map[uint64]bnPartition,
+) (map[uint64][]*contractLog, error) {
 	contract2Logs := make(map[uint64][]*contractLog, len(contract2BnPartitions))
 
 	for _, data := range dataSlice {
@@ -224,9 +283,13 @@ func (bcls *bigContractLogStore) Add(
 				}
 
 				for k, log := range receipt.Logs {
+					if !bcls.selector.selected(&log) {
+						continue
+					}
+
 					cid, _, err := bcls.cs.AddContractIfAbsent(log.Address.MustGetBase32Address())
 					if err != nil {
-						return errors.WithMessage(err, "failed to add contract")
+						return nil, errors.WithMessage(err, "failed to add contract")
 					}
 
 					// only collect big contract event logs
@@ -240,49 +303,14 @@ func (bcls *bigContractLogStore) Add(
 					}
 
 					log := store.ParseCfxLog(&log, cid, bn, logExt)
+					log.Extra = bcls.compression.Compress(log.Extra)
 					contract2Logs[cid] = append(contract2Logs[cid], (*contractLog)(log))
 				}
 			}
 		}
 	}
 
-	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
-	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
-
-	for cid, partition := range contract2BnPartitions {
-		clEntity, clTabler := bcls.contractEntity(cid), bcls.contractTabler(cid)
-
-		// update block range for contract log partition router
-		err := bcls.expandBnRange(dbTx, clEntity, int(partition.Index), bnMin, bnMax)
-		if err != nil {
-			return errors.WithMessage(err, "failed to expand partition bn range")
-		}
-
-		logs := contract2Logs[cid]
-		if len(logs) == 0 {
-			continue
-		}
-
-		tblName := bcls.getPartitionedTableName(clTabler, partition.Index)
-		err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
-		if err != nil {
-			return err
-		}
-
-		// update partition data count
-		err = bcls.deltaUpdateCount(dbTx, clEntity, int(partition.Index), len(logs))
-		if err != nil {
-			return errors.WithMessage(err, "failed to delta update partition size")
-		}
-
-		// Update contract statistics (log count and lastest updated epoch).
-		latestUpdateEpoch := logs[len(logs)-1].Epoch
-		if err := bcls.cs.UpdateContractStats(dbTx, cid, len(logs), latestUpdateEpoch); err != nil {
-			return errors.WithMessage(err, "failed to update contract statistics")
-		}
-	}
-
-	return nil
+	return contract2Logs, nil
 }
 
 func (bcls *bigContractLogStore) Popn(dbTx *gorm.DB, epochUntil uint64) error {
@@ -417,6 +445,41 @@ func (bcls *bigContractLogStore) GetContractLogs(
 	return result, nil
 }
 
+// explain returns the partitioned tables to query contract event logs for the specified filter.
+func (bcls *bigContractLogStore) explain(cid uint64, storeFilter store.LogFilter) ([]LogsQueryIndex, error) {
+	partitions, _, err := bcls.searchPartitions(
+		bcls.contractEntity(cid), types.RangeUint64{
+			From: storeFilter.BlockFrom,
+			To:   storeFilter.BlockTo,
+		},
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to search partitions")
+	}
+
+	contractTabler := bcls.contractTabler(cid)
+
+	result := make([]LogsQueryIndex, 0, len(partitions))
+	for _, partition := range partitions {
+		tblName := bcls.getPartitionedTableName(contractTabler, partition.Index)
+		result = append(result, LogsQueryIndex{
+			Table: tblName,
+			Index: explainLogIndex(bcls.db, tblName, storeFilter),
+		})
+	}
+
+	return result, nil
+}
+
+// createTopicIndex creates index by topic0 on the new created contract log partition. Note, the partition
+// is still available to query by block number if failed.
+func (bcls *bigContractLogStore) createTopicIndex(clTabler *contractLog, partition bnPartition) {
+	tblName := bcls.getPartitionedTableName(clTabler, partition.Index)
+	if err := createLogTopicIndex(bcls.db, tblName); err != nil {
+		logrus.WithError(err).WithField("table", tblName).Warn("Failed to create topic index on log partition")
+	}
+}
+
 // GetContractBnPartitionedLogs returns contract event logs for the log filter from
 // specified table partition ranged by block number.
 func (bcls *bigContractLogStore) GetContractBnPartitionedLogs(
@@ -424,6 +487,7 @@ func (bcls *bigContractLogStore) GetContractBnPartitionedLogs(
 ) ([]*contractLog, error) {
 	contractTabler := bcls.contractTabler(cid)
 	filter.TableName = bcls.getPartitionedTableName(contractTabler, partition.Index)
+	filter.TopicIndexed = hasLogTopicIndex(bcls.db, filter.TableName)
 
 	var res []*contractLog
 	err := filter.find(bcls.db, &res)
@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// name patterns of event log tables with extension data, including block number partitioned logs,
+// address indexed logs and big contract logs.
+var logTablePatterns = []string{`logs\_%`, `addr\_logs\_%`, `clogs\_%`}
+
+// LogCompressionStats statistics to compress the extension data of event logs in table.
+type LogCompressionStats struct {
+	Table       string
+	Rows        int64 // number of rows compressed
+	BytesBefore int64 // size of extension data before compression
+	BytesAfter  int64 // size of extension data after compression
+}
+
+type logExtraRow struct {
+	ID    uint64
+	Extra []byte
+}
+
+// LogTables returns all the event log tables with extension data.
+func (ms *MysqlStore) LogTables() (tables []string, err error) {
+	db := ms.baseStore.db.Table("information_schema.columns").
+		Select("table_name").
+		Where("table_schema = DATABASE() AND column_name = ?", "extra")
+
+	cond := ms.baseStore.db.Where("table_name LIKE ?", logTablePatterns[0])
+	for _, pattern := range logTablePatterns[1:] {
+		cond = cond.Or("table_name LIKE ?", pattern)
+	}
+
+	err = db.Where(cond).Order("table_name").Pluck("table_name", &tables).Error
+	return tables, err
+}
+
+// CompressLogTable migrates the extension data column of event log table to binary type if
+// necessary, and then compresses the existing uncompressed extension data in batches.
+//
+// Note, it's safe to run again if interrupted, since compressed data will be skipped.
+func (ms *MysqlStore) CompressLogTable(
+	ctx context.Context, table string, compression store.LogCompression, batchSize int,
+) (*LogCompressionStats, error) {
+	if len(compression) == 0 {
+		return nil, errors.New("log compression not specified")
+	}
+
+	if err := compression.Validate(); err != nil {
+		return nil, err
+	}
+
+	var colType string
+	err := ms.baseStore.db.Table("information_schema.columns").
+		Select("data_type").
+		Where("table_schema = DATABASE() AND table_name = ? AND column_name = ?", table, "extra").
+		Row().Scan(&colType)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get column type of extension data")
+	}
+
+	// compressed data could only be stored in binary column
+	if colType != "mediumblob" {
+		if err := ms.baseStore.db.Exec("ALTER TABLE `" + table + "` MODIFY `extra` MEDIUMBLOB").Error; err != nil {
+			return nil, errors.WithMessage(err, "failed to alter column type of extension data")
+		}
+	}
+
+	stats := LogCompressionStats{Table: table}
+
+	for lastID := uint64(0); ; {
+		select {
+		case <-ctx.Done():
+			return &stats, ctx.Err()
+		default:
+		}
+
+		var rows []logExtraRow
+		err := ms.baseStore.db.Table(table).Select("id", "extra").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return &stats, errors.WithMessage(err, "failed to load extension data")
+		}
+
+		if len(rows) == 0 {
+			return &stats, nil
+		}
+
+		lastID = rows[len(rows)-1].ID
+
+		batch := LogCompressionStats{Table: table}
+
+		err = ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+			for _, row := range rows {
+				if len(row.Extra) == 0 || store.IsLogExtraCompressed(row.Extra) {
+					continue
+				}
+
+				compressed := compression.Compress(row.Extra)
+				if err := dbTx.Table(table).Where("id = ?", row.ID).Update("extra", compressed).Error; err != nil {
+					return err
+				}
+
+				batch.Rows++
+				batch.BytesBefore += int64(len(row.Extra))
+				batch.BytesAfter += int64(len(compressed))
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return &stats, errors.WithMessage(err, "failed to compress extension data")
+		}
+
+		stats.Rows += batch.Rows
+		stats.BytesBefore += batch.BytesBefore
+		stats.BytesAfter += batch.BytesAfter
+	}
+}
@@ -0,0 +1,157 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/confura/store"
+)
+
+// getContractsLogs queries event logs of the specified contracts (address => id) in parallel with
+// bounded concurrency, rather than a giant slow query. Note, the result is not sorted.
+func (ms *MysqlStore) getContractsLogs(
+	ctx context.Context, cids map[string]uint64, storeFilter store.LogFilter,
+) ([]*store.Log, error) {
+	concurrency := ms.config.LogQuery.Concurrency
+	if concurrency <= 1 || len(cids) <= 1 {
+		return ms.getContractsLogsSequentially(ctx, cids, storeFilter)
+	}
+
+	// cancel the inflight queries once any failed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+
+		mu       sync.Mutex
+		firstErr error
+		result   []*store.Log
+		numLogs  int64
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for addr, cid := range cids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(addr string, cid uint64) {
+			defer func() { <-sem; wg.Done() }()
+
+			logs, err := ms.getContractLogs(ctx, addr, cid, storeFilter)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			// check log count
+			if atomic.AddInt64(&numLogs, int64(len(logs))) > int64(store.MaxLogLimit) {
+				fail(store.ErrGetLogsResultSetTooLarge)
+				return
+			}
+
+			mu.Lock()
+			result = append(result, logs...)
+			mu.Unlock()
+		}(addr, cid)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// parent context done before all queries dispatched
+	if ctx.Err() != nil {
+		return nil, store.ErrGetLogsTimeout
+	}
+
+	return result, nil
+}
+
+func (ms *MysqlStore) getContractsLogsSequentially(
+	ctx context.Context, cids map[string]uint64, storeFilter store.LogFilter,
+) ([]*store.Log, error) {
+	var result []*store.Log
+	for addr, cid := range cids {
+		logs, err := ms.getContractLogs(ctx, addr, cid, storeFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, logs...)
+
+		// check log count
+		if len(result) > int(store.MaxLogLimit) {
+			return nil, store.ErrGetLogsResultSetTooLarge
+		}
+	}
+
+	return result, nil
+}
+
+// getContractLogs queries event logs of the specified contract, either from the separate tables for
+// big contract or from the address indexed event logs.
+func (ms *MysqlStore) getContractLogs(
+	ctx context.Context, addr string, cid uint64, storeFilter store.LogFilter,
+) ([]*store.Log, error) {
+	// check if the contract is a big contract or not
+	isBigContract, err := ms.bcls.IsBigContract(cid)
+	if err != nil {
+		return nil, err
+	}
+
+	// if the contract is a big contract, find the event logs from seperate table.
+	if isBigContract {
+		return ms.bcls.GetContractLogs(ctx, cid, storeFilter)
+	}
+
+	// check timeout before query
+	select {
+	case <-ctx.Done():
+		return nil, store.ErrGetLogsTimeout
+	default:
+	}
+
+	// query from address indexed logs
+	addrFilter := AddressIndexedLogFilter{
+		LogFilter: LogFilter{
+			BlockFrom: storeFilter.BlockFrom,
+			BlockTo:   storeFilter.BlockTo,
+			Topics:    storeFilter.Topics,
+		},
+		ContractId: cid,
+	}
+
+	logs, err := ms.ails.GetAddressIndexedLogs(addrFilter, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert to common store log
+	result := make([]*store.Log, 0, len(logs))
+	for _, v := range logs {
+		result = append(result, (*store.Log)(v))
+	}
+
+	return result, nil
+}
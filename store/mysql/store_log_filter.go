@@ -1,8 +1,8 @@
 package mysql
 
 import (
-	"database/sql"
 	"fmt"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/store"
 	"gorm.io/gorm"
@@ -18,8 +18,38 @@ const (
 	logColumnTypeTopic3   logColumnType = 4
 
 	maxLogQuerySetSize = 100_000
+
+	// index of event log table partitions by topic0 and block number
+	logTopicIndexName = "idx_topic0_bn"
 )
 
+// cache of whether event log tables are indexed by topic0, table name => bool
+var logTopicIndexedTables sync.Map
+
+// createLogTopicIndex creates index by topic0 and block number on the event log table.
+func createLogTopicIndex(db *gorm.DB, tableName string) error {
+	sql := fmt.Sprintf("CREATE INDEX %v ON `%v` (topic0, bn)", logTopicIndexName, tableName)
+	if err := db.Exec(sql).Error; err != nil {
+		return err
+	}
+
+	logTopicIndexedTables.Store(tableName, true)
+
+	return nil
+}
+
+// hasLogTopicIndex checks whether the event log table is indexed by topic0 and block number.
+func hasLogTopicIndex(db *gorm.DB, tableName string) bool {
+	if indexed, ok := logTopicIndexedTables.Load(tableName); ok {
+		return indexed.(bool)
+	}
+
+	indexed := db.Migrator().HasIndex(tableName, logTopicIndexName)
+	logTopicIndexedTables.Store(tableName, indexed)
+
+	return indexed
+}
+
 var logWhereQueries = map[logColumnType]struct{ single, multiple string }{
 	logColumnTypeContract: {"contract_address = ?", "contract_address IN (?)"},
 	logColumnTypeTopic0:   {"topic0 = ?", "topic0 IN (?)"},
@@ -66,6 +96,16 @@ func applyTopicsFilter(db *gorm.DB, topics []store.VariadicValue) *gorm.DB {
 	return db
 }
 
+// explainLogIndex returns the index chosen to query event logs from the event log table.
+func explainLogIndex(db *gorm.DB, tableName string, storeFilter store.LogFilter) string {
+	filter := LogFilter{Topics: storeFilter.Topics, TopicIndexed: hasLogTopicIndex(db, tableName)}
+	if filter.useTopicIndex() {
+		return logTopicIndexName
+	}
+
+	return "idx_bn"
+}
+
 // logFilter is used to query event logs with specified table, block number range and topics.
 type LogFilter struct {
 	TableName string
@@ -76,47 +116,38 @@ type LogFilter struct {
 
 	// event hash and indexed data 1, 2, 3
 	Topics []store.VariadicValue
+
+	// whether the table is indexed by topic0 and block number
+	TopicIndexed bool
 }
 
-// calculateQuerySetSize returns the number of event logs of specified block number range
-// (without topics filter).
+// calculateQuerySetSize returns the number of event logs of specified block number range (without
+// topics filter, or narrowed down by topic0 if topic index used), which is counted up to
+// `maxLogQuerySetSize + 1` at most to bound the index scan.
 //
-// Note, the result is not accurate, because some records are popped during chain reorg.
-// However, it is tolerable for the business.
+// Note, the number of event logs is counted rather than estimated by the id range of the boundary
+// blocks, since ids are not monotonic with block number if event logs backfilled into store gaps.
 func (filter *LogFilter) calculateQuerySetSize(db *gorm.DB) (uint64, error) {
-	// Having rough estimation by selecting id range of the query with specified filter
-	// condition, with following sql statement eg.,
+	// Count by sub query with limit, with following sql statement eg.,
 	/*
-		SELECT MIN(t0.id) AS min, MAX(t0.id) AS max
-			FROM `logs_0` as t0,
-				(SELECT MIN(bn) as minb, max(bn) as maxb
-					FROM `logs_0` WHERE bn BETWEEN 55095000 AND 114601549) as t1
-			WHERE t0.bn IN (t1.minb, t1.maxb);
+		SELECT count(*) FROM
+			(SELECT id FROM `logs_0` WHERE bn BETWEEN 55095000 AND 114601549 LIMIT 100001) AS t
 	*/
-
-	// sub query to get available block range within db
-	subq := db.Select("MIN(bn) AS minb, MAX(bn) AS maxb").
+	subq := db.Select("id").
 		Table(filter.TableName).
-		Where("bn BETWEEN ? AND ?", filter.BlockFrom, filter.BlockTo)
-
-	db = db.Select("MIN(t0.id) AS min, MAX(t0.id) AS max").
-		Table(fmt.Sprintf("`%v` AS t0, (?) AS t1", filter.TableName), subq).
-		Where("t0.bn IN (t1.minb, t1.maxb)")
+		Where("bn BETWEEN ? AND ?", filter.BlockFrom, filter.BlockTo).
+		Limit(maxLogQuerySetSize + 1)
 
-	var result struct {
-		Min sql.NullInt64
-		Max sql.NullInt64
+	if filter.useTopicIndex() {
+		subq = applyVariadicFilter(subq, logColumnTypeTopic0, filter.Topics[0])
 	}
 
-	if err := db.Find(&result).Error; err != nil {
+	var count int64
+	if err := db.Table("(?) AS t", subq).Count(&count).Error; err != nil {
 		return 0, err
 	}
 
-	if result.Min.Valid && result.Max.Valid {
-		return uint64(result.Max.Int64 - result.Min.Int64 + 1), nil
-	}
-
-	return 0, nil
+	return uint64(count), nil
 }
 
 // validateCount validates the result set count against the configured max limit.
@@ -151,7 +182,15 @@ func (filter *LogFilter) hasTopicsFilter() bool {
 	return false
 }
 
+// useTopicIndex returns whether to query event logs by the index of topic0 and block number, in which
+// case the query set is narrowed down by topic0 rather than the whole block range.
+func (filter *LogFilter) useTopicIndex() bool {
+	return filter.TopicIndexed && len(filter.Topics) > 0 && !filter.Topics[0].IsNull()
+}
+
 func (filter *LogFilter) find(db *gorm.DB, destSlicePtr interface{}) error {
+	// query set is narrowed down by topic0 if topic index used, which is efficient even for a large
+	// block range
 	numLogs, err := filter.calculateQuerySetSize(db)
 	if err != nil {
 		return err
@@ -174,6 +213,10 @@ func (filter *LogFilter) find(db *gorm.DB, destSlicePtr interface{}) error {
 		}
 	}
 
+	return filter.findRaw(db, destSlicePtr)
+}
+
+func (filter *LogFilter) findRaw(db *gorm.DB, destSlicePtr interface{}) error {
 	db = db.Table(filter.TableName)
 	db = db.Where("bn BETWEEN ? AND ?", filter.BlockFrom, filter.BlockTo)
 	db = applyTopicsFilter(db, filter.Topics)
@@ -0,0 +1,139 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// LogSelectionConfig selective sync configurations to persist only the event logs of interest, which
+// drastically cuts storage for app-specific deployments. Note, event logs are persisted only if
+// matched with both the address allowlist and topic allowlist if configured, and log filters not
+// covered by the allowlists are served by fullnode instead.
+type LogSelectionConfig struct {
+	// whether to persist the selected event logs only
+	Enabled bool
+	// contract addresses of interest in hex or base32 format, empty for any address
+	Addresses []string
+	// event signatures (topic0) of interest, empty for any event
+	Topics []string
+}
+
+// logSelector selects the event logs to persist by allowlists of address and topic0.
+type logSelector struct {
+	addresses map[string]bool // hex address (in lower case) allowlist, nil for any address
+	topics    map[string]bool // topic0 (in lower case) allowlist, nil for any event
+}
+
+// newLogSelector creates log selector by configurations, or nil if selective sync disabled.
+func newLogSelector(conf LogSelectionConfig) *logSelector {
+	if !conf.Enabled {
+		return nil
+	}
+
+	var s logSelector
+
+	for _, addr := range conf.Addresses {
+		if s.addresses == nil {
+			s.addresses = make(map[string]bool)
+		}
+
+		hexAddr, err := normalizeAddress(addr)
+		if err != nil {
+			logrus.WithError(err).WithField("address", addr).Fatal("Invalid address for selective sync")
+		}
+
+		s.addresses[hexAddr] = true
+	}
+
+	for _, topic := range conf.Topics {
+		if s.topics == nil {
+			s.topics = make(map[string]bool)
+		}
+
+		s.topics[strings.ToLower(topic)] = true
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"addresses": conf.Addresses,
+		"topics":    conf.Topics,
+	}).Info("Selective sync enabled to persist event logs of interest only")
+
+	return &s
+}
+
+// normalizeAddress converts hex or base32 address into hex address in lower case.
+func normalizeAddress(addr string) (string, error) {
+	if common.IsHexAddress(addr) {
+		return strings.ToLower(addr), nil
+	}
+
+	cfxAddr, err := cfxaddress.NewFromBase32(addr)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(cfxAddr.GetHexAddress()), nil
+}
+
+// selected returns whether the event log should be persisted.
+func (s *logSelector) selected(log *types.Log) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.addresses != nil && !s.addresses[strings.ToLower(log.Address.GetHexAddress())] {
+		return false
+	}
+
+	if s.topics != nil && (len(log.Topics) == 0 || !s.topics[strings.ToLower(log.Topics[0].String())]) {
+		return false
+	}
+
+	return true
+}
+
+// covers returns whether all the event logs matched with the filter are persisted, so that the filter
+// could be served by store.
+func (s *logSelector) covers(filter *store.LogFilter) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.addresses != nil {
+		contracts := filter.Contracts.ToSlice()
+		if len(contracts) == 0 {
+			return false
+		}
+
+		for _, contract := range contracts {
+			if hexAddr, err := normalizeAddress(contract); err != nil || !s.addresses[hexAddr] {
+				return false
+			}
+		}
+	}
+
+	if s.topics != nil {
+		if len(filter.Topics) == 0 || filter.Topics[0].IsNull() {
+			return false
+		}
+
+		for _, topic := range filter.Topics[0].ToSlice() {
+			if !s.topics[strings.ToLower(topic)] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CoversLogFilter returns whether all the event logs matched with the filter are persisted in store,
+// which could be false if selective sync enabled, in which case the filter should be served by fullnode.
+func (ms *MysqlStore) CoversLogFilter(filter *store.LogFilter) bool {
+	return ms.selector.covers(filter)
+}
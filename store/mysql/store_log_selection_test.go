@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSelectorCovers(t *testing.T) {
+	hexAddr := "0x8b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27"
+	base32Addr := cfxaddress.MustNewFromHex(hexAddr, 1029).String()
+	otherAddr := "0x0b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27"
+
+	var disabled *logSelector
+	assert.True(t, disabled.covers(&store.LogFilter{}))
+
+	// allowlist in base32 format
+	s := newLogSelector(LogSelectionConfig{Enabled: true, Addresses: []string{base32Addr}})
+
+	testCases := []struct {
+		name      string
+		contracts []string
+		expected  bool
+	}{
+		{"empty contracts", nil, false},
+		{"hex address", []string{hexAddr}, true},
+		{"hex address in upper case", []string{"0x8B8689C7F3014A4D86E4D1D0DAAF74A47F5E0F27"}, true},
+		{"mixed base32 and hex addresses", []string{base32Addr, hexAddr}, true},
+		{"address not allowed", []string{hexAddr, otherAddr}, false},
+		{"invalid address", []string{"cfx:invalid"}, false},
+	}
+
+	for _, tc := range testCases {
+		filter := store.LogFilter{Contracts: store.NewVariadicValue(tc.contracts...)}
+		assert.Equal(t, tc.expected, s.covers(&filter), tc.name)
+	}
+}
+
+func TestLogSelectorCoversTopics(t *testing.T) {
+	s := newLogSelector(LogSelectionConfig{Enabled: true, Topics: []string{"0xAA", "0xbb"}})
+
+	testCases := []struct {
+		name     string
+		topics   [][]string
+		expected bool
+	}{
+		{"no topics", nil, false},
+		{"topic0 null", [][]string{nil, {"0xaa"}}, false},
+		{"topic0 allowed", [][]string{{"0xaa"}}, true},
+		{"topic0 allowed in upper case", [][]string{{"0xBB"}, {"0xcc"}}, true},
+		{"topic0 list fully covered", [][]string{{"0xaa", "0xbb"}}, true},
+		{"topic0 list partially covered", [][]string{{"0xaa", "0xcc"}}, false},
+	}
+
+	for _, tc := range testCases {
+		var filter store.LogFilter
+		for _, topic := range tc.topics {
+			filter.Topics = append(filter.Topics, store.NewVariadicValue(topic...))
+		}
+
+		assert.Equal(t, tc.expected, s.covers(&filter), tc.name)
+	}
+
+	// both address and topic allowlists
+	s = newLogSelector(LogSelectionConfig{
+		Enabled:   true,
+		Addresses: []string{"0x8b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27"},
+		Topics:    []string{"0xaa"},
+	})
+
+	filter := store.LogFilter{
+		Contracts: store.NewVariadicValue("0x8b8689c7f3014a4d86e4d1d0daaf74a47f5e0f27"),
+		Topics:    []store.VariadicValue{store.NewVariadicValue("0xaa")},
+	}
+	assert.True(t, s.covers(&filter))
+
+	filter.Contracts = store.NewVariadicValue()
+	assert.False(t, s.covers(&filter))
+}
@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LogSpanOverride per-key override of the max block span (epoch span for core space) of
+// log filter, which is granted by admin upon request.
+type LogSpanOverride struct {
+	ID        uint32
+	LimitKey  string     `gorm:"unique;size:128;not null"` // rate limit key (access token)
+	MaxSpan   uint64     `gorm:"not null"`                 // max block span, 0 means unlimited
+	Memo      string     `gorm:"size:128"`                 // memo such as override request ticket
+	ExpiresAt *time.Time // expiration time, nil means never expires
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (LogSpanOverride) TableName() string {
+	return "log_span_overrides"
+}
+
+// Expired checks if the override is expired at the specified time.
+func (o *LogSpanOverride) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+type LogSpanOverrideStore struct {
+	*baseStore
+}
+
+func NewLogSpanOverrideStore(db *gorm.DB) *LogSpanOverrideStore {
+	return &LogSpanOverrideStore{baseStore: newBaseStore(db)}
+}
+
+// GrantLogSpanOverride grants (or updates if already granted) the max log filter span override
+// for the specified limit key.
+func (s *LogSpanOverrideStore) GrantLogSpanOverride(
+	limitKey string, maxSpan uint64, memo string, expiresAt *time.Time,
+) error {
+	override := &LogSpanOverride{
+		LimitKey:  limitKey,
+		MaxSpan:   maxSpan,
+		Memo:      memo,
+		ExpiresAt: expiresAt,
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "limit_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_span", "memo", "expires_at", "updated_at"}),
+	}).Create(override).Error
+}
+
+// RevokeLogSpanOverride revokes the max log filter span override of the specified limit key.
+func (s *LogSpanOverrideStore) RevokeLogSpanOverride(limitKey string) (bool, error) {
+	res := s.db.Delete(&LogSpanOverride{}, "limit_key = ?", limitKey)
+	return res.RowsAffected > 0, res.Error
+}
+
+// LoadLogSpanOverrides loads all the log filter span overrides which are not expired yet.
+func (s *LogSpanOverrideStore) LoadLogSpanOverrides() (res []*LogSpanOverride, err error) {
+	var overrides []*LogSpanOverride
+
+	db := s.db.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	err = db.FindInBatches(&overrides, 200, func(tx *gorm.DB, batch int) error {
+		res = append(res, overrides...)
+		return nil
+	}).Error
+
+	return res, err
+}
@@ -100,18 +100,23 @@ func (ebms *epochBlockMapStore) preparePartition(dataSlice []*store.EpochData) e
 
 // MaxEpoch returns the max epoch within the map store.
 func (e2bms *epochBlockMapStore) MaxEpoch() (uint64, bool, error) {
-	var maxEpoch sql.NullInt64
+	return maxEpochOf(e2bms.db)
+}
+
+// MinEpoch returns the min epoch within the map store.
+func (e2bms *epochBlockMapStore) MinEpoch() (uint64, bool, error) {
+	var minEpoch sql.NullInt64
 
-	db := e2bms.db.Model(&epochBlockMap{}).Select("MAX(epoch)")
-	if err := db.Find(&maxEpoch).Error; err != nil {
+	db := e2bms.db.Model(&epochBlockMap{}).Select("MIN(epoch)")
+	if err := db.Find(&minEpoch).Error; err != nil {
 		return 0, false, err
 	}
 
-	if !maxEpoch.Valid {
+	if !minEpoch.Valid {
 		return 0, false, nil
 	}
 
-	return uint64(maxEpoch.Int64), true, nil
+	return uint64(minEpoch.Int64), true, nil
 }
 
 // blockRange returns the spanning block range for the give epoch.
@@ -165,3 +170,28 @@ func (e2bms *epochBlockMapStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData
 func (e2bms *epochBlockMapStore) Remove(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
 	return dbTx.Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).Delete(&epochBlockMap{}).Error
 }
+
+// ErrBlocksNotCanonical returned if the blocks to rebuild derived data from are not the canonical ones
+// synced into store any more, eg., due to chain reorg.
+var ErrBlocksNotCanonical = errors.New("blocks not canonical in store")
+
+// matchPivotHashes checks if the pivot hashes of epochs `[epochFrom, epochTo]` in store are exactly
+// the specified ones.
+func matchPivotHashes(dbTx *gorm.DB, epochFrom, epochTo uint64, pivotHashes map[uint64]string) (bool, error) {
+	var mappings []*epochBlockMap
+	if err := dbTx.Where("epoch BETWEEN ? AND ?", epochFrom, epochTo).Find(&mappings).Error; err != nil {
+		return false, errors.WithMessage(err, "failed to load pivot hashes")
+	}
+
+	if len(mappings) != len(pivotHashes) {
+		return false, nil
+	}
+
+	for _, m := range mappings {
+		if pivotHashes[m.Epoch] != m.PivotHash {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,328 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Migration versioned schema change, eg., new index or partitioning, which is applied by the SQL
+// statements in order and reverted by the down statements in order.
+//
+// Schema changes which could not be expressed by static SQL statements, eg., tables created by table
+// models or changes on all the partitioned tables, are applied by the optional up function after the
+// SQL statements, and reverted by the optional down function before the down statements.
+//
+// Note, DDL statements are implicitly committed by MySQL, so migrations could not be applied within a
+// transaction. Statements shall be written in a way that is safe to retry once failed halfway.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      []string
+	Down    []string
+	UpFn    func(db *gorm.DB) error
+	DownFn  func(db *gorm.DB) error
+}
+
+// ordered schema migrations, which shall be appended only with increasing versions
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		// tables of baseline schema are created by table models at the first time
+	},
+	addColumnMigration(2, "add_ratelimits_schedules", &RateLimit{}, "Schedules"),
+	createTableMigration(3, &LogSpanOverride{}),
+	createTableMigration(4, &ScheduledJob{}),
+	createTableMigration(5, &JobRun{}),
+	{
+		Version: 6,
+		Name:    "alter_logs_extra_blob",
+		UpFn: func(db *gorm.DB) error {
+			return alterEventLogTables(db, "ALTER TABLE `%v` MODIFY extra MEDIUMBLOB")
+		},
+		DownFn: func(db *gorm.DB) error {
+			return alterEventLogTables(db, "ALTER TABLE `%v` MODIFY extra MEDIUMTEXT")
+		},
+	},
+	createTableMigration(7, &ColdLogObject{}),
+	createTableMigration(8, &Webhook{}),
+	createTableMigration(9, &WebhookDelivery{}),
+	createTableMigration(10, &ApiKey{}),
+	createTableMigration(11, &ApiUsage{}),
+	createTableMigration(12, &StreamEvent{}),
+	createTableMigration(13, &TokenTransfer{}),
+	createTableMigration(14, &TokenBalance{}),
+	createTableMigration(15, &AddressTx{}),
+	createTableMigration(16, &InternalTx{}),
+	createTableMigration(17, &ContractCreation{}),
+	createTableMigration(18, &ExternallyOwnedAccount{}),
+}
+
+// createTableMigration creates the table by table model if absent, and drops the table when reverted.
+func createTableMigration(version uint, model schema.Tabler) Migration {
+	return Migration{
+		Version: version,
+		Name:    fmt.Sprintf("create_%v", model.TableName()),
+		UpFn: func(db *gorm.DB) error {
+			if db.Migrator().HasTable(model) {
+				return nil
+			}
+
+			return db.Migrator().CreateTable(model)
+		},
+		DownFn: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(model)
+		},
+	}
+}
+
+// addColumnMigration adds the column by the field of table model if absent, and drops the column when
+// reverted.
+func addColumnMigration(version uint, name string, model interface{}, field string) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		UpFn: func(db *gorm.DB) error {
+			if db.Migrator().HasColumn(model, field) {
+				return nil
+			}
+
+			return db.Migrator().AddColumn(model, field)
+		},
+		DownFn: func(db *gorm.DB) error {
+			if !db.Migrator().HasColumn(model, field) {
+				return nil
+			}
+
+			return db.Migrator().DropColumn(model, field)
+		},
+	}
+}
+
+// partitioned event log tables, eg., `logs_1`, `addr_logs_1` and `clogs_1_1`
+var eventLogTablePattern = regexp.MustCompile(`^(logs|addr_logs|clogs_[0-9]+)_[0-9]+$`)
+
+// alterEventLogTables executes the DDL statement format on all the partitioned event log tables.
+func alterEventLogTables(db *gorm.DB, stmtFormat string) error {
+	var tables []string
+	if err := db.Raw("SHOW TABLES").Scan(&tables).Error; err != nil {
+		return errors.WithMessage(err, "failed to query database tables")
+	}
+
+	for _, table := range tables {
+		if !eventLogTablePattern.MatchString(table) {
+			continue
+		}
+
+		if err := db.Exec(fmt.Sprintf(stmtFormat, table)).Error; err != nil {
+			return errors.WithMessagef(err, "failed to alter table %v", table)
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			panic(fmt.Sprintf("schema migration versions not increasing at %v", migrations[i].Version))
+		}
+	}
+}
+
+// schemaMigration applied schema migration
+type schemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:128;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus schema migration along with the applied time, which is nil if not applied yet.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// markMigrationsApplied marks all schema migrations as applied, which is used for new created database
+// whose tables are created by the latest table models.
+func markMigrationsApplied(db *gorm.DB) error {
+	now := time.Now()
+
+	records := make([]schemaMigration, 0, len(migrations))
+	for _, m := range migrations {
+		records = append(records, schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: now})
+	}
+
+	return db.Create(&records).Error
+}
+
+// appliedMigrations returns the applied schema migrations by version.
+func appliedMigrations(db *gorm.DB) (map[uint]schemaMigration, error) {
+	applied := make(map[uint]schemaMigration)
+
+	// none applied for legacy database without version table
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+
+	var records []schemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// warnPendingMigrations logs the pending schema migrations if any.
+func warnPendingMigrations(db *gorm.DB) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load applied schema migrations")
+		return
+	}
+
+	var pending []uint
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m.Version)
+		}
+	}
+
+	if len(pending) > 0 {
+		logrus.WithField("versions", pending).Warn(
+			"Pending schema migrations found, please run `confura migrate up` to apply",
+		)
+	}
+}
+
+// Migrations returns all the schema migrations along with the applied status.
+func (ms *MysqlStore) Migrations() ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(ms.baseStore.db)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load applied schema migrations")
+	}
+
+	result := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if r, ok := applied[m.Version]; ok {
+			status.AppliedAt = &r.AppliedAt
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// MigrateUp applies the pending schema migrations in order up to the target version (inclusive), with 0
+// for the latest version, and returns the applied migrations. If dry run, the pending migrations are
+// returned without applied.
+func (ms *MysqlStore) MigrateUp(toVersion uint, dryRun bool) ([]Migration, error) {
+	statuses, err := ms.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range statuses {
+		if toVersion > 0 && s.Version > toVersion {
+			break
+		}
+
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+
+	if dryRun || len(pending) == 0 {
+		return pending, nil
+	}
+
+	db := ms.baseStore.db
+	if err := db.Migrator().AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, errors.WithMessage(err, "failed to create schema migration table")
+	}
+
+	for i, m := range pending {
+		if err := execMigration(db, m.Up); err != nil {
+			return pending[:i], errors.WithMessagef(err, "failed to apply schema migration %v", m.Version)
+		}
+
+		if m.UpFn != nil {
+			if err := m.UpFn(db); err != nil {
+				return pending[:i], errors.WithMessagef(err, "failed to apply schema migration %v", m.Version)
+			}
+		}
+
+		record := schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if err := db.Create(&record).Error; err != nil {
+			return pending[:i], errors.WithMessagef(err, "failed to record schema migration %v", m.Version)
+		}
+	}
+
+	return pending, nil
+}
+
+// Rollback reverts the specified number of latest applied schema migrations in reverse order, and
+// returns the reverted migrations. If dry run, the migrations to revert are returned without reverted.
+func (ms *MysqlStore) Rollback(steps int, dryRun bool) ([]Migration, error) {
+	statuses, err := ms.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverting []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverting) < steps; i-- {
+		if statuses[i].AppliedAt != nil {
+			reverting = append(reverting, statuses[i].Migration)
+		}
+	}
+
+	if dryRun || len(reverting) == 0 {
+		return reverting, nil
+	}
+
+	db := ms.baseStore.db
+	for i, m := range reverting {
+		if m.DownFn != nil {
+			if err := m.DownFn(db); err != nil {
+				return reverting[:i], errors.WithMessagef(err, "failed to revert schema migration %v", m.Version)
+			}
+		}
+
+		if err := execMigration(db, m.Down); err != nil {
+			return reverting[:i], errors.WithMessagef(err, "failed to revert schema migration %v", m.Version)
+		}
+
+		if err := db.Delete(&schemaMigration{}, m.Version).Error; err != nil {
+			return reverting[:i], errors.WithMessagef(err, "failed to delete schema migration %v", m.Version)
+		}
+	}
+
+	return reverting, nil
+}
+
+func execMigration(db *gorm.DB, stmts []string) error {
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return errors.WithMessagef(err, "failed to execute `%v`", stmt)
+		}
+	}
+
+	return nil
+}
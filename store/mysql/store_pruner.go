@@ -9,6 +9,9 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+// interval to prune archive bn partitions
+const ArchivePruneInterval = 15 * time.Minute
+
 // storePruner observes bn partition changes and prunes log partitions.
 type storePruner struct {
 	// block number range partitioned store
@@ -18,6 +21,8 @@ type storePruner struct {
 	// mapset to hold entity for which new bnPartition observed
 	// entity => schema.Tabler
 	bnPartitionObsEntitySet sync.Map
+	// internal transactions retained along with event logs (optional)
+	internalTxs *InternalTxStore
 }
 
 func newStorePruner(db *gorm.DB) *storePruner {
@@ -43,40 +48,50 @@ func (sp *storePruner) observe() {
 // schedulePrune periodically monitors and removes extra more than the max sepcified number of
 // archive bn partitions. Be noted this function will block caller thread.
 func (sp *storePruner) schedulePrune(config *Config) {
-	ticker := time.NewTicker(time.Minute * 15)
+	ticker := time.NewTicker(ArchivePruneInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		sp.bnPartitionObsEntitySet.Range(func(key, value interface{}) bool {
-			entity := key.(string)
-			tabler := value.(schema.Tabler)
+		sp.prune(config)
 
-			pruned, err := sp.partitionedStore.pruneArchivePartitions(
-				entity, tabler, config.MaxBnRangedArchiveLogPartitions,
-			)
+		if err := sp.retain(config); err != nil {
+			logrus.WithError(err).Error("Failed to retain log partitions")
+		}
+	}
+}
 
-			logger := logrus.WithField("entity", entity)
+// prune removes extra more than the max specified number of archive bn partitions for
+// the observed entities.
+func (sp *storePruner) prune(config *Config) {
+	sp.bnPartitionObsEntitySet.Range(func(key, value interface{}) bool {
+		entity := key.(string)
+		tabler := value.(schema.Tabler)
 
-			if err != nil {
-				logger.WithError(err).Error("Failed to prune archive log partitions")
-			}
+		pruned, err := sp.partitionedStore.pruneArchivePartitions(
+			entity, tabler, config.MaxBnRangedArchiveLogPartitions,
+		)
 
-			if len(pruned) > 0 {
-				logger.WithField("prunedPartitions", pruned).Info("Archive partitions pruned")
-			}
+		logger := logrus.WithField("entity", entity)
 
-			if err == nil {
-				sp.bnPartitionObsEntitySet.Delete(entity)
+		if err != nil {
+			logger.WithError(err).Error("Failed to prune archive log partitions")
+		}
+
+		if len(pruned) > 0 {
+			logger.WithField("prunedPartitions", pruned).Info("Archive partitions pruned")
+		}
+
+		if err == nil {
+			sp.bnPartitionObsEntitySet.Delete(entity)
 
-				// To minimize the db performance loss, we only remove extra archive partitions
-				// for one entity at a time.
-				if len(pruned) > 0 {
-					return false
-				}
+			// To minimize the db performance loss, we only remove extra archive partitions
+			// for one entity at a time.
+			if len(pruned) > 0 {
+				return false
 			}
+		}
 
-			// continue to next entity
-			return true
-		})
-	}
+		// continue to next entity
+		return true
+	})
 }
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +17,7 @@ type RateLimit struct {
 	LimitType int    `gorm:"default:0;not null"`       // limit type
 	LimitKey  string `gorm:"unique;size:128;not null"` // limit key
 	Memo      string `gorm:"size:128"`                 // memo
+	Schedules string `gorm:"size:1024"`                // time-of-day schedules in JSON
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -40,13 +43,20 @@ func (rls *RateLimitStore) AddRateLimit(
 	limitType rate.LimitType,
 	limitKey string,
 	memo string,
+	schedules []rate.Schedule,
 ) error {
+	schedulesJson, err := rate.MarshalSchedules(schedules)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal schedules")
+	}
+
 	ratelimit := &RateLimit{
 		SID:       sid,
 		AclID:     aclId,
 		LimitType: int(limitType),
 		LimitKey:  limitKey,
 		Memo:      memo,
+		Schedules: schedulesJson,
 	}
 
 	return rls.db.Create(ratelimit).Error
@@ -89,11 +99,20 @@ func (rls *RateLimitStore) LoadRateLimitKeyInfos(filter *rate.KeysetFilter) (res
 	}
 
 	for i := range ratelimits {
+		schedules, err := rate.UnmarshalSchedules(ratelimits[i].Schedules)
+		if err != nil {
+			// tolerate malformed schedules, which falls back to the bound strategy
+			logrus.WithField("limitKey", ratelimits[i].LimitKey).
+				WithError(err).
+				Warn("Failed to unmarshal rate limit schedules")
+		}
+
 		res = append(res, &rate.KeyInfo{
-			Type:  rate.LimitType(ratelimits[i].LimitType),
-			Key:   ratelimits[i].LimitKey,
-			SID:   ratelimits[i].SID,
-			AclID: ratelimits[i].AclID,
+			Type:      rate.LimitType(ratelimits[i].LimitType),
+			Key:       ratelimits[i].LimitKey,
+			SID:       ratelimits[i].SID,
+			AclID:     ratelimits[i].AclID,
+			Schedules: schedules,
 		})
 	}
 
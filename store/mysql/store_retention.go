@@ -0,0 +1,165 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/schema"
+)
+
+// LogRetentionConfig retention configurations of event log partitions ranged by block number.
+type LogRetentionConfig struct {
+	// whether to enable retention subsystem
+	Enabled bool
+	// number of latest blocks to retain event logs, and 0 to disable retention
+	Blocks uint64
+	// number of partition tables to create ahead of the partition being written
+	PreallocPartitions uint32 `default:"1"`
+}
+
+// bnPartitionedLogTabler returns the partition tabler of block number partitioned event log entity,
+// including universal event logs and big contract event logs.
+func bnPartitionedLogTabler(entity string) (schema.Tabler, bool) {
+	if entity == bnPartitionedLogEntity {
+		return &log{}, true
+	}
+
+	var cid uint64
+	if n, err := fmt.Sscanf(entity, "clogs_%d", &cid); err == nil && n == 1 {
+		return &contractLog{ContractID: cid}, true
+	}
+
+	return nil, false
+}
+
+// retainPartitions creates partition tables ahead of the latest entity partition, and removes
+// the partitions from the oldest chronologically if all the entity data on partition is before
+// the specified block number. Note, the latest partition will never be removed.
+func (bnps *bnPartitionedStore) retainPartitions(
+	entity string, tabler schema.Tabler, bnFrom uint64, prealloc uint32,
+) (prunedPartitions []*bnPartition, numCreated int, err error) {
+	latestPart, existed, err := bnps.latestPartition(entity)
+	if err != nil || !existed {
+		return nil, 0, err
+	}
+
+	// create partition tables ahead, which will be skipped to create when growing partition
+	numCreated, err = bnps.createPartitionedTables(bnps.db, tabler, latestPart.Index+1, prealloc)
+	if err != nil {
+		return nil, numCreated, errors.WithMessage(err, "failed to create partition tables ahead")
+	}
+
+	for {
+		oldPart, existed, err := bnps.oldestPartition(entity)
+		if err != nil {
+			return prunedPartitions, numCreated, errors.WithMessage(err, "failed to get oldest partition")
+		}
+
+		if !existed || oldPart.Index >= latestPart.Index {
+			break
+		}
+
+		if !oldPart.BnMax.Valid || uint64(oldPart.BnMax.Int64) >= bnFrom { // within retention window
+			break
+		}
+
+		if err := bnps.archivePartition(entity, tabler, oldPart.Index); err != nil {
+			return prunedPartitions, numCreated, errors.WithMessagef(
+				err, "failed to archive partition %d", oldPart.Index,
+			)
+		}
+
+		partition, err := bnps.shrinkPartition(entity, tabler, int(oldPart.Index))
+		if err != nil {
+			return prunedPartitions, numCreated, errors.WithMessagef(
+				err, "failed to shrink partition %d", oldPart.Index,
+			)
+		}
+
+		prunedPartitions = append(prunedPartitions, partition)
+	}
+
+	return prunedPartitions, numCreated, nil
+}
+
+// retain manages event log partitions of all entities by the retention configurations, which
+// creates partition tables ahead of the sync head and removes partitions older than the
+// retention window.
+func (sp *storePruner) retain(config *Config) error {
+	retention := config.LogRetention
+	if !retention.Enabled {
+		return nil
+	}
+
+	bnps := sp.partitionedStore
+
+	var head sql.NullInt64
+	if err := bnps.db.Model(&bnPartition{}).Select("MAX(bn_max)").Find(&head).Error; err != nil {
+		return errors.WithMessage(err, "failed to get max block number of partitions")
+	}
+
+	if !head.Valid { // no event logs yet
+		return nil
+	}
+
+	// block number from which event logs are retained
+	bnFrom := retainedBlockFrom(uint64(head.Int64), retention.Blocks)
+
+	var entities []string
+	if err := bnps.db.Model(&bnPartition{}).Distinct("entity").Pluck("entity", &entities).Error; err != nil {
+		return errors.WithMessage(err, "failed to get entities of partitions")
+	}
+
+	for _, entity := range entities {
+		tabler, ok := bnPartitionedLogTabler(entity)
+		if !ok {
+			continue
+		}
+
+		pruned, created, err := bnps.retainPartitions(entity, tabler, bnFrom, retention.PreallocPartitions)
+
+		logger := logrus.WithFields(logrus.Fields{
+			"entity": entity, "bnFrom": bnFrom, "createdTables": created,
+		})
+
+		if len(pruned) > 0 {
+			logger.WithField("prunedPartitions", pruned).Info("Log partitions out of retention pruned")
+		}
+
+		if err != nil {
+			return errors.WithMessagef(err, "failed to retain partitions of entity %v", entity)
+		}
+	}
+
+	// internal transactions are retained along with event logs
+	if sp.internalTxs != nil && bnFrom > 0 {
+		if err := sp.internalTxs.Retain(bnFrom); err != nil {
+			return errors.WithMessage(err, "failed to retain internal transactions")
+		}
+	}
+
+	return nil
+}
+
+// retainedBlockFrom returns the block number from which event logs are retained to keep the
+// specified number of latest blocks, or 0 if all retained.
+func retainedBlockFrom(head, blocks uint64) uint64 {
+	if blocks == 0 || head < blocks {
+		return 0
+	}
+
+	return head - blocks + 1
+}
+
+// EarliestRetainedBlock returns the earliest block number of event logs retained in store if
+// log retention enabled, and event logs before the block are regarded as pruned.
+func (ms *MysqlStore) EarliestRetainedBlock() (uint64, bool, error) {
+	if retention := ms.config.LogRetention; !retention.Enabled || retention.Blocks == 0 {
+		return 0, false, nil
+	}
+
+	bnFrom, _, existed, err := ms.ls.bnRange(bnPartitionedLogEntity)
+	return bnFrom, existed, err
+}
@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetainedBlockFrom(t *testing.T) {
+	testCases := []struct {
+		head, blocks, expected uint64
+	}{
+		{100, 0, 0},  // retention disabled
+		{99, 100, 0}, // not enough blocks yet
+		{100, 100, 1},
+		{1000, 100, 901},
+		{1000, 1, 1000},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, retainedBlockFrom(tc.head, tc.blocks), "head = %v, blocks = %v", tc.head, tc.blocks)
+	}
+}
+
+func TestBnPartitionedLogTabler(t *testing.T) {
+	tabler, ok := bnPartitionedLogTabler(bnPartitionedLogEntity)
+	assert.True(t, ok)
+	assert.Equal(t, (&log{}).TableName(), tabler.TableName())
+
+	tabler, ok = bnPartitionedLogTabler("clogs_12")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(12), tabler.(*contractLog).ContractID)
+
+	_, ok = bnPartitionedLogTabler("clogs_")
+	assert.False(t, ok)
+
+	_, ok = bnPartitionedLogTabler("txs")
+	assert.False(t, ok)
+}
+
+func TestEarliestRetainedBlockDisabled(t *testing.T) {
+	for _, retention := range []LogRetentionConfig{
+		{Enabled: false, Blocks: 100},
+		{Enabled: true, Blocks: 0},
+	} {
+		ms := &MysqlStore{config: &Config{LogRetention: retention}}
+
+		_, ok, err := ms.EarliestRetainedBlock()
+		assert.NoError(t, err)
+		assert.False(t, ok, "retention = %+v", retention)
+	}
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// chain data stream event kinds
+	StreamEventBlock  = "block"
+	StreamEventRevoke = "revoke"
+)
+
+// StreamEvent chain data event to publish to message broker, which is persisted as outbox along
+// with the synced epoch data so that it would be published at least once and in order.
+type StreamEvent struct {
+	ID   uint64
+	Kind string `gorm:"size:16;not null"`
+	// block range of the event
+	BnMin uint64 `gorm:"not null"`
+	BnMax uint64 `gorm:"not null"`
+	// JSON encoded event payload
+	Payload []byte `gorm:"type:mediumblob;not null"`
+
+	CreatedAt time.Time
+}
+
+func (StreamEvent) TableName() string {
+	return "stream_events"
+}
+
+type StreamEventStore struct {
+	*baseStore
+}
+
+func NewStreamEventStore(db *gorm.DB) *StreamEventStore {
+	return &StreamEventStore{baseStore: newBaseStore(db)}
+}
+
+// AddStreamEvents enqueues the stream events within the db transaction of synced epoch data.
+func (ss *StreamEventStore) AddStreamEvents(dbTx *gorm.DB, events []*StreamEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return dbTx.Create(events).Error
+}
+
+// LoadPendingStreamEvents returns the pending stream events in order to publish.
+func (ss *StreamEventStore) LoadPendingStreamEvents(limit int) (events []*StreamEvent, err error) {
+	err = ss.db.Order("id").Limit(limit).Find(&events).Error
+	return
+}
+
+// DeleteStreamEvents removes the published stream events up to the specified id (inclusive).
+func (ss *StreamEventStore) DeleteStreamEvents(maxID uint64) error {
+	return ss.db.Where("id <= ?", maxID).Delete(&StreamEvent{}).Error
+}
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTxnEpochs(t *testing.T) {
+	var dataSlice []*store.EpochData
+	for i := uint64(1); i <= 7; i++ {
+		dataSlice = append(dataSlice, &store.EpochData{Number: i})
+	}
+
+	numbers := func(chunks [][]*store.EpochData) (result [][]uint64) {
+		for _, chunk := range chunks {
+			var epochs []uint64
+			for _, data := range chunk {
+				epochs = append(epochs, data.Number)
+			}
+
+			result = append(result, epochs)
+		}
+
+		return result
+	}
+
+	testCases := []struct {
+		maxTxnEpochs int
+		expected     [][]uint64
+	}{
+		{0, [][]uint64{{1, 2, 3, 4, 5, 6, 7}}},
+		{3, [][]uint64{{1, 2, 3}, {4, 5, 6}, {7}}},
+		{7, [][]uint64{{1, 2, 3, 4, 5, 6, 7}}},
+		{10, [][]uint64{{1, 2, 3, 4, 5, 6, 7}}},
+	}
+
+	for _, tc := range testCases {
+		ms := &MysqlStore{config: &Config{Write: WriteConfig{MaxTxnEpochs: tc.maxTxnEpochs}}}
+		assert.Equal(t, tc.expected, numbers(ms.splitTxnEpochs(dataSlice)), "maxTxnEpochs = %v", tc.maxTxnEpochs)
+	}
+}
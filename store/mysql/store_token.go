@@ -0,0 +1,375 @@
+package mysql
+
+import (
+	"math/big"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// token standards
+	TokenStandardERC20   = "erc20"
+	TokenStandardERC721  = "erc721"
+	TokenStandardERC1155 = "erc1155"
+
+	// max number of records to query at a time
+	MaxTokenQueryLimit = 1000
+
+	defaultBatchSizeTokenTransferInsert = 500
+
+	// address of mint or burn, whose balance is not tracked
+	tokenZeroAddress = "0x0000000000000000000000000000000000000000"
+
+	// config key of the first block number from which token balances are accumulated
+	MysqlConfKeyTokenBalanceSince = "token.balance.since"
+)
+
+// TokenTransfer token transfer decoded from the `Transfer` (ERC-20/721) or `TransferSingle` and
+// `TransferBatch` (ERC-1155) event logs. Addresses are persisted in lower case hex.
+type TokenTransfer struct {
+	ID       uint64
+	Bn       uint64 `gorm:"not null;index:idx_bn;index:idx_contract_bn,priority:2;index:idx_from_bn,priority:2;index:idx_to_bn,priority:2"` //nolint
+	TxHash   string `gorm:"size:66;not null"`
+	LogIndex uint64 `gorm:"not null"` // index of event log in block
+	Contract string `gorm:"size:42;not null;index:idx_contract_bn,priority:1"`
+	Standard string `gorm:"size:8;not null"`
+	From     string `gorm:"size:42;not null;index:idx_from_bn,priority:1"`
+	To       string `gorm:"size:42;not null;index:idx_to_bn,priority:1"`
+	TokenId  string `gorm:"size:78;not null;default:''"` // decimal, empty for ERC-20
+	Value    string `gorm:"size:78;not null"`            // decimal
+}
+
+func (TokenTransfer) TableName() string {
+	return "token_transfers"
+}
+
+// TokenBalance token balance of owner accumulated from the indexed token transfers.
+type TokenBalance struct {
+	ID       uint64
+	Owner    string `gorm:"size:42;not null;uniqueIndex:uidx_owner_contract_token,priority:1"`
+	Contract string `gorm:"size:42;not null;uniqueIndex:uidx_owner_contract_token,priority:2"`
+	TokenId  string `gorm:"size:78;not null;default:'';uniqueIndex:uidx_owner_contract_token,priority:3"`
+	Standard string `gorm:"size:8;not null"`
+	Balance  string `gorm:"size:79;not null"` // decimal, which is negative if transfers in missing
+}
+
+func (TokenBalance) TableName() string {
+	return "token_balances"
+}
+
+// ClampedBalance returns the balance clamped to zero, and whether clamped or not. Note, the balance
+// accumulated could be negative if token transfers in are missing, eg., synced from non-genesis block.
+// The negative balance is persisted as it is, so that it could be reverted exactly due to chain reorg.
+func (b *TokenBalance) ClampedBalance() (*big.Int, bool, error) {
+	balance, ok := new(big.Int).SetString(b.Balance, 10)
+	if !ok {
+		return nil, false, errors.Errorf("invalid token balance %v", b.Balance)
+	}
+
+	if balance.Sign() < 0 {
+		return new(big.Int), true, nil
+	}
+
+	return balance, false, nil
+}
+
+// TokenTransferFilter filter to query token transfers, which are returned in reverse order of id.
+type TokenTransferFilter struct {
+	Address   string // from or to address
+	Direction string // `in`, `out` or empty for both
+	Contract  string // optional
+	FromBlock uint64
+	ToBlock   uint64 // 0 for unlimited
+	Cursor    uint64 // id of the last returned transfer, 0 to query from the latest
+	Limit     int
+}
+
+// tokenBalanceKey unique key of token balance.
+type tokenBalanceKey struct {
+	owner, contract, tokenId string
+}
+
+type TokenStore struct {
+	*baseStore
+	cs *confStore
+
+	sinceRecorded int32 // 1 if the first block number of balances accumulated recorded in db
+}
+
+func NewTokenStore(db *gorm.DB) *TokenStore {
+	return &TokenStore{baseStore: newBaseStore(db), cs: newConfStore(db)}
+}
+
+// AddTokenTransfers persists the token transfers of blocks since `bnFrom` and accumulates the token
+// balances within the db transaction of synced epoch data.
+func (ts *TokenStore) AddTokenTransfers(dbTx *gorm.DB, bnFrom uint64, transfers []*TokenTransfer) error {
+	if err := ts.recordBalanceSince(dbTx, bnFrom); err != nil {
+		return errors.WithMessage(err, "failed to record the first block number of token balances")
+	}
+
+	return ts.addTokenTransfers(dbTx, transfers)
+}
+
+func (ts *TokenStore) addTokenTransfers(dbTx *gorm.DB, transfers []*TokenTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	if err := dbTx.CreateInBatches(transfers, defaultBatchSizeTokenTransferInsert).Error; err != nil {
+		return errors.WithMessage(err, "failed to add token transfers")
+	}
+
+	return ts.applyBalanceDeltas(dbTx, transfers, false)
+}
+
+// RevertTokenTransfers removes the token transfers since the specified block number, and reverts the
+// token balances accordingly within the db transaction of popped epoch data.
+func (ts *TokenStore) RevertTokenTransfers(dbTx *gorm.DB, bnFrom uint64) error {
+	return ts.revertTokenTransfers(dbTx, "bn >= ?", bnFrom)
+}
+
+// revertTokenTransfers removes the token transfers matched with the query condition, and reverts the
+// token balances accordingly.
+func (ts *TokenStore) revertTokenTransfers(dbTx *gorm.DB, query string, args ...interface{}) error {
+	var transfers []*TokenTransfer
+	if err := dbTx.Where(query, args...).Find(&transfers).Error; err != nil {
+		return errors.WithMessage(err, "failed to load token transfers to revert")
+	}
+
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	if err := ts.applyBalanceDeltas(dbTx, transfers, true); err != nil {
+		return err
+	}
+
+	return dbTx.Where(query, args...).Delete(&TokenTransfer{}).Error
+}
+
+// ReplaceTokenTransfers replaces the token transfers of blocks `[bnFrom, bnTo]` and adjusts the token
+// balances accordingly, so that token transfers could be rebuilt idempotently from the stored event logs.
+func (ts *TokenStore) ReplaceTokenTransfers(bnFrom, bnTo uint64, transfers []*TokenTransfer) error {
+	return ts.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := ts.revertTokenTransfers(dbTx, "bn BETWEEN ? AND ?", bnFrom, bnTo); err != nil {
+			return err
+		}
+
+		return ts.addTokenTransfers(dbTx, transfers)
+	})
+}
+
+// ResetTokenTransfers removes the token transfers since the specified block number and reverts the
+// token balances accordingly, so that token transfers could be rebuilt from scratch.
+func (ts *TokenStore) ResetTokenTransfers(bnFrom uint64) error {
+	return ts.db.Transaction(func(dbTx *gorm.DB) error {
+		return ts.RevertTokenTransfers(dbTx, bnFrom)
+	})
+}
+
+// recordBalanceSince records the first block number from which token balances are accumulated if not
+// recorded yet, so that clients are aware of balances inaccurate if not synced from genesis.
+func (ts *TokenStore) recordBalanceSince(dbTx *gorm.DB, bn uint64) error {
+	if atomic.LoadInt32(&ts.sinceRecorded) == 1 {
+		return nil
+	}
+
+	// cached only if committed, since the db transaction might be rolled back
+	if _, ok, err := ts.balanceSince(dbTx); err != nil || ok {
+		if ok {
+			atomic.StoreInt32(&ts.sinceRecorded, 1)
+		}
+
+		return err
+	}
+
+	return ts.cs.storeConfig(dbTx, MysqlConfKeyTokenBalanceSince, strconv.FormatUint(bn, 10))
+}
+
+func (ts *TokenStore) balanceSince(db *gorm.DB) (uint64, bool, error) {
+	var c conf
+	if err := db.Where("name = ?", MysqlConfKeyTokenBalanceSince).Limit(1).Find(&c).Error; err != nil {
+		return 0, false, err
+	}
+
+	if c.ID == 0 {
+		return 0, false, nil
+	}
+
+	bn, err := strconv.ParseUint(c.Value, 10, 64)
+	if err != nil {
+		return 0, false, errors.WithMessagef(err, "invalid %v", MysqlConfKeyTokenBalanceSince)
+	}
+
+	return bn, true, nil
+}
+
+// TokenBalanceSince returns the first block number from which token balances are accumulated, in which
+// case balances are inaccurate if not 0 (genesis), or false if no block indexed yet.
+func (ts *TokenStore) TokenBalanceSince() (uint64, bool, error) {
+	return ts.balanceSince(ts.db)
+}
+
+// tokenBalanceDeltas accumulated (or reverted) balance deltas of token transfers.
+type tokenBalanceDeltas struct {
+	deltas    map[tokenBalanceKey]*big.Int
+	standards map[tokenBalanceKey]string
+	owners    []string
+}
+
+// newTokenBalanceDeltas accumulates (or reverts) the balance deltas of both senders and recipients,
+// where the zero address (mint or burn) is ignored.
+func newTokenBalanceDeltas(transfers []*TokenTransfer, revert bool) (*tokenBalanceDeltas, error) {
+	deltas := make(map[tokenBalanceKey]*big.Int)
+	standards := make(map[tokenBalanceKey]string)
+
+	var owners []string
+	addDelta := func(owner string, t *TokenTransfer, value *big.Int, neg bool) {
+		if owner == tokenZeroAddress {
+			return
+		}
+
+		key := tokenBalanceKey{owner, t.Contract, t.TokenId}
+		if _, ok := deltas[key]; !ok {
+			deltas[key] = new(big.Int)
+			standards[key] = t.Standard
+			owners = append(owners, owner)
+		}
+
+		if neg {
+			deltas[key].Sub(deltas[key], value)
+		} else {
+			deltas[key].Add(deltas[key], value)
+		}
+	}
+
+	for _, t := range transfers {
+		value, ok := new(big.Int).SetString(t.Value, 10)
+		if !ok {
+			return nil, errors.Errorf("invalid token transfer value %v", t.Value)
+		}
+
+		addDelta(t.From, t, value, !revert)
+		addDelta(t.To, t, value, revert)
+	}
+
+	return &tokenBalanceDeltas{deltas: deltas, standards: standards, owners: owners}, nil
+}
+
+// applyBalanceDeltas accumulates (or reverts) the token balances of both senders and recipients.
+func (ts *TokenStore) applyBalanceDeltas(dbTx *gorm.DB, transfers []*TokenTransfer, revert bool) error {
+	bd, err := newTokenBalanceDeltas(transfers, revert)
+	if err != nil {
+		return err
+	}
+
+	// locked in case of token transfers rebuilt concurrently
+	var existed []*TokenBalance
+	err = dbTx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("owner IN (?)", bd.owners).Find(&existed).Error
+	if err != nil {
+		return errors.WithMessage(err, "failed to load token balances")
+	}
+
+	balances := make(map[tokenBalanceKey]*TokenBalance, len(existed))
+	for _, b := range existed {
+		balances[tokenBalanceKey{b.Owner, b.Contract, b.TokenId}] = b
+	}
+
+	for key, delta := range bd.deltas {
+		if delta.Sign() == 0 {
+			continue
+		}
+
+		b, ok := balances[key]
+		if !ok {
+			b = &TokenBalance{
+				Owner: key.owner, Contract: key.contract, TokenId: key.tokenId, Standard: bd.standards[key], Balance: "0",
+			}
+		}
+
+		balance, ok := new(big.Int).SetString(b.Balance, 10)
+		if !ok {
+			return errors.Errorf("invalid token balance %v", b.Balance)
+		}
+
+		balance.Add(balance, delta)
+
+		switch {
+		case balance.Sign() == 0 && b.ID > 0: // remove empty balance, eg., NFT transferred out
+			err = dbTx.Delete(b).Error
+		case balance.Sign() == 0:
+		case b.ID > 0:
+			err = dbTx.Model(b).Update("balance", balance.String()).Error
+		default:
+			b.Balance = balance.String()
+			err = dbTx.Create(b).Error
+		}
+
+		if err != nil {
+			return errors.WithMessage(err, "failed to update token balance")
+		}
+	}
+
+	return nil
+}
+
+// GetTokenBalances returns the token balances of owner in order of id, optionally filtered by
+// contract. Note, `cursor` is the id of the last returned balance, or 0 to query from the first.
+func (ts *TokenStore) GetTokenBalances(owner, contract string, cursor uint64, limit int) ([]*TokenBalance, error) {
+	db := ts.db.Where("owner = ? AND id > ?", owner, cursor)
+	if len(contract) > 0 {
+		db = db.Where("contract = ?", contract)
+	}
+
+	var balances []*TokenBalance
+	err := db.Order("id").Limit(normalizeTokenQueryLimit(limit)).Find(&balances).Error
+
+	return balances, err
+}
+
+// GetTokenTransfers returns the token transfers matched with the filter in reverse order of id.
+func (ts *TokenStore) GetTokenTransfers(filter TokenTransferFilter) ([]*TokenTransfer, error) {
+	db := ts.db
+
+	switch filter.Direction {
+	case "in":
+		db = db.Where("`to` = ?", filter.Address)
+	case "out":
+		db = db.Where("`from` = ?", filter.Address)
+	default:
+		db = db.Where("(`from` = ? OR `to` = ?)", filter.Address, filter.Address)
+	}
+
+	if len(filter.Contract) > 0 {
+		db = db.Where("contract = ?", filter.Contract)
+	}
+
+	if filter.FromBlock > 0 {
+		db = db.Where("bn >= ?", filter.FromBlock)
+	}
+
+	if filter.ToBlock > 0 {
+		db = db.Where("bn <= ?", filter.ToBlock)
+	}
+
+	if filter.Cursor > 0 {
+		db = db.Where("id < ?", filter.Cursor)
+	}
+
+	var transfers []*TokenTransfer
+	err := db.Order("id DESC").Limit(normalizeTokenQueryLimit(filter.Limit)).Find(&transfers).Error
+
+	return transfers, err
+}
+
+func normalizeTokenQueryLimit(limit int) int {
+	if limit <= 0 || limit > MaxTokenQueryLimit {
+		return MaxTokenQueryLimit
+	}
+
+	return limit
+}
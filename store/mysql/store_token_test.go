@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBalanceDeltas(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000a11"
+	bob := "0x0000000000000000000000000000000000000b0b"
+	token := "0x00000000000000000000000000000000000000c0"
+
+	transfers := []*TokenTransfer{
+		{Contract: token, Standard: TokenStandardERC20, From: tokenZeroAddress, To: alice, Value: "100"}, // mint
+		{Contract: token, Standard: TokenStandardERC20, From: alice, To: bob, Value: "30"},
+		{Contract: token, Standard: TokenStandardERC20, From: bob, To: tokenZeroAddress, Value: "5"}, // burn
+	}
+
+	bd, err := newTokenBalanceDeltas(transfers, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{alice, bob}, bd.owners)
+	assert.Equal(t, big.NewInt(70), bd.deltas[tokenBalanceKey{alice, token, ""}])
+	assert.Equal(t, big.NewInt(25), bd.deltas[tokenBalanceKey{bob, token, ""}])
+	assert.Equal(t, TokenStandardERC20, bd.standards[tokenBalanceKey{bob, token, ""}])
+
+	// reverted exactly
+	bd, err = newTokenBalanceDeltas(transfers, true)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(-70), bd.deltas[tokenBalanceKey{alice, token, ""}])
+	assert.Equal(t, big.NewInt(-25), bd.deltas[tokenBalanceKey{bob, token, ""}])
+
+	// transfers out without transfers in, eg., synced from non-genesis block
+	bd, err = newTokenBalanceDeltas(transfers[1:2], false)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(-30), bd.deltas[tokenBalanceKey{alice, token, ""}])
+
+	_, err = newTokenBalanceDeltas([]*TokenTransfer{{From: alice, To: bob, Value: "0x1"}}, false)
+	assert.Error(t, err)
+}
+
+func TestTokenBalanceClamped(t *testing.T) {
+	testCases := []struct {
+		balance  string
+		expected *big.Int
+		clamped  bool
+	}{
+		{"100", big.NewInt(100), false},
+		{"0", big.NewInt(0), false},
+		{"-30", big.NewInt(0), true},
+	}
+
+	for _, tc := range testCases {
+		balance, clamped, err := (&TokenBalance{Balance: tc.balance}).ClampedBalance()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, tc.expected.Cmp(balance), tc.balance)
+		assert.Equal(t, tc.clamped, clamped, tc.balance)
+	}
+
+	_, _, err := (&TokenBalance{Balance: "invalid"}).ClampedBalance()
+	assert.Error(t, err)
+}
@@ -66,6 +66,11 @@ func newTx(
 	return result
 }
 
+// encodedSize returns the size in bytes of encoded transaction and receipt data.
+func (tx *transaction) encodedSize() int {
+	return len(tx.TxRawData) + len(tx.ReceiptRawData) + len(tx.Extra) + len(tx.ReceiptExtra)
+}
+
 func (tx *transaction) parseTxExtra() *store.TransactionExtra {
 	if len(tx.Extra) == 0 {
 		return nil
@@ -142,6 +147,31 @@ func (ts *txStore) GetReceipt(ctx context.Context, txHash types.Hash) (*store.Tr
 	}, nil
 }
 
+// getReceiptsByEpoch loads all the receipts of the specified epoch in a single query.
+func (ts *txStore) getReceiptsByEpoch(ctx context.Context, epoch uint64) ([]*store.TransactionReceipt, error) {
+	var txs []transaction
+	if err := ts.db.Where("epoch = ?", epoch).Order("id").Find(&txs).Error; err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*store.TransactionReceipt, 0, len(txs))
+	for i := range txs {
+		// receipt might not be stored if disabled
+		if len(txs[i].ReceiptRawData) == 0 {
+			return nil, store.ErrUnsupported
+		}
+
+		var receipt types.TransactionReceipt
+		util.MustUnmarshalRLP(txs[i].ReceiptRawData, &receipt)
+
+		receipts = append(receipts, &store.TransactionReceipt{
+			CfxReceipt: &receipt, Extra: txs[i].parseTxReceiptExtra(),
+		})
+	}
+
+	return receipts, nil
+}
+
 // Add batch save epoch transactions into db store.
 func (ts *txStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, skipTx, skipRcpt bool) error {
 	if skipTx && skipRcpt {
@@ -181,6 +211,7 @@ func (ts *txStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, skipTx, skip
 				if !skipTx || !skipRcpt {
 					txn := newTx(&tx, receipt, txExt, rcptExt, skipTx, skipRcpt)
 					txns = append(txns, txn)
+					data.Size += txn.encodedSize()
 				}
 			}
 		}
@@ -0,0 +1,198 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhook delivery kinds
+	WebhookDeliveryLogs   = "logs"
+	WebhookDeliveryRevoke = "revoke"
+
+	// max length of webhook delivery error to persist
+	maxWebhookDeliveryErrorLen = 1024
+)
+
+// Webhook user registered event log subscription, matched event logs of which will be pushed to
+// the callback URL as blocks are synced.
+type Webhook struct {
+	ID   uint32
+	Name string `gorm:"unique;size:64;not null"`
+	// callback URL to push notifications
+	Url string `gorm:"size:512;not null"`
+	// comma separated contract addresses to filter, empty for any contract
+	Contracts string `gorm:"size:4096"`
+	// JSON encoded topics to filter, eg., `[["0x..."],[],["0x...","0x..."]]`
+	Topics string `gorm:"size:4096"`
+	// secret to sign the notification payload, empty for no signature
+	Secret string `gorm:"size:128"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery notification to push to the webhook, which is persisted as outbox along with
+// the synced epoch data so that it would be delivered at least once.
+type WebhookDelivery struct {
+	ID        uint64
+	WebhookID uint32 `gorm:"not null;index:idx_webhook_bn,priority:1"`
+	Kind      string `gorm:"size:16;not null"`
+	// block range of the notification
+	BnMin uint64 `gorm:"not null;index:idx_webhook_bn,priority:2"`
+	BnMax uint64 `gorm:"not null"`
+	// JSON encoded notification payload
+	Payload []byte `gorm:"type:mediumblob;not null"`
+
+	Attempts      uint32     `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null"`
+	LastError     string     `gorm:"size:1024"`
+	DeliveredAt   *time.Time `gorm:"index"` // nil if not delivered yet
+
+	CreatedAt time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+type WebhookStore struct {
+	*baseStore
+}
+
+func NewWebhookStore(db *gorm.DB) *WebhookStore {
+	return &WebhookStore{baseStore: newBaseStore(db)}
+}
+
+// AddWebhook registers a new webhook.
+func (ws *WebhookStore) AddWebhook(webhook *Webhook) error {
+	return ws.db.Create(webhook).Error
+}
+
+// DeleteWebhook removes the webhook along with all its deliveries.
+func (ws *WebhookStore) DeleteWebhook(name string) (bool, error) {
+	var webhook Webhook
+
+	exists, err := ws.exists(&webhook, "name = ?", name)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	err = ws.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Delete(&WebhookDelivery{}, "webhook_id = ?", webhook.ID).Error; err != nil {
+			return err
+		}
+
+		return dbTx.Delete(&webhook).Error
+	})
+
+	return err == nil, err
+}
+
+// LoadWebhooks loads all the registered webhooks.
+func (ws *WebhookStore) LoadWebhooks() (res []*Webhook, err error) {
+	err = ws.db.Order("id").Find(&res).Error
+	return res, err
+}
+
+// AddWebhookDeliveries adds the webhook deliveries within the specified db transaction.
+func (ws *WebhookStore) AddWebhookDeliveries(dbTx *gorm.DB, deliveries []*WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return dbTx.Create(deliveries).Error
+}
+
+// RevokeWebhookDeliveries removes the undelivered event logs of blocks since the specified block
+// number within the specified db transaction, and returns the webhooks that event logs of the
+// reverted blocks might have been delivered to.
+func (ws *WebhookStore) RevokeWebhookDeliveries(dbTx *gorm.DB, bnFrom uint64) ([]uint32, error) {
+	err := dbTx.Where("kind = ? AND bn_min >= ? AND delivered_at IS NULL", WebhookDeliveryLogs, bnFrom).
+		Delete(&WebhookDelivery{}).Error
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to remove undelivered webhook deliveries")
+	}
+
+	var webhookIds []uint32
+	err = dbTx.Model(&WebhookDelivery{}).
+		Where("kind = ? AND bn_max >= ?", WebhookDeliveryLogs, bnFrom).
+		Distinct().
+		Pluck("webhook_id", &webhookIds).Error
+
+	return webhookIds, err
+}
+
+// LoadPendingWebhookDeliveries loads the undelivered webhook deliveries of webhooks whose earliest
+// undelivered delivery is due to attempt, so that the batch is never filled with deliveries blocked
+// by the failed ones in backoff. Deliveries are ordered by the next attempt time of their webhooks,
+// and then in order for each webhook.
+func (ws *WebhookStore) LoadPendingWebhookDeliveries(limit int) (res []*WebhookDelivery, err error) {
+	heads := ws.db.Model(&WebhookDelivery{}).
+		Select("webhook_id, MIN(id) AS head_id").
+		Where("delivered_at IS NULL").
+		Group("webhook_id")
+
+	err = ws.db.Table("webhook_deliveries AS d").
+		Select("d.*").
+		Joins("JOIN (?) AS h ON h.webhook_id = d.webhook_id", heads).
+		Joins("JOIN webhook_deliveries AS hd ON hd.id = h.head_id").
+		Where("d.delivered_at IS NULL AND hd.next_attempt_at <= ?", time.Now()).
+		Order("hd.next_attempt_at, d.id").
+		Limit(limit).
+		Find(&res).Error
+
+	return res, err
+}
+
+// MarkWebhookDelivered marks the webhook delivery as delivered.
+func (ws *WebhookStore) MarkWebhookDelivered(id uint64, deliveredAt time.Time) error {
+	return ws.db.Model(&WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"delivered_at": deliveredAt,
+			"last_error":   "",
+		}).Error
+}
+
+// RetryWebhookDelivery schedules the failed webhook delivery to retry later.
+func (ws *WebhookStore) RetryWebhookDelivery(id uint64, nextAttemptAt time.Time, deliveryErr string) error {
+	if len(deliveryErr) > maxWebhookDeliveryErrorLen {
+		deliveryErr = deliveryErr[:maxWebhookDeliveryErrorLen]
+	}
+
+	return ws.db.Model(&WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      deliveryErr,
+		}).Error
+}
+
+// PruneWebhookDeliveries removes the webhook deliveries delivered before the specified time.
+func (ws *WebhookStore) PruneWebhookDeliveries(before time.Time) (int64, error) {
+	res := ws.db.Where("delivered_at < ?", before).Delete(&WebhookDelivery{})
+	return res.RowsAffected, res.Error
+}
+
+// EpochDataObserver observes the epoch data pushed into or popped from store within the same
+// db transaction, so that the derived data (eg., webhook deliveries) keeps consistent.
+type EpochDataObserver interface {
+	OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error
+	OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error
+}
+
+// AddEpochDataObserver adds observer of epoch data pushed into or popped from store, and the epoch
+// data will not be pushed or popped if failed to observe.
+func (ms *MysqlStore) AddEpochDataObserver(observer EpochDataObserver) {
+	ms.observers = append(ms.observers, observer)
+}
@@ -140,6 +140,11 @@ func (rs *RedisStore) GetReceipt(ctx context.Context, txHash types.Hash) (*store
 	return &store.TransactionReceipt{CfxReceipt: receipt}, nil
 }
 
+func (rs *RedisStore) GetReceiptsByEpoch(ctx context.Context, epochNumber uint64) ([]*store.TransactionReceipt, error) {
+	// Only receipts of executed txs are cached, which might be incomplete for the epoch.
+	return nil, store.ErrUnsupported
+}
+
 func (rs *RedisStore) GetBlocksByEpoch(ctx context.Context, epochNumber uint64) ([]types.Hash, error) {
 	return loadEpochBlocks(rs.ctx, rs.rdb, epochNumber)
 }
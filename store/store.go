@@ -13,8 +13,10 @@ import (
 	_ "github.com/Conflux-Chain/confura/config"
 
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
-	"github.com/Conflux-Chain/go-conflux-util/viper"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -49,6 +51,7 @@ type Readable interface {
 
 	GetTransaction(ctx context.Context, txHash types.Hash) (*Transaction, error)
 	GetReceipt(ctx context.Context, txHash types.Hash) (*TransactionReceipt, error)
+	GetReceiptsByEpoch(ctx context.Context, epochNumber uint64) ([]*TransactionReceipt, error)
 
 	GetBlocksByEpoch(ctx context.Context, epochNumber uint64) ([]types.Hash, error)
 	GetBlockByEpoch(ctx context.Context, epochNumber uint64) (*Block, error)
@@ -115,13 +118,31 @@ type storeConfig struct {
 	// disabled store chain data types, available options are:
 	// `block`, `transaction`, `receipt` and `log`
 	Disables []string `default:"[block,transaction,receipt]"`
+	// whether to persist only block headers and event logs, but skip transactions and receipts,
+	// which are served by fullnode instead, eg., for operators who only care about fast `getLogs`
+	ReceiptLight bool
 
+	// whether `Disables` is defaulted rather than specified explicitly
+	disablesDefaulted       bool
 	disabledDataTypeMapping map[string]bool
 }
 
 func (conf *storeConfig) mustInit(viperRoot string) {
-	viper.MustUnmarshalKey(viperRoot, conf)
+	viperutil.MustUnmarshalKey(viperRoot, conf)
+	conf.disablesDefaulted = !viper.IsSet(viperRoot + ".disables")
 
+	if err := conf.initDisabledDataTypes(); err != nil {
+		logrus.WithError(err).Fatal("Failed to init store config")
+	}
+
+	if conf.ReceiptLight {
+		logrus.WithField("viperRoot", viperRoot).Info(
+			"Store in receipt-light mode to persist block headers and event logs only",
+		)
+	}
+}
+
+func (conf *storeConfig) initDisabledDataTypes() error {
 	dataTypeMapping := make(map[string]bool, 4)
 	for _, dt := range []string{"block", "transaction", "receipt", "log"} {
 		dataTypeMapping[dt] = false
@@ -130,16 +151,36 @@ func (conf *storeConfig) mustInit(viperRoot string) {
 	for _, dt := range conf.Disables {
 		ldt := strings.ToLower(dt)
 
+		// blocks disabled by default are persisted as block summaries in receipt-light mode
+		if ldt == "block" && conf.ReceiptLight && conf.disablesDefaulted {
+			continue
+		}
+
 		if _, ok := dataTypeMapping[ldt]; !ok {
-			logrus.WithField("dataType", dt).Fatal(
-				"Failed to init store config due to invalid disabled store data type",
-			)
+			return errors.Errorf("invalid disabled store data type %v", dt)
 		}
 
 		dataTypeMapping[ldt] = true
 	}
 
+	if conf.ReceiptLight {
+		// event logs are indispensable in receipt-light mode
+		if dataTypeMapping["log"] {
+			return errors.New("event logs could not be disabled in receipt-light mode")
+		}
+
+		// block headers are indispensable in receipt-light mode
+		if dataTypeMapping["block"] {
+			return errors.New("blocks could not be disabled explicitly in receipt-light mode")
+		}
+
+		// block headers are persisted as block summaries
+		dataTypeMapping["transaction"] = true
+		dataTypeMapping["receipt"] = true
+	}
+
 	conf.disabledDataTypeMapping = dataTypeMapping
+	return nil
 }
 
 func (conf *storeConfig) IsChainBlockDisabled() bool {
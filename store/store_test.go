@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreConfigReceiptLight(t *testing.T) {
+	conf := storeConfig{Disables: []string{"transaction"}, ReceiptLight: true}
+	assert.NoError(t, conf.initDisabledDataTypes())
+
+	assert.False(t, conf.IsChainBlockDisabled())
+	assert.True(t, conf.IsChainTxnDisabled())
+	assert.True(t, conf.IsChainReceiptDisabled())
+	assert.False(t, conf.IsChainLogDisabled())
+
+	// event logs are indispensable
+	conf = storeConfig{Disables: []string{"log"}, ReceiptLight: true}
+	assert.Error(t, conf.initDisabledDataTypes())
+
+	// block headers are indispensable
+	conf = storeConfig{Disables: []string{"block", "transaction", "receipt"}, ReceiptLight: true}
+	assert.Error(t, conf.initDisabledDataTypes())
+
+	// blocks disabled by default are persisted
+	conf = storeConfig{
+		Disables: []string{"block", "transaction", "receipt"}, ReceiptLight: true, disablesDefaulted: true,
+	}
+	assert.NoError(t, conf.initDisabledDataTypes())
+	assert.False(t, conf.IsChainBlockDisabled())
+	assert.True(t, conf.IsChainTxnDisabled())
+	assert.True(t, conf.IsChainReceiptDisabled())
+	assert.False(t, conf.IsChainLogDisabled())
+
+	// blocks still disabled by default if not in receipt-light mode
+	conf = storeConfig{Disables: []string{"block", "transaction", "receipt"}, disablesDefaulted: true}
+	assert.NoError(t, conf.initDisabledDataTypes())
+	assert.True(t, conf.IsChainBlockDisabled())
+
+	// invalid data type
+	conf = storeConfig{Disables: []string{"trace"}}
+	assert.Error(t, conf.initDisabledDataTypes())
+}
+
+func TestStoreConfigReceiptLightDefaultDisables(t *testing.T) {
+	viper.Set("teststore.receiptLight", true)
+
+	var conf storeConfig
+	conf.mustInit("teststore")
+
+	assert.Equal(t, []string{"block", "transaction", "receipt"}, conf.Disables)
+	assert.False(t, conf.IsChainBlockDisabled())
+	assert.True(t, conf.IsChainTxnDisabled())
+	assert.True(t, conf.IsChainReceiptDisabled())
+}
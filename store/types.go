@@ -5,6 +5,7 @@ import (
 	"math"
 	"strings"
 
+	"github.com/Conflux-Chain/confura/util/errcode"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -46,7 +47,7 @@ var (
 	ErrUnsupported            = errors.New("not supported")
 	ErrEpochPivotSwitched     = errors.New("epoch pivot switched")
 	ErrContinousEpochRequired = errors.New("continous epoch required")
-	ErrAlreadyPruned          = errors.New("data already pruned")
+	ErrAlreadyPruned          = errcode.New(errcode.DataPruned, errors.New("data already pruned"))
 	ErrChainReorged           = errors.New("chain re-orged")
 
 	// operationable epoch data types
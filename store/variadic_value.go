@@ -1,6 +1,10 @@
 package store
 
-import "github.com/Conflux-Chain/go-conflux-sdk/types"
+import (
+	"sort"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
 
 // VariadicValue represents an union value, including null, single value or multiple values.
 type VariadicValue struct {
@@ -86,12 +90,16 @@ func (vv *VariadicValue) ToSlice() []string {
 		return []string{vv.single}
 	}
 
-	result := make([]string, 0, vv.count)
-	for k := range vv.multiple {
-		result = append(result, k)
+	return vv.sortedMultiple()
+}
+
+// Contains checks if the value is one of the variadic values.
+func (vv *VariadicValue) Contains(value string) bool {
+	if vv.count == 1 {
+		return vv.single == value
 	}
 
-	return result
+	return vv.multiple[value]
 }
 
 func (vv *VariadicValue) Count() int {
@@ -115,11 +123,18 @@ func (vv *VariadicValue) FlatMultiple() ([]string, bool) {
 		return nil, false
 	}
 
-	result := make([]string, 0, vv.count)
+	return vv.sortedMultiple(), true
+}
 
+// sortedMultiple returns the multiple values in order, so that the generated SQL statements (eg., IN
+// clause) are deterministic for query plan cache.
+func (vv *VariadicValue) sortedMultiple() []string {
+	result := make([]string, 0, vv.count)
 	for k := range vv.multiple {
 		result = append(result, k)
 	}
 
-	return result, true
+	sort.Strings(result)
+
+	return result
 }
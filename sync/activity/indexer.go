@@ -0,0 +1,132 @@
+// Package activity indexes the evm space transactions by the relevant addresses as blocks synced
+// into store, so that transactions of an address could be queried without scanning the chain.
+package activity
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go/types"
+	"gorm.io/gorm"
+)
+
+var _ mysql.EpochDataObserver = (*Indexer)(nil)
+
+// Config address activity index configurations
+type Config struct {
+	// whether to index transactions by address as blocks synced
+	Enabled bool
+}
+
+// Store persists the address transactions.
+type Store interface {
+	AddAddressTxs(dbTx *gorm.DB, txs []*mysql.AddressTx) error
+	RemoveAddressTxs(dbTx *gorm.DB, bnFrom uint64) error
+}
+
+// Indexer indexes the executed transactions of synced blocks by sender and recipient (or created
+// contract) within the same db transaction, which will be removed as well if blocks popped due to
+// chain reorg.
+type Indexer struct {
+	store Store
+}
+
+// MustLoadConfigFromViper loads address activity index configurations from viper settings, which
+// are shared by both sync and RPC services.
+func MustLoadConfigFromViper() (conf Config) {
+	viper.MustUnmarshalKey("sync.activityIndex", &conf)
+	return conf
+}
+
+// MustNewIndexerFromViper creates address activity indexer from viper settings, or returns false if
+// not enabled.
+func MustNewIndexerFromViper(store Store) (*Indexer, bool) {
+	if conf := MustLoadConfigFromViper(); !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	return NewIndexer(store), true
+}
+
+func NewIndexer(store Store) *Indexer {
+	return &Indexer{store: store}
+}
+
+// OnEpochDataPushed implements the `mysql.EpochDataObserver` interface to index transactions.
+func (idx *Indexer) OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	var txs []*mysql.AddressTx
+
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			for i := range block.Transactions {
+				tx := &block.Transactions[i]
+
+				// skip transactions that unexecuted in block
+				if data.Receipts[tx.Hash] == nil || !util.IsTxExecutedInBlock(tx) {
+					continue
+				}
+
+				txs = append(txs, NewAddressTxs(data.Number, ethbridge.ConvertTx(tx, nil))...)
+			}
+		}
+	}
+
+	return idx.store.AddAddressTxs(dbTx, txs)
+}
+
+// OnEpochDataPopped implements the `mysql.EpochDataObserver` interface to remove transactions.
+func (idx *Indexer) OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	return idx.store.RemoveAddressTxs(dbTx, epochFrom)
+}
+
+// NewAddressTxs returns the address transactions of sender and recipient (or created contract),
+// where only one is returned for self transfer.
+func NewAddressTxs(bn uint64, tx *types.TransactionDetail) []*mysql.AddressTx {
+	from := strings.ToLower(tx.From.Hex())
+
+	var to string
+	switch {
+	case tx.To != nil:
+		to = strings.ToLower(tx.To.Hex())
+	case tx.Creates != nil:
+		to = strings.ToLower(tx.Creates.Hex())
+	}
+
+	var txIndex, status uint64
+	if tx.TransactionIndex != nil {
+		txIndex = *tx.TransactionIndex
+	}
+
+	if tx.Status != nil {
+		status = *tx.Status
+	}
+
+	value := "0"
+	if tx.Value != nil {
+		value = tx.Value.String()
+	}
+
+	newAddressTx := func(address string) *mysql.AddressTx {
+		return &mysql.AddressTx{
+			Address: address,
+			Bn:      bn,
+			TxHash:  tx.Hash.Hex(),
+			TxIndex: txIndex,
+			From:    from,
+			To:      to,
+			Value:   value,
+			Status:  status,
+		}
+	}
+
+	txs := []*mysql.AddressTx{newAddressTx(from)}
+	if len(to) > 0 && to != from {
+		txs = append(txs, newAddressTx(to))
+	}
+
+	return txs
+}
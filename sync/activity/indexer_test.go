@@ -0,0 +1,40 @@
+package activity
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAddressTxs(t *testing.T) {
+	from := common.HexToAddress("0x000000000000000000000000000000000000000A")
+	to := common.HexToAddress("0x000000000000000000000000000000000000000B")
+	status, txIndex := uint64(1), uint64(3)
+
+	// normal transfer
+	txs := NewAddressTxs(10, &types.TransactionDetail{
+		From: from, To: &to, Value: big.NewInt(100), Status: &status, TransactionIndex: &txIndex,
+	})
+	assert.Equal(t, 2, len(txs))
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", txs[0].Address)
+	assert.Equal(t, "0x000000000000000000000000000000000000000b", txs[1].Address)
+	assert.Equal(t, txs[0].From, txs[1].From)
+	assert.Equal(t, "0x000000000000000000000000000000000000000b", txs[1].To)
+	assert.Equal(t, "100", txs[1].Value)
+	assert.Equal(t, uint64(10), txs[1].Bn)
+	assert.Equal(t, uint64(3), txs[1].TxIndex)
+	assert.Equal(t, uint64(1), txs[1].Status)
+
+	// self transfer
+	txs = NewAddressTxs(10, &types.TransactionDetail{From: from, To: &from})
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, "0", txs[0].Value)
+
+	// contract creation
+	txs = NewAddressTxs(10, &types.TransactionDetail{From: from, Creates: &to})
+	assert.Equal(t, 2, len(txs))
+	assert.Equal(t, "0x000000000000000000000000000000000000000b", txs[1].To)
+}
@@ -0,0 +1,251 @@
+package sync
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// data integrity audit configuration
+type auditConfig struct {
+	// whether to audit the synced data in background
+	Enabled bool
+	// interval to audit a sample of synced epochs
+	Interval time.Duration `default:"10m"`
+	// number of epochs sampled to audit per round
+	SampleEpochs int `default:"10"`
+	// number of the latest synced epochs to sample from, 0 for all the synced epochs
+	Window uint64
+	// whether to re-sync the epoch data since the first mismatched epoch
+	Repair bool
+	// max number of epochs behind the latest synced epoch to repair, so as to avoid re-syncing
+	// too many epochs, beyond which mismatches are only reported
+	MaxRepairDepth uint64 `default:"1000"`
+}
+
+// epochDataFetcher fetches the epoch data of the specified epoch from fullnode.
+type epochDataFetcher func(epochNo uint64) (*store.EpochData, error)
+
+func cfxEpochDataFetcher(cfx sdk.ClientOperator) epochDataFetcher {
+	return func(epochNo uint64) (*store.EpochData, error) {
+		data, err := store.QueryEpochData(cfx, epochNo, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return &data, nil
+	}
+}
+
+func ethEpochDataFetcher(w3c *web3go.Client, chainId uint32) epochDataFetcher {
+	return func(blockNo uint64) (*store.EpochData, error) {
+		data, err := store.QueryEthData(w3c, blockNo, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return convertEthToEpochData(data, chainId), nil
+	}
+}
+
+// Resyncer re-syncs the epoch data since some epoch, which is used to repair the mismatched data.
+type Resyncer interface {
+	// Resync reverts the synced epoch data since the specified epoch and then re-syncs them
+	// asynchronously.
+	Resync(fromEpoch uint64)
+}
+
+// Auditor audits the data integrity of store against fullnode, by comparing the digest (eg., block
+// hashes, transaction hashes, number and bloom of event logs) of sampled epochs.
+type Auditor struct {
+	conf     auditConfig
+	space    string
+	db       *mysql.MysqlStore
+	disabler store.StoreDisabler
+	fetcher  epochDataFetcher
+}
+
+func mustNewAuditor(
+	space string, db *mysql.MysqlStore, disabler store.StoreDisabler, fetcher epochDataFetcher,
+) *Auditor {
+	var conf auditConfig
+	viperutil.MustUnmarshalKey("sync.audit", &conf)
+
+	return &Auditor{
+		conf:     conf,
+		space:    space,
+		db:       db,
+		disabler: disabler,
+		fetcher:  fetcher,
+	}
+}
+
+// MustNewCfxAuditor creates an instance of Auditor to audit core space store.
+func MustNewCfxAuditor(cfx sdk.ClientOperator, db *mysql.MysqlStore) *Auditor {
+	return mustNewAuditor("cfx", db, store.StoreConfig(), cfxEpochDataFetcher(cfx))
+}
+
+// MustNewEthAuditor creates an instance of Auditor to audit evm space store.
+func MustNewEthAuditor(w3c *web3go.Client, db *mysql.MysqlStore) *Auditor {
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get chain ID from eth space")
+	}
+
+	return mustNewAuditor("eth", db, store.EthStoreConfig(), ethEpochDataFetcher(w3c, uint32(*chainId)))
+}
+
+// Enabled returns whether to audit the synced data in background.
+func (a *Auditor) Enabled() bool {
+	return a.conf.Enabled
+}
+
+// Audit compares the digest of the specified epoch in store with that of fullnode, and returns the
+// mismatched fields if any. Note, false is returned if epoch not synced into store.
+func (a *Auditor) Audit(ctx context.Context, epoch uint64) ([]string, bool, error) {
+	stored, ok, err := a.db.EpochDigest(ctx, epoch)
+	if err != nil || !ok {
+		return nil, false, errors.WithMessage(err, "failed to get epoch digest from store")
+	}
+
+	data, err := a.fetcher(epoch)
+	if err != nil {
+		return nil, true, errors.WithMessage(err, "failed to query epoch data from fullnode")
+	}
+
+	// event logs not selected or out of retention window are not persisted in store
+	opt, err := a.db.EpochDigestOption()
+	if err != nil {
+		return nil, true, err
+	}
+
+	return stored.Diff(store.NewEpochDigest(data, a.disabler, opt)), true, nil
+}
+
+// Run audits a sample of synced epochs periodically until context done. If repair enabled, the epoch
+// data since the first mismatched epoch will be re-synced by resyncer.
+func (a *Auditor) Run(ctx context.Context, wg *sync.WaitGroup, resyncer Resyncer) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logrus.WithField("space", a.space).Info("Data integrity auditor started")
+
+	ticker := time.NewTicker(a.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.auditOnce(ctx, resyncer); err != nil {
+				logrus.WithField("space", a.space).WithError(err).Info("Failed to audit synced data")
+			}
+		}
+	}
+}
+
+func (a *Auditor) auditOnce(ctx context.Context, resyncer Resyncer) error {
+	minEpoch, ok, err := a.db.MinEpoch()
+	if err != nil || !ok {
+		return errors.WithMessage(err, "failed to get min synced epoch")
+	}
+
+	maxEpoch, ok, err := a.db.MaxEpoch()
+	if err != nil || !ok {
+		return errors.WithMessage(err, "failed to get max synced epoch")
+	}
+
+	if a.conf.Window > 0 && maxEpoch-minEpoch+1 > a.conf.Window {
+		minEpoch = maxEpoch - a.conf.Window + 1
+	}
+
+	var mismatched bool
+	var firstMismatched uint64
+	for _, epoch := range sampleEpochs(minEpoch, maxEpoch, a.conf.SampleEpochs) {
+		mismatches, ok, err := a.Audit(ctx, epoch)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to audit epoch %v", epoch)
+		}
+
+		if !ok { // pruned or reverted in the meantime
+			continue
+		}
+
+		metrics.Registry.Sync.AuditEpochs(a.space).Mark(1)
+
+		if len(mismatches) == 0 {
+			continue
+		}
+
+		metrics.Registry.Sync.AuditMismatches(a.space).Mark(1)
+		logrus.WithFields(logrus.Fields{
+			"space": a.space, "epoch": epoch, "mismatches": mismatches,
+		}).Warn("Synced data mismatched with fullnode")
+
+		// epochs are sampled in ascending order
+		if !mismatched {
+			mismatched, firstMismatched = true, epoch
+		}
+	}
+
+	if !mismatched || !a.conf.Repair || resyncer == nil {
+		return nil
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"space": a.space, "fromEpoch": firstMismatched, "maxEpoch": maxEpoch,
+	})
+
+	if maxEpoch-firstMismatched >= a.conf.MaxRepairDepth {
+		logger.Warn("Mismatched synced data too deep to repair automatically")
+		return nil
+	}
+
+	logger.Info("Re-syncing mismatched synced data")
+	resyncer.Resync(firstMismatched)
+
+	return nil
+}
+
+// sampleEpochs returns at most n distinct epochs randomly sampled within [from, to] in ascending order.
+func sampleEpochs(from, to uint64, n int) []uint64 {
+	if from > to || n <= 0 {
+		return nil
+	}
+
+	total := to - from + 1
+	if total <= uint64(n) {
+		epochs := make([]uint64, 0, total)
+		for e := from; e <= to; e++ {
+			epochs = append(epochs, e)
+		}
+
+		return epochs
+	}
+
+	sampled := make(map[uint64]bool, n)
+	for len(sampled) < n {
+		sampled[from+uint64(rand.Int63n(int64(total)))] = true
+	}
+
+	epochs := make([]uint64, 0, n)
+	for e := range sampled {
+		epochs = append(epochs, e)
+	}
+
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+
+	return epochs
+}
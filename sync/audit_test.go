@@ -0,0 +1,24 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleEpochs(t *testing.T) {
+	assert.Nil(t, sampleEpochs(10, 9, 5))
+	assert.Nil(t, sampleEpochs(1, 10, 0))
+	assert.Equal(t, []uint64{3, 4, 5}, sampleEpochs(3, 5, 10))
+
+	epochs := sampleEpochs(100, 10000, 20)
+	assert.Equal(t, 20, len(epochs))
+
+	for i, e := range epochs {
+		assert.True(t, e >= 100 && e <= 10000)
+
+		if i > 0 {
+			assert.Greater(t, e, epochs[i-1])
+		}
+	}
+}
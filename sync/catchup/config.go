@@ -9,4 +9,7 @@ type config struct {
 	MaxDbRows int `default:"7500"`
 	// capacity of channel per worker to buffer queried epoch data
 	WorkerChanSize int `default:"5"`
+	// max number of collected batches pending to persist asynchronously, so that fetching epoch data
+	// overlaps with db writes, 0 to persist synchronously
+	MaxPendingBatches int `default:"2"`
 }
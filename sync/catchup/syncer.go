@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/sync/progress"
 	"github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/pkg/errors"
@@ -29,12 +31,20 @@ type Syncer struct {
 	// whether to automatically adjust target sync epoch number to the latest stable epoch,
 	// which is maximum between the latest finalized and the checkpoint epoch number.
 	adaptive bool
+	// max epoch to sync up to even though adaptive, 0 if unbounded
+	maxEpochTo uint64
 	// min num of db rows per batch persistence
 	minBatchDbRows int
 	// max num of db rows collected before persistence
 	maxDbRows int
+	// max num of collected batches pending to persist asynchronously
+	maxPendingBatches int
 	// benchmark catch-up sync performance
 	bmarker *benchmarker
+	// options to create sdk clients of workers, eg., rate limiter shared with other syncers
+	clientOptions []rpc.ClientOption
+	// progress to report catch-up throughput, backlog and ETA
+	progress *progress.Reporter
 }
 
 // functional options for syncer
@@ -58,6 +68,13 @@ func WithEpochTo(epochTo uint64) SyncOption {
 	}
 }
 
+// WithMaxEpochTo bounds the target epoch number adjusted adaptively, eg., range-limited sync.
+func WithMaxEpochTo(epochTo uint64) SyncOption {
+	return func(s *Syncer) {
+		s.maxEpochTo = epochTo
+	}
+}
+
 func WithMinBatchDbRows(dbRows int) SyncOption {
 	return func(s *Syncer) {
 		s.minBatchDbRows = dbRows
@@ -70,12 +87,26 @@ func WithMaxDbRows(dbRows int) SyncOption {
 	}
 }
 
+func WithMaxPendingBatches(batches int) SyncOption {
+	return func(s *Syncer) {
+		s.maxPendingBatches = batches
+	}
+}
+
 func WithWorkers(workers []*worker) SyncOption {
 	return func(s *Syncer) {
 		s.workers = workers
 	}
 }
 
+// WithClientOptions specifies the options to create sdk clients of workers, eg., the rate limiter of
+// fullnode host shared with evm space sync.
+func WithClientOptions(options ...rpc.ClientOption) SyncOption {
+	return func(s *Syncer) {
+		s.clientOptions = append(s.clientOptions, options...)
+	}
+}
+
 func WithBenchmark(benchmark bool) SyncOption {
 	return func(s *Syncer) {
 		if benchmark {
@@ -90,26 +121,30 @@ func MustNewSyncer(cfx sdk.ClientOperator, db store.StackOperable, opts ...SyncO
 	var conf config
 	viperutil.MustUnmarshalKey("sync.catchup", &conf)
 
-	var workers []*worker
-	for i, nodeUrl := range conf.CfxPool { // initialize workers
-		name := fmt.Sprintf("CUWorker#%v", i)
-		worker := mustNewWorker(name, nodeUrl, conf.WorkerChanSize)
-		workers = append(workers, worker)
-	}
-
 	var newOpts []SyncOption
 	newOpts = append(newOpts,
 		WithMaxDbRows(conf.MaxDbRows),
 		WithMinBatchDbRows(conf.DbRowsThreshold),
-		WithWorkers(workers),
+		WithMaxPendingBatches(conf.MaxPendingBatches),
 	)
 
-	return newSyncer(cfx, db, append(newOpts, opts...)...)
+	syncer := newSyncer(cfx, db, append(newOpts, opts...)...)
+
+	if len(syncer.workers) == 0 { // initialize workers with client options applied
+		for i, nodeUrl := range conf.CfxPool {
+			name := fmt.Sprintf("CUWorker#%v", i)
+			worker := mustNewWorker(name, nodeUrl, conf.WorkerChanSize, syncer.clientOptions...)
+			syncer.workers = append(syncer.workers, worker)
+		}
+	}
+
+	return syncer
 }
 
 func newSyncer(cfx sdk.ClientOperator, db store.StackOperable, opts ...SyncOption) *Syncer {
 	syncer := &Syncer{
 		db: db, cfx: cfx, adaptive: true, minBatchDbRows: 1500,
+		progress: progress.NewReporter("cfx", "catchup"),
 	}
 
 	for _, opt := range opts {
@@ -189,9 +224,13 @@ func (s *Syncer) fetchResult(ctx context.Context, wg *sync.WaitGroup, start, end
 	var epochData *store.EpochData
 	var state persistState
 
+	pipeline := s.newCommitPipeline()
+
 	defer wg.Done()
+	// wait for the pending batches persisted before the sync range is updated
+	defer pipeline.close()
 	// do last db write anyway since there may be some epochs not persisted yet.
-	defer s.persist(&state)
+	defer pipeline.commit(&state)
 
 	for eno := start; eno <= end; {
 		for i := 0; i < len(s.workers) && eno <= end; i++ {
@@ -223,7 +262,7 @@ func (s *Syncer) fetchResult(ctx context.Context, wg *sync.WaitGroup, start, end
 			// Batch insert into db if enough db rows collected, also use total db rows here to
 			// restrict memory usage.
 			if state.totalDbRows >= s.maxDbRows || state.insertDbRows >= s.minBatchDbRows {
-				s.persist(&state)
+				pipeline.commit(&state)
 			}
 		}
 	}
@@ -255,6 +294,58 @@ func (s *persistState) update(epochData *store.EpochData) (int, int) {
 	return totalDbRows, storeDbRows
 }
 
+// commitPipeline persists the collected batches of epoch data in order, either synchronously or
+// asynchronously by a dedicated goroutine with bounded number of pending batches, so that fetching
+// epoch data from workers overlaps with db writes.
+type commitPipeline struct {
+	syncer  *Syncer
+	pending chan persistState // nil to persist synchronously
+	done    chan struct{}     // closed once all pending batches persisted
+}
+
+func (s *Syncer) newCommitPipeline() *commitPipeline {
+	p := &commitPipeline{syncer: s}
+
+	if s.maxPendingBatches > 0 {
+		p.pending = make(chan persistState, s.maxPendingBatches)
+		p.done = make(chan struct{})
+
+		go p.run()
+	}
+
+	return p
+}
+
+func (p *commitPipeline) run() {
+	defer close(p.done)
+
+	for state := range p.pending {
+		p.syncer.persist(&state)
+	}
+}
+
+// commit persists the collected epoch data and resets the state for further collection. Note, it blocks
+// if too many batches pending to persist, which restricts memory usage as well.
+func (p *commitPipeline) commit(state *persistState) {
+	if p.pending == nil {
+		p.syncer.persist(state)
+		return
+	}
+
+	if state.numEpochs() > 0 {
+		p.pending <- *state
+		state.reset()
+	}
+}
+
+// close waits until all the pending batches persisted, which confirms the commit of collected epochs.
+func (p *commitPipeline) close() {
+	if p.pending != nil {
+		close(p.pending)
+		<-p.done
+	}
+}
+
 func (s *Syncer) persist(state *persistState) {
 	numEpochs := state.numEpochs()
 	if numEpochs == 0 {
@@ -280,8 +371,14 @@ func (s *Syncer) persist(state *persistState) {
 		time.Sleep(time.Second)
 	}
 
+	s.progress.Observe(state.epochs, time.Since(start))
+
 	s.syncRange.From += uint64(numEpochs)
-	s.logger().WithField("numEpochs", numEpochs).Debug("Catch-up syncer persisted epoch data")
+	backlog, eta := s.progress.UpdateBacklog(s.syncRange.From, s.syncRange.To)
+
+	s.logger().WithFields(logrus.Fields{
+		"numEpochs": numEpochs, "backlog": backlog, "eta": eta,
+	}).Debug("Catch-up syncer persisted epoch data")
 }
 
 func (s *Syncer) logger() *logrus.Entry {
@@ -299,7 +396,10 @@ func (s *Syncer) updateEpochTo(ctx context.Context) bool {
 
 		err := s.doUpdateEpochTo()
 		if err == nil {
-			s.logger().Debug("Catch-up syncer updated epoch to number")
+			backlog, eta := s.progress.UpdateBacklog(s.syncRange.From, s.syncRange.To)
+			s.logger().WithFields(logrus.Fields{"backlog": backlog, "eta": eta}).Info(
+				"Catch-up syncer updated epoch to number",
+			)
 			return true
 		}
 
@@ -327,6 +427,10 @@ func (s *Syncer) doUpdateEpochTo() error {
 		uint64(status.LatestFinalized), uint64(status.LatestCheckpoint),
 	)
 
+	if s.maxEpochTo > 0 {
+		s.syncRange.To = util.MinUint64(s.syncRange.To, s.maxEpochTo)
+	}
+
 	return nil
 }
 
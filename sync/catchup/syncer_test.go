@@ -0,0 +1,114 @@
+package catchup
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/sync/progress"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockStackStore records the epochs pushed, which takes some time to simulate db writes.
+type mockStackStore struct {
+	mu      sync.Mutex
+	latency time.Duration
+	epochs  []uint64
+	batches int
+}
+
+func (s *mockStackStore) Push(data *store.EpochData) error {
+	return s.Pushn([]*store.EpochData{data})
+}
+
+func (s *mockStackStore) Pushn(dataSlice []*store.EpochData) error {
+	time.Sleep(s.latency)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, data := range dataSlice {
+		s.epochs = append(s.epochs, data.Number)
+	}
+	s.batches++
+
+	return nil
+}
+
+func (s *mockStackStore) Popn(epochUntil uint64) error {
+	return nil
+}
+
+func newTestSyncer(db store.StackOperable, maxPendingBatches int) *Syncer {
+	return &Syncer{
+		db:                db,
+		syncRange:         types.RangeUint64{From: 1, To: 100},
+		maxPendingBatches: maxPendingBatches,
+		progress:          progress.NewReporter("cfx", "catchup_test"),
+	}
+}
+
+// collect simulates fetching epoch data from workers, and commits every batchEpochs epochs.
+func collect(pipeline *commitPipeline, epochs, batchEpochs int, fetchLatency time.Duration) {
+	var state persistState
+
+	for eno := 1; eno <= epochs; eno++ {
+		time.Sleep(fetchLatency)
+		state.update(&store.EpochData{Number: uint64(eno)})
+
+		if state.numEpochs() >= batchEpochs {
+			pipeline.commit(&state)
+		}
+	}
+
+	pipeline.commit(&state)
+	pipeline.close()
+}
+
+func TestCommitPipeline(t *testing.T) {
+	for _, maxPendingBatches := range []int{0, 1, 3} {
+		db := &mockStackStore{latency: time.Millisecond}
+		syncer := newTestSyncer(db, maxPendingBatches)
+
+		collect(syncer.newCommitPipeline(), 20, 3, 0)
+
+		// persisted in order, and sync range advanced once all pending batches confirmed
+		expected := make([]uint64, 20)
+		for i := range expected {
+			expected[i] = uint64(i + 1)
+		}
+
+		assert.Equal(t, expected, db.epochs, "maxPendingBatches = %v", maxPendingBatches)
+		assert.Equal(t, 7, db.batches, "maxPendingBatches = %v", maxPendingBatches)
+		assert.Equal(t, uint64(21), syncer.syncRange.From, "maxPendingBatches = %v", maxPendingBatches)
+	}
+}
+
+func TestCommitPipelineEmptyBatch(t *testing.T) {
+	db := &mockStackStore{}
+	syncer := newTestSyncer(db, 2)
+
+	var state persistState
+	pipeline := syncer.newCommitPipeline()
+	pipeline.commit(&state)
+	pipeline.close()
+
+	assert.Zero(t, db.batches)
+	assert.Equal(t, uint64(1), syncer.syncRange.From)
+}
+
+// benchmarkCommitPipeline measures the catch-up throughput when fetching epoch data overlaps with
+// db writes or not.
+func benchmarkCommitPipeline(b *testing.B, maxPendingBatches int) {
+	for i := 0; i < b.N; i++ {
+		db := &mockStackStore{latency: 5 * time.Millisecond}
+		syncer := newTestSyncer(db, maxPendingBatches)
+
+		collect(syncer.newCommitPipeline(), 50, 10, 100*time.Microsecond)
+	}
+}
+
+func BenchmarkCommitPipelineSync(b *testing.B)  { benchmarkCommitPipeline(b, 0) }
+func BenchmarkCommitPipelineAsync(b *testing.B) { benchmarkCommitPipeline(b, 2) }
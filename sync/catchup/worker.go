@@ -20,11 +20,11 @@ type worker struct {
 	cfx sdk.ClientOperator
 }
 
-func mustNewWorker(name, nodeUrl string, chanSize int) *worker {
+func mustNewWorker(name, nodeUrl string, chanSize int, options ...rpc.ClientOption) *worker {
 	return &worker{
 		name:       name,
 		resultChan: make(chan *store.EpochData, chanSize),
-		cfx:        rpc.MustNewCfxClient(nodeUrl),
+		cfx:        rpc.MustNewCfxClient(nodeUrl, options...),
 	}
 }
 
@@ -0,0 +1,123 @@
+// Package contract indexes the contract creations and externally owned accounts of evm space as
+// blocks synced into store, which serves contract creation lookup and accelerates `eth_getCode`.
+package contract
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go/types"
+	"gorm.io/gorm"
+)
+
+var _ mysql.EpochDataObserver = (*Indexer)(nil)
+
+// Config contract creation index configurations
+type Config struct {
+	// whether to index contract creations as blocks synced
+	Enabled bool
+}
+
+// Store persists the contract creations and externally owned accounts.
+type Store interface {
+	AddContractCreations(dbTx *gorm.DB, creations []*mysql.ContractCreation, eoas []*mysql.ExternallyOwnedAccount) error
+	RemoveContractCreations(dbTx *gorm.DB, bnFrom uint64) error
+}
+
+// Indexer indexes the contracts deployed by the executed transactions of synced blocks, along with
+// the transaction senders which are known as externally owned accounts, within the same db
+// transaction, which will be removed as well if blocks popped due to chain reorg.
+type Indexer struct {
+	store Store
+}
+
+// MustLoadConfigFromViper loads contract creation index configurations from viper settings, which
+// are shared by both sync and RPC services.
+func MustLoadConfigFromViper() (conf Config) {
+	viper.MustUnmarshalKey("sync.contractIndex", &conf)
+	return conf
+}
+
+// MustNewIndexerFromViper creates contract creation indexer from viper settings, or returns false if
+// not enabled.
+func MustNewIndexerFromViper(store Store) (*Indexer, bool) {
+	if conf := MustLoadConfigFromViper(); !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	return NewIndexer(store), true
+}
+
+func NewIndexer(store Store) *Indexer {
+	return &Indexer{store: store}
+}
+
+// OnEpochDataPushed implements the `mysql.EpochDataObserver` interface to index contract creations.
+func (idx *Indexer) OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	c := newCreationCollector()
+
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			for i := range block.Transactions {
+				tx := &block.Transactions[i]
+
+				// skip transactions that unexecuted in block
+				if data.Receipts[tx.Hash] == nil || !util.IsTxExecutedInBlock(tx) {
+					continue
+				}
+
+				c.add(data.Number, ethbridge.ConvertTx(tx, nil))
+			}
+		}
+	}
+
+	return idx.store.AddContractCreations(dbTx, c.creations, c.eoas)
+}
+
+// OnEpochDataPopped implements the `mysql.EpochDataObserver` interface to remove contract creations.
+func (idx *Indexer) OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	return idx.store.RemoveContractCreations(dbTx, epochFrom)
+}
+
+// creationCollector collects the contract creations and externally owned accounts of the executed
+// transactions, where each sender is collected only once.
+type creationCollector struct {
+	creations []*mysql.ContractCreation
+	eoas      []*mysql.ExternallyOwnedAccount
+	senders   map[string]bool
+}
+
+func newCreationCollector() *creationCollector {
+	return &creationCollector{senders: make(map[string]bool)}
+}
+
+func (c *creationCollector) add(bn uint64, tx *types.TransactionDetail) {
+	from := strings.ToLower(tx.From.Hex())
+
+	if !c.senders[from] {
+		c.senders[from] = true
+		c.eoas = append(c.eoas, &mysql.ExternallyOwnedAccount{Address: from, Bn: bn})
+	}
+
+	// contract created only if transaction succeeded
+	if tx.Creates == nil || tx.Status == nil || *tx.Status != 1 {
+		return
+	}
+
+	var txIndex uint64
+	if tx.TransactionIndex != nil {
+		txIndex = *tx.TransactionIndex
+	}
+
+	c.creations = append(c.creations, &mysql.ContractCreation{
+		Address: strings.ToLower(tx.Creates.Hex()),
+		Bn:      bn,
+		TxHash:  tx.Hash.Hex(),
+		TxIndex: txIndex,
+		Creator: from,
+	})
+}
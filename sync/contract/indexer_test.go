@@ -0,0 +1,75 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockStore struct {
+	creations []*mysql.ContractCreation
+	eoas      []*mysql.ExternallyOwnedAccount
+}
+
+func (s *mockStore) AddContractCreations(
+	dbTx *gorm.DB, creations []*mysql.ContractCreation, eoas []*mysql.ExternallyOwnedAccount,
+) error {
+	s.creations, s.eoas = creations, eoas
+	return nil
+}
+
+func (s *mockStore) RemoveContractCreations(dbTx *gorm.DB, bnFrom uint64) error {
+	return nil
+}
+
+func TestIndexerOnEpochDataPushed(t *testing.T) {
+	sender := cfxaddress.MustNewFromHex("0x1d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a1", 1030)
+	contract := cfxaddress.MustNewFromHex("0x8d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a2", 1030)
+	blockHash := types.Hash("0x0000000000000000000000000000000000000000000000000000000000000abc")
+
+	newTx := func(hash types.Hash, status uint64, created *cfxaddress.Address) types.Transaction {
+		return types.Transaction{
+			Hash:             hash,
+			From:             sender,
+			BlockHash:        &blockHash,
+			Status:           (*hexutil.Uint64)(&status),
+			ContractCreated:  created,
+			TransactionIndex: new(hexutil.Uint64),
+		}
+	}
+
+	txs := []types.Transaction{
+		newTx("0x01", 0, &contract), // contract created
+		newTx("0x02", 1, &contract), // failed
+		newTx("0x03", 0, nil),       // normal transaction
+	}
+
+	data := &store.EpochData{
+		Number:   10,
+		Blocks:   []*types.Block{{Transactions: txs}},
+		Receipts: make(map[types.Hash]*types.TransactionReceipt),
+	}
+
+	for _, tx := range txs {
+		data.Receipts[tx.Hash] = &types.TransactionReceipt{}
+	}
+
+	s := &mockStore{}
+	err := NewIndexer(s).OnEpochDataPushed(nil, []*store.EpochData{data})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(s.eoas))
+	assert.Equal(t, "0x1d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a1", s.eoas[0].Address)
+	assert.Equal(t, uint64(10), s.eoas[0].Bn)
+
+	assert.Equal(t, 1, len(s.creations))
+	assert.Equal(t, "0x8d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a2", s.creations[0].Address)
+	assert.Equal(t, s.eoas[0].Address, s.creations[0].Creator)
+	assert.Equal(t, uint64(10), s.creations[0].Bn)
+}
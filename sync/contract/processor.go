@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/reprocess"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// ProcessorName name of the processor to rebuild contract creations and externally owned accounts.
+const ProcessorName = "contract_creations"
+
+var (
+	_ reprocess.Processor = (*Processor)(nil)
+	_ ProcessorStore      = (*mysql.MysqlStore)(nil)
+)
+
+func init() {
+	reprocess.RegisterProcessor(ProcessorName, func(db reprocess.Store) (reprocess.Processor, error) {
+		ps, ok := db.(ProcessorStore)
+		if !ok {
+			return nil, errors.New("contract creations not supported by db store")
+		}
+
+		return NewProcessor(rpcutil.MustNewEthClientFromViper(), ps), nil
+	})
+}
+
+// ProcessorStore persists the rebuilt contract creations and externally owned accounts.
+type ProcessorStore interface {
+	ReplaceContractCreations(
+		bnFrom, bnTo uint64, blockHashes map[uint64]string,
+		creations []*mysql.ContractCreation, eoas []*mysql.ExternallyOwnedAccount,
+	) error
+	ResetContractCreations(bnFrom uint64) error
+}
+
+// Processor rebuilds contract creations and externally owned accounts from the blocks queried from
+// fullnode, since transactions are not derivable from the stored event logs.
+type Processor struct {
+	store       ProcessorStore
+	queryBlocks func(bnRange citypes.RangeUint64) ([]*types.Block, map[uint64]string, error)
+}
+
+func NewProcessor(w3c *web3go.Client, store ProcessorStore) *Processor {
+	return &Processor{
+		store: store,
+		queryBlocks: func(bnRange citypes.RangeUint64) ([]*types.Block, map[uint64]string, error) {
+			return reprocess.QueryEthBlocks(w3c, bnRange)
+		},
+	}
+}
+
+// Name implements the `reprocess.Processor` interface.
+func (p *Processor) Name() string {
+	return ProcessorName
+}
+
+// Reset implements the `reprocess.Processor` interface to remove contract creations.
+func (p *Processor) Reset(bnFrom uint64) error {
+	return p.store.ResetContractCreations(bnFrom)
+}
+
+// Process implements the `reprocess.Processor` interface to index contract creations of the block
+// range, where the event logs are not used at all.
+func (p *Processor) Process(ctx context.Context, bnRange citypes.RangeUint64, logs []*store.Log) error {
+	blocks, blockHashes, err := p.queryBlocks(bnRange)
+	if err != nil {
+		return err
+	}
+
+	c := newCreationCollector()
+
+	for _, block := range blocks {
+		blockTxs := block.Transactions.Transactions()
+
+		for i := range blockTxs {
+			// skip transactions that unexecuted in block
+			if blockTxs[i].Status == nil {
+				continue
+			}
+
+			c.add(block.Number.Uint64(), &blockTxs[i])
+		}
+	}
+
+	return p.store.ReplaceContractCreations(bnRange.From, bnRange.To, blockHashes, c.creations, c.eoas)
+}
@@ -0,0 +1,73 @@
+package contract
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProcessorStore struct {
+	bnFrom, bnTo uint64
+	creations    []*mysql.ContractCreation
+	eoas         []*mysql.ExternallyOwnedAccount
+}
+
+func (s *mockProcessorStore) ReplaceContractCreations(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string,
+	creations []*mysql.ContractCreation, eoas []*mysql.ExternallyOwnedAccount,
+) error {
+	s.bnFrom, s.bnTo, s.creations, s.eoas = bnFrom, bnTo, creations, eoas
+	return nil
+}
+
+func (s *mockProcessorStore) ResetContractCreations(bnFrom uint64) error {
+	return nil
+}
+
+func TestProcessorProcess(t *testing.T) {
+	sender := common.HexToAddress("0x1d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a1")
+	contract := common.HexToAddress("0x8d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a2")
+
+	newTx := func(status *uint64, created *common.Address) types.TransactionDetail {
+		return types.TransactionDetail{From: sender, Status: status, Creates: created}
+	}
+
+	success, failure := uint64(1), uint64(0)
+	blocks := []*types.Block{
+		{Number: big.NewInt(10), Transactions: *types.NewTxOrHashListByTxs([]types.TransactionDetail{
+			newTx(nil, &contract), // unexecuted
+			newTx(&failure, &contract),
+		})},
+		{Number: big.NewInt(11), Transactions: *types.NewTxOrHashListByTxs([]types.TransactionDetail{
+			newTx(&success, &contract),
+		})},
+	}
+
+	s := &mockProcessorStore{}
+	p := &Processor{
+		store: s,
+		queryBlocks: func(bnRange citypes.RangeUint64) ([]*types.Block, map[uint64]string, error) {
+			return blocks, map[uint64]string{10: "0xa", 11: "0xb"}, nil
+		},
+	}
+
+	err := p.Process(context.Background(), citypes.RangeUint64{From: 10, To: 11}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(10), s.bnFrom)
+	assert.Equal(t, uint64(11), s.bnTo)
+
+	assert.Equal(t, 1, len(s.eoas))
+	assert.Equal(t, "0x1d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a1", s.eoas[0].Address)
+	assert.Equal(t, uint64(10), s.eoas[0].Bn)
+
+	assert.Equal(t, 1, len(s.creations))
+	assert.Equal(t, "0x8d3e5b6e3b5b0a9f6c2ed7e3c2f1e4e2b0b6c1a2", s.creations[0].Address)
+	assert.Equal(t, uint64(11), s.creations[0].Bn)
+}
@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// coordinatorConfig configurations to coordinate core space and evm space sync in the same process.
+type coordinatorConfig struct {
+	// max number of RPC requests per second to the same fullnode host shared by core space and
+	// evm space sync, 0 for unlimited
+	RateLimit float64
+	// max burst of RPC requests to the same fullnode host
+	Burst int `default:"10"`
+	// interval to refresh the consistency view of both spaces
+	Interval time.Duration `default:"10s"`
+}
+
+// HeadStore provides the max epoch (or block) synced into store.
+type HeadStore interface {
+	MaxEpoch() (uint64, bool, error)
+}
+
+// SpaceHead max epoch (or block) synced into store of some space.
+type SpaceHead struct {
+	MaxEpoch uint64 `json:"maxEpoch"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (h *SpaceHead) valid() bool {
+	return h != nil && len(h.Error) == 0
+}
+
+// ConsistencyView combined view of core space and evm space sync, by which clients could reason about
+// the data consistency across spaces. Note, the block number of evm space is the same as the epoch
+// number of core space.
+type ConsistencyView struct {
+	Cfx       *SpaceHead `json:"cfx,omitempty"` // nil if core space not synced
+	Eth       *SpaceHead `json:"eth,omitempty"` // nil if evm space not synced
+	Skew      int64      `json:"skew"`          // core space max epoch minus evm space max block
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Coordinator coordinates core space and evm space sync running concurrently in the same process,
+// with RPC requests to the same fullnode rate limited in a shared manner, and the max epoch of both
+// spaces reported as a combined consistency view via metrics.
+type Coordinator struct {
+	conf    coordinatorConfig
+	limiter *rpc.HostRateLimiter // nil if unlimited
+}
+
+// MustNewCoordinatorFromViper creates coordinator from viper settings.
+func MustNewCoordinatorFromViper() *Coordinator {
+	var conf coordinatorConfig
+	viperutil.MustUnmarshalKey("sync.coordinator", &conf)
+
+	return newCoordinator(conf)
+}
+
+func newCoordinator(conf coordinatorConfig) *Coordinator {
+	c := &Coordinator{conf: conf}
+
+	if conf.RateLimit > 0 {
+		c.limiter = rpc.NewHostRateLimiter(conf.RateLimit, conf.Burst)
+	}
+
+	return c
+}
+
+// ClientOptions returns the options to create sync clients, so that RPC requests to the same fullnode
+// are rate limited in a shared manner. It's safe to call on nil coordinator, eg., of extra networks.
+func (c *Coordinator) ClientOptions() []rpc.ClientOption {
+	if c == nil || c.limiter == nil {
+		return nil
+	}
+
+	return []rpc.ClientOption{rpc.WithClientRateLimiter(c.limiter)}
+}
+
+// Run refreshes the consistency view of both spaces periodically until context done, in which the
+// store could be nil if the space not synced.
+func (c *Coordinator) Run(ctx context.Context, wg *sync.WaitGroup, cfxStore, ethStore HeadStore) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(c.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(cfxStore, ethStore)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh updates metrics of the consistency view of both spaces, and returns the refreshed view.
+func (c *Coordinator) refresh(cfxStore, ethStore HeadStore) *ConsistencyView {
+	view := ConsistencyView{
+		Cfx:       spaceHead("cfx", cfxStore),
+		Eth:       spaceHead("eth", ethStore),
+		UpdatedAt: time.Now(),
+	}
+
+	if view.Cfx.valid() && view.Eth.valid() {
+		view.Skew = int64(view.Cfx.MaxEpoch) - int64(view.Eth.MaxEpoch)
+		metrics.Registry.Sync.SpaceSkew().Update(view.Skew)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"cfx": view.Cfx, "eth": view.Eth, "skew": view.Skew,
+	}).Debug("Sync coordinator refreshed consistency view")
+
+	return &view
+}
+
+func spaceHead(space string, store HeadStore) *SpaceHead {
+	if util.IsInterfaceValNil(store) {
+		return nil
+	}
+
+	var head SpaceHead
+
+	maxEpoch, ok, err := store.MaxEpoch()
+	if err != nil {
+		head.Error = err.Error()
+		return &head
+	}
+
+	if ok {
+		head.MaxEpoch = maxEpoch
+		metrics.Registry.Sync.SpaceMaxEpoch(space).Update(int64(maxEpoch))
+	}
+
+	return &head
+}
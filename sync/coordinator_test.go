@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHeadStore struct {
+	maxEpoch uint64
+	ok       bool
+	err      error
+}
+
+func (s *mockHeadStore) MaxEpoch() (uint64, bool, error) {
+	return s.maxEpoch, s.ok, s.err
+}
+
+func TestCoordinatorRefresh(t *testing.T) {
+	c := newCoordinator(coordinatorConfig{})
+	assert.Nil(t, c.ClientOptions())
+
+	view := c.refresh(&mockHeadStore{maxEpoch: 105, ok: true}, &mockHeadStore{maxEpoch: 100, ok: true})
+	assert.Equal(t, uint64(105), view.Cfx.MaxEpoch)
+	assert.Equal(t, uint64(100), view.Eth.MaxEpoch)
+	assert.Equal(t, int64(5), view.Skew)
+
+	view = c.refresh(&mockHeadStore{maxEpoch: 105, ok: true}, &mockHeadStore{err: errors.New("db down")})
+	assert.Equal(t, "db down", view.Eth.Error)
+	assert.Zero(t, view.Skew)
+
+	var ethStore *mysql.MysqlStore
+	view = c.refresh(&mockHeadStore{maxEpoch: 105, ok: true}, ethStore)
+	assert.NotNil(t, view.Cfx)
+	assert.Nil(t, view.Eth)
+}
+
+func TestCoordinatorRateLimit(t *testing.T) {
+	c := newCoordinator(coordinatorConfig{RateLimit: 10, Burst: 1})
+	assert.Len(t, c.ClientOptions(), 1)
+
+	// extra networks not coordinated
+	var nilCoordinator *Coordinator
+	assert.Nil(t, nilCoordinator.ClientOptions())
+}
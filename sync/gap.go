@@ -0,0 +1,233 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// gap detection and backfill configuration
+type gapConfig struct {
+	// whether to detect and backfill the missing epochs in store
+	Enabled bool
+	// interval to scan for gaps and backfill
+	Interval time.Duration `default:"1m"`
+	// number of epochs scanned for gaps per round
+	ScanEpochs uint64 `default:"100000"`
+	// max number of epochs to backfill per batch
+	BatchEpochs uint64 `default:"10"`
+	// max number of pending gaps queued to backfill
+	MaxPendingGaps int `default:"100"`
+}
+
+// HeadSyncer syncs the latest epoch data into store, which has higher priority than backfill.
+type HeadSyncer interface {
+	// CaughtUp returns whether head sync caught up to the latest epoch to sync.
+	CaughtUp() bool
+}
+
+// GapBackfiller scans the store for missing epochs (eg., due to partial write failures), and backfills
+// them from fullnode only when the head sync caught up, so as not to compete with head sync.
+type GapBackfiller struct {
+	conf    gapConfig
+	space   string
+	db      *mysql.MysqlStore
+	fetcher epochDataFetcher
+
+	// next epoch to scan from, which wraps around to the min epoch once the max epoch scanned
+	cursor uint64
+	// pending gaps to backfill in ascending order
+	gaps []citypes.RangeUint64
+}
+
+func mustNewGapBackfiller(space string, db *mysql.MysqlStore, fetcher epochDataFetcher) *GapBackfiller {
+	var conf gapConfig
+	viperutil.MustUnmarshalKey("sync.gap", &conf)
+
+	return &GapBackfiller{conf: conf, space: space, db: db, fetcher: fetcher}
+}
+
+// MustNewCfxGapBackfiller creates an instance of GapBackfiller for core space store.
+func MustNewCfxGapBackfiller(cfx sdk.ClientOperator, db *mysql.MysqlStore) *GapBackfiller {
+	return mustNewGapBackfiller("cfx", db, cfxEpochDataFetcher(cfx))
+}
+
+// MustNewEthGapBackfiller creates an instance of GapBackfiller for evm space store.
+func MustNewEthGapBackfiller(w3c *web3go.Client, db *mysql.MysqlStore) *GapBackfiller {
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get chain ID from eth space")
+	}
+
+	return mustNewGapBackfiller("eth", db, ethEpochDataFetcher(w3c, uint32(*chainId)))
+}
+
+// Enabled returns whether to detect and backfill the missing epochs in store.
+func (gb *GapBackfiller) Enabled() bool {
+	return gb.conf.Enabled
+}
+
+// Run scans for gaps and backfills them periodically until context done.
+func (gb *GapBackfiller) Run(ctx context.Context, wg *sync.WaitGroup, head HeadSyncer) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logrus.WithField("space", gb.space).Info("Gap backfiller started")
+
+	ticker := time.NewTicker(gb.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := gb.scanOnce(); err != nil {
+				logrus.WithField("space", gb.space).WithError(err).Info("Failed to scan gaps in store")
+			}
+
+			gb.backfill(ctx, head)
+		}
+	}
+}
+
+// scanOnce scans a range of epochs from the cursor for gaps, and enqueues the detected gaps.
+func (gb *GapBackfiller) scanOnce() error {
+	minEpoch, ok, err := gb.db.MinEpoch()
+	if err != nil || !ok {
+		return errors.WithMessage(err, "failed to get min epoch")
+	}
+
+	maxEpoch, ok, err := gb.db.MaxEpoch()
+	if err != nil || !ok {
+		return errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	// wrap around once the max epoch scanned, or epochs pruned in the meantime
+	if gb.cursor < minEpoch || gb.cursor > maxEpoch {
+		gb.cursor = minEpoch
+	}
+
+	to := maxEpoch
+	if gb.conf.ScanEpochs > 0 && maxEpoch-gb.cursor >= gb.conf.ScanEpochs {
+		to = gb.cursor + gb.conf.ScanEpochs - 1
+	}
+
+	gaps, err := gb.db.Gaps(gb.cursor, to)
+	if err != nil {
+		return errors.WithMessage(err, "failed to detect gaps")
+	}
+
+	gb.cursor = to + 1
+
+	for _, gap := range gaps {
+		if !gb.enqueue(gap) {
+			break
+		}
+
+		metrics.Registry.Sync.GapsDetected(gb.space).Mark(1)
+		logrus.WithFields(logrus.Fields{
+			"space": gb.space, "gap": gap,
+		}).Warn("Gap detected in store")
+	}
+
+	gb.updatePendingMetrics()
+
+	return nil
+}
+
+// enqueue adds the gap to backfill if not queued yet, and returns false if queue is full.
+func (gb *GapBackfiller) enqueue(gap citypes.RangeUint64) bool {
+	for _, g := range gb.gaps {
+		if g.From == gap.From {
+			return true
+		}
+	}
+
+	if len(gb.gaps) >= gb.conf.MaxPendingGaps {
+		return false
+	}
+
+	gb.gaps = append(gb.gaps, gap)
+
+	return true
+}
+
+// backfill backfills the pending gaps in batches as long as head sync caught up.
+func (gb *GapBackfiller) backfill(ctx context.Context, head HeadSyncer) {
+	for len(gb.gaps) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// head sync always takes precedence over backfill
+		if head != nil && !head.CaughtUp() {
+			return
+		}
+
+		gap := gb.gaps[0]
+
+		to := gap.To
+		if gb.conf.BatchEpochs > 0 && to-gap.From >= gb.conf.BatchEpochs {
+			to = gap.From + gb.conf.BatchEpochs - 1
+		}
+
+		if err := gb.backfillOnce(gap.From, to); err != nil {
+			// drop the gap, which will be detected again in the next scan cycle if still missing
+			gb.gaps = gb.gaps[1:]
+			gb.updatePendingMetrics()
+
+			logrus.WithFields(logrus.Fields{
+				"space": gb.space, "gap": gap,
+			}).WithError(err).Info("Failed to backfill gap in store")
+
+			continue
+		}
+
+		metrics.Registry.Sync.GapBackfilledEpochs(gb.space).Mark(int64(to - gap.From + 1))
+
+		if to == gap.To {
+			gb.gaps = gb.gaps[1:]
+		} else {
+			gb.gaps[0].From = to + 1
+		}
+
+		gb.updatePendingMetrics()
+	}
+}
+
+func (gb *GapBackfiller) backfillOnce(from, to uint64) error {
+	dataSlice := make([]*store.EpochData, 0, to-from+1)
+
+	for epoch := from; epoch <= to; epoch++ {
+		data, err := gb.fetcher(epoch)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to query epoch data for epoch %v", epoch)
+		}
+
+		dataSlice = append(dataSlice, data)
+	}
+
+	return gb.db.Backfill(dataSlice)
+}
+
+func (gb *GapBackfiller) updatePendingMetrics() {
+	var pending uint64
+	for _, gap := range gb.gaps {
+		pending += gap.To - gap.From + 1
+	}
+
+	metrics.Registry.Sync.GapEpochs(gb.space).Update(int64(pending))
+}
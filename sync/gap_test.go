@@ -0,0 +1,20 @@
+package sync
+
+import (
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGapBackfillerEnqueue(t *testing.T) {
+	gb := GapBackfiller{conf: gapConfig{MaxPendingGaps: 2}}
+
+	assert.True(t, gb.enqueue(citypes.RangeUint64{From: 10, To: 20}))
+	assert.True(t, gb.enqueue(citypes.RangeUint64{From: 10, To: 20})) // deduplicated
+	assert.Equal(t, 1, len(gb.gaps))
+
+	assert.True(t, gb.enqueue(citypes.RangeUint64{From: 30, To: 30}))
+	assert.False(t, gb.enqueue(citypes.RangeUint64{From: 40, To: 50})) // queue full
+	assert.Equal(t, []citypes.RangeUint64{{From: 10, To: 20}, {From: 30, To: 30}}, gb.gaps)
+}
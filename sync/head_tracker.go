@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"sync"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// number of the recent pivot switches kept for confirmation depth adaption
+	pivotSwitchHistorySize = 100
+)
+
+// pivotSwitch pivot chain switch of Conflux tree-graph, by which the epochs from the fork
+// epoch up to the old head epoch are replaced by the new pivot chain.
+type pivotSwitch struct {
+	fork         uint64     // the first switched epoch
+	oldHead      uint64     // head epoch before pivot switch
+	newPivotHash types.Hash // pivot hash of the fork epoch on the new pivot chain
+}
+
+// depth returns the number of epochs switched.
+func (ps *pivotSwitch) depth() uint64 {
+	return ps.oldHead - ps.fork + 1
+}
+
+// headTracker tracks the head epoch of pivot chain notified from pubsub, and detects pivot
+// chain switches explicitly.
+type headTracker struct {
+	mu sync.Mutex
+
+	space        string   // "cfx" or "eth"
+	storeName    string   // "db" or "kv"
+	head         uint64   // head epoch number, `citypes.EpochNumberNil` if not tracked yet
+	recentDepths []uint64 // depths of the recent pivot switches
+}
+
+func newHeadTracker(space, storeName string) *headTracker {
+	return &headTracker{space: space, storeName: storeName, head: citypes.EpochNumberNil}
+}
+
+// track tracks the new head epoch, and returns the pivot switch if detected. Besides, it also
+// validates if the new head is continuous to the last tracked head.
+func (t *headTracker) track(newHead uint64, pivotHash types.Hash) (*pivotSwitch, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case t.head == citypes.EpochNumberNil: // initial state
+		t.head = newHead
+		return nil, nil
+	case t.head+1 == newHead: // continuous
+		t.head = newHead
+		return nil, nil
+	case t.head < newHead: // bad incontinuous epoch
+		return nil, errors.Errorf("bad incontinuous epoch, expect %v got %v", t.head+1, newHead)
+	}
+
+	ps := &pivotSwitch{fork: newHead, oldHead: t.head, newPivotHash: pivotHash}
+
+	t.head = newHead
+
+	t.recentDepths = append(t.recentDepths, ps.depth())
+	if len(t.recentDepths) > pivotSwitchHistorySize {
+		t.recentDepths = t.recentDepths[1:]
+	}
+
+	metrics.Registry.Sync.PivotSwitches(t.space, t.storeName).Mark(1)
+	metrics.Registry.Sync.PivotSwitchDepth(t.space, t.storeName).Update(int64(ps.depth()))
+
+	logrus.WithFields(logrus.Fields{
+		"space":        t.space,
+		"storeName":    t.storeName,
+		"fork":         ps.fork,
+		"oldHead":      ps.oldHead,
+		"depth":        ps.depth(),
+		"newPivotHash": pivotHash,
+	}).Info("Head tracker detected pivot chain switch")
+
+	return ps, nil
+}
+
+// reset resets the tracked head, eg., once pubsub re-subscribed.
+func (t *headTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.head = citypes.EpochNumberNil
+}
+
+// currentHead returns the tracked head epoch number.
+func (t *headTracker) currentHead() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.head
+}
+
+// maxRecentDepth returns the max depth of the recent pivot switches.
+func (t *headTracker) maxRecentDepth() (depth uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, d := range t.recentDepths {
+		depth = util.MaxUint64(depth, d)
+	}
+
+	return depth
+}
+
+// confirmation depth policy configuration, which determines the boundary epoch of store
+// (the max epoch to sync into store).
+type confirmationConfig struct {
+	// head epoch to count confirmations from, available options are "latest_mined",
+	// "latest_state", "latest_confirmed" and "latest_finalized".
+	Epoch string `default:"latest_confirmed"`
+	// number of confirmed epochs required behind the head epoch
+	Depth uint64
+	// whether to extend the confirmation depth to the max depth of recent pivot switches
+	Adaptive bool
+}
+
+// confirmationPolicy determines the boundary epoch of store by confirmation depth.
+type confirmationPolicy struct {
+	conf    confirmationConfig
+	head    *types.Epoch
+	tracker *headTracker
+}
+
+func mustNewConfirmationPolicy(conf confirmationConfig, tracker *headTracker) *confirmationPolicy {
+	var head *types.Epoch
+
+	switch conf.Epoch {
+	case types.EpochLatestMined.String():
+		head = types.EpochLatestMined
+	case types.EpochLatestState.String():
+		head = types.EpochLatestState
+	case types.EpochLatestConfirmed.String():
+		head = types.EpochLatestConfirmed
+	case types.EpochLatestFinalized.String():
+		head = types.EpochLatestFinalized
+	default:
+		logrus.WithField("epoch", conf.Epoch).Fatal("Invalid head epoch for confirmation policy")
+	}
+
+	return &confirmationPolicy{conf: conf, head: head, tracker: tracker}
+}
+
+// depth returns the confirmation depth to apply.
+func (p *confirmationPolicy) depth() uint64 {
+	if !p.conf.Adaptive || p.tracker == nil {
+		return p.conf.Depth
+	}
+
+	return util.MaxUint64(p.conf.Depth, p.tracker.maxRecentDepth())
+}
+
+// boundary returns the max epoch which is confirmed enough to be synced into store, or false
+// if no epoch confirmed yet.
+func (p *confirmationPolicy) boundary(cfx sdk.ClientOperator) (uint64, bool, error) {
+	epoch, err := cfx.GetEpochNumber(p.head)
+	if err != nil {
+		return 0, false, errors.WithMessagef(err, "failed to query the %v epoch number", p.head)
+	}
+
+	headEpoch, depth := epoch.ToInt().Uint64(), p.depth()
+	if headEpoch < depth {
+		return 0, false, nil
+	}
+
+	return headEpoch - depth, true, nil
+}
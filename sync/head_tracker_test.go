@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadTrackerPivotSwitch(t *testing.T) {
+	tracker := newHeadTracker("cfx", "test")
+
+	for epoch := uint64(100); epoch <= 105; epoch++ {
+		ps, err := tracker.track(epoch, "")
+		assert.NoError(t, err)
+		assert.Nil(t, ps)
+	}
+
+	// incontinuous epoch
+	_, err := tracker.track(107, "")
+	assert.Error(t, err)
+	assert.Equal(t, uint64(105), tracker.currentHead())
+
+	// pivot switched back to epoch 103
+	ps, err := tracker.track(103, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(103), ps.fork)
+	assert.Equal(t, uint64(105), ps.oldHead)
+	assert.Equal(t, uint64(3), ps.depth())
+	assert.Equal(t, uint64(3), tracker.maxRecentDepth())
+
+	ps, err = tracker.track(103, "0x2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), ps.depth())
+	assert.Equal(t, uint64(3), tracker.maxRecentDepth())
+
+	tracker.reset()
+	assert.Equal(t, citypes.EpochNumberNil, tracker.currentHead())
+}
+
+func TestConfirmationPolicyDepth(t *testing.T) {
+	tracker := newHeadTracker("cfx", "test")
+	tracker.track(100, "")
+	tracker.track(95, "") // pivot switch with depth 6
+
+	policy := mustNewConfirmationPolicy(confirmationConfig{Epoch: "latest_state", Depth: 5}, tracker)
+	assert.Equal(t, uint64(5), policy.depth())
+
+	policy.conf.Adaptive = true
+	assert.Equal(t, uint64(6), policy.depth())
+}
@@ -0,0 +1,66 @@
+package progress
+
+import (
+	"math"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util/metrics"
+)
+
+// Reporter reports the throughput of sync pipeline, eg., epochs (or blocks) and bytes synced per
+// second, store write latency, backlog behind the store boundary and the estimated catch-up ETA.
+type Reporter struct {
+	space     string // "cfx" or "eth"
+	storeName string // eg., "db", "kv" or "catchup"
+}
+
+func NewReporter(space, storeName string) *Reporter {
+	return &Reporter{space: space, storeName: storeName}
+}
+
+// Observe reports the epoch data persisted into store within some sync round.
+func (r *Reporter) Observe(dataSlice []*store.EpochData, writeLatency time.Duration) {
+	metrics.Registry.Sync.SyncEpochs(r.space, r.storeName).Mark(int64(len(dataSlice)))
+	metrics.Registry.Sync.SyncBytes(r.space, r.storeName).Mark(int64(epochDataSize(dataSlice)))
+	metrics.Registry.Sync.StoreWriteLatency(r.space, r.storeName).Update(writeLatency)
+}
+
+// UpdateBacklog reports the number of epochs (or blocks) behind the store boundary, along with the
+// catch-up ETA estimated by the recent sync rate, which are returned as well.
+func (r *Reporter) UpdateBacklog(nextEpoch, boundary uint64) (backlog uint64, eta time.Duration) {
+	if boundary >= nextEpoch {
+		backlog = boundary - nextEpoch + 1
+	}
+
+	rate := metrics.Registry.Sync.SyncEpochs(r.space, r.storeName).Rate1()
+	eta = CatchUpEta(backlog, rate)
+
+	metrics.Registry.Sync.Backlog(r.space, r.storeName).Update(int64(backlog))
+	metrics.Registry.Sync.CatchUpEta(r.space, r.storeName).Update(int64(eta.Seconds()))
+
+	return backlog, eta
+}
+
+// CatchUpEta estimates the duration to catch up with the backlog at the specified rate (epochs per
+// second), or -1 if not predictable, eg., no progress made recently.
+func CatchUpEta(backlog uint64, rate float64) time.Duration {
+	if backlog == 0 {
+		return 0
+	}
+
+	if rate <= 0 || math.IsNaN(rate) {
+		return -time.Second
+	}
+
+	return time.Duration(float64(backlog) / rate * float64(time.Second))
+}
+
+// epochDataSize returns the encoded size of epoch data measured by store at persistence time.
+func epochDataSize(dataSlice []*store.EpochData) (size int) {
+	for _, data := range dataSlice {
+		size += data.Size
+	}
+
+	return size
+}
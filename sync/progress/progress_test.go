@@ -0,0 +1,21 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatchUpEta(t *testing.T) {
+	assert.Equal(t, time.Duration(0), CatchUpEta(0, 0))
+	assert.Equal(t, -time.Second, CatchUpEta(100, 0))
+	assert.Equal(t, 50*time.Second, CatchUpEta(100, 2))
+	assert.Equal(t, 250*time.Millisecond, CatchUpEta(1, 4))
+}
+
+func TestEpochDataSize(t *testing.T) {
+	dataSlice := []*store.EpochData{{Number: 1, Size: 100}, {Number: 2}, {Number: 3, Size: 28}}
+	assert.Equal(t, 128, epochDataSize(dataSlice))
+}
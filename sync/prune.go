@@ -69,6 +69,17 @@ func (pruner *Pruner) Prune(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// Interval returns the interval to run pruning.
+func (pruner *Pruner) Interval() time.Duration {
+	return pruner.pruneConfig.PruneInterval
+}
+
+// PruneOnce prunes blockchain data once, which is used by job scheduler rather than pruning
+// periodically by `Prune`.
+func (pruner *Pruner) PruneOnce(ctx context.Context) error {
+	return pruner.doTicker()
+}
+
 func (pruner *Pruner) doTicker() error {
 	for _, dt := range store.OpEpochDataTypes {
 		if err := pruner.pruneEpochData(dt); err != nil {
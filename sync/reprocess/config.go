@@ -0,0 +1,16 @@
+package reprocess
+
+import "time"
+
+type config struct {
+	// number of concurrent workers to reprocess block ranges
+	Workers int `default:"4"`
+	// number of blocks per batch to reprocess
+	BatchBlocks uint64 `default:"1000"`
+	// max number of batches to reprocess per second, 0 means no throttling
+	BatchRate float64
+	// max number of retries for a failed batch before aborting
+	MaxRetries int `default:"3"`
+	// interval to retry a failed batch
+	RetryInterval time.Duration `default:"5s"`
+}
@@ -0,0 +1,33 @@
+package reprocess
+
+import (
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/openweb3/web3go"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// QueryEthBlocks queries the evm space blocks with full transactions of the specified block range from
+// fullnode, for processors that rebuild derived table from transactions rather than event logs. Block
+// hashes are returned by block number as well, so as to validate against the blocks synced into store.
+func QueryEthBlocks(
+	w3c *web3go.Client, bnRange types.RangeUint64,
+) (blocks []*web3Types.Block, blockHashes map[uint64]string, err error) {
+	blockHashes = make(map[uint64]string)
+
+	for bn := bnRange.From; bn <= bnRange.To; bn++ {
+		block, err := w3c.Eth.BlockByNumber(web3Types.BlockNumber(bn), true)
+		if err == nil && block == nil {
+			err = errors.New("block not found")
+		}
+
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed to get block %v", bn)
+		}
+
+		blocks = append(blocks, block)
+		blockHashes[bn] = block.Hash.Hex()
+	}
+
+	return blocks, blockHashes, nil
+}
@@ -0,0 +1,64 @@
+package reprocess
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/pkg/errors"
+)
+
+// Processor rebuilds some derived table (eg., token transfers or address activities) from
+// the stored raw event logs, or from fullnode if not derivable from event logs (eg., address
+// activities which are derived from transactions).
+type Processor interface {
+	// Name returns the unique name of the processor.
+	Name() string
+
+	// Reset removes derived data from the specified block number on, so that derived data
+	// could be rebuilt from scratch.
+	Reset(bnFrom uint64) error
+
+	// Process rebuilds derived data with raw event logs within the specified block range.
+	//
+	// Note, block ranges are processed concurrently in no particular order, and the same block
+	// range may be processed again when resuming from checkpoint, so it must be idempotent.
+	Process(ctx context.Context, bnRange types.RangeUint64, logs []*store.Log) error
+}
+
+// ProcessorFactory creates processor with the db store where derived table resides, which is usually
+// asserted to the narrow interface required by the processor.
+type ProcessorFactory func(db Store) (Processor, error)
+
+var factories = make(map[string]ProcessorFactory)
+
+// RegisterProcessor registers processor factory by name, which is usually called in `init`
+// when some new derived table introduced.
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	if _, ok := factories[name]; ok {
+		panic(errors.Errorf("processor %v already registered", name))
+	}
+
+	factories[name] = factory
+}
+
+// NewProcessor creates processor by the registered name.
+func NewProcessor(name string, db Store) (Processor, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, errors.Errorf("processor %v not registered", name)
+	}
+
+	return factory(db)
+}
+
+// Processors returns the names of all registered processors.
+func Processors() (names []string) {
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
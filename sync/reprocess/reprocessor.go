@@ -0,0 +1,338 @@
+package reprocess
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/types"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// config key prefix to persist reprocess checkpoint
+	checkpointConfKeyPrefix = "reprocess.checkpoint."
+)
+
+// batchResult result of a reprocessed block range batch
+type batchResult struct {
+	bnRange types.RangeUint64
+	err     error
+}
+
+// Store db store to scan raw event logs and persist reprocess checkpoint, which is also passed to
+// the processor factory so that processors could rebuild derived table in the same db store.
+type Store interface {
+	MaxEpoch() (uint64, bool, error)
+	BlockRange(epoch uint64) (types.RangeUint64, bool, error)
+	ScanLogs(ctx context.Context, bnFrom, bnTo uint64) ([]*store.Log, error)
+	LoadConfig(confNames ...string) (map[string]interface{}, error)
+	StoreConfig(confName string, confVal interface{}) error
+}
+
+// Reprocessor rebuilds derived table from the stored raw event logs in parallel, with checkpoint
+// persisted so that it could be resumed after interruption, and throttled so as not to overload
+// the database.
+type Reprocessor struct {
+	conf      config
+	db        Store
+	processor Processor
+
+	// block range to reprocess, `To` defaults to the latest block number in db store if 0
+	bnRange types.RangeUint64
+	// whether to reset derived data and ignore the persisted checkpoint
+	reset bool
+	// limiter to throttle the reprocess rate, nil means no throttling
+	limiter *rate.Limiter
+}
+
+// functional options for reprocessor
+type Option func(*Reprocessor)
+
+func WithBlockFrom(bnFrom uint64) Option {
+	return func(r *Reprocessor) {
+		r.bnRange.From = bnFrom
+	}
+}
+
+func WithBlockTo(bnTo uint64) Option {
+	return func(r *Reprocessor) {
+		r.bnRange.To = bnTo
+	}
+}
+
+func WithReset(reset bool) Option {
+	return func(r *Reprocessor) {
+		r.reset = reset
+	}
+}
+
+func MustNewReprocessor(db Store, processor Processor, opts ...Option) *Reprocessor {
+	var conf config
+	viperutil.MustUnmarshalKey("sync.reprocess", &conf)
+
+	return newReprocessor(conf, db, processor, opts...)
+}
+
+func newReprocessor(conf config, db Store, processor Processor, opts ...Option) *Reprocessor {
+	r := &Reprocessor{conf: conf, db: db, processor: processor}
+
+	if conf.Workers <= 0 {
+		r.conf.Workers = 1
+	}
+
+	if conf.BatchBlocks == 0 {
+		r.conf.BatchBlocks = 1
+	}
+
+	if conf.BatchRate > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(conf.BatchRate), 1)
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run reprocesses the block range until completed or the context canceled.
+func (r *Reprocessor) Run(ctx context.Context) {
+	logger := logrus.WithField("processor", r.processor.Name())
+
+	if err := r.run(ctx); err != nil {
+		logger.WithError(err).Error("Failed to reprocess derived table")
+		return
+	}
+
+	if ctx.Err() != nil {
+		logger.Info("Reprocess derived table interrupted")
+		return
+	}
+
+	logger.Info("Reprocess derived table completed")
+}
+
+func (r *Reprocessor) run(ctx context.Context) error {
+	bnRange, err := r.prepare()
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"processor": r.processor.Name(), "bnRange": bnRange,
+	})
+
+	if bnRange.From > bnRange.To {
+		logger.Info("No blocks to reprocess for derived table")
+		return nil
+	}
+
+	logger.Info("Start to reprocess derived table")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	taskCh := make(chan types.RangeUint64, r.conf.Workers)
+	resultCh := make(chan batchResult, r.conf.Workers)
+
+	var workerWg sync.WaitGroup
+	for i := 0; i < r.conf.Workers; i++ {
+		workerWg.Add(1)
+
+		go func() {
+			defer workerWg.Done()
+
+			for task := range taskCh {
+				resultCh <- batchResult{bnRange: task, err: r.processBatch(ctx, task)}
+			}
+		}()
+	}
+
+	go func() { // dispatch batches
+		defer close(taskCh)
+
+		for from := bnRange.From; from <= bnRange.To; from += r.conf.BatchBlocks {
+			to := from + r.conf.BatchBlocks - 1
+			if to > bnRange.To {
+				to = bnRange.To
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case taskCh <- types.RangeUint64{From: from, To: to}:
+			}
+		}
+	}()
+
+	go func() {
+		workerWg.Wait()
+		close(resultCh)
+	}()
+
+	return r.collect(cancel, bnRange, resultCh)
+}
+
+// prepare determines the block range to reprocess, either resumed from checkpoint or reset.
+func (r *Reprocessor) prepare() (types.RangeUint64, error) {
+	bnRange := r.bnRange
+
+	if bnRange.To == 0 {
+		maxEpoch, ok, err := r.db.MaxEpoch()
+		if err != nil {
+			return bnRange, errors.WithMessage(err, "failed to get max epoch")
+		}
+
+		if !ok { // no data in db store
+			return types.RangeUint64{From: 1, To: 0}, nil
+		}
+
+		epochBnRange, ok, err := r.db.BlockRange(maxEpoch)
+		if err != nil {
+			return bnRange, errors.WithMessagef(err, "failed to get block range of epoch %v", maxEpoch)
+		}
+
+		if !ok {
+			return bnRange, errors.Errorf("no block range found for epoch %v", maxEpoch)
+		}
+
+		bnRange.To = epochBnRange.To
+	}
+
+	if r.reset {
+		if err := r.processor.Reset(bnRange.From); err != nil {
+			return bnRange, errors.WithMessage(err, "failed to reset derived table")
+		}
+
+		return bnRange, r.saveCheckpoint(bnRange.From)
+	}
+
+	checkpoint, ok, err := r.loadCheckpoint()
+	if err != nil {
+		return bnRange, errors.WithMessage(err, "failed to load checkpoint")
+	}
+
+	if ok && checkpoint > bnRange.From {
+		bnRange.From = checkpoint
+	}
+
+	return bnRange, nil
+}
+
+// processBatch reprocesses the block range batch with retry.
+func (r *Reprocessor) processBatch(ctx context.Context, bnRange types.RangeUint64) (err error) {
+	for i := 0; i <= r.conf.MaxRetries; i++ {
+		if i > 0 {
+			logrus.WithFields(logrus.Fields{
+				"processor": r.processor.Name(), "bnRange": bnRange, "retries": i,
+			}).WithError(err).Info("Failed to reprocess batch, retrying...")
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.conf.RetryInterval):
+			}
+		}
+
+		if r.limiter != nil {
+			if err = r.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		var logs []*store.Log
+		if logs, err = r.db.ScanLogs(ctx, bnRange.From, bnRange.To); err != nil {
+			err = errors.WithMessage(err, "failed to scan event logs")
+			continue
+		}
+
+		if err = r.processor.Process(ctx, bnRange, logs); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// collect collects batch results and advances the checkpoint to the lowest block number
+// that has not been reprocessed yet.
+func (r *Reprocessor) collect(
+	cancel context.CancelFunc, bnRange types.RangeUint64, resultCh <-chan batchResult,
+) error {
+	// completed batches that not continuous to the checkpoint yet (block from => block to)
+	pendings := make(map[uint64]uint64)
+	checkpoint := bnRange.From
+
+	var resErr error
+	for res := range resultCh {
+		if res.err != nil {
+			if resErr == nil && !errors.Is(res.err, context.Canceled) {
+				resErr = errors.WithMessagef(res.err, "failed to reprocess block range %v", res.bnRange)
+			}
+
+			cancel() // abort since the checkpoint can't be advanced any more
+			continue
+		}
+
+		pendings[res.bnRange.From] = res.bnRange.To
+
+		advanced := false
+		for to, ok := pendings[checkpoint]; ok; to, ok = pendings[checkpoint] {
+			delete(pendings, checkpoint)
+			checkpoint, advanced = to+1, true
+		}
+
+		if !advanced {
+			continue
+		}
+
+		if err := r.saveCheckpoint(checkpoint); err != nil {
+			logrus.WithError(err).WithField("checkpoint", checkpoint).Info("Failed to save reprocess checkpoint")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"processor":  r.processor.Name(),
+			"checkpoint": checkpoint,
+			"progress":   float64(checkpoint-bnRange.From) / float64(bnRange.To-bnRange.From+1),
+		}).Debug("Reprocess checkpoint advanced")
+	}
+
+	return resErr
+}
+
+func (r *Reprocessor) checkpointConfKey() string {
+	return checkpointConfKeyPrefix + r.processor.Name()
+}
+
+// loadCheckpoint loads the next block number to reprocess.
+func (r *Reprocessor) loadCheckpoint() (uint64, bool, error) {
+	key := r.checkpointConfKey()
+
+	confs, err := r.db.LoadConfig(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	val, ok := confs[key].(string)
+	if !ok {
+		return 0, false, nil
+	}
+
+	checkpoint, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, false, errors.WithMessagef(err, "invalid checkpoint %v", val)
+	}
+
+	return checkpoint, true, nil
+}
+
+// saveCheckpoint saves the next block number to reprocess.
+func (r *Reprocessor) saveCheckpoint(checkpoint uint64) error {
+	return r.db.StoreConfig(r.checkpointConfKey(), strconv.FormatUint(checkpoint, 10))
+}
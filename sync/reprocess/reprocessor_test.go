@@ -0,0 +1,196 @@
+package reprocess
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockStore stores one event log per block, and epoch number is the same as block number.
+type mockStore struct {
+	mu      sync.Mutex
+	maxBn   uint64
+	configs map[string]interface{}
+}
+
+func newMockStore(maxBn uint64) *mockStore {
+	return &mockStore{maxBn: maxBn, configs: make(map[string]interface{})}
+}
+
+func (s *mockStore) MaxEpoch() (uint64, bool, error) {
+	return s.maxBn, s.maxBn > 0, nil
+}
+
+func (s *mockStore) BlockRange(epoch uint64) (types.RangeUint64, bool, error) {
+	return types.RangeUint64{From: epoch, To: epoch}, epoch <= s.maxBn, nil
+}
+
+func (s *mockStore) ScanLogs(ctx context.Context, bnFrom, bnTo uint64) (logs []*store.Log, err error) {
+	for bn := bnFrom; bn <= bnTo; bn++ {
+		logs = append(logs, &store.Log{BlockNumber: bn})
+	}
+
+	return logs, nil
+}
+
+func (s *mockStore) LoadConfig(confNames ...string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]interface{})
+	for _, name := range confNames {
+		if val, ok := s.configs[name]; ok {
+			result[name] = val
+		}
+	}
+
+	return result, nil
+}
+
+func (s *mockStore) StoreConfig(confName string, confVal interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configs[confName] = confVal
+	return nil
+}
+
+// mockProcessor records the processed blocks, and always fails to process the specified block.
+type mockProcessor struct {
+	mu        sync.Mutex
+	failBn    uint64
+	resetFrom *uint64
+	blocks    []uint64
+}
+
+func (p *mockProcessor) Name() string { return "mock" }
+
+func (p *mockProcessor) Reset(bnFrom uint64) error {
+	p.resetFrom = &bnFrom
+	return nil
+}
+
+func (p *mockProcessor) Process(ctx context.Context, bnRange types.RangeUint64, logs []*store.Log) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, log := range logs {
+		if log.BlockNumber == p.failBn {
+			return errors.New("failed to process")
+		}
+	}
+
+	for _, log := range logs {
+		p.blocks = append(p.blocks, log.BlockNumber)
+	}
+
+	return nil
+}
+
+func (p *mockProcessor) processed() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	blocks := append([]uint64{}, p.blocks...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+
+	return blocks
+}
+
+func blockRange(from, to uint64) (blocks []uint64) {
+	for bn := from; bn <= to; bn++ {
+		blocks = append(blocks, bn)
+	}
+
+	return blocks
+}
+
+func newTestReprocessor(db Store, processor Processor, opts ...Option) *Reprocessor {
+	return newReprocessor(config{Workers: 2, BatchBlocks: 3, MaxRetries: 1}, db, processor, opts...)
+}
+
+func TestReprocessorRun(t *testing.T) {
+	db, processor := newMockStore(10), &mockProcessor{}
+
+	// block range defaults to the latest block in db store
+	r := newTestReprocessor(db, processor, WithBlockFrom(1))
+	assert.NoError(t, r.run(context.Background()))
+
+	assert.Equal(t, blockRange(1, 10), processor.processed())
+	assert.Equal(t, "11", db.configs[r.checkpointConfKey()])
+}
+
+func TestReprocessorRunEmptyStore(t *testing.T) {
+	processor := &mockProcessor{}
+
+	r := newTestReprocessor(newMockStore(0), processor, WithBlockFrom(1))
+	assert.NoError(t, r.run(context.Background()))
+	assert.Empty(t, processor.processed())
+}
+
+func TestReprocessorResume(t *testing.T) {
+	db, processor := newMockStore(10), &mockProcessor{}
+	db.configs[checkpointConfKeyPrefix+"mock"] = "6"
+
+	r := newTestReprocessor(db, processor, WithBlockFrom(1), WithBlockTo(8))
+	assert.NoError(t, r.run(context.Background()))
+
+	assert.Nil(t, processor.resetFrom)
+	assert.Equal(t, blockRange(6, 8), processor.processed())
+	assert.Equal(t, "9", db.configs[r.checkpointConfKey()])
+}
+
+func TestReprocessorReset(t *testing.T) {
+	db, processor := newMockStore(10), &mockProcessor{}
+	db.configs[checkpointConfKeyPrefix+"mock"] = "6"
+
+	r := newTestReprocessor(db, processor, WithBlockFrom(3), WithReset(true))
+	assert.NoError(t, r.run(context.Background()))
+
+	// checkpoint ignored
+	assert.Equal(t, uint64(3), *processor.resetFrom)
+	assert.Equal(t, blockRange(3, 10), processor.processed())
+	assert.Equal(t, "11", db.configs[r.checkpointConfKey()])
+}
+
+func TestReprocessorFailure(t *testing.T) {
+	db, processor := newMockStore(20), &mockProcessor{failBn: 8}
+
+	r := newTestReprocessor(db, processor, WithBlockFrom(1))
+	assert.Error(t, r.run(context.Background()))
+
+	// checkpoint never advanced beyond the failed batch [7, 9]
+	checkpoint, ok, err := r.loadCheckpoint()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.LessOrEqual(t, checkpoint, uint64(7))
+	assert.NotContains(t, processor.processed(), uint64(8))
+}
+
+func TestRegisterProcessor(t *testing.T) {
+	defer func(old map[string]ProcessorFactory) { factories = old }(factories)
+	factories = make(map[string]ProcessorFactory)
+
+	RegisterProcessor("mock", func(db Store) (Processor, error) {
+		return &mockProcessor{}, nil
+	})
+
+	assert.Panics(t, func() {
+		RegisterProcessor("mock", func(db Store) (Processor, error) { return nil, nil })
+	})
+
+	assert.Equal(t, []string{"mock"}, Processors())
+
+	processor, err := NewProcessor("mock", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "mock", processor.Name())
+
+	_, err = NewProcessor("unknown", nil)
+	assert.Error(t, err)
+}
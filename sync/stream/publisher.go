@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	BrokerKafka = "kafka"
+	BrokerNats  = "nats"
+)
+
+// Message message to publish to the topic of message broker.
+type Message struct {
+	// key to partition messages, messages of the same key will be consumed in order
+	Key   string
+	Value []byte
+}
+
+// Publisher publishes messages to message broker in order.
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// KafkaConfig Kafka broker configurations
+type KafkaConfig struct {
+	// Kafka brokers to discover the topic partitions
+	Brokers []string
+}
+
+// KafkaPublisher publishes messages to Kafka topic.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(conf KafkaConfig, topic string) (*KafkaPublisher, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, errors.New("kafka brokers not configured")
+	}
+
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:  conf.Brokers,
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+	})
+
+	return &KafkaPublisher{writer: writer}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msgs []Message) error {
+	kmsgs := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		kmsgs = append(kmsgs, kafka.Message{Key: []byte(msg.Key), Value: msg.Value})
+	}
+
+	return p.writer.WriteMessages(ctx, kmsgs...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// NatsConfig NATS broker configurations
+type NatsConfig struct {
+	// NATS server URLs separated by comma
+	Url string `default:"nats://127.0.0.1:4222"`
+}
+
+// NatsPublisher publishes messages to NATS subject, where message key is ignored since messages
+// are delivered in order as published.
+type NatsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNatsPublisher(conf NatsConfig, subject string) (*NatsPublisher, error) {
+	conn, err := nats.Connect(conf.Url, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to nats server")
+	}
+
+	return &NatsPublisher{conn: conn, subject: subject}, nil
+}
+
+func (p *NatsPublisher) Publish(ctx context.Context, msgs []Message) error {
+	for _, msg := range msgs {
+		if err := p.conn.Publish(p.subject, msg.Value); err != nil {
+			return err
+		}
+	}
+
+	// wait for the server to process all messages
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	return p.conn.FlushTimeout(timeout)
+}
+
+func (p *NatsPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/rpc/cfxbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockStore struct {
+	events []*mysql.StreamEvent
+}
+
+func (s *mockStore) AddStreamEvents(dbTx *gorm.DB, events []*mysql.StreamEvent) error {
+	for _, e := range events {
+		e.ID = uint64(len(s.events) + 1)
+		s.events = append(s.events, e)
+	}
+
+	return nil
+}
+
+func (s *mockStore) LoadPendingStreamEvents(limit int) ([]*mysql.StreamEvent, error) {
+	if len(s.events) > limit {
+		return s.events[:limit], nil
+	}
+
+	return s.events, nil
+}
+
+func (s *mockStore) DeleteStreamEvents(maxID uint64) error {
+	for len(s.events) > 0 && s.events[0].ID <= maxID {
+		s.events = s.events[1:]
+	}
+
+	return nil
+}
+
+type mockPublisher struct {
+	failed bool
+	msgs   []Message
+}
+
+func (p *mockPublisher) Publish(ctx context.Context, msgs []Message) error {
+	if p.failed {
+		return errors.New("broker unavailable")
+	}
+
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *mockPublisher) Close() error { return nil }
+
+func newEpochData(bn int64) *store.EpochData {
+	ethBlock := &types.Block{
+		Number:       big.NewInt(bn),
+		Hash:         common.BigToHash(big.NewInt(bn)),
+		Difficulty:   big.NewInt(0),
+		Transactions: *types.NewTxOrHashListByTxs([]types.TransactionDetail{}),
+	}
+
+	return &store.EpochData{
+		Number:   uint64(bn),
+		Blocks:   []*cfxtypes.Block{cfxbridge.ConvertBlock(ethBlock, 71)},
+		Receipts: map[cfxtypes.Hash]*cfxtypes.TransactionReceipt{},
+	}
+}
+
+func TestStreamerPublishInOrder(t *testing.T) {
+	ms, mp := &mockStore{}, &mockPublisher{}
+	streamer := NewStreamer(Config{BatchSize: 2}, ms, mp)
+
+	assert.NoError(t, streamer.OnEpochDataPushed(nil, []*store.EpochData{newEpochData(100), newEpochData(101)}))
+	assert.NoError(t, streamer.OnEpochDataPopped(nil, 101, 101))
+	assert.NoError(t, streamer.OnEpochDataPushed(nil, []*store.EpochData{newEpochData(101)}))
+
+	// kept as outbox if failed to publish
+	mp.failed = true
+	_, err := streamer.publishOnce(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, ms.events, 4)
+
+	mp.failed = false
+	for {
+		n, err := streamer.publishOnce(context.Background())
+		assert.NoError(t, err)
+
+		if n == 0 {
+			break
+		}
+	}
+
+	assert.Empty(t, ms.events)
+	assert.Len(t, mp.msgs, 4)
+
+	var kinds []string
+	var blocks []uint64
+
+	for _, msg := range mp.msgs {
+		var event Event
+		assert.NoError(t, json.Unmarshal(msg.Value, &event))
+
+		kinds = append(kinds, event.Type)
+		blocks = append(blocks, event.FromBlock)
+	}
+
+	assert.Equal(t, []string{"block", "block", "revoke", "block"}, kinds)
+	assert.Equal(t, []uint64{100, 101, 101, 101}, blocks)
+}
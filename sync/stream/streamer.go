@@ -0,0 +1,237 @@
+// Package stream publishes the evm space chain data synced into store to message broker (Kafka or
+// NATS), so that downstream indexers could consume chain data without polling RPC.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// key of all messages so that they are published to the same partition in order
+const messageKey = "eth"
+
+var _ mysql.EpochDataObserver = (*Streamer)(nil)
+
+// Config chain data streaming configurations
+type Config struct {
+	// whether to publish the synced chain data to message broker
+	Enabled bool
+	// message broker, `kafka` or `nats`
+	Broker string `default:"kafka"`
+	// Kafka topic or NATS subject to publish events
+	Topic string `default:"confura.eth.chain"`
+	// interval to poll the pending events from store
+	PollInterval time.Duration `default:"1s"`
+	// max number of pending events to publish each time
+	BatchSize int `default:"100"`
+	// timeout to publish a batch of events
+	Timeout time.Duration `default:"10s"`
+
+	Kafka KafkaConfig
+	Nats  NatsConfig
+}
+
+// Store persists the events as outbox.
+type Store interface {
+	AddStreamEvents(dbTx *gorm.DB, events []*mysql.StreamEvent) error
+	LoadPendingStreamEvents(limit int) ([]*mysql.StreamEvent, error)
+	DeleteStreamEvents(maxID uint64) error
+}
+
+// Event chain data event published to message broker.
+type Event struct {
+	// event type, `block` for the newly synced block along with its receipts (including event
+	// logs), or `revoke` for the reverted blocks due to chain reorg, which should be discarded
+	// by consumer.
+	Type      string           `json:"type"`
+	FromBlock uint64           `json:"fromBlock"`
+	ToBlock   uint64           `json:"toBlock"`
+	Block     *types.Block     `json:"block,omitempty"`
+	Receipts  []*types.Receipt `json:"receipts,omitempty"`
+}
+
+// Streamer publishes the evm space blocks, receipts and event logs to message broker as they are
+// synced into store.
+//
+// Events are persisted as outbox within the same db transaction of synced block data, and then
+// published in order, so that they will be published at least once. Besides, a revocation event
+// will be published if blocks are reverted due to chain reorg.
+type Streamer struct {
+	conf      Config
+	store     Store
+	publisher Publisher
+}
+
+// MustNewStreamerFromViper creates chain data streamer from viper settings, or returns false if
+// not enabled.
+func MustNewStreamerFromViper(store Store) (*Streamer, bool) {
+	var conf Config
+	viper.MustUnmarshalKey("sync.stream", &conf)
+
+	if !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	var publisher Publisher
+	var err error
+
+	switch conf.Broker {
+	case BrokerKafka:
+		publisher, err = NewKafkaPublisher(conf.Kafka, conf.Topic)
+	case BrokerNats:
+		publisher, err = NewNatsPublisher(conf.Nats, conf.Topic)
+	default:
+		err = errors.Errorf("invalid broker %v", conf.Broker)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create chain data stream publisher")
+	}
+
+	return NewStreamer(conf, store, publisher), true
+}
+
+func NewStreamer(conf Config, store Store, publisher Publisher) *Streamer {
+	return &Streamer{conf: conf, store: store, publisher: publisher}
+}
+
+// OnEpochDataPushed implements the `mysql.EpochDataObserver` interface to enqueue block events.
+func (s *Streamer) OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	var events []*mysql.StreamEvent
+
+	for _, data := range dataSlice {
+		for i, block := range data.Blocks {
+			var blockExt *store.BlockExtra
+			if i < len(data.BlockExts) {
+				blockExt = data.BlockExts[i]
+			}
+
+			event := Event{Block: ethbridge.ConvertBlock(block, blockExt)}
+
+			for j := range block.Transactions {
+				receipt := data.Receipts[block.Transactions[j].Hash]
+				if receipt == nil { // skip transactions that unexecuted in block
+					continue
+				}
+
+				var rcptExt *store.ReceiptExtra
+				if len(data.ReceiptExts) > 0 {
+					rcptExt = data.ReceiptExts[block.Transactions[j].Hash]
+				}
+
+				event.Receipts = append(event.Receipts, ethbridge.ConvertReceipt(receipt, rcptExt))
+			}
+
+			bn := event.Block.Number.Uint64()
+
+			se, err := newStreamEvent(mysql.StreamEventBlock, bn, bn, &event)
+			if err != nil {
+				return err
+			}
+
+			events = append(events, se)
+		}
+	}
+
+	return s.store.AddStreamEvents(dbTx, events)
+}
+
+// OnEpochDataPopped implements the `mysql.EpochDataObserver` interface to enqueue revocation event.
+func (s *Streamer) OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	event, err := newStreamEvent(mysql.StreamEventRevoke, epochFrom, epochTo, &Event{})
+	if err != nil {
+		return err
+	}
+
+	return s.store.AddStreamEvents(dbTx, []*mysql.StreamEvent{event})
+}
+
+func newStreamEvent(kind string, fromBlock, toBlock uint64, event *Event) (*mysql.StreamEvent, error) {
+	event.Type = kind
+	event.FromBlock = fromBlock
+	event.ToBlock = toBlock
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal stream event")
+	}
+
+	return &mysql.StreamEvent{
+		Kind:    kind,
+		BnMin:   fromBlock,
+		BnMax:   toBlock,
+		Payload: payload,
+	}, nil
+}
+
+// Run publishes the pending events to message broker until context done.
+func (s *Streamer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	defer s.publisher.Close()
+
+	logrus.WithField("broker", s.conf.Broker).Info("Chain data streamer started")
+
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Chain data streamer shutdown ok")
+			return
+		case <-ticker.C:
+			// publish continuously until no more pending events
+			for {
+				n, err := s.publishOnce(ctx)
+				if err != nil {
+					logrus.WithError(err).Error("Chain data streamer failed to publish events")
+				}
+
+				if err != nil || n < s.conf.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// publishOnce publishes a batch of pending events in order, and returns the number of published
+// events.
+func (s *Streamer) publishOnce(ctx context.Context) (int, error) {
+	events, err := s.store.LoadPendingStreamEvents(s.conf.BatchSize)
+	if err != nil || len(events) == 0 {
+		return 0, errors.WithMessage(err, "failed to load pending events")
+	}
+
+	msgs := make([]Message, 0, len(events))
+	for _, event := range events {
+		msgs = append(msgs, Message{Key: messageKey, Value: event.Payload})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.conf.Timeout)
+	defer cancel()
+
+	if err := s.publisher.Publish(ctx, msgs); err != nil {
+		return 0, errors.WithMessage(err, "failed to publish events")
+	}
+
+	if err := s.store.DeleteStreamEvents(events[len(events)-1].ID); err != nil {
+		return 0, errors.WithMessage(err, "failed to delete published events")
+	}
+
+	return len(events), nil
+}
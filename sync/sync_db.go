@@ -9,9 +9,11 @@ import (
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/sync/catchup"
+	"github.com/Conflux-Chain/confura/sync/progress"
 	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
@@ -24,17 +26,18 @@ const (
 	syncPivotInfoWinCapacity = 50
 )
 
-type pivotSwitchEvent struct {
-	revertFrom uint64
-	revertTo   uint64
-}
-
 // db sync configuration
 type syncConfig struct {
 	FromEpoch uint64 `default:"0"`
+	// last epoch to sync for range-limited sync, 0 if unbounded
+	ToEpoch   uint64
 	MaxEpochs uint64 `default:"10"`
 	UseBatch  bool   `default:"false"`
 	Sub       syncSubConfig
+	Recovery  recoveryConfig
+	Reorg     reorgConfig
+	// confirmation depth policy of the store boundary
+	Confirmation confirmationConfig
 }
 
 type syncSubConfig struct {
@@ -57,40 +60,62 @@ type DatabaseSyncer struct {
 	syncIntervalNormal time.Duration
 	// interval to sync data in catching up mode
 	syncIntervalCatchUp time.Duration
-	// last received epoch number from pubsub for pivot chain switch detection
-	lastSubEpochNo uint64
+	// head tracker for pivot chain switch detection from pubsub
+	headTracker *headTracker
+	// confirmation depth policy to determine the max epoch to sync
+	confirmation *confirmationPolicy
 	// channel to receive pivot chain switch events
-	pivotSwitchEventCh chan *pivotSwitchEvent
+	pivotSwitchEventCh chan *pivotSwitch
 	// checkpoint channel received to check sync data
 	checkPointCh chan bool
+	// channel to receive the epoch to re-sync from, eg., to repair mismatched data
+	resyncCh chan uint64
 	// window to cache epoch pivot info
 	epochPivotWin *epochPivotWindow
 	// sync is ready only after fast catch-up is completed
 	catchupCompleted uint32
+	// whether the latest sync round caught up to the store boundary epoch
+	headCaughtUp uint32
+	// progress to report sync throughput, backlog and catch-up ETA
+	progress *progress.Reporter
+	// store name to scope sync metrics, eg., "db" or "testnet/db" for extra network
+	storeName string
+	// options to create sdk clients of fast catch-up workers
+	catchupClientOptions []rpc.ClientOption
 }
 
-// MustNewDatabaseSyncer creates an instance of DatabaseSyncer to sync blockchain data.
-func MustNewDatabaseSyncer(cfx sdk.ClientOperator, db *mysql.MysqlStore) *DatabaseSyncer {
+// MustNewDatabaseSyncer creates an instance of DatabaseSyncer to sync blockchain data, in which the
+// client options are applied to the sdk clients of fast catch-up workers, eg., shared rate limiter.
+func MustNewDatabaseSyncer(
+	cfx sdk.ClientOperator, db *mysql.MysqlStore, catchupClientOptions ...rpc.ClientOption,
+) *DatabaseSyncer {
 	var conf syncConfig
 	viperutil.MustUnmarshalKey("sync", &conf)
 
-	syncer := &DatabaseSyncer{
-		conf:                &conf,
-		cfx:                 cfx,
-		db:                  db,
-		epochFrom:           0,
-		maxSyncEpochs:       conf.MaxEpochs,
-		syncIntervalNormal:  time.Second,
-		syncIntervalCatchUp: time.Millisecond,
-		lastSubEpochNo:      citypes.EpochNumberNil,
-		pivotSwitchEventCh:  make(chan *pivotSwitchEvent, conf.Sub.Buffer),
-		checkPointCh:        make(chan bool, 2),
-		epochPivotWin:       newEpochPivotWindow(syncPivotInfoWinCapacity),
-	}
+	tracker := newHeadTracker("cfx", "db")
 
-	// Ensure epoch data validity in database
-	if err := ensureStoreEpochDataOk(cfx, db); err != nil {
-		logrus.WithError(err).Fatal("Db sync failed to ensure epoch data validity in db")
+	syncer := &DatabaseSyncer{
+		conf:                 &conf,
+		cfx:                  cfx,
+		db:                   db,
+		epochFrom:            0,
+		maxSyncEpochs:        conf.MaxEpochs,
+		syncIntervalNormal:   time.Second,
+		syncIntervalCatchUp:  time.Millisecond,
+		headTracker:          tracker,
+		confirmation:         mustNewConfirmationPolicy(conf.Confirmation, tracker),
+		pivotSwitchEventCh:   make(chan *pivotSwitch, conf.Sub.Buffer),
+		checkPointCh:         make(chan bool, 2),
+		resyncCh:             make(chan uint64, 1),
+		epochPivotWin:        newEpochPivotWindow(syncPivotInfoWinCapacity),
+		progress:             progress.NewReporter("cfx", "db"),
+		storeName:            "db",
+		catchupClientOptions: catchupClientOptions,
+	}
+
+	// Verify the latest epoch data in database to recover from crash
+	if err := recoverFromCheckpoint(db, conf.Recovery, cfxPivotHashFetcher(cfx)); err != nil {
+		logrus.WithError(err).Fatal("Db sync failed to recover from sync checkpoint")
 	}
 
 	// Load last sync epoch information
@@ -99,6 +124,14 @@ func MustNewDatabaseSyncer(cfx sdk.ClientOperator, db *mysql.MysqlStore) *Databa
 	return syncer
 }
 
+// ScopeMetrics scopes sync metrics by the extra network, so as not to mix up with the default network.
+// Note, this method must be called before sync started.
+func (syncer *DatabaseSyncer) ScopeMetrics(network string) {
+	syncer.storeName = network + "/db"
+	syncer.headTracker.storeName = syncer.storeName
+	syncer.progress = progress.NewReporter("cfx", syncer.storeName)
+}
+
 // Sync starts to sync epoch blockchain data.
 func (syncer *DatabaseSyncer) Sync(ctx context.Context, wg *sync.WaitGroup) {
 	logrus.WithField("epochFrom", syncer.epochFrom).Info("DB sync starting to sync epoch data")
@@ -152,7 +185,10 @@ func (syncer *DatabaseSyncer) Sync(ctx context.Context, wg *sync.WaitGroup) {
 // (maximum between the latest finalized and checkpoint epoch)
 func (syncer *DatabaseSyncer) fastCatchup(ctx context.Context) {
 	catchUpSyncer := catchup.MustNewSyncer(
-		syncer.cfx, syncer.db, catchup.WithEpochFrom(syncer.epochFrom),
+		syncer.cfx, syncer.db,
+		catchup.WithEpochFrom(syncer.epochFrom),
+		catchup.WithMaxEpochTo(syncer.conf.ToEpoch),
+		catchup.WithClientOptions(syncer.catchupClientOptions...),
 	)
 	defer catchUpSyncer.Close()
 
@@ -188,23 +224,34 @@ func (syncer *DatabaseSyncer) loadLastSyncEpoch() (loaded bool, err error) {
 	return ok, nil
 }
 
-// Sync data once and return true if catch up to the latest confirmed epoch, otherwise false.
+// Sync data once and return true if catch up to the store boundary epoch, otherwise false.
 func (syncer *DatabaseSyncer) syncOnce() (bool, error) {
 	// Drain pivot switch reorg event channel to handle pivot chain reorg
 	if err := syncer.drainPivotReorgEvents(); err != nil {
 		return false, err
 	}
 
-	// Fetch latest confirmed epoch from blockchain
-	epoch, err := syncer.cfx.GetEpochNumber(types.EpochLatestConfirmed)
+	// Revert the epoch data to re-sync if requested
+	if err := syncer.drainResyncRequests(); err != nil {
+		return false, err
+	}
+
+	// Determine the max epoch to sync by confirmation depth policy
+	maxEpochTo, ok, err := syncer.confirmation.boundary(syncer.cfx)
 	if err != nil {
-		return false, errors.WithMessage(
-			err, "failed to query the latest confirmed epoch number",
-		)
+		return false, errors.WithMessage(err, "failed to determine store boundary epoch")
 	}
 
-	maxEpochTo := epoch.ToInt().Uint64()
-	if syncer.epochFrom > maxEpochTo { // cached up to the latest confirmed epoch?
+	// range-limited sync stops at the configured end epoch
+	if toEpoch := syncer.conf.ToEpoch; ok && toEpoch > 0 {
+		maxEpochTo = util.MinUint64(maxEpochTo, toEpoch)
+	}
+
+	if ok {
+		syncer.progress.UpdateBacklog(syncer.epochFrom, maxEpochTo)
+	}
+
+	if !ok || syncer.epochFrom > maxEpochTo { // cached up to the store boundary epoch?
 		logrus.WithFields(logrus.Fields{
 			"epochRange": citypes.RangeUint64{From: syncer.epochFrom, To: maxEpochTo},
 		}).Debug("Db syncer skipped due to already catch-up")
@@ -251,15 +298,15 @@ func (syncer *DatabaseSyncer) syncOnce() (bool, error) {
 				eplogger.WithFields(logrus.Fields{
 					"latestStoreEpoch": latestStoreEpochNo,
 					"latestPivotHash":  latestPivotHash,
-				}).Warn("Db syncer popping latest epoch from db store due to parent hash mismatched")
+				}).Warn("Db syncer reverting reorged epochs from db store due to parent hash mismatched")
 
-				if err := syncer.pivotSwitchRevert(latestStoreEpochNo); err != nil {
+				if err := syncer.reorgRevert(latestStoreEpochNo); err != nil {
 					eplogger.WithError(err).Error(
-						"Db syncer failed to pop latest epoch from db store due to parent hash mismatched",
+						"Db syncer failed to revert reorged epochs from db store due to parent hash mismatched",
 					)
 
 					return false, errors.WithMessage(
-						err, "failed to pop latest epoch from db store due to parent hash mismatched",
+						err, "failed to revert reorged epochs from db store due to parent hash mismatched",
 					)
 				}
 
@@ -283,18 +330,21 @@ func (syncer *DatabaseSyncer) syncOnce() (bool, error) {
 		eplogger.Debug("Db syncer succeeded to query epoch data")
 	}
 
-	metrics.Registry.Sync.SyncOnceSize("cfx", "db").Update(int64(len(epochDataSlice)))
+	metrics.Registry.Sync.SyncOnceSize("cfx", syncer.storeName).Update(int64(len(epochDataSlice)))
 
 	if len(epochDataSlice) == 0 { // empty epoch data query
 		logger.Debug("Db syncer skipped due to empty sync range")
 		return false, nil
 	}
 
+	start := time.Now()
 	if err = syncer.db.Pushn(epochDataSlice); err != nil {
 		logger.WithError(err).Error("Db syncer failed to save epoch data to db")
 		return false, errors.WithMessage(err, "failed to save epoch data to db")
 	}
 
+	syncer.progress.Observe(epochDataSlice, time.Since(start))
+
 	syncer.epochFrom += uint64(len(epochDataSlice))
 
 	for _, epdata := range epochDataSlice { // cache epoch pivot info for late use
@@ -319,8 +369,8 @@ func (syncer *DatabaseSyncer) syncOnce() (bool, error) {
 
 func (syncer *DatabaseSyncer) doCheckPoint() error {
 	logger := logrus.WithFields(logrus.Fields{
-		"epochFrom":      syncer.epochFrom,
-		"lastSubEpochNo": atomic.LoadUint64(&syncer.lastSubEpochNo),
+		"epochFrom":   syncer.epochFrom,
+		"trackedHead": syncer.headTracker.currentHead(),
 	})
 
 	logger.Info("Db syncer ensuring epoch data validity on pubsub checkpoint")
@@ -341,6 +391,11 @@ func (syncer *DatabaseSyncer) doCheckPoint() error {
 		return errors.WithMessage(err, "failed to reload last sync point")
 	}
 
+	// all epoch data in database have been verified
+	if err := syncer.db.MarkSyncVerified(syncer.latestStoreEpoch()); err != nil {
+		logger.WithError(err).Info("Db syncer failed to mark sync verified on checkpoint")
+	}
+
 	syncer.epochPivotWin.popn(syncer.epochFrom)
 
 	return nil
@@ -351,7 +406,8 @@ func (syncer *DatabaseSyncer) doTicker(ticker *time.Ticker) error {
 
 	start := time.Now()
 	complete, err := syncer.syncOnce()
-	metrics.Registry.Sync.SyncOnceQps("cfx", "db", err).UpdateSince(start)
+	metrics.Registry.Sync.SyncOnceQps("cfx", syncer.storeName, err).UpdateSince(start)
+	syncer.setHeadCaughtUp(err == nil && complete)
 
 	if err != nil {
 		ticker.Reset(syncer.syncIntervalNormal)
@@ -365,6 +421,20 @@ func (syncer *DatabaseSyncer) doTicker(ticker *time.Ticker) error {
 	return nil
 }
 
+func (syncer *DatabaseSyncer) setHeadCaughtUp(caughtUp bool) {
+	if caughtUp {
+		atomic.StoreUint32(&syncer.headCaughtUp, 1)
+	} else {
+		atomic.StoreUint32(&syncer.headCaughtUp, 0)
+	}
+}
+
+// CaughtUp implements the HeadSyncer interface to check whether head sync caught up to the store
+// boundary epoch.
+func (syncer *DatabaseSyncer) CaughtUp() bool {
+	return atomic.LoadUint32(&syncer.headCaughtUp) == 1
+}
+
 // implement the EpochSubscriber interface.
 func (syncer *DatabaseSyncer) onEpochReceived(epoch types.WebsocketEpochResponse) {
 	if atomic.LoadUint32(&syncer.catchupCompleted) != 1 { // not ready for sync yet
@@ -391,8 +461,7 @@ func (syncer *DatabaseSyncer) onEpochSubStart() {
 
 	logrus.Debug("DB sync onEpochSubStart event received")
 
-	// reset lastSubEpochNo
-	atomic.StoreUint64(&(syncer.lastSubEpochNo), citypes.EpochNumberNil)
+	syncer.headTracker.reset()
 	syncer.triggerCheckpoint()
 }
 
@@ -411,18 +480,19 @@ func (syncer *DatabaseSyncer) drainPivotReorgEvents() error {
 	for {
 		select {
 		case psevent := <-syncer.pivotSwitchEventCh:
-			if psevent.revertTo >= syncer.epochFrom {
+			if psevent.fork >= syncer.epochFrom {
 				break
 			}
 
 			logrus.WithFields(logrus.Fields{
-				"revertFromEpoch": psevent.revertFrom,
-				"revertToEpoch":   psevent.revertTo,
-				"syncFromEpoch":   syncer.epochFrom,
-			}).Warn("Db syncer detected pivot chain reorg for the latest confirmed epoch from pubsub")
-
-			// pivot switch reorg for the latest confirmed epoch
-			if err := syncer.pivotSwitchRevert(psevent.revertTo); err != nil {
+				"forkEpoch":     psevent.fork,
+				"oldHeadEpoch":  psevent.oldHead,
+				"depth":         psevent.depth(),
+				"syncFromEpoch": syncer.epochFrom,
+			}).Warn("Db syncer detected pivot chain reorg for the synced epoch from pubsub")
+
+			// pivot switch reorg for the synced epoch
+			if err := syncer.pivotSwitchRevert(psevent.fork); err != nil {
 				return errors.WithMessage(err, "failed to revert epoch(s) from pivot switch reorg channel")
 			}
 		default:
@@ -431,6 +501,33 @@ func (syncer *DatabaseSyncer) drainPivotReorgEvents() error {
 	}
 }
 
+// Resync implements the Resyncer interface to revert the synced epoch data since the specified epoch,
+// which will be re-synced later. Note, the request is dropped if another one is pending.
+func (syncer *DatabaseSyncer) Resync(fromEpoch uint64) {
+	select {
+	case syncer.resyncCh <- fromEpoch:
+	default:
+		logrus.WithField("fromEpoch", fromEpoch).Info("Db syncer dropped resync request due to pending one")
+	}
+}
+
+func (syncer *DatabaseSyncer) drainResyncRequests() error {
+	select {
+	case fromEpoch := <-syncer.resyncCh:
+		logrus.WithFields(logrus.Fields{
+			"fromEpoch":     fromEpoch,
+			"syncFromEpoch": syncer.epochFrom,
+		}).Warn("Db syncer reverting epoch data to re-sync")
+
+		if err := syncer.pivotSwitchRevert(fromEpoch); err != nil {
+			return errors.WithMessage(err, "failed to revert epoch(s) to re-sync")
+		}
+	default:
+	}
+
+	return nil
+}
+
 func (syncer *DatabaseSyncer) pivotSwitchRevert(revertTo uint64) error {
 	if revertTo == 0 {
 		return errors.New("genesis epoch must not be reverted")
@@ -467,6 +564,26 @@ func (syncer *DatabaseSyncer) pivotSwitchRevert(revertTo uint64) error {
 	return nil
 }
 
+// reorgRevert detects the depth of reorg from the latest epoch in store, and reverts all the
+// affected epoch data so that they will be re-synced.
+func (syncer *DatabaseSyncer) reorgRevert(latestEpoch uint64) error {
+	reorg, err := detectReorg(syncer.db, syncer.conf.Reorg, cfxPivotHashFetcher(syncer.cfx), latestEpoch)
+	if err != nil {
+		return errors.WithMessage(err, "failed to detect reorg")
+	}
+
+	if err := revertReorg(syncer.db, "cfx", reorg); err != nil {
+		return errors.WithMessage(err, "failed to revert reorged epoch data")
+	}
+
+	// remove pivot data of reverted epoch from cache window
+	syncer.epochPivotWin.popn(reorg.reverted.From)
+	// re-sync from the first reverted epoch
+	syncer.epochFrom = reorg.reverted.From
+
+	return nil
+}
+
 func (syncer *DatabaseSyncer) triggerCheckpoint() {
 	if len(syncer.checkPointCh) == 0 {
 		syncer.checkPointCh <- true
@@ -478,36 +595,18 @@ func (syncer *DatabaseSyncer) triggerCheckpoint() {
 func (syncer *DatabaseSyncer) detectPivotSwitchFromPubsub(epoch *types.WebsocketEpochResponse) error {
 	newEpoch := epoch.EpochNumber.ToInt().Uint64()
 
-	addrPtr := &(syncer.lastSubEpochNo)
-	lastSubEpochNo := atomic.LoadUint64(addrPtr)
-
 	var pivotHash types.Hash
 	if len(epoch.EpochHashesOrdered) > 0 {
 		pivotHash = epoch.EpochHashesOrdered[len(epoch.EpochHashesOrdered)-1]
 	}
 
-	logger := logrus.WithFields(logrus.Fields{
-		"newEpoch": newEpoch, "lastSubEpochNo": lastSubEpochNo, "pivotHash": pivotHash,
-	})
-
-	switch {
-	case lastSubEpochNo == citypes.EpochNumberNil: // initial state
-		logger.Debug("Db syncer initially set last sub epoch number for pivot switch detection")
-
-		atomic.StoreUint64(addrPtr, newEpoch)
-	case lastSubEpochNo >= newEpoch: // pivot switch
-		logger.Info("Db syncer detected pubsub new epoch pivot switched")
-
-		atomic.StoreUint64(addrPtr, newEpoch)
-		syncer.pivotSwitchEventCh <- &pivotSwitchEvent{
-			revertFrom: lastSubEpochNo, revertTo: newEpoch,
-		}
-	case lastSubEpochNo+1 == newEpoch: // continuous
-		logger.Debug("Db syncer validated continuous new epoch from pubsub")
+	ps, err := syncer.headTracker.track(newEpoch, pivotHash)
+	if err != nil {
+		return err
+	}
 
-		atomic.StoreUint64(addrPtr, newEpoch)
-	default: // bad incontinuous epoch
-		return errors.Errorf("bad incontinuous epoch, expect %v got %v", lastSubEpochNo+1, newEpoch)
+	if ps != nil {
+		syncer.pivotSwitchEventCh <- ps
 	}
 
 	return nil
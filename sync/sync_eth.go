@@ -3,11 +3,13 @@ package sync
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/confura/rpc/cfxbridge"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/progress"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -25,8 +27,12 @@ const (
 
 type syncEthConfig struct {
 	FromBlock uint64 `default:"1"`
+	// last block to sync for range-limited sync, 0 if unbounded
+	ToBlock   uint64
 	MaxBlocks uint64 `default:"10"`
 	UseBatch  bool   `default:"false"`
+	Recovery  recoveryConfig
+	Reorg     reorgConfig
 }
 
 // EthSyncer is used to synchronize evm space blockchain data into db store.
@@ -48,6 +54,14 @@ type EthSyncer struct {
 	syncIntervalCatchUp time.Duration
 	// window to cache block info
 	epochPivotWin *epochPivotWindow
+	// channel to receive the block to re-sync from, eg., to repair mismatched data
+	resyncCh chan uint64
+	// whether the latest sync round caught up to the most recent block
+	headCaughtUp uint32
+	// progress to report sync throughput, backlog and catch-up ETA
+	progress *progress.Reporter
+	// store name to scope sync metrics, eg., "db" or "testnet/db" for extra network
+	storeName string
 }
 
 // MustNewEthSyncer creates an instance of EthSyncer to sync Conflux EVM space chaindata.
@@ -69,6 +83,14 @@ func MustNewEthSyncer(ethC *web3go.Client, db *mysql.MysqlStore) *EthSyncer {
 		syncIntervalNormal:  time.Second,
 		syncIntervalCatchUp: time.Millisecond,
 		epochPivotWin:       newEpochPivotWindow(syncPivotInfoWinCapacity),
+		resyncCh:            make(chan uint64, 1),
+		progress:            progress.NewReporter("eth", "db"),
+		storeName:           "db",
+	}
+
+	// Verify the latest block data in ethdb to recover from crash
+	if err := recoverFromCheckpoint(db, ethConf.Recovery, ethPivotHashFetcher(ethC)); err != nil {
+		logrus.WithError(err).Fatal("ETH syncer failed to recover from sync checkpoint")
 	}
 
 	// Load last sync block information
@@ -77,6 +99,13 @@ func MustNewEthSyncer(ethC *web3go.Client, db *mysql.MysqlStore) *EthSyncer {
 	return syncer
 }
 
+// ScopeMetrics scopes sync metrics by the extra network, so as not to mix up with the default network.
+// Note, this method must be called before sync started.
+func (syncer *EthSyncer) ScopeMetrics(network string) {
+	syncer.storeName = network + "/db"
+	syncer.progress = progress.NewReporter("eth", syncer.storeName)
+}
+
 // Sync starts to sync Conflux EVM space blockchain data.
 func (syncer *EthSyncer) Sync(ctx context.Context, wg *sync.WaitGroup) {
 	logrus.WithField("fromBlock", syncer.fromBlock).Info("ETH sync starting to sync block data")
@@ -107,7 +136,8 @@ func (syncer *EthSyncer) doTicker(ticker *time.Ticker) error {
 
 	start := time.Now()
 	complete, err := syncer.syncOnce()
-	metrics.Registry.Sync.SyncOnceQps("eth", "db", err).UpdateSince(start)
+	metrics.Registry.Sync.SyncOnceQps("eth", syncer.storeName, err).UpdateSince(start)
+	syncer.setHeadCaughtUp(err == nil && complete)
 
 	if err != nil {
 		ticker.Reset(syncer.syncIntervalNormal)
@@ -121,8 +151,27 @@ func (syncer *EthSyncer) doTicker(ticker *time.Ticker) error {
 	return nil
 }
 
+func (syncer *EthSyncer) setHeadCaughtUp(caughtUp bool) {
+	if caughtUp {
+		atomic.StoreUint32(&syncer.headCaughtUp, 1)
+	} else {
+		atomic.StoreUint32(&syncer.headCaughtUp, 0)
+	}
+}
+
+// CaughtUp implements the HeadSyncer interface to check whether head sync caught up to the most
+// recent block.
+func (syncer *EthSyncer) CaughtUp() bool {
+	return atomic.LoadUint32(&syncer.headCaughtUp) == 1
+}
+
 // Sync data once and return true if catch up to the most recent block, otherwise false.
 func (syncer *EthSyncer) syncOnce() (bool, error) {
+	// Revert the block data to re-sync if requested
+	if err := syncer.drainResyncRequests(); err != nil {
+		return false, err
+	}
+
 	recentBlockNumber, err := syncer.w3c.Eth.BlockNumber()
 	if err != nil {
 		return false, errors.WithMessage(err, "failed to query the latest block number")
@@ -133,6 +182,13 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 		recentBlockNo = recentBlockNo - skipBlocksAheadLatest
 	}
 
+	// range-limited sync stops at the configured end block
+	if syncer.conf.ToBlock > 0 {
+		recentBlockNo = util.MinUint64(recentBlockNo, syncer.conf.ToBlock)
+	}
+
+	syncer.progress.UpdateBacklog(syncer.fromBlock, recentBlockNo)
+
 	// catched up to the most recent block?
 	if syncer.fromBlock > recentBlockNo {
 		logrus.WithFields(logrus.Fields{
@@ -197,7 +253,7 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 				}
 
 				blogger.WithField("latestBlockHash", latestBlockHash).Info(
-					"ETH syncer reverted latest block from ethdb store due to parent hash mismatched",
+					"ETH syncer reverted reorged blocks from ethdb store due to parent hash mismatched",
 				)
 
 				return false, nil
@@ -221,7 +277,7 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 		blogger.Debug("ETH syncer succeeded to query epoch data")
 	}
 
-	metrics.Registry.Sync.SyncOnceSize("eth", "db").Update(int64(len(ethDataSlice)))
+	metrics.Registry.Sync.SyncOnceSize("eth", syncer.storeName).Update(int64(len(ethDataSlice)))
 
 	if len(ethDataSlice) == 0 { // empty eth data query
 		logger.Debug("ETH syncer skipped due to empty sync range")
@@ -235,11 +291,14 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 		epochDataSlice = append(epochDataSlice, epochData)
 	}
 
+	start := time.Now()
 	if err = syncer.db.Pushn(epochDataSlice); err != nil {
 		logger.WithError(err).Error("ETH syncer failed to save eth data to ethdb")
 		return false, errors.WithMessage(err, "failed to save eth data")
 	}
 
+	syncer.progress.Observe(epochDataSlice, time.Since(start))
+
 	for _, edata := range ethDataSlice { // cache eth block info for late use
 		cfxbh := cfxbridge.ConvertBlockHeader(edata.Block, syncer.chainId)
 		err := syncer.epochPivotWin.push(&cfxtypes.Block{BlockHeader: *cfxbh})
@@ -263,37 +322,63 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 	return false, nil
 }
 
-func (syncer *EthSyncer) reorgRevert(revertTo uint64) error {
-	if revertTo == 0 {
-		return errors.New("genesis block must not be reverted")
+// reorgRevert detects the depth of reorg from the latest block in store, and reverts
+// all the affected block data so that they will be re-synced.
+func (syncer *EthSyncer) reorgRevert(latestBlock uint64) error {
+	reorg, err := detectReorg(syncer.db, syncer.conf.Reorg, ethPivotHashFetcher(syncer.w3c), latestBlock)
+	if err != nil {
+		return errors.WithMessage(err, "failed to detect reorg")
 	}
 
-	logger := logrus.WithFields(logrus.Fields{
-		"revertTo": revertTo, "revertFrom": syncer.latestStoreBlock(),
-	})
+	if err := revertReorg(syncer.db, "eth", reorg); err != nil {
+		return errors.WithMessage(err, "failed to revert reorged block data")
+	}
+
+	// remove block hash of reverted block from cache window
+	syncer.epochPivotWin.popn(reorg.reverted.From)
+	// re-sync from the first reverted block
+	syncer.fromBlock = reorg.reverted.From
 
-	if revertTo >= syncer.fromBlock {
-		logger.Debug(
-			"ETH syncer skipped re-org revert due to not catched up yet",
-		)
+	return nil
+}
+
+// Resync implements the Resyncer interface to revert the synced block data since the specified block,
+// which will be re-synced later. Note, the request is dropped if another one is pending.
+func (syncer *EthSyncer) Resync(fromBlock uint64) {
+	select {
+	case syncer.resyncCh <- fromBlock:
+	default:
+		logrus.WithField("fromBlock", fromBlock).Info("ETH syncer dropped resync request due to pending one")
+	}
+}
+
+func (syncer *EthSyncer) drainResyncRequests() error {
+	var fromBlock uint64
+
+	select {
+	case fromBlock = <-syncer.resyncCh:
+	default:
+		return nil
+	}
+
+	if fromBlock == 0 || fromBlock >= syncer.fromBlock {
 		return nil
 	}
 
-	// remove block data from database due to chain re-org
-	if err := syncer.db.Popn(revertTo); err != nil {
-		logger.WithError(err).Error(
-			"ETH syncer failed to pop eth data from ethdb due to chain re-org",
-		)
+	logrus.WithFields(logrus.Fields{
+		"fromBlock":     fromBlock,
+		"syncFromBlock": syncer.fromBlock,
+	}).Warn("ETH syncer reverting block data to re-sync")
 
-		return errors.WithMessage(err, "failed to pop eth data from ethdb")
+	if err := syncer.db.Popn(fromBlock); err != nil {
+		return errors.WithMessage(err, "failed to revert block data to re-sync")
 	}
 
 	// remove block hash of reverted block from cache window
-	syncer.epochPivotWin.popn(revertTo)
-	// update syncer start block
-	syncer.fromBlock = revertTo
+	syncer.epochPivotWin.popn(fromBlock)
+	// re-sync from the first reverted block
+	syncer.fromBlock = fromBlock
 
-	logger.Info("ETH syncer reverted block data due to chain re-org")
 	return nil
 }
 
@@ -342,20 +427,25 @@ func (syncer *EthSyncer) getStoreLatestBlockHash() (string, error) {
 // convertToEpochData converts evm space block data to core space epoch data. This is used to bridge
 // eth block data with epoch data to reuse code logic eg., db store logic.
 func (syncer *EthSyncer) convertToEpochData(ethData *store.EthData) *store.EpochData {
+	return convertEthToEpochData(ethData, syncer.chainId)
+}
+
+// convertEthToEpochData converts evm space block data to epoch data, so as to reuse db store logic.
+func convertEthToEpochData(ethData *store.EthData, chainId uint32) *store.EpochData {
 	epochData := &store.EpochData{
 		Number:      ethData.Number,
 		Receipts:    make(map[cfxtypes.Hash]*cfxtypes.TransactionReceipt),
 		ReceiptExts: make(map[cfxtypes.Hash]*store.ReceiptExtra),
 	}
 
-	pivotBlock := cfxbridge.ConvertBlock(ethData.Block, syncer.chainId)
+	pivotBlock := cfxbridge.ConvertBlock(ethData.Block, chainId)
 	epochData.Blocks = []*cfxtypes.Block{pivotBlock}
 
 	blockExt := store.ExtractEthBlockExt(ethData.Block)
 	epochData.BlockExts = []*store.BlockExtra{blockExt}
 
 	for txh, rcpt := range ethData.Receipts {
-		txRcpt := cfxbridge.ConvertReceipt(rcpt, syncer.chainId)
+		txRcpt := cfxbridge.ConvertReceipt(rcpt, chainId)
 		txHash := cfxbridge.ConvertHash(txh)
 
 		epochData.Receipts[txHash] = txRcpt
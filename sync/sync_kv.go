@@ -3,11 +3,9 @@ package sync
 import (
 	"context"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/confura/store"
-	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -38,9 +36,8 @@ type KVCacheSyncer struct {
 	maxSyncEpochs uint64
 	// epoch sync window on which the sync polling depends
 	syncWindow *epochWindow
-	// last received epoch number from subscription, which is used for
-	// pubsub validation
-	lastSubEpochNo uint64
+	// head tracker for pubsub validation and pivot chain switch detection
+	headTracker *headTracker
 	// receive the epoch from pub/sub to detect pivot chain switch or
 	// to update epoch sync window
 	subEpochCh chan uint64
@@ -66,7 +63,7 @@ func MustNewKVCacheSyncer(cfx sdk.ClientOperator, cache store.CacheStore) *KVCac
 		syncIntervalCatchUp: time.Millisecond,
 		maxSyncEpochs:       conf.MaxEpochs,
 		syncWindow:          newEpochWindow(decayedEpochGapThreshold),
-		lastSubEpochNo:      citypes.EpochNumberNil,
+		headTracker:         newHeadTracker("cfx", "kv"),
 		subEpochCh:          make(chan uint64, conf.Sub.Buffer),
 		checkPointCh:        make(chan bool, 2),
 		epochPivotWin:       newEpochPivotWindow(syncPivotInfoWinCapacity),
@@ -153,8 +150,8 @@ func (syncer *KVCacheSyncer) Sync(ctx context.Context, wg *sync.WaitGroup) {
 // doCheckPoint pubsub checkpoint to validate epoch data in cache
 func (syncer *KVCacheSyncer) doCheckPoint() error {
 	logger := logrus.WithFields(logrus.Fields{
-		"syncWindow":     syncer.syncWindow,
-		"lastSubEpochNo": atomic.LoadUint64(&syncer.lastSubEpochNo),
+		"syncWindow":  syncer.syncWindow,
+		"trackedHead": syncer.headTracker.currentHead(),
 	})
 
 	logger.Info("Cache syncer ensuring epoch data validity on pubsub checkpoint")
@@ -374,33 +371,14 @@ func (syncer *KVCacheSyncer) syncOnce() error {
 func (syncer *KVCacheSyncer) validateNewReceivedEpoch(epoch *types.WebsocketEpochResponse) error {
 	newEpoch := epoch.EpochNumber.ToInt().Uint64()
 
-	addrPtr := &(syncer.lastSubEpochNo)
-	lastSubEpochNo := atomic.LoadUint64(addrPtr)
-
-	logger := logrus.WithFields(logrus.Fields{
-		"newEpoch":       newEpoch,
-		"lastSubEpochNo": lastSubEpochNo,
-	})
-
-	switch {
-	case lastSubEpochNo == citypes.EpochNumberNil: // initial state
-		logger.Debug("Cache syncer initially set last sub epoch number for validation")
-
-		atomic.StoreUint64(addrPtr, newEpoch)
-		return nil
-	case lastSubEpochNo >= newEpoch: // pivot switch
-		logger.Info("Cache syncer validated pubsub new epoch pivot switched")
-
-		atomic.StoreUint64(addrPtr, newEpoch)
-		return nil
-	case lastSubEpochNo+1 == newEpoch: // continuous
-		logger.Debug("Cache syncer validated pubsub new epoch continuous")
-
-		atomic.StoreUint64(addrPtr, newEpoch)
-		return nil
-	default: // bad incontinuous epoch
-		return errors.Errorf("bad incontinuous epoch, expect %v got %v", lastSubEpochNo+1, newEpoch)
+	var pivotHash types.Hash
+	if len(epoch.EpochHashesOrdered) > 0 {
+		pivotHash = epoch.EpochHashesOrdered[len(epoch.EpochHashesOrdered)-1]
 	}
+
+	// pivot switch will be handled by sync window later
+	_, err := syncer.headTracker.track(newEpoch, pivotHash)
+	return err
 }
 
 func (syncer *KVCacheSyncer) getStoreLatestPivotHash() (types.Hash, error) {
@@ -466,8 +444,7 @@ func (syncer *KVCacheSyncer) onEpochReceived(epoch types.WebsocketEpochResponse)
 			"Cache syncer failed to validate new received epoch from pubsub",
 		)
 
-		// reset lastSubEpochNo
-		atomic.StoreUint64(&(syncer.lastSubEpochNo), citypes.EpochNumberNil)
+		syncer.headTracker.reset()
 		return
 	}
 
@@ -477,8 +454,7 @@ func (syncer *KVCacheSyncer) onEpochReceived(epoch types.WebsocketEpochResponse)
 func (syncer *KVCacheSyncer) onEpochSubStart() {
 	logrus.Debug("Cache syncer onEpochSubStart event received")
 
-	// reset lastSubEpochNo
-	atomic.StoreUint64(&(syncer.lastSubEpochNo), citypes.EpochNumberNil)
+	syncer.headTracker.reset()
 	syncer.triggerCheckpoint()
 }
 
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/openweb3/web3go"
+	ethtypes "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// crash recovery configuration
+type recoveryConfig struct {
+	// number of the latest epochs in store to verify against fullnode on restart
+	VerifyEpochs uint64 `default:"100"`
+}
+
+// pivotHashFetcher fetches the pivot block hash of the specified epoch from fullnode.
+type pivotHashFetcher func(epochNo uint64) (string, error)
+
+func cfxPivotHashFetcher(cfx sdk.ClientOperator) pivotHashFetcher {
+	return func(epochNo uint64) (string, error) {
+		block, err := cfx.GetBlockSummaryByEpoch(cfxtypes.NewEpochNumberUint64(epochNo))
+		if err != nil {
+			return "", err
+		}
+
+		return string(block.Hash), nil
+	}
+}
+
+func ethPivotHashFetcher(w3c *web3go.Client) pivotHashFetcher {
+	return func(blockNo uint64) (string, error) {
+		block, err := w3c.Eth.BlockByNumber(ethtypes.BlockNumber(blockNo), false)
+		if err != nil {
+			return "", err
+		}
+
+		if block == nil {
+			return "", errors.Errorf("block %v not found", blockNo)
+		}
+
+		return block.Hash.Hex(), nil
+	}
+}
+
+// recoverFromCheckpoint verifies the latest epochs in store, together with the pending reorg
+// window of the persisted sync checkpoint, against fullnode on restart. Any reverted or missing
+// epoch data will be pruned so that sync could be resumed without gaps or duplicates after crash.
+func recoverFromCheckpoint(db *mysql.MysqlStore, conf recoveryConfig, fetcher pivotHashFetcher) error {
+	maxEpoch, ok, err := db.MaxEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	if !ok { // no epoch data in store yet
+		return nil
+	}
+
+	minEpoch, _, err := db.MinEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get min epoch")
+	}
+
+	// genesis epoch must not be reverted
+	minEpoch = util.MaxUint64(minEpoch, 1)
+
+	verifyRange := citypes.RangeUint64{From: minEpoch, To: maxEpoch}
+	if conf.VerifyEpochs > 0 && maxEpoch >= minEpoch+conf.VerifyEpochs {
+		verifyRange.From = maxEpoch - conf.VerifyEpochs + 1
+	}
+
+	cp, ok, err := db.LoadSyncCheckpoint()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load sync checkpoint")
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"maxEpoch": maxEpoch, "checkpoint": cp})
+
+	switch {
+	case !ok:
+		logger.Info("No sync checkpoint found, only the latest epochs will be verified")
+	case cp.Epoch != maxEpoch:
+		// checkpoint out of sync with the data (eg., persisted by legacy version), verify all
+		logger.Warn("Sync checkpoint mismatched with store, all epochs will be verified")
+		verifyRange.From = minEpoch
+	default:
+		if window, ok := cp.PendingReorgWindow(); ok && window.From < verifyRange.From {
+			verifyRange.From = util.MaxUint64(window.From, minEpoch)
+		}
+	}
+
+	logger = logger.WithField("verifyRange", verifyRange)
+	logger.Info("Verifying the latest epoch data against fullnode for crash recovery")
+
+	checker := func(_ sdk.ClientOperator, _ store.StackOperable, epochNo uint64) (bool, error) {
+		return checkIfEpochIsDirty(db, fetcher, epochNo)
+	}
+
+	searcher := func(cfx sdk.ClientOperator, s store.StackOperable, er citypes.RangeUint64) (uint64, error) {
+		return findFirstRevertedEpochInRange(cfx, s, er, checker)
+	}
+
+	if err := ensureEpochRangeNotRerverted(nil, db, verifyRange, searcher, pruneRevertedEpochData); err != nil {
+		return errors.WithMessage(err, "failed to ensure epoch data not reverted")
+	}
+
+	// all the remaining epoch data are verified
+	maxEpoch, ok, err = db.MaxEpoch()
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := db.MarkSyncVerified(maxEpoch); err != nil {
+		return errors.WithMessage(err, "failed to mark sync verified")
+	}
+
+	logger.WithField("verifiedEpoch", maxEpoch).Info("Crash recovery verification completed")
+	return nil
+}
+
+// checkIfEpochIsDirty checks if the epoch data in store is reverted or missing (gap).
+func checkIfEpochIsDirty(db *mysql.MysqlStore, fetcher pivotHashFetcher, epochNo uint64) (bool, error) {
+	pivotHash, ok, err := db.PivotHash(epochNo)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to get epoch pivot hash")
+	}
+
+	if !ok { // gap found
+		return true, nil
+	}
+
+	chainPivotHash, err := fetcher(epochNo)
+	if err != nil {
+		return false, errors.WithMessagef(err, "failed to get pivot hash for epoch %v from fullnode", epochNo)
+	}
+
+	return pivotHash != chainPivotHash, nil
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// reorg handling configuration
+type reorgConfig struct {
+	// number of the latest epochs in store which are not finalized yet and subject to reorg,
+	// any reorg deeper than this window will be handled as deep reorg.
+	UnfinalizedWindow uint64 `default:"50"`
+}
+
+// reorgInfo reorg detected from store against fullnode
+type reorgInfo struct {
+	reverted citypes.RangeUint64 // affected epoch range to be reverted
+	deep     bool                // whether reorg is deeper than the unfinalized window
+}
+
+func (r *reorgInfo) depth() uint64 {
+	return r.reverted.To - r.reverted.From + 1
+}
+
+// detectReorg detects the depth of reorg once the latest epoch in store is found not continuous
+// to the chain, by searching the first reverted epoch within the unfinalized window, and then
+// beyond the window in case of deep reorg.
+func detectReorg(
+	db *mysql.MysqlStore, conf reorgConfig, fetcher pivotHashFetcher, latestEpoch uint64,
+) (*reorgInfo, error) {
+	minEpoch, _, err := db.MinEpoch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get min epoch")
+	}
+
+	// genesis epoch must not be reverted
+	minEpoch = util.MaxUint64(minEpoch, 1)
+	if latestEpoch < minEpoch {
+		return nil, errors.Errorf("no epoch data to revert in store (latest epoch %v)", latestEpoch)
+	}
+
+	checker := func(_ sdk.ClientOperator, _ store.StackOperable, epochNo uint64) (bool, error) {
+		return checkIfEpochIsDirty(db, fetcher, epochNo)
+	}
+
+	window := citypes.RangeUint64{From: minEpoch, To: latestEpoch}
+	if conf.UnfinalizedWindow > 0 && latestEpoch >= minEpoch+conf.UnfinalizedWindow {
+		window.From = latestEpoch - conf.UnfinalizedWindow + 1
+	}
+
+	firstReverted, err := findFirstRevertedEpochInRange(nil, db, window, checker)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to search reverted epoch within unfinalized window")
+	}
+
+	switch {
+	case firstReverted == 0:
+		// store is consistent with fullnode, maybe the chain reorged again during sync,
+		// so just revert the latest epoch as usual.
+		firstReverted = latestEpoch
+	case firstReverted == window.From && window.From > minEpoch:
+		// whole unfinalized window reverted, search beyond the window for deep reorg
+		beyond := citypes.RangeUint64{From: minEpoch, To: window.From - 1}
+
+		epochNo, err := findFirstRevertedEpochInRange(nil, db, beyond, checker)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to search reverted epoch beyond unfinalized window")
+		}
+
+		if epochNo != 0 {
+			firstReverted = epochNo
+		}
+
+		return &reorgInfo{
+			reverted: citypes.RangeUint64{From: firstReverted, To: latestEpoch},
+			deep:     firstReverted < window.From,
+		}, nil
+	}
+
+	return &reorgInfo{reverted: citypes.RangeUint64{From: firstReverted, To: latestEpoch}}, nil
+}
+
+// revertReorg reverts the affected epoch data of reorg from store, so that they could be
+// re-synced automatically. Besides, deep reorg will be reported for alerting.
+func revertReorg(db *mysql.MysqlStore, space string, reorg *reorgInfo) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"space": space, "reverted": reorg.reverted, "depth": reorg.depth(),
+	})
+
+	metrics.Registry.Sync.ReorgDepth(space).Update(int64(reorg.depth()))
+
+	if !reorg.deep {
+		logger.Info("Reverting epoch data due to chain reorg")
+		return db.Popn(reorg.reverted.From)
+	}
+
+	metrics.Registry.Sync.DeepReorgs(space).Mark(1)
+	logger.Error("Deep reorg beyond unfinalized window detected, affected epoch data will be re-synced")
+
+	// prune in batches in case of too many epoch data reverted
+	return pruneRevertedEpochData(db, reorg.reverted)
+}
@@ -0,0 +1,102 @@
+package token
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/web3go/types"
+)
+
+var (
+	// Transfer(address indexed from, address indexed to, uint256 value) for ERC-20, or
+	// Transfer(address indexed from, address indexed to, uint256 indexed tokenId) for ERC-721
+	topicTransfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value)
+	topicTransferSingle = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	// TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids, uint256[] values)
+	topicTransferBatch = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	transferBatchArgs abi.Arguments
+)
+
+func init() {
+	uint256ArrType, _ := abi.NewType("uint256[]", "", nil)
+	transferBatchArgs = abi.Arguments{{Type: uint256ArrType}, {Type: uint256ArrType}}
+}
+
+// DecodeTransfers decodes the token transfers from event log, or returns nil if not a well-formed
+// `Transfer`, `TransferSingle` or `TransferBatch` event.
+func DecodeTransfers(log *types.Log) []*mysql.TokenTransfer {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+
+	newTransfer := func(standard string, from, to common.Hash, tokenId, value *big.Int) *mysql.TokenTransfer {
+		t := &mysql.TokenTransfer{
+			Bn:       log.BlockNumber,
+			TxHash:   log.TxHash.Hex(),
+			LogIndex: uint64(log.Index),
+			Contract: strings.ToLower(log.Address.Hex()),
+			Standard: standard,
+			From:     topicToAddress(from),
+			To:       topicToAddress(to),
+			Value:    value.String(),
+		}
+
+		if tokenId != nil {
+			t.TokenId = tokenId.String()
+		}
+
+		return t
+	}
+
+	switch {
+	case log.Topics[0] == topicTransfer && len(log.Topics) == 3 && len(log.Data) == 32: // ERC-20
+		value := new(big.Int).SetBytes(log.Data)
+		return []*mysql.TokenTransfer{
+			newTransfer(mysql.TokenStandardERC20, log.Topics[1], log.Topics[2], nil, value),
+		}
+	case log.Topics[0] == topicTransfer && len(log.Topics) == 4 && len(log.Data) == 0: // ERC-721
+		tokenId := log.Topics[3].Big()
+		return []*mysql.TokenTransfer{
+			newTransfer(mysql.TokenStandardERC721, log.Topics[1], log.Topics[2], tokenId, big.NewInt(1)),
+		}
+	case log.Topics[0] == topicTransferSingle && len(log.Topics) == 4 && len(log.Data) == 64:
+		tokenId := new(big.Int).SetBytes(log.Data[:32])
+		value := new(big.Int).SetBytes(log.Data[32:])
+		return []*mysql.TokenTransfer{
+			newTransfer(mysql.TokenStandardERC1155, log.Topics[2], log.Topics[3], tokenId, value),
+		}
+	case log.Topics[0] == topicTransferBatch && len(log.Topics) == 4:
+		values, err := transferBatchArgs.Unpack(log.Data)
+		if err != nil || len(values) != 2 {
+			return nil
+		}
+
+		ids, ok1 := values[0].([]*big.Int)
+		amounts, ok2 := values[1].([]*big.Int)
+		if !ok1 || !ok2 || len(ids) != len(amounts) {
+			return nil
+		}
+
+		transfers := make([]*mysql.TokenTransfer, 0, len(ids))
+		for i := range ids {
+			transfers = append(transfers, newTransfer(
+				mysql.TokenStandardERC1155, log.Topics[2], log.Topics[3], ids[i], amounts[i],
+			))
+		}
+
+		return transfers
+	}
+
+	return nil
+}
+
+// topicToAddress converts the indexed address topic to lower case hex address.
+func topicToAddress(topic common.Hash) string {
+	return strings.ToLower(common.BytesToAddress(topic.Bytes()).Hex())
+}
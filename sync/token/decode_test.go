@@ -0,0 +1,78 @@
+package token
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testContract = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	testOperator = common.HexToHash("0x3333333333333333333333333333333333333333")
+	testFrom     = common.HexToHash("0x000000000000000000000000000000000000000a")
+	testTo       = common.HexToHash("0x000000000000000000000000000000000000000b")
+)
+
+func TestDecodeTransfers(t *testing.T) {
+	// ERC-20
+	transfers := DecodeTransfers(&types.Log{
+		Address:     testContract,
+		BlockNumber: 10,
+		Topics:      []common.Hash{topicTransfer, testFrom, testTo},
+		Data:        common.LeftPadBytes(big.NewInt(100).Bytes(), 32),
+	})
+	assert.Equal(t, 1, len(transfers))
+	assert.Equal(t, mysql.TokenStandardERC20, transfers[0].Standard)
+	assert.Equal(t, "0x1111111111111111111111111111111111111111", transfers[0].Contract)
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", transfers[0].From)
+	assert.Equal(t, "0x000000000000000000000000000000000000000b", transfers[0].To)
+	assert.Equal(t, "", transfers[0].TokenId)
+	assert.Equal(t, "100", transfers[0].Value)
+	assert.Equal(t, uint64(10), transfers[0].Bn)
+
+	// ERC-721
+	transfers = DecodeTransfers(&types.Log{
+		Address: testContract,
+		Topics:  []common.Hash{topicTransfer, testFrom, testTo, common.BigToHash(big.NewInt(7))},
+	})
+	assert.Equal(t, 1, len(transfers))
+	assert.Equal(t, mysql.TokenStandardERC721, transfers[0].Standard)
+	assert.Equal(t, "7", transfers[0].TokenId)
+	assert.Equal(t, "1", transfers[0].Value)
+
+	// ERC-1155 single
+	data := append(common.LeftPadBytes(big.NewInt(5).Bytes(), 32), common.LeftPadBytes(big.NewInt(20).Bytes(), 32)...)
+	transfers = DecodeTransfers(&types.Log{
+		Address: testContract,
+		Topics:  []common.Hash{topicTransferSingle, testOperator, testFrom, testTo},
+		Data:    data,
+	})
+	assert.Equal(t, 1, len(transfers))
+	assert.Equal(t, mysql.TokenStandardERC1155, transfers[0].Standard)
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", transfers[0].From)
+	assert.Equal(t, "5", transfers[0].TokenId)
+	assert.Equal(t, "20", transfers[0].Value)
+
+	// ERC-1155 batch
+	data, err := transferBatchArgs.Pack(
+		[]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(30), big.NewInt(40)},
+	)
+	assert.NoError(t, err)
+
+	transfers = DecodeTransfers(&types.Log{
+		Address: testContract,
+		Topics:  []common.Hash{topicTransferBatch, testOperator, testFrom, testTo},
+		Data:    data,
+	})
+	assert.Equal(t, 2, len(transfers))
+	assert.Equal(t, "2", transfers[1].TokenId)
+	assert.Equal(t, "40", transfers[1].Value)
+
+	// malformed or unrelated event
+	assert.Nil(t, DecodeTransfers(&types.Log{Topics: []common.Hash{topicTransfer, testFrom}}))
+	assert.Nil(t, DecodeTransfers(&types.Log{Topics: []common.Hash{testOperator}}))
+}
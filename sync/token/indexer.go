@@ -0,0 +1,97 @@
+// Package token indexes the ERC-20/721/1155 token transfers of evm space as blocks synced into
+// store, so that token balances and transfer history could be queried by address.
+package token
+
+import (
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"gorm.io/gorm"
+)
+
+var _ mysql.EpochDataObserver = (*Indexer)(nil)
+
+// Config token index configurations
+type Config struct {
+	// whether to index the token transfers as blocks synced
+	Enabled bool
+}
+
+// Store persists the token transfers and balances.
+type Store interface {
+	AddTokenTransfers(dbTx *gorm.DB, bnFrom uint64, transfers []*mysql.TokenTransfer) error
+	RevertTokenTransfers(dbTx *gorm.DB, bnFrom uint64) error
+}
+
+// Indexer decodes the token transfer event logs of synced blocks, and persists them along with the
+// accumulated token balances within the same db transaction, which will be reverted as well if
+// blocks popped due to chain reorg.
+type Indexer struct {
+	store Store
+}
+
+// MustLoadConfigFromViper loads token index configurations from viper settings, which are shared by
+// both sync and RPC services.
+func MustLoadConfigFromViper() (conf Config) {
+	viper.MustUnmarshalKey("sync.tokenIndex", &conf)
+	return conf
+}
+
+// MustNewIndexerFromViper creates token indexer from viper settings, or returns false if not enabled.
+func MustNewIndexerFromViper(store Store) (*Indexer, bool) {
+	if conf := MustLoadConfigFromViper(); !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	return NewIndexer(store), true
+}
+
+func NewIndexer(store Store) *Indexer {
+	return &Indexer{store: store}
+}
+
+// OnEpochDataPushed implements the `mysql.EpochDataObserver` interface to index token transfers.
+func (idx *Indexer) OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	if len(dataSlice) == 0 {
+		return nil
+	}
+
+	var transfers []*mysql.TokenTransfer
+
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			for _, tx := range block.Transactions {
+				receipt := data.Receipts[tx.Hash]
+
+				// skip transactions that unexecuted in block
+				if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+					continue
+				}
+
+				var rcptExt *store.ReceiptExtra
+				if len(data.ReceiptExts) > 0 {
+					rcptExt = data.ReceiptExts[tx.Hash]
+				}
+
+				for k := range receipt.Logs {
+					var logExt *store.LogExtra
+					if rcptExt != nil && k < len(rcptExt.LogExts) {
+						logExt = rcptExt.LogExts[k]
+					}
+
+					log := ethbridge.ConvertLog(&receipt.Logs[k], logExt)
+					transfers = append(transfers, DecodeTransfers(log)...)
+				}
+			}
+		}
+	}
+
+	return idx.store.AddTokenTransfers(dbTx, dataSlice[0].Number, transfers)
+}
+
+// OnEpochDataPopped implements the `mysql.EpochDataObserver` interface to revert token transfers.
+func (idx *Indexer) OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	return idx.store.RevertTokenTransfers(dbTx, epochFrom)
+}
@@ -0,0 +1,75 @@
+package token
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/reprocess"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/pkg/errors"
+)
+
+// ProcessorName name of the processor to rebuild token transfers and balances.
+const ProcessorName = "token_transfers"
+
+var (
+	_ reprocess.Processor = (*Processor)(nil)
+	_ ProcessorStore      = (*mysql.MysqlStore)(nil)
+)
+
+func init() {
+	reprocess.RegisterProcessor(ProcessorName, func(db reprocess.Store) (reprocess.Processor, error) {
+		ps, ok := db.(ProcessorStore)
+		if !ok {
+			return nil, errors.New("token transfers not supported by db store")
+		}
+
+		return NewProcessor(ps), nil
+	})
+}
+
+// ProcessorStore persists the rebuilt token transfers and balances.
+type ProcessorStore interface {
+	ReplaceTokenTransfers(bnFrom, bnTo uint64, transfers []*mysql.TokenTransfer) error
+	ResetTokenTransfers(bnFrom uint64) error
+}
+
+// Processor rebuilds token transfers and balances from the stored event logs of evm space.
+type Processor struct {
+	store ProcessorStore
+}
+
+func NewProcessor(store ProcessorStore) *Processor {
+	return &Processor{store: store}
+}
+
+// Name implements the `reprocess.Processor` interface.
+func (p *Processor) Name() string {
+	return ProcessorName
+}
+
+// Reset implements the `reprocess.Processor` interface to revert token transfers and balances.
+func (p *Processor) Reset(bnFrom uint64) error {
+	return p.store.ResetTokenTransfers(bnFrom)
+}
+
+// Process implements the `reprocess.Processor` interface to decode token transfers from event logs.
+func (p *Processor) Process(ctx context.Context, bnRange types.RangeUint64, logs []*store.Log) error {
+	var transfers []*mysql.TokenTransfer
+
+	for _, log := range logs {
+		// skip the irrelevant event logs without decompression
+		switch log.Topic0 {
+		case topicTransfer.Hex(), topicTransferSingle.Hex(), topicTransferBatch.Hex():
+		default:
+			continue
+		}
+
+		cfxLog, logExt := log.ToCfxLog()
+		transfers = append(transfers, DecodeTransfers(ethbridge.ConvertLog(cfxLog, logExt))...)
+	}
+
+	return p.store.ReplaceTokenTransfers(bnRange.From, bnRange.To, transfers)
+}
@@ -0,0 +1,83 @@
+package token
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProcessorStore struct {
+	resetFrom *uint64
+	bnRange   citypes.RangeUint64
+	transfers []*mysql.TokenTransfer
+}
+
+func (s *mockProcessorStore) ReplaceTokenTransfers(bnFrom, bnTo uint64, transfers []*mysql.TokenTransfer) error {
+	s.bnRange, s.transfers = citypes.RangeUint64{From: bnFrom, To: bnTo}, transfers
+	return nil
+}
+
+func (s *mockProcessorStore) ResetTokenTransfers(bnFrom uint64) error {
+	s.resetFrom = &bnFrom
+	return nil
+}
+
+// newTestStoreLog creates event log persisted in store of the test token contract.
+func newTestStoreLog(bn uint64, topics []common.Hash, data []byte) *store.Log {
+	txHash := types.Hash(common.HexToHash("0x1").Hex())
+
+	log := &types.Log{
+		Address:          cfxaddress.MustNewFromCommon(testContract, 1030),
+		Data:             data,
+		EpochNumber:      types.NewBigInt(bn),
+		LogIndex:         types.NewBigInt(0),
+		TransactionHash:  &txHash,
+		TransactionIndex: types.NewBigInt(0),
+	}
+
+	for _, topic := range topics {
+		log.Topics = append(log.Topics, types.Hash(topic.Hex()))
+	}
+
+	return store.ParseCfxLog(log, 0, bn, nil)
+}
+
+func TestProcessorProcess(t *testing.T) {
+	s := &mockProcessorStore{}
+	p := NewProcessor(s)
+
+	logs := []*store.Log{
+		newTestStoreLog(5, []common.Hash{topicTransfer, testFrom, testTo}, common.LeftPadBytes(big.NewInt(100).Bytes(), 32)),
+		newTestStoreLog(6, []common.Hash{common.HexToHash("0xabc"), testFrom, testTo}, nil), // irrelevant
+		newTestStoreLog(7, []common.Hash{topicTransfer, testFrom, testTo, common.BigToHash(big.NewInt(7))}, nil),
+	}
+
+	err := p.Process(context.Background(), citypes.RangeUint64{From: 1, To: 10}, logs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, citypes.RangeUint64{From: 1, To: 10}, s.bnRange)
+	assert.Equal(t, 2, len(s.transfers))
+	assert.Equal(t, mysql.TokenStandardERC20, s.transfers[0].Standard)
+	assert.Equal(t, "0x1111111111111111111111111111111111111111", s.transfers[0].Contract)
+	assert.Equal(t, "100", s.transfers[0].Value)
+	assert.Equal(t, uint64(5), s.transfers[0].Bn)
+	assert.Equal(t, mysql.TokenStandardERC721, s.transfers[1].Standard)
+	assert.Equal(t, "7", s.transfers[1].TokenId)
+
+	// replaced with nothing if no transfers in block range
+	err = p.Process(context.Background(), citypes.RangeUint64{From: 11, To: 20}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, citypes.RangeUint64{From: 11, To: 20}, s.bnRange)
+	assert.Empty(t, s.transfers)
+
+	assert.NoError(t, p.Reset(3))
+	assert.Equal(t, uint64(3), *s.resetFrom)
+}
@@ -0,0 +1,311 @@
+// Package trace indexes the internal transactions of evm space traced from a trace-enabled fullnode,
+// which runs as an optional sync stage lagging behind the synced blocks in store.
+package trace
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Config internal transaction index configurations
+type Config struct {
+	// whether to index internal transactions traced from fullnode
+	Enabled bool
+	// URL of trace-enabled fullnode, which defaults to the fullnode to sync from
+	Node string
+	// block number to index from if nothing indexed yet
+	FromBlock uint64 `default:"1"`
+	// max number of blocks to trace each time
+	MaxBlocks uint64 `default:"10"`
+	// interval to poll the newly synced blocks from store
+	PollInterval time.Duration `default:"1s"`
+	// whether to index value transfers only, otherwise all the internal calls
+	ValueTransferOnly bool
+}
+
+// Store persists the internal transactions.
+type Store interface {
+	MinEpoch() (uint64, bool, error)
+	MaxEpoch() (uint64, bool, error)
+	InitInternalTxNextBlock(bn uint64) (uint64, error)
+	AdvanceInternalTxNextBlock(bn uint64) (uint64, error)
+	AddInternalTxs(bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*mysql.InternalTx) error
+}
+
+// Indexer traces the blocks synced into store from a trace-enabled fullnode, and indexes the internal
+// transactions so that explorers could query internal value transfers by address or transaction.
+//
+// Traces are indexed only if the traced block hashes match the synced ones in store, and will be
+// reverted by store along with the synced blocks popped due to chain reorg.
+type Indexer struct {
+	conf  Config
+	w3c   *web3go.Client
+	store Store
+
+	next uint64 // next block to index
+}
+
+// MustLoadConfigFromViper loads internal transaction index configurations from viper settings, which
+// are shared by both sync and RPC services.
+func MustLoadConfigFromViper() (conf Config) {
+	viper.MustUnmarshalKey("sync.traceIndex", &conf)
+	return conf
+}
+
+// MustNewIndexerFromViper creates internal transaction indexer from viper settings, or returns false
+// if not enabled. Note, the specified client is used to trace blocks if no fullnode configured.
+func MustNewIndexerFromViper(w3c *web3go.Client, store Store) (*Indexer, bool) {
+	conf := MustLoadConfigFromViper()
+	if !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	if len(conf.Node) > 0 {
+		w3c = rpcutil.MustNewEthClient(conf.Node)
+	}
+
+	return NewIndexer(conf, w3c, store), true
+}
+
+func NewIndexer(conf Config, w3c *web3go.Client, store Store) *Indexer {
+	return &Indexer{conf: conf, w3c: w3c, store: store}
+}
+
+// Run traces the newly synced blocks and indexes internal transactions until context done.
+func (idx *Indexer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logrus.Info("Internal transaction indexer started")
+
+	ticker := time.NewTicker(idx.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Internal transaction indexer shutdown ok")
+			return
+		case <-ticker.C:
+			// index continuously until caught up with store
+			for {
+				caughtUp, err := idx.indexOnce()
+				if err != nil {
+					logrus.WithError(err).Error("Internal transaction indexer failed to index blocks")
+				}
+
+				if err != nil || caughtUp || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// indexOnce traces a batch of synced blocks and indexes internal transactions, and returns true if
+// caught up with store.
+func (idx *Indexer) indexOnce() (bool, error) {
+	if idx.next == 0 {
+		if err := idx.loadNextBlock(); err != nil {
+			return false, err
+		}
+	}
+
+	if err := idx.skipPrunedBlocks(); err != nil {
+		return false, err
+	}
+
+	maxBlock, ok, err := idx.store.MaxEpoch()
+	if err != nil || !ok || idx.next > maxBlock {
+		return true, errors.WithMessage(err, "failed to get max synced block")
+	}
+
+	bnFrom, bnTo := idx.next, maxBlock
+	if bnTo-bnFrom+1 > idx.conf.MaxBlocks {
+		bnTo = bnFrom + idx.conf.MaxBlocks - 1
+	}
+
+	blockHashes := make(map[uint64]string)
+	var txs []*mysql.InternalTx
+
+	for bn := bnFrom; bn <= bnTo; bn++ {
+		blockHash, blockTxs, err := idx.traceBlock(bn)
+		if err != nil {
+			return false, errors.WithMessagef(err, "failed to trace block %v", bn)
+		}
+
+		blockHashes[bn] = blockHash
+		txs = append(txs, blockTxs...)
+	}
+
+	err = idx.store.AddInternalTxs(bnFrom, bnTo, blockHashes, txs)
+	if errors.Is(err, mysql.ErrInternalTxStale) {
+		// reverted due to chain reorg, or trace node lags behind, reload and retry later
+		logrus.WithFields(logrus.Fields{
+			"bnFrom": bnFrom, "bnTo": bnTo,
+		}).Info("Internal transaction indexer skipped stale traced blocks")
+
+		idx.next = 0
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	idx.next = bnTo + 1
+	return bnTo == maxBlock, nil
+}
+
+func (idx *Indexer) loadNextBlock() error {
+	fromBlock := idx.conf.FromBlock
+
+	// no need to index blocks that already pruned from store
+	minBlock, ok, err := idx.store.MinEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get min synced block")
+	}
+
+	if ok && minBlock > fromBlock {
+		fromBlock = minBlock
+	}
+
+	idx.next, err = idx.store.InitInternalTxNextBlock(fromBlock)
+	return err
+}
+
+// skipPrunedBlocks moves the next block to index past the blocks pruned from store (e.g. due to
+// retention), which could never be indexed any more.
+func (idx *Indexer) skipPrunedBlocks() error {
+	minBlock, ok, err := idx.store.MinEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get min synced block")
+	}
+
+	if !ok || idx.next >= minBlock {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"next": idx.next, "minBlock": minBlock,
+	}).Info("Internal transaction indexer skipped blocks pruned from store")
+
+	idx.next, err = idx.store.AdvanceInternalTxNextBlock(minBlock)
+	return err
+}
+
+func (idx *Indexer) traceBlock(bn uint64) (string, []*mysql.InternalTx, error) {
+	return traceBlock(idx.w3c, bn, idx.conf.ValueTransferOnly)
+}
+
+// traceBlock traces the specified block, and returns the block hash along with internal transactions.
+func traceBlock(w3c *web3go.Client, bn uint64, valueTransferOnly bool) (string, []*mysql.InternalTx, error) {
+	traces, err := w3c.Trace.Blocks(types.BlockNumberOrHashWithNumber(types.BlockNumber(bn)))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(traces) > 0 {
+		return traces[0].BlockHash.Hex(), ConvertTraces(traces, valueTransferOnly), nil
+	}
+
+	// no trace in block, retrieve block hash to validate against store
+	block, err := w3c.Eth.BlockByNumber(types.BlockNumber(bn), false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if block == nil {
+		return "", nil, errors.New("block not found")
+	}
+
+	return block.Hash.Hex(), nil, nil
+}
+
+// ConvertTraces converts the nested traces (namely the internal calls, contract creations and self
+// destructs) to internal transactions, and ignores the top level ones which are the transactions.
+func ConvertTraces(traces []types.LocalizedTrace, valueTransferOnly bool) (txs []*mysql.InternalTx) {
+	for i := range traces {
+		trace := &traces[i]
+
+		if len(trace.TraceAddress) == 0 || trace.TransactionHash == nil {
+			continue
+		}
+
+		if trace.Valid != nil && !*trace.Valid {
+			continue
+		}
+
+		itx := &mysql.InternalTx{
+			Bn:           trace.BlockNumber,
+			TxHash:       trace.TransactionHash.Hex(),
+			TraceAddress: formatTraceAddress(trace.TraceAddress),
+			Type:         string(trace.Type),
+		}
+
+		if trace.TransactionPosition != nil {
+			itx.TxIndex = uint64(*trace.TransactionPosition)
+		}
+
+		if trace.Error != nil {
+			itx.Error = *trace.Error
+		}
+
+		var value *big.Int
+
+		switch action := trace.Action.(type) {
+		case types.Call:
+			itx.From, itx.To, itx.CallType = action.From.Hex(), action.To.Hex(), string(action.CallType)
+			value = action.Value
+		case types.Create:
+			itx.From, value = action.From.Hex(), action.Value
+			if result, ok := trace.Result.(types.CreateResult); ok {
+				itx.To = result.Address.Hex()
+			}
+		case types.Suicide:
+			itx.From, itx.To, value = action.Address.Hex(), action.RefundAddress.Hex(), action.Balance
+		default:
+			continue
+		}
+
+		if value == nil {
+			value = big.NewInt(0)
+		}
+
+		if valueTransferOnly && value.Sign() == 0 {
+			continue
+		}
+
+		itx.From, itx.To, itx.Value = strings.ToLower(itx.From), strings.ToLower(itx.To), value.String()
+		if len(itx.Error) > 256 {
+			itx.Error = itx.Error[:256]
+		}
+
+		txs = append(txs, itx)
+	}
+
+	return txs
+}
+
+// formatTraceAddress formats trace address as path in call tree, eg., `0_1`.
+func formatTraceAddress(traceAddress []uint) string {
+	path := make([]string, len(traceAddress))
+	for i, v := range traceAddress {
+		path[i] = strconv.FormatUint(uint64(v), 10)
+	}
+
+	return strings.Join(path, "_")
+}
@@ -0,0 +1,134 @@
+package trace
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertTraces(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	pos := uint(2)
+	from := common.HexToAddress("0x000000000000000000000000000000000000000A")
+	to := common.HexToAddress("0x000000000000000000000000000000000000000B")
+	errMsg := "Reverted"
+
+	traces := []types.LocalizedTrace{
+		{ // top level call
+			Type:            types.TRACE_CALL,
+			Action:          types.Call{From: from, To: to, Value: big.NewInt(1)},
+			TransactionHash: &txHash,
+		},
+		{ // internal value transfer
+			Type:                types.TRACE_CALL,
+			Action:              types.Call{From: to, To: from, Value: big.NewInt(5), CallType: "call"},
+			TraceAddress:        []uint{0},
+			TransactionHash:     &txHash,
+			TransactionPosition: &pos,
+			BlockNumber:         100,
+		},
+		{ // internal static call failed
+			Type:            types.TRACE_CALL,
+			Action:          types.Call{From: to, To: from, Value: big.NewInt(0), CallType: "staticcall"},
+			TraceAddress:    []uint{0, 1},
+			TransactionHash: &txHash,
+			Error:           &errMsg,
+		},
+		{ // internal contract creation
+			Type:            types.TRACE_CREATE,
+			Action:          types.Create{From: to, Value: big.NewInt(0)},
+			Result:          types.CreateResult{Address: from},
+			TraceAddress:    []uint{1},
+			TransactionHash: &txHash,
+		},
+	}
+
+	txs := ConvertTraces(traces, false)
+	assert.Equal(t, 3, len(txs))
+
+	assert.Equal(t, uint64(100), txs[0].Bn)
+	assert.Equal(t, txHash.Hex(), txs[0].TxHash)
+	assert.Equal(t, uint64(2), txs[0].TxIndex)
+	assert.Equal(t, "0", txs[0].TraceAddress)
+	assert.Equal(t, "0x000000000000000000000000000000000000000b", txs[0].From)
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", txs[0].To)
+	assert.Equal(t, "5", txs[0].Value)
+
+	assert.Equal(t, "0_1", txs[1].TraceAddress)
+	assert.Equal(t, "staticcall", txs[1].CallType)
+	assert.Equal(t, errMsg, txs[1].Error)
+
+	assert.Equal(t, types.TRACE_CREATE, txs[2].Type)
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", txs[2].To)
+
+	// value transfers only
+	txs = ConvertTraces(traces, true)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, "5", txs[0].Value)
+}
+
+type mockStore struct {
+	minBlock, maxBlock uint64
+	next               uint64
+}
+
+func (s *mockStore) MinEpoch() (uint64, bool, error) { return s.minBlock, true, nil }
+func (s *mockStore) MaxEpoch() (uint64, bool, error) { return s.maxBlock, true, nil }
+
+func (s *mockStore) InitInternalTxNextBlock(bn uint64) (uint64, error) {
+	if s.next == 0 {
+		s.next = bn
+	}
+
+	return s.next, nil
+}
+
+func (s *mockStore) AdvanceInternalTxNextBlock(bn uint64) (uint64, error) {
+	if s.next < bn {
+		s.next = bn
+	}
+
+	return s.next, nil
+}
+
+func (s *mockStore) AddInternalTxs(bnFrom, bnTo uint64, _ map[uint64]string, _ []*mysql.InternalTx) error {
+	s.next = bnTo + 1
+	return nil
+}
+
+func TestIndexerSkipPrunedBlocks(t *testing.T) {
+	store := &mockStore{minBlock: 100, maxBlock: 200, next: 50}
+	idx := NewIndexer(Config{FromBlock: 1, MaxBlocks: 10}, nil, store)
+
+	// resumed from persisted cursor, which is behind the pruned blocks
+	assert.NoError(t, idx.loadNextBlock())
+	assert.Equal(t, uint64(50), idx.next)
+
+	assert.NoError(t, idx.skipPrunedBlocks())
+	assert.Equal(t, uint64(100), idx.next)
+	assert.Equal(t, uint64(100), store.next)
+
+	// pruned again while indexing
+	store.minBlock = 150
+	assert.NoError(t, idx.skipPrunedBlocks())
+	assert.Equal(t, uint64(150), idx.next)
+
+	// never move backward
+	store.minBlock = 120
+	assert.NoError(t, idx.skipPrunedBlocks())
+	assert.Equal(t, uint64(150), idx.next)
+	assert.Equal(t, uint64(150), store.next)
+}
+
+func TestIndexerLoadNextBlockFromMinBlock(t *testing.T) {
+	store := &mockStore{minBlock: 100, maxBlock: 200}
+	idx := NewIndexer(Config{FromBlock: 1, MaxBlocks: 10}, nil, store)
+
+	// nothing indexed yet, starts from min block in store
+	assert.NoError(t, idx.loadNextBlock())
+	assert.Equal(t, uint64(100), idx.next)
+}
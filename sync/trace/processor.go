@@ -0,0 +1,113 @@
+package trace
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/reprocess"
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ProcessorName name of the processor to rebuild internal transactions.
+const ProcessorName = "internal_txs"
+
+var (
+	_ reprocess.Processor = (*Processor)(nil)
+	_ ProcessorStore      = (*mysql.MysqlStore)(nil)
+)
+
+func init() {
+	reprocess.RegisterProcessor(ProcessorName, func(db reprocess.Store) (reprocess.Processor, error) {
+		ps, ok := db.(ProcessorStore)
+		if !ok {
+			return nil, errors.New("internal transactions not supported by db store")
+		}
+
+		conf := MustLoadConfigFromViper()
+
+		var w3c *web3go.Client
+		if len(conf.Node) > 0 {
+			w3c = rpcutil.MustNewEthClient(conf.Node)
+		} else {
+			w3c = rpcutil.MustNewEthClientFromViper()
+		}
+
+		return NewProcessor(conf, w3c, ps), nil
+	})
+}
+
+// ProcessorStore persists the rebuilt internal transactions.
+type ProcessorStore interface {
+	InternalTxNextBlock() (uint64, bool, error)
+	ReplaceInternalTxs(bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*mysql.InternalTx) error
+	ResetInternalTxs(bnFrom uint64) error
+}
+
+// Processor rebuilds internal transactions by re-tracing the already indexed blocks from the trace-enabled
+// fullnode, eg., to index all the internal calls rather than value transfers only. Note, blocks not
+// indexed yet are left to the indexer.
+type Processor struct {
+	store      ProcessorStore
+	traceBlock func(bn uint64) (string, []*mysql.InternalTx, error)
+}
+
+func NewProcessor(conf Config, w3c *web3go.Client, store ProcessorStore) *Processor {
+	return &Processor{
+		store: store,
+		traceBlock: func(bn uint64) (string, []*mysql.InternalTx, error) {
+			return traceBlock(w3c, bn, conf.ValueTransferOnly)
+		},
+	}
+}
+
+// Name implements the `reprocess.Processor` interface.
+func (p *Processor) Name() string {
+	return ProcessorName
+}
+
+// Reset implements the `reprocess.Processor` interface to remove the indexed internal transactions.
+func (p *Processor) Reset(bnFrom uint64) error {
+	return p.store.ResetInternalTxs(bnFrom)
+}
+
+// Process implements the `reprocess.Processor` interface to re-trace the already indexed blocks of the
+// block range, where the event logs are not used at all.
+func (p *Processor) Process(ctx context.Context, bnRange citypes.RangeUint64, logs []*store.Log) error {
+	next, ok, err := p.store.InternalTxNextBlock()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get next block of internal transactions")
+	}
+
+	if !ok || bnRange.From >= next {
+		return nil
+	}
+
+	bnTo := bnRange.To
+	if bnTo >= next {
+		logrus.WithFields(logrus.Fields{
+			"bnRange": bnRange, "next": next,
+		}).Debug("Internal transaction processor left blocks not indexed yet to indexer")
+
+		bnTo = next - 1
+	}
+
+	blockHashes := make(map[uint64]string)
+	var txs []*mysql.InternalTx
+
+	for bn := bnRange.From; bn <= bnTo; bn++ {
+		blockHash, blockTxs, err := p.traceBlock(bn)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to trace block %v", bn)
+		}
+
+		blockHashes[bn] = blockHash
+		txs = append(txs, blockTxs...)
+	}
+
+	return p.store.ReplaceInternalTxs(bnRange.From, bnTo, blockHashes, txs)
+}
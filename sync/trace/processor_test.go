@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProcessorStore struct {
+	next, bnFrom, bnTo uint64
+	blockHashes        map[uint64]string
+	txs                []*mysql.InternalTx
+}
+
+func (s *mockProcessorStore) InternalTxNextBlock() (uint64, bool, error) {
+	return s.next, s.next > 0, nil
+}
+
+func (s *mockProcessorStore) ReplaceInternalTxs(
+	bnFrom, bnTo uint64, blockHashes map[uint64]string, txs []*mysql.InternalTx,
+) error {
+	s.bnFrom, s.bnTo, s.blockHashes, s.txs = bnFrom, bnTo, blockHashes, txs
+	return nil
+}
+
+func (s *mockProcessorStore) ResetInternalTxs(bnFrom uint64) error {
+	return nil
+}
+
+func newTestProcessor(s *mockProcessorStore) *Processor {
+	return &Processor{
+		store: s,
+		traceBlock: func(bn uint64) (string, []*mysql.InternalTx, error) {
+			return "hash", []*mysql.InternalTx{{Bn: bn}}, nil
+		},
+	}
+}
+
+func TestProcessorProcess(t *testing.T) {
+	s := &mockProcessorStore{next: 8}
+	p := newTestProcessor(s)
+
+	assert.NoError(t, p.Process(context.Background(), citypes.RangeUint64{From: 3, To: 5}, nil))
+	assert.Equal(t, uint64(3), s.bnFrom)
+	assert.Equal(t, uint64(5), s.bnTo)
+	assert.Equal(t, 3, len(s.blockHashes))
+	assert.Equal(t, 3, len(s.txs))
+
+	// blocks not indexed yet are left to indexer
+	assert.NoError(t, p.Process(context.Background(), citypes.RangeUint64{From: 6, To: 10}, nil))
+	assert.Equal(t, uint64(6), s.bnFrom)
+	assert.Equal(t, uint64(7), s.bnTo)
+	assert.Equal(t, 2, len(s.txs))
+}
+
+func TestProcessorProcessNotIndexed(t *testing.T) {
+	for _, s := range []*mockProcessorStore{{}, {next: 8}} {
+		p := newTestProcessor(s)
+
+		assert.NoError(t, p.Process(context.Background(), citypes.RangeUint64{From: 8, To: 10}, nil))
+		assert.Nil(t, s.txs)
+	}
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// HTTP headers of webhook callback
+	HeaderDeliveryId = "X-Webhook-Delivery"
+	HeaderSignature  = "X-Webhook-Signature"
+
+	// interval to prune the expired deliveries
+	pruneInterval = time.Hour
+)
+
+// Run delivers the pending notifications to webhooks until context done.
+func (n *Notifier) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logrus.Info("Webhook notifier started")
+
+	ticker := time.NewTicker(n.conf.PollInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Webhook notifier shutdown ok")
+			return
+		case <-ticker.C:
+			if err := n.dispatchOnce(ctx); err != nil {
+				logrus.WithError(err).Error("Webhook notifier failed to dispatch deliveries")
+			}
+		case <-pruneTicker.C:
+			pruned, err := n.store.PruneWebhookDeliveries(time.Now().Add(-n.conf.Retention))
+			if err != nil {
+				logrus.WithError(err).Error("Webhook notifier failed to prune expired deliveries")
+			} else if pruned > 0 {
+				logrus.WithField("pruned", pruned).Info("Webhook notifier pruned expired deliveries")
+			}
+		}
+	}
+}
+
+// dispatchOnce delivers the pending deliveries concurrently among webhooks, but in order for
+// each webhook, and the rest will be blocked if any delivery failed.
+func (n *Notifier) dispatchOnce(ctx context.Context) error {
+	deliveries, err := n.store.LoadPendingWebhookDeliveries(n.conf.BatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return errors.WithMessage(err, "failed to load pending deliveries")
+	}
+
+	webhooks, err := n.store.LoadWebhooks()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load webhooks")
+	}
+
+	id2Webhooks := make(map[uint32]*mysql.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		id2Webhooks[webhook.ID] = webhook
+	}
+
+	queues := make(map[uint32][]*mysql.WebhookDelivery)
+	for _, d := range deliveries {
+		queues[d.WebhookID] = append(queues[d.WebhookID], d)
+	}
+
+	var wg sync.WaitGroup
+
+	for id, queue := range queues {
+		webhook, ok := id2Webhooks[id]
+		if !ok { // webhook removed
+			continue
+		}
+
+		wg.Add(1)
+		go func(webhook *mysql.Webhook, queue []*mysql.WebhookDelivery) {
+			defer wg.Done()
+			n.deliverInOrder(ctx, webhook, queue)
+		}(webhook, queue)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (n *Notifier) deliverInOrder(ctx context.Context, webhook *mysql.Webhook, queue []*mysql.WebhookDelivery) {
+	for _, d := range queue {
+		if ctx.Err() != nil || d.NextAttemptAt.After(time.Now()) {
+			return
+		}
+
+		logger := logrus.WithFields(logrus.Fields{
+			"webhook": webhook.Name, "delivery": d.ID, "kind": d.Kind, "attempts": d.Attempts,
+		})
+
+		start := time.Now()
+		err := n.deliver(ctx, webhook, d)
+		metrics.Registry.Sync.WebhookDelivery(err).UpdateSince(start)
+
+		if err != nil {
+			nextAttemptAt := time.Now().Add(n.backoff(d.Attempts))
+			if err := n.store.RetryWebhookDelivery(d.ID, nextAttemptAt, err.Error()); err != nil {
+				logger.WithError(err).Error("Webhook notifier failed to schedule delivery retry")
+			}
+
+			logger.WithError(err).Info("Webhook notifier failed to deliver notification")
+			return
+		}
+
+		if err := n.store.MarkWebhookDelivered(d.ID, time.Now()); err != nil {
+			// will be delivered again, which is allowed for at-least-once delivery
+			logger.WithError(err).Error("Webhook notifier failed to mark delivery as delivered")
+			return
+		}
+
+		logger.Debug("Webhook notifier delivered notification")
+	}
+}
+
+// deliver posts the notification payload to the webhook callback URL, along with the HMAC-SHA256
+// signature if secret specified.
+func (n *Notifier) deliver(ctx context.Context, webhook *mysql.Webhook, d *mysql.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, n.conf.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return errors.WithMessage(err, "failed to create request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryId, strconv.FormatUint(d.ID, 10))
+
+	if len(webhook.Secret) > 0 {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, d.Payload))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// drain the response body to reuse connection
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff returns the duration to retry after the specified number of failed attempts.
+func (n *Notifier) backoff(attempts uint32) time.Duration {
+	backoff := n.conf.MinBackoff
+
+	for i := uint32(0); i < attempts && backoff < n.conf.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > n.conf.MaxBackoff {
+		return n.conf.MaxBackoff
+	}
+
+	return backoff
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of payload, which is used by receiver to
+// verify the `X-Webhook-Signature` header.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
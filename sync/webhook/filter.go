@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// filter event log filter of webhook, which follows the semantics of `eth_getLogs`.
+type filter struct {
+	contracts map[common.Address]bool // empty for any contract
+	topics    []map[common.Hash]bool  // empty for any topic at the position
+}
+
+func newFilter(webhook *mysql.Webhook) (*filter, error) {
+	f := &filter{contracts: make(map[common.Address]bool)}
+
+	for _, v := range strings.Split(webhook.Contracts, ",") {
+		if v = strings.TrimSpace(v); len(v) == 0 {
+			continue
+		}
+
+		if !common.IsHexAddress(v) {
+			return nil, errors.Errorf("invalid contract address %v", v)
+		}
+
+		f.contracts[common.HexToAddress(v)] = true
+	}
+
+	if len(webhook.Topics) == 0 {
+		return f, nil
+	}
+
+	var topics [][]common.Hash
+	if err := json.Unmarshal([]byte(webhook.Topics), &topics); err != nil {
+		return nil, errors.WithMessage(err, "invalid topics")
+	}
+
+	if len(topics) > 4 {
+		return nil, errors.New("too many topics")
+	}
+
+	for _, hashes := range topics {
+		set := make(map[common.Hash]bool, len(hashes))
+		for _, h := range hashes {
+			set[h] = true
+		}
+
+		f.topics = append(f.topics, set)
+	}
+
+	return f, nil
+}
+
+func (f *filter) matches(log *types.Log) bool {
+	if len(f.contracts) > 0 && !f.contracts[log.Address] {
+		return false
+	}
+
+	if len(f.topics) > len(log.Topics) {
+		return false
+	}
+
+	for i, set := range f.topics {
+		if len(set) > 0 && !set[log.Topics[i]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateWebhook validates the callback URL and event log filter of webhook.
+func ValidateWebhook(webhook *mysql.Webhook) error {
+	u, err := url.Parse(webhook.Url)
+	if err != nil {
+		return errors.WithMessage(err, "invalid callback URL")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("unsupported callback URL scheme %v", u.Scheme)
+	}
+
+	_, err = newFilter(webhook)
+	return err
+}
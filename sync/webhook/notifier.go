@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ mysql.EpochDataObserver = (*Notifier)(nil)
+
+// webhook notifier configurations
+type Config struct {
+	// whether to push matched event logs to the registered webhooks
+	Enabled bool
+	// interval to poll the pending deliveries from store
+	PollInterval time.Duration `default:"1s"`
+	// max number of pending deliveries to poll each time
+	BatchSize int `default:"500"`
+	// timeout of each HTTP callback
+	Timeout time.Duration `default:"10s"`
+	// exponential backoff to retry the failed delivery
+	MinBackoff time.Duration `default:"1s"`
+	MaxBackoff time.Duration `default:"10m"`
+	// max number of event logs per delivery
+	MaxLogsPerDelivery int `default:"1000"`
+	// duration to keep the delivered deliveries
+	Retention time.Duration `default:"72h"`
+}
+
+// Store persists the webhooks along with the deliveries as outbox.
+type Store interface {
+	LoadWebhooks() ([]*mysql.Webhook, error)
+	AddWebhookDeliveries(dbTx *gorm.DB, deliveries []*mysql.WebhookDelivery) error
+	RevokeWebhookDeliveries(dbTx *gorm.DB, bnFrom uint64) ([]uint32, error)
+	LoadPendingWebhookDeliveries(limit int) ([]*mysql.WebhookDelivery, error)
+	MarkWebhookDelivered(id uint64, deliveredAt time.Time) error
+	RetryWebhookDelivery(id uint64, nextAttemptAt time.Time, deliveryErr string) error
+	PruneWebhookDeliveries(before time.Time) (int64, error)
+}
+
+// Notification payload pushed to the webhook callback URL.
+type Notification struct {
+	// notification type, `logs` for the matched event logs, or `revoke` for the event logs of
+	// reverted blocks due to chain reorg, which should be discarded by receiver.
+	Type      string      `json:"type"`
+	Webhook   string      `json:"webhook"`
+	FromBlock uint64      `json:"fromBlock"`
+	ToBlock   uint64      `json:"toBlock"`
+	Logs      []types.Log `json:"logs,omitempty"`
+}
+
+// Notifier pushes the matched event logs of evm space to the registered webhooks as blocks are
+// synced into store.
+//
+// Notifications are persisted as outbox within the same db transaction of synced block data, and
+// then delivered in order per webhook with retries, so that they will be delivered at least once.
+// Besides, a revocation notice will be delivered if the blocks are reverted due to chain reorg.
+type Notifier struct {
+	conf  Config
+	store Store
+}
+
+// MustNewNotifierFromViper creates webhook notifier from viper settings, or returns nil if disabled.
+func MustNewNotifierFromViper(store Store) (*Notifier, bool) {
+	var conf Config
+	viper.MustUnmarshalKey("sync.webhook", &conf)
+
+	if !conf.Enabled || util.IsInterfaceValNil(store) {
+		return nil, false
+	}
+
+	return NewNotifier(conf, store), true
+}
+
+func NewNotifier(conf Config, store Store) *Notifier {
+	return &Notifier{conf: conf, store: store}
+}
+
+// OnEpochDataPushed implements the `mysql.EpochDataObserver` interface to enqueue deliveries of
+// the matched event logs.
+func (n *Notifier) OnEpochDataPushed(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	webhooks, err := n.store.LoadWebhooks()
+	if err != nil || len(webhooks) == 0 {
+		return errors.WithMessage(err, "failed to load webhooks")
+	}
+
+	logs := extractEthLogs(dataSlice)
+	if len(logs) == 0 {
+		return nil
+	}
+
+	var deliveries []*mysql.WebhookDelivery
+
+	for _, webhook := range webhooks {
+		f, err := newFilter(webhook)
+		if err != nil {
+			logrus.WithField("webhook", webhook.Name).WithError(err).Warn("Invalid webhook filter")
+			continue
+		}
+
+		var matched []types.Log
+		for i := range logs {
+			if f.matches(&logs[i]) {
+				matched = append(matched, logs[i])
+			}
+		}
+
+		for len(matched) > 0 {
+			size := len(matched)
+			if n.conf.MaxLogsPerDelivery > 0 && size > n.conf.MaxLogsPerDelivery {
+				size = n.conf.MaxLogsPerDelivery
+			}
+
+			delivery, err := newDelivery(webhook, mysql.WebhookDeliveryLogs, &Notification{
+				FromBlock: matched[0].BlockNumber,
+				ToBlock:   matched[size-1].BlockNumber,
+				Logs:      matched[:size],
+			})
+			if err != nil {
+				return err
+			}
+
+			deliveries = append(deliveries, delivery)
+			matched = matched[size:]
+		}
+	}
+
+	return n.store.AddWebhookDeliveries(dbTx, deliveries)
+}
+
+// OnEpochDataPopped implements the `mysql.EpochDataObserver` interface to revoke the undelivered
+// event logs of reverted blocks, and enqueue revocation notices for the delivered ones.
+func (n *Notifier) OnEpochDataPopped(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	webhookIds, err := n.store.RevokeWebhookDeliveries(dbTx, epochFrom)
+	if err != nil || len(webhookIds) == 0 {
+		return errors.WithMessage(err, "failed to revoke webhook deliveries")
+	}
+
+	webhooks, err := n.store.LoadWebhooks()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load webhooks")
+	}
+
+	revoked := make(map[uint32]bool, len(webhookIds))
+	for _, id := range webhookIds {
+		revoked[id] = true
+	}
+
+	var deliveries []*mysql.WebhookDelivery
+
+	for _, webhook := range webhooks {
+		if !revoked[webhook.ID] {
+			continue
+		}
+
+		delivery, err := newDelivery(webhook, mysql.WebhookDeliveryRevoke, &Notification{
+			FromBlock: epochFrom,
+			ToBlock:   epochTo,
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	metrics.Registry.Sync.WebhookRevocations().Mark(int64(len(deliveries)))
+
+	return n.store.AddWebhookDeliveries(dbTx, deliveries)
+}
+
+func newDelivery(webhook *mysql.Webhook, kind string, notification *Notification) (*mysql.WebhookDelivery, error) {
+	notification.Type = kind
+	notification.Webhook = webhook.Name
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal webhook notification")
+	}
+
+	return &mysql.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Kind:          kind,
+		BnMin:         notification.FromBlock,
+		BnMax:         notification.ToBlock,
+		Payload:       payload,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// extractEthLogs extracts evm space event logs in order from the epoch data bridged from evm space.
+func extractEthLogs(dataSlice []*store.EpochData) (logs []types.Log) {
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			for _, tx := range block.Transactions {
+				receipt := data.Receipts[tx.Hash]
+
+				// skip transactions that unexecuted in block
+				if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+					continue
+				}
+
+				var rcptExt *store.ReceiptExtra
+				if len(data.ReceiptExts) > 0 {
+					rcptExt = data.ReceiptExts[tx.Hash]
+				}
+
+				for k := range receipt.Logs {
+					var logExt *store.LogExtra
+					if rcptExt != nil && k < len(rcptExt.LogExts) {
+						logExt = rcptExt.LogExts[k]
+					}
+
+					logs = append(logs, *ethbridge.ConvertLog(&receipt.Logs[k], logExt))
+				}
+			}
+		}
+	}
+
+	return logs
+}
@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockStore struct {
+	mu         sync.Mutex
+	webhooks   []*mysql.Webhook
+	deliveries []*mysql.WebhookDelivery
+}
+
+func (s *mockStore) LoadWebhooks() ([]*mysql.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *mockStore) AddWebhookDeliveries(dbTx *gorm.DB, deliveries []*mysql.WebhookDelivery) error {
+	for _, d := range deliveries {
+		d.ID = uint64(len(s.deliveries) + 1)
+		s.deliveries = append(s.deliveries, d)
+	}
+
+	return nil
+}
+
+func (s *mockStore) RevokeWebhookDeliveries(dbTx *gorm.DB, bnFrom uint64) ([]uint32, error) {
+	return nil, nil
+}
+
+func (s *mockStore) LoadPendingWebhookDeliveries(limit int) (res []*mysql.WebhookDelivery, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// webhook id => whether the earliest undelivered delivery is due
+	ready := make(map[uint32]bool)
+
+	for _, d := range s.deliveries {
+		if d.DeliveredAt != nil {
+			continue
+		}
+
+		if _, ok := ready[d.WebhookID]; !ok {
+			ready[d.WebhookID] = !d.NextAttemptAt.After(time.Now())
+		}
+
+		if ready[d.WebhookID] && (limit <= 0 || len(res) < limit) {
+			dcopy := *d
+			res = append(res, &dcopy)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *mockStore) MarkWebhookDelivered(id uint64, deliveredAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[id-1].Attempts++
+	s.deliveries[id-1].DeliveredAt = &deliveredAt
+	return nil
+}
+
+func (s *mockStore) RetryWebhookDelivery(id uint64, nextAttemptAt time.Time, deliveryErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[id-1].Attempts++
+	s.deliveries[id-1].NextAttemptAt = nextAttemptAt
+	s.deliveries[id-1].LastError = deliveryErr
+	return nil
+}
+
+func (s *mockStore) PruneWebhookDeliveries(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestFilterMatches(t *testing.T) {
+	addr1 := common.HexToAddress("0x1")
+	addr2 := common.HexToAddress("0x2")
+	topicA := common.HexToHash("0xa")
+	topicB := common.HexToHash("0xb")
+
+	f, err := newFilter(&mysql.Webhook{
+		Contracts: addr1.Hex() + ", " + addr2.Hex(),
+		Topics:    `[["` + topicA.Hex() + `"],[],["` + topicA.Hex() + `","` + topicB.Hex() + `"]]`,
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, f.matches(&types.Log{Address: addr1, Topics: []common.Hash{topicA, topicB, topicB}}))
+	assert.True(t, f.matches(&types.Log{Address: addr2, Topics: []common.Hash{topicA, topicA, topicA, topicB}}))
+	assert.False(t, f.matches(&types.Log{Address: common.HexToAddress("0x3"), Topics: []common.Hash{topicA, topicB, topicB}}))
+	assert.False(t, f.matches(&types.Log{Address: addr1, Topics: []common.Hash{topicB, topicB, topicB}}))
+	assert.False(t, f.matches(&types.Log{Address: addr1, Topics: []common.Hash{topicA, topicB}}))
+
+	// match any
+	f, err = newFilter(&mysql.Webhook{})
+	assert.NoError(t, err)
+	assert.True(t, f.matches(&types.Log{Address: addr1}))
+
+	assert.Error(t, ValidateWebhook(&mysql.Webhook{Url: "ftp://localhost"}))
+	assert.Error(t, ValidateWebhook(&mysql.Webhook{Url: "http://localhost", Contracts: "0xinvalid"}))
+	assert.Error(t, ValidateWebhook(&mysql.Webhook{Url: "http://localhost", Topics: "[[],[],[],[],[]]"}))
+	assert.NoError(t, ValidateWebhook(&mysql.Webhook{Url: "http://localhost", Topics: "[[]]"}))
+}
+
+func TestDispatchInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+	failures := 1
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+Sign("secret", body), r.Header.Get(HeaderSignature))
+
+		var notification Notification
+		assert.NoError(t, json.Unmarshal(body, &notification))
+		received = append(received, notification)
+	}))
+	defer server.Close()
+
+	webhook := &mysql.Webhook{ID: 1, Name: "test", Url: server.URL, Secret: "secret"}
+	store := &mockStore{webhooks: []*mysql.Webhook{webhook}}
+	n := NewNotifier(Config{Timeout: time.Second, MinBackoff: 0, MaxBackoff: 0}, store)
+
+	for _, kind := range []string{mysql.WebhookDeliveryLogs, mysql.WebhookDeliveryRevoke} {
+		d, err := newDelivery(webhook, kind, &Notification{FromBlock: 10, ToBlock: 10})
+		assert.NoError(t, err)
+		store.AddWebhookDeliveries(nil, []*mysql.WebhookDelivery{d})
+	}
+
+	// the first delivery failed, and the rest blocked
+	assert.NoError(t, n.dispatchOnce(context.Background()))
+	assert.Empty(t, received)
+	assert.Equal(t, uint32(1), store.deliveries[0].Attempts)
+	assert.Equal(t, uint32(0), store.deliveries[1].Attempts)
+
+	// retried in order
+	assert.NoError(t, n.dispatchOnce(context.Background()))
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, mysql.WebhookDeliveryLogs, received[0].Type)
+	assert.Equal(t, mysql.WebhookDeliveryRevoke, received[1].Type)
+	assert.Equal(t, "test", received[1].Webhook)
+}
+
+func TestDispatchNotBlockedByBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var notification Notification
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &notification))
+		received = append(received, notification)
+	}))
+	defer server.Close()
+
+	blocked := &mysql.Webhook{ID: 1, Name: "blocked", Url: server.URL}
+	webhook := &mysql.Webhook{ID: 2, Name: "test", Url: server.URL}
+	store := &mockStore{webhooks: []*mysql.Webhook{blocked, webhook}}
+	n := NewNotifier(Config{Timeout: time.Second, BatchSize: 2}, store)
+
+	for _, w := range []*mysql.Webhook{blocked, blocked, webhook} {
+		d, err := newDelivery(w, mysql.WebhookDeliveryLogs, &Notification{FromBlock: 10, ToBlock: 10})
+		assert.NoError(t, err)
+		store.AddWebhookDeliveries(nil, []*mysql.WebhookDelivery{d})
+	}
+
+	// the earliest delivery of webhook in backoff
+	store.RetryWebhookDelivery(1, time.Now().Add(time.Hour), "server error")
+
+	assert.NoError(t, n.dispatchOnce(context.Background()))
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, "test", received[0].Webhook)
+	assert.Equal(t, uint32(0), store.deliveries[1].Attempts)
+}
+
+func TestBackoff(t *testing.T) {
+	n := NewNotifier(Config{MinBackoff: time.Second, MaxBackoff: time.Minute}, nil)
+
+	assert.Equal(t, time.Second, n.backoff(0))
+	assert.Equal(t, 4*time.Second, n.backoff(2))
+	assert.Equal(t, time.Minute, n.backoff(10))
+	assert.Equal(t, time.Minute, n.backoff(1000))
+}
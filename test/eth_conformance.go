@@ -0,0 +1,480 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// conformance test result status
+	ConformanceStatusPass        = "pass"
+	ConformanceStatusFail        = "fail"
+	ConformanceStatusUnsupported = "unsupported"
+	ConformanceStatusSkip        = "skip"
+
+	// JSON-RPC error code for method not found
+	errCodeMethodNotFound = -32601
+)
+
+// EthConformanceConfig conformance test config provided to EthConformanceRunner
+type EthConformanceConfig struct {
+	RpcEndpoint    string        // JSON-RPC endpoint to be tested against
+	VectorsDir     string        // directory of execution-apis test vectors (`*.io` files), optional
+	StrictVectors  bool          // whether to compare the result of test vectors strictly
+	ReportFile     string        // file path to write the JSON compatibility report, optional
+	RequestTimeout time.Duration // timeout for each JSON-RPC request
+}
+
+// ConformanceResult result of a single conformance test case.
+type ConformanceResult struct {
+	Case    string        `json:"case"`
+	Method  string        `json:"method"`
+	Source  string        `json:"source"` // "builtin" or test vector file
+	Status  string        `json:"status"`
+	Reason  string        `json:"reason,omitempty"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// ConformanceReport compatibility report of the JSON-RPC endpoint against Ethereum JSON-RPC spec.
+type ConformanceReport struct {
+	Endpoint    string              `json:"endpoint"`
+	StartedAt   time.Time           `json:"startedAt"`
+	Total       int                 `json:"total"`
+	Passed      int                 `json:"passed"`
+	Failed      int                 `json:"failed"`
+	Unsupported int                 `json:"unsupported"`
+	Skipped     int                 `json:"skipped"`
+	Results     []ConformanceResult `json:"results"`
+}
+
+func (report *ConformanceReport) add(result ConformanceResult) {
+	report.Total++
+
+	switch result.Status {
+	case ConformanceStatusPass:
+		report.Passed++
+	case ConformanceStatusFail:
+		report.Failed++
+	case ConformanceStatusUnsupported:
+		report.Unsupported++
+	case ConformanceStatusSkip:
+		report.Skipped++
+	}
+
+	report.Results = append(report.Results, result)
+}
+
+// jsonRpcError JSON-RPC error object.
+type jsonRpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *jsonRpcError) Error() string {
+	return fmt.Sprintf("%v (code %v)", e.Message, e.Code)
+}
+
+// jsonRpcResponse JSON-RPC response object.
+type jsonRpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRpcError   `json:"error"`
+}
+
+// EthConformanceRunner runs builtin conformance test cases (including Conflux eSpace specific cases)
+// along with the execution-apis test vectors if provided against the evm space JSON-RPC endpoint,
+// and produces a compatibility report.
+type EthConformanceRunner struct {
+	conf   *EthConformanceConfig
+	client *http.Client
+}
+
+func NewEthConformanceRunner(conf *EthConformanceConfig) *EthConformanceRunner {
+	return &EthConformanceRunner{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.RequestTimeout},
+	}
+}
+
+// Run runs all the conformance test cases and returns the compatibility report.
+func (runner *EthConformanceRunner) Run(ctx context.Context) (*ConformanceReport, error) {
+	report := &ConformanceReport{
+		Endpoint:  runner.conf.RpcEndpoint,
+		StartedAt: time.Now(),
+		Results:   []ConformanceResult{},
+	}
+
+	env, err := runner.prepareEnv(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to prepare conformance test env")
+	}
+
+	for _, tc := range ethConformanceCases {
+		report.add(runner.runCase(ctx, env, tc))
+	}
+
+	if len(runner.conf.VectorsDir) > 0 {
+		vectors, err := loadConformanceVectors(runner.conf.VectorsDir)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load test vectors")
+		}
+
+		for _, v := range vectors {
+			report.add(runner.runVector(ctx, v))
+		}
+	}
+
+	if len(runner.conf.ReportFile) > 0 {
+		if err := writeConformanceReport(runner.conf.ReportFile, report); err != nil {
+			return report, errors.WithMessage(err, "failed to write report")
+		}
+	}
+
+	return report, nil
+}
+
+// runCase runs the builtin conformance test case.
+func (runner *EthConformanceRunner) runCase(
+	ctx context.Context, env *conformanceEnv, tc ethConformanceCase,
+) ConformanceResult {
+	result := ConformanceResult{Case: tc.name, Method: tc.method, Source: "builtin"}
+
+	params, err := tc.params(env)
+	if err != nil {
+		result.Status, result.Reason = ConformanceStatusSkip, err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := runner.call(ctx, tc.method, params...)
+	result.Elapsed = time.Since(start)
+
+	switch {
+	case err != nil:
+		result.Status, result.Reason = ConformanceStatusFail, err.Error()
+	case resp.Error != nil && resp.Error.Code == errCodeMethodNotFound && !tc.expectError:
+		result.Status, result.Reason = ConformanceStatusUnsupported, resp.Error.Error()
+	default:
+		if err := tc.check(env, resp); err != nil {
+			result.Status, result.Reason = ConformanceStatusFail, err.Error()
+		} else {
+			result.Status = ConformanceStatusPass
+		}
+	}
+
+	return result
+}
+
+// runVector runs the execution-apis test vector.
+func (runner *EthConformanceRunner) runVector(ctx context.Context, v *conformanceVector) ConformanceResult {
+	result := ConformanceResult{Case: v.name, Method: v.method, Source: v.file}
+
+	start := time.Now()
+	resp, err := runner.post(ctx, v.request)
+	result.Elapsed = time.Since(start)
+
+	if err != nil {
+		result.Status, result.Reason = ConformanceStatusFail, err.Error()
+		return result
+	}
+
+	var actual jsonRpcResponse
+	if err := json.Unmarshal(resp, &actual); err != nil {
+		result.Status, result.Reason = ConformanceStatusFail, "malformed response: "+err.Error()
+		return result
+	}
+
+	if v.response.Error == nil && actual.Error != nil && actual.Error.Code == errCodeMethodNotFound {
+		result.Status, result.Reason = ConformanceStatusUnsupported, actual.Error.Error()
+		return result
+	}
+
+	if err := compareVectorResponse(&v.response, &actual, runner.conf.StrictVectors); err != nil {
+		result.Status, result.Reason = ConformanceStatusFail, err.Error()
+	} else {
+		result.Status = ConformanceStatusPass
+	}
+
+	return result
+}
+
+// call sends JSON-RPC request with the method and params.
+func (runner *EthConformanceRunner) call(
+	ctx context.Context, method string, params ...interface{},
+) (*jsonRpcResponse, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	req, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := runner.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp jsonRpcResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, errors.WithMessage(err, "malformed response")
+	}
+
+	return &resp, nil
+}
+
+// post sends the raw JSON-RPC request and returns the raw response.
+func (runner *EthConformanceRunner) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, runner.conf.RpcEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := runner.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected http status %v: %v", resp.StatusCode, string(data))
+	}
+
+	return data, nil
+}
+
+// conformanceVector test vector of execution-apis, which is in format of:
+//
+//	// comments
+//	>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+//	<< {"jsonrpc":"2.0","id":1,"result":"0xc72dd9d5e883e"}
+type conformanceVector struct {
+	file     string
+	name     string
+	method   string
+	request  []byte
+	response jsonRpcResponse
+}
+
+// loadConformanceVectors loads all the test vectors (`*.io` files) under the directory recursively.
+func loadConformanceVectors(dir string) ([]*conformanceVector, error) {
+	var vectors []*conformanceVector
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".io" {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		rel, _ := filepath.Rel(dir, path)
+
+		fvectors, err := parseConformanceVectors(rel, file)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid test vector file %v", rel)
+		}
+
+		vectors = append(vectors, fvectors...)
+		return nil
+	})
+
+	sort.SliceStable(vectors, func(i, j int) bool {
+		return vectors[i].file < vectors[j].file
+	})
+
+	return vectors, err
+}
+
+func parseConformanceVectors(file string, r io.Reader) ([]*conformanceVector, error) {
+	var vectors []*conformanceVector
+	var pending *conformanceVector
+
+	name := strings.TrimSuffix(filepath.Base(file), ".io")
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, ">>"):
+			req := []byte(strings.TrimSpace(strings.TrimPrefix(line, ">>")))
+
+			var call struct{ Method string }
+			if err := json.Unmarshal(req, &call); err != nil {
+				return nil, errors.WithMessage(err, "malformed request")
+			}
+
+			pending = &conformanceVector{file: file, name: name, method: call.Method, request: req}
+		case strings.HasPrefix(line, "<<"):
+			if pending == nil {
+				return nil, errors.New("response without request")
+			}
+
+			resp := []byte(strings.TrimSpace(strings.TrimPrefix(line, "<<")))
+			if err := json.Unmarshal(resp, &pending.response); err != nil {
+				return nil, errors.WithMessage(err, "malformed response")
+			}
+
+			vectors = append(vectors, pending)
+			pending = nil
+		}
+	}
+
+	return vectors, scanner.Err()
+}
+
+// compareVectorResponse compares the actual response with the expected one of test vector.
+//
+// Since the chain data of gateway differs from the one test vectors generated against, only the
+// response shape (result or error, JSON type and object fields) is compared unless in strict mode.
+func compareVectorResponse(expected, actual *jsonRpcResponse, strict bool) error {
+	if expected.Error != nil {
+		if actual.Error == nil {
+			return errors.Errorf("error expected but got result %v", string(actual.Result))
+		}
+
+		return nil
+	}
+
+	if actual.Error != nil {
+		return errors.Errorf("result expected but got error %v", actual.Error)
+	}
+
+	var ev, av interface{}
+	if err := json.Unmarshal(expected.Result, &ev); err != nil {
+		return errors.WithMessage(err, "malformed expected result")
+	}
+
+	if err := json.Unmarshal(actual.Result, &av); err != nil {
+		return errors.WithMessage(err, "malformed actual result")
+	}
+
+	if strict {
+		if !jsonEqual(ev, av) {
+			return errors.Errorf("result mismatched, expected %v but got %v", string(expected.Result), string(actual.Result))
+		}
+
+		return nil
+	}
+
+	return compareJsonShape("result", ev, av)
+}
+
+// compareJsonShape compares the JSON type and object fields recursively.
+func compareJsonShape(path string, expected, actual interface{}) error {
+	// null is allowed for any value, e.g., block not found on the gateway chain
+	if expected == nil || actual == nil {
+		return nil
+	}
+
+	switch ev := expected.(type) {
+	case map[string]interface{}:
+		av, ok := actual.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%v: object expected but got %T", path, actual)
+		}
+
+		for k := range ev {
+			if _, ok := av[k]; !ok {
+				return errors.Errorf("%v.%v: field missing", path, k)
+			}
+
+			if err := compareJsonShape(path+"."+k, ev[k], av[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		av, ok := actual.([]interface{})
+		if !ok {
+			return errors.Errorf("%v: array expected but got %T", path, actual)
+		}
+
+		// only the first element is compared since the length might differ
+		if len(ev) > 0 && len(av) > 0 {
+			return compareJsonShape(path+"[0]", ev[0], av[0])
+		}
+	default:
+		if fmt.Sprintf("%T", expected) != fmt.Sprintf("%T", actual) {
+			return errors.Errorf("%v: %T expected but got %T", path, expected, actual)
+		}
+	}
+
+	return nil
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+func writeConformanceReport(path string, report *ConformanceReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LogConformanceReport prints the compatibility report.
+func LogConformanceReport(report *ConformanceReport) {
+	for _, r := range report.Results {
+		logger := logrus.WithFields(logrus.Fields{
+			"method":  r.Method,
+			"source":  r.Source,
+			"elapsed": r.Elapsed,
+		})
+
+		if len(r.Reason) > 0 {
+			logger = logger.WithField("reason", r.Reason)
+		}
+
+		switch r.Status {
+		case ConformanceStatusFail:
+			logger.Warnf("[%v] %v", r.Status, r.Case)
+		default:
+			logger.Infof("[%v] %v", r.Status, r.Case)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"endpoint":    report.Endpoint,
+		"total":       report.Total,
+		"passed":      report.Passed,
+		"failed":      report.Failed,
+		"unsupported": report.Unsupported,
+		"skipped":     report.Skipped,
+	}).Info("Conformance test completed")
+}
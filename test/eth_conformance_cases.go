@@ -0,0 +1,548 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of blocks to scan backward for a sample block with transactions
+	maxConformanceSampleScan = 200
+
+	// JSON-RPC error code for invalid params
+	errCodeInvalidParams = -32602
+
+	unknownHash     = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	zeroAddress     = "0x0000000000000000000000000000000000000000"
+	crossSpaceCall  = "0x0888000000000000000000000000000000000006" // Conflux internal contract
+	farFutureNumber = "0x7fffffffffffffff"
+)
+
+var (
+	errNoSampleBlock = errors.New("no sample block with transactions found")
+
+	quantityRegexp = regexp.MustCompile(`^0x(0|[1-9a-f][0-9a-f]*)$`)
+	dataRegexp     = regexp.MustCompile(`^0x([0-9a-f]{2})*$`)
+	addressRegexp  = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+	// required fields of block, transaction, receipt and log objects by spec
+	blockFields = map[string]func(interface{}) error{
+		"number": isQuantity, "hash": isHash, "parentHash": isHash, "nonce": isData,
+		"sha3Uncles": isHash, "logsBloom": isData, "transactionsRoot": isHash, "stateRoot": isHash,
+		"receiptsRoot": isHash, "miner": isAddress, "difficulty": isQuantity, "extraData": isData,
+		"size": isQuantity, "gasLimit": isQuantity, "gasUsed": isQuantity, "timestamp": isQuantity,
+		"transactions": isArray, "uncles": isArray,
+	}
+	txFields = map[string]func(interface{}) error{
+		"blockHash": isHash, "blockNumber": isQuantity, "from": isAddress, "gas": isQuantity,
+		"gasPrice": isQuantity, "hash": isHash, "input": isData, "nonce": isQuantity,
+		"transactionIndex": isQuantity, "value": isQuantity, "v": isQuantity, "r": isQuantity,
+		"s": isQuantity,
+	}
+	receiptFields = map[string]func(interface{}) error{
+		"transactionHash": isHash, "transactionIndex": isQuantity, "blockHash": isHash,
+		"blockNumber": isQuantity, "from": isAddress, "cumulativeGasUsed": isQuantity,
+		"gasUsed": isQuantity, "logs": isArray, "logsBloom": isData, "status": isQuantity,
+	}
+	logFields = map[string]func(interface{}) error{
+		"address": isAddress, "topics": isArray, "data": isData, "blockNumber": isQuantity,
+		"transactionHash": isHash, "transactionIndex": isQuantity, "blockHash": isHash,
+		"logIndex": isQuantity, "removed": isBool,
+	}
+	feeHistoryFields = map[string]func(interface{}) error{
+		"oldestBlock": isQuantity, "baseFeePerGas": isArray, "gasUsedRatio": isArray,
+	}
+)
+
+// conformanceEnv chain state sampled from the JSON-RPC endpoint to build the test cases.
+type conformanceEnv struct {
+	latestBlock uint64
+	sampleTx    map[string]interface{} // the first transaction of the latest block with transactions
+}
+
+func (env *conformanceEnv) sample(field string) (string, error) {
+	if env.sampleTx == nil {
+		return "", errNoSampleBlock
+	}
+
+	if v, ok := env.sampleTx[field].(string); ok {
+		return v, nil
+	}
+
+	return "", errors.Errorf("field %v missing in sample transaction", field)
+}
+
+// ethConformanceCase builtin conformance test case.
+type ethConformanceCase struct {
+	name        string
+	method      string
+	expectError bool // whether error is expected, e.g., method not found
+	params      func(env *conformanceEnv) ([]interface{}, error)
+	check       func(env *conformanceEnv, resp *jsonRpcResponse) error
+}
+
+// prepareEnv samples the chain state from the JSON-RPC endpoint, e.g., latest block number and
+// the latest block with transactions.
+func (runner *EthConformanceRunner) prepareEnv(ctx context.Context) (*conformanceEnv, error) {
+	var env conformanceEnv
+
+	resp, err := runner.call(ctx, "eth_blockNumber")
+	if err != nil {
+		return nil, err
+	}
+
+	var latest string
+	if err := decodeResult(resp, &latest); err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	if env.latestBlock, err = strconv.ParseUint(latest, 0, 64); err != nil {
+		return nil, errors.WithMessage(err, "invalid latest block number")
+	}
+
+	for i := uint64(0); i < maxConformanceSampleScan && i <= env.latestBlock; i++ {
+		bn := fmt.Sprintf("0x%x", env.latestBlock-i)
+
+		resp, err := runner.call(ctx, "eth_getBlockByNumber", bn, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var block map[string]interface{}
+		if err := decodeResult(resp, &block); err != nil {
+			return nil, errors.WithMessagef(err, "failed to get block %v", bn)
+		}
+
+		if txs, ok := block["transactions"].([]interface{}); ok && len(txs) > 0 {
+			env.sampleTx, _ = txs[0].(map[string]interface{})
+			break
+		}
+	}
+
+	return &env, nil
+}
+
+// ethConformanceCases builtin conformance test cases, including Conflux eSpace specific cases.
+var ethConformanceCases = []ethConformanceCase{
+	{
+		name: "chain id", method: "eth_chainId",
+		params: noParams, check: expectResult(isQuantity),
+	},
+	{
+		name: "network id", method: "net_version",
+		params: noParams, check: expectResult(isDecimal),
+	},
+	{
+		name: "client version", method: "web3_clientVersion",
+		params: noParams, check: expectResult(isString),
+	},
+	{
+		name: "latest block number", method: "eth_blockNumber",
+		params: noParams, check: expectResult(isQuantity),
+	},
+	{
+		name: "gas price", method: "eth_gasPrice",
+		params: noParams, check: expectResult(isQuantity),
+	},
+	{
+		name: "max priority fee per gas", method: "eth_maxPriorityFeePerGas",
+		params: noParams, check: expectResult(isQuantity),
+	},
+	{
+		name: "syncing status", method: "eth_syncing",
+		params: noParams, check: expectResult(isBoolOrObject),
+	},
+	{
+		name: "fee history", method: "eth_feeHistory",
+		params: staticParams("0x4", "latest", []int{25, 75}),
+		check:  expectResult(isObject(feeHistoryFields)),
+	},
+	{
+		name: "latest block with tx hashes", method: "eth_getBlockByNumber",
+		params: staticParams("latest", false), check: expectResult(isObject(blockFields)),
+	},
+	{
+		name: "earliest block", method: "eth_getBlockByNumber",
+		params: staticParams("earliest", false), check: expectResult(isObject(blockFields)),
+	},
+	{
+		name: "safe block (eSpace: latest confirmed epoch)", method: "eth_getBlockByNumber",
+		params: staticParams("safe", false), check: expectResult(isObject(blockFields)),
+	},
+	{
+		name: "finalized block (eSpace: latest finalized epoch)", method: "eth_getBlockByNumber",
+		params: staticParams("finalized", false), check: expectResult(isObject(blockFields)),
+	},
+	{
+		name: "future block not found", method: "eth_getBlockByNumber",
+		params: staticParams(farFutureNumber, false), check: expectNull,
+	},
+	{
+		name: "block with full transactions", method: "eth_getBlockByNumber",
+		params: sampleParams("blockNumber", true),
+		check:  expectResult(isObject(blockFields), isFirstTx(txFields)),
+	},
+	{
+		name: "block by hash", method: "eth_getBlockByHash",
+		params: sampleParams("blockHash", false),
+		check:  expectResult(isObject(blockFields), matchSample("hash", "blockHash")),
+	},
+	{
+		name: "unknown block by hash", method: "eth_getBlockByHash",
+		params: staticParams(unknownHash, false), check: expectNull,
+	},
+	{
+		name: "block transaction count", method: "eth_getBlockTransactionCountByNumber",
+		params: sampleParams("blockNumber"), check: expectResult(isQuantity),
+	},
+	{
+		name: "uncle count (eSpace: always zero)", method: "eth_getUncleCountByBlockNumber",
+		params: staticParams("latest"), check: expectResult(isZero),
+	},
+	{
+		name: "uncle by block number (eSpace: always null)", method: "eth_getUncleByBlockNumberAndIndex",
+		params: staticParams("latest", "0x0"), check: expectNull,
+	},
+	{
+		name: "transaction by hash", method: "eth_getTransactionByHash",
+		params: sampleParams("hash"),
+		check:  expectResult(isObject(txFields), matchSample("hash", "hash")),
+	},
+	{
+		name: "unknown transaction by hash", method: "eth_getTransactionByHash",
+		params: staticParams(unknownHash), check: expectNull,
+	},
+	{
+		name: "transaction receipt", method: "eth_getTransactionReceipt",
+		params: sampleParams("hash"),
+		check:  expectResult(isObject(receiptFields), matchSample("transactionHash", "hash")),
+	},
+	{
+		name: "unknown transaction receipt", method: "eth_getTransactionReceipt",
+		params: staticParams(unknownHash), check: expectNull,
+	},
+	{
+		name: "block receipts", method: "eth_getBlockReceipts",
+		params: sampleParams("blockNumber"), check: expectResult(isArrayOf(receiptFields)),
+	},
+	{
+		name: "balance", method: "eth_getBalance",
+		params: sampleParams("from", "latest"), check: expectResult(isQuantity),
+	},
+	{
+		name: "transaction count", method: "eth_getTransactionCount",
+		params: sampleParams("from", "latest"), check: expectResult(isQuantity),
+	},
+	{
+		name: "pending transaction count", method: "eth_getTransactionCount",
+		params: sampleParams("from", "pending"), check: expectResult(isQuantity),
+	},
+	{
+		name: "code of internal contract (eSpace: CrossSpaceCall)", method: "eth_getCode",
+		params: staticParams(crossSpaceCall, "latest"), check: expectResult(isData),
+	},
+	{
+		name: "storage at", method: "eth_getStorageAt",
+		params: staticParams(zeroAddress, "0x0", "latest"), check: expectResult(isData),
+	},
+	{
+		name: "call", method: "eth_call",
+		params: staticParams(map[string]string{"to": zeroAddress, "data": "0x"}, "latest"),
+		check:  expectResult(isData),
+	},
+	{
+		name: "estimate gas for transfer", method: "eth_estimateGas",
+		params: staticParams(map[string]string{"to": zeroAddress, "value": "0x0"}),
+		check:  expectResult(isQuantity),
+	},
+	{
+		name: "logs by block range", method: "eth_getLogs",
+		params: func(env *conformanceEnv) ([]interface{}, error) {
+			bn, err := env.sample("blockNumber")
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{map[string]string{"fromBlock": bn, "toBlock": bn}}, nil
+		},
+		check: expectResult(isArrayOf(logFields)),
+	},
+	{
+		name: "logs by block hash", method: "eth_getLogs",
+		params: func(env *conformanceEnv) ([]interface{}, error) {
+			hash, err := env.sample("blockHash")
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{map[string]string{"blockHash": hash}}, nil
+		},
+		check: expectResult(isArrayOf(logFields)),
+	},
+	{
+		name: "logs with invalid block range", method: "eth_getLogs",
+		params: staticParams(map[string]string{"fromBlock": "0x2", "toBlock": "0x1"}),
+		check:  expectError(0), expectError: true,
+	},
+	{
+		name: "send malformed raw transaction", method: "eth_sendRawTransaction",
+		params: staticParams("0x00"), check: expectError(0), expectError: true,
+	},
+	{
+		name: "invalid params", method: "eth_getBalance",
+		params: staticParams("0xinvalid", "latest"),
+		check:  expectError(errCodeInvalidParams), expectError: true,
+	},
+	{
+		name: "method not found", method: "eth_nonExistentMethod",
+		params: noParams, check: expectError(errCodeMethodNotFound), expectError: true,
+	},
+}
+
+func noParams(env *conformanceEnv) ([]interface{}, error) {
+	return nil, nil
+}
+
+func staticParams(params ...interface{}) func(env *conformanceEnv) ([]interface{}, error) {
+	return func(env *conformanceEnv) ([]interface{}, error) {
+		return params, nil
+	}
+}
+
+// sampleParams returns params with the first one as the specified field of sample transaction.
+func sampleParams(field string, rest ...interface{}) func(env *conformanceEnv) ([]interface{}, error) {
+	return func(env *conformanceEnv) ([]interface{}, error) {
+		v, err := env.sample(field)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]interface{}{v}, rest...), nil
+	}
+}
+
+func decodeResult(resp *jsonRpcResponse, v interface{}) error {
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return json.Unmarshal(resp.Result, v)
+}
+
+type resultChecker func(env *conformanceEnv, result interface{}) error
+
+// expectResult expects result to pass the validation.
+func expectResult(validate func(interface{}) error, checkers ...resultChecker) func(
+	env *conformanceEnv, resp *jsonRpcResponse) error {
+	return func(env *conformanceEnv, resp *jsonRpcResponse) error {
+		var result interface{}
+		if err := decodeResult(resp, &result); err != nil {
+			return err
+		}
+
+		if err := validate(result); err != nil {
+			return err
+		}
+
+		for _, check := range checkers {
+			if err := check(env, result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// expectNull expects null result.
+func expectNull(env *conformanceEnv, resp *jsonRpcResponse) error {
+	var result interface{}
+	if err := decodeResult(resp, &result); err != nil {
+		return err
+	}
+
+	if result != nil {
+		return errors.Errorf("null expected but got %v", string(resp.Result))
+	}
+
+	return nil
+}
+
+// expectError expects error with the specified code, or any code if 0.
+func expectError(code int) func(env *conformanceEnv, resp *jsonRpcResponse) error {
+	return func(env *conformanceEnv, resp *jsonRpcResponse) error {
+		if resp.Error == nil {
+			return errors.Errorf("error expected but got result %v", string(resp.Result))
+		}
+
+		if code != 0 && resp.Error.Code != code {
+			return errors.Errorf("error code %v expected but got %v", code, resp.Error)
+		}
+
+		return nil
+	}
+}
+
+// matchSample checks the result field equals to the specified field of sample transaction.
+func matchSample(field, sampleField string) resultChecker {
+	return func(env *conformanceEnv, result interface{}) error {
+		expected, err := env.sample(sampleField)
+		if err != nil {
+			return err
+		}
+
+		if actual := result.(map[string]interface{})[field]; actual != expected {
+			return errors.Errorf("%v mismatched, expected %v but got %v", field, expected, actual)
+		}
+
+		return nil
+	}
+}
+
+// isFirstTx checks the first transaction of block is full transaction object.
+func isFirstTx(fields map[string]func(interface{}) error) resultChecker {
+	return func(env *conformanceEnv, result interface{}) error {
+		txs, _ := result.(map[string]interface{})["transactions"].([]interface{})
+		if len(txs) == 0 {
+			return errors.New("transactions missing")
+		}
+
+		return errors.WithMessage(isObject(fields)(txs[0]), "transactions[0]")
+	}
+}
+
+func isObject(fields map[string]func(interface{}) error) func(interface{}) error {
+	return func(v interface{}) error {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("object expected but got %v", v)
+		}
+
+		for name, validate := range fields {
+			fv, ok := obj[name]
+			if !ok {
+				return errors.Errorf("field %v missing", name)
+			}
+
+			if err := validate(fv); err != nil {
+				return errors.WithMessagef(err, "field %v", name)
+			}
+		}
+
+		return nil
+	}
+}
+
+func isArrayOf(fields map[string]func(interface{}) error) func(interface{}) error {
+	return func(v interface{}) error {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return errors.Errorf("array expected but got %v", v)
+		}
+
+		for i := range arr {
+			if err := isObject(fields)(arr[i]); err != nil {
+				return errors.WithMessagef(err, "[%v]", i)
+			}
+		}
+
+		return nil
+	}
+}
+
+func isString(v interface{}) error {
+	if _, ok := v.(string); !ok {
+		return errors.Errorf("string expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isDecimal(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return errors.Errorf("decimal string expected but got %v", v)
+	}
+
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return errors.Errorf("decimal string expected but got %v", s)
+	}
+
+	return nil
+}
+
+func isQuantity(v interface{}) error {
+	if s, ok := v.(string); !ok || !quantityRegexp.MatchString(s) {
+		return errors.Errorf("hex quantity expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isZero(v interface{}) error {
+	if v != "0x0" {
+		return errors.Errorf("0x0 expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isData(v interface{}) error {
+	if s, ok := v.(string); !ok || !dataRegexp.MatchString(s) {
+		return errors.Errorf("hex data expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isFixedData(size int) func(interface{}) error {
+	return func(v interface{}) error {
+		if s, ok := v.(string); !ok || !dataRegexp.MatchString(s) || len(s) != 2+size*2 {
+			return errors.Errorf("%v bytes hex data expected but got %v", size, v)
+		}
+
+		return nil
+	}
+}
+
+func isHash(v interface{}) error {
+	return isFixedData(32)(v)
+}
+
+func isAddress(v interface{}) error {
+	if s, ok := v.(string); !ok || !addressRegexp.MatchString(s) {
+		return errors.Errorf("address expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isArray(v interface{}) error {
+	if _, ok := v.([]interface{}); !ok {
+		return errors.Errorf("array expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isBool(v interface{}) error {
+	if _, ok := v.(bool); !ok {
+		return errors.Errorf("bool expected but got %v", v)
+	}
+
+	return nil
+}
+
+func isBoolOrObject(v interface{}) error {
+	switch v.(type) {
+	case bool, map[string]interface{}:
+		return nil
+	}
+
+	return errors.Errorf("bool or object expected but got %v", v)
+}
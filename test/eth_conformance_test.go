@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConformanceVectors(t *testing.T) {
+	content := `// retrieves the client's current chain id
+>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+<< {"jsonrpc":"2.0","id":1,"result":"0xc72dd9d5e883e"}
+`
+
+	vectors, err := parseConformanceVectors("eth_chainId/get-chain-id.io", strings.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(vectors))
+
+	assert.Equal(t, "get-chain-id", vectors[0].name)
+	assert.Equal(t, "eth_chainId", vectors[0].method)
+	assert.Equal(t, `"0xc72dd9d5e883e"`, string(vectors[0].response.Result))
+
+	_, err = parseConformanceVectors("bad.io", strings.NewReader(`<< {"result":"0x1"}`))
+	assert.Error(t, err)
+}
+
+func TestCompareVectorResponse(t *testing.T) {
+	expected := jsonRpcResponse{Result: json.RawMessage(`{"number":"0x1","transactions":[{"hash":"0x01"}]}`)}
+
+	actual := jsonRpcResponse{Result: json.RawMessage(`{"number":"0x2","transactions":[{"hash":"0x02","extra":1}]}`)}
+	assert.NoError(t, compareVectorResponse(&expected, &actual, false))
+	assert.Error(t, compareVectorResponse(&expected, &actual, true))
+
+	// field missing
+	actual = jsonRpcResponse{Result: json.RawMessage(`{"number":"0x1","transactions":[{}]}`)}
+	assert.Error(t, compareVectorResponse(&expected, &actual, false))
+
+	// error expected
+	expected = jsonRpcResponse{Error: &jsonRpcError{Code: -32000}}
+	assert.Error(t, compareVectorResponse(&expected, &actual, false))
+}
+
+func TestEthConformanceRunner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var req struct{ Method string }
+		json.Unmarshal(body, &req)
+
+		switch req.Method {
+		case "eth_blockNumber", "eth_chainId":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+		case "eth_getBlockByNumber":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"transactions":[]}}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	runner := NewEthConformanceRunner(&EthConformanceConfig{RpcEndpoint: server.URL, RequestTimeout: time.Second})
+	report, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, len(ethConformanceCases), report.Total)
+	assert.Equal(t, report.Total, report.Passed+report.Failed+report.Unsupported+report.Skipped)
+
+	results := make(map[string]string)
+	for _, r := range report.Results {
+		results[r.Case] = r.Status
+	}
+
+	assert.Equal(t, ConformanceStatusPass, results["chain id"])
+	assert.Equal(t, ConformanceStatusPass, results["method not found"])
+	assert.Equal(t, ConformanceStatusUnsupported, results["gas price"])
+	assert.Equal(t, ConformanceStatusSkip, results["transaction by hash"])
+	assert.Equal(t, ConformanceStatusFail, results["latest block with tx hashes"])
+}
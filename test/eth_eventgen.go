@@ -0,0 +1,443 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/signers"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// event emitted by the synthetic test contract
+	eventGenSignature = "Generated(address,uint256,uint256)"
+
+	// gas limits to deploy test contract and emit each event
+	eventGenDeployGas   = 200000
+	eventGenBaseGas     = 50000
+	eventGenGasPerEvent = 2500
+)
+
+// eventGenTopic0 event hash of the synthetic test contract event.
+var eventGenTopic0 = crypto.Keccak256Hash([]byte(eventGenSignature))
+
+// eventEmitterCode returns the creation code of the synthetic test contract, which is hand
+// assembled to avoid solidity compiler dependency. The contract emits `n` events as below
+// for each call with calldata `abi.encode(uint256 n, uint256 seed)`:
+//
+//	event Generated(address indexed sender, uint256 indexed index, uint256 value);
+//	for (uint256 i = 0; i < n; i++) emit Generated(msg.sender, i, seed + i);
+func eventEmitterCode() []byte {
+	runtime := []byte{
+		0x60, 0x00, 0x35, // PUSH1 0, CALLDATALOAD => n
+		0x60, 0x00, // PUSH1 0 => i
+		0x5b,             // 0x05: JUMPDEST (loop)
+		0x81, 0x81, 0x10, // DUP2, DUP2, LT => i < n
+		0x15, 0x60, 0x43, 0x57, // ISZERO, PUSH1 0x43, JUMPI => jump to end if i >= n
+		0x60, 0x20, 0x35, // PUSH1 0x20, CALLDATALOAD => seed
+		0x81, 0x01, // DUP2, ADD => seed + i
+		0x60, 0x00, 0x52, // PUSH1 0, MSTORE => data
+		0x80, 0x33, // DUP1, CALLER => topic2 (index), topic1 (sender)
+		0x7f, // PUSH32 => topic0
+	}
+	runtime = append(runtime, eventGenTopic0.Bytes()...)
+	runtime = append(runtime,
+		0x60, 0x20, 0x60, 0x00, 0xa3, // PUSH1 0x20, PUSH1 0, LOG3
+		0x60, 0x01, 0x01, // PUSH1 1, ADD => i++
+		0x60, 0x05, 0x56, // PUSH1 0x05, JUMP => loop
+		0x5b, 0x00, // 0x43: JUMPDEST (end), STOP
+	)
+
+	// constructor to copy runtime code into memory and return
+	code := []byte{
+		0x60, byte(len(runtime)), 0x80, // PUSH1 len, DUP1
+		0x60, 0x0b, 0x60, 0x00, 0x39, // PUSH1 0x0b (runtime offset), PUSH1 0, CODECOPY
+		0x60, 0x00, 0xf3, // PUSH1 0, RETURN
+	}
+
+	return append(code, runtime...)
+}
+
+// eventEmitterCalldata returns the calldata for synthetic test contract to emit events.
+func eventEmitterCalldata(numEvents, seed uint64) []byte {
+	data := common.LeftPadBytes(new(big.Int).SetUint64(numEvents).Bytes(), 32)
+	return append(data, common.LeftPadBytes(new(big.Int).SetUint64(seed).Bytes(), 32)...)
+}
+
+// EthEventGenConfig configurations of evm space synthetic event generator for devnets.
+type EthEventGenConfig struct {
+	// fullnode rpc endpoint of devnet to send transactions
+	FullnodeRpcEndpoint string
+	// infura rpc endpoint to verify the generated event logs, and verification is disabled if empty
+	InfuraRpcEndpoint string
+	// private key of the funded account on devnet to send transactions
+	PrivateKey string
+	// existing test contracts to generate events, or deploy new ones if not specified
+	Contracts []string
+	// number of test contracts to deploy if no existing ones specified
+	NumContracts int `default:"3"`
+	// number of events emitted by each transaction
+	EventsPerTx uint64 `default:"10"`
+	// number of transactions sent in each round, which are distributed to test contracts evenly
+	TxsPerRound int `default:"10"`
+	// interval between rounds
+	Interval time.Duration `default:"1s"`
+	// number of rounds to generate events, and 0 to generate endlessly
+	Rounds int
+	// timeout to wait for transaction receipt
+	ReceiptTimeout time.Duration `default:"1m"`
+	// delay to verify event logs from infura after generated, so as to wait for sync
+	VerifyDelay time.Duration `default:"30s"`
+}
+
+// MustNewEthEventGenConfigFromViper loads event generator configurations from viper.
+func MustNewEthEventGenConfigFromViper() *EthEventGenConfig {
+	var conf EthEventGenConfig
+	viper.MustUnmarshalKey("eventGenerator", &conf)
+
+	return &conf
+}
+
+// eventGenBatch event logs generated by some contract in some block, to be verified from infura.
+type eventGenBatch struct {
+	contract    common.Address
+	blockNumber uint64
+	numLogs     int
+	generatedAt time.Time
+}
+
+// EthEventGenerator deploys synthetic test contracts on devnet and generates configurable volume
+// of event logs, so as to exercise the performance and correctness of event log ingestion and
+// query pipeline without mainnet data.
+type EthEventGenerator struct {
+	conf    *EthEventGenConfig
+	fn      *web3go.Client
+	infura  *web3go.Client // nil if verification disabled
+	signer  *signers.PrivateKeySigner
+	chainId *big.Int
+
+	nonce     uint64
+	contracts []common.Address
+	seed      uint64
+
+	verifyCh chan []eventGenBatch
+}
+
+func MustNewEthEventGenerator(conf *EthEventGenConfig) *EthEventGenerator {
+	fn, err := web3go.NewClient(conf.FullnodeRpcEndpoint)
+	if err != nil {
+		logrus.WithField("endpoint", conf.FullnodeRpcEndpoint).WithError(err).Fatal("Failed to new web3 client")
+	}
+
+	signer, err := signers.NewPrivateKeySignerByString(conf.PrivateKey)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create signer by private key")
+	}
+
+	chainId, err := fn.Eth.ChainId()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get chain id")
+	}
+
+	nonce, err := fn.Eth.TransactionCount(signer.Address(), nil)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get nonce of sender")
+	}
+
+	gen := &EthEventGenerator{
+		conf:     conf,
+		fn:       fn,
+		signer:   signer,
+		chainId:  new(big.Int).SetUint64(*chainId),
+		nonce:    nonce.Uint64(),
+		seed:     uint64(time.Now().Unix()),
+		verifyCh: make(chan []eventGenBatch, 1000),
+	}
+
+	if len(conf.InfuraRpcEndpoint) > 0 {
+		gen.infura, err = web3go.NewClient(conf.InfuraRpcEndpoint)
+		if err != nil {
+			logrus.WithField("endpoint", conf.InfuraRpcEndpoint).WithError(err).Fatal("Failed to new web3 client")
+		}
+	}
+
+	for _, addr := range conf.Contracts {
+		gen.contracts = append(gen.contracts, common.HexToAddress(addr))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"sender": signer.Address(), "chainId": *chainId, "nonce": gen.nonce,
+	}).Info("Event generator created")
+
+	return gen
+}
+
+func (gen *EthEventGenerator) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	if len(gen.contracts) == 0 {
+		if err := gen.deployContracts(ctx); err != nil {
+			logrus.WithError(err).Error("Event generator failed to deploy test contracts")
+			return
+		}
+	}
+
+	if gen.infura != nil {
+		go gen.verify(ctx)
+	}
+
+	ticker := time.NewTicker(gen.conf.Interval)
+	defer ticker.Stop()
+
+	for round := 1; gen.conf.Rounds == 0 || round <= gen.conf.Rounds; round++ {
+		start := time.Now()
+
+		batches, err := gen.generate(ctx)
+		if err != nil {
+			logrus.WithField("round", round).WithError(err).Error("Event generator failed to generate events")
+		} else {
+			gen.logRound(round, batches, time.Since(start))
+
+			if gen.infura != nil {
+				gen.verifyCh <- batches
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	logrus.WithField("rounds", gen.conf.Rounds).Info("Event generator completed")
+}
+
+func (gen *EthEventGenerator) logRound(round int, batches []eventGenBatch, elapsed time.Duration) {
+	var numLogs int
+	for i := range batches {
+		numLogs += batches[i].numLogs
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"round":   round,
+		"numLogs": numLogs,
+		"elapsed": elapsed,
+		"tps":     float64(gen.conf.TxsPerRound) / elapsed.Seconds(),
+	}).Info("Event generator round completed")
+}
+
+// deployContracts deploys synthetic test contracts.
+func (gen *EthEventGenerator) deployContracts(ctx context.Context) error {
+	var txHashes []common.Hash
+
+	for i := 0; i < gen.conf.NumContracts; i++ {
+		txHash, err := gen.sendTransaction(nil, eventEmitterCode(), eventGenDeployGas)
+		if err != nil {
+			return errors.WithMessage(err, "failed to send deployment transaction")
+		}
+
+		txHashes = append(txHashes, txHash)
+	}
+
+	for _, txHash := range txHashes {
+		receipt, err := gen.waitReceipt(ctx, txHash)
+		if err != nil {
+			return err
+		}
+
+		if receipt.ContractAddress == nil {
+			return errors.Errorf("no contract deployed by transaction %v", txHash)
+		}
+
+		gen.contracts = append(gen.contracts, *receipt.ContractAddress)
+	}
+
+	logrus.WithField("contracts", gen.contracts).Info("Event generator test contracts deployed")
+
+	return nil
+}
+
+// generate sends transactions to emit events in round robin on test contracts, and waits for
+// the receipts to collect generated event logs.
+func (gen *EthEventGenerator) generate(ctx context.Context) ([]eventGenBatch, error) {
+	gas := uint64(eventGenBaseGas + eventGenGasPerEvent*gen.conf.EventsPerTx)
+
+	var txHashes []common.Hash
+	for i := 0; i < gen.conf.TxsPerRound; i++ {
+		contract := gen.contracts[i%len(gen.contracts)]
+		data := eventEmitterCalldata(gen.conf.EventsPerTx, gen.seed)
+
+		txHash, err := gen.sendTransaction(&contract, data, gas)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to send transaction")
+		}
+
+		txHashes = append(txHashes, txHash)
+		gen.seed += gen.conf.EventsPerTx
+	}
+
+	// aggregate event logs by contract and block
+	type batchKey struct {
+		contract    common.Address
+		blockNumber uint64
+	}
+
+	var batches []eventGenBatch
+	indices := make(map[batchKey]int)
+
+	for _, txHash := range txHashes {
+		receipt, err := gen.waitReceipt(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if receipt.Status == nil || *receipt.Status != ethtypes.ReceiptStatusSuccessful {
+			return nil, errors.Errorf("transaction %v failed", txHash)
+		}
+
+		key := batchKey{contract: *receipt.To, blockNumber: receipt.BlockNumber}
+		if _, ok := indices[key]; !ok {
+			indices[key] = len(batches)
+			batches = append(batches, eventGenBatch{
+				contract: key.contract, blockNumber: key.blockNumber, generatedAt: time.Now(),
+			})
+		}
+
+		batches[indices[key]].numLogs += len(receipt.Logs)
+	}
+
+	return batches, nil
+}
+
+func (gen *EthEventGenerator) sendTransaction(to *common.Address, data []byte, gas uint64) (common.Hash, error) {
+	gasPrice, err := gen.fn.Eth.GasPrice()
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "failed to get gas price")
+	}
+
+	tx, err := gen.signer.SignTransaction(ethtypes.NewTx(&ethtypes.LegacyTx{
+		Nonce:    gen.nonce,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       to,
+		Data:     data,
+	}), gen.chainId)
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "failed to sign transaction")
+	}
+
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "failed to encode transaction")
+	}
+
+	txHash, err := gen.fn.Eth.SendRawTransaction(rawTx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	gen.nonce++
+
+	return txHash, nil
+}
+
+func (gen *EthEventGenerator) waitReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, gen.conf.ReceiptTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := gen.fn.Eth.TransactionReceipt(txHash)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get receipt of transaction %v", txHash)
+		}
+
+		if receipt != nil {
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Errorf("timeout to wait for receipt of transaction %v", txHash)
+		case <-ticker.C:
+		}
+	}
+}
+
+// verify verifies the generated event logs from infura after synchronized.
+func (gen *EthEventGenerator) verify(ctx context.Context) {
+	var numBatches, numMismatches int
+
+	for {
+		var batches []eventGenBatch
+
+		select {
+		case <-ctx.Done():
+			return
+		case batches = <-gen.verifyCh:
+		}
+
+		for _, batch := range batches {
+			// wait for event logs synchronized into store
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(batch.generatedAt.Add(gen.conf.VerifyDelay))):
+			}
+
+			numBatches++
+
+			if err := gen.verifyBatch(batch); err != nil {
+				numMismatches++
+
+				logrus.WithFields(logrus.Fields{
+					"contract":    batch.contract,
+					"blockNumber": batch.blockNumber,
+					"numBatches":  numBatches,
+					"mismatches":  numMismatches,
+				}).WithError(err).Error("Event generator failed to verify event logs from infura")
+			}
+		}
+	}
+}
+
+func (gen *EthEventGenerator) verifyBatch(batch eventGenBatch) error {
+	bn := types.BlockNumber(batch.blockNumber)
+
+	start := time.Now()
+	logs, err := gen.infura.Eth.Logs(types.FilterQuery{
+		FromBlock: &bn,
+		ToBlock:   &bn,
+		Addresses: []common.Address{batch.contract},
+		Topics:    [][]common.Hash{{eventGenTopic0}},
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed to get logs")
+	}
+
+	if len(logs) != batch.numLogs {
+		return errors.Errorf("number of logs mismatched, expected %v, got %v", batch.numLogs, len(logs))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"contract":    batch.contract,
+		"blockNumber": batch.blockNumber,
+		"numLogs":     len(logs),
+		"latency":     time.Since(start),
+	}).Debug("Event generator verified event logs from infura")
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEmitterCode(t *testing.T) {
+	sender := common.HexToAddress("0x1")
+	cfg := &runtime.Config{Origin: sender, GasLimit: 10000000}
+
+	code, addr, _, err := runtime.Create(eventEmitterCode(), cfg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, code)
+
+	_, _, err = runtime.Call(addr, eventEmitterCalldata(3, 100), cfg)
+	assert.NoError(t, err)
+
+	logs := cfg.State.Logs()
+	assert.Equal(t, 3, len(logs))
+
+	for i, log := range logs {
+		assert.Equal(t, addr, log.Address)
+		assert.Equal(t, []common.Hash{
+			eventGenTopic0,
+			common.BytesToHash(sender.Bytes()),
+			common.BigToHash(big.NewInt(int64(i))),
+		}, log.Topics)
+		assert.Equal(t, common.BigToHash(big.NewInt(int64(100+i))).Bytes(), log.Data)
+	}
+}
@@ -9,4 +9,6 @@ type GasStationPrice struct {
 	Fastest *hexutil.Big `json:"fastest"` // Recommended fastest gas price in drip
 	SafeLow *hexutil.Big `json:"safeLow"` // Recommended safe gas price in drip
 	Average *hexutil.Big `json:"average"` // Recommended average gas price in drip
+
+	PendingTxs *hexutil.Uint64 `json:"pendingTxs,omitempty"` // Number of pending txs ready to be packed
 }
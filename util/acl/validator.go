@@ -231,6 +231,7 @@ func NewEthValidator(al *AllowList) Validator {
 		"eth_call":                v.parseCallRequest,
 		"eth_estimateGas":         v.parseCallRequest,
 		"eth_getLogs":             v.parseFilterQuery,
+		"eth_explainLogs":         v.parseFilterQuery,
 		"eth_getBalance":          v.parseAddr,
 		"eth_getTransactionCount": v.parseAddr,
 		"eth_getCode":             v.parseAddr,
@@ -301,6 +302,7 @@ func NewCfxValidator(al *AllowList) Validator {
 		"cfx_call":                     v.parseCallRequest,
 		"cfx_estimateGasAndCollateral": v.parseCallRequest,
 		"cfx_getLogs":                  v.parseLogFilter,
+		"cfx_explainLogs":              v.parseLogFilter,
 		"cfx_getBalance":               v.parseAddr,
 		"cfx_getNextNonce":             v.parseAddr,
 		"cfx_getCode":                  v.parseAddr,
@@ -0,0 +1,290 @@
+// Package audit records RPC calls asynchronously into rotating file or Kafka topic, which is
+// intended for abuse investigation and capacity planning.
+package audit
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	OutcomeBlocked = "blocked"
+
+	SinkFile  = "file"
+	SinkKafka = "kafka"
+
+	// max number of records written to sink at a time
+	maxBatchSize = 100
+)
+
+type ctxKey struct{}
+
+// default audit logger shared by all RPC servers, which is nil if not enabled
+var defaultLogger *Logger
+
+// DefaultLogger returns the default audit logger, or nil if not enabled.
+func DefaultLogger() *Logger {
+	return defaultLogger
+}
+
+// MustInitDefaultFromViper initializes the default audit logger shared by all RPC servers from
+// viper settings, and returns false if not enabled. Note, it should be called before RPC servers
+// started, and the returned logger should be run to write records.
+func MustInitDefaultFromViper() (*Logger, bool) {
+	logger, ok := MustNewLoggerFromViper("rpc.audit")
+	if ok {
+		defaultLogger = logger
+	}
+
+	return logger, ok
+}
+
+// Record audit record of RPC call.
+type Record struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	ParamsHash string    `json:"paramsHash"` // xxhash of the raw JSON params
+	Tenant     string    `json:"tenant,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	Node       string    `json:"node,omitempty"` // routed fullnode
+	LatencyMs  float64   `json:"latencyMs"`
+	StoreHit   bool      `json:"storeHit"`
+	Outcome    string    `json:"outcome"`
+	ErrorCode  int       `json:"errorCode,omitempty"`
+}
+
+// Sink persists audit records.
+type Sink interface {
+	Write(records []*Record) error
+	Close() error
+}
+
+// Config audit logging configurations
+type Config struct {
+	// whether to record RPC calls
+	Enabled bool
+	// sink to write audit records, `file` or `kafka`
+	Sink string `default:"file"`
+	// max number of pending records, beyond which new records will be dropped
+	QueueSize int `default:"10000"`
+	// ratio (0 ~ 1) of RPC calls to record
+	SampleRate float64 `default:"1"`
+	// method => sample rate to override the default one, note method is case insensitive
+	MethodSampleRates map[string]float64
+	// whether to always record failed RPC calls regardless of sample rate
+	AlwaysOnError bool `default:"true"`
+	// rotating file sink configurations
+	File FileConfig
+	// Kafka sink configurations
+	Kafka KafkaConfig
+}
+
+// Logger samples and records RPC calls asynchronously.
+type Logger struct {
+	conf  Config
+	sink  Sink
+	queue chan *Record
+}
+
+// MustNewLoggerFromViper creates audit logger from viper settings of the specified key, or returns
+// false if not enabled.
+func MustNewLoggerFromViper(key string) (*Logger, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	var sink Sink
+	switch conf.Sink {
+	case SinkFile:
+		sink = NewFileSink(conf.File)
+	case SinkKafka:
+		sink = MustNewKafkaSink(conf.Kafka)
+	default:
+		logrus.WithField("sink", conf.Sink).Fatal("Invalid audit logging sink")
+	}
+
+	return NewLogger(conf, sink), true
+}
+
+func NewLogger(conf Config, sink Sink) *Logger {
+	rates := make(map[string]float64, len(conf.MethodSampleRates))
+	for method, rate := range conf.MethodSampleRates {
+		rates[strings.ToLower(method)] = rate
+	}
+
+	conf.MethodSampleRates = rates
+
+	return &Logger{
+		conf:  conf,
+		sink:  sink,
+		queue: make(chan *Record, conf.QueueSize),
+	}
+}
+
+// Sampled returns true if the RPC call should be recorded.
+func (l *Logger) Sampled(method string, failed bool) bool {
+	if failed && l.conf.AlwaysOnError {
+		return true
+	}
+
+	rate, ok := l.conf.MethodSampleRates[strings.ToLower(method)]
+	if !ok {
+		rate = l.conf.SampleRate
+	}
+
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// Log enqueues the record without blocking, and drops it if too many pending records.
+func (l *Logger) Log(record *Record) {
+	select {
+	case l.queue <- record:
+	default:
+		metrics.Registry.RPC.AuditDropped().Mark(1)
+	}
+}
+
+// Run writes the pending records into sink in batches until context canceled.
+func (l *Logger) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	defer func() {
+		if err := l.sink.Close(); err != nil {
+			logrus.WithError(err).Warn("Failed to close audit logging sink")
+		}
+	}()
+
+	batch := make([]*Record, 0, maxBatchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// drain the pending records before exit
+			for {
+				select {
+				case record := <-l.queue:
+					batch = l.collect(batch, record)
+				default:
+					l.write(batch)
+					return
+				}
+			}
+		case record := <-l.queue:
+			batch = l.collect(batch, record)
+
+			// write once no more pending records
+			if len(l.queue) == 0 {
+				l.write(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// collect appends the record into batch, and writes the batch once full.
+func (l *Logger) collect(batch []*Record, record *Record) []*Record {
+	if batch = append(batch, record); len(batch) < maxBatchSize {
+		return batch
+	}
+
+	l.write(batch)
+
+	return batch[:0]
+}
+
+func (l *Logger) write(batch []*Record) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := l.sink.Write(batch); err != nil {
+		metrics.Registry.RPC.AuditWriteErrors().Mark(1)
+		logrus.WithError(err).WithField("records", len(batch)).Warn("Failed to write audit records")
+	}
+}
+
+// Entry mutable fields of audit record populated while handling RPC call.
+type Entry struct {
+	mu       sync.Mutex
+	tenant   string
+	node     string
+	storeHit bool
+	blocked  bool // rejected by security guard, eg., signing methods
+}
+
+// Fields returns the populated tenant, routed node and store hit flag.
+func (e *Entry) Fields() (tenant, node string, storeHit bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.tenant, e.node, e.storeHit
+}
+
+// Blocked returns whether the RPC call is rejected by security guard.
+func (e *Entry) Blocked() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.blocked
+}
+
+// NewContext returns a new context along with the audit entry to populate.
+func NewContext(ctx context.Context) (context.Context, *Entry) {
+	entry := &Entry{}
+	return context.WithValue(ctx, ctxKey{}, entry), entry
+}
+
+func fromContext(ctx context.Context) (*Entry, bool) {
+	entry, ok := ctx.Value(ctxKey{}).(*Entry)
+	return entry, ok
+}
+
+// SetTenant populates the tenant of RPC call if audited.
+func SetTenant(ctx context.Context, tenant string) {
+	if entry, ok := fromContext(ctx); ok {
+		entry.mu.Lock()
+		entry.tenant = tenant
+		entry.mu.Unlock()
+	}
+}
+
+// SetBlocked marks the RPC call rejected by security guard if audited, which is always recorded
+// regardless of sample rate.
+func SetBlocked(ctx context.Context) {
+	if entry, ok := fromContext(ctx); ok {
+		entry.mu.Lock()
+		entry.blocked = true
+		entry.mu.Unlock()
+	}
+}
+
+// SetNode populates the routed fullnode of RPC call if audited.
+func SetNode(ctx context.Context, node string) {
+	if entry, ok := fromContext(ctx); ok {
+		entry.mu.Lock()
+		entry.node = node
+		entry.mu.Unlock()
+	}
+}
+
+// MarkStoreHit populates whether RPC call served by store if audited.
+func MarkStoreHit(ctx context.Context, hit bool) {
+	if entry, ok := fromContext(ctx); ok {
+		entry.mu.Lock()
+		entry.storeHit = hit
+		entry.mu.Unlock()
+	}
+}
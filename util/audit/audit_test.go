@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSink struct {
+	records []*Record
+	closed  bool
+}
+
+func (s *mockSink) Write(records []*Record) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *mockSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestLoggerSampled(t *testing.T) {
+	logger := NewLogger(Config{
+		SampleRate:        0,
+		MethodSampleRates: map[string]float64{"eth_getLogs": 1},
+		AlwaysOnError:     true,
+	}, &mockSink{})
+
+	assert.False(t, logger.Sampled("eth_call", false))
+	assert.True(t, logger.Sampled("eth_call", true))
+	assert.True(t, logger.Sampled("eth_getlogs", false))
+}
+
+func TestLoggerRun(t *testing.T) {
+	sink := &mockSink{}
+	logger := NewLogger(Config{QueueSize: 300}, sink)
+
+	for i := 0; i < 300; i++ {
+		logger.Log(&Record{Method: "eth_call"})
+	}
+
+	// dropped if queue full
+	logger.Log(&Record{Method: "eth_call"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	logger.Run(ctx, &wg)
+	wg.Wait()
+
+	assert.Len(t, sink.records, 300)
+	assert.True(t, sink.closed)
+}
+
+func TestEntry(t *testing.T) {
+	// not audited
+	SetNode(context.Background(), "node1")
+
+	ctx, entry := NewContext(context.Background())
+	SetTenant(ctx, "alice")
+	SetNode(ctx, "node1")
+	MarkStoreHit(ctx, true)
+
+	tenant, node, hit := entry.Fields()
+	assert.Equal(t, "alice", tenant)
+	assert.Equal(t, "node1", node)
+	assert.True(t, hit)
+}
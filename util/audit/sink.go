@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileConfig rotating file sink configurations
+type FileConfig struct {
+	// path of the audit log file
+	Path string `default:"audit.log"`
+	// max size in megabytes of the file before rotated
+	MaxSizeMB int `default:"100"`
+	// max number of rotated files to retain, 0 to retain all
+	MaxBackups int `default:"10"`
+	// max days to retain rotated files, 0 to retain all
+	MaxAgeDays int `default:"7"`
+	// whether to compress rotated files with gzip
+	Compress bool
+}
+
+// FileSink writes audit records into rotating file as JSON lines.
+type FileSink struct {
+	writer *lumberjack.Logger
+}
+
+func NewFileSink(conf FileConfig) *FileSink {
+	return &FileSink{
+		writer: &lumberjack.Logger{
+			Filename:   conf.Path,
+			MaxSize:    conf.MaxSizeMB,
+			MaxBackups: conf.MaxBackups,
+			MaxAge:     conf.MaxAgeDays,
+			Compress:   conf.Compress,
+		},
+	}
+}
+
+func (s *FileSink) Write(records []*Record) error {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return errors.WithMessage(err, "failed to encode audit record")
+		}
+	}
+
+	_, err := s.writer.Write(buf.Bytes())
+	return err
+}
+
+func (s *FileSink) Close() error {
+	return s.writer.Close()
+}
+
+// KafkaConfig Kafka sink configurations
+type KafkaConfig struct {
+	// Kafka brokers to discover the topic partitions
+	Brokers []string
+	// topic to produce audit records
+	Topic string `default:"confura_rpc_audit"`
+	// timeout to write a batch of audit records
+	WriteTimeout time.Duration `default:"10s"`
+}
+
+// KafkaSink produces audit records into Kafka topic as JSON messages keyed by method.
+type KafkaSink struct {
+	conf   KafkaConfig
+	writer *kafka.Writer
+}
+
+func MustNewKafkaSink(conf KafkaConfig) *KafkaSink {
+	if len(conf.Brokers) == 0 {
+		logrus.Fatal("Kafka brokers not configured for audit logging")
+	}
+
+	return &KafkaSink{
+		conf: conf,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers:  conf.Brokers,
+			Topic:    conf.Topic,
+			Balancer: &kafka.Hash{},
+		}),
+	}
+}
+
+func (s *KafkaSink) Write(records []*Record) error {
+	msgs := make([]kafka.Message, 0, len(records))
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return errors.WithMessage(err, "failed to encode audit record")
+		}
+
+		msgs = append(msgs, kafka.Message{Key: []byte(record.Method), Value: value})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.conf.WriteTimeout)
+	defer cancel()
+
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,89 @@
+// Package errcode defines the JSON-RPC error codes and stable error kinds responded to clients, which
+// is a leaf package so that low level packages (eg., store) could classify errors without depending
+// on RPC utilities.
+package errcode
+
+const (
+	// JSON-RPC error code when requested resource not found (conforms to EIP-1474)
+	ResourceNotFound = -32001
+	// JSON-RPC error code when method not supported (conforms to EIP-1474)
+	MethodUnsupported = -32004
+	// JSON-RPC error code when request rate limit exceeded (conforms to EIP-1474)
+	LimitExceeded = -32005
+	// JSON-RPC error code when upstream fullnode is unavailable (eg., io error or timeout)
+	UpstreamUnavailable = -32011
+	// JSON-RPC error code when no upstream fullnode available at all (eg., none configured or
+	// all unhealthy), which is not worth retrying immediately
+	NoUpstreamAvailable = -32012
+	// JSON-RPC error code when upstream fullnodes diverge on the result without quorum reached
+	UpstreamDiverged = -32013
+	// JSON-RPC error code when request failed to authenticate (eg., invalid JWT or HMAC signature)
+	Unauthorized = -32014
+	// JSON-RPC error code when requested data already pruned from store
+	DataPruned = -32015
+	// JSON-RPC error code when chain reorg is in progress, which is worth retrying later
+	ReorgInProgress = -32016
+	// JSON-RPC error code when query timed out in store, which is worth narrowing down the query
+	QueryTimeout = -32017
+)
+
+// Stable error kinds responded as JSON-RPC error data along with error codes, so that API consumers
+// could handle them programmatically rather than parsing error messages.
+const (
+	KindNotFound            = "not-found"
+	KindUnsupported         = "unsupported"
+	KindOverLimit           = "over-limit"
+	KindUpstreamUnavailable = "upstream-unavailable"
+	KindUpstreamDiverged    = "upstream-diverged"
+	KindUnauthorized        = "unauthorized"
+	KindPruned              = "pruned"
+	KindReorgInProgress     = "reorg-in-progress"
+	KindTimeout             = "timeout"
+)
+
+var codeKinds = map[int]string{
+	ResourceNotFound:    KindNotFound,
+	MethodUnsupported:   KindUnsupported,
+	LimitExceeded:       KindOverLimit,
+	UpstreamUnavailable: KindUpstreamUnavailable,
+	NoUpstreamAvailable: KindUpstreamUnavailable,
+	UpstreamDiverged:    KindUpstreamDiverged,
+	Unauthorized:        KindUnauthorized,
+	DataPruned:          KindPruned,
+	ReorgInProgress:     KindReorgInProgress,
+	QueryTimeout:        KindTimeout,
+}
+
+// Kind returns the stable error kind of the JSON-RPC error code, or empty if unclassified.
+func Kind(code int) string {
+	return codeKinds[code]
+}
+
+// CodedError error with JSON-RPC error code, which will be responded to the client
+// rather than the default error code.
+type CodedError struct {
+	Code int
+	Err  error
+}
+
+func New(code int, err error) *CodedError {
+	return &CodedError{Code: code, Err: err}
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+// ErrorCode implements `rpc.Error`
+func (e *CodedError) ErrorCode() int {
+	return e.Code
+}
+
+// Cause implements `errors.causer` so that the original error could be inspected.
+func (e *CodedError) Cause() error {
+	return e.Err
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
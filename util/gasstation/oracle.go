@@ -0,0 +1,291 @@
+package gasstation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errOracleNotReady = errors.New("gas price oracle not ready yet")
+)
+
+// gas price oracle configurations
+type OracleConfig struct {
+	// whether to enable gas price oracle
+	Enabled bool
+	// interval to sample the latest blocks and txpool
+	Interval time.Duration `default:"1s"`
+	// number of recent blocks (epochs for core space) to compute gas price suggestions from
+	HistoricalBlocks uint64 `default:"100"`
+	// percentiles of the sampled gas prices for each suggestion
+	Percentiles struct {
+		SafeLow float64 `default:"10"`
+		Average float64 `default:"50"`
+		Fast    float64 `default:"80"`
+		Fastest float64 `default:"95"`
+	}
+	// HTTP endpoint to serve gas price suggestions, empty means disabled
+	Endpoint string
+}
+
+// TxPoolSample gas price sample of txpool.
+type TxPoolSample struct {
+	GasPrice   *big.Int // gas price suggested by fullnode according to txpool
+	PendingTxs *uint64  // number of pending transactions ready to be packed if available
+}
+
+// Sampler samples gas prices from the latest blocks and txpool of fullnodes.
+type Sampler interface {
+	// LatestBlock returns the latest block (epoch for core space) number to sample.
+	LatestBlock() (uint64, error)
+	// SampleBlock returns the gas prices of executed transactions within the block (epoch for core space).
+	SampleBlock(bn uint64) ([]*big.Int, error)
+	// SampleTxPool samples txpool of fullnode.
+	SampleTxPool() (*TxPoolSample, error)
+}
+
+// Oracle continuously samples gas prices from the recent blocks and txpool of fullnodes, and
+// suggests percentile-based gas prices, which are cached until the next sampling.
+type Oracle struct {
+	mu sync.Mutex
+
+	space     string
+	conf      OracleConfig
+	sampler   Sampler
+	samples   map[uint64][]*big.Int  // block number => gas prices of executed txs
+	nextBlock uint64                 // next block number to sample
+	price     *types.GasStationPrice // cached gas price suggestions
+}
+
+// MustNewOracleFromViper creates gas price oracle from viper settings of the specified key,
+// or returns false if not enabled.
+func MustNewOracleFromViper(space, key string, sampler Sampler) (*Oracle, bool) {
+	var conf OracleConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	if conf.HistoricalBlocks == 0 {
+		logrus.WithField("space", space).Fatal("Historical blocks of gas price oracle must be greater than 0")
+	}
+
+	return NewOracle(space, conf, sampler), true
+}
+
+func NewOracle(space string, conf OracleConfig, sampler Sampler) *Oracle {
+	return &Oracle{
+		space:   space,
+		conf:    conf,
+		sampler: sampler,
+		samples: make(map[uint64][]*big.Int),
+	}
+}
+
+// Config returns the oracle configurations.
+func (o *Oracle) Config() OracleConfig {
+	return o.conf
+}
+
+// Run samples gas prices periodically until context done.
+func (o *Oracle) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := o.sample(); err != nil {
+				logrus.WithField("space", o.space).WithError(err).Debug("Gas price oracle failed to sample")
+			}
+		}
+	}
+}
+
+// Suggest returns the cached gas price suggestions.
+func (o *Oracle) Suggest() (*types.GasStationPrice, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.price == nil {
+		return nil, errOracleNotReady
+	}
+
+	return o.price, nil
+}
+
+func (o *Oracle) sample() error {
+	latest, err := o.sampler.LatestBlock()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get latest block")
+	}
+
+	var oldest uint64
+	if latest+1 > o.conf.HistoricalBlocks {
+		oldest = latest + 1 - o.conf.HistoricalBlocks
+	}
+
+	o.mu.Lock()
+	from := o.nextBlock
+	o.mu.Unlock()
+
+	if from < oldest {
+		from = oldest
+	}
+
+	// Note, the recent blocks might be re-sampled in case of pivot switch, which are
+	// negligible for gas price suggestions.
+	newSamples := make(map[uint64][]*big.Int)
+	for bn := from; bn <= latest; bn++ {
+		prices, err := o.sampler.SampleBlock(bn)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to sample block %v", bn)
+		}
+
+		newSamples[bn] = prices
+	}
+
+	txpool, err := o.sampler.SampleTxPool()
+	if err != nil {
+		return errors.WithMessage(err, "failed to sample txpool")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for bn, prices := range newSamples {
+		o.samples[bn] = prices
+	}
+
+	for bn := range o.samples {
+		if bn < oldest || bn > latest {
+			delete(o.samples, bn)
+		}
+	}
+
+	o.nextBlock = latest + 1
+	o.price = o.suggest(txpool)
+
+	logrus.WithFields(logrus.Fields{
+		"space":  o.space,
+		"latest": latest,
+		"price":  o.price,
+	}).Debug("Gas price oracle sampled the latest blocks")
+
+	return nil
+}
+
+// suggest computes gas price suggestions at the configured percentiles of the sampled gas
+// prices, which are not lower than the gas price suggested by fullnode.
+func (o *Oracle) suggest(txpool *TxPoolSample) *types.GasStationPrice {
+	var prices []*big.Int
+	for _, samples := range o.samples {
+		prices = append(prices, samples...)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+
+	percentile := func(p float64) *hexutil.Big {
+		price := txpool.GasPrice
+		if len(prices) > 0 {
+			if v := percentileNearestRank(prices, p); price == nil || v.Cmp(price) > 0 {
+				price = v
+			}
+		}
+
+		return (*hexutil.Big)(price)
+	}
+
+	result := &types.GasStationPrice{
+		SafeLow: percentile(o.conf.Percentiles.SafeLow),
+		Average: percentile(o.conf.Percentiles.Average),
+		Fast:    percentile(o.conf.Percentiles.Fast),
+		Fastest: percentile(o.conf.Percentiles.Fastest),
+	}
+
+	if txpool.PendingTxs != nil {
+		result.PendingTxs = (*hexutil.Uint64)(txpool.PendingTxs)
+	}
+
+	return result
+}
+
+// percentileNearestRank returns the value at the percentile of sorted values by nearest rank.
+func percentileNearestRank(sorted []*big.Int, p float64) *big.Int {
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+
+	if idx < 0 {
+		idx = 0
+	}
+
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+
+	return sorted[idx]
+}
+
+// ServeHTTP serves the cached gas price suggestions in JSON.
+func (o *Oracle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	price, err := o.Suggest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(o.conf.Interval.Seconds())))
+
+	if err := json.NewEncoder(w).Encode(price); err != nil {
+		logrus.WithError(err).Debug("Gas price oracle failed to write HTTP response")
+	}
+}
+
+// MustServeGraceful serves gas price suggestions over HTTP until graceful shutdown.
+func (o *Oracle) MustServeGraceful(ctx context.Context, wg *sync.WaitGroup, endpoint string) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logger := logrus.WithFields(logrus.Fields{
+		"space":    o.space,
+		"endpoint": endpoint,
+	})
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to endpoint for gas price oracle")
+	}
+
+	server := &http.Server{Handler: o}
+	go server.Serve(listener)
+
+	logger.Info("Gas price oracle HTTP server started")
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.WithError(err).Error("Failed to shutdown gas price oracle HTTP server")
+	}
+}
@@ -0,0 +1,73 @@
+package gasstation
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSampler struct {
+	latest   uint64
+	blocks   map[uint64][]int64
+	gasPrice int64
+}
+
+func (s *mockSampler) LatestBlock() (uint64, error) {
+	return s.latest, nil
+}
+
+func (s *mockSampler) SampleBlock(bn uint64) ([]*big.Int, error) {
+	var prices []*big.Int
+	for _, v := range s.blocks[bn] {
+		prices = append(prices, big.NewInt(v))
+	}
+
+	return prices, nil
+}
+
+func (s *mockSampler) SampleTxPool() (*TxPoolSample, error) {
+	return &TxPoolSample{GasPrice: big.NewInt(s.gasPrice)}, nil
+}
+
+func TestOracleSuggest(t *testing.T) {
+	sampler := &mockSampler{
+		latest: 3,
+		blocks: map[uint64][]int64{
+			1: {100, 100},
+			2: {1, 2, 3, 4, 5},
+			3: {6, 7, 8, 9, 10},
+		},
+		gasPrice: 2,
+	}
+
+	var conf OracleConfig
+	conf.HistoricalBlocks = 2
+	conf.Percentiles.SafeLow = 10
+	conf.Percentiles.Average = 50
+	conf.Percentiles.Fast = 80
+	conf.Percentiles.Fastest = 100
+
+	oracle := NewOracle("cfx", conf, sampler)
+
+	_, err := oracle.Suggest()
+	assert.Equal(t, errOracleNotReady, err)
+
+	// only the recent 2 blocks sampled, and suggestions no lower than fullnode gas price
+	assert.NoError(t, oracle.sample())
+	price, err := oracle.Suggest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", price.SafeLow.String())
+	assert.Equal(t, "0x5", price.Average.String())
+	assert.Equal(t, "0x8", price.Fast.String())
+	assert.Equal(t, "0xa", price.Fastest.String())
+
+	// oldest block evicted from sampling window
+	sampler.latest = 4
+	assert.NoError(t, oracle.sample())
+	price, err = oracle.Suggest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0x6", price.SafeLow.String())
+	assert.Equal(t, "0xa", price.Fastest.String())
+	assert.Equal(t, 2, len(oracle.samples))
+}
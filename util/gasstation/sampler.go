@@ -0,0 +1,155 @@
+package gasstation
+
+import (
+	"math/big"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// route key to get fullnode client for gas price sampling
+	samplerRouteKey = "gasstation"
+)
+
+// CfxSampler samples gas prices from the pivot blocks of the latest state epochs and txpool
+// of core space fullnodes.
+type CfxSampler struct {
+	provider *node.CfxClientProvider
+}
+
+func NewCfxSampler(provider *node.CfxClientProvider) *CfxSampler {
+	return &CfxSampler{provider: provider}
+}
+
+func (s *CfxSampler) LatestBlock() (uint64, error) {
+	cfx, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return 0, err
+	}
+
+	epoch, err := cfx.GetEpochNumber(types.EpochLatestState)
+	if err != nil {
+		return 0, err
+	}
+
+	return epoch.ToInt().Uint64(), nil
+}
+
+func (s *CfxSampler) SampleBlock(bn uint64) ([]*big.Int, error) {
+	cfx, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := cfx.GetBlockByEpoch(types.NewEpochNumberUint64(bn))
+	if err != nil {
+		return nil, err
+	}
+
+	if block == nil {
+		return nil, errors.New("pivot block not found")
+	}
+
+	var prices []*big.Int
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+
+		// skip transactions not executed in the pivot block
+		if tx.Status == nil || *tx.Status > 1 || tx.GasPrice == nil {
+			continue
+		}
+
+		prices = append(prices, tx.GasPrice.ToInt())
+	}
+
+	return prices, nil
+}
+
+func (s *CfxSampler) SampleTxPool() (*TxPoolSample, error) {
+	cfx, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, err := cfx.GetGasPrice()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := cfx.TxPool().Status()
+	if err != nil {
+		return nil, err
+	}
+
+	pendingTxs := uint64(status.Ready)
+	return &TxPoolSample{GasPrice: gasPrice.ToInt(), PendingTxs: &pendingTxs}, nil
+}
+
+// EthSampler samples gas prices from the latest blocks and txpool of evm space fullnodes.
+type EthSampler struct {
+	provider *node.EthClientProvider
+}
+
+func NewEthSampler(provider *node.EthClientProvider) *EthSampler {
+	return &EthSampler{provider: provider}
+}
+
+func (s *EthSampler) LatestBlock() (uint64, error) {
+	w3c, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return 0, err
+	}
+
+	bn, err := w3c.Eth.BlockNumber()
+	if err != nil {
+		return 0, err
+	}
+
+	return bn.Uint64(), nil
+}
+
+func (s *EthSampler) SampleBlock(bn uint64) ([]*big.Int, error) {
+	w3c, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := w3c.Eth.BlockByNumber(web3Types.BlockNumber(bn), true)
+	if err != nil {
+		return nil, err
+	}
+
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+
+	var prices []*big.Int
+	for _, tx := range block.Transactions.Transactions() {
+		// skip transactions failed to execute (eg., nonce too stale)
+		if (tx.Status != nil && *tx.Status > 1) || tx.GasPrice == nil {
+			continue
+		}
+
+		prices = append(prices, tx.GasPrice)
+	}
+
+	return prices, nil
+}
+
+func (s *EthSampler) SampleTxPool() (*TxPoolSample, error) {
+	w3c, err := s.provider.GetClient(samplerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// txpool status is not available in evm space
+	gasPrice, err := w3c.Eth.GasPrice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxPoolSample{GasPrice: gasPrice}, nil
+}
@@ -18,6 +18,7 @@ type Metrics struct {
 	Store         StoreMetrics
 	Nodes         NodeManagerMetrics
 	VirtualFilter VirtualFilterMetrics
+	Relay         RelayMetrics
 }
 
 // RPC metrics
@@ -37,6 +38,14 @@ func (*RpcMetrics) BatchLatency() metrics.Histogram {
 	return GetOrRegisterHistogram("infura/rpc/batch/latency")
 }
 
+func (*RpcMetrics) BatchFailedItems() metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/batch/failed")
+}
+
+func (*RpcMetrics) BatchRetriedItems() metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/batch/retried")
+}
+
 func (*RpcMetrics) UpdateDuration(method string, err error, start time.Time) {
 	var isNilErr, isRpcErr bool
 	if isNilErr = util.IsInterfaceValNil(err); !isNilErr {
@@ -83,6 +92,42 @@ func (*RpcMetrics) InputBlockGap(method string) metrics.Histogram {
 	return GetOrRegisterHistogram("infura/rpc/input/block/gap/%v", method)
 }
 
+// RPC metrics - tiered cache
+
+func (*RpcMetrics) CacheTierHit(name, tier string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/cache/%v/hit/%v", name, tier)
+}
+
+func (*RpcMetrics) CachePromotions(name string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/cache/%v/promotions", name)
+}
+
+func (*RpcMetrics) CacheDemotions(name string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/cache/%v/demotions", name)
+}
+
+func (*RpcMetrics) CacheStaleHit(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/cache/stale/hit/%v", method)
+}
+
+func (*RpcMetrics) CacheHotBytes(name string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/cache/%v/hot/bytes", name)
+}
+
+// RPC metrics - logs prefetching
+
+func (*RpcMetrics) LogsPrefetchHit(space string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/logs/prefetch/%v/hit", space)
+}
+
+func (*RpcMetrics) LogsPrefetchSkipped(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/logs/prefetch/%v/skipped", space)
+}
+
+func (*RpcMetrics) TraceResponseSize(method string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/trace/size/%v", method)
+}
+
 // PRC metrics - percentages
 
 func (*RpcMetrics) Percentage(method, name string) Percentage {
@@ -122,6 +167,125 @@ func (*RpcMetrics) FullnodeNonRpcErrorRate(node ...string) Percentage {
 	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/rate/nonRpcErr/%v", node[0])
 }
 
+func (*RpcMetrics) FullnodeCircuitState(node string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/fullnode/circuit/state/%v", node)
+}
+
+func (*RpcMetrics) FullnodeCircuitRejects(node string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/circuit/rejects/%v", node)
+}
+
+func (*RpcMetrics) FullnodeInflight(node string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/fullnode/inflight/%v", node)
+}
+
+func (*RpcMetrics) FullnodeSaturatedRejects(node string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/saturated/rejects/%v", node)
+}
+
+func (*RpcMetrics) FullnodeSaturatedReroutes(node string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/saturated/reroutes/%v", node)
+}
+
+func (*RpcMetrics) FullnodeUsageRequests(node, space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/usage/%v/%v/requests", node, space)
+}
+
+func (*RpcMetrics) FullnodeUsageErrors(node, space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/usage/%v/%v/errors", node, space)
+}
+
+// direction: `in` for response bytes, `out` for request bytes
+func (*RpcMetrics) FullnodeUsageBytes(node, space, direction string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/usage/%v/%v/bytes/%v", node, space, direction)
+}
+
+func (*RpcMetrics) FullnodeHedged(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/hedge/requests/%v", method)
+}
+
+func (*RpcMetrics) FullnodeHedgeWin(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/hedge/win/%v", method)
+}
+
+func (*RpcMetrics) FullnodeCoalesced(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/coalesce/rate/%v", method)
+}
+
+func (*RpcMetrics) FullnodeVerifyDiverged(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/verify/diverged/%v", method)
+}
+
+func (*RpcMetrics) FullnodeVerifyQuorumCapped(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/verify/quorumCapped/%v", method)
+}
+
+func (*RpcMetrics) FullnodeVerifyMismatch(node string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fullnode/verify/mismatch/%v", node)
+}
+
+// RPC metrics - audit logging
+
+func (*RpcMetrics) AuditDropped() metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/audit/dropped")
+}
+
+func (*RpcMetrics) AuditWriteErrors() metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/audit/write/errors")
+}
+
+// RPC metrics - admission control
+
+func (*RpcMetrics) AdmissionInflight() metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/admission/inflight")
+}
+
+func (*RpcMetrics) AdmissionQueueDepth(priority string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/admission/queue/%v/depth", priority)
+}
+
+func (*RpcMetrics) AdmissionQueueLatency(priority string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/admission/queue/%v/latency", priority)
+}
+
+func (*RpcMetrics) AdmissionShed(priority, reason string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/admission/shed/%v/%v", priority, reason)
+}
+
+// RPC metrics - websocket connections
+
+func (*RpcMetrics) WsConns(server string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/ws/%v/conns", server)
+}
+
+func (*RpcMetrics) WsRejected(server, reason string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/ws/%v/rejected/%v", server, reason)
+}
+
+func (*RpcMetrics) WsEvicted(server, reason string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/ws/%v/evicted/%v", server, reason)
+}
+
+// RPC metrics - shadow traffic mirroring
+
+func (*RpcMetrics) MirrorMatched(method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/mirror/matched/%v", method)
+}
+
+func (*RpcMetrics) MirrorErrors(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/mirror/errors/%v", method)
+}
+
+func (*RpcMetrics) MirrorDropped() metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/mirror/dropped")
+}
+
+// RPC metrics - idempotency keys of write RPCs
+
+func (*RpcMetrics) IdempotentReplays(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/idempotency/replays/%v", method)
+}
+
 // Sync service metrics
 type SyncMetrics struct{}
 
@@ -137,6 +301,40 @@ func (*SyncMetrics) SyncOnceSize(space, storeName string) metrics.Histogram {
 	return GetOrRegisterHistogram("infura/sync/%v/%v/once/size", space, storeName)
 }
 
+// epochs (or blocks) persisted into store
+func (*SyncMetrics) SyncEpochs(space, storeName string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/%v/epochs", space, storeName)
+}
+
+// bytes of epoch (or block) data persisted into store
+func (*SyncMetrics) SyncBytes(space, storeName string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/%v/bytes", space, storeName)
+}
+
+func (*SyncMetrics) StoreWriteLatency(space, storeName string) metrics.Timer {
+	return GetOrRegisterTimer("infura/sync/%v/%v/write", space, storeName)
+}
+
+// epochs (or blocks) behind the store boundary to sync
+func (*SyncMetrics) Backlog(space, storeName string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/sync/%v/%v/backlog", space, storeName)
+}
+
+// estimated seconds to catch up with the backlog, -1 if not predictable
+func (*SyncMetrics) CatchUpEta(space, storeName string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/sync/%v/%v/eta", space, storeName)
+}
+
+// max epoch (or block) synced into store of the space in the consistency view of sync coordinator
+func (*SyncMetrics) SpaceMaxEpoch(space string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/sync/coordinator/%v/maxepoch", space)
+}
+
+// core space max epoch minus evm space max block
+func (*SyncMetrics) SpaceSkew() metrics.Gauge {
+	return GetOrRegisterGauge("infura/sync/coordinator/skew")
+}
+
 func (*SyncMetrics) QueryEpochData(space string) TimerUpdater {
 	return NewTimerUpdaterByName(fmt.Sprintf("infura/sync/%v/fullnode", space))
 }
@@ -145,6 +343,55 @@ func (*SyncMetrics) QueryEpochDataAvailability(space string) Percentage {
 	return GetOrRegisterTimeWindowPercentageDefault("infura/sync/%v/fullnode/availability", space)
 }
 
+func (*SyncMetrics) ReorgDepth(space string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/sync/%v/reorg/depth", space)
+}
+
+func (*SyncMetrics) DeepReorgs(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/reorg/deep", space)
+}
+
+func (*SyncMetrics) PivotSwitches(space, storeName string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/%v/pivotswitch", space, storeName)
+}
+
+func (*SyncMetrics) PivotSwitchDepth(space, storeName string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/sync/%v/%v/pivotswitch/depth", space, storeName)
+}
+
+func (*SyncMetrics) AuditEpochs(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/audit/epochs", space)
+}
+
+func (*SyncMetrics) AuditMismatches(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/audit/mismatches", space)
+}
+
+// pending epochs missing in store to backfill
+func (*SyncMetrics) GapEpochs(space string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/sync/%v/gap/epochs", space)
+}
+
+func (*SyncMetrics) GapsDetected(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/gap/detected", space)
+}
+
+func (*SyncMetrics) GapBackfilledEpochs(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/gap/backfilled", space)
+}
+
+func (*SyncMetrics) WebhookDelivery(err error) metrics.Timer {
+	if util.IsInterfaceValNil(err) {
+		return GetOrRegisterTimer("infura/sync/webhook/delivery/success")
+	}
+
+	return GetOrRegisterTimer("infura/sync/webhook/delivery/failure")
+}
+
+func (*SyncMetrics) WebhookRevocations() metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/webhook/revocations")
+}
+
 // Store metrics
 type StoreMetrics struct{}
 
@@ -160,6 +407,14 @@ func (*StoreMetrics) GetLogs() TimerUpdater {
 	return NewTimerUpdaterByName("infura/store/mysql/getlogs")
 }
 
+func (*StoreMetrics) ReplicaHit(storeName string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/store/%v/replica/hit", storeName)
+}
+
+func (*StoreMetrics) ReplicaLag(storeName, replica string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/store/%v/replica/%v/lag", storeName, replica)
+}
+
 // Node manager metrics
 type NodeManagerMetrics struct{}
 
@@ -167,6 +422,30 @@ func (*NodeManagerMetrics) Routes(space, group, node string) metrics.Meter {
 	return GetOrRegisterMeter("infura/nodes/%v/routes/%v/%v", space, group, node)
 }
 
+func (*NodeManagerMetrics) LagExcluded(space, group, node string) metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/%v/lagexcluded/%v/%v", space, group, node)
+}
+
+func (*NodeManagerMetrics) RouteSkew(space, group string) metrics.GaugeFloat64 {
+	return GetOrRegisterGaugeFloat64("infura/nodes/%v/routestats/%v/skew", space, group)
+}
+
+func (*NodeManagerMetrics) RouteTopKeyShare(space, group string) metrics.GaugeFloat64 {
+	return GetOrRegisterGaugeFloat64("infura/nodes/%v/routestats/%v/topkey/share", space, group)
+}
+
+func (*NodeManagerMetrics) RouteTopPartitionQps(space, group string) metrics.GaugeFloat64 {
+	return GetOrRegisterGaugeFloat64("infura/nodes/%v/routestats/%v/toppartition/qps", space, group)
+}
+
+func (*NodeManagerMetrics) RepartitionMoves(space, group string) metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/%v/repartition/%v/moves", space, group)
+}
+
+func (*NodeManagerMetrics) RouteHotKeys(space, group string) metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/%v/routestats/%v/hotkeys", space, group)
+}
+
 func (*NodeManagerMetrics) NodeLatency(space, group, node string) string {
 	return fmt.Sprintf("infura/nodes/%v/latency/%v/%v", space, group, node)
 }
@@ -186,6 +465,22 @@ func (*PubSubMetrics) InputLogFilter(space string) Percentage {
 	return GetOrRegisterTimeWindowPercentageDefault("infura/pubsub/%v/input/logFilter", space)
 }
 
+func (*PubSubMetrics) Failover(space, topic string, err error) metrics.Meter {
+	if util.IsInterfaceValNil(err) {
+		return GetOrRegisterMeter("infura/pubsub/%v/failover/%v/success", space, topic)
+	}
+
+	return GetOrRegisterMeter("infura/pubsub/%v/failover/%v/failure", space, topic)
+}
+
+func (*PubSubMetrics) ReplayTooLarge(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/pubsub/%v/failover/replay/tooLarge", space)
+}
+
+func (*PubSubMetrics) BufferOverflow(policy string) metrics.Meter {
+	return GetOrRegisterMeter("infura/pubsub/buffer/overflow/%v", policy)
+}
+
 // Virtual filter metrics
 type VirtualFilterMetrics struct{}
 
@@ -218,3 +513,30 @@ func (*VirtualFilterMetrics) StoreQueryPercentage(space string, node, store stri
 	metricName := fmt.Sprintf("infura/virtualFilter/percentage/query/%v/%v/filterChanges/%v", space, node, store)
 	return GetOrRegisterTimeWindowPercentageDefault(metricName)
 }
+
+// Transaction relay metrics
+type RelayMetrics struct{}
+
+func (*RelayMetrics) BroadcastQps(space string, err error) metrics.Timer {
+	if util.IsInterfaceValNil(err) {
+		return GetOrRegisterTimer("infura/relay/broadcast/%v/success", space)
+	}
+
+	return GetOrRegisterTimer("infura/relay/broadcast/%v/failure", space)
+}
+
+func (*RelayMetrics) BroadcastNodes(space string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/relay/broadcast/%v/nodes", space)
+}
+
+func (*RelayMetrics) BroadcastAccepted(space string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/relay/broadcast/%v/accepted", space)
+}
+
+func (*RelayMetrics) BroadcastConflicts(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/relay/broadcast/%v/conflicts", space)
+}
+
+func (*RelayMetrics) BroadcastAvailability(space, node string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/relay/broadcast/%v/availability/%v", space, node)
+}
@@ -1,11 +1,13 @@
 package metrics
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/metrics/influxdb"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,12 +29,20 @@ func Init() {
 			Enabled  bool
 			Interval time.Duration `default:"10s"`
 		}
+		Prometheus struct {
+			// HTTP endpoint to expose metrics in Prometheus text format, eg., ":9101"
+			Endpoint string
+		}
 	}
 
 	viper.MustUnmarshalKey("metrics", &config)
 
 	metrics.Enabled = config.Enabled
 
+	if metrics.Enabled && len(config.Prometheus.Endpoint) > 0 {
+		go servePrometheus(config.Prometheus.Endpoint)
+	}
+
 	if !metrics.Enabled || !config.Report.Enabled {
 		return
 	}
@@ -49,3 +59,15 @@ func Init() {
 
 	logrus.Info("Start to report metrics to influxdb periodically")
 }
+
+// servePrometheus exposes metrics at path `/metrics` for Prometheus to scrape.
+func servePrometheus(endpoint string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler(InfuraRegistry))
+
+	logrus.WithField("endpoint", endpoint).Info("Start to expose metrics for Prometheus")
+
+	if err := http.ListenAndServe(endpoint, mux); err != nil {
+		logrus.WithError(err).Error("Failed to serve metrics for Prometheus")
+	}
+}
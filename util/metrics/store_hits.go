@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	shcOncer   sync.Once
+	defaultShc *timeWindowStoreHitCollector
+)
+
+// DefaultStoreHitCollector returns the store hit collector of event logs queries within the
+// last hour.
+func DefaultStoreHitCollector() StoreHitCollector {
+	if !metrics.Enabled {
+		return &noopStoreHitCollector{}
+	}
+
+	shcOncer.Do(func() {
+		defaultShc = newTimeWindowStoreHitCollector(time.Minute, 60)
+	})
+
+	return defaultShc
+}
+
+// StoreHitCollector collects whether the queries are served by store per visitor source (eg., API
+// key) and query shape, so as to report the acceleration ratio of store for different workloads.
+type StoreHitCollector interface {
+	MarkHit(source, shape string, hit bool)
+	TopkStats(k int) []StoreHitStat
+}
+
+type noopStoreHitCollector struct{}
+
+func (nsc *noopStoreHitCollector) MarkHit(source, shape string, hit bool) {}
+func (nsc *noopStoreHitCollector) TopkStats(k int) []StoreHitStat         { return nil }
+
+// StoreHitStat store hit statistics of some visitor source and query shape.
+type StoreHitStat struct {
+	Source string  `json:"source"` // visitor source
+	Shape  string  `json:"shape"`  // query shape, eg., range type and filter conditions
+	Total  int     `json:"total"`  // number of queries
+	Hits   int     `json:"hits"`   // number of queries served by store
+	Ratio  float64 `json:"ratio"`  // acceleration ratio, percentage of queries served by store
+}
+
+type storeHitKey struct {
+	source, shape string
+}
+
+type storeHitCount struct {
+	total, hits int
+}
+
+// twStoreHitData time window store hit data
+type twStoreHitData struct {
+	data map[storeHitKey]storeHitCount
+}
+
+// implements `SlotData` interface
+
+func (d twStoreHitData) Add(v SlotData) SlotData {
+	rhs := v.(twStoreHitData)
+	for k, v := range rhs.data {
+		count := d.data[k]
+		count.total += v.total
+		count.hits += v.hits
+		d.data[k] = count
+	}
+
+	return twStoreHitData{data: d.data}
+}
+
+func (d twStoreHitData) Sub(v SlotData) SlotData {
+	rhs := v.(twStoreHitData)
+	for k, v := range rhs.data {
+		count := d.data[k]
+		count.total -= v.total
+		count.hits -= v.hits
+
+		if count.total <= 0 {
+			delete(d.data, k)
+		} else {
+			d.data[k] = count
+		}
+	}
+
+	return twStoreHitData{data: d.data}
+}
+
+func (d twStoreHitData) SnapShot() SlotData {
+	data := make(map[storeHitKey]storeHitCount, len(d.data))
+
+	for k, v := range d.data {
+		data[k] = v
+	}
+
+	return twStoreHitData{data: data}
+}
+
+// timeWindowStoreHitCollector collects store hits using sliding window
+type timeWindowStoreHitCollector struct {
+	window *TimeWindow // store hit data within a sliding time window.
+}
+
+func newTimeWindowStoreHitCollector(
+	slotInterval time.Duration, numSlots int) *timeWindowStoreHitCollector {
+
+	return &timeWindowStoreHitCollector{
+		window: NewTimeWindow(slotInterval, numSlots),
+	}
+}
+
+// MarkHit marks a query from the visitor source of some shape, and whether served by store.
+func (sc *timeWindowStoreHitCollector) MarkHit(source, shape string, hit bool) {
+	count := storeHitCount{total: 1}
+	if hit {
+		count.hits = 1
+	}
+
+	sc.window.Add(twStoreHitData{
+		data: map[storeHitKey]storeHitCount{{source, shape}: count},
+	})
+}
+
+// TopkStats returns the store hit statistics of topK visitor source and query shape with the most
+// queries, so that operators could tell which workloads actually benefit from the store.
+func (sc *timeWindowStoreHitCollector) TopkStats(k int) []StoreHitStat {
+	if k <= 0 {
+		return nil
+	}
+
+	sdata, ok := sc.window.Data().(twStoreHitData)
+	if !ok { // no queries yet
+		return nil
+	}
+
+	res := make([]StoreHitStat, 0, len(sdata.data))
+	for key, count := range sdata.data {
+		res = append(res, StoreHitStat{
+			Source: key.source,
+			Shape:  key.shape,
+			Total:  count.total,
+			Hits:   count.hits,
+			Ratio:  float64(count.hits) * 100 / float64(count.total),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+
+		if res[i].Source != res[j].Source {
+			return res[i].Source < res[j].Source
+		}
+
+		return res[i].Shape < res[j].Shape
+	})
+
+	if len(res) > k {
+		res = res[:k]
+	}
+
+	return res
+}
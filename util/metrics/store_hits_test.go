@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreHitCollector(t *testing.T) {
+	sc := newTimeWindowStoreHitCollector(time.Second, 5)
+
+	for i := 0; i < 4; i++ {
+		sc.MarkHit("key1", "block/addr:1/topics:0", i%2 == 0)
+	}
+
+	sc.MarkHit("key2", "blockhash/addr:0/topics:-", false)
+	sc.MarkHit("key2", "blockhash/addr:0/topics:-", false)
+	sc.MarkHit("key1", "block/addr:n/topics:-", true)
+
+	stats := sc.TopkStats(10)
+	assert.Equal(t, []StoreHitStat{
+		{Source: "key1", Shape: "block/addr:1/topics:0", Total: 4, Hits: 2, Ratio: 50},
+		{Source: "key2", Shape: "blockhash/addr:0/topics:-", Total: 2, Hits: 0, Ratio: 0},
+		{Source: "key1", Shape: "block/addr:n/topics:-", Total: 1, Hits: 1, Ratio: 100},
+	}, stats)
+
+	assert.Equal(t, stats[:1], sc.TopkStats(1))
+	assert.Nil(t, sc.TopkStats(0))
+
+	time.Sleep(6 * time.Second)
+	assert.Empty(t, sc.TopkStats(10))
+}
@@ -25,6 +25,15 @@ func DefaultTrafficCollector() TrafficCollector {
 	return defaultTc
 }
 
+// NewTrafficCollector creates traffic collector within a sliding time window.
+func NewTrafficCollector(slotInterval time.Duration, numSlots int) TrafficCollector {
+	if !metrics.Enabled {
+		return &noopTrafficCollector{}
+	}
+
+	return newTimeWindowTrafficCollector(slotInterval, numSlots)
+}
+
 // TrafficCollector collects traffic hits and calculate topK stats.
 type TrafficCollector interface {
 	MarkHit(source string)
@@ -117,8 +126,12 @@ func (tc *timeWindowTrafficCollector) TopkVisitors(k int) []Visitor {
 		return nil
 	}
 
+	tdata, ok := tc.window.Data().(twTrafficData)
+	if !ok { // no traffic yet
+		return nil
+	}
+
 	topkHeap := topkVisitorHeap(make([]*visitorItem, 0, k+1))
-	tdata := tc.window.Data().(twTrafficData)
 
 	for src, hits := range tdata.data {
 		vi := &visitorItem{
@@ -18,6 +18,9 @@ type KeyInfo struct {
 	AclID uint32    // bound allowlist ID
 	Key   string    // limit key
 	Type  LimitType // limit type
+
+	// time-of-day schedules to apply different strategies within specific time windows
+	Schedules []Schedule
 }
 
 type KeysetFilter struct {
@@ -174,6 +174,19 @@ func (r *Registry) genKeyInfoGroupAndKey(
 		return
 	}
 
+	// use the scheduled strategy instead if within the time window
+	if sch, ok := activeSchedule(ki.Schedules, time.Now()); ok {
+		if schStg, ok := r.strategies[sch.Strategy]; ok {
+			stg = schStg
+		} else {
+			logrus.WithFields(logrus.Fields{
+				"limitKey": limitKey,
+				"resource": resource,
+				"schedule": sch,
+			}).Warn("Rate limit strategy of schedule not found")
+		}
+	}
+
 	if _, ok := stg.LimitOptions[resource]; !ok {
 		// limit rule not defined
 		return
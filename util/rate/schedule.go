@@ -0,0 +1,150 @@
+package rate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// layout of schedule time of day, which is always in UTC
+	ScheduleTimeLayout = "15:04"
+)
+
+// Schedule time window within which the limit key will be rate limited by the bound strategy
+// rather than the default one, eg., higher limits during the batch window of a customer at night.
+type Schedule struct {
+	From     string // start time of day (inclusive) in the format of "HH:MM" (UTC)
+	To       string // end time of day (exclusive) in the format of "HH:MM" (UTC)
+	Strategy string // name of the strategy to apply within the time window
+}
+
+// ParseSchedule parses schedule from the format of "HH:MM-HH:MM=strategy", eg., "22:00-06:00=night".
+func ParseSchedule(s string) (sch Schedule, err error) {
+	window, strategy := s, ""
+	if i := strings.LastIndex(s, "="); i >= 0 {
+		window, strategy = s[:i], s[i+1:]
+	}
+
+	times := strings.Split(window, "-")
+	if len(times) != 2 {
+		return sch, errors.Errorf("malformed schedule %v", s)
+	}
+
+	sch = Schedule{
+		From:     strings.TrimSpace(times[0]),
+		To:       strings.TrimSpace(times[1]),
+		Strategy: strings.TrimSpace(strategy),
+	}
+
+	return sch, sch.Validate()
+}
+
+// Validate validates the time window and strategy of the schedule.
+func (s Schedule) Validate() error {
+	if len(s.Strategy) == 0 {
+		return errors.New("schedule strategy must not be empty")
+	}
+
+	from, err := parseTimeOfDay(s.From)
+	if err != nil {
+		return errors.WithMessage(err, "invalid schedule start time")
+	}
+
+	to, err := parseTimeOfDay(s.To)
+	if err != nil {
+		return errors.WithMessage(err, "invalid schedule end time")
+	}
+
+	if from == to {
+		return errors.New("empty schedule time window")
+	}
+
+	return nil
+}
+
+// Contains checks if the specified time is within the time window of the schedule. Time window
+// that crosses midnight (eg., "22:00-06:00") is also supported.
+func (s Schedule) Contains(t time.Time) bool {
+	from, err := parseTimeOfDay(s.From)
+	if err != nil {
+		return false
+	}
+
+	to, err := parseTimeOfDay(s.To)
+	if err != nil {
+		return false
+	}
+
+	t = t.UTC()
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if from < to {
+		return now >= from && now < to
+	}
+
+	// crosses midnight
+	return now >= from || now < to
+}
+
+func (s Schedule) String() string {
+	return fmt.Sprintf("%v-%v=%v", s.From, s.To, s.Strategy)
+}
+
+// MarshalSchedules marshals schedules into JSON string for persistence.
+func MarshalSchedules(schedules []Schedule) (string, error) {
+	if len(schedules) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// UnmarshalSchedules unmarshals schedules from the persisted JSON string.
+func UnmarshalSchedules(data string) ([]Schedule, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var schedules []Schedule
+	if err := json.Unmarshal([]byte(data), &schedules); err != nil {
+		return nil, errors.WithMessage(err, "malformed json format")
+	}
+
+	for i := range schedules {
+		if err := schedules[i].Validate(); err != nil {
+			return nil, errors.WithMessagef(err, "invalid schedule %v", schedules[i])
+		}
+	}
+
+	return schedules, nil
+}
+
+// activeSchedule returns the first schedule whose time window contains the specified time.
+func activeSchedule(schedules []Schedule, t time.Time) (Schedule, bool) {
+	for _, s := range schedules {
+		if s.Contains(t) {
+			return s, true
+		}
+	}
+
+	return Schedule{}, false
+}
+
+// parseTimeOfDay parses the "HH:MM" time of day into duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(ScheduleTimeLayout, s)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
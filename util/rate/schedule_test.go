@@ -0,0 +1,60 @@
+package rate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	sch, err := ParseSchedule("22:00-06:00=night")
+	assert.NoError(t, err)
+	assert.Equal(t, Schedule{From: "22:00", To: "06:00", Strategy: "night"}, sch)
+
+	for _, s := range []string{"22:00-06:00", "22:00=night", "25:00-06:00=night", "06:00-06:00=night"} {
+		_, err = ParseSchedule(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestScheduleContains(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2023, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	day := Schedule{From: "09:00", To: "17:30", Strategy: "day"}
+	assert.False(t, day.Contains(at(8, 59)))
+	assert.True(t, day.Contains(at(9, 0)))
+	assert.True(t, day.Contains(at(17, 29)))
+	assert.False(t, day.Contains(at(17, 30)))
+
+	night := Schedule{From: "22:00", To: "06:00", Strategy: "night"}
+	assert.True(t, night.Contains(at(23, 0)))
+	assert.True(t, night.Contains(at(0, 0)))
+	assert.True(t, night.Contains(at(5, 59)))
+	assert.False(t, night.Contains(at(6, 0)))
+	assert.False(t, night.Contains(at(21, 59)))
+
+	sch, ok := activeSchedule([]Schedule{day, night}, at(3, 0))
+	assert.True(t, ok)
+	assert.Equal(t, night, sch)
+
+	_, ok = activeSchedule([]Schedule{day, night}, at(20, 0))
+	assert.False(t, ok)
+}
+
+func TestMarshalSchedules(t *testing.T) {
+	schedules := []Schedule{{From: "22:00", To: "06:00", Strategy: "night"}}
+
+	data, err := MarshalSchedules(schedules)
+	assert.NoError(t, err)
+
+	res, err := UnmarshalSchedules(data)
+	assert.NoError(t, err)
+	assert.Equal(t, schedules, res)
+
+	res, err = UnmarshalSchedules("")
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
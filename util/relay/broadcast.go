@@ -0,0 +1,200 @@
+package relay
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errBroadcastTimeout = errors.New("timeout to broadcast raw transaction")
+)
+
+// BroadcastConfig raw transaction broadcasting configurations
+type BroadcastConfig struct {
+	// whether to broadcast raw transaction to healthy full nodes of the route group
+	// concurrently rather than the routed full node only
+	Enabled bool
+	// max number of full nodes to broadcast (including the routed one), 0 means all
+	MaxNodes int
+	// timeout to wait for broadcasting results
+	Timeout time.Duration `default:"3s"`
+}
+
+// SendFunc sends raw transaction to the full node of the specified URL.
+type SendFunc func(url string) (txHash string, err error)
+
+// Broadcaster broadcasts raw transaction to full nodes concurrently, and deduplicates the
+// results by transaction hash.
+type Broadcaster struct {
+	space string
+	conf  BroadcastConfig
+}
+
+// MustNewBroadcasterFromViper creates broadcaster from viper settings, or nil if disabled.
+func MustNewBroadcasterFromViper(space string) *Broadcaster {
+	var conf BroadcastConfig
+	viper.MustUnmarshalKey("relay.broadcast", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewBroadcaster(space, conf)
+}
+
+func NewBroadcaster(space string, conf BroadcastConfig) *Broadcaster {
+	return &Broadcaster{space: space, conf: conf}
+}
+
+type broadcastResult struct {
+	url    string
+	txHash string
+	err    error
+}
+
+// Broadcast broadcasts raw transaction to the routed full node along with the other full nodes
+// concurrently, and returns the transaction hash accepted by most full nodes. If no full node
+// accepted, the error from the routed full node is preferred to return.
+func (b *Broadcaster) Broadcast(routedUrl string, urls []string, send SendFunc) (txHash string, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.Registry.Relay.BroadcastQps(b.space, err).UpdateSince(start)
+	}()
+
+	targets := b.selectNodes(routedUrl, urls)
+	metrics.Registry.Relay.BroadcastNodes(b.space).Update(int64(len(targets)))
+
+	// buffered to never block the senders in case of timeout
+	resultCh := make(chan broadcastResult, len(targets))
+	for _, url := range targets {
+		go func(url string) {
+			txHash, err := send(url)
+
+			nodeName := rpcutil.Url2NodeName(url)
+			metrics.Registry.Relay.BroadcastAvailability(b.space, nodeName).Mark(err == nil)
+
+			resultCh <- broadcastResult{url: url, txHash: txHash, err: err}
+		}(url)
+	}
+
+	timer := time.NewTimer(b.conf.Timeout)
+	defer timer.Stop()
+
+	var results []broadcastResult
+	for len(results) < len(targets) {
+		select {
+		case res := <-resultCh:
+			results = append(results, res)
+		case <-timer.C:
+			logrus.WithFields(logrus.Fields{
+				"space":   b.space,
+				"nodes":   len(targets),
+				"results": len(results),
+			}).Debug("Broadcaster timed out to wait for all broadcasting results")
+
+			return b.dedup(routedUrl, results)
+		}
+	}
+
+	return b.dedup(routedUrl, results)
+}
+
+// dedup deduplicates the broadcasting results by transaction hash.
+func (b *Broadcaster) dedup(routedUrl string, results []broadcastResult) (string, error) {
+	var routedHash string
+	var routedErr, firstErr error
+
+	// deduplicated tx hash => number of accepted full nodes
+	hashes := make(map[string]int)
+
+	for _, res := range results {
+		isRouted := res.url == routedUrl
+
+		if res.err == nil {
+			hashes[res.txHash]++
+
+			if isRouted {
+				routedHash = res.txHash
+			}
+
+			continue
+		}
+
+		if isRouted {
+			routedErr = res.err
+		} else if firstErr == nil {
+			firstErr = res.err
+		}
+	}
+
+	var accepted int
+	for _, n := range hashes {
+		accepted += n
+	}
+
+	metrics.Registry.Relay.BroadcastAccepted(b.space).Update(int64(accepted))
+
+	if len(hashes) == 0 {
+		switch {
+		case routedErr != nil:
+			return "", routedErr
+		case firstErr != nil:
+			return "", firstErr
+		default:
+			return "", errBroadcastTimeout
+		}
+	}
+
+	if len(hashes) > 1 { // should never happen for the same raw transaction
+		metrics.Registry.Relay.BroadcastConflicts(b.space).Mark(1)
+		logrus.WithFields(logrus.Fields{
+			"space":  b.space,
+			"hashes": hashes,
+		}).Warn("Broadcaster got conflicting tx hashes from full nodes")
+	}
+
+	if len(routedHash) > 0 {
+		return routedHash, nil
+	}
+
+	var txHash string
+	for hash, n := range hashes {
+		if n > hashes[txHash] {
+			txHash = hash
+		}
+	}
+
+	return txHash, nil
+}
+
+// selectNodes selects the full nodes to broadcast with the routed full node always included,
+// and others are randomly selected if the max number of full nodes exceeded.
+func (b *Broadcaster) selectNodes(routedUrl string, urls []string) []string {
+	targets := []string{routedUrl}
+	visited := map[string]bool{rpcutil.Url2NodeName(routedUrl): true}
+
+	var others []string
+	for _, url := range urls {
+		nodeName := rpcutil.Url2NodeName(url)
+		if !visited[nodeName] {
+			visited[nodeName] = true
+			others = append(others, url)
+		}
+	}
+
+	if b.conf.MaxNodes > 0 && len(others) >= b.conf.MaxNodes {
+		rand.Shuffle(len(others), func(i, j int) {
+			others[i], others[j] = others[j], others[i]
+		})
+
+		others = others[:b.conf.MaxNodes-1]
+	}
+
+	return append(targets, others...)
+}
@@ -0,0 +1,48 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcasterBroadcast(t *testing.T) {
+	b := NewBroadcaster("cfx", BroadcastConfig{Timeout: time.Second})
+	urls := []string{"http://node1", "http://node2", "http://node3"}
+
+	// routed node rejected but accepted by others
+	txHash, err := b.Broadcast("http://node1", urls, func(url string) (string, error) {
+		if url == "http://node1" {
+			return "", errors.New("routed node unavailable")
+		}
+
+		return "0xabc", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xabc", txHash)
+
+	// error of routed node preferred if rejected by all
+	routedErr := errors.New("nonce too stale")
+	_, err = b.Broadcast("http://node1", urls, func(url string) (string, error) {
+		if url == "http://node1" {
+			return "", routedErr
+		}
+
+		return "", errors.New("other error")
+	})
+	assert.Equal(t, routedErr, err)
+}
+
+func TestBroadcasterSelectNodes(t *testing.T) {
+	b := NewBroadcaster("eth", BroadcastConfig{MaxNodes: 2})
+
+	targets := b.selectNodes("http://node1", []string{"http://node1", "http://node2", "http://node3"})
+	assert.Len(t, targets, 2)
+	assert.Equal(t, "http://node1", targets[0])
+
+	b.conf.MaxNodes = 0
+	targets = b.selectNodes("http://node1", []string{"http://NODE1", "http://node2", "http://node3"})
+	assert.Equal(t, []string{"http://node1", "http://node2", "http://node3"}, targets)
+}
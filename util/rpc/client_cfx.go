@@ -4,6 +4,7 @@ import (
 	"time"
 
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
 	"github.com/sirupsen/logrus"
 )
 
@@ -59,10 +60,38 @@ func NewCfxClient(url string, options ...ClientOption) (*sdk.Client, error) {
 		o(opt)
 	}
 
+	// Note, SDK dials with its default provider to initialize (eg., query network ID), which is the
+	// reason that custom TLS (`eth.tls`) is scoped to evm space only.
 	cfx, err := sdk.NewClient(url, *opt.ClientOption)
-	if err == nil && opt.hookMetrics {
+	if err != nil {
+		return nil, err
+	}
+
+	// replace the provider dialed by SDK with pooled keep-alive connections, which also counts bytes
+	// on wire if usage accounting enabled, since SDK could not dial with custom connections.
+	p, err := newPooledProvider(url, nil, &cfxClientCfg.Pool, providers.Option{
+		RetryCount:           opt.RetryCount,
+		RetryInterval:        opt.RetryInterval,
+		RequestTimeout:       opt.RequestTimeout,
+		MaxConnectionPerHost: opt.MaxConnectionPerHost,
+	}, usageConnWrapper(url, "cfx"))
+	if err != nil {
+		cfx.Close()
+		return nil, err
+	}
+
+	sdkProvider := cfx.MiddlewarableProvider
+	cfx.MiddlewarableProvider = p
+	sdkProvider.Close()
+
+	if opt.hookMetrics {
 		HookMiddlewares(cfx.Provider(), url, "cfx")
 	}
 
-	return cfx, err
+	// account usages for all clients regardless of metrics, eg., sync and catch-up workers
+	hookUsageMiddlewares(cfx.Provider(), url, "cfx")
+
+	opt.hookCallMiddlewares(cfx.Provider(), url)
+
+	return cfx, nil
 }
@@ -5,6 +5,7 @@ import (
 
 	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
 	"github.com/openweb3/web3go"
+	w3providers "github.com/openweb3/web3go/providers"
 	"github.com/sirupsen/logrus"
 )
 
@@ -58,10 +59,49 @@ func NewEthClient(url string, options ...ClientOption) (*web3go.Client, error) {
 		o(&opt)
 	}
 
-	eth, err := web3go.NewClientWithOption(url, opt.ClientOption)
-	if err == nil && opt.hookMetrics {
+	// count bytes on wire if usage accounting enabled
+	wrapConn := usageConnWrapper(url, "eth")
+
+	var eth *web3go.Client
+
+	if ethTLSCfg.Applicable(url) {
+		// mutual TLS, which could not be dialed by web3go with client certificate
+		p, err := newTLSProvider(url, &ethTLSCfg, &ethClientCfg.Pool, opt.Option, wrapConn)
+		if err != nil {
+			return nil, err
+		}
+
+		eth = web3go.NewClientWithProvider(p)
+	} else {
+		var err error
+		if eth, err = web3go.NewClientWithOption(url, opt.ClientOption); err != nil {
+			return nil, err
+		}
+
+		// replace the provider dialed by web3go with pooled keep-alive connections
+		p, err := newPooledProvider(url, nil, &ethClientCfg.Pool, opt.Option, wrapConn)
+		if err != nil {
+			eth.Close()
+			return nil, err
+		}
+
+		w3Provider := eth.Provider()
+		if opt.SignerManager != nil {
+			eth.SetProvider(w3providers.NewSignableProvider(p, opt.SignerManager))
+		} else {
+			eth.SetProvider(p)
+		}
+		w3Provider.Close()
+	}
+
+	if opt.hookMetrics {
 		HookMiddlewares(eth.Provider(), url, "eth")
 	}
 
-	return eth, err
+	// account usages for all clients regardless of metrics, eg., sync and catch-up workers
+	hookUsageMiddlewares(eth.Provider(), url, "eth")
+
+	opt.hookCallMiddlewares(eth.Provider(), url)
+
+	return eth, nil
 }
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"golang.org/x/time/rate"
+)
+
+// HostRateLimiter limits the rate of RPC requests per fullnode host, which could be shared by the
+// clients of both core space and evm space, since they are usually served by the same fullnode
+// though at different ports.
+type HostRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // host => limiter
+}
+
+// NewHostRateLimiter creates rate limiter with the max number of requests per second and burst
+// allowed for each fullnode host.
+func NewHostRateLimiter(rps float64, burst int) *HostRateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &HostRateLimiter{
+		limit:    rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// limiter gets or creates the rate limiter of fullnode host.
+func (l *HostRateLimiter) limiter(rawurl string) *rate.Limiter {
+	host := rawurl
+	if u, err := url.Parse(rawurl); err == nil && len(u.Hostname()) > 0 {
+		host = u.Hostname()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[host] = limiter
+	}
+
+	return limiter
+}
+
+// Middleware returns the RPC client call middleware to wait for the rate limit of fullnode host.
+func (l *HostRateLimiter) Middleware(rawurl string) providers.CallContextMiddleware {
+	limiter := l.limiter(rawurl)
+
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+
+			return handler(ctx, result, method, args...)
+		}
+	}
+}
+
+// BatchMiddleware returns the RPC client batch call middleware to wait for the rate limit of fullnode
+// host, which takes each batch item as a request.
+func (l *HostRateLimiter) BatchMiddleware(rawurl string) providers.BatchCallContextMiddleware {
+	limiter := l.limiter(rawurl)
+
+	return func(handler providers.BatchCallContextFunc) providers.BatchCallContextFunc {
+		return func(ctx context.Context, b []rpc.BatchElem) error {
+			for range b {
+				if err := limiter.Wait(ctx); err != nil {
+					return err
+				}
+			}
+
+			return handler(ctx, b)
+		}
+	}
+}
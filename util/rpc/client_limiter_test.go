@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openweb3/web3go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostRateLimiterSharedByHost(t *testing.T) {
+	l := NewHostRateLimiter(10, 0)
+
+	cfx := l.limiter("http://127.0.0.1:12537")
+	eth := l.limiter("ws://127.0.0.1:8545")
+	other := l.limiter("http://10.0.0.1:12537")
+
+	assert.Same(t, cfx, eth)
+	assert.NotSame(t, cfx, other)
+	assert.Equal(t, 1, cfx.Burst())
+}
+
+func TestHostRateLimiterSharedByClients(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	})
+
+	// fullnodes of both spaces served by the same host at different ports
+	cfxServer, ethServer := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer cfxServer.Close()
+	defer ethServer.Close()
+
+	const rps, requests = 50, 21
+	limiter := NewHostRateLimiter(rps, 1)
+
+	var clients []*web3go.Client
+	for _, url := range []string{cfxServer.URL, ethServer.URL} {
+		client, err := NewEthClient(url, WithClientRateLimiter(limiter))
+		assert.NoError(t, err)
+		defer client.Close()
+
+		clients = append(clients, client)
+	}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(client *web3go.Client) {
+			defer wg.Done()
+
+			_, err := client.Eth.BlockNumber()
+			assert.NoError(t, err)
+		}(clients[i%len(clients)])
+	}
+	wg.Wait()
+
+	// all requests of both clients are throttled by the same limiter, otherwise done in half time
+	assert.GreaterOrEqual(t, time.Since(start), time.Second*(requests-1)/rps)
+}
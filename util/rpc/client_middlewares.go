@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"net/url"
 	"strings"
 	"time"
 
@@ -20,8 +21,38 @@ func Url2NodeName(url string) string {
 	return strings.TrimPrefix(nodeName, "/")
 }
 
+// fullnodeConfig configurations of upstream fullnodes.
+type fullnodeConfig struct {
+	// aliases of fullnode URLs to name fullnodes in metrics, logs and status reports
+	Aliases []fullnodeAlias
+}
+
+type fullnodeAlias struct {
+	URL   string
+	Alias string
+}
+
+// NodeAlias returns the configured alias of fullnode URL, or the host (with port if any) of URL by
+// default, which is safe to expose in metrics, logs and status reports. Note, Url2NodeName should not
+// be exposed since hosted fullnode URLs may contain credentials (eg., API key in URL path).
+func NodeAlias(rawurl string) string {
+	for _, v := range fullnodeCfg.Aliases {
+		if strings.EqualFold(v.URL, rawurl) && len(v.Alias) > 0 {
+			return v.Alias
+		}
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil || len(u.Host) == 0 { // eg., IPC path
+		return Url2NodeName(rawurl)
+	}
+
+	return strings.ToLower(u.Host)
+}
+
 func HookMiddlewares(provider *providers.MiddlewarableProvider, url, space string) {
-	nodeName := Url2NodeName(url)
+	nodeName := NodeAlias(url)
+	provider.HookCallContext(middlewareUpstreamError)
 	provider.HookCallContext(middlewareLog(nodeName, space))
 	provider.HookCallContext(middlewareMetrics(nodeName, space))
 }
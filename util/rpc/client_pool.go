@@ -0,0 +1,305 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/gorilla/websocket"
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	// CfxClientPool shared core space clients per fullnode.
+	CfxClientPool = newClientPool(&cfxClientCfg.Pool, func(url string, opts ...ClientOption) (interface{}, error) {
+		return NewCfxClient(url, append(opts, WithClientHookMetrics(true))...)
+	})
+
+	// EthClientPool shared evm space clients per fullnode.
+	EthClientPool = newClientPool(&ethClientCfg.Pool, func(url string, opts ...ClientOption) (interface{}, error) {
+		return NewEthClient(url, append(opts, WithClientHookMetrics(true))...)
+	})
+)
+
+// clientPoolConfig connection pooling and keep-alive configurations to dial upstream fullnodes. Note,
+// HTTP/2 is not supported since HTTP requests are sent by fasthttp, which speaks HTTP/1.1 only.
+type clientPoolConfig struct {
+	// timeout to dial a new connection to fullnode
+	DialTimeout time.Duration `default:"3s"`
+	// duration to keep idle HTTP connections alive before closed
+	MaxIdleConnDuration time.Duration `default:"1m"`
+	// duration to wait for a free HTTP connection if `maxConnsPerHost` reached, 0 to fail immediately
+	MaxConnWaitTimeout time.Duration
+	// max lifetime of HTTP connections before closed, so that connections are re-balanced among
+	// fullnodes behind load balancer, 0 for unlimited
+	MaxConnDuration time.Duration
+	// per connection buffer size for reading or writing HTTP messages, 0 for default (4KB)
+	ReadBufferSize  int
+	WriteBufferSize int
+	// base and max interval to back off before reconnecting to fullnode, with jitter applied
+	ReconnectInterval    time.Duration `default:"1s"`
+	MaxReconnectInterval time.Duration `default:"30s"`
+}
+
+// backoff returns the jittered interval to wait before reconnecting after consecutive failures.
+func (c *clientPoolConfig) backoff(failures int) time.Duration {
+	interval := c.ReconnectInterval
+	for i := 1; i < failures && interval < c.MaxReconnectInterval; i++ {
+		interval *= 2
+	}
+
+	if interval > c.MaxReconnectInterval {
+		interval = c.MaxReconnectInterval
+	}
+
+	if interval <= 0 {
+		return 0
+	}
+
+	// jitter in [interval/2, interval] so as to avoid reconnecting in lockstep
+	half := int64(interval / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// newPooledProvider dials the fullnode with pooled keep-alive connections and TLS configurations if
+// provided, and wraps the provider with timeout and retry as the default provider does. Besides, the
+// dialed connections could be wrapped if specified, eg., to count bytes on wire.
+func newPooledProvider(
+	rawurl string,
+	tlsConf *tls.Config,
+	poolConf *clientPoolConfig,
+	option providers.Option,
+	wrapConn func(net.Conn) net.Conn,
+) (*providers.MiddlewarableProvider, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid URL")
+	}
+
+	var client *rpc.Client
+	switch u.Scheme {
+	case "http", "https":
+		client, err = rpc.DialHTTPWithClient(rawurl, &fasthttp.Client{
+			TLSConfig:           tlsConf,
+			MaxConnsPerHost:     option.MaxConnectionPerHost,
+			MaxIdleConnDuration: poolConf.MaxIdleConnDuration,
+			MaxConnWaitTimeout:  poolConf.MaxConnWaitTimeout,
+			MaxConnDuration:     poolConf.MaxConnDuration,
+			ReadBufferSize:      poolConf.ReadBufferSize,
+			WriteBufferSize:     poolConf.WriteBufferSize,
+			Dial: func(addr string) (net.Conn, error) {
+				conn, err := fasthttp.DialTimeout(addr, poolConf.DialTimeout)
+				if err == nil && wrapConn != nil {
+					conn = wrapConn(conn)
+				}
+
+				return conn, err
+			},
+		})
+	case "ws", "wss":
+		dialer := websocket.Dialer{
+			TLSClientConfig:  tlsConf,
+			HandshakeTimeout: poolConf.DialTimeout,
+			Proxy:            websocket.DefaultDialer.Proxy,
+		}
+
+		if wrapConn != nil {
+			dialer.NetDialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				if err == nil {
+					conn = wrapConn(conn)
+				}
+
+				return conn, err
+			}
+		}
+		client, err = rpc.DialWebsocketWithDialer(context.Background(), rawurl, "", dialer)
+	default: // eg., IPC
+		client, err = rpc.DialContext(context.Background(), rawurl)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := providers.NewTimeoutableProvider(client, option.RequestTimeout)
+	p = providers.NewRetriableProvider(p, option.RetryCount, option.RetryInterval)
+
+	return p, nil
+}
+
+// ClientPool manages RPC clients shared per fullnode, so that handlers reuse the same client (and its
+// keep-alive connections) rather than create one for each call. If failed to connect, it backs off
+// with jitter before reconnecting again, so as not to overwhelm the unavailable fullnode. Besides,
+// the shared client is evicted once any call failed due to transport error (eg., connection broken),
+// so that it will be reconnected on next retrieval.
+type ClientPool struct {
+	mu      sync.Mutex
+	conf    *clientPoolConfig
+	factory func(url string, options ...ClientOption) (interface{}, error)
+	clients map[string]*pooledClient // url => client
+}
+
+type pooledClient struct {
+	client   interface{} // nil if failed to connect
+	err      error       // latest connection error
+	failures int         // consecutive connection failures
+	retryAt  time.Time   // time to reconnect after failure
+}
+
+func newClientPool(
+	conf *clientPoolConfig, factory func(url string, options ...ClientOption) (interface{}, error),
+) *ClientPool {
+	return &ClientPool{
+		conf:    conf,
+		factory: factory,
+		clients: make(map[string]*pooledClient),
+	}
+}
+
+// Get gets or creates the shared client of the fullnode. Note, the shared client should never be
+// closed by caller, but evicted from pool instead.
+func (p *ClientPool) Get(url string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc, ok := p.clients[url]
+	if ok && pc.client != nil {
+		return pc.client, nil
+	}
+
+	if ok && time.Now().Before(pc.retryAt) {
+		return nil, errors.WithMessage(pc.err, "reconnect backoff")
+	}
+
+	if !ok {
+		pc = &pooledClient{}
+		p.clients[url] = pc
+	}
+
+	client, err := p.factory(url, WithClientCallMiddlewares(p.middlewareEvict(url, pc)))
+	if err != nil {
+		pc.err, pc.failures = err, pc.failures+1
+		pc.retryAt = time.Now().Add(p.conf.backoff(pc.failures))
+
+		logrus.WithField("url", url).WithError(err).Info("Failed to connect to full node for client pool")
+		return nil, err
+	}
+
+	pc.client, pc.err, pc.failures = client, nil, 0
+
+	return client, nil
+}
+
+// Evict closes and removes the shared client of the fullnode, eg., connection broken, so that it will
+// be reconnected on next retrieval.
+func (p *ClientPool) Evict(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pc, ok := p.clients[url]; ok {
+		p.evict(url, pc)
+	}
+}
+
+// evict closes and removes the pooled client of the fullnode if not evicted yet, which must be called
+// with lock held.
+func (p *ClientPool) evict(url string, pc *pooledClient) {
+	if p.clients[url] != pc || pc.client == nil {
+		return
+	}
+
+	if c, ok := pc.client.(interface{ Close() }); ok {
+		c.Close()
+	}
+
+	delete(p.clients, url)
+}
+
+// middlewareEvict evicts the pooled client once any call failed due to transport error. Note, the
+// pooled client is captured so that a newly reconnected client is never evicted by stale errors.
+func (p *ClientPool) middlewareEvict(url string, pc *pooledClient) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			err := handler(ctx, result, method, args...)
+			if !isTransportError(err) {
+				return err
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"node": NodeAlias(url), "method": method,
+			}).WithError(err).Info("Evicting pooled client of full node due to transport error")
+
+			// closes the client asynchronously to not block the call
+			go func() {
+				p.mu.Lock()
+				defer p.mu.Unlock()
+
+				p.evict(url, pc)
+			}()
+
+			return err
+		}
+	}
+}
+
+// isTransportError returns whether the error is caused by broken connection to fullnode, rather than
+// JSON-RPC error responded or request timeout.
+func isTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if _, ok := err.(rpc.Error); ok { // JSON-RPC error
+		return false
+	}
+
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return !netErr.Timeout()
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, rpc.ErrClientQuit) ||
+		errors.Is(err, fasthttp.ErrConnectionClosed) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// GetCfxClient gets or creates the shared core space client of the fullnode.
+func (p *ClientPool) GetCfxClient(url string) (*sdk.Client, error) {
+	client, err := p.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*sdk.Client), nil
+}
+
+// GetEthClient gets or creates the shared evm space client of the fullnode.
+func (p *ClientPool) GetEthClient(url string) (*web3go.Client, error) {
+	client, err := p.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*web3go.Client), nil
+}
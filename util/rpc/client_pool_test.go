@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientPoolReconnectBackoff(t *testing.T) {
+	var dials int
+	var dialErr error
+
+	conf := clientPoolConfig{ReconnectInterval: time.Hour, MaxReconnectInterval: time.Hour}
+	pool := newClientPool(&conf, func(url string, options ...ClientOption) (interface{}, error) {
+		dials++
+		if dialErr != nil {
+			return nil, dialErr
+		}
+
+		return url, nil
+	})
+
+	dialErr = errors.New("connection refused")
+	_, err := pool.Get("http://node1")
+	assert.Error(t, err)
+
+	// backoff before reconnecting
+	dialErr = nil
+	_, err = pool.Get("http://node1")
+	assert.Error(t, err)
+	assert.Equal(t, 1, dials)
+
+	// shared client reused once connected
+	client, err := pool.Get("http://node2")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://node2", client)
+
+	_, err = pool.Get("http://node2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, dials)
+
+	// reconnect once evicted
+	pool.Evict("http://node2")
+	_, err = pool.Get("http://node2")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dials)
+}
+
+func TestClientPoolBackoffJitter(t *testing.T) {
+	conf := clientPoolConfig{ReconnectInterval: time.Second, MaxReconnectInterval: 10 * time.Second}
+
+	for failures, max := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 10 * time.Second} {
+		backoff := conf.backoff(failures)
+		assert.True(t, backoff >= max/2 && backoff <= max, "failures = %v, backoff = %v", failures, backoff)
+	}
+}
+
+func TestIsTransportError(t *testing.T) {
+	assert.False(t, isTransportError(nil))
+	assert.False(t, isTransportError(context.DeadlineExceeded))
+	assert.False(t, isTransportError(&rpc.JsonError{Code: -32000, Message: "execution reverted"}))
+	assert.False(t, isTransportError(&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}))
+
+	assert.True(t, isTransportError(io.EOF))
+	assert.True(t, isTransportError(errors.WithMessage(io.ErrUnexpectedEOF, "failed to read response")))
+	assert.True(t, isTransportError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.True(t, isTransportError(&websocket.CloseError{Code: websocket.CloseAbnormalClosure}))
+	assert.True(t, isTransportError(rpc.ErrClientQuit))
+}
+
+func TestClientPoolEvictOnTransportError(t *testing.T) {
+	var callErr error
+	handler := func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		return callErr
+	}
+
+	var dials int
+	var calls []providers.CallContextFunc
+
+	conf := clientPoolConfig{}
+	pool := newClientPool(&conf, func(url string, options ...ClientOption) (interface{}, error) {
+		dials++
+
+		var opt ethClientOption
+		for _, o := range options {
+			o(&opt)
+		}
+
+		call := handler
+		for _, mw := range opt.callMiddlewares {
+			call = mw(call)
+		}
+		calls = append(calls, call)
+
+		return dials, nil
+	})
+
+	client, _ := pool.Get("http://node1")
+	assert.Equal(t, 1, client)
+
+	// JSON-RPC error responded
+	callErr = &rpc.JsonError{Code: -32000, Message: "execution reverted"}
+	assert.Error(t, calls[0](context.Background(), nil, "eth_call"))
+
+	// connection broken
+	callErr = io.EOF
+	assert.Error(t, calls[0](context.Background(), nil, "eth_call"))
+
+	assert.Eventually(t, func() bool {
+		client, _ := pool.Get("http://node1")
+		return client == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// stale errors of evicted client never evict the reconnected one
+	assert.Error(t, calls[0](context.Background(), nil, "eth_call"))
+	time.Sleep(50 * time.Millisecond)
+
+	client, _ = pool.Get("http://node1")
+	assert.Equal(t, 2, client)
+	assert.Equal(t, 2, dials)
+}
@@ -0,0 +1,262 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	gometrics "github.com/ethereum/go-ethereum/metrics"
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+)
+
+var (
+	usageCfg upstreamUsageConfig
+
+	// process wide usages of upstream fullnodes
+	upstreamUsages = newUsageAccountant()
+)
+
+// upstreamUsageConfig configurations to account RPC usages per upstream fullnode, so that operators
+// paying per request for hosted fullnodes could attribute costs.
+type upstreamUsageConfig struct {
+	// whether to account requests, errors and byte volumes per upstream fullnode
+	Enabled bool
+}
+
+// UpstreamUsage accounted RPC usage of some upstream fullnode since process started or last reset.
+// Note, the fullnode is named by the alias or host of URL, so that credentials in URL never exposed.
+type UpstreamUsage struct {
+	Node          string            `json:"node"`
+	Space         string            `json:"space"`
+	Requests      uint64            `json:"requests"`      // number of requests, including batch items
+	Errors        uint64            `json:"errors"`        // number of failed requests
+	RequestBytes  uint64            `json:"requestBytes"`  // bytes written on wire
+	ResponseBytes uint64            `json:"responseBytes"` // bytes read on wire
+	Methods       map[string]uint64 `json:"methods"`       // number of requests per method
+}
+
+// wireCounter counts bytes on wire of the connections to some upstream fullnode.
+type wireCounter struct {
+	out, in uint64 // accessed atomically
+
+	node, space string
+
+	outMeter, inMeter gometrics.Meter
+}
+
+func (c *wireCounter) write(n int) {
+	atomic.AddUint64(&c.out, uint64(n))
+	c.outMeter.Mark(int64(n))
+}
+
+func (c *wireCounter) read(n int) {
+	atomic.AddUint64(&c.in, uint64(n))
+	c.inMeter.Mark(int64(n))
+}
+
+// countingConn counts bytes read from and written to the underlying connection.
+type countingConn struct {
+	net.Conn
+	counter *wireCounter
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.counter.read(n)
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.counter.write(n)
+	return n, err
+}
+
+// usageAccountant accounts RPC usages per upstream fullnode.
+type usageAccountant struct {
+	mu     sync.Mutex
+	usages map[string]*UpstreamUsage // space/node => usage
+	wires  map[string]*wireCounter   // space/node => wire counter, never reset
+}
+
+func newUsageAccountant() *usageAccountant {
+	return &usageAccountant{
+		usages: make(map[string]*UpstreamUsage),
+		wires:  make(map[string]*wireCounter),
+	}
+}
+
+// getOrCreate returns the usage of fullnode, which must be called with lock held.
+func (a *usageAccountant) getOrCreate(node, space string) *UpstreamUsage {
+	key := space + "/" + node
+
+	usage, ok := a.usages[key]
+	if !ok {
+		usage = &UpstreamUsage{Node: node, Space: space, Methods: make(map[string]uint64)}
+		a.usages[key] = usage
+	}
+
+	return usage
+}
+
+func (a *usageAccountant) account(node, space, method string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage := a.getOrCreate(node, space)
+	usage.Requests++
+	usage.Methods[method]++
+
+	if err != nil {
+		usage.Errors++
+	}
+}
+
+// wire returns the wire counter of fullnode, which is shared by all connections to the fullnode.
+func (a *usageAccountant) wire(node, space string) *wireCounter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := space + "/" + node
+
+	counter, ok := a.wires[key]
+	if !ok {
+		counter = &wireCounter{
+			node:     node,
+			space:    space,
+			outMeter: metrics.Registry.RPC.FullnodeUsageBytes(node, space, "out"),
+			inMeter:  metrics.Registry.RPC.FullnodeUsageBytes(node, space, "in"),
+		}
+		a.wires[key] = counter
+	}
+
+	return counter
+}
+
+// snapshot returns the accounted usages sorted by space and node, and resets them if required.
+func (a *usageAccountant) snapshot(reset bool) []UpstreamUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, counter := range a.wires {
+		var out, in uint64
+		if reset {
+			out, in = atomic.SwapUint64(&counter.out, 0), atomic.SwapUint64(&counter.in, 0)
+		} else {
+			out, in = atomic.LoadUint64(&counter.out), atomic.LoadUint64(&counter.in)
+		}
+
+		if out == 0 && in == 0 {
+			continue
+		}
+
+		usage := a.getOrCreate(counter.node, counter.space)
+		usage.RequestBytes, usage.ResponseBytes = out, in
+	}
+
+	result := make([]UpstreamUsage, 0, len(a.usages))
+	for _, v := range a.usages {
+		usage := *v
+
+		usage.Methods = make(map[string]uint64, len(v.Methods))
+		for method, requests := range v.Methods {
+			usage.Methods[method] = requests
+		}
+
+		result = append(result, usage)
+	}
+
+	if reset {
+		a.usages = make(map[string]*UpstreamUsage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Space != result[j].Space {
+			return result[i].Space < result[j].Space
+		}
+
+		return result[i].Node < result[j].Node
+	})
+
+	return result
+}
+
+// UpstreamUsages returns the accounted RPC usages of all upstream fullnodes in this process, and
+// resets the accounted usages if required (eg., at the beginning of a billing period).
+func UpstreamUsages(reset bool) []UpstreamUsage {
+	return upstreamUsages.snapshot(reset)
+}
+
+// UpstreamUsageEnabled returns whether RPC usages are accounted per upstream fullnode.
+func UpstreamUsageEnabled() bool {
+	return usageCfg.Enabled
+}
+
+// usageConnWrapper returns the function to count bytes on wire of connections to the fullnode if
+// usage accounting enabled, otherwise nil.
+func usageConnWrapper(url, space string) func(net.Conn) net.Conn {
+	if !usageCfg.Enabled {
+		return nil
+	}
+
+	counter := upstreamUsages.wire(NodeAlias(url), space)
+
+	return func(conn net.Conn) net.Conn {
+		return &countingConn{Conn: conn, counter: counter}
+	}
+}
+
+// hookUsageMiddlewares accounts requests of both single and batch calls to the fullnode if usage
+// accounting enabled.
+func hookUsageMiddlewares(provider *providers.MiddlewarableProvider, url, space string) {
+	if !usageCfg.Enabled {
+		return
+	}
+
+	fullnode := NodeAlias(url)
+	provider.HookCallContext(middlewareUsage(fullnode, space))
+	provider.HookBatchCallContext(middlewareBatchUsage(fullnode, space))
+}
+
+func middlewareUsage(fullnode, space string) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			err := handler(ctx, result, method, args...)
+			accountUsage(fullnode, space, method, err)
+
+			return err
+		}
+	}
+}
+
+func middlewareBatchUsage(fullnode, space string) providers.BatchCallContextMiddleware {
+	return func(handler providers.BatchCallContextFunc) providers.BatchCallContextFunc {
+		return func(ctx context.Context, b []rpc.BatchElem) error {
+			err := handler(ctx, b)
+
+			for i := range b {
+				elemErr := b[i].Error
+				if err != nil {
+					elemErr = err
+				}
+
+				accountUsage(fullnode, space, b[i].Method, elemErr)
+			}
+
+			return err
+		}
+	}
+}
+
+func accountUsage(fullnode, space, method string, err error) {
+	upstreamUsages.account(fullnode, space, method, err)
+
+	metrics.Registry.RPC.FullnodeUsageRequests(fullnode, space).Mark(1)
+	if err != nil {
+		metrics.Registry.RPC.FullnodeUsageErrors(fullnode, space).Mark(1)
+	}
+}
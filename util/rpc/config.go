@@ -4,11 +4,15 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/sirupsen/logrus"
 )
 
 var (
 	cfxClientCfg clientConfig
 	ethClientCfg clientConfig
+	ethTLSCfg    tlsClientConfig // TLS configurations to dial evm space fullnodes
+	fullnodeCfg  fullnodeConfig
 )
 
 type clientConfig struct {
@@ -18,6 +22,7 @@ type clientConfig struct {
 	RetryInterval   time.Duration `default:"1s"`
 	RequestTimeout  time.Duration `default:"3s"`
 	MaxConnsPerHost int           `default:"1024"`
+	Pool            clientPoolConfig
 }
 
 type ClientOptioner interface {
@@ -26,16 +31,51 @@ type ClientOptioner interface {
 	SetRequestTimeout(reqTimeout time.Duration)
 	SetMaxConnsPerHost(maxConns int)
 	SetHookMetrics(hook bool)
+	AddCallMiddlewares(middlewares ...providers.CallContextMiddleware)
+	AddBatchCallMiddlewares(middlewares ...providers.BatchCallContextMiddleware)
+	SetRateLimiter(limiter *HostRateLimiter)
 }
 
 type baseClientOption struct {
-	hookMetrics bool
+	hookMetrics          bool
+	callMiddlewares      []providers.CallContextMiddleware
+	batchCallMiddlewares []providers.BatchCallContextMiddleware
+	rateLimiter          *HostRateLimiter
 }
 
 func (o *baseClientOption) SetHookMetrics(hook bool) {
 	o.hookMetrics = hook
 }
 
+func (o *baseClientOption) AddCallMiddlewares(middlewares ...providers.CallContextMiddleware) {
+	o.callMiddlewares = append(o.callMiddlewares, middlewares...)
+}
+
+func (o *baseClientOption) AddBatchCallMiddlewares(middlewares ...providers.BatchCallContextMiddleware) {
+	o.batchCallMiddlewares = append(o.batchCallMiddlewares, middlewares...)
+}
+
+func (o *baseClientOption) SetRateLimiter(limiter *HostRateLimiter) {
+	o.rateLimiter = limiter
+}
+
+// hookCallMiddlewares hooks the extra (batch) call middlewares if any into the provider, along with
+// the rate limiter of fullnode host if specified.
+func (o *baseClientOption) hookCallMiddlewares(provider *providers.MiddlewarableProvider, url string) {
+	for _, mw := range o.callMiddlewares {
+		provider.HookCallContext(mw)
+	}
+
+	for _, mw := range o.batchCallMiddlewares {
+		provider.HookBatchCallContext(mw)
+	}
+
+	if o.rateLimiter != nil {
+		provider.HookCallContext(o.rateLimiter.Middleware(url))
+		provider.HookBatchCallContext(o.rateLimiter.BatchMiddleware(url))
+	}
+}
+
 type ClientOption func(opt ClientOptioner)
 
 func WithClientRetryCount(retry int) ClientOption {
@@ -68,7 +108,49 @@ func WithClientHookMetrics(hook bool) ClientOption {
 	}
 }
 
+// WithClientCallMiddlewares hooks extra call middlewares into the client provider.
+func WithClientCallMiddlewares(middlewares ...providers.CallContextMiddleware) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.AddCallMiddlewares(middlewares...)
+	}
+}
+
+// WithClientBatchCallMiddlewares hooks extra batch call middlewares into the client provider.
+func WithClientBatchCallMiddlewares(middlewares ...providers.BatchCallContextMiddleware) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.AddBatchCallMiddlewares(middlewares...)
+	}
+}
+
+// WithClientRateLimiter limits the rate of requests to the fullnode host, which could be shared among
+// multiple clients.
+func WithClientRateLimiter(limiter *HostRateLimiter) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.SetRateLimiter(limiter)
+	}
+}
+
 func init() {
 	viper.MustUnmarshalKey("cfx", &cfxClientCfg)
 	viper.MustUnmarshalKey("eth", &ethClientCfg)
+	viper.MustUnmarshalKey("eth.tls", &ethTLSCfg)
+
+	// Custom TLS is not supported to dial core space fullnodes, since core space SDK client always
+	// dials with its default provider to initialize (eg., query network ID), and could not be created
+	// with custom provider (eg., sub clients for pos or txpool not initialized). So, fail fast rather
+	// than silently dial core space fullnodes without client certificate.
+	var cfxTLS tlsClientConfig
+	viper.MustUnmarshalKey("cfx.tls", &cfxTLS)
+	if cfxTLS.Enabled() {
+		logrus.Fatal("Custom TLS to dial core space fullnodes is unsupported, use `eth.tls` for evm space only")
+	}
+
+	viper.MustUnmarshalKey("rpc.timeout", &timeoutCfg)
+	timeoutCfg.init()
+
+	viper.MustUnmarshalKey("rpc.websocket", &wsCfg)
+	viper.MustUnmarshalKey("rpc.http", &httpCfg)
+	viper.MustUnmarshalKey("rpc.tls", &tlsServerCfg)
+	viper.MustUnmarshalKey("rpc.upstreamUsage", &usageCfg)
+	viper.MustUnmarshalKey("rpc.fullnodes", &fullnodeCfg)
 }
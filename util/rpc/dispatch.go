@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SpaceCfx = "cfx"
+	SpaceEth = "eth"
+
+	// max number of bytes of request body to peek the JSON-RPC method
+	maxDispatchPeekBytes = 5 * 1024 * 1024
+)
+
+// method namespaces that are only available in a specific space, and others (eg., `trace_`) are
+// routed to the default space.
+var dispatchNamespaces = map[string]string{
+	"cfx":  SpaceCfx,
+	"pos":  SpaceCfx,
+	"eth":  SpaceEth,
+	"net":  SpaceEth,
+	"web3": SpaceEth,
+}
+
+// DispatchConfig configurations to serve RPC of both spaces on the same endpoint.
+type DispatchConfig struct {
+	// HTTP and websocket endpoints to serve both spaces, empty to disable
+	Endpoint   string
+	WsEndpoint string
+	// space (`cfx` or `eth`) to route requests matched by neither URL path nor method prefix
+	DefaultSpace string `default:"eth"`
+	// whether to translate core space methods into evm space via CfxBridge if core space RPC server
+	// not started, so that clients could use core space dialect against evm space data
+	Translate bool
+}
+
+// NewDispatchServer creates RPC server to dispatch requests to the RPC server of specific space by URL
+// path prefix (`/cfx` or `/eth`, which is stripped before dispatched), or by the method prefix (eg.,
+// `cfx_` or `eth_`) of JSON-RPC request otherwise. Note, batch request is dispatched by the method of
+// the first item, and websocket request is dispatched by URL path only.
+func NewDispatchServer(spaces map[string]*Server, defaultSpace string) (*Server, error) {
+	if _, ok := spaces[defaultSpace]; !ok {
+		return nil, errors.Errorf("RPC server of default space %v not available", defaultSpace)
+	}
+
+	d := &Server{name: "dispatch", servers: make(map[Protocol]*http.Server)}
+
+	for _, protocol := range []Protocol{ProtocolHttp, ProtocolWS} {
+		handlers := make(map[string]http.Handler)
+		for space, server := range spaces {
+			handlers[space] = server.servers[protocol].Handler
+		}
+
+		d.servers[protocol] = &http.Server{
+			Handler: newDispatchHandler(handlers, defaultSpace, protocol == ProtocolHttp),
+		}
+	}
+
+	return d, nil
+}
+
+func newDispatchHandler(spaces map[string]http.Handler, defaultSpace string, peek bool) http.Handler {
+	prefixed := make(map[string]http.Handler)
+	for space, handler := range spaces {
+		prefixed[space] = http.StripPrefix("/"+space, handler)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for space, handler := range prefixed {
+			prefix := "/" + space
+			if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		space := defaultSpace
+		if peek && r.Method == http.MethodPost {
+			space = peekRequestSpace(r, defaultSpace)
+		}
+
+		handler, ok := spaces[space]
+		if !ok {
+			handler = spaces[defaultSpace]
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// peekRequestSpace peeks the method of JSON-RPC request to determine the space, in which case the
+// request body is restored to dispatch.
+func peekRequestSpace(r *http.Request, defaultSpace string) string {
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxDispatchPeekBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+
+	if err != nil {
+		return defaultSpace
+	}
+
+	return methodSpace(peekMethod(buf), defaultSpace)
+}
+
+func peekMethod(body []byte) string {
+	var msg struct {
+		Method string `json:"method"`
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+			return ""
+		}
+
+		body = batch[0]
+	}
+
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return ""
+	}
+
+	return msg.Method
+}
+
+func methodSpace(method, defaultSpace string) string {
+	idx := strings.Index(method, "_")
+	if idx <= 0 {
+		return defaultSpace
+	}
+
+	if space, ok := dispatchNamespaces[method[:idx]]; ok {
+		return space
+	}
+
+	return defaultSpace
+}
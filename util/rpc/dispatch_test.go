@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchHandler(t *testing.T) {
+	named := func(space string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write([]byte(space + ":" + r.URL.Path + ":" + string(body)))
+		})
+	}
+
+	handler := newDispatchHandler(map[string]http.Handler{
+		SpaceCfx: named(SpaceCfx),
+		SpaceEth: named(SpaceEth),
+	}, SpaceEth, true)
+
+	testCases := []struct {
+		path, body, expected string
+	}{
+		{"/cfx/key", `{"method":"eth_chainId"}`, `cfx:/key:{"method":"eth_chainId"}`},
+		{"/eth", `{"method":"cfx_epochNumber"}`, `eth::{"method":"cfx_epochNumber"}`},
+		{"/key", `{"id":1,"method":"cfx_epochNumber"}`, `cfx:/key:{"id":1,"method":"cfx_epochNumber"}`},
+		{"/", `[{"method":"cfx_getStatus"},{"method":"eth_chainId"}]`, `cfx:/:[{"method":"cfx_getStatus"},{"method":"eth_chainId"}]`},
+		{"/", `{"method":"trace_block"}`, `eth:/:{"method":"trace_block"}`},
+		{"/", `invalid`, `eth:/:invalid`},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		assert.Equal(t, tc.expected, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/util/errcode"
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/pkg/errors"
+)
+
+// Error codes and kinds defined in leaf package `errcode`, which are aliased here for RPC utilities.
+const (
+	ErrCodeResourceNotFound    = errcode.ResourceNotFound
+	ErrCodeMethodUnsupported   = errcode.MethodUnsupported
+	ErrCodeLimitExceeded       = errcode.LimitExceeded
+	ErrCodeUpstreamUnavailable = errcode.UpstreamUnavailable
+	ErrCodeNoUpstreamAvailable = errcode.NoUpstreamAvailable
+	ErrCodeUpstreamDiverged    = errcode.UpstreamDiverged
+	ErrCodeUnauthorized        = errcode.Unauthorized
+	ErrCodeDataPruned          = errcode.DataPruned
+	ErrCodeReorgInProgress     = errcode.ReorgInProgress
+	ErrCodeQueryTimeout        = errcode.QueryTimeout
+)
+
+const (
+	ErrKindNotFound            = errcode.KindNotFound
+	ErrKindUnsupported         = errcode.KindUnsupported
+	ErrKindOverLimit           = errcode.KindOverLimit
+	ErrKindUpstreamUnavailable = errcode.KindUpstreamUnavailable
+	ErrKindUpstreamDiverged    = errcode.KindUpstreamDiverged
+	ErrKindUnauthorized        = errcode.KindUnauthorized
+	ErrKindPruned              = errcode.KindPruned
+	ErrKindReorgInProgress     = errcode.KindReorgInProgress
+	ErrKindTimeout             = errcode.KindTimeout
+)
+
+var ( // common errors
+	ErrUnknownBlock = NewCodedError(ErrCodeResourceNotFound, errors.New("unknown block"))
+
+	ErrReorgInProgress = NewCodedError(
+		ErrCodeReorgInProgress, errors.New("chain reorg in progress, please try again later"),
+	)
+)
+
+// ErrorKind returns the stable error kind of the JSON-RPC error code, or empty if unclassified.
+func ErrorKind(code int) string {
+	return errcode.Kind(code)
+}
+
+// CodedError error with JSON-RPC error code, which will be responded to the client
+// rather than the default error code.
+type CodedError = errcode.CodedError
+
+func NewCodedError(code int, err error) *CodedError {
+	return errcode.New(code, err)
+}
+
+// codedJsonError converts to JSON-RPC error with the stable error kind if any as error data.
+func codedJsonError(e *CodedError) *rpc.JsonError {
+	jsonErr := &rpc.JsonError{Code: e.Code, Message: e.Error()}
+	if kind := ErrorKind(e.Code); len(kind) > 0 {
+		jsonErr.Data = &ErrorHints{Kind: kind}
+	}
+
+	return jsonErr
+}
+
+// middlewareUpstreamError marks non RPC errors (generally io error or timeout) from upstream
+// fullnode with dedicated error code, so that clients could tell them apart.
+func middlewareUpstreamError(handler providers.CallContextFunc) providers.CallContextFunc {
+	return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		err := handler(ctx, result, method, args...)
+		if err == nil || utils.IsRPCJSONError(err) || errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		return NewCodedError(ErrCodeUpstreamUnavailable, err)
+	}
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CtxKeyPrincipal = CtxKey("Infura-Principal")
+
+	// HTTP headers of HMAC signed request, in which the signature is the hex encoded HMAC-SHA256
+	// of `<timestamp>.<nonce>.<HTTP method>.<request URI>.<request body>` with the secret of key ID.
+	HeaderAuthKeyId     = "X-Auth-Key-Id"
+	HeaderAuthTimestamp = "X-Auth-Timestamp" // unix timestamp in seconds
+	HeaderAuthNonce     = "X-Auth-Nonce"     // unique per request of the key ID to prevent replay
+	HeaderAuthSignature = "X-Auth-Signature"
+
+	AuthSchemeJwt  = "jwt"
+	AuthSchemeHmac = "hmac"
+)
+
+var (
+	ErrInvalidJwt           = errors.New("invalid JWT bearer token")
+	ErrJwtExpired           = errors.New("JWT bearer token expired or not valid yet")
+	ErrJwtNoExpiry          = errors.New("JWT bearer token without expiration time")
+	ErrUnknownHmacKey       = errors.New("unknown HMAC key ID")
+	ErrInvalidHmacSignature = errors.New("invalid HMAC signature")
+	ErrHmacTimestampSkewed  = errors.New("HMAC timestamp skewed too much")
+	ErrHmacNonceReplayed    = errors.New("HMAC nonce replayed")
+)
+
+// Principal authenticated identity of request by JWT bearer token or HMAC signature.
+type Principal struct {
+	Scheme  string   // `jwt` or `hmac`
+	Subject string   // subject of JWT or owner of HMAC key
+	Methods []string // allowed methods, empty for all methods
+	Err     error    // authentication failure if any
+}
+
+// MethodAllowed checks if the method is allowed for the principal. Method patterns are case
+// insensitive, and could end with `*` as wildcard, eg., `eth_*`.
+func (p *Principal) MethodAllowed(method string) bool {
+	return len(p.Methods) == 0 || MatchMethod(p.Methods, method)
+}
+
+// GetPrincipalFromContext returns the principal if request authenticated by JWT or HMAC.
+func GetPrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(CtxKeyPrincipal).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// JwtConfig JWT bearer token authentication configurations.
+type JwtConfig struct {
+	// whether to authenticate requests with JWT bearer token
+	Enabled bool
+	// shared secret to verify HS256 signature
+	Secret string
+	// expected issuer if not empty
+	Issuer string
+	// expected audience if not empty
+	Audience string
+	// claim of allowed methods, either an array or a space separated string
+	MethodsClaim string `default:"methods"`
+	// tolerance of clock skew to validate `exp` and `nbf`
+	Leeway time.Duration `default:"30s"`
+}
+
+// HmacKey HMAC key to sign requests.
+type HmacKey struct {
+	// shared secret to sign requests
+	Secret string
+	// owner of the key
+	Subject string
+	// allowed methods, empty for all methods
+	Methods []string
+}
+
+// HmacConfig HMAC signed request authentication configurations.
+type HmacConfig struct {
+	// whether to authenticate HMAC signed requests
+	Enabled bool
+	// key ID => HMAC key, note key IDs are case insensitive
+	Keys map[string]HmacKey
+	// max clock skew of the signed timestamp
+	MaxSkew time.Duration `default:"5m"`
+	// max number of nonces remembered within the skew window to reject replayed requests
+	NonceCacheSize int `default:"100000"`
+}
+
+// AuthConfig authentication configurations in addition to API keys.
+type AuthConfig struct {
+	Jwt  JwtConfig
+	Hmac HmacConfig
+}
+
+// Authenticator authenticates requests with JWT bearer token or HMAC signature, so that enterprise
+// users could integrate with their identity systems.
+type Authenticator struct {
+	conf AuthConfig
+
+	// (key ID, nonce) => struct{} of HMAC signed requests within the skew window
+	nonces  *util.ExpirableLruCache
+	nonceMu sync.Mutex
+}
+
+// MustNewAuthenticatorFromViper creates authenticator from viper settings of the specified key,
+// or returns false if neither JWT nor HMAC authentication enabled.
+func MustNewAuthenticatorFromViper(key string) (*Authenticator, bool) {
+	var conf AuthConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Jwt.Enabled && !conf.Hmac.Enabled {
+		return nil, false
+	}
+
+	authenticator, err := NewAuthenticator(conf)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid authentication configurations")
+	}
+
+	return authenticator, true
+}
+
+// NewAuthenticator creates authenticator, or returns error if any enabled scheme misconfigured,
+// eg., empty secret by which anyone could forge credentials.
+func NewAuthenticator(conf AuthConfig) (*Authenticator, error) {
+	if conf.Jwt.Enabled && len(conf.Jwt.Secret) == 0 {
+		return nil, errors.New("JWT secret is empty")
+	}
+
+	keys := make(map[string]HmacKey, len(conf.Hmac.Keys))
+	for id, key := range conf.Hmac.Keys {
+		if conf.Hmac.Enabled && len(key.Secret) == 0 {
+			return nil, errors.Errorf("HMAC secret of key %v is empty", id)
+		}
+
+		keys[strings.ToLower(id)] = key
+	}
+
+	conf.Hmac.Keys = keys
+
+	if conf.Hmac.Enabled && conf.Hmac.MaxSkew <= 0 {
+		return nil, errors.New("HMAC max skew must be positive")
+	}
+
+	if conf.Hmac.NonceCacheSize <= 0 {
+		conf.Hmac.NonceCacheSize = 100000
+	}
+
+	// nonces must be remembered until the signed timestamp skewed too much in both directions
+	nonces := util.NewExpirableLruCache(conf.Hmac.NonceCacheSize, 2*conf.Hmac.MaxSkew)
+
+	return &Authenticator{conf: conf, nonces: nonces}, nil
+}
+
+// WithAuthentication injects the authenticated principal (or authentication failure) into request
+// context if JWT bearer token or HMAC signature provided.
+func (a *Authenticator) WithAuthentication(r *http.Request) *http.Request {
+	var principal *Principal
+
+	if token, ok := bearerToken(r); ok && a.conf.Jwt.Enabled {
+		principal = a.authenticateJwt(token, time.Now())
+	} else if keyId := r.Header.Get(HeaderAuthKeyId); len(keyId) > 0 && a.conf.Hmac.Enabled {
+		principal = a.authenticateHmac(r, keyId, time.Now())
+	}
+
+	if principal == nil {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), CtxKeyPrincipal, principal))
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(auth[7:]), true
+}
+
+// jwtClaims registered claims of JWT, along with the custom claims.
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+
+	custom map[string]json.RawMessage
+}
+
+// authenticateJwt verifies the HS256 signed JWT and its claims.
+func (a *Authenticator) authenticateJwt(token string, now time.Time) *Principal {
+	principal := &Principal{Scheme: AuthSchemeJwt}
+
+	claims, err := a.parseJwt(token)
+	if err != nil {
+		principal.Err = err
+		return principal
+	}
+
+	principal.Subject = claims.Subject
+
+	conf := a.conf.Jwt
+	if claims.ExpiresAt == nil { // otherwise, the token never expires once leaked
+		principal.Err = ErrJwtNoExpiry
+		return principal
+	}
+
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(conf.Leeway)) {
+		principal.Err = ErrJwtExpired
+		return principal
+	}
+
+	if claims.NotBefore != nil && now.Add(conf.Leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		principal.Err = ErrJwtExpired
+		return principal
+	}
+
+	if len(conf.Issuer) > 0 && claims.Issuer != conf.Issuer {
+		principal.Err = errors.WithMessage(ErrInvalidJwt, "issuer mismatched")
+		return principal
+	}
+
+	if len(conf.Audience) > 0 && !includeString(unmarshalStrings(claims.Audience), conf.Audience) {
+		principal.Err = errors.WithMessage(ErrInvalidJwt, "audience mismatched")
+		return principal
+	}
+
+	principal.Methods = unmarshalStrings(claims.custom[conf.MethodsClaim])
+
+	return principal
+}
+
+func (a *Authenticator) parseJwt(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.WithMessage(ErrInvalidJwt, "malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+
+	if err := decodeJwtSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	if header.Alg != "HS256" {
+		return nil, errors.WithMessagef(ErrInvalidJwt, "unsupported algorithm %v", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.WithMessage(ErrInvalidJwt, "malformed signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.conf.Jwt.Secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.WithMessage(ErrInvalidJwt, "signature mismatched")
+	}
+
+	var claims jwtClaims
+	if err := decodeJwtSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJwtSegment(parts[1], &claims.custom); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func decodeJwtSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.WithMessage(ErrInvalidJwt, "malformed segment")
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.WithMessage(ErrInvalidJwt, "malformed segment")
+	}
+
+	return nil
+}
+
+// unmarshalStrings unmarshals either a string array or a space separated string.
+func unmarshalStrings(data json.RawMessage) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err == nil {
+		return values
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		return strings.Fields(value)
+	}
+
+	return nil
+}
+
+func includeString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticateHmac verifies the HMAC signature over the signed timestamp, nonce, request method,
+// request URI and request body, which is restored for later reading. Besides, the nonce could be
+// used only once within the skew window to prevent replay.
+func (a *Authenticator) authenticateHmac(r *http.Request, keyId string, now time.Time) *Principal {
+	principal := &Principal{Scheme: AuthSchemeHmac}
+
+	key, ok := a.conf.Hmac.Keys[strings.ToLower(keyId)]
+	if !ok {
+		principal.Err = ErrUnknownHmacKey
+		return principal
+	}
+
+	principal.Subject, principal.Methods = key.Subject, key.Methods
+
+	timestamp := r.Header.Get(HeaderAuthTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		principal.Err = errors.WithMessage(ErrInvalidHmacSignature, "malformed timestamp")
+		return principal
+	}
+
+	if skew := now.Sub(time.Unix(ts, 0)); skew > a.conf.Hmac.MaxSkew || -skew > a.conf.Hmac.MaxSkew {
+		principal.Err = ErrHmacTimestampSkewed
+		return principal
+	}
+
+	nonce := r.Header.Get(HeaderAuthNonce)
+	if len(nonce) == 0 {
+		principal.Err = errors.WithMessage(ErrInvalidHmacSignature, "nonce missing")
+		return principal
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			principal.Err = errors.WithMessage(err, "failed to read request body")
+			return principal
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(HeaderAuthSignature), "0x"))
+	if err != nil {
+		principal.Err = errors.WithMessage(ErrInvalidHmacSignature, "malformed signature")
+		return principal
+	}
+
+	// the original request URI, since URL path might be stripped for mounted RPC servers
+	uri := r.RequestURI
+	if len(uri) == 0 {
+		uri = r.URL.RequestURI()
+	}
+
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, r.Method, uri}, ".") + "."))
+	mac.Write(body)
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		principal.Err = ErrInvalidHmacSignature
+		return principal
+	}
+
+	// remember nonce only for valid signature, so that nonce could not be burnt by anyone else
+	if !a.useNonce(strings.ToLower(keyId), nonce) {
+		principal.Err = ErrHmacNonceReplayed
+	}
+
+	return principal
+}
+
+// useNonce marks the nonce of key ID used, or returns false if already used.
+func (a *Authenticator) useNonce(keyId, nonce string) bool {
+	a.nonceMu.Lock()
+	defer a.nonceMu.Unlock()
+
+	key := keyId + "." + nonce
+	if _, ok := a.nonces.Get(key); ok {
+		return false
+	}
+
+	a.nonces.Add(key, struct{}{})
+
+	return true
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signTestJwt(secret, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticateJwt(t *testing.T) {
+	a, err := NewAuthenticator(AuthConfig{Jwt: JwtConfig{
+		Enabled: true, Secret: "secret", Audience: "confura", MethodsClaim: "methods",
+	}})
+	assert.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+
+	token := signTestJwt("secret", `{"sub":"acme","aud":["confura"],"exp":1700000060,"methods":"eth_* net_version"}`)
+	p := a.authenticateJwt(token, now)
+	assert.NoError(t, p.Err)
+	assert.Equal(t, "acme", p.Subject)
+	assert.True(t, p.MethodAllowed("eth_getLogs"))
+	assert.True(t, p.MethodAllowed("NET_VERSION"))
+	assert.False(t, p.MethodAllowed("debug_traceTransaction"))
+
+	// expired
+	assert.Equal(t, ErrJwtExpired, a.authenticateJwt(token, now.Add(time.Hour)).Err)
+
+	// without expiration time
+	token = signTestJwt("secret", `{"sub":"acme","aud":"confura"}`)
+	assert.Equal(t, ErrJwtNoExpiry, a.authenticateJwt(token, now).Err)
+
+	// bad signature
+	token = signTestJwt("other", `{"sub":"acme","aud":"confura","exp":1700000060}`)
+	assert.Error(t, a.authenticateJwt(token, now).Err)
+
+	// audience mismatched
+	token = signTestJwt("secret", `{"sub":"acme","aud":"other","exp":1700000060}`)
+	assert.Error(t, a.authenticateJwt(token, now).Err)
+}
+
+func TestNewAuthenticatorEmptySecret(t *testing.T) {
+	_, err := NewAuthenticator(AuthConfig{Jwt: JwtConfig{Enabled: true}})
+	assert.Error(t, err)
+
+	_, err = NewAuthenticator(AuthConfig{Hmac: HmacConfig{
+		Enabled: true,
+		MaxSkew: time.Minute,
+		Keys:    map[string]HmacKey{"acme": {Subject: "acme"}},
+	}})
+	assert.Error(t, err)
+}
+
+func TestAuthenticateHmac(t *testing.T) {
+	a, err := NewAuthenticator(AuthConfig{Hmac: HmacConfig{
+		Enabled: true,
+		MaxSkew: time.Minute,
+		Keys:    map[string]HmacKey{"Acme": {Secret: "secret", Subject: "acme"}},
+	}})
+	assert.NoError(t, err)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	sign := func(timestamp, nonce, method, uri string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(strings.Join([]string{timestamp, nonce, method, uri, body}, ".")))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	newRequest := func(timestamp, nonce, uri, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body))
+		r.Header.Set(HeaderAuthKeyId, "acme")
+		r.Header.Set(HeaderAuthTimestamp, timestamp)
+		r.Header.Set(HeaderAuthNonce, nonce)
+		r.Header.Set(HeaderAuthSignature, signature)
+
+		return a.WithAuthentication(r)
+	}
+
+	principalErr := func(r *http.Request) error {
+		p, ok := GetPrincipalFromContext(r.Context())
+		assert.True(t, ok)
+		return p.Err
+	}
+
+	r := newRequest(timestamp, "n1", "/testnet", sign(timestamp, "n1", http.MethodPost, "/testnet"))
+	p, ok := GetPrincipalFromContext(r.Context())
+	assert.True(t, ok)
+	assert.NoError(t, p.Err)
+	assert.Equal(t, "acme", p.Subject)
+
+	// body restored
+	restored, _ := ioutil.ReadAll(r.Body)
+	assert.Equal(t, body, string(restored))
+
+	// replayed
+	r = newRequest(timestamp, "n1", "/testnet", sign(timestamp, "n1", http.MethodPost, "/testnet"))
+	assert.Equal(t, ErrHmacNonceReplayed, principalErr(r))
+
+	// signed for other path
+	r = newRequest(timestamp, "n2", "/mainnet", sign(timestamp, "n2", http.MethodPost, "/testnet"))
+	assert.Equal(t, ErrInvalidHmacSignature, principalErr(r))
+
+	// nonce missing
+	r = newRequest(timestamp, "", "/", sign(timestamp, "", http.MethodPost, "/"))
+	assert.Error(t, principalErr(r))
+
+	// timestamp skewed
+	skewed := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	r = newRequest(skewed, "n3", "/", sign(skewed, "n3", http.MethodPost, "/"))
+	assert.Equal(t, ErrHmacTimestampSkewed, principalErr(r))
+
+	r = newRequest(timestamp, "n4", "/", hex.EncodeToString([]byte("bad signature")))
+	assert.Equal(t, ErrInvalidHmacSignature, principalErr(r))
+
+	// nonce not burnt by invalid signature
+	r = newRequest(timestamp, "n4", "/", sign(timestamp, "n4", http.MethodPost, "/"))
+	assert.NoError(t, principalErr(r))
+
+	// no credential provided
+	r = a.WithAuthentication(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	_, ok = GetPrincipalFromContext(r.Context())
+	assert.False(t, ok)
+}
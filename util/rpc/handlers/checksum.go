@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cespare/xxhash"
+)
+
+const (
+	CtxKeyResultChecksum = CtxKey("Infura-Result-Checksum")
+
+	// HTTP header for client to request checksum of JSON-RPC result, which is also used to respond
+	// the checksum in format of `<algorithm>=<hex checksum>`.
+	HeaderResultChecksum = "X-Result-Checksum"
+
+	// xxhash (64 bits) checksum algorithm
+	ChecksumAlgorithmXxhash = "xxhash"
+)
+
+// ResultChecksum checksum of the JSON-RPC result, which is computed over the compact JSON bytes
+// of the `result` field as responded.
+type ResultChecksum struct {
+	value string
+}
+
+// Set computes the checksum of JSON-RPC result.
+func (c *ResultChecksum) Set(result []byte) {
+	c.value = fmt.Sprintf("%v=%016x", ChecksumAlgorithmXxhash, xxhash.Sum64(result))
+}
+
+// Value returns the checksum of JSON-RPC result if computed.
+func (c *ResultChecksum) Value() (string, bool) {
+	return c.value, len(c.value) > 0
+}
+
+// GetResultChecksumFromContext returns the result checksum if requested by client.
+func GetResultChecksumFromContext(ctx context.Context) (*ResultChecksum, bool) {
+	checksum, ok := ctx.Value(CtxKeyResultChecksum).(*ResultChecksum)
+	return checksum, ok && checksum != nil
+}
+
+// WithResultChecksum injects result checksum into request context if requested by client, and
+// wraps the response writer to respond the checksum in HTTP header once computed. Note, websocket
+// upgrade request is left as it is, since the response writer must be hijackable.
+func WithResultChecksum(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return w, r
+	}
+
+	algorithm := strings.TrimSpace(r.Header.Get(HeaderResultChecksum))
+	if !strings.EqualFold(algorithm, ChecksumAlgorithmXxhash) {
+		return w, r
+	}
+
+	checksum := &ResultChecksum{}
+	ctx := context.WithValue(r.Context(), CtxKeyResultChecksum, checksum)
+
+	return &checksumResponseWriter{ResponseWriter: w, checksum: checksum}, r.WithContext(ctx)
+}
+
+// checksumResponseWriter responds the result checksum in HTTP header before the body written.
+type checksumResponseWriter struct {
+	http.ResponseWriter
+
+	checksum    *ResultChecksum
+	wroteHeader bool
+}
+
+func (w *checksumResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		if value, ok := w.checksum.Value(); ok {
+			w.Header().Set(HeaderResultChecksum, value)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *checksumResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
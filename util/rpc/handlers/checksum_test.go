@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultChecksum(t *testing.T) {
+	result := []byte(`[{"address":"0x0000000000000000000000000000000000000000"}]`)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checksum, ok := GetResultChecksumFromContext(r.Context()); ok {
+			checksum.Set(result)
+		}
+
+		w.Write(result)
+	})
+
+	serve := func(algorithm string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if len(algorithm) > 0 {
+			req.Header.Set(HeaderResultChecksum, algorithm)
+		}
+
+		rec := httptest.NewRecorder()
+		w, r := WithResultChecksum(rec, req)
+		handler.ServeHTTP(w, r)
+
+		return rec
+	}
+
+	// not requested
+	assert.Empty(t, serve("").Header().Get(HeaderResultChecksum))
+
+	// unsupported algorithm
+	assert.Empty(t, serve("md5").Header().Get(HeaderResultChecksum))
+
+	rec := serve("XXHash")
+	assert.Equal(t, result, rec.Body.Bytes())
+	assert.Regexp(t, "^xxhash=[0-9a-f]{16}$", rec.Header().Get(HeaderResultChecksum))
+
+	var checksum ResultChecksum
+	checksum.Set(result)
+	value, _ := checksum.Value()
+	assert.Equal(t, value, rec.Header().Get(HeaderResultChecksum))
+}
+
+func TestResultChecksumWebsocketUpgrade(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set(HeaderResultChecksum, ChecksumAlgorithmXxhash)
+
+	rec := httptest.NewRecorder()
+	w, r := WithResultChecksum(rec, req)
+
+	// response writer not wrapped, so as to be hijackable
+	assert.Equal(t, rec, w)
+	assert.Equal(t, req, r)
+
+	_, ok := GetResultChecksumFromContext(r.Context())
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	CtxKeyConsistentRead = CtxKey("Infura-Consistent-Read")
+
+	// HTTP header or URL query parameter for client to opt in the consistency mode.
+	HeaderConsistency = "X-Consistency"
+	QueryConsistency  = "consistency"
+
+	// consistency mode to resolve `latest` block parameter to the head of store
+	ConsistencyStore = "store"
+)
+
+// HeadLoader loads the head block number of store.
+type HeadLoader func() (uint64, error)
+
+// ConsistentRead resolves `latest` block parameter to the head of store, which is pinned for all
+// the RPC calls (including batch items) within the same HTTP request.
+type ConsistentRead struct {
+	load HeadLoader
+	pin  bool
+
+	once sync.Once
+	head uint64
+	err  error
+}
+
+// Head returns the head block number of store to resolve `latest` block parameter.
+func (c *ConsistentRead) Head() (uint64, error) {
+	if !c.pin {
+		return c.load()
+	}
+
+	c.once.Do(func() {
+		c.head, c.err = c.load()
+	})
+
+	return c.head, c.err
+}
+
+// GetConsistentReadFromContext returns the consistent read mode if opted in by client.
+func GetConsistentReadFromContext(ctx context.Context) (*ConsistentRead, bool) {
+	cr, ok := ctx.Value(CtxKeyConsistentRead).(*ConsistentRead)
+	return cr, ok
+}
+
+// WithConsistentRead injects consistent read mode into request context if opted in by client via
+// HTTP header or URL query parameter. Note, the head of store is loaded per RPC call rather than
+// pinned for websocket connection, which lasts for a long time.
+func WithConsistentRead(r *http.Request, load HeadLoader) *http.Request {
+	mode := r.Header.Get(HeaderConsistency)
+	if len(mode) == 0 {
+		mode = r.URL.Query().Get(QueryConsistency)
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(mode), ConsistencyStore) {
+		return r
+	}
+
+	cr := &ConsistentRead{
+		load: load,
+		pin:  !strings.EqualFold(r.Header.Get("Upgrade"), "websocket"),
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), CtxKeyConsistentRead, cr))
+}
@@ -9,11 +9,13 @@ type Middleware func(next http.Handler) http.Handler
 type CtxKey string
 
 const (
-	CtxKeyRateRegistry = CtxKey("Infura-Rate-Limit-Registry")
-	CtxKeyAuthId       = CtxKey("Infura-Auth-ID")
+	CtxKeyRateRegistry   = CtxKey("Infura-Rate-Limit-Registry")
+	CtxKeyTenantRegistry = CtxKey("Infura-Tenant-Registry")
+	CtxKeyAuthId         = CtxKey("Infura-Auth-ID")
 
 	CtxKeyRealIP      = CtxKey("Infura-Real-IP")
 	CtxKeyAccessToken = CtxKey("Infura-Access-Token")
 	CtxKeyReqOrigin   = CtxKey("Infura-Req-Origin")
 	CtxKeyUserAgent   = CtxKey("Infura-User-Agent")
+	CtxKeyRequestId   = CtxKey("Infura-Request-ID")
 )
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	CtxKeyIdempotencyKey = CtxKey("Infura-Idempotency-Key")
+
+	// HeaderIdempotencyKey request header of client specified idempotency key for write RPCs
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// max length of idempotency key, beyond which the key is ignored
+	maxIdempotencyKeyLen = 256
+)
+
+// WithIdempotencyKey injects the idempotency key of write RPCs into context if specified by client.
+func WithIdempotencyKey(r *http.Request) *http.Request {
+	key := r.Header.Get(HeaderIdempotencyKey)
+	if len(key) == 0 || len(key) > maxIdempotencyKeyLen {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), CtxKeyIdempotencyKey, key))
+}
+
+// GetIdempotencyKeyFromContext returns the idempotency key of write RPCs if specified by client.
+func GetIdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(CtxKeyIdempotencyKey).(string)
+	return val, ok
+}
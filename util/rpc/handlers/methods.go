@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+)
+
+const CtxKeyMethodFilter = CtxKey("Infura-Method-Filter")
+
+// MatchMethod checks if the method matches any of the patterns, which are case insensitive and
+// could end with `*` as wildcard, eg., `debug_*`.
+func MatchMethod(patterns []string, method string) bool {
+	method = strings.ToLower(method)
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		if pattern == method || pattern == "*" {
+			return true
+		}
+
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(method, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MethodList allowlist and denylist of RPC methods.
+type MethodList struct {
+	// methods exposed only, empty for all methods
+	Allow []string
+	// methods never exposed, which takes precedence over allowlist
+	Deny []string
+}
+
+// Allowed checks if the method is exposed.
+func (l *MethodList) Allowed(method string) bool {
+	if MatchMethod(l.Deny, method) {
+		return false
+	}
+
+	return len(l.Allow) == 0 || MatchMethod(l.Allow, method)
+}
+
+// MethodFilterConfig method allowlist and denylist configurations of RPC endpoint.
+type MethodFilterConfig struct {
+	MethodList `mapstructure:",squash"`
+
+	// tenant => method lists to override that of endpoint, note tenants are case insensitive
+	Tenants map[string]MethodList
+}
+
+// MethodFilter exposes only a subset of RPC methods on the endpoint, which could be overridden
+// per tenant (by API key).
+type MethodFilter struct {
+	conf MethodFilterConfig
+}
+
+// MustNewMethodFilterFromViper creates method filter from viper settings of the specified key,
+// or returns false if not configured.
+func MustNewMethodFilterFromViper(key string) (*MethodFilter, bool) {
+	var conf MethodFilterConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if len(conf.Allow) == 0 && len(conf.Deny) == 0 && len(conf.Tenants) == 0 {
+		return nil, false
+	}
+
+	return NewMethodFilter(conf), true
+}
+
+func NewMethodFilter(conf MethodFilterConfig) *MethodFilter {
+	tenants := make(map[string]MethodList, len(conf.Tenants))
+	for tenant, list := range conf.Tenants {
+		tenants[strings.ToLower(tenant)] = list
+	}
+
+	conf.Tenants = tenants
+
+	return &MethodFilter{conf: conf}
+}
+
+// Allowed checks if the method is exposed to the tenant, which is empty if not tagged.
+func (f *MethodFilter) Allowed(tenant, method string) bool {
+	if list, ok := f.conf.Tenants[strings.ToLower(tenant)]; ok && len(tenant) > 0 {
+		return list.Allowed(method)
+	}
+
+	return f.conf.MethodList.Allowed(method)
+}
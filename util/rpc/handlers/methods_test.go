@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodFilter(t *testing.T) {
+	filter := NewMethodFilter(MethodFilterConfig{
+		MethodList: MethodList{Deny: []string{"debug_*", "txpool_status"}},
+		Tenants: map[string]MethodList{
+			"Alice": {Allow: []string{"*"}},
+			"bob":   {Allow: []string{"eth_*"}, Deny: []string{"eth_getLogs"}},
+		},
+	})
+
+	assert.True(t, filter.Allowed("", "eth_blockNumber"))
+	assert.False(t, filter.Allowed("", "debug_traceTransaction"))
+	assert.False(t, filter.Allowed("", "TXPOOL_STATUS"))
+	assert.True(t, filter.Allowed("", "txpool_content"))
+
+	// overridden per tenant
+	assert.True(t, filter.Allowed("alice", "debug_traceTransaction"))
+	assert.True(t, filter.Allowed("bob", "eth_call"))
+	assert.False(t, filter.Allowed("bob", "eth_getLogs"))
+	assert.False(t, filter.Allowed("bob", "net_version"))
+
+	// tenant without override
+	assert.False(t, filter.Allowed("carol", "debug_traceTransaction"))
+}
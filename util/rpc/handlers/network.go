@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+const CtxKeyNetwork = CtxKey("Infura-Network")
+
+// WithNetwork injects the name of extra network (eg., testnet) served on URL prefix into context.
+func WithNetwork(r *http.Request, network string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), CtxKeyNetwork, network))
+}
+
+// GetNetworkFromContext returns the name of extra network served on URL prefix if requested, otherwise
+// the default network is requested.
+func GetNetworkFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(CtxKeyNetwork).(string)
+	return val, ok && len(val) > 0
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	CtxKeyQuotaRemaining = CtxKey("Infura-Quota-Remaining")
+
+	// HTTP headers to respond the remaining compute unit quotas of tenant, which are omitted if
+	// unlimited.
+	HeaderQuotaRemainingDaily   = "X-Quota-Remaining-Daily"
+	HeaderQuotaRemainingMonthly = "X-Quota-Remaining-Monthly"
+)
+
+// QuotaRemaining remaining compute unit quotas of tenant after the request handled.
+type QuotaRemaining struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+// Set updates the remaining quotas, where nil means unlimited.
+func (q *QuotaRemaining) Set(daily, monthly *uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.headers = make(map[string]string)
+
+	if daily != nil {
+		q.headers[HeaderQuotaRemainingDaily] = strconv.FormatUint(*daily, 10)
+	}
+
+	if monthly != nil {
+		q.headers[HeaderQuotaRemainingMonthly] = strconv.FormatUint(*monthly, 10)
+	}
+}
+
+func (q *QuotaRemaining) writeHeaders(header http.Header) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for k, v := range q.headers {
+		header.Set(k, v)
+	}
+}
+
+// GetQuotaRemainingFromContext returns the remaining quotas to respond in HTTP header if any.
+func GetQuotaRemainingFromContext(ctx context.Context) (*QuotaRemaining, bool) {
+	remaining, ok := ctx.Value(CtxKeyQuotaRemaining).(*QuotaRemaining)
+	return remaining, ok
+}
+
+// WithQuotaRemaining injects remaining quotas into request context, and wraps the response writer
+// to respond the remaining quotas in HTTP header if set. Note, websocket upgrade request is left
+// as it is, since the response writer must be hijackable.
+func WithQuotaRemaining(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return w, r
+	}
+
+	remaining := &QuotaRemaining{}
+	ctx := context.WithValue(r.Context(), CtxKeyQuotaRemaining, remaining)
+
+	return &quotaResponseWriter{ResponseWriter: w, remaining: remaining}, r.WithContext(ctx)
+}
+
+// quotaResponseWriter responds the remaining quotas in HTTP header before the body written.
+type quotaResponseWriter struct {
+	http.ResponseWriter
+
+	remaining   *QuotaRemaining
+	wroteHeader bool
+}
+
+func (w *quotaResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.remaining.writeHeaders(w.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *quotaResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
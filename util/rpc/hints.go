@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+var (
+	// pattern to extract wait duration from the rate limited error message
+	retryAfterPattern = regexp.MustCompile(`try again after ([0-9a-zµ.]+)`)
+)
+
+// PaginationHint suggested first page of block range (epoch range for core space) to query,
+// and the successive pages could follow with the same page size.
+type PaginationHint struct {
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// ErrorHints machine-readable hints responded in the JSON-RPC error data, so that client SDKs
+// could auto adapt the request (eg., backoff or split block range) without human intervention.
+type ErrorHints struct {
+	// stable error kind, eg., `over-limit` or `pruned`
+	Kind string `json:"kind,omitempty"`
+	// suggested milliseconds to wait before retry
+	RetryAfterMs *int64 `json:"retryAfterMs,omitempty"`
+	// suggested max block range (epoch range for core space) of the log filter
+	MaxBlockRange *uint64 `json:"maxBlockRange,omitempty"`
+	// suggested pagination params of the log filter
+	Pagination *PaginationHint `json:"pagination,omitempty"`
+	// exhausted compute unit quota of tenant
+	Quota *QuotaHint `json:"quota,omitempty"`
+	// exceeded size limit of response
+	ResponseLimit *ResponseLimitHint `json:"responseLimit,omitempty"`
+}
+
+// QuotaHint exhausted compute unit quota, which will be reset at the specified time.
+type QuotaHint struct {
+	Period  string `json:"period"` // "daily" or "monthly"
+	Limit   uint64 `json:"limit"`
+	Used    uint64 `json:"used"`
+	ResetAt int64  `json:"resetAt"` // unix timestamp in seconds
+}
+
+// ResponseLimitHint exceeded size limit of response, 0 for unlimited or not applicable.
+type ResponseLimitHint struct {
+	Bytes    int `json:"bytes"`
+	MaxBytes int `json:"maxBytes"`
+	Items    int `json:"items,omitempty"`
+	MaxItems int `json:"maxItems,omitempty"`
+}
+
+// HintedError coded error along with hints for the client to auto adapt.
+type HintedError struct {
+	*CodedError
+	Hints *ErrorHints
+}
+
+func NewHintedError(code int, err error, hints *ErrorHints) *HintedError {
+	return &HintedError{CodedError: NewCodedError(code, err), Hints: hints}
+}
+
+// JsonError converts to JSON-RPC error with hints as error data.
+func (e *HintedError) JsonError() *rpc.JsonError {
+	hints := ErrorHints{}
+	if e.Hints != nil {
+		hints = *e.Hints
+	}
+
+	if len(hints.Kind) == 0 {
+		hints.Kind = ErrorKind(e.Code)
+	}
+
+	return &rpc.JsonError{Code: e.Code, Message: e.Error(), Data: &hints}
+}
+
+// ResponseError converts the hinted or coded error (if any in the error chain) to JSON-RPC error
+// so that hints or stable error kind will be responded as error data, otherwise returns the error
+// as it is.
+func ResponseError(err error) error {
+	var jsonErr *rpc.JsonError
+
+	var he *HintedError
+	var ce *CodedError
+	if errors.As(err, &he) {
+		jsonErr = he.JsonError()
+	} else if errors.As(err, &ce) {
+		jsonErr = codedJsonError(ce)
+	} else {
+		return err
+	}
+
+	jsonErr.Message = err.Error()
+
+	return jsonErr
+}
+
+// RetryAfterHint returns hints with the wait duration suggested by the rate limited error,
+// or nil if not available.
+func RetryAfterHint(err error) *ErrorHints {
+	matches := retryAfterPattern.FindStringSubmatch(err.Error())
+	if len(matches) < 2 {
+		return nil
+	}
+
+	waitTime, parseErr := time.ParseDuration(matches[1])
+	if parseErr != nil {
+		return nil
+	}
+
+	// round up to avoid retry too early
+	retryAfterMs := int64((waitTime + time.Millisecond - 1) / time.Millisecond)
+	return &ErrorHints{RetryAfterMs: &retryAfterMs}
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryAfterHint(t *testing.T) {
+	err := errors.New("Too many requests (exceeds 10), try again after 1.5s")
+	hints := RetryAfterHint(errors.WithMessage(err, "allowed qps exceeded"))
+	assert.NotNil(t, hints)
+	assert.Equal(t, int64(1500), *hints.RetryAfterMs)
+
+	hints = RetryAfterHint(errors.New("Too many requests (exceeds 10), try again after 200µs"))
+	assert.NotNil(t, hints)
+	assert.Equal(t, int64(1), *hints.RetryAfterMs)
+
+	assert.Nil(t, RetryAfterHint(errors.New("Too many requests, exceeds 10 at a time")))
+}
+
+func TestResponseError(t *testing.T) {
+	plainErr := errors.New("plain error")
+	assert.Equal(t, plainErr, ResponseError(plainErr))
+
+	maxBlockRange := uint64(100)
+	hintedErr := NewHintedError(ErrCodeLimitExceeded, errors.New("block range too large"), &ErrorHints{
+		MaxBlockRange: &maxBlockRange,
+		Pagination:    &PaginationHint{FromBlock: 1000, ToBlock: 1099},
+	})
+
+	jsonErr := ResponseError(errors.WithMessage(hintedErr, "getLogs"))
+	data, err := json.Marshal(jsonErr)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"code": -32005,
+		"message": "getLogs: block range too large",
+		"data": {"kind": "over-limit", "maxBlockRange": 100, "pagination": {"fromBlock": 1000, "toBlock": 1099}}
+	}`, string(data))
+
+	// stable error kind of coded error
+	jsonErr = ResponseError(errors.WithMessage(ErrReorgInProgress, "getLogs"))
+	data, err = json.Marshal(jsonErr)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"code": -32016,
+		"message": "getLogs: chain reorg in progress, please try again later",
+		"data": {"kind": "reorg-in-progress"}
+	}`, string(data))
+
+	assert.Equal(t, ErrKindTimeout, ErrorKind(ErrCodeQueryTimeout))
+}
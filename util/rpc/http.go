@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/klauspost/compress/gzhttp"
+	"github.com/rs/cors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	accessLogFormatFields   = "fields"
+	accessLogFormatCombined = "combined"
+)
+
+var httpCfg httpConfig
+
+// httpConfig HTTP middleware configurations of the RPC HTTP server, so that deployments serving
+// browsers or dapps directly do not require an extra reverse proxy.
+type httpConfig struct {
+	Cors struct {
+		// whether to handle CORS requests
+		Enabled bool `default:"true"`
+		// allowed origins (`*` as wildcard, eg., `https://*.example.com`), empty for any origin
+		Origins []string
+		// allowed request headers, empty for any header
+		Headers []string
+		// duration to cache the preflight response
+		MaxAge time.Duration `default:"10m"`
+	}
+	// whether to compress response with gzip if accepted by client
+	Gzip bool `default:"true"`
+	// max size in bytes of request body, 0 for the default limit (5MB) of RPC server
+	MaxBodySize int64
+	RequestId   struct {
+		// whether to inject request ID, which is echoed back in response header and logged
+		Enabled bool
+		// header to read the request ID from client and write back, generated if not provided
+		Header string `default:"X-Request-Id"`
+	}
+	AccessLog struct {
+		// whether to log each HTTP request
+		Enabled bool
+		// log format, `fields` for structured fields or `combined` for NCSA combined log line
+		Format string `default:"fields"`
+	}
+}
+
+// newHttpHandlerStack wraps the RPC handler with the configured HTTP middlewares.
+func newHttpHandlerStack(handler http.Handler, conf httpConfig) http.Handler {
+	if conf.Gzip {
+		handler = gzhttp.GzipHandler(handler)
+	}
+
+	if conf.Cors.Enabled {
+		handler = newCorsHandler(handler, conf)
+	}
+
+	if conf.MaxBodySize > 0 {
+		handler = newBodyLimitHandler(handler, conf.MaxBodySize)
+	}
+
+	if conf.AccessLog.Enabled {
+		handler = newAccessLogHandler(handler, conf.AccessLog.Format)
+	}
+
+	if conf.RequestId.Enabled {
+		handler = newRequestIdHandler(handler, conf.RequestId.Header)
+	}
+
+	return handler
+}
+
+func newCorsHandler(handler http.Handler, conf httpConfig) http.Handler {
+	origins, headers := conf.Cors.Origins, conf.Cors.Headers
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+
+	if len(headers) == 0 {
+		headers = []string{"*"}
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodPost, http.MethodGet},
+		AllowedHeaders: headers,
+		ExposedHeaders: []string{conf.RequestId.Header},
+		MaxAge:         int(conf.Cors.MaxAge.Seconds()),
+	}).Handler(handler)
+}
+
+func newBodyLimitHandler(handler http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			msg := fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxBytes)
+			http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func newRequestIdHandler(handler http.Handler, header string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqId := r.Header.Get(header)
+		if len(reqId) == 0 || len(reqId) > 128 {
+			reqId = newRequestId()
+		}
+
+		w.Header().Set(header, reqId)
+
+		ctx := context.WithValue(r.Context(), handlers.CtxKeyRequestId, reqId)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestId() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(buf[:])
+}
+
+// accessLogWriter records the status code and size of the HTTP response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	return n, err
+}
+
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func newAccessLogHandler(handler http.Handler, format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &accessLogWriter{ResponseWriter: w}
+
+		handler.ServeHTTP(lw, r)
+
+		if lw.status == 0 {
+			lw.status = http.StatusOK
+		}
+
+		logger := logrus.NewEntry(logrus.StandardLogger())
+		if reqId, ok := r.Context().Value(handlers.CtxKeyRequestId).(string); ok {
+			logger = logger.WithField("reqId", reqId)
+		}
+
+		if strings.EqualFold(format, accessLogFormatCombined) {
+			logger.Info(combinedLogLine(r, start, lw.status, lw.size))
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"ip":        handlers.GetIPAddress(r),
+			"method":    r.Method,
+			"path":      r.URL.RequestURI(),
+			"status":    lw.status,
+			"size":      lw.size,
+			"elapsed":   time.Since(start),
+			"referer":   r.Referer(),
+			"userAgent": r.UserAgent(),
+		}).Info("HTTP access")
+	})
+}
+
+// combinedLogLine formats the HTTP request in NCSA combined log format.
+func combinedLogLine(r *http.Request, start time.Time, status, size int) string {
+	user := "-"
+	if r.URL.User != nil && len(r.URL.User.Username()) > 0 {
+		user = r.URL.User.Username()
+	}
+
+	return fmt.Sprintf(
+		"%v - %v [%v] \"%v %v %v\" %v %v %q %q",
+		handlers.GetIPAddress(r), user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, r.URL.RequestURI(), r.Proto, status, size, r.Referer(), r.UserAgent(),
+	)
+}
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpHandlerStack(t *testing.T) {
+	var conf httpConfig
+	conf.Cors.Enabled = true
+	conf.Cors.Origins = []string{"https://*.example.com"}
+	conf.MaxBodySize = 16
+	conf.RequestId.Enabled = true
+	conf.RequestId.Header = "X-Request-Id"
+
+	var reqId interface{}
+	handler := newHttpHandlerStack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqId = r.Context().Value(handlers.CtxKeyRequestId)
+	}), conf)
+
+	// request ID echoed back
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("Origin", "https://app.example.com")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, "abc", reqId)
+	assert.Equal(t, "abc", recorder.Header().Get("X-Request-Id"))
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// request ID generated and origin disallowed
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	req.Header.Set("Origin", "https://evil.com")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Len(t, recorder.Header().Get("X-Request-Id"), 32)
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// body too large
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 17)))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func TestPrefixHandler(t *testing.T) {
+	named := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			network, _ := handlers.GetNetworkFromContext(r.Context())
+			w.Write([]byte(name + ":" + handlers.GetAccessToken(r) + "@" + network))
+		})
+	}
+
+	handler := newPrefixHandler(named("default"), "testnet", "/testnet", named("testnet"))
+
+	for path, expected := range map[string]string{
+		"/":                "default:@",
+		"/key":             "default:key@",
+		"/testnet":         "testnet:@testnet",
+		"/testnet/key":     "testnet:key@testnet",
+		"/testnetwork/key": "default:testnetwork@",
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+}
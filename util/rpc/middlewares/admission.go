@@ -0,0 +1,262 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// admission priorities in descending order
+const (
+	admissionPriorityHigh   = "high"   // VIP or SVIP, or tenants configured
+	admissionPriorityNormal = "normal" // tenants or requests with access token
+	admissionPriorityLow    = "low"    // anonymous requests
+)
+
+var (
+	admissionPriorities = []string{admissionPriorityHigh, admissionPriorityNormal, admissionPriorityLow}
+
+	// default max number of queued requests per priority
+	defaultAdmissionQueueSizes = map[string]int{
+		admissionPriorityHigh:   1000,
+		admissionPriorityNormal: 500,
+		admissionPriorityLow:    100,
+	}
+
+	errServerOverloaded = errors.New("server overloaded, please retry later")
+)
+
+// admissionConfig admission control configurations.
+//
+// Note, priorities and tenants are case insensitive since viper lower cases map keys.
+type admissionConfig struct {
+	// whether to enable admission control
+	Enabled bool
+	// max number of in-flight requests globally
+	MaxConcurrency int `default:"1000"`
+	// max duration to wait in queue before shed
+	QueueTimeout time.Duration `default:"1s"`
+	// priority => max number of queued requests, 0 to shed directly under overload
+	QueueSizes map[string]int
+	// tenant => priority, which defaults to normal priority
+	Tenants map[string]string
+}
+
+func (c *admissionConfig) init() {
+	queueSizes := make(map[string]int, len(defaultAdmissionQueueSizes))
+	for priority, size := range defaultAdmissionQueueSizes {
+		queueSizes[priority] = size
+	}
+
+	for priority, size := range c.QueueSizes {
+		priority = strings.ToLower(priority)
+		if _, ok := queueSizes[priority]; !ok {
+			logrus.WithField("priority", priority).Fatal("Invalid admission priority")
+		}
+
+		queueSizes[priority] = size
+	}
+
+	tenants := make(map[string]string, len(c.Tenants))
+	for name, priority := range c.Tenants {
+		priority = strings.ToLower(priority)
+		if _, ok := queueSizes[priority]; !ok {
+			logrus.WithField("priority", priority).Fatal("Invalid admission priority of tenant")
+		}
+
+		tenants[strings.ToLower(name)] = priority
+	}
+
+	c.QueueSizes, c.Tenants = queueSizes, tenants
+}
+
+// admissionController bounds the in-flight requests globally, and queues the excess requests by
+// priority, so that high priority requests are always admitted first under overload, while low
+// priority requests are shed first once queue is full.
+type admissionController struct {
+	conf admissionConfig
+
+	mu       sync.Mutex
+	inflight int
+	queues   map[string][]chan struct{} // priority => waiters in FIFO order
+}
+
+func newAdmissionController(conf admissionConfig) *admissionController {
+	conf.init()
+
+	return &admissionController{
+		conf:   conf,
+		queues: make(map[string][]chan struct{}),
+	}
+}
+
+// Admission returns middleware to enforce admission control with priority queues if enabled.
+func Admission() rpc.HandleCallMsgMiddleware {
+	var conf admissionConfig
+	viper.MustUnmarshalKey("rpc.admission", &conf)
+
+	if !conf.Enabled {
+		return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc { return next }
+	}
+
+	controller := newAdmissionController(conf)
+
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			release, err := controller.admit(ctx, controller.priority(ctx))
+			if err != nil {
+				return msg.ErrorResponse(err)
+			}
+
+			defer release()
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// priority returns the admission priority of request.
+func (c *admissionController) priority(ctx context.Context) string {
+	if _, ok := handlers.VipStatusFromContext(ctx); ok {
+		return admissionPriorityHigh
+	}
+
+	if _, ok := rate.SVipStatusFromContext(ctx); ok {
+		return admissionPriorityHigh
+	}
+
+	if name, ok := tenant.FromContext(ctx); ok {
+		if priority, ok := c.conf.Tenants[strings.ToLower(name)]; ok {
+			return priority
+		}
+
+		return admissionPriorityNormal
+	}
+
+	if key, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(key) > 0 {
+		return admissionPriorityNormal
+	}
+
+	return admissionPriorityLow
+}
+
+// admit admits the request immediately if any idle slot, or queues it until some slot released by
+// requests of the same or higher priority. If admitted, the returned release function must be
+// called after request handled.
+func (c *admissionController) admit(ctx context.Context, priority string) (func(), error) {
+	c.mu.Lock()
+
+	if c.inflight < c.conf.MaxConcurrency {
+		c.inflight++
+		metrics.Registry.RPC.AdmissionInflight().Update(int64(c.inflight))
+		c.mu.Unlock()
+
+		return c.release, nil
+	}
+
+	if len(c.queues[priority]) >= c.conf.QueueSizes[priority] {
+		c.mu.Unlock()
+
+		metrics.Registry.RPC.AdmissionShed(priority, "queuefull").Mark(1)
+		return nil, c.errOverloaded()
+	}
+
+	waiter := make(chan struct{})
+	c.queues[priority] = append(c.queues[priority], waiter)
+	metrics.Registry.RPC.AdmissionQueueDepth(priority).Update(int64(len(c.queues[priority])))
+
+	c.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		metrics.Registry.RPC.AdmissionQueueLatency(priority).Update(time.Since(start).Nanoseconds())
+	}()
+
+	timer := time.NewTimer(c.conf.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-waiter:
+		return c.release, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		metrics.Registry.RPC.AdmissionShed(priority, "timeout").Mark(1)
+		err = c.errOverloaded()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dequeue(priority, waiter) {
+		return nil, err
+	}
+
+	// slot already handed over in the meantime, so pass it on
+	c.releaseLocked()
+
+	return nil, err
+}
+
+// release releases the slot of admitted request, which is handed over to the first queued request
+// of the highest priority if any.
+func (c *admissionController) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.releaseLocked()
+}
+
+func (c *admissionController) releaseLocked() {
+	for _, priority := range admissionPriorities {
+		queue := c.queues[priority]
+		if len(queue) == 0 {
+			continue
+		}
+
+		c.queues[priority] = queue[1:]
+		metrics.Registry.RPC.AdmissionQueueDepth(priority).Update(int64(len(queue) - 1))
+
+		close(queue[0])
+
+		return
+	}
+
+	c.inflight--
+	metrics.Registry.RPC.AdmissionInflight().Update(int64(c.inflight))
+}
+
+// dequeue removes the waiter from queue, and returns false if not found.
+func (c *admissionController) dequeue(priority string, waiter chan struct{}) bool {
+	queue := c.queues[priority]
+
+	for i := range queue {
+		if queue[i] == waiter {
+			c.queues[priority] = append(queue[:i:i], queue[i+1:]...)
+			metrics.Registry.RPC.AdmissionQueueDepth(priority).Update(int64(len(queue) - 1))
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *admissionController) errOverloaded() error {
+	retryAfterMs := c.conf.QueueTimeout.Milliseconds()
+	hints := &rpcutil.ErrorHints{RetryAfterMs: &retryAfterMs}
+
+	return rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, errServerOverloaded, hints).JsonError()
+}
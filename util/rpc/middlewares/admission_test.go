@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmissionController(t *testing.T) {
+	c := newAdmissionController(admissionConfig{
+		MaxConcurrency: 1,
+		QueueTimeout:   time.Second,
+		QueueSizes:     map[string]int{admissionPriorityLow: 0},
+	})
+
+	release, err := c.admit(context.Background(), admissionPriorityLow)
+	assert.NoError(t, err)
+
+	// low priority shed directly under overload
+	_, err = c.admit(context.Background(), admissionPriorityLow)
+	assert.Error(t, err)
+
+	// queue normal priority request before high priority one
+	admitted := make(chan string, 2)
+	for _, priority := range []string{admissionPriorityNormal, admissionPriorityHigh} {
+		go func(priority string) {
+			if release, err := c.admit(context.Background(), priority); err == nil {
+				admitted <- priority
+				release()
+			}
+		}(priority)
+
+		// wait until queued
+		assert.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return len(c.queues[priority]) == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	// high priority admitted first
+	release()
+	assert.Equal(t, admissionPriorityHigh, <-admitted)
+	assert.Equal(t, admissionPriorityNormal, <-admitted)
+
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.inflight == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestAdmissionControllerQueueTimeout(t *testing.T) {
+	c := newAdmissionController(admissionConfig{MaxConcurrency: 1, QueueTimeout: 10 * time.Millisecond})
+
+	release, err := c.admit(context.Background(), admissionPriorityHigh)
+	assert.NoError(t, err)
+
+	_, err = c.admit(context.Background(), admissionPriorityHigh)
+	assert.Error(t, err)
+	assert.Empty(t, c.queues[admissionPriorityHigh])
+
+	release()
+	assert.Equal(t, 0, c.inflight)
+}
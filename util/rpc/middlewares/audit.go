@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/audit"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/cespare/xxhash"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// Audit records the sampled RPC calls asynchronously if audit logging enabled.
+func Audit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		logger := audit.DefaultLogger()
+		if logger == nil {
+			return next(ctx, msg)
+		}
+
+		ctx, entry := audit.NewContext(ctx)
+
+		start := time.Now()
+		resp := next(ctx, msg)
+		latency := time.Since(start)
+
+		failed := resp != nil && resp.Error != nil
+		blocked := entry.Blocked()
+		if !blocked && !logger.Sampled(msg.Method, failed) {
+			return resp
+		}
+
+		record := audit.Record{
+			Time:       start,
+			Method:     msg.Method,
+			ParamsHash: fmt.Sprintf("%016x", xxhash.Sum64(msg.Params)),
+			LatencyMs:  float64(latency) / float64(time.Millisecond),
+			Outcome:    audit.OutcomeSuccess,
+		}
+
+		record.Tenant, record.Node, record.StoreHit = entry.Fields()
+		record.IP, _ = handlers.GetIPAddressFromContext(ctx)
+
+		if failed {
+			record.Outcome = audit.OutcomeError
+			record.ErrorCode = resp.Error.Code
+		}
+
+		if blocked {
+			record.Outcome = audit.OutcomeBlocked
+		}
+
+		logger.Log(&record)
+
+		return resp
+	}
+}
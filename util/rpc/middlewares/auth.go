@@ -4,8 +4,10 @@ import (
 	"context"
 
 	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,7 +26,20 @@ func Auth() rpc.HandleCallMsgMiddleware {
 
 func Authenticate(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
-		if vs, ok := handlers.VipStatusFromContext(ctx); ok { // access from web3pay VIP user
+		if p, ok := handlers.GetPrincipalFromContext(ctx); ok { // access with JWT or HMAC signature
+			if p.Err != nil {
+				return msg.ErrorResponse(rpcutil.NewCodedError(rpcutil.ErrCodeUnauthorized, p.Err))
+			}
+
+			if !p.MethodAllowed(msg.Method) {
+				err := errors.Errorf("method %v not allowed for the credential", msg.Method)
+				return msg.ErrorResponse(rpcutil.NewCodedError(rpcutil.ErrCodeMethodUnsupported, err))
+			}
+
+			if len(p.Subject) > 0 {
+				ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, p.Subject)
+			}
+		} else if vs, ok := handlers.VipStatusFromContext(ctx); ok { // access from web3pay VIP user
 			ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, vs.ID)
 		} else if svs, ok := rate.SVipStatusFromContext(ctx); ok { // access from SVIP user
 			ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, svs.Key)
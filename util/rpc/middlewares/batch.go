@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ctxKeyBatchRetryBudget = handlers.CtxKey("Infura-Batch-Retry-Budget")
+)
+
+// batchConfig batch RPC configurations
+type batchConfig struct {
+	// max number of failed items per batch to be retried internally due to upstream errors
+	MaxRetryItems int `default:"10"`
+}
+
+// batchRetryBudget number of failed items that could still be retried within a batch
+type batchRetryBudget struct {
+	remaining int32
+	retried   int32
+}
+
+func (b *batchRetryBudget) acquire() bool {
+	if atomic.AddInt32(&b.remaining, -1) < 0 {
+		return false
+	}
+
+	atomic.AddInt32(&b.retried, 1)
+	return true
+}
+
+// SoftFailBatch returns batch middleware so that items failed due to upstream errors within
+// the batch could be retried internally, and the number of retried items is capped by config.
+// Note that the batch never fails as a whole, and each failed item is responded with its own
+// error code (eg., limit exceeded or upstream unavailable).
+func SoftFailBatch() rpc.HandleBatchMiddleware {
+	var conf batchConfig
+	viper.MustUnmarshalKey("rpc.batch", &conf)
+
+	return func(next rpc.HandleBatchFunc) rpc.HandleBatchFunc {
+		return func(ctx context.Context, msgs []*rpc.JsonRpcMessage) []*rpc.JsonRpcMessage {
+			budget := &batchRetryBudget{remaining: int32(conf.MaxRetryItems)}
+			ctx = context.WithValue(ctx, ctxKeyBatchRetryBudget, budget)
+
+			resp := next(ctx, msgs)
+
+			var numFailed int64
+			for i := range resp {
+				if resp[i] != nil && resp[i].Error != nil {
+					numFailed++
+				}
+			}
+
+			metrics.Registry.RPC.BatchFailedItems().Update(numFailed)
+			metrics.Registry.RPC.BatchRetriedItems().Update(int64(atomic.LoadInt32(&budget.retried)))
+
+			return resp
+		}
+	}
+}
+
+// SoftFail retries the batch item once on some other full node if failed due to upstream errors, as
+// long as there is still retry budget left for the batch. Note, write methods (eg., sending raw
+// transaction) are never retried in case of duplicate broadcast.
+func SoftFail(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		budget, ok := ctx.Value(ctxKeyBatchRetryBudget).(*batchRetryBudget)
+		if !ok || isWriteMethod(msg.Method) { // not in batch or not retriable
+			return next(ctx, msg)
+		}
+
+		// track the routed full node, so that retry is routed to some other full node
+		ctx = node.WithRoutedNodes(ctx)
+
+		resp := next(ctx, msg)
+		if resp == nil || resp.Error == nil || resp.Error.Code != rpcutil.ErrCodeUpstreamUnavailable {
+			return resp
+		}
+
+		if !budget.acquire() { // retry budget exhausted
+			return resp
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"method": msg.Method,
+			"error":  resp.Error.Message,
+		}).Debug("Retry failed batch item on other full node due to upstream error")
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/node"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftFail(t *testing.T) {
+	var calls int
+	handler := SoftFail(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		calls++
+		node.RecordRoutedNode(ctx, "node1")
+
+		err := rpcutil.NewCodedError(rpcutil.ErrCodeUpstreamUnavailable, errors.New("connection refused"))
+		return msg.ErrorResponse(rpcutil.ResponseError(err))
+	})
+
+	budget := &batchRetryBudget{remaining: 2}
+	ctx := context.WithValue(context.Background(), ctxKeyBatchRetryBudget, budget)
+
+	// retried once within batch
+	resp := handler(ctx, &rpc.JsonRpcMessage{Version: "2.0", ID: []byte("1"), Method: "eth_getBalance"})
+	assert.Equal(t, rpcutil.ErrCodeUpstreamUnavailable, resp.Error.Code)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int32(1), budget.retried)
+
+	// write method never retried
+	calls = 0
+	handler(ctx, &rpc.JsonRpcMessage{Version: "2.0", ID: []byte("2"), Method: "eth_sendRawTransaction"})
+	assert.Equal(t, 1, calls)
+
+	// not retried out of batch
+	calls = 0
+	handler(context.Background(), &rpc.JsonRpcMessage{Version: "2.0", ID: []byte("3"), Method: "eth_getBalance"})
+	assert.Equal(t, 1, calls)
+
+	// retry budget exhausted
+	calls = 0
+	budget.remaining = 0
+	handler(ctx, &rpc.JsonRpcMessage{Version: "2.0", ID: []byte("4"), Method: "eth_getBalance"})
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// checksumConfig result checksum configurations
+type checksumConfig struct {
+	// min size in bytes of JSON-RPC result to compute checksum
+	MinResultSize int `default:"65536"`
+}
+
+// ResultChecksumBatch disables result checksum for batch items, since only one checksum could
+// be responded in HTTP header.
+func ResultChecksumBatch(next rpc.HandleBatchFunc) rpc.HandleBatchFunc {
+	return func(ctx context.Context, msgs []*rpc.JsonRpcMessage) []*rpc.JsonRpcMessage {
+		if _, ok := handlers.GetResultChecksumFromContext(ctx); ok {
+			ctx = context.WithValue(ctx, handlers.CtxKeyResultChecksum, (*handlers.ResultChecksum)(nil))
+		}
+
+		return next(ctx, msgs)
+	}
+}
+
+// ResultChecksum returns middleware to compute checksum of large JSON-RPC result if requested
+// by client, so that client could detect truncated or corrupted transfers through intermediaries.
+func ResultChecksum() rpc.HandleCallMsgMiddleware {
+	var conf checksumConfig
+	viper.MustUnmarshalKey("rpc.checksum", &conf)
+
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			resp := next(ctx, msg)
+
+			checksum, ok := handlers.GetResultChecksumFromContext(ctx)
+			if ok && resp != nil && resp.Error == nil && len(resp.Result) >= conf.MinResultSize {
+				checksum.Set(resp.Result)
+			}
+
+			return resp
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	rpcMethodEthBlockNumber = "eth_blockNumber"
+	rpcMethodEthGetLogs     = "eth_getLogs"
+)
+
+var (
+	blockTagLatest = []byte(`"latest"`)
+
+	// method => index of block parameter, which defaults to `latest` if omitted
+	consistentBlockParamIndexes = map[string]int{
+		"eth_getBalance":                          1,
+		"eth_getCode":                             1,
+		"eth_getTransactionCount":                 1,
+		"eth_getStorageAt":                        2,
+		"eth_call":                                1,
+		"eth_estimateGas":                         1,
+		"eth_feeHistory":                          1,
+		"eth_getBlockByNumber":                    0,
+		"eth_getBlockTransactionCountByNumber":    0,
+		"eth_getTransactionByBlockNumberAndIndex": 0,
+		"eth_getUncleByBlockNumberAndIndex":       0,
+		"eth_getUncleCountByBlockNumber":          0,
+		"eth_getBlockReceipts":                    0,
+	}
+)
+
+// ConsistentRead resolves `latest` block parameter to the head of store rather than the tip of
+// each routed fullnode if opted in by client, so that a sequence of RPC calls from the same client
+// sees a monotonic and consistent snapshot.
+func ConsistentRead(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		cr, ok := handlers.GetConsistentReadFromContext(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		idx, ok := consistentBlockParamIndexes[msg.Method]
+		if !ok && msg.Method != rpcMethodEthBlockNumber && msg.Method != rpcMethodEthGetLogs {
+			return next(ctx, msg)
+		}
+
+		head, err := cr.Head()
+		if err != nil {
+			return msg.ErrorResponse(errors.WithMessage(err, "failed to load store head for consistent read"))
+		}
+
+		headNum, _ := json.Marshal(hexutil.Uint64(head))
+
+		if msg.Method == rpcMethodEthBlockNumber {
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: headNum}
+		}
+
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return next(ctx, msg) // leave it as it is to fail with invalid params
+		}
+
+		if msg.Method == rpcMethodEthGetLogs {
+			ok = resolveLogFilterLatest(params, headNum)
+		} else {
+			params, ok = resolveBlockParamLatest(params, idx, headNum)
+		}
+
+		if ok {
+			if params, err := json.Marshal(params); err == nil {
+				pinned := *msg
+				pinned.Params = params
+				msg = &pinned
+			}
+		}
+
+		return next(ctx, msg)
+	}
+}
+
+// resolveBlockParamLatest resolves `latest` or omitted block parameter, including the EIP-1898
+// style `{"blockNumber": "latest"}`, and returns true if resolved.
+func resolveBlockParamLatest(params []json.RawMessage, idx int, head json.RawMessage) ([]json.RawMessage, bool) {
+	if idx > len(params) { // leave it as it is to fail with invalid params
+		return params, false
+	}
+
+	if idx == len(params) { // omitted
+		return append(params, head), true
+	}
+
+	if isBlockTagLatest(params[idx]) {
+		params[idx] = head
+		return params, true
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(params[idx], &obj); err != nil {
+		return params, false
+	}
+
+	if v, ok := obj["blockNumber"]; !ok || !isBlockTagLatest(v) {
+		return params, false
+	}
+
+	obj["blockNumber"] = head
+
+	resolved, err := json.Marshal(obj)
+	if err != nil {
+		return params, false
+	}
+
+	params[idx] = resolved
+
+	return params, true
+}
+
+// resolveLogFilterLatest resolves `latest` or omitted block range of log filter in place, and
+// returns true if resolved.
+func resolveLogFilterLatest(params []json.RawMessage, head json.RawMessage) bool {
+	if len(params) == 0 {
+		return false
+	}
+
+	var filter map[string]json.RawMessage
+	if err := json.Unmarshal(params[0], &filter); err != nil {
+		return false
+	}
+
+	if _, ok := filter["blockHash"]; ok {
+		return false
+	}
+
+	var resolved bool
+	for _, field := range []string{"fromBlock", "toBlock"} {
+		if v, ok := filter[field]; !ok || isBlockTagLatest(v) {
+			filter[field] = head
+			resolved = true
+		}
+	}
+
+	if !resolved {
+		return false
+	}
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return false
+	}
+
+	params[0] = encoded
+
+	return true
+}
+
+func isBlockTagLatest(v json.RawMessage) bool {
+	v = bytes.TrimSpace(v)
+	return bytes.Equal(v, []byte("null")) || bytes.Equal(v, blockTagLatest)
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsistentRead(t *testing.T) {
+	loads := 0
+	req := httptest.NewRequest(http.MethodPost, "/?consistency=store", nil)
+	req = handlers.WithConsistentRead(req, func() (uint64, error) {
+		loads++
+		return 16, nil
+	})
+
+	var forwarded string
+	handle := ConsistentRead(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		forwarded = string(msg.Params)
+		return msg
+	})
+
+	call := func(ctx context.Context, method, params string) *rpc.JsonRpcMessage {
+		msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: method}
+		if len(params) > 0 {
+			msg.Params = json.RawMessage(params)
+		}
+
+		forwarded = ""
+		return handle(ctx, msg)
+	}
+
+	ctx := req.Context()
+
+	// block number answered by store head
+	resp := call(ctx, "eth_blockNumber", "")
+	assert.Equal(t, `"0x10"`, string(resp.Result))
+
+	// latest or omitted block parameter
+	call(ctx, "eth_getBalance", `["0x0000000000000000000000000000000000000000","latest"]`)
+	assert.Equal(t, `["0x0000000000000000000000000000000000000000","0x10"]`, forwarded)
+
+	call(ctx, "eth_call", `[{"to":"0x0000000000000000000000000000000000000000"}]`)
+	assert.Equal(t, `[{"to":"0x0000000000000000000000000000000000000000"},"0x10"]`, forwarded)
+
+	call(ctx, "eth_call", `[{},{"blockNumber":"latest"}]`)
+	assert.Equal(t, `[{},{"blockNumber":"0x10"}]`, forwarded)
+
+	// explicit block parameter left as it is
+	call(ctx, "eth_getBlockByNumber", `["0x1",false]`)
+	assert.Equal(t, `["0x1",false]`, forwarded)
+
+	// log filter
+	call(ctx, "eth_getLogs", `[{"fromBlock":"0x1"}]`)
+	assert.Equal(t, `[{"fromBlock":"0x1","toBlock":"0x10"}]`, forwarded)
+
+	call(ctx, "eth_getLogs", `[{"blockHash":"0x01"}]`)
+	assert.Equal(t, `[{"blockHash":"0x01"}]`, forwarded)
+
+	// head pinned within the same HTTP request
+	assert.Equal(t, 1, loads)
+
+	// not opted in
+	call(context.Background(), "eth_getBalance", `["0x0000000000000000000000000000000000000000","latest"]`)
+	assert.Equal(t, `["0x0000000000000000000000000000000000000000","latest"]`, forwarded)
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// ErrorKind responds the stable error kind as error data for any coded error without error data,
+// so that API consumers could classify errors of all methods rather than only those converted by
+// `rpcutil.ResponseError` explicitly.
+func ErrorKind(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		resp := next(ctx, msg)
+		if resp == nil || resp.Error == nil || resp.Error.Data != nil {
+			return resp
+		}
+
+		if kind := rpcutil.ErrorKind(resp.Error.Code); len(kind) > 0 {
+			resp.Error.Data = &rpcutil.ErrorHints{Kind: kind}
+		}
+
+		return resp
+	}
+}
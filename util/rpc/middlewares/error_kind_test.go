@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKind(t *testing.T) {
+	var respErr error
+	handler := ErrorKind(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		return msg.ErrorResponse(respErr)
+	})
+
+	msg := &rpc.JsonRpcMessage{Version: "2.0", ID: []byte("1"), Method: "eth_getBlockByNumber"}
+
+	// coded error classified
+	respErr = rpcutil.NewCodedError(rpcutil.ErrCodeDataPruned, errors.New("data already pruned"))
+	resp := handler(context.Background(), msg)
+	assert.Equal(t, rpcutil.ErrCodeDataPruned, resp.Error.Code)
+	assert.Equal(t, &rpcutil.ErrorHints{Kind: rpcutil.ErrKindPruned}, resp.Error.Data)
+
+	// hints of error data reserved
+	hints := &rpcutil.ErrorHints{Kind: rpcutil.ErrKindOverLimit, RetryAfterMs: new(int64)}
+	respErr = rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, errors.New("rate limited"), hints).JsonError()
+	resp = handler(context.Background(), msg)
+	assert.Equal(t, hints, resp.Error.Data)
+
+	// unclassified error
+	respErr = errors.New("execution reverted")
+	resp = handler(context.Background(), msg)
+	assert.Nil(t, resp.Error.Data)
+}
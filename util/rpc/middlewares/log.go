@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/sirupsen/logrus"
 )
@@ -35,6 +36,10 @@ func Log(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 		}
 
 		logger := logrus.WithField("input", msg)
+		if reqId, ok := ctx.Value(handlers.CtxKeyRequestId).(string); ok {
+			logger = logger.WithField("reqId", reqId)
+		}
+
 		logger.Debug("RPC enter")
 
 		start := time.Now()
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"context"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+// MethodFilter rejects the RPC methods not exposed on the endpoint or to the tenant if configured.
+func MethodFilter(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		filter, ok := ctx.Value(handlers.CtxKeyMethodFilter).(*handlers.MethodFilter)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		name, _ := tenant.FromContext(ctx)
+		if !filter.Allowed(name, msg.Method) {
+			err := errors.Errorf("the method %v is not available", msg.Method)
+			return msg.ErrorResponse(rpcutil.NewCodedError(rpcutil.ErrCodeMethodUnsupported, err))
+		}
+
+		return next(ctx, msg)
+	}
+}
@@ -33,6 +33,11 @@ func Metrics(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 			metricMethod = "method_not_found"
 		}
 
+		// scope metrics by extra network (eg., `testnet/cfx_getBalance`) served on URL prefix
+		if network, ok := handlers.GetNetworkFromContext(ctx); ok {
+			metricMethod = network + "/" + metricMethod
+		}
+
 		// collect rpc QPS/latency etc.
 		metrics.Registry.RPC.UpdateDuration(metricMethod, resp.Error, start)
 		// collect traffic hits
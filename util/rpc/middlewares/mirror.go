@@ -0,0 +1,236 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+// max length of result to log once mirrored response mismatched
+const mirrorMaxLoggedResult = 512
+
+var (
+	// read-only methods mirrored by default
+	defaultMirrorMethods = []string{
+		"cfx_getBlockByHash", "cfx_getBlockByEpochNumber", "cfx_getTransactionByHash",
+		"cfx_getTransactionReceipt", "cfx_getBalance", "cfx_getCode", "cfx_call", "cfx_getLogs",
+		"eth_getBlockByHash", "eth_getBlockByNumber", "eth_getTransactionByHash",
+		"eth_getTransactionReceipt", "eth_getBalance", "eth_getCode", "eth_call", "eth_getLogs",
+	}
+
+	// write methods never mirrored or retried
+	writeMethodSuffixes = []string{"_sendRawTransaction", "_sendTransaction"}
+)
+
+// mirrorConfig shadow traffic mirroring configurations
+type mirrorConfig struct {
+	// whether to mirror shadow traffic to canary fullnode
+	Enabled bool
+	// canary fullnode URL of core space, empty to disable mirroring for core space
+	CfxNode string
+	// canary fullnode URL of evm space, empty to disable mirroring for evm space
+	EthNode string
+	// ratio (0 ~ 1) of RPC calls to mirror
+	SampleRate float64 `default:"0.01"`
+	// read-only methods (case insensitive) to mirror, defaults to common read methods if empty
+	Methods []string
+	// timeout of the mirrored RPC call
+	Timeout time.Duration `default:"5s"`
+	// max number of in-flight mirrored RPC calls, beyond which new ones will be dropped
+	MaxConcurrency int `default:"16"`
+}
+
+// mirror duplicates sampled RPC calls to the canary fullnodes asynchronously.
+type mirror struct {
+	conf    mirrorConfig
+	methods map[string]bool
+	cfx     *rpc.Client
+	eth     *rpc.Client
+	sem     chan struct{}
+}
+
+func mustNewMirror(conf mirrorConfig) *mirror {
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = defaultMirrorMethods
+	}
+
+	m := &mirror{
+		conf:    conf,
+		methods: make(map[string]bool),
+		sem:     make(chan struct{}, conf.MaxConcurrency),
+	}
+
+	for _, method := range methods {
+		if isWriteMethod(method) {
+			logrus.WithField("method", method).Warn("Write method ignored for shadow traffic mirroring")
+			continue
+		}
+
+		m.methods[strings.ToLower(method)] = true
+	}
+
+	var err error
+
+	if len(conf.CfxNode) > 0 {
+		if m.cfx, err = rpc.DialHTTP(conf.CfxNode); err != nil {
+			logrus.WithError(err).WithField("url", conf.CfxNode).Fatal("Failed to dial core space canary node")
+		}
+	}
+
+	if len(conf.EthNode) > 0 {
+		if m.eth, err = rpc.DialHTTP(conf.EthNode); err != nil {
+			logrus.WithError(err).WithField("url", conf.EthNode).Fatal("Failed to dial evm space canary node")
+		}
+	}
+
+	return m
+}
+
+func isWriteMethod(method string) bool {
+	for _, suffix := range writeMethodSuffixes {
+		if strings.HasSuffix(strings.ToLower(method), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canary returns the canary fullnode client to mirror the specified method, or nil if not mirrored.
+func (m *mirror) canary(method string) *rpc.Client {
+	if !m.methods[strings.ToLower(method)] {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(method, "cfx_"):
+		return m.cfx
+	case strings.HasPrefix(method, "eth_"):
+		return m.eth
+	default:
+		return nil
+	}
+}
+
+// sample mirrors the RPC call asynchronously if sampled, and discards the response of canary fullnode
+// once compared.
+func (m *mirror) sample(msg *rpc.JsonRpcMessage, resp *rpc.JsonRpcMessage) {
+	// only mirror successful calls, since errors (eg., rate limited) are mostly raised by gateway
+	if resp == nil || resp.Error != nil {
+		return
+	}
+
+	client := m.canary(msg.Method)
+	if client == nil || rand.Float64() >= m.conf.SampleRate {
+		return
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		metrics.Registry.RPC.MirrorDropped().Mark(1)
+		return
+	}
+
+	go func() {
+		defer func() { <-m.sem }()
+		m.call(client, msg, resp.Result)
+	}()
+}
+
+func (m *mirror) call(client *rpc.Client, msg *rpc.JsonRpcMessage, expected json.RawMessage) {
+	logger := logrus.WithFields(logrus.Fields{
+		"method": msg.Method,
+		"params": string(msg.Params),
+	})
+
+	var params []json.RawMessage
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			logger.WithError(err).Debug("Failed to unmarshal params for shadow traffic mirroring")
+			return
+		}
+	}
+
+	args := make([]interface{}, 0, len(params))
+	for _, p := range params {
+		args = append(args, p)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.conf.Timeout)
+	defer cancel()
+
+	var result json.RawMessage
+	if err := client.CallContext(ctx, &result, msg.Method, args...); err != nil {
+		metrics.Registry.RPC.MirrorErrors(msg.Method).Mark(1)
+		logger.WithError(err).Info("Failed to mirror RPC call to canary node")
+		return
+	}
+
+	matched := mirrorMatched(expected, result)
+	metrics.Registry.RPC.MirrorMatched(msg.Method).Mark(matched)
+
+	if !matched {
+		logger.WithFields(logrus.Fields{
+			"expected": truncateMirrorResult(expected),
+			"canary":   truncateMirrorResult(result),
+		}).Info("Mirrored RPC response mismatched with canary node")
+	}
+}
+
+// mirrorMatched checks whether the two JSON results are semantically equal, regardless of field order
+// and whitespaces.
+func mirrorMatched(expected, actual json.RawMessage) bool {
+	var v1, v2 interface{}
+
+	if err := json.Unmarshal(expected, &v1); err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(actual, &v2); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(v1, v2)
+}
+
+func truncateMirrorResult(result json.RawMessage) string {
+	if len(result) > mirrorMaxLoggedResult {
+		return string(result[:mirrorMaxLoggedResult]) + "..."
+	}
+
+	return string(result)
+}
+
+// Mirror returns middleware to duplicate a sample of read requests to the canary fullnodes
+// asynchronously, whose responses are discarded while mismatches logged, so as to validate new
+// fullnode versions before adding them into the node cluster.
+func Mirror() rpc.HandleCallMsgMiddleware {
+	var conf mirrorConfig
+	viper.MustUnmarshalKey("rpc.mirror", &conf)
+
+	if !conf.Enabled || (len(conf.CfxNode) == 0 && len(conf.EthNode) == 0) {
+		return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+			return next
+		}
+	}
+
+	m := mustNewMirror(conf)
+
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			resp := next(ctx, msg)
+			m.sample(msg, resp)
+			return resp
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorMatched(t *testing.T) {
+	assert.True(t, mirrorMatched(json.RawMessage(`{"a":1,"b":[1,2]}`), json.RawMessage(`{ "b": [1, 2], "a": 1 }`)))
+	assert.True(t, mirrorMatched(json.RawMessage(`null`), json.RawMessage(`null`)))
+	assert.False(t, mirrorMatched(json.RawMessage(`{"a":1}`), json.RawMessage(`{"a":2}`)))
+	assert.False(t, mirrorMatched(json.RawMessage(`"0x1"`), json.RawMessage(`null`)))
+}
+
+func TestMirrorMethods(t *testing.T) {
+	m := mustNewMirror(mirrorConfig{
+		EthNode:        "http://127.0.0.1:8545",
+		Methods:        []string{"ETH_call", "eth_sendRawTransaction", "cfx_getLogs"},
+		MaxConcurrency: 1,
+	})
+
+	assert.NotNil(t, m.canary("eth_call"))
+	assert.Nil(t, m.canary("eth_getLogs"))
+	assert.Nil(t, m.canary("eth_sendRawTransaction"))
+	assert.Nil(t, m.canary("cfx_getLogs")) // core space canary not configured
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
@@ -33,7 +34,7 @@ func QpsRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 }
 
 func errQpsRateLimited(err error) error {
-	return errors.WithMessage(err, "allowed qps exceeded")
+	return errRateLimitedWithHints(errors.WithMessage(err, "allowed qps exceeded"))
 }
 
 func DailyMaxReqRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
@@ -53,5 +54,15 @@ func DailyMaxReqRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 }
 
 func errDailyMaxReqRateLimited(err error) error {
-	return errors.WithMessage(err, "daily request limit exceeded")
+	return errRateLimitedWithHints(errors.WithMessage(err, "daily request limit exceeded"))
+}
+
+// errRateLimitedWithHints responds the suggested retry-after in error data if available.
+func errRateLimitedWithHints(err error) error {
+	hints := rpcutil.RetryAfterHint(err)
+	if hints == nil {
+		return rpcutil.NewCodedError(rpcutil.ErrCodeLimitExceeded, err)
+	}
+
+	return rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, err, hints).JsonError()
 }
@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+var errResponseTooLarge = errors.New("response too large, narrow your filter")
+
+// responseLimit max size of JSON-RPC result, 0 for unlimited.
+type responseLimit struct {
+	// max size in bytes of JSON-RPC result
+	MaxBytes int
+	// max number of items if JSON-RPC result is an array
+	MaxItems int
+}
+
+// responseLimitConfig per-method response size cap configurations.
+//
+// Note, method names are case insensitive since viper lower cases map keys.
+type responseLimitConfig struct {
+	// whether to cap the response size
+	Enabled bool
+	// limits for methods not specified
+	Default responseLimit
+	// method => limits
+	Methods map[string]responseLimit
+}
+
+func (c *responseLimitConfig) limit(method string) responseLimit {
+	if limit, ok := c.Methods[strings.ToLower(method)]; ok {
+		return limit
+	}
+
+	return c.Default
+}
+
+// ResponseLimit returns middleware to cap the size (bytes and item count) of JSON-RPC result after
+// handler executed, so as to protect the gateway from pathological queries that passed the pre-checks.
+func ResponseLimit() rpc.HandleCallMsgMiddleware {
+	var conf responseLimitConfig
+	viper.MustUnmarshalKey("rpc.responseLimit", &conf)
+
+	return newResponseLimitMiddleware(conf)
+}
+
+func newResponseLimitMiddleware(conf responseLimitConfig) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			resp := next(ctx, msg)
+			if !conf.Enabled || resp == nil || resp.Error != nil {
+				return resp
+			}
+
+			limit := conf.limit(msg.Method)
+			if limit.MaxBytes <= 0 && limit.MaxItems <= 0 {
+				return resp
+			}
+
+			hint := rpcutil.ResponseLimitHint{Bytes: len(resp.Result), MaxBytes: limit.MaxBytes}
+			oversized := limit.MaxBytes > 0 && hint.Bytes > limit.MaxBytes
+
+			if !oversized && limit.MaxItems > 0 {
+				if items, ok := countJsonArrayItems(resp.Result); ok {
+					hint.Items, hint.MaxItems = items, limit.MaxItems
+					oversized = items > limit.MaxItems
+				}
+			}
+
+			metrics.Registry.RPC.Percentage(msg.Method, "response/oversized").Mark(oversized)
+
+			if !oversized {
+				return resp
+			}
+
+			hints := &rpcutil.ErrorHints{ResponseLimit: &hint}
+			jsonErr := rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, errResponseTooLarge, hints).JsonError()
+
+			return &rpc.JsonRpcMessage{Version: resp.Version, ID: resp.ID, Error: jsonErr}
+		}
+	}
+}
+
+// countJsonArrayItems returns the number of top level items if the JSON data is an array.
+func countJsonArrayItems(data []byte) (int, bool) {
+	var depth, commas int
+	var inString, escaped, isArray bool
+	empty := true
+
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+
+			continue
+		}
+
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+
+		if depth == 0 {
+			if c != '[' { // object or scalar value
+				return 0, false
+			}
+
+			isArray = true
+			depth++
+
+			continue
+		}
+
+		if depth == 1 && c != ']' {
+			empty = false
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 1 {
+				commas++
+			}
+		}
+	}
+
+	if !isArray || empty {
+		return 0, isArray
+	}
+
+	return commas + 1, true
+}
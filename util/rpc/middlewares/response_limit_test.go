@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountJsonArrayItems(t *testing.T) {
+	for data, expected := range map[string]int{
+		`[]`:                        0,
+		` [ ] `:                     0,
+		`[1]`:                       1,
+		`[1, 2, 3]`:                 3,
+		`[{"a":[1,2]},{"b":"x,]"}]`: 2,
+		`["a\"],", "b"]`:            2,
+		`[[1,2],[3,4],[5]]`:         3,
+	} {
+		items, ok := countJsonArrayItems([]byte(data))
+		assert.True(t, ok, data)
+		assert.Equal(t, expected, items, data)
+	}
+
+	for _, data := range []string{`{"a":[1,2]}`, `"0x1"`, `null`} {
+		_, ok := countJsonArrayItems([]byte(data))
+		assert.False(t, ok, data)
+	}
+}
+
+func TestResponseLimit(t *testing.T) {
+	var result json.RawMessage
+	handle := newResponseLimitMiddleware(responseLimitConfig{
+		Enabled: true,
+		Methods: map[string]responseLimit{
+			"eth_getlogs": {MaxBytes: 16, MaxItems: 2},
+		},
+	})(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+	})
+
+	call := func(method string) *rpc.JsonRpcMessage {
+		msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: method}
+		return handle(context.Background(), msg)
+	}
+
+	// within limits
+	result = json.RawMessage(`[1,2]`)
+	assert.Nil(t, call("eth_getLogs").Error)
+
+	// too many items
+	result = json.RawMessage(`[1,2,3]`)
+	resp := call("eth_getLogs")
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, rpcutil.ErrCodeLimitExceeded, resp.Error.Code)
+	assert.Equal(t, json.RawMessage("1"), resp.ID)
+
+	hints := resp.Error.Data.(*rpcutil.ErrorHints)
+	assert.Equal(t, 3, hints.ResponseLimit.Items)
+
+	// too many bytes
+	result = json.RawMessage(`["0x0123456789abcdef"]`)
+	assert.NotNil(t, call("eth_getLogs").Error)
+
+	// unlimited for methods not specified
+	assert.Nil(t, call("eth_getBlockByNumber").Error)
+}
@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/audit"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// account unlocking and signing RPC methods, which are always rejected by default in case of
+	// accidental exposure of any permissive fullnode in the node cluster.
+	signingMethods = []string{
+		"cfx_accounts", "cfx_sign", "cfx_signTransaction", "cfx_sendTransaction",
+		"eth_accounts", "eth_sign", "eth_signTransaction", "eth_sendTransaction", "eth_signTypedData*",
+		"personal_*", "wallet_*",
+	}
+
+	signingConf     signingConfig
+	signingConfOnce sync.Once
+)
+
+// signingConfig configurations to guard the account unlocking and signing RPC methods.
+type signingConfig struct {
+	// whether to allow and forward the signing methods to fullnodes, only for private deployments
+	Allow bool
+	// extra methods (case insensitive, and `*` suffix as wildcard) to reject besides the default ones
+	Methods []string
+}
+
+// signingBlocked structured error data of the rejected signing methods.
+type signingBlocked struct {
+	Method string `json:"method"`
+	Reason string `json:"reason"`
+}
+
+func loadSigningConfig() signingConfig {
+	signingConfOnce.Do(func() {
+		viper.MustUnmarshalKey("rpc.signing", &signingConf)
+	})
+
+	return signingConf
+}
+
+// SigningAllowed returns whether the account unlocking and signing RPC methods are allowed to be
+// forwarded to fullnodes.
+func SigningAllowed() bool {
+	return loadSigningConfig().Allow
+}
+
+// SigningGuard returns middleware to reject the account unlocking and signing RPC methods, which
+// are always recorded in audit log if enabled.
+func SigningGuard() rpc.HandleCallMsgMiddleware {
+	return newSigningGuard(loadSigningConfig())
+}
+
+func newSigningGuard(conf signingConfig) rpc.HandleCallMsgMiddleware {
+	if conf.Allow {
+		return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc { return next }
+	}
+
+	methods := append(append([]string{}, signingMethods...), conf.Methods...)
+
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			if !handlers.MatchMethod(methods, msg.Method) {
+				return next(ctx, msg)
+			}
+
+			audit.SetBlocked(ctx)
+
+			name, _ := tenant.FromContext(ctx)
+			ip, _ := handlers.GetIPAddressFromContext(ctx)
+			logrus.WithFields(logrus.Fields{
+				"method": msg.Method, "tenant": name, "ip": ip,
+			}).Warn("Signing RPC method rejected")
+
+			return msg.ErrorResponse(&rpc.JsonError{
+				Code: rpcutil.ErrCodeMethodUnsupported,
+				Message: fmt.Sprintf(
+					"method %v not supported by gateway, please use a wallet to manage accounts", msg.Method,
+				),
+				Data: signingBlocked{Method: msg.Method, Reason: "signing"},
+			})
+		}
+	}
+}
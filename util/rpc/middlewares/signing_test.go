@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningGuard(t *testing.T) {
+	next := func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage(`"0x1"`)}
+	}
+
+	call := func(conf signingConfig, method string) *rpc.JsonRpcMessage {
+		msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: method}
+		return newSigningGuard(conf)(next)(context.Background(), msg)
+	}
+
+	conf := signingConfig{Methods: []string{"debug_unlock*"}}
+
+	for _, method := range []string{
+		"eth_sign", "ETH_ACCOUNTS", "eth_signTypedData_v3", "personal_unlockAccount", "debug_unlockAll",
+	} {
+		resp := call(conf, method)
+		if assert.NotNil(t, resp.Error, method) {
+			assert.Equal(t, rpcutil.ErrCodeMethodUnsupported, resp.Error.Code)
+		}
+	}
+
+	assert.Nil(t, call(conf, "eth_call").Error)
+	assert.Nil(t, call(conf, "eth_sendRawTransaction").Error)
+
+	// allowed on private deployments
+	assert.Nil(t, call(signingConfig{Allow: true}, "eth_sign").Error)
+}
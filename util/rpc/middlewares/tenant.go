@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/audit"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tenant"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+// Tenant tags the request with tenant by API key (access token), and accounts the usage of tenant.
+func Tenant(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		registry, ok := ctx.Value(handlers.CtxKeyTenantRegistry).(*tenant.Registry)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		key, ok := handlers.GetAccessTokenFromContext(ctx)
+		if !ok || len(key) == 0 {
+			return next(ctx, msg)
+		}
+
+		apiKey, ok, err := registry.Lookup(key)
+		if err != nil { // fail closed, otherwise revoked API keys might be accepted
+			return msg.ErrorResponse(tenant.ErrApiKeyUnverified)
+		}
+
+		if !ok { // not an API key, eg., rate limit key or web3pay key
+			return next(ctx, msg)
+		}
+
+		if apiKey.Revoked {
+			return msg.ErrorResponse(tenant.ErrApiKeyRevoked)
+		}
+
+		audit.SetTenant(ctx, apiKey.Tenant)
+
+		if _, err := registry.CheckQuota(apiKey.Tenant); err != nil {
+			registry.Record(apiKey.Tenant, tenant.MethodQuotaExceeded, true)
+			return msg.ErrorResponse(errQuotaExceeded(err))
+		}
+
+		resp := next(tenant.NewContext(ctx, apiKey.Tenant), msg)
+
+		// account unknown methods altogether to bound the usage records
+		method := msg.Method
+		if resp.Error != nil && isMethodNotFoundByError(msg.Method, resp.Error) {
+			method = "method_not_found"
+		}
+
+		registry.Record(apiKey.Tenant, method, resp.Error != nil)
+
+		// respond the remaining quotas in HTTP header
+		if remaining, ok := handlers.GetQuotaRemainingFromContext(ctx); ok {
+			if status, ok := registry.Quota(apiKey.Tenant); ok {
+				remaining.Set(quotaRemaining(status.Daily), quotaRemaining(status.Monthly))
+			}
+		}
+
+		return resp
+	}
+}
+
+func quotaRemaining(period *tenant.QuotaPeriod) *uint64 {
+	if period == nil { // unlimited
+		return nil
+	}
+
+	return &period.Remaining
+}
+
+// errQuotaExceeded responds the exhausted quota along with the suggested retry-after in error data.
+func errQuotaExceeded(err error) error {
+	var qe *tenant.QuotaExceededError
+	if !errors.As(err, &qe) {
+		return err
+	}
+
+	retryAfterMs := time.Until(qe.ResetAt).Milliseconds()
+	hints := &rpcutil.ErrorHints{
+		RetryAfterMs: &retryAfterMs,
+		Quota: &rpcutil.QuotaHint{
+			Period:  qe.Period,
+			Limit:   qe.Limit,
+			Used:    qe.Used,
+			ResetAt: qe.ResetAt.Unix(),
+		},
+	}
+
+	return rpcutil.NewHintedError(rpcutil.ErrCodeLimitExceeded, err, hints).JsonError()
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// Timeout bounds the handling time of RPC call by the configured timeout of method, so that the
+// remaining time budget could be propagated into store queries and fullnode delegations.
+func Timeout(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		// subscriptions outlive the RPC call
+		if strings.HasSuffix(msg.Method, "_subscribe") || strings.HasSuffix(msg.Method, "_unsubscribe") {
+			return next(ctx, msg)
+		}
+
+		if rpcutil.MethodTimeout(msg.Method) <= 0 {
+			return next(ctx, msg)
+		}
+
+		ctx, cancel := rpcutil.WithMethodTimeout(ctx, msg.Method)
+		defer cancel()
+
+		return next(ctx, msg)
+	}
+}
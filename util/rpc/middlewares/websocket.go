@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// WebsocketLimits keeps the websocket connection active upon request, and constrains the max number
+// of subscriptions per connection.
+func WebsocketLimits(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conn, ok := rpcutil.WsConnFromContext(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		conn.Touch()
+
+		switch {
+		case strings.HasSuffix(msg.Method, "_subscribe"):
+			if !conn.AcquireSubscription() {
+				return msg.ErrorResponse(rpcutil.ErrTooManySubscriptions)
+			}
+
+			resp := next(ctx, msg)
+			if resp == nil || resp.Error != nil {
+				conn.ReleaseSubscription()
+			}
+
+			return resp
+		case strings.HasSuffix(msg.Method, "_unsubscribe"):
+			resp := next(ctx, msg)
+			if resp != nil && resp.Error == nil && string(resp.Result) == "true" {
+				conn.ReleaseSubscription()
+			}
+
+			return resp
+		default:
+			return next(ctx, msg)
+		}
+	}
+}
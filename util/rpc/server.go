@@ -2,13 +2,14 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
-	"github.com/ethereum/go-ethereum/node"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -53,14 +54,15 @@ func MustNewServer(name string, rpcs map[string]interface{}, middlewares ...hand
 	}).Info("RPC server APIs registered")
 
 	httpServer := http.Server{
-		Handler: node.NewHTTPHandlerStack(handler, []string{"*"}, []string{"*"}),
+		Handler: newHttpHandlerStack(handler, httpCfg),
 	}
 
 	viper.SetDefault("rpc.wsPingInterval", defaultWsPingInterval)
+	wsHandler := handler.WebsocketHandler([]string{"*"}, rpc.WebsocketOption{
+		WsPingInterval: viper.GetDuration("rpc.wsPingInterval"),
+	})
 	wsServer := http.Server{
-		Handler: handler.WebsocketHandler([]string{"*"}, rpc.WebsocketOption{
-			WsPingInterval: viper.GetDuration("rpc.wsPingInterval"),
-		}),
+		Handler: newWsLimiter(name, wsCfg).Handler(wsHandler),
 	}
 
 	for i := len(middlewares) - 1; i >= 0; i-- {
@@ -77,6 +79,39 @@ func MustNewServer(name string, rpcs map[string]interface{}, middlewares ...hand
 	}
 }
 
+// Mount serves the specified RPC server of the named network on the URL path prefix (eg., `/testnet`)
+// of the same endpoint for all protocols, in which case the prefix is stripped from URL path and the
+// network name is injected into request context before dispatched. Note, this method must be called
+// before served.
+func (s *Server) Mount(network, prefix string, sub *Server) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	for protocol, server := range s.servers {
+		if subServer, ok := sub.servers[protocol]; ok {
+			server.Handler = newPrefixHandler(server.Handler, network, prefix, subServer.Handler)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"name":    s.name,
+		"network": network,
+		"prefix":  prefix,
+		"sub":     sub.name,
+	}).Info("RPC server mounted")
+}
+
+func newPrefixHandler(handler http.Handler, network, prefix string, sub http.Handler) http.Handler {
+	sub = http.StripPrefix(prefix, sub)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			sub.ServeHTTP(w, handlers.WithNetwork(r, network))
+		} else {
+			handler.ServeHTTP(w, r)
+		}
+	})
+}
+
 // MustServe serves RPC server in blocking way or panics if failed.
 func (s *Server) MustServe(endpoint string, protocol Protocol) {
 	logger := logrus.WithFields(logrus.Fields{
@@ -95,6 +130,16 @@ func (s *Server) MustServe(endpoint string, protocol Protocol) {
 		logger.WithError(err).Fatal("Failed to listen to endpoint")
 	}
 
+	tlsConf, err := serverTLSConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load TLS configurations")
+	}
+
+	if tlsConf != nil {
+		listener = tls.NewListener(listener, tlsConf)
+		logger = logger.WithField("tls", true)
+	}
+
 	logger.Info("JSON RPC server started")
 
 	server.Serve(listener)
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	web3Types "github.com/openweb3/web3go/types"
+)
+
+// Tag block tag unified for both spaces, which is mapped onto the epoch tag of core space and the block
+// tag of evm space by Conflux confirmation rules.
+type Tag string
+
+const (
+	TagEarliest   Tag = "earliest"
+	TagLatest     Tag = "latest"            // latest executed, `latest_state` of core space
+	TagPending    Tag = "pending"           // latest mined but not executed, `latest_mined` of core space
+	TagSafe       Tag = "safe"              // confirmed with low revert risk, `latest_confirmed` of core space
+	TagFinalized  Tag = "finalized"         // finalized by PoS, `latest_finalized` of core space
+	TagCheckpoint Tag = "latest_checkpoint" // core space only
+)
+
+var (
+	tag2Epochs = map[Tag]*types.Epoch{
+		TagEarliest:   types.EpochEarliest,
+		TagLatest:     types.EpochLatestState,
+		TagPending:    types.EpochLatestMined,
+		TagSafe:       types.EpochLatestConfirmed,
+		TagFinalized:  types.EpochLatestFinalized,
+		TagCheckpoint: types.EpochLatestCheckpoint,
+	}
+
+	tag2Blocks = map[Tag]web3Types.BlockNumber{
+		TagEarliest:  web3Types.EarliestBlockNumber,
+		TagLatest:    web3Types.LatestBlockNumber,
+		TagPending:   web3Types.PendingBlockNumber,
+		TagSafe:      web3Types.SafeBlockNumber,
+		TagFinalized: web3Types.FinalizedBlockNumber,
+	}
+
+	epoch2Tags = make(map[string]Tag)
+	block2Tags = make(map[web3Types.BlockNumber]Tag)
+)
+
+func init() {
+	for tag, epoch := range tag2Epochs {
+		epoch2Tags[epoch.String()] = tag
+	}
+
+	for tag, bn := range tag2Blocks {
+		if bn < 0 { // earliest block is also block number 0
+			block2Tags[bn] = tag
+		}
+	}
+}
+
+// ParseTag parses the unified tag from either block tag of evm space or epoch tag of core space,
+// eg., both `latest` and `latest_state` are parsed as `TagLatest`.
+func ParseTag(s string) (Tag, bool) {
+	if tag, ok := epoch2Tags[s]; ok {
+		return tag, true
+	}
+
+	if _, ok := tag2Blocks[Tag(s)]; ok {
+		return Tag(s), true
+	}
+
+	return "", false
+}
+
+// CfxEpochTag returns the unified tag of core space epoch, or false if epoch number or hash.
+func CfxEpochTag(epoch *types.Epoch) (Tag, bool) {
+	if epoch == nil {
+		return "", false
+	}
+
+	tag, ok := epoch2Tags[epoch.String()]
+	return tag, ok
+}
+
+// EthBlockTag returns the unified tag of evm space block number, or false if block number.
+func EthBlockTag(bn web3Types.BlockNumber) (Tag, bool) {
+	tag, ok := block2Tags[bn]
+	return tag, ok
+}
+
+// CfxEpoch returns the core space epoch tag.
+func (t Tag) CfxEpoch() (*types.Epoch, bool) {
+	epoch, ok := tag2Epochs[t]
+	return epoch, ok
+}
+
+// EthBlockNumber returns the evm space block tag, or false if not available in evm space.
+func (t Tag) EthBlockNumber() (web3Types.BlockNumber, bool) {
+	bn, ok := tag2Blocks[t]
+	return bn, ok
+}
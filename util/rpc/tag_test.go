@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagMapping(t *testing.T) {
+	tag, ok := CfxEpochTag(types.EpochLatestConfirmed)
+	assert.True(t, ok)
+	assert.Equal(t, TagSafe, tag)
+
+	bn, ok := tag.EthBlockNumber()
+	assert.True(t, ok)
+	assert.Equal(t, web3Types.SafeBlockNumber, bn)
+
+	tag, ok = EthBlockTag(web3Types.FinalizedBlockNumber)
+	assert.True(t, ok)
+
+	epoch, ok := tag.CfxEpoch()
+	assert.True(t, ok)
+	assert.Equal(t, types.EpochLatestFinalized, epoch)
+
+	// core space only
+	_, ok = TagCheckpoint.EthBlockNumber()
+	assert.False(t, ok)
+
+	// neither epoch nor block number is tag
+	_, ok = CfxEpochTag(types.NewEpochNumberUint64(100))
+	assert.False(t, ok)
+
+	_, ok = EthBlockTag(web3Types.BlockNumber(100))
+	assert.False(t, ok)
+}
+
+func TestParseTag(t *testing.T) {
+	testCases := map[string]Tag{
+		"latest":            TagLatest,
+		"latest_state":      TagLatest,
+		"pending":           TagPending,
+		"latest_mined":      TagPending,
+		"safe":              TagSafe,
+		"latest_confirmed":  TagSafe,
+		"finalized":         TagFinalized,
+		"latest_finalized":  TagFinalized,
+		"latest_checkpoint": TagCheckpoint,
+		"earliest":          TagEarliest,
+	}
+
+	for s, expected := range testCases {
+		tag, ok := ParseTag(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, tag, s)
+	}
+
+	_, ok := ParseTag("0x10")
+	assert.False(t, ok)
+}
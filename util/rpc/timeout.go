@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	timeoutCfg timeoutConfig
+
+	// default method timeouts, which could be overridden by configurations
+	defaultMethodTimeouts = map[string]time.Duration{
+		"cfx_getlogs": 6 * time.Second,
+		"eth_getlogs": 6 * time.Second,
+	}
+
+	// default time budgets of store queries within the method timeouts, so that the remaining time
+	// budget is left for fullnode fallback, which could be overridden by configurations
+	defaultStoreTimeouts = map[string]time.Duration{
+		"cfx_getlogs": 3 * time.Second,
+		"eth_getlogs": 3 * time.Second,
+	}
+)
+
+// timeoutConfig per-method timeout configurations, which bound the overall handling time of
+// RPC call, including store queries and fullnode delegations.
+//
+// Note, method names are case insensitive since viper lower cases map keys.
+type timeoutConfig struct {
+	// timeout for methods not specified, 0 for unlimited
+	Default time.Duration
+	// method => timeout, 0 for unlimited
+	Methods map[string]time.Duration
+	// method => time budget of store queries within the method timeout, 0 for unlimited, which
+	// should be less than the method timeout to leave time budget for fullnode fallback
+	Store map[string]time.Duration
+}
+
+func (c *timeoutConfig) init() {
+	c.Methods = mergeTimeouts(defaultMethodTimeouts, c.Methods)
+	c.Store = mergeTimeouts(defaultStoreTimeouts, c.Store)
+
+	for method, storeTimeout := range c.Store {
+		if timeout := c.methodTimeout(method); timeout > 0 && (storeTimeout <= 0 || storeTimeout >= timeout) {
+			logrus.WithFields(logrus.Fields{
+				"method":       method,
+				"timeout":      timeout,
+				"storeTimeout": storeTimeout,
+			}).Warn("Store timeout not less than method timeout, no time budget left for fullnode fallback")
+		}
+	}
+}
+
+// mergeTimeouts returns the default timeouts overridden by the configured ones in lower case.
+func mergeTimeouts(defaults, configured map[string]time.Duration) map[string]time.Duration {
+	result := make(map[string]time.Duration, len(defaults)+len(configured))
+	for method, timeout := range defaults {
+		result[method] = timeout
+	}
+
+	for method, timeout := range configured {
+		result[strings.ToLower(method)] = timeout
+	}
+
+	return result
+}
+
+func (c *timeoutConfig) methodTimeout(method string) time.Duration {
+	if timeout, ok := c.Methods[strings.ToLower(method)]; ok {
+		return timeout
+	}
+
+	return c.Default
+}
+
+// MethodTimeout returns the timeout of the specified RPC method, or 0 if unlimited.
+func MethodTimeout(method string) time.Duration {
+	return timeoutCfg.methodTimeout(method)
+}
+
+// WithMethodTimeout returns a copy of the parent context bounded by the timeout of the specified
+// RPC method. Note, the deadline of parent context is kept if earlier, so that the remaining time
+// budget of RPC call is never extended.
+func WithMethodTimeout(parent context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout := MethodTimeout(method)
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
+
+// StoreTimeout returns the time budget of store queries for the specified RPC method, or 0 if
+// unlimited.
+func StoreTimeout(method string) time.Duration {
+	return timeoutCfg.Store[strings.ToLower(method)]
+}
+
+// WithStoreTimeout returns a copy of the parent context bounded by the time budget of store queries
+// for the specified RPC method, so that the remaining time budget of method is left for fullnode
+// fallback. Note, the deadline of parent context is kept if earlier.
+func WithStoreTimeout(parent context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout := StoreTimeout(method)
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodTimeout(t *testing.T) {
+	defer func(old timeoutConfig) { timeoutCfg = old }(timeoutCfg)
+
+	timeoutCfg = timeoutConfig{
+		Default: time.Second,
+		Methods: map[string]time.Duration{"trace_block": 10 * time.Second, "eth_getlogs": 0},
+	}
+	timeoutCfg.init()
+
+	assert.Equal(t, 10*time.Second, MethodTimeout("trace_block"))
+	assert.Equal(t, time.Duration(0), MethodTimeout("eth_getLogs")) // overrides default
+	assert.Equal(t, 6*time.Second, MethodTimeout("cfx_getLogs"))
+	assert.Equal(t, time.Second, MethodTimeout("eth_call"))
+
+	// unlimited
+	ctx, cancel := WithMethodTimeout(context.Background(), "eth_getLogs")
+	defer cancel()
+
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	// earlier deadline of parent context is kept
+	parent, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	ctx, cancel = WithMethodTimeout(parent, "trace_block")
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, time.Until(deadline) <= 100*time.Millisecond)
+}
+
+func TestStoreTimeout(t *testing.T) {
+	defer func(old timeoutConfig) { timeoutCfg = old }(timeoutCfg)
+
+	timeoutCfg = timeoutConfig{Store: map[string]time.Duration{"eth_getLogs": time.Second}}
+	timeoutCfg.init()
+
+	assert.Equal(t, time.Second, StoreTimeout("eth_getLogs"))
+	assert.Equal(t, 3*time.Second, StoreTimeout("cfx_getLogs"))
+	assert.Equal(t, time.Duration(0), StoreTimeout("eth_call"))
+
+	// time budget left for fullnode fallback by default
+	assert.Less(t, int64(StoreTimeout("cfx_getLogs")), int64(MethodTimeout("cfx_getLogs")))
+
+	// store context bounded within the RPC call context
+	parent, cancel := WithMethodTimeout(context.Background(), "eth_getLogs")
+	defer cancel()
+
+	ctx, cancel := WithStoreTimeout(parent, "eth_getLogs")
+	defer cancel()
+
+	storeDeadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+
+	deadline, _ := parent.Deadline()
+	assert.True(t, storeDeadline.Before(deadline))
+
+	<-ctx.Done()
+	assert.NoError(t, parent.Err())
+}
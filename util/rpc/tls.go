@@ -0,0 +1,253 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	tlsServerCfg tlsServerConfig
+
+	tlsServerOnce    sync.Once
+	tlsServerConf    *tls.Config
+	tlsServerConfErr error
+)
+
+// tlsServerConfig TLS termination configurations of RPC servers.
+type tlsServerConfig struct {
+	// whether to serve RPC over TLS
+	Enabled bool
+	// PEM encoded certificate and private key files
+	CertFile string
+	KeyFile  string
+	// PEM encoded CA certificates to verify client certificates if provided, empty to not require
+	ClientCAFile string
+	// interval to check the certificate files for changes and reload, 0 to disable
+	ReloadInterval time.Duration `default:"1m"`
+}
+
+// tlsClientConfig TLS configurations to dial upstream fullnodes, which is enabled if either client
+// certificate (mutual TLS) or CA certificates provided.
+type tlsClientConfig struct {
+	// PEM encoded client certificate and private key files for mutual TLS
+	CertFile string
+	KeyFile  string
+	// PEM encoded CA certificates to verify fullnode, empty to use the system CA pool
+	CAFile string
+	// server name to verify fullnode certificate, empty to use the host of URL
+	ServerName string
+	// whether to skip verification of fullnode certificate, only for testing purpose
+	InsecureSkipVerify bool
+}
+
+// Enabled returns whether custom TLS configured to dial upstream fullnodes.
+func (c *tlsClientConfig) Enabled() bool {
+	return len(c.CertFile) > 0 || len(c.CAFile) > 0 || c.InsecureSkipVerify
+}
+
+// Applicable returns whether custom TLS configured to dial the specified fullnode URL.
+func (c *tlsClientConfig) Applicable(rawurl string) bool {
+	if !c.Enabled() {
+		return false
+	}
+
+	u, err := url.Parse(rawurl)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "wss")
+}
+
+func (c *tlsClientConfig) build() (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if len(c.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load client certificate")
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(c.CAFile) > 0 {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		conf.RootCAs = pool
+	}
+
+	return conf, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read CA certificates")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no valid CA certificate found in %v", caFile)
+	}
+
+	return pool, nil
+}
+
+// newTLSProvider dials the fullnode with the specified TLS configurations, and wraps the provider
+// with timeout and retry as the default provider does.
+func newTLSProvider(
+	rawurl string,
+	conf *tlsClientConfig,
+	poolConf *clientPoolConfig,
+	option providers.Option,
+	wrapConn func(net.Conn) net.Conn,
+) (*providers.MiddlewarableProvider, error) {
+	tlsConf, err := conf.build()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid URL")
+	}
+
+	if u.Scheme != "https" && u.Scheme != "wss" {
+		return nil, errors.Errorf("TLS unsupported for URL scheme %v", u.Scheme)
+	}
+
+	return newPooledProvider(rawurl, tlsConf, poolConf, option, wrapConn)
+}
+
+// certReloader loads the certificate for TLS handshake, and reloads it once the certificate files
+// changed (eg., renewed by cert-manager or certbot).
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time // latest modification time of certificate files
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, errors.WithMessage(err, "failed to stat certificate file")
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load certificate")
+	}
+
+	r.cert, r.modTime, r.checkedAt = &cert, modTime, time.Now()
+
+	return nil
+}
+
+// GetCertificate implements the `tls.Config.GetCertificate` to reload the certificate on demand, in
+// which case the previous certificate is still used if failed to reload.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval <= 0 || time.Since(r.checkedAt) < r.interval {
+		return r.cert, nil
+	}
+
+	r.checkedAt = time.Now()
+
+	modTime, err := r.latestModTime()
+	if err != nil || !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+
+	logger := logrus.WithField("certFile", r.certFile)
+	if err := r.load(modTime); err != nil {
+		logger.WithError(err).Error("Failed to reload TLS certificate")
+	} else {
+		logger.Info("TLS certificate reloaded")
+	}
+
+	return r.cert, nil
+}
+
+// serverTLSConfig returns the TLS configurations shared by RPC servers, or nil if TLS disabled.
+func serverTLSConfig() (*tls.Config, error) {
+	if !tlsServerCfg.Enabled {
+		return nil, nil
+	}
+
+	tlsServerOnce.Do(func() {
+		tlsServerConf, tlsServerConfErr = newServerTLSConfig(tlsServerCfg)
+	})
+
+	return tlsServerConf, tlsServerConfErr
+}
+
+func newServerTLSConfig(conf tlsServerConfig) (*tls.Config, error) {
+	reloader, err := newCertReloader(conf.CertFile, conf.KeyFile, conf.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if len(conf.ClientCAFile) > 0 {
+		pool, err := loadCertPool(conf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConf, nil
+}
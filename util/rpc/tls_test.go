@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCert(t *testing.T, dir, cn string, modTime time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	assert.NoError(t, ioutil.WriteFile(certFile, certPem, 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, keyPem, 0600))
+	assert.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	assert.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	return certFile, keyFile
+}
+
+func certCommonName(t *testing.T, r *certReloader) string {
+	cert, err := r.GetCertificate(nil)
+	assert.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confura-tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	certFile, keyFile := writeTestCert(t, dir, "old", now.Add(-time.Minute))
+
+	r, err := newCertReloader(certFile, keyFile, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", certCommonName(t, r))
+
+	// certificate renewed
+	writeTestCert(t, dir, "new", now)
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, "new", certCommonName(t, r))
+
+	// previous certificate still used if failed to reload
+	assert.NoError(t, ioutil.WriteFile(keyFile, []byte("bad"), 0600))
+	assert.NoError(t, os.Chtimes(keyFile, now.Add(time.Minute), now.Add(time.Minute)))
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, "new", certCommonName(t, r))
+}
@@ -0,0 +1,295 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const ctxKeyWsConn = handlers.CtxKey("Infura-WS-Conn")
+
+var (
+	wsCfg wsConfig
+
+	// ErrTooManySubscriptions returned if max number of subscriptions per connection exceeded.
+	ErrTooManySubscriptions = NewCodedError(
+		ErrCodeLimitExceeded, errors.New("too many subscriptions on websocket connection"),
+	)
+)
+
+// wsConfig websocket connection lifecycle configurations, where 0 means unlimited or disabled.
+type wsConfig struct {
+	// max number of connections per client IP
+	MaxConnsPerIP int
+	// max number of connections per API key
+	MaxConnsPerKey int
+	// max number of subscriptions per connection
+	MaxSubsPerConn int
+	// duration to close the connection without any request or active subscription
+	IdleTimeout time.Duration
+	// duration to wait for pong after ping sent before closing the connection
+	PongTimeout time.Duration `default:"30s"`
+	// duration to evict slow consumer which fails to read the pending message in time
+	SlowConsumerTimeout time.Duration `default:"10s"`
+}
+
+// WsConn websocket connection state shared by RPC calls over the connection.
+type WsConn struct {
+	limiter  *wsLimiter
+	ip, key  string
+	netConn  net.Conn // hijacked underlying connection
+	closedCh chan struct{}
+
+	mu         sync.Mutex
+	subs       int       // number of active subscriptions
+	lastActive time.Time // last time of request
+	evicted    string    // reason to evict the connection if any
+}
+
+// WsConnFromContext returns the websocket connection state of RPC call, or false if not served
+// over websocket.
+func WsConnFromContext(ctx context.Context) (*WsConn, bool) {
+	conn, ok := ctx.Value(ctxKeyWsConn).(*WsConn)
+	return conn, ok
+}
+
+// Touch marks the connection active upon request.
+func (c *WsConn) Touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastActive = time.Now()
+}
+
+// AcquireSubscription reserves a subscription slot of the connection, or returns false if max number
+// of subscriptions exceeded.
+func (c *WsConn) AcquireSubscription() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limiter.conf.MaxSubsPerConn > 0 && c.subs >= c.limiter.conf.MaxSubsPerConn {
+		return false
+	}
+
+	c.subs++
+	return true
+}
+
+// ReleaseSubscription releases a subscription slot of the connection once unsubscribed.
+func (c *WsConn) ReleaseSubscription() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.subs > 0 {
+		c.subs--
+	}
+}
+
+// evict closes the underlying connection due to the specified reason.
+func (c *WsConn) evict(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.evicted) > 0 || c.netConn == nil {
+		return
+	}
+
+	c.evicted = reason
+	c.netConn.Close()
+
+	metrics.Registry.RPC.WsEvicted(c.limiter.server, reason).Mark(1)
+	logrus.WithFields(logrus.Fields{
+		"server": c.limiter.server,
+		"ip":     c.ip,
+		"reason": reason,
+	}).Debug("Websocket connection evicted")
+}
+
+// idleLoop evicts the connection if idle for too long, namely without any request or active
+// subscription.
+func (c *WsConn) idleLoop() {
+	timeout := c.limiter.conf.IdleTimeout
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closedCh:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			idle := c.subs == 0 && time.Since(c.lastActive) > timeout
+			c.mu.Unlock()
+
+			if idle {
+				c.evict("idle")
+				return
+			}
+		}
+	}
+}
+
+// wsLimiter enforces websocket connection lifecycle controls.
+type wsLimiter struct {
+	server string
+	conf   wsConfig
+
+	mu         sync.Mutex
+	ipConns    map[string]int
+	keyConns   map[string]int
+	totalConns int64
+}
+
+func newWsLimiter(server string, conf wsConfig) *wsLimiter {
+	return &wsLimiter{
+		server:   server,
+		conf:     conf,
+		ipConns:  make(map[string]int),
+		keyConns: make(map[string]int),
+	}
+}
+
+// acquire reserves connection slots of client IP and API key, or returns the rejected reason.
+func (l *wsLimiter) acquire(ip, key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conf.MaxConnsPerIP > 0 && len(ip) > 0 && l.ipConns[ip] >= l.conf.MaxConnsPerIP {
+		return "ip", false
+	}
+
+	if l.conf.MaxConnsPerKey > 0 && len(key) > 0 && l.keyConns[key] >= l.conf.MaxConnsPerKey {
+		return "key", false
+	}
+
+	l.ipConns[ip]++
+	l.keyConns[key]++
+	l.totalConns++
+
+	metrics.Registry.RPC.WsConns(l.server).Update(l.totalConns)
+
+	return "", true
+}
+
+func (l *wsLimiter) release(ip, key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.ipConns[ip]--; l.ipConns[ip] <= 0 {
+		delete(l.ipConns, ip)
+	}
+
+	if l.keyConns[key]--; l.keyConns[key] <= 0 {
+		delete(l.keyConns, key)
+	}
+
+	l.totalConns--
+
+	metrics.Registry.RPC.WsConns(l.server).Update(l.totalConns)
+}
+
+// Handler wraps the websocket handler to enforce connection limits, and injects connection state
+// into request context for RPC calls.
+func (l *wsLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _ := handlers.GetIPAddressFromContext(r.Context())
+		key, _ := r.Context().Value(handlers.CtxKeyAccessToken).(string)
+
+		if reason, ok := l.acquire(ip, key); !ok {
+			metrics.Registry.RPC.WsRejected(l.server, reason).Mark(1)
+			http.Error(w, "too many websocket connections", http.StatusTooManyRequests)
+			return
+		}
+		defer l.release(ip, key)
+
+		conn := &WsConn{
+			limiter:    l,
+			ip:         ip,
+			key:        key,
+			closedCh:   make(chan struct{}),
+			lastActive: time.Now(),
+		}
+		defer close(conn.closedCh)
+
+		if l.conf.IdleTimeout > 0 {
+			go conn.idleLoop()
+		}
+
+		ctx := context.WithValue(r.Context(), ctxKeyWsConn, conn)
+
+		// served until connection closed
+		next.ServeHTTP(&wsResponseWriter{ResponseWriter: w, conn: conn}, r.WithContext(ctx))
+	})
+}
+
+// wsResponseWriter hijacks the underlying connection for websocket upgrade.
+type wsResponseWriter struct {
+	http.ResponseWriter
+	conn *WsConn
+}
+
+func (w *wsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped := &wsNetConn{Conn: netConn, conn: w.conn}
+
+	w.conn.mu.Lock()
+	w.conn.netConn = wrapped
+	w.conn.mu.Unlock()
+
+	return wrapped, brw, nil
+}
+
+// wsNetConn underlying websocket connection that bounds the deadlines of pong and message writing.
+type wsNetConn struct {
+	net.Conn
+	conn *WsConn
+}
+
+// SetReadDeadline bounds the deadline to wait for pong, which is only set once ping sent.
+func (c *wsNetConn) SetReadDeadline(t time.Time) error {
+	if !t.IsZero() && c.conn.limiter.conf.PongTimeout > 0 {
+		t = time.Now().Add(c.conn.limiter.conf.PongTimeout)
+	}
+
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline bounds the deadline to write message, so as to evict slow consumer in time.
+func (c *wsNetConn) SetWriteDeadline(t time.Time) error {
+	if timeout := c.conn.limiter.conf.SlowConsumerTimeout; timeout > 0 {
+		if deadline := time.Now().Add(timeout); t.IsZero() || t.After(deadline) {
+			t = deadline
+		}
+	}
+
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *wsNetConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		c.conn.evict("slow")
+	}
+
+	return n, err
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWsLimiterConns(t *testing.T) {
+	limiter := newWsLimiter("test", wsConfig{MaxConnsPerIP: 2, MaxConnsPerKey: 1})
+
+	_, ok := limiter.acquire("127.0.0.1", "key1")
+	assert.True(t, ok)
+
+	// max conns per key exceeded
+	reason, ok := limiter.acquire("127.0.0.1", "key1")
+	assert.False(t, ok)
+	assert.Equal(t, "key", reason)
+
+	_, ok = limiter.acquire("127.0.0.1", "")
+	assert.True(t, ok)
+
+	// max conns per IP exceeded
+	reason, ok = limiter.acquire("127.0.0.1", "key2")
+	assert.False(t, ok)
+	assert.Equal(t, "ip", reason)
+
+	limiter.release("127.0.0.1", "key1")
+
+	_, ok = limiter.acquire("127.0.0.1", "key1")
+	assert.True(t, ok)
+}
+
+func TestWsConnSubscriptions(t *testing.T) {
+	conn := &WsConn{limiter: newWsLimiter("test", wsConfig{MaxSubsPerConn: 1})}
+
+	assert.True(t, conn.AcquireSubscription())
+	assert.False(t, conn.AcquireSubscription())
+
+	conn.ReleaseSubscription()
+	assert.True(t, conn.AcquireSubscription())
+}
@@ -0,0 +1,231 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// built-in job to prune the expired run history
+	JobNamePruneHistory = "scheduler.history.prune"
+)
+
+// job scheduler configurations
+type Config struct {
+	// whether to enable job scheduler, otherwise jobs are run by ad-hoc tickers
+	Enabled bool
+	// interval to poll the due jobs from store
+	PollInterval time.Duration `default:"10s"`
+	// duration to keep the job run history
+	HistoryRetention time.Duration `default:"168h"`
+}
+
+// Store persists the scheduled jobs along with run history.
+type Store interface {
+	RegisterJob(name string, interval time.Duration) error
+	LoadJobs() ([]*mysql.ScheduledJob, error)
+	ClaimJob(name string, now, nextRunAt time.Time) (bool, error)
+	AddJobRun(run *mysql.JobRun) error
+	FinishJobRun(run *mysql.JobRun) error
+	PruneJobRuns(before time.Time) (int64, error)
+}
+
+// JobFunc runs the job once.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Scheduler embedded cron-like job scheduler for operational tasks (eg., pruning), which are
+// persisted in store with run history, so that they could be listed, triggered or paused by admin.
+//
+// Note, jobs could be scheduled by multiple instances, but each run is claimed by only one instance.
+type Scheduler struct {
+	conf     Config
+	store    Store
+	instance string
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	running map[string]bool
+}
+
+// MustNewSchedulerFromViper creates job scheduler from viper settings, or returns nil if disabled.
+func MustNewSchedulerFromViper(store Store) *Scheduler {
+	var conf Config
+	viper.MustUnmarshalKey("scheduler", &conf)
+
+	if !conf.Enabled || store == nil {
+		return nil
+	}
+
+	return NewScheduler(conf, store)
+}
+
+func NewScheduler(conf Config, store Store) *Scheduler {
+	instance, _ := os.Hostname()
+
+	s := &Scheduler{
+		conf:     conf,
+		store:    store,
+		instance: instance,
+		jobs:     make(map[string]*job),
+		running:  make(map[string]bool),
+	}
+
+	s.Register(JobNamePruneHistory, time.Hour, s.pruneHistory)
+
+	return s
+}
+
+// Register registers job to run periodically, which must be called before `Run`.
+func (s *Scheduler) Register(name string, interval time.Duration, run JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[name] = &job{name: name, interval: interval, run: run}
+}
+
+// Run persists the registered jobs and runs the due jobs until context done.
+func (s *Scheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if err := s.store.RegisterJob(j.name, j.interval); err != nil {
+			logrus.WithField("job", j.name).WithError(err).Fatal("Failed to register scheduled job")
+		}
+	}
+	s.mu.Unlock()
+
+	logrus.WithField("instance", s.instance).Info("Job scheduler started")
+
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Job scheduler shutdown ok")
+			return
+		case <-ticker.C:
+			if err := s.poll(ctx, wg); err != nil {
+				logrus.WithError(err).Error("Job scheduler failed to poll due jobs")
+			}
+		}
+	}
+}
+
+// poll claims the due jobs from store and runs them asynchronously.
+func (s *Scheduler) poll(ctx context.Context, wg *sync.WaitGroup) error {
+	jobs, err := s.store.LoadJobs()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load jobs")
+	}
+
+	now := time.Now()
+
+	for _, sj := range jobs {
+		if sj.Paused || sj.NextRunAt.After(now) {
+			continue
+		}
+
+		s.mu.Lock()
+		j, ok := s.jobs[sj.Name]
+		busy := s.running[sj.Name]
+		s.mu.Unlock()
+
+		// job not registered by this instance or still running
+		if !ok || busy {
+			continue
+		}
+
+		claimed, err := s.store.ClaimJob(j.name, now, now.Add(j.interval))
+		if err != nil {
+			return errors.WithMessagef(err, "failed to claim job %v", j.name)
+		}
+
+		if claimed {
+			s.mu.Lock()
+			s.running[j.name] = true
+			s.mu.Unlock()
+
+			wg.Add(1)
+			go s.execute(ctx, wg, j)
+		}
+	}
+
+	return nil
+}
+
+// execute runs the job and records the run history.
+func (s *Scheduler) execute(ctx context.Context, wg *sync.WaitGroup, j *job) {
+	defer wg.Done()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, j.name)
+		s.mu.Unlock()
+	}()
+
+	logger := logrus.WithField("job", j.name)
+
+	run := &mysql.JobRun{
+		JobName:   j.name,
+		Instance:  s.instance,
+		Status:    mysql.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+
+	if err := s.store.AddJobRun(run); err != nil {
+		logger.WithError(err).Error("Job scheduler failed to add job run history")
+	}
+
+	err := j.run(ctx)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = mysql.JobRunStatusSucceeded
+
+	if err != nil {
+		run.Status = mysql.JobRunStatusFailed
+		run.Error = err.Error()
+
+		logger.WithError(err).Error("Scheduled job failed to run")
+	} else {
+		logger.WithField("elapsed", finishedAt.Sub(run.StartedAt)).Debug("Scheduled job run")
+	}
+
+	if run.ID == 0 { // run history not added
+		return
+	}
+
+	if err := s.store.FinishJobRun(run); err != nil {
+		logger.WithError(err).Error("Job scheduler failed to update job run history")
+	}
+}
+
+// pruneHistory prunes the expired job run history.
+func (s *Scheduler) pruneHistory(ctx context.Context) error {
+	pruned, err := s.store.PruneJobRuns(time.Now().Add(-s.conf.HistoryRetention))
+	if err != nil {
+		return err
+	}
+
+	if pruned > 0 {
+		logrus.WithField("pruned", pruned).Info("Job scheduler pruned expired run history")
+	}
+
+	return nil
+}
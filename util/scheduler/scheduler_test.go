@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStore struct {
+	mu   sync.Mutex
+	jobs map[string]*mysql.ScheduledJob
+	runs []*mysql.JobRun
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{jobs: make(map[string]*mysql.ScheduledJob)}
+}
+
+func (s *mockStore) RegisterJob(name string, interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[name] = &mysql.ScheduledJob{Name: name, Interval: interval, NextRunAt: time.Now()}
+	return nil
+}
+
+func (s *mockStore) LoadJobs() (res []*mysql.ScheduledJob, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		jobCopy := *job
+		res = append(res, &jobCopy)
+	}
+
+	return res, nil
+}
+
+func (s *mockStore) ClaimJob(name string, now, nextRunAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.jobs[name]
+	if job.Paused || job.NextRunAt.After(now) {
+		return false, nil
+	}
+
+	job.NextRunAt = nextRunAt
+	return true, nil
+}
+
+func (s *mockStore) AddJobRun(run *mysql.JobRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = append(s.runs, run)
+	run.ID = uint64(len(s.runs))
+
+	return nil
+}
+
+func (s *mockStore) FinishJobRun(run *mysql.JobRun) error {
+	return nil
+}
+
+func (s *mockStore) PruneJobRuns(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestSchedulerPoll(t *testing.T) {
+	store := newMockStore()
+	scheduler := NewScheduler(Config{}, store)
+
+	scheduler.Register("ok", time.Hour, func(ctx context.Context) error { return nil })
+	scheduler.Register("failed", time.Hour, func(ctx context.Context) error { return errors.New("boom") })
+
+	for name, job := range scheduler.jobs {
+		assert.NoError(t, store.RegisterJob(name, job.interval))
+	}
+
+	store.jobs["failed"].Paused = true
+
+	var wg sync.WaitGroup
+	assert.NoError(t, scheduler.poll(context.Background(), &wg))
+	wg.Wait()
+
+	// paused job not run
+	assert.Equal(t, 2, len(store.runs))
+
+	status := make(map[string]string)
+	for _, run := range store.runs {
+		status[run.JobName] = run.Status
+		assert.NotNil(t, run.FinishedAt)
+	}
+
+	assert.Equal(t, mysql.JobRunStatusSucceeded, status["ok"])
+	assert.Equal(t, mysql.JobRunStatusSucceeded, status[JobNamePruneHistory])
+
+	// not due until the next interval
+	assert.NoError(t, scheduler.poll(context.Background(), &wg))
+	wg.Wait()
+	assert.Equal(t, 2, len(store.runs))
+
+	// resumed
+	store.jobs["failed"].Paused = false
+	assert.NoError(t, scheduler.poll(context.Background(), &wg))
+	wg.Wait()
+
+	assert.Equal(t, 3, len(store.runs))
+	assert.Equal(t, mysql.JobRunStatusFailed, store.runs[2].Status)
+	assert.Equal(t, "boom", store.runs[2].Error)
+}
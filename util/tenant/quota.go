@@ -0,0 +1,310 @@
+package tenant
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// method to account requests rejected due to quota exceeded, which costs nothing
+	MethodQuotaExceeded = "quota_exceeded"
+
+	monthLayout = "2006-01"
+
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// QuotaLimit compute unit quotas of tenant, 0 for unlimited.
+type QuotaLimit struct {
+	Daily   uint64
+	Monthly uint64
+}
+
+// QuotaConfig compute unit quota configurations, which are enforced per tenant.
+//
+// Note, map keys (method and tenant names) are case insensitive since viper lower cases them.
+type QuotaConfig struct {
+	// whether to enforce compute unit quotas
+	Enabled bool
+	// default quotas of all tenants
+	QuotaLimit `mapstructure:",squash"`
+	// compute units of method not configured in weights
+	DefaultWeight uint64 `default:"1"`
+	// method => compute units per request
+	Weights map[string]uint64
+	// tenant => quotas to override the default ones
+	Tenants map[string]QuotaLimit
+}
+
+func (conf *QuotaConfig) normalize() {
+	weights := make(map[string]uint64, len(conf.Weights))
+	for method, weight := range conf.Weights {
+		weights[strings.ToLower(method)] = weight
+	}
+
+	tenants := make(map[string]QuotaLimit, len(conf.Tenants))
+	for tenant, limit := range conf.Tenants {
+		tenants[strings.ToLower(tenant)] = limit
+	}
+
+	conf.Weights, conf.Tenants = weights, tenants
+}
+
+// weight returns the compute units per request of the method.
+func (conf *QuotaConfig) weight(method string) uint64 {
+	if method == MethodQuotaExceeded {
+		return 0
+	}
+
+	if weight, ok := conf.Weights[strings.ToLower(method)]; ok {
+		return weight
+	}
+
+	return conf.DefaultWeight
+}
+
+// limit returns the compute unit quotas of tenant.
+func (conf *QuotaConfig) limit(tenant string) QuotaLimit {
+	if limit, ok := conf.Tenants[strings.ToLower(tenant)]; ok {
+		return limit
+	}
+
+	return conf.QuotaLimit
+}
+
+// QuotaPeriod compute unit quota of tenant within a period.
+type QuotaPeriod struct {
+	Limit     uint64    `json:"limit"`
+	Used      uint64    `json:"used"`
+	Remaining uint64    `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// Exceeded returns true if quota used up.
+func (p *QuotaPeriod) Exceeded() bool {
+	return p != nil && p.Used >= p.Limit
+}
+
+func newQuotaPeriod(limit, used uint64, resetAt time.Time) *QuotaPeriod {
+	if limit == 0 { // unlimited
+		return nil
+	}
+
+	period := QuotaPeriod{Limit: limit, Used: used, ResetAt: resetAt}
+	if used < limit {
+		period.Remaining = limit - used
+	}
+
+	return &period
+}
+
+// QuotaStatus compute unit quota status of tenant, where nil period means unlimited.
+type QuotaStatus struct {
+	Tenant  string       `json:"tenant"`
+	Daily   *QuotaPeriod `json:"daily,omitempty"`
+	Monthly *QuotaPeriod `json:"monthly,omitempty"`
+}
+
+// Exceeded returns the exhausted quota period if any.
+func (s *QuotaStatus) Exceeded() (string, *QuotaPeriod, bool) {
+	if s.Daily.Exceeded() {
+		return QuotaPeriodDaily, s.Daily, true
+	}
+
+	if s.Monthly.Exceeded() {
+		return QuotaPeriodMonthly, s.Monthly, true
+	}
+
+	return "", nil, false
+}
+
+// QuotaExceededError returned when compute unit quota of tenant used up.
+type QuotaExceededError struct {
+	Tenant string
+	Period string
+	*QuotaPeriod
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf(
+		"%v compute unit quota exceeded (%v/%v), reset at %v",
+		e.Period, e.Used, e.Limit, e.ResetAt.Format(time.RFC3339),
+	)
+}
+
+// quotaUsage consumed compute units of tenant within the current day and month, which consists of
+// the ones synchronized from store and the ones recorded locally since then.
+type quotaUsage struct {
+	day, month string
+
+	dailyBase, monthlyBase   uint64
+	dailyDelta, monthlyDelta uint64
+}
+
+// rollover resets the usage once day or month changed.
+func (u *quotaUsage) rollover(now time.Time) {
+	if day := now.Format(DateLayout); u.day != day {
+		u.day, u.dailyBase, u.dailyDelta = day, 0, 0
+	}
+
+	if month := now.Format(monthLayout); u.month != month {
+		u.month, u.monthlyBase, u.monthlyDelta = month, 0, 0
+	}
+}
+
+func (u *quotaUsage) daily() uint64   { return u.dailyBase + u.dailyDelta }
+func (u *quotaUsage) monthly() uint64 { return u.monthlyBase + u.monthlyDelta }
+
+// nextDay returns the start of next day in UTC.
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// nextMonth returns the start of next month in UTC.
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Quota returns the compute unit quota status of tenant, or false if quota not enabled.
+func (r *Registry) Quota(tenant string) (*QuotaStatus, bool) {
+	if !r.conf.Quota.Enabled {
+		return nil, false
+	}
+
+	now := time.Now().UTC()
+	limit := r.conf.Quota.limit(tenant)
+
+	r.mu.Lock()
+	usage := r.quotaUsage(tenant, now)
+	daily, monthly := usage.daily(), usage.monthly()
+	r.mu.Unlock()
+
+	return &QuotaStatus{
+		Tenant:  tenant,
+		Daily:   newQuotaPeriod(limit.Daily, daily, nextDay(now)),
+		Monthly: newQuotaPeriod(limit.Monthly, monthly, nextMonth(now)),
+	}, true
+}
+
+// CheckQuota returns `QuotaExceededError` if compute unit quota of tenant used up.
+func (r *Registry) CheckQuota(tenant string) (*QuotaStatus, error) {
+	status, ok := r.Quota(tenant)
+	if !ok {
+		return nil, nil
+	}
+
+	if period, quota, ok := status.Exceeded(); ok {
+		return status, &QuotaExceededError{Tenant: tenant, Period: period, QuotaPeriod: quota}
+	}
+
+	return status, nil
+}
+
+// quotaUsage returns the rolled over quota usage of tenant, which requires lock held.
+func (r *Registry) quotaUsage(tenant string, now time.Time) *quotaUsage {
+	usage, ok := r.quotas[tenant]
+	if !ok {
+		usage = &quotaUsage{}
+		r.quotas[tenant] = usage
+	}
+
+	usage.rollover(now)
+
+	return usage
+}
+
+// consumeQuota consumes the compute units of tenant for the method, which requires lock held.
+func (r *Registry) consumeQuota(tenant, method string, now time.Time) {
+	if !r.conf.Quota.Enabled {
+		return
+	}
+
+	weight := r.conf.Quota.weight(method)
+	if weight == 0 {
+		return
+	}
+
+	usage := r.quotaUsage(tenant, now)
+	usage.dailyDelta += weight
+	usage.monthlyDelta += weight
+}
+
+// snapshotQuotas returns a copy of the locally recorded quota usages, which requires lock held.
+func (r *Registry) snapshotQuotas() map[string]quotaUsage {
+	if !r.conf.Quota.Enabled {
+		return nil
+	}
+
+	snapshot := make(map[string]quotaUsage, len(r.quotas))
+	for tenant, usage := range r.quotas {
+		snapshot[tenant] = *usage
+	}
+
+	return snapshot
+}
+
+// syncQuotas loads the compute units consumed by all RPC instances from store, and deducts the
+// flushed local usages in snapshot.
+func (r *Registry) syncQuotas(snapshot map[string]quotaUsage) error {
+	now := time.Now().UTC()
+	day := now.Format(DateLayout)
+	monthStart := now.Format(monthLayout) + "-01"
+
+	dailyCUs, err := r.loadComputeUnits(day, day)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load daily usages")
+	}
+
+	monthlyCUs, err := r.loadComputeUnits(monthStart, day)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load monthly usages")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tenant := range monthlyCUs {
+		r.quotaUsage(tenant, now)
+	}
+
+	for tenant, usage := range r.quotas {
+		usage.rollover(now)
+
+		flushed, ok := snapshot[tenant]
+
+		if usage.day == day {
+			usage.dailyBase = dailyCUs[tenant]
+			if ok && flushed.day == usage.day {
+				usage.dailyDelta -= flushed.dailyDelta
+			}
+		}
+
+		usage.monthlyBase = monthlyCUs[tenant]
+		if ok && flushed.month == usage.month {
+			usage.monthlyDelta -= flushed.monthlyDelta
+		}
+	}
+
+	return nil
+}
+
+// loadComputeUnits sums up the compute units of all tenants within the date range from store.
+func (r *Registry) loadComputeUnits(fromDate, toDate string) (map[string]uint64, error) {
+	usages, err := r.store.SumUsages(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]uint64)
+	for _, u := range usages {
+		result[u.Tenant] += u.Requests * r.conf.Quota.weight(u.Method)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,233 @@
+// Package tenant tags RPC requests with the tenant of API key, and accounts the per-method usage
+// of each tenant, which is the foundation for quotas and billing.
+package tenant
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiKeyCacheSize = 5000
+
+	// usage date layout in UTC
+	DateLayout = "2006-01-02"
+)
+
+var (
+	// ErrApiKeyRevoked returned when request with revoked API key.
+	ErrApiKeyRevoked = errors.New("API key revoked")
+
+	// ErrApiKeyUnverified returned when failed to look up API key, eg., store unavailable.
+	ErrApiKeyUnverified = errors.New("failed to verify API key, please try again later")
+)
+
+type ctxKey struct{}
+
+// ApiKey API key issued to tenant.
+type ApiKey struct {
+	Key     string
+	Tenant  string
+	Revoked bool
+}
+
+// Usage accounted requests of some tenant and method within a day.
+type Usage struct {
+	Tenant   string
+	Method   string
+	Date     string // in UTC, eg., "2006-01-02"
+	Requests uint64
+	Errors   uint64
+}
+
+// Store persists API keys and usages.
+type Store interface {
+	// GetApiKey returns the API key, or false if not found.
+	GetApiKey(key string) (*ApiKey, bool, error)
+	// AddUsages accumulates the usages.
+	AddUsages(usages []*Usage) error
+	// SumUsages returns the usages summed up per tenant and method within the date range (inclusive).
+	SumUsages(fromDate, toDate string) ([]*Usage, error)
+}
+
+// Config API key subsystem configurations
+type Config struct {
+	// whether to tag requests with tenant by API key
+	Enabled bool
+	// interval to flush the accounted usages into store
+	FlushInterval time.Duration `default:"10s"`
+	// expiration of cached API keys, so revoked keys take effect within the duration
+	CacheTTL time.Duration `default:"1m"`
+	// compute unit quotas enforced per tenant
+	Quota QuotaConfig
+}
+
+type usageKey struct {
+	tenant, method, date string
+}
+
+// Registry looks up tenant by API key, and accounts the usages in memory before flushing into store
+// periodically.
+type Registry struct {
+	conf  Config
+	store Store
+
+	keyCache *util.ExpirableLruCache // key => *ApiKey (nil if not found)
+
+	mu     sync.Mutex
+	usages map[usageKey]*Usage
+	quotas map[string]*quotaUsage // tenant => consumed compute units
+}
+
+// MustNewRegistryFromViper creates tenant registry from viper settings of the specified key,
+// or returns false if not enabled.
+func MustNewRegistryFromViper(key string, store Store) (*Registry, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	return NewRegistry(conf, store), true
+}
+
+func NewRegistry(conf Config, store Store) *Registry {
+	conf.Quota.normalize()
+
+	return &Registry{
+		conf:     conf,
+		store:    store,
+		keyCache: util.NewExpirableLruCache(apiKeyCacheSize, conf.CacheTTL),
+		usages:   make(map[usageKey]*Usage),
+		quotas:   make(map[string]*quotaUsage),
+	}
+}
+
+// Lookup returns the API key from cache or store, or false if not found. Error is returned if failed
+// to look up from store, in which case the request shall be rejected rather than regarded as without
+// API key.
+func (r *Registry) Lookup(key string) (*ApiKey, bool, error) {
+	if v, ok := r.keyCache.Get(key); ok {
+		apiKey := v.(*ApiKey)
+		return apiKey, apiKey != nil, nil
+	}
+
+	apiKey, ok, err := r.store.GetApiKey(key)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get API key from store")
+		return nil, false, errors.WithMessage(err, "failed to get API key from store")
+	}
+
+	if !ok {
+		apiKey = nil
+	}
+
+	// cache nil for the missing key as well to mitigate store pressure
+	r.keyCache.Add(key, apiKey)
+
+	return apiKey, ok, nil
+}
+
+// Record accounts the request of tenant for the specified method, and consumes the compute units
+// if quota enabled.
+func (r *Registry) Record(tenant, method string, failed bool) {
+	now := time.Now().UTC()
+	key := usageKey{tenant, method, now.Format(DateLayout)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, ok := r.usages[key]
+	if !ok {
+		usage = &Usage{Tenant: key.tenant, Method: key.method, Date: key.date}
+		r.usages[key] = usage
+	}
+
+	usage.Requests++
+	if failed {
+		usage.Errors++
+	}
+
+	r.consumeQuota(tenant, method, now)
+}
+
+// Run flushes the accounted usages into store periodically until context canceled, along with the
+// compute units consumed by all RPC instances synchronized from store if quota enabled.
+func (r *Registry) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(r.conf.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.flush()
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+func (r *Registry) flush() {
+	r.mu.Lock()
+	usages := make([]*Usage, 0, len(r.usages))
+	for _, usage := range r.usages {
+		usages = append(usages, usage)
+	}
+	r.usages = make(map[usageKey]*Usage)
+	quotas := r.snapshotQuotas()
+	r.mu.Unlock()
+
+	if len(usages) > 0 {
+		if err := r.store.AddUsages(usages); err != nil {
+			logrus.WithError(err).WithField("usages", len(usages)).Error("Failed to flush tenant usages into store")
+
+			// merge back to retry next time
+			r.mu.Lock()
+			for _, usage := range usages {
+				r.merge(usage)
+			}
+			r.mu.Unlock()
+
+			return
+		}
+	}
+
+	if quotas != nil {
+		if err := r.syncQuotas(quotas); err != nil {
+			logrus.WithError(err).Error("Failed to sync tenant quotas from store")
+		}
+	}
+}
+
+func (r *Registry) merge(usage *Usage) {
+	key := usageKey{usage.Tenant, usage.Method, usage.Date}
+
+	if v, ok := r.usages[key]; ok {
+		v.Requests += usage.Requests
+		v.Errors += usage.Errors
+	} else {
+		r.usages[key] = usage
+	}
+}
+
+// NewContext returns a new context tagged with the tenant.
+func NewContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, tenant)
+}
+
+// FromContext returns the tenant tagged in context if any.
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(ctxKey{}).(string)
+	return tenant, ok
+}
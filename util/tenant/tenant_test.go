@@ -0,0 +1,185 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStore struct {
+	keys         map[string]*ApiKey
+	lookups      int
+	lookupFailed bool
+	failed       bool
+	usages       []*Usage
+}
+
+func (s *mockStore) GetApiKey(key string) (*ApiKey, bool, error) {
+	s.lookups++
+	if s.lookupFailed {
+		return nil, false, errors.New("store unavailable")
+	}
+
+	apiKey, ok := s.keys[key]
+	return apiKey, ok, nil
+}
+
+func (s *mockStore) AddUsages(usages []*Usage) error {
+	if s.failed {
+		return errors.New("store unavailable")
+	}
+
+	s.usages = append(s.usages, usages...)
+	return nil
+}
+
+func (s *mockStore) SumUsages(fromDate, toDate string) ([]*Usage, error) {
+	sums := make(map[[2]string]*Usage)
+	for _, u := range s.usages {
+		if u.Date < fromDate || u.Date > toDate {
+			continue
+		}
+
+		key := [2]string{u.Tenant, u.Method}
+		if v, ok := sums[key]; ok {
+			v.Requests += u.Requests
+			v.Errors += u.Errors
+		} else {
+			sums[key] = &Usage{Tenant: u.Tenant, Method: u.Method, Requests: u.Requests, Errors: u.Errors}
+		}
+	}
+
+	var result []*Usage
+	for _, v := range sums {
+		result = append(result, v)
+	}
+
+	return result, nil
+}
+
+func TestRegistryLookup(t *testing.T) {
+	store := &mockStore{keys: map[string]*ApiKey{
+		"k1": {Key: "k1", Tenant: "alice"},
+	}}
+	registry := NewRegistry(Config{FlushInterval: time.Second, CacheTTL: time.Minute}, store)
+
+	apiKey, ok, err := registry.Lookup("k1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", apiKey.Tenant)
+
+	_, ok, err = registry.Lookup("unknown")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// both hit cache
+	registry.Lookup("k1")
+	registry.Lookup("unknown")
+	assert.Equal(t, 2, store.lookups)
+
+	// store failure not cached
+	store.lookupFailed = true
+	_, _, err = registry.Lookup("k2")
+	assert.Error(t, err)
+
+	store.lookupFailed = false
+	store.keys["k2"] = &ApiKey{Key: "k2", Tenant: "bob"}
+	apiKey, ok, err = registry.Lookup("k2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "bob", apiKey.Tenant)
+}
+
+func TestRegistryFlush(t *testing.T) {
+	store := &mockStore{failed: true}
+	registry := NewRegistry(Config{FlushInterval: time.Hour, CacheTTL: time.Minute}, store)
+
+	registry.Record("alice", "eth_call", false)
+	registry.Record("alice", "eth_call", true)
+	registry.Record("bob", "eth_getLogs", false)
+
+	// merged back on failure
+	registry.flush()
+	registry.Record("alice", "eth_call", false)
+	assert.Empty(t, store.usages)
+
+	// flushed on exit
+	store.failed = false
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	registry.Run(ctx, &wg)
+	wg.Wait()
+
+	assert.Len(t, store.usages, 2)
+	for _, usage := range store.usages {
+		switch usage.Tenant {
+		case "alice":
+			assert.Equal(t, "eth_call", usage.Method)
+			assert.Equal(t, uint64(3), usage.Requests)
+			assert.Equal(t, uint64(1), usage.Errors)
+		case "bob":
+			assert.Equal(t, uint64(1), usage.Requests)
+			assert.Equal(t, uint64(0), usage.Errors)
+		}
+	}
+}
+
+func TestRegistryQuota(t *testing.T) {
+	store := &mockStore{}
+	registry := NewRegistry(Config{
+		FlushInterval: time.Hour,
+		CacheTTL:      time.Minute,
+		Quota: QuotaConfig{
+			Enabled:       true,
+			QuotaLimit:    QuotaLimit{Daily: 10},
+			DefaultWeight: 1,
+			Weights:       map[string]uint64{"eth_getlogs": 5},
+			Tenants:       map[string]QuotaLimit{"vip": {Daily: 100, Monthly: 1000}},
+		},
+	}, store)
+
+	// compute units consumed by other RPC instances
+	today := time.Now().UTC().Format(DateLayout)
+	store.usages = append(store.usages, &Usage{Tenant: "alice", Method: "eth_call", Date: today, Requests: 2})
+
+	registry.Record("alice", "eth_getLogs", false)
+	registry.Record("alice", "eth_call", true)
+	registry.Record("vip", "eth_getLogs", false)
+
+	status, err := registry.CheckQuota("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), status.Daily.Used)
+	assert.Nil(t, status.Monthly)
+
+	// flushed and synchronized without double counting
+	registry.flush()
+
+	status, err = registry.CheckQuota("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), status.Daily.Used)
+	assert.Equal(t, uint64(2), status.Daily.Remaining)
+
+	registry.Record("alice", "eth_call", false)
+	registry.Record("alice", "eth_call", false)
+
+	_, err = registry.CheckQuota("alice")
+	assert.IsType(t, &QuotaExceededError{}, err)
+
+	// rejected requests cost nothing
+	registry.Record("alice", MethodQuotaExceeded, true)
+
+	status, err = registry.CheckQuota("vip")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), status.Daily.Used)
+	assert.Equal(t, uint64(5), status.Monthly.Used)
+	assert.Equal(t, uint64(995), status.Monthly.Remaining)
+
+	status, _ = registry.CheckQuota("alice")
+	assert.Equal(t, uint64(10), status.Daily.Used)
+}
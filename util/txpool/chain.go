@@ -0,0 +1,116 @@
+package txpool
+
+import (
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+)
+
+const (
+	// route key to get fullnode client for transaction tracking
+	trackerRouteKey = "txtracker"
+)
+
+// CfxChain queries transaction status from core space fullnodes.
+type CfxChain struct {
+	provider *node.CfxClientProvider
+}
+
+func NewCfxChain(provider *node.CfxClientProvider) *CfxChain {
+	return &CfxChain{provider: provider}
+}
+
+func (c *CfxChain) Transaction(txHash string) (*TxMeta, error) {
+	cfx, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := cfx.GetTransactionByHash(types.Hash(txHash))
+	if err != nil || tx == nil {
+		return nil, err
+	}
+
+	return &TxMeta{Sender: tx.From.String(), Nonce: tx.Nonce.ToInt().Uint64()}, nil
+}
+
+func (c *CfxChain) NextNonce(sender string) (uint64, error) {
+	cfx, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return 0, err
+	}
+
+	addr, err := cfxaddress.NewFromBase32(sender)
+	if err != nil {
+		return 0, err
+	}
+
+	nonce, err := cfx.GetNextNonce(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	return nonce.ToInt().Uint64(), nil
+}
+
+func (c *CfxChain) SendRawTransaction(signedTx hexutil.Bytes) error {
+	cfx, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = cfx.SendRawTransaction(signedTx)
+	return err
+}
+
+// EthChain queries transaction status from evm space fullnodes.
+type EthChain struct {
+	provider *node.EthClientProvider
+}
+
+func NewEthChain(provider *node.EthClientProvider) *EthChain {
+	return &EthChain{provider: provider}
+}
+
+func (c *EthChain) Transaction(txHash string) (*TxMeta, error) {
+	w3c, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := w3c.Eth.TransactionByHash(common.HexToHash(txHash))
+	if err != nil || tx == nil {
+		return nil, err
+	}
+
+	return &TxMeta{Sender: tx.From.Hex(), Nonce: tx.Nonce}, nil
+}
+
+func (c *EthChain) NextNonce(sender string) (uint64, error) {
+	w3c, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return 0, err
+	}
+
+	latest := web3Types.BlockNumberOrHashWithNumber(web3Types.LatestBlockNumber)
+
+	nonce, err := w3c.Eth.TransactionCount(common.HexToAddress(sender), &latest)
+	if err != nil {
+		return 0, err
+	}
+
+	return nonce.Uint64(), nil
+}
+
+func (c *EthChain) SendRawTransaction(signedTx hexutil.Bytes) error {
+	w3c, err := c.provider.GetClient(trackerRouteKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = w3c.Eth.SendRawTransaction(signedTx)
+	return err
+}
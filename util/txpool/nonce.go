@@ -0,0 +1,100 @@
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// pending nonce tracker configurations
+type NonceTrackerConfig struct {
+	// whether to enable pending nonce tracker
+	Enabled bool
+	// duration to keep the relayed nonce since submitted, which should be long enough for
+	// the lagging fullnodes to catch up
+	Expiry time.Duration `default:"1m"`
+	// max number of tracked senders
+	MaxSenders int `default:"100000"`
+}
+
+type relayedNonce struct {
+	nonce     uint64
+	relayedAt time.Time
+}
+
+// NonceTracker tracks the max nonce of transactions relayed through gateway per sender, so that
+// rapid submitters won't get stale pending nonce from lagging fullnodes.
+type NonceTracker struct {
+	mu sync.Mutex
+
+	conf    NonceTrackerConfig
+	senders *simplelru.LRU // sender => *relayedNonce
+}
+
+// MustNewNonceTrackerFromViper creates pending nonce tracker from viper settings of the specified
+// key, or returns nil if not enabled.
+func MustNewNonceTrackerFromViper(key string) *NonceTracker {
+	var conf NonceTrackerConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewNonceTracker(conf)
+}
+
+func NewNonceTracker(conf NonceTrackerConfig) *NonceTracker {
+	senders, _ := simplelru.NewLRU(conf.MaxSenders, nil)
+	return &NonceTracker{conf: conf, senders: senders}
+}
+
+// Relay records the nonce of transaction successfully relayed for the sender.
+//
+// Note, tracker is nil-safe, in which case nothing tracked.
+func (t *NonceTracker) Relay(sender string, nonce uint64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if val, ok := t.senders.Get(sender); ok {
+		if last := val.(*relayedNonce); last.nonce > nonce && time.Since(last.relayedAt) <= t.conf.Expiry {
+			return
+		}
+	}
+
+	t.senders.Add(sender, &relayedNonce{nonce: nonce, relayedAt: time.Now()})
+}
+
+// PendingNonce combines the pending nonce from fullnode with the max nonce relayed for the sender,
+// and returns the greater one.
+//
+// Note, tracker is nil-safe, in which case pending nonce from fullnode returned.
+func (t *NonceTracker) PendingNonce(sender string, fullnodeNonce uint64) uint64 {
+	if t == nil {
+		return fullnodeNonce
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	val, ok := t.senders.Peek(sender)
+	if !ok {
+		return fullnodeNonce
+	}
+
+	relayed := val.(*relayedNonce)
+
+	// fullnode already caught up or relayed nonce expired
+	if fullnodeNonce > relayed.nonce || time.Since(relayed.relayedAt) > t.conf.Expiry {
+		t.senders.Remove(sender)
+		return fullnodeNonce
+	}
+
+	return relayed.nonce + 1
+}
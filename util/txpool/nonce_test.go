@@ -0,0 +1,40 @@
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceTrackerPendingNonce(t *testing.T) {
+	tracker := NewNonceTracker(NonceTrackerConfig{Expiry: time.Minute, MaxSenders: 10})
+
+	// not tracked
+	assert.Equal(t, uint64(5), tracker.PendingNonce("alice", 5))
+
+	tracker.Relay("alice", 5)
+	tracker.Relay("alice", 6)
+	tracker.Relay("alice", 4) // lower nonce ignored
+
+	// fullnode lags behind
+	assert.Equal(t, uint64(7), tracker.PendingNonce("alice", 5))
+
+	// fullnode caught up
+	assert.Equal(t, uint64(8), tracker.PendingNonce("alice", 8))
+	assert.Equal(t, uint64(5), tracker.PendingNonce("alice", 5))
+
+	// nil tracker
+	var nilTracker *NonceTracker
+	nilTracker.Relay("alice", 10)
+	assert.Equal(t, uint64(5), nilTracker.PendingNonce("alice", 5))
+}
+
+func TestNonceTrackerExpired(t *testing.T) {
+	tracker := NewNonceTracker(NonceTrackerConfig{Expiry: time.Millisecond, MaxSenders: 10})
+
+	tracker.Relay("alice", 10)
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, uint64(5), tracker.PendingNonce("alice", 5))
+}
@@ -0,0 +1,95 @@
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// relay cache configurations
+type RelayCacheConfig struct {
+	// whether to enable read-your-writes for relayed transactions
+	Enabled bool
+	// duration to keep the relayed transaction since submitted, which should be long enough for
+	// the lagging fullnodes to catch up
+	Expiry time.Duration `default:"1m"`
+	// max number of cached transactions
+	MaxTxs int `default:"100000"`
+}
+
+// RelayedTx transaction relayed through gateway.
+type RelayedTx struct {
+	Node      string // URL of the fullnode which accepted the transaction
+	SignedTx  hexutil.Bytes
+	RelayedAt time.Time
+}
+
+// RelayCache caches the transactions relayed through gateway along with the fullnode which accepted
+// them, so that the immediate queries of the relayed transactions could be routed to the accepting
+// fullnode (or answered from cache) rather than returning null from lagging fullnodes.
+type RelayCache struct {
+	mu sync.Mutex
+
+	conf RelayCacheConfig
+	txs  *simplelru.LRU // tx hash => *RelayedTx
+}
+
+// MustNewRelayCacheFromViper creates relay cache from viper settings of the specified key, or
+// returns nil if not enabled.
+func MustNewRelayCacheFromViper(key string) *RelayCache {
+	var conf RelayCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewRelayCache(conf)
+}
+
+func NewRelayCache(conf RelayCacheConfig) *RelayCache {
+	txs, _ := simplelru.NewLRU(conf.MaxTxs, nil)
+	return &RelayCache{conf: conf, txs: txs}
+}
+
+// Add records the transaction successfully relayed to the specified fullnode.
+//
+// Note, cache is nil-safe, in which case nothing cached.
+func (c *RelayCache) Add(txHash, node string, signedTx hexutil.Bytes) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.txs.Add(txHash, &RelayedTx{Node: node, SignedTx: signedTx, RelayedAt: time.Now()})
+}
+
+// Get returns the relayed transaction if not expired.
+//
+// Note, cache is nil-safe, in which case nothing returned.
+func (c *RelayCache) Get(txHash string) (*RelayedTx, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, ok := c.txs.Peek(txHash)
+	if !ok {
+		return nil, false
+	}
+
+	relayed := val.(*RelayedTx)
+	if time.Since(relayed.RelayedAt) > c.conf.Expiry {
+		c.txs.Remove(txHash)
+		return nil, false
+	}
+
+	return relayed, true
+}
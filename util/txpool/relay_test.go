@@ -0,0 +1,34 @@
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayCache(t *testing.T) {
+	cache := NewRelayCache(RelayCacheConfig{Expiry: 5 * time.Millisecond, MaxTxs: 10})
+
+	_, ok := cache.Get("0x1")
+	assert.False(t, ok)
+
+	cache.Add("0x1", "http://node1", []byte{0x1})
+
+	relayed, ok := cache.Get("0x1")
+	assert.True(t, ok)
+	assert.Equal(t, "http://node1", relayed.Node)
+
+	// expired
+	time.Sleep(10 * time.Millisecond)
+
+	_, ok = cache.Get("0x1")
+	assert.False(t, ok)
+
+	// nil cache
+	var nilCache *RelayCache
+	nilCache.Add("0x1", "http://node1", []byte{0x1})
+
+	_, ok = nilCache.Get("0x1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,379 @@
+package txpool
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// transaction waiting to be executed
+	TxStatusPending = "pending"
+	// transaction not executed for a long time even though no nonce gap ahead
+	TxStatusStuck = "stuck"
+	// transaction blocked by nonce gap ahead
+	TxStatusNonceGapped = "nonceGapped"
+)
+
+var (
+	errTxNotTracked = errors.New("transaction not tracked or already executed")
+)
+
+// transaction tracker configurations
+type TrackerConfig struct {
+	// whether to enable transaction tracker
+	Enabled bool
+	// interval to poll the status of tracked transactions
+	Interval time.Duration `default:"5s"`
+	// duration of transaction not executed since submitted to be regarded as stuck
+	StuckTimeout time.Duration `default:"1m"`
+	// duration to keep tracking transaction since submitted
+	Expiry time.Duration `default:"1h"`
+	// max number of tracked pending transactions per sender
+	MaxTxsPerSender int `default:"64"`
+	// whether to resubmit the stuck transactions automatically
+	AutoResubmit bool
+}
+
+// TxMeta transaction meta info from fullnode.
+type TxMeta struct {
+	Sender string
+	Nonce  uint64
+}
+
+// Chain is implemented by both core space and evm space to query transaction status from fullnode.
+type Chain interface {
+	// Transaction returns the meta info of the transaction, or nil if not found.
+	Transaction(txHash string) (*TxMeta, error)
+	// NextNonce returns the next nonce of the sender to be executed.
+	NextNonce(sender string) (uint64, error)
+	// SendRawTransaction submits the raw transaction to fullnode.
+	SendRawTransaction(signedTx hexutil.Bytes) error
+}
+
+// TxStatus status of tracked pending transaction.
+type TxStatus struct {
+	Hash        string         `json:"hash"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Status      string         `json:"status"`
+	SubmittedAt time.Time      `json:"submittedAt"`
+	Resubmits   int            `json:"resubmits"`
+
+	signedTx        hexutil.Bytes
+	lastSubmittedAt time.Time
+}
+
+// SenderStatus status of tracked pending transactions for some sender.
+type SenderStatus struct {
+	Sender        string           `json:"sender"`
+	NextNonce     hexutil.Uint64   `json:"nextNonce"`     // next nonce to be executed
+	MissingNonces []hexutil.Uint64 `json:"missingNonces"` // nonces missing ahead of the pending transactions
+	Pending       []*TxStatus      `json:"pending"`       // tracked pending transactions sorted by nonce
+}
+
+type senderTxs struct {
+	nextNonce uint64
+	txs       map[uint64]*TxStatus // nonce => tx
+}
+
+// Tracker tracks the pending transactions submitted through gateway per sender, so as to detect
+// the stuck or nonce gapped transactions, which could be reported or resubmitted.
+type Tracker struct {
+	mu sync.Mutex
+
+	space      string
+	conf       TrackerConfig
+	chain      Chain
+	unresolved []*TxStatus           // submitted transactions of which sender not resolved yet
+	senders    map[string]*senderTxs // sender => pending transactions
+	hash2Txs   map[string]*TxStatus  // tx hash => tracked transaction
+}
+
+// MustNewTrackerFromViper creates transaction tracker from viper settings of the specified key,
+// or returns nil if not enabled.
+func MustNewTrackerFromViper(space, key string, chain Chain) *Tracker {
+	var conf TrackerConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewTracker(space, conf, chain)
+}
+
+func NewTracker(space string, conf TrackerConfig, chain Chain) *Tracker {
+	return &Tracker{
+		space:    space,
+		conf:     conf,
+		chain:    chain,
+		senders:  make(map[string]*senderTxs),
+		hash2Txs: make(map[string]*TxStatus),
+	}
+}
+
+// Track tracks the raw transaction successfully submitted, of which sender and nonce will be
+// resolved from fullnode asynchronously.
+//
+// Note, tracker is nil-safe, in which case nothing tracked.
+func (t *Tracker) Track(txHash string, signedTx hexutil.Bytes) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.hash2Txs[txHash]; ok {
+		return
+	}
+
+	now := time.Now()
+	tx := &TxStatus{
+		Hash:            txHash,
+		Status:          TxStatusPending,
+		SubmittedAt:     now,
+		signedTx:        signedTx,
+		lastSubmittedAt: now,
+	}
+
+	t.unresolved = append(t.unresolved, tx)
+	t.hash2Txs[txHash] = tx
+}
+
+// Status returns the status of tracked pending transactions for the specified sender.
+func (t *Tracker) Status(sender string) *SenderStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := &SenderStatus{
+		Sender:        sender,
+		MissingNonces: []hexutil.Uint64{},
+		Pending:       []*TxStatus{},
+	}
+
+	stxs, ok := t.senders[sender]
+	if !ok {
+		return status
+	}
+
+	status.NextNonce = hexutil.Uint64(stxs.nextNonce)
+
+	expected := stxs.nextNonce
+	for _, nonce := range stxs.sortedNonces() {
+		for ; expected < nonce; expected++ {
+			status.MissingNonces = append(status.MissingNonces, hexutil.Uint64(expected))
+		}
+
+		txCopy := *stxs.txs[nonce]
+		status.Pending = append(status.Pending, &txCopy)
+		expected = nonce + 1
+	}
+
+	return status
+}
+
+// Resubmit resubmits the tracked pending transaction to fullnode.
+func (t *Tracker) Resubmit(txHash string) error {
+	t.mu.Lock()
+	tx, ok := t.hash2Txs[txHash]
+	t.mu.Unlock()
+
+	if !ok {
+		return errTxNotTracked
+	}
+
+	return t.resubmit(tx)
+}
+
+func (t *Tracker) resubmit(tx *TxStatus) error {
+	if err := t.chain.SendRawTransaction(tx.signedTx); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tx.Resubmits++
+	tx.lastSubmittedAt = time.Now()
+
+	return nil
+}
+
+// Run polls the status of tracked transactions periodically until context done.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.poll()
+		}
+	}
+}
+
+func (t *Tracker) poll() {
+	t.resolve()
+
+	t.mu.Lock()
+	senders := make([]string, 0, len(t.senders))
+	for sender := range t.senders {
+		senders = append(senders, sender)
+	}
+	t.mu.Unlock()
+
+	var stuckTxs []*TxStatus
+	for _, sender := range senders {
+		nextNonce, err := t.chain.NextNonce(sender)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"space": t.space, "sender": sender,
+			}).WithError(err).Debug("Txn tracker failed to get next nonce")
+			continue
+		}
+
+		stuckTxs = append(stuckTxs, t.update(sender, nextNonce)...)
+	}
+
+	if !t.conf.AutoResubmit {
+		return
+	}
+
+	for _, tx := range stuckTxs {
+		if err := t.resubmit(tx); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"space": t.space, "txHash": tx.Hash,
+			}).WithError(err).Debug("Txn tracker failed to resubmit stuck transaction")
+		}
+	}
+}
+
+// resolve resolves the sender and nonce of the submitted transactions from fullnode.
+func (t *Tracker) resolve() {
+	t.mu.Lock()
+	unresolved := t.unresolved
+	t.unresolved = nil
+	t.mu.Unlock()
+
+	var remained []*TxStatus
+	for _, tx := range unresolved {
+		meta, err := t.chain.Transaction(tx.Hash)
+		if err == nil && meta != nil {
+			t.add(tx, meta)
+			continue
+		}
+
+		// untrack if not resolved for a long time
+		if time.Since(tx.SubmittedAt) > t.conf.Expiry {
+			t.untrack(tx.Hash)
+			continue
+		}
+
+		remained = append(remained, tx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.unresolved = append(t.unresolved, remained...)
+}
+
+func (t *Tracker) add(tx *TxStatus, meta *TxMeta) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stxs, ok := t.senders[meta.Sender]
+	if !ok {
+		stxs = &senderTxs{nextNonce: meta.Nonce, txs: make(map[uint64]*TxStatus)}
+		t.senders[meta.Sender] = stxs
+	}
+
+	if len(stxs.txs) >= t.conf.MaxTxsPerSender {
+		delete(t.hash2Txs, tx.Hash)
+		return
+	}
+
+	// replaced by the new transaction with the same nonce
+	if old, ok := stxs.txs[meta.Nonce]; ok {
+		delete(t.hash2Txs, old.Hash)
+	}
+
+	tx.Nonce = hexutil.Uint64(meta.Nonce)
+	stxs.txs[meta.Nonce] = tx
+}
+
+func (t *Tracker) untrack(txHash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.hash2Txs, txHash)
+}
+
+// update updates the status of pending transactions for the sender with the latest next nonce,
+// and returns the stuck transactions to resubmit if any.
+func (t *Tracker) update(sender string, nextNonce uint64) (stuckTxs []*TxStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stxs, ok := t.senders[sender]
+	if !ok {
+		return nil
+	}
+
+	stxs.nextNonce = nextNonce
+
+	expected := nextNonce
+	for _, nonce := range stxs.sortedNonces() {
+		tx := stxs.txs[nonce]
+
+		// untrack the executed or expired transactions
+		if nonce < nextNonce || time.Since(tx.SubmittedAt) > t.conf.Expiry {
+			delete(stxs.txs, nonce)
+			delete(t.hash2Txs, tx.Hash)
+			continue
+		}
+
+		switch {
+		case nonce > expected:
+			tx.Status = TxStatusNonceGapped
+		case time.Since(tx.SubmittedAt) > t.conf.StuckTimeout:
+			tx.Status = TxStatusStuck
+
+			if time.Since(tx.lastSubmittedAt) > t.conf.StuckTimeout {
+				stuckTxs = append(stuckTxs, tx)
+			}
+		default:
+			tx.Status = TxStatusPending
+		}
+
+		expected = nonce + 1
+	}
+
+	if len(stxs.txs) == 0 {
+		delete(t.senders, sender)
+	}
+
+	return stuckTxs
+}
+
+func (stxs *senderTxs) sortedNonces() []uint64 {
+	nonces := make([]uint64, 0, len(stxs.txs))
+	for nonce := range stxs.txs {
+		nonces = append(nonces, nonce)
+	}
+
+	sort.Slice(nonces, func(i, j int) bool {
+		return nonces[i] < nonces[j]
+	})
+
+	return nonces
+}
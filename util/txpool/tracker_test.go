@@ -0,0 +1,85 @@
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChain struct {
+	txs       map[string]*TxMeta
+	nextNonce uint64
+	resubmits int
+}
+
+func (c *mockChain) Transaction(txHash string) (*TxMeta, error) {
+	return c.txs[txHash], nil
+}
+
+func (c *mockChain) NextNonce(sender string) (uint64, error) {
+	return c.nextNonce, nil
+}
+
+func (c *mockChain) SendRawTransaction(signedTx hexutil.Bytes) error {
+	c.resubmits++
+	return nil
+}
+
+func TestTrackerNonceGap(t *testing.T) {
+	chain := &mockChain{
+		txs: map[string]*TxMeta{
+			"0x1": {Sender: "alice", Nonce: 1},
+			"0x3": {Sender: "alice", Nonce: 3},
+		},
+		nextNonce: 1,
+	}
+
+	tracker := NewTracker("eth", TrackerConfig{
+		StuckTimeout: time.Hour, Expiry: time.Hour, MaxTxsPerSender: 64,
+	}, chain)
+
+	tracker.Track("0x1", hexutil.Bytes{0x1})
+	tracker.Track("0x3", hexutil.Bytes{0x3})
+	tracker.poll()
+
+	status := tracker.Status("alice")
+	assert.Equal(t, hexutil.Uint64(1), status.NextNonce)
+	assert.Equal(t, []hexutil.Uint64{2}, status.MissingNonces)
+	assert.Len(t, status.Pending, 2)
+	assert.Equal(t, TxStatusPending, status.Pending[0].Status)
+	assert.Equal(t, TxStatusNonceGapped, status.Pending[1].Status)
+
+	// the first transaction executed
+	chain.nextNonce = 2
+	tracker.poll()
+
+	status = tracker.Status("alice")
+	assert.Len(t, status.Pending, 1)
+	assert.Equal(t, "0x3", status.Pending[0].Hash)
+	assert.Error(t, tracker.Resubmit("0x1"))
+
+	assert.NoError(t, tracker.Resubmit("0x3"))
+	assert.Equal(t, 1, chain.resubmits)
+}
+
+func TestTrackerAutoResubmit(t *testing.T) {
+	chain := &mockChain{
+		txs:       map[string]*TxMeta{"0x1": {Sender: "bob", Nonce: 5}},
+		nextNonce: 5,
+	}
+
+	tracker := NewTracker("cfx", TrackerConfig{
+		Expiry: time.Hour, MaxTxsPerSender: 64, AutoResubmit: true,
+	}, chain)
+
+	tracker.Track("0x1", hexutil.Bytes{0x1})
+	tracker.poll()
+
+	status := tracker.Status("bob")
+	assert.Len(t, status.Pending, 1)
+	assert.Equal(t, TxStatusStuck, status.Pending[0].Status)
+	assert.Equal(t, 1, status.Pending[0].Resubmits)
+	assert.Equal(t, 1, chain.resubmits)
+}
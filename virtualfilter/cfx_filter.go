@@ -131,7 +131,7 @@ func (f *cfxLogFilter) fetch() (filterChanges, error) {
 
 	blockLogs := make(map[string][]types.Log, len(missingBlockhashes))
 	if len(missingBlockhashes) > 0 { // load missing event logs from db store
-		timeoutCtx, cancel := context.WithTimeout(context.Background(), store.TimeoutGetLogs)
+		timeoutCtx, cancel := rpcutil.WithStoreTimeout(context.Background(), "cfx_getLogs")
 		defer cancel()
 
 		metricTimer := metrics.Registry.VirtualFilter.QueryFilterChanges("cfx", f.nodeName(), "mysql")
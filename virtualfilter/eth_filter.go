@@ -11,6 +11,7 @@ import (
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
@@ -130,7 +131,7 @@ func (f *ethLogFilter) fetch() (filterChanges, error) {
 
 	blockLogs := make(map[string][]types.Log, len(missingBlockhashes))
 	if len(missingBlockhashes) > 0 { // load missing event logs from db store
-		timeoutCtx, cancel := context.WithTimeout(context.Background(), store.TimeoutGetLogs)
+		timeoutCtx, cancel := rpcutil.WithStoreTimeout(context.Background(), "eth_getLogs")
 		defer cancel()
 
 		metricTimer := metrics.Registry.VirtualFilter.QueryFilterChanges("eth", f.nodeName(), "mysql")